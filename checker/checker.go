@@ -1,7 +1,34 @@
 package checker
 
-import "github.com/lamoda/gonkey/models"
+import (
+	"reflect"
+
+	"github.com/lamoda/gonkey/models"
+)
 
 type CheckerInterface interface {
 	Check(models.TestInterface, *models.Result) ([]error, error)
 }
+
+// NamedChecker is implemented by the checkers which have a name for the reports
+type NamedChecker interface {
+	Name() string
+}
+
+// PreparingChecker is implemented by the checkers which need the state of the service before the request,
+// Prepare is called right before each attempt to send the request of the test
+type PreparingChecker interface {
+	Prepare(models.TestInterface) error
+}
+
+// Name returns the name of the checker, the type name is used for the checkers without Name method
+func Name(c CheckerInterface) string {
+	if named, ok := c.(NamedChecker); ok {
+		return named.Name()
+	}
+	t := reflect.TypeOf(c)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.String()
+}
@@ -0,0 +1,172 @@
+package response_body
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/lamoda/gonkey/files"
+	"github.com/lamoda/gonkey/models"
+)
+
+// CaptureResponsesEnv is the environment variable enabling the capture of the responses into the test files
+const CaptureResponsesEnv = "GONKEY_CAPTURE_RESPONSES"
+
+// captureMu serializes the rewrites of the test files, the tests of the same file may run in parallel
+var captureMu sync.Mutex
+
+// captureResponse writes the actual response into the empty response placeholder (response: with no value
+// or {}) of the test in its file, false is returned if the test has no placeholder
+func (c *ResponseBodyChecker) captureResponse(t models.TestInterface, result *models.Result) (bool, error) {
+	if _, ok := t.GetResponse(result.ResponseStatusCode); ok || t.GetFileName() == "" {
+		return false, nil
+	}
+
+	captureMu.Lock()
+	defer captureMu.Unlock()
+
+	fileName := t.GetFileName()
+	data, err := files.ReadFile(fileName)
+	if err != nil {
+		return false, fmt.Errorf("unable to capture response of test %s: %s", t.GetName(), err)
+	}
+	line, column, err := findResponsePlaceholder(data, t.GetDefinitionName())
+	if err != nil {
+		return false, fmt.Errorf("unable to capture response of test %s: %s", t.GetName(), err)
+	}
+	if line == 0 {
+		return false, nil
+	}
+
+	body, err := capturedBody(t, result)
+	if err != nil {
+		return false, err
+	}
+	data, err = fillResponsePlaceholder(data, line, column, result.ResponseStatusCode, body)
+	if err != nil {
+		return false, fmt.Errorf("unable to capture response of test %s: %s", t.GetName(), err)
+	}
+	if err := files.WriteFile(fileName, data, 0644); err != nil {
+		return false, fmt.Errorf("unable to capture response of test %s: %s", t.GetName(), err)
+	}
+
+	fmt.Printf("Response %d of test %s captured into %s\n", result.ResponseStatusCode, t.GetName(), fileName)
+	return true, nil
+}
+
+// capturedBody returns the body written into the test file, the JSON bodies are formatted
+// and only their part under responseRoot is written
+func capturedBody(t models.TestInterface, result *models.Result) (string, error) {
+	if !strings.Contains(result.ResponseContentType, "json") {
+		return result.ResponseBody, nil
+	}
+	body, err := responseRoot(t.GetResponseRoot(), result.ResponseBody)
+	if err != nil {
+		return "", fmt.Errorf("unable to capture response of test %s: %s", t.GetName(), err)
+	}
+	return string(normalizeJson([]byte(body))), nil
+}
+
+// findResponsePlaceholder returns the line and the column of the response key of the test named so in the file
+// if its value is empty, zero line is returned if the test has no placeholder. The placeholder of a test with
+// several cases is shared by them, so it's not filled with the response of one of them.
+func findResponsePlaceholder(data []byte, name string) (int, int, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return 0, 0, err
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.SequenceNode {
+		return 0, 0, nil
+	}
+
+	for _, item := range doc.Content[0].Content {
+		if item.Kind != yaml.MappingNode {
+			continue
+		}
+		nameValue := mappingValue(item, "name")
+		if nameValue == nil || nameValue.Value != name {
+			continue
+		}
+		for i := 0; i+1 < len(item.Content); i += 2 {
+			key, value := item.Content[i], item.Content[i+1]
+			if key.Value != "response" {
+				continue
+			}
+			if value.Tag != "!!null" && (value.Kind != yaml.MappingNode || len(value.Content) != 0) {
+				continue
+			}
+			if cases := mappingValue(item, "cases"); mappingValue(item, "casesFile") != nil ||
+				(cases != nil && cases.Kind == yaml.SequenceNode && len(cases.Content) > 1) {
+				return 0, 0, errors.New("the response placeholder is shared by several cases, " +
+					"capture the response with one case and then add the others")
+			}
+			return key.Line, key.Column, nil
+		}
+	}
+	return 0, 0, nil
+}
+
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// fillResponsePlaceholder replaces the empty value of the response key at the line and the column
+// with the body of the status, the rest of the file is kept as it is
+func fillResponsePlaceholder(data []byte, line, column, status int, body string) ([]byte, error) {
+	lines := strings.SplitAfter(string(data), "\n")
+	if line > len(lines) {
+		return nil, errors.New("response placeholder is out of the file")
+	}
+	keyLine := lines[line-1]
+	colon := strings.Index(keyLine[column-1:], ":")
+	if colon < 0 {
+		return nil, errors.New("response placeholder is not a block mapping key")
+	}
+	colon += column - 1
+
+	// the comment after the placeholder is kept, its empty value (~, null or {}) is dropped
+	filled := keyLine[:colon+1]
+	if i := strings.Index(keyLine[colon+1:], "#"); i >= 0 {
+		filled += " " + strings.TrimRight(keyLine[colon+1+i:], "\r\n")
+	}
+	filled += "\n"
+
+	value := &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{
+		{Kind: yaml.ScalarNode, Value: strconv.Itoa(status)},
+		{Kind: yaml.ScalarNode, Tag: "!!str", Value: body, Style: yaml.LiteralStyle},
+	}}
+	buf := &bytes.Buffer{}
+	encoder := yaml.NewEncoder(buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(value); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+
+	indent := strings.Repeat(" ", column+1)
+	for _, valueLine := range strings.SplitAfter(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+		if valueLine = strings.TrimSuffix(valueLine, "\n"); valueLine != "" {
+			filled += indent + valueLine
+		}
+		filled += "\n"
+	}
+	// the last line of the file may have no line break
+	if line == len(lines) && !strings.HasSuffix(keyLine, "\n") {
+		filled = strings.TrimSuffix(filled, "\n")
+	}
+
+	lines[line-1] = filled
+	return []byte(strings.Join(lines, "")), nil
+}
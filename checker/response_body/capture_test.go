@@ -0,0 +1,172 @@
+package response_body
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lamoda/gonkey/testloader/yaml_file"
+)
+
+const captureFile = `# orders
+- name: get order
+  method: GET
+  path: /orders/1
+  response: # captured from staging
+  responseHeaders:
+    200:
+      Content-Type: application/json
+
+- name: list orders
+  method: GET
+  path: /orders
+  response: {}
+`
+
+func captureTest(fileName, name string, responses map[int]string) *yaml_file.Test {
+	return &yaml_file.Test{
+		TestDefinition: yaml_file.TestDefinition{Name: name},
+		Filename:       fileName,
+		Responses:      responses,
+	}
+}
+
+func TestCaptureResponses(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	fileName := filepath.Join(dir, "orders.yaml")
+	require.NoError(t, ioutil.WriteFile(fileName, []byte(captureFile), 0644))
+	checker := NewCheckerWithOptions(Options{CaptureResponses: true})
+
+	errs, err := checker.Check(captureTest(fileName, "get order", nil), jsonResult(`{"name":"book","id":1}`))
+	require.NoError(t, err)
+	assert.Empty(t, errs)
+
+	result := jsonResult("")
+	result.ResponseStatusCode = 404
+	result.ResponseContentType = "text/plain"
+	result.ResponseBody = "not found\n"
+	errs, err = checker.Check(captureTest(fileName, "list orders", map[int]string{}), result)
+	require.NoError(t, err)
+	assert.Empty(t, errs)
+
+	content, err := ioutil.ReadFile(fileName)
+	require.NoError(t, err)
+	assert.Equal(t, `# orders
+- name: get order
+  method: GET
+  path: /orders/1
+  response: # captured from staging
+    200: |
+      {
+        "id": 1,
+        "name": "book"
+      }
+  responseHeaders:
+    200:
+      Content-Type: application/json
+
+- name: list orders
+  method: GET
+  path: /orders
+  response:
+    404: |
+      not found
+`, string(content))
+
+	// the filled responses are compared as usual
+	errs, err = checker.Check(captureTest(fileName, "get order", map[int]string{200: `{"id": 1}`}), jsonResult(`{"id":2}`))
+	require.NoError(t, err)
+	assert.Len(t, errs, 1)
+}
+
+func TestCaptureResponseOfFirstKey(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	fileName := filepath.Join(dir, "orders.yaml")
+	require.NoError(t, ioutil.WriteFile(fileName, []byte("- response: ~\n  name: get order"), 0644))
+
+	errs, err := NewCheckerWithOptions(Options{CaptureResponses: true}).Check(
+		captureTest(fileName, "get order", nil), jsonResult(`{"data": {"id": 1}, "meta": {}}`),
+	)
+	require.NoError(t, err)
+	assert.Empty(t, errs)
+
+	content, err := ioutil.ReadFile(fileName)
+	require.NoError(t, err)
+	assert.Equal(t, "- response:\n    200: |\n      {\n        \"data\": {\n          \"id\": 1\n        },\n        \"meta\": {}\n      }\n  name: get order", string(content))
+}
+
+func TestCaptureResponsesDisabled(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	fileName := filepath.Join(dir, "orders.yaml")
+	require.NoError(t, ioutil.WriteFile(fileName, []byte(captureFile), 0644))
+
+	errs, err := NewChecker().Check(captureTest(fileName, "get order", nil), jsonResult(`{"id":1}`))
+	require.NoError(t, err)
+	assert.EqualError(t, errs[0], "server responded with status 200")
+
+	content, err := ioutil.ReadFile(fileName)
+	require.NoError(t, err)
+	assert.Equal(t, captureFile, string(content))
+}
+
+func TestCaptureResponsesOfCases(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	fileName := filepath.Join(dir, "orders.yaml")
+	require.NoError(t, ioutil.WriteFile(fileName, []byte(`- name: get order
+  method: GET
+  path: /orders/{{ .id }}
+  response:
+  cases:
+    - name: book
+      requestArgs:
+        id: 1
+
+- name: list orders
+  method: GET
+  path: /orders
+  response: {}
+  cases:
+    - requestArgs:
+        page: 1
+    - requestArgs:
+        page: 2
+`), 0644))
+	tests, err := yaml_file.NewLoader(fileName).Load()
+	require.NoError(t, err)
+	require.Len(t, tests, 3)
+	require.Equal(t, "get order #book", tests[0].GetName())
+	checker := NewCheckerWithOptions(Options{CaptureResponses: true})
+
+	// the test of the only case is found by the name in the file
+	errs, err := checker.Check(tests[0], jsonResult(`{"id":1}`))
+	require.NoError(t, err)
+	assert.Empty(t, errs)
+
+	// the placeholder shared by the cases is not filled with the response of one of them
+	_, err = checker.Check(tests[1], jsonResult(`{"page":1}`))
+	assert.EqualError(t, err, "unable to capture response of test list orders #1: "+
+		"the response placeholder is shared by several cases, capture the response with one case and then add the others")
+
+	content, err := ioutil.ReadFile(fileName)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), `  path: /orders/{{ .id }}
+  response:
+    200: |
+      {
+        "id": 1
+      }
+  cases:`)
+	assert.Contains(t, string(content), "  response: {}\n")
+}
@@ -0,0 +1,157 @@
+package response_body
+
+import (
+	"fmt"
+	"mime"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/lamoda/gonkey/compare"
+	"github.com/lamoda/gonkey/models"
+	"github.com/lamoda/gonkey/output"
+)
+
+// encodingTextSize is how much of the decoded body is shown when it doesn't match the expected text
+const encodingTextSize = 1024
+
+// decoders decode the body in the charset, the offset of the first invalid byte sequence is returned
+// if the body can't be decoded
+var decoders = map[string]func(body string) (string, int){
+	"utf-8":      decodeUTF8,
+	"us-ascii":   decodeASCII,
+	"iso-8859-1": decodeLatin1,
+	"utf-16":     decodeUTF16,
+	"utf-16be":   func(body string) (string, int) { return decodeUTF16Order(body, true) },
+	"utf-16le":   func(body string) (string, int) { return decodeUTF16Order(body, false) },
+}
+
+var charsetAliases = map[string]string{
+	"utf8":    "utf-8",
+	"ascii":   "us-ascii",
+	"latin1":  "iso-8859-1",
+	"latin-1": "iso-8859-1",
+}
+
+// checkEncoding decodes the body in the expected charset or in the charset of Content-Type
+// and compares the decoded body with the expected text
+func checkEncoding(t models.TestInterface, encoding *models.BodyEncoding, result *models.Result) ([]error, error) {
+	expected := normalizeCharset(encoding.Charset)
+	if _, ok := decoders[expected]; !ok && expected != "" {
+		return nil, fmt.Errorf("unsupported charset %s in responseBodyEncoding of test %s", encoding.Charset, t.GetName())
+	}
+
+	declared := ""
+	if _, params, err := mime.ParseMediaType(result.ResponseContentType); err == nil {
+		declared = params["charset"]
+	}
+	charset := normalizeCharset(declared)
+	switch {
+	case expected != "" && charset != "" && charset != expected:
+		return []error{fmt.Errorf("response charset %s does not match expected %s", declared, encoding.Charset)}, nil
+	case expected != "":
+		charset = expected
+	case charset == "":
+		charset = "utf-8"
+	}
+
+	decode, ok := decoders[charset]
+	if !ok {
+		return []error{fmt.Errorf("response charset %s is not supported", declared)}, nil
+	}
+	text, offset := decode(result.ResponseBody)
+	if offset >= 0 {
+		return []error{fmt.Errorf("response body is not valid %s: invalid byte sequence at offset %d", charset, offset)}, nil
+	}
+
+	if encoding.Text != "" && len(compare.Compare(encoding.Text, text, compare.CompareParams{})) != 0 {
+		return []error{fmt.Errorf(
+			"decoded response body does not match expected %q:\n%s", encoding.Text, output.TruncateBody(text, encodingTextSize),
+		)}, nil
+	}
+	return nil, nil
+}
+
+func normalizeCharset(charset string) string {
+	charset = strings.ToLower(strings.Trim(strings.TrimSpace(charset), `"`))
+	if alias, ok := charsetAliases[charset]; ok {
+		return alias
+	}
+	return charset
+}
+
+func decodeUTF8(body string) (string, int) {
+	for i := 0; i < len(body); {
+		r, size := utf8.DecodeRuneInString(body[i:])
+		if r == utf8.RuneError && size == 1 {
+			return "", i
+		}
+		i += size
+	}
+	return body, -1
+}
+
+func decodeASCII(body string) (string, int) {
+	for i := 0; i < len(body); i++ {
+		if body[i] >= utf8.RuneSelf {
+			return "", i
+		}
+	}
+	return body, -1
+}
+
+func decodeLatin1(body string) (string, int) {
+	runes := make([]rune, len(body))
+	for i := 0; i < len(body); i++ {
+		runes[i] = rune(body[i])
+	}
+	return string(runes), -1
+}
+
+// decodeUTF16 decodes UTF-16 with the byte order of its BOM, big endian without BOM
+func decodeUTF16(body string) (string, int) {
+	switch {
+	case strings.HasPrefix(body, "\xfe\xff"):
+		text, offset := decodeUTF16Order(body[2:], true)
+		return text, shiftOffset(offset, 2)
+	case strings.HasPrefix(body, "\xff\xfe"):
+		text, offset := decodeUTF16Order(body[2:], false)
+		return text, shiftOffset(offset, 2)
+	}
+	return decodeUTF16Order(body, true)
+}
+
+func decodeUTF16Order(body string, bigEndian bool) (string, int) {
+	units := make([]uint16, len(body)/2)
+	for i := range units {
+		if bigEndian {
+			units[i] = uint16(body[2*i])<<8 | uint16(body[2*i+1])
+		} else {
+			units[i] = uint16(body[2*i+1])<<8 | uint16(body[2*i])
+		}
+	}
+
+	for i := 0; i < len(units); i++ {
+		switch {
+		case utf16.IsSurrogate(rune(units[i])) && units[i] < 0xdc00:
+			// the high surrogate must be followed by the low one
+			if i+1 == len(units) || units[i+1] < 0xdc00 || units[i+1] > 0xdfff {
+				return "", 2 * i
+			}
+			i++
+		case utf16.IsSurrogate(rune(units[i])):
+			return "", 2 * i
+		}
+	}
+	if len(body)%2 != 0 {
+		return "", len(body) - 1
+	}
+	return string(utf16.Decode(units)), -1
+}
+
+func shiftOffset(offset, shift int) int {
+	if offset < 0 {
+		return offset
+	}
+	return offset + shift
+}
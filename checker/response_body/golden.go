@@ -0,0 +1,67 @@
+package response_body
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lamoda/gonkey/files"
+	"github.com/lamoda/gonkey/models"
+)
+
+// checkGolden compares the response with the content of the golden file,
+// in update mode the file is rewritten with the actual response when they differ
+func (c *ResponseBodyChecker) checkGolden(t models.TestInterface, goldenFile string, result *models.Result) ([]error, error) {
+	expectedBody, err := files.ReadFile(goldenFile)
+	if err != nil {
+		if os.IsNotExist(err) && c.opts.UpdateGolden {
+			return nil, writeGolden(goldenFile, result)
+		}
+		return nil, fmt.Errorf("unable to read golden file %s: %s", goldenFile, err)
+	}
+
+	errs, err := c.compareBody(t, string(expectedBody), result)
+	if err != nil || len(errs) == 0 || !c.opts.UpdateGolden {
+		return errs, err
+	}
+
+	return nil, writeGolden(goldenFile, result)
+}
+
+func writeGolden(goldenFile string, result *models.Result) error {
+	body := []byte(result.ResponseBody)
+	if strings.Contains(result.ResponseContentType, "json") {
+		body = normalizeJson(body)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(goldenFile), 0755); err != nil {
+		return fmt.Errorf("unable to update golden file %s: %s", goldenFile, err)
+	}
+	if err := files.WriteFile(goldenFile, body, 0644); err != nil {
+		return fmt.Errorf("unable to update golden file %s: %s", goldenFile, err)
+	}
+
+	fmt.Printf("Golden file %s updated\n", goldenFile)
+	return nil
+}
+
+// normalizeJson formats JSON with sorted keys and indentation to make diffs of golden files readable,
+// body is returned as is if it's not a valid JSON
+func normalizeJson(body []byte) []byte {
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return body
+	}
+
+	buf := &bytes.Buffer{}
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(value); err != nil {
+		return body
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,124 @@
+package response_body
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lamoda/gonkey/files"
+	"github.com/lamoda/gonkey/models"
+	"github.com/lamoda/gonkey/testloader/yaml_file"
+)
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "golden")
+	require.NoError(t, err)
+	return dir
+}
+
+func goldenTest(goldenFile string) *yaml_file.Test {
+	return &yaml_file.Test{
+		TestDefinition: yaml_file.TestDefinition{
+			Name:              "golden",
+			ResponseBodyFiles: map[int]string{200: goldenFile},
+		},
+	}
+}
+
+func jsonResult(body string) *models.Result {
+	return &models.Result{
+		ResponseStatusCode:  200,
+		ResponseContentType: "application/json",
+		ResponseBody:        body,
+	}
+}
+
+func TestGoldenMatches(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	goldenFile := filepath.Join(dir, "golden.json")
+	require.NoError(t, ioutil.WriteFile(goldenFile, []byte(`{"id": 1, "name": "$matchRegexp(^j)"}`), 0644))
+
+	errs, err := NewChecker().Check(goldenTest(goldenFile), jsonResult(`{"name":"john","id":1}`))
+	require.NoError(t, err)
+	assert.Empty(t, errs)
+}
+
+func TestGoldenMismatchFails(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	goldenFile := filepath.Join(dir, "golden.json")
+	require.NoError(t, ioutil.WriteFile(goldenFile, []byte(`{"id": 1}`), 0644))
+
+	errs, err := NewChecker().Check(goldenTest(goldenFile), jsonResult(`{"id":2}`))
+	require.NoError(t, err)
+	assert.Len(t, errs, 1)
+
+	content, err := ioutil.ReadFile(goldenFile)
+	require.NoError(t, err)
+	assert.Equal(t, `{"id": 1}`, string(content))
+}
+
+func TestGoldenMissingFails(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	goldenFile := filepath.Join(dir, "golden.json")
+
+	_, err := NewChecker().Check(goldenTest(goldenFile), jsonResult(`{"id":2}`))
+	assert.Error(t, err)
+}
+
+func TestGoldenUpdate(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	checker := NewCheckerWithOptions(Options{UpdateGolden: true})
+
+	cases := map[string]string{
+		"mismatch": `{"id": 1}`,
+		"missing":  "",
+	}
+	for name, initial := range cases {
+		goldenFile := filepath.Join(dir, name, "golden.json")
+		if initial != "" {
+			require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "golden.json"), []byte(initial), 0644))
+			goldenFile = filepath.Join(dir, "golden.json")
+		}
+
+		errs, err := checker.Check(goldenTest(goldenFile), jsonResult(`{"name":"<john>","id":2}`))
+		require.NoError(t, err, name)
+		assert.Empty(t, errs, name)
+
+		content, err := ioutil.ReadFile(goldenFile)
+		require.NoError(t, err, name)
+		assert.Equal(t, "{\n  \"id\": 2,\n  \"name\": \"<john>\"\n}\n", string(content), name)
+	}
+}
+
+func TestGoldenCompressed(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	goldenFile := filepath.Join(dir, "golden.json.gz")
+	require.NoError(t, files.WriteFile(goldenFile, []byte(`{"id": 1}`), 0644))
+
+	errs, err := NewChecker().Check(goldenTest(goldenFile), jsonResult(`{"id":1}`))
+	require.NoError(t, err)
+	assert.Empty(t, errs)
+
+	checker := NewCheckerWithOptions(Options{UpdateGolden: true})
+	errs, err = checker.Check(goldenTest(goldenFile), jsonResult(`{"id":2}`))
+	require.NoError(t, err)
+	assert.Empty(t, errs)
+
+	content, err := files.ReadFile(goldenFile)
+	require.NoError(t, err)
+	assert.Equal(t, "{\n  \"id\": 2\n}\n", string(content))
+}
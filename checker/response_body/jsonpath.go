@@ -0,0 +1,186 @@
+package response_body
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/tidwall/gjson"
+
+	"github.com/lamoda/gonkey/models"
+)
+
+var jsonPathIndexRx = regexp.MustCompile(`\[(\d+)\]`)
+
+// jsonPathOperators are checked in this order, so that >= is not taken for >
+var jsonPathOperators = []string{"==", "!=", ">=", "<=", ">", "<"}
+
+// jsonPathAssertion is a parsed assertion: the path must exist (op is empty), must not exist (absent)
+// or its value must satisfy the comparison with the expected value
+type jsonPathAssertion struct {
+	source   string
+	path     string
+	absent   bool
+	op       string
+	expected interface{}
+}
+
+// checkJSONPaths evaluates JSONPath assertions against JSON response, e.g. $.data.total == 5
+func (c *ResponseBodyChecker) checkJSONPaths(t models.TestInterface, sources []string, result *models.Result) ([]error, error) {
+	assertions := make([]jsonPathAssertion, len(sources))
+	for i, source := range sources {
+		assertion, err := parseJSONPathAssertion(source)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"invalid JSONPath assertion %s in responseJSONPath for test %s (status %d): %s",
+				source,
+				t.GetName(),
+				result.ResponseStatusCode,
+				err.Error(),
+			)
+		}
+		assertions[i] = assertion
+	}
+
+	if !gjson.Valid(result.ResponseBody) {
+		return []error{errors.New("could not parse response")}, nil
+	}
+
+	var errs []error
+	for _, assertion := range assertions {
+		if err := assertion.check(result.ResponseBody); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs, nil
+}
+
+func parseJSONPathAssertion(source string) (jsonPathAssertion, error) {
+	assertion := jsonPathAssertion{source: source}
+	expr := strings.TrimSpace(source)
+	if strings.HasPrefix(expr, "!") {
+		assertion.absent = true
+		assertion.path = toGJSONPath(strings.TrimSpace(expr[1:]))
+		return assertion, nil
+	}
+
+	path, op, value := splitJSONPathComparison(expr)
+	assertion.path = toGJSONPath(path)
+	if op == "" {
+		return assertion, nil
+	}
+	assertion.op = op
+
+	// single quoted strings are allowed as in the other JSONPath implementations
+	if strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'") && len(value) > 1 {
+		assertion.expected = value[1 : len(value)-1]
+	} else if err := json.Unmarshal([]byte(value), &assertion.expected); err != nil {
+		return assertion, fmt.Errorf("expected value %s is not a JSON value", value)
+	}
+
+	if op != "==" && op != "!=" {
+		if _, ok := assertion.expected.(float64); !ok {
+			return assertion, fmt.Errorf("operator %s requires a number", op)
+		}
+	}
+	return assertion, nil
+}
+
+// splitJSONPathComparison splits the expression by the comparison operator,
+// the operators inside of quotes and brackets are skipped
+func splitJSONPathComparison(expr string) (string, string, string) {
+	var quote rune
+	depth := 0
+	for i, r := range expr {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+			continue
+		case r == '\'' || r == '"':
+			quote = r
+			continue
+		case r == '(' || r == '[':
+			depth++
+			continue
+		case r == ')' || r == ']':
+			depth--
+			continue
+		case depth != 0:
+			continue
+		}
+		for _, op := range jsonPathOperators {
+			if strings.HasPrefix(expr[i:], op) {
+				return strings.TrimSpace(expr[:i]), op, strings.TrimSpace(expr[i+len(op):])
+			}
+		}
+	}
+	return expr, "", ""
+}
+
+// toGJSONPath converts JSONPath ($.items[0].id, $.items.length()) to the path of gjson (items.0.id, items.#),
+// the paths of gjson are accepted as is
+func toGJSONPath(path string) string {
+	path = strings.TrimPrefix(path, "$")
+	path = jsonPathIndexRx.ReplaceAllString(path, ".$1")
+	path = strings.TrimPrefix(path, ".")
+	if path == "length()" {
+		return "#"
+	}
+	if strings.HasSuffix(path, ".length()") {
+		return strings.TrimSuffix(path, "length()") + "#"
+	}
+	return path
+}
+
+func (a jsonPathAssertion) check(body string) error {
+	actual := gjson.Parse(body)
+	if a.path != "" {
+		actual = actual.Get(a.path)
+	}
+
+	var passed bool
+	switch {
+	case a.absent:
+		passed = !actual.Exists()
+	case a.op == "":
+		passed = actual.Exists()
+	case !actual.Exists():
+		passed = false
+	case a.op == "==":
+		passed = reflect.DeepEqual(a.expected, actual.Value())
+	case a.op == "!=":
+		passed = !reflect.DeepEqual(a.expected, actual.Value())
+	default:
+		passed = compareNumbers(a.op, actual, a.expected.(float64))
+	}
+	if passed {
+		return nil
+	}
+
+	value := "no value"
+	if actual.Exists() {
+		value = actual.Raw
+	}
+	return fmt.Errorf("JSONPath %s is not satisfied, actual value: %s", a.source, value)
+}
+
+func compareNumbers(op string, actual gjson.Result, expected float64) bool {
+	if actual.Type != gjson.Number {
+		return false
+	}
+	switch op {
+	case ">":
+		return actual.Num > expected
+	case ">=":
+		return actual.Num >= expected
+	case "<":
+		return actual.Num < expected
+	default:
+		return actual.Num <= expected
+	}
+}
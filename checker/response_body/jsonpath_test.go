@@ -0,0 +1,90 @@
+package response_body
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lamoda/gonkey/testloader/yaml_file"
+)
+
+const ordersJSON = `{
+  "data": {"total": 5, "status": "ok", "paid": true, "coupon": null},
+  "items": [{"id": 1, "name": "book"}, {"id": 2, "name": "pen"}]
+}`
+
+func jsonPathTest(assertions ...string) *yaml_file.Test {
+	return &yaml_file.Test{
+		TestDefinition: yaml_file.TestDefinition{
+			Name:              "json path",
+			ResponseJSONPaths: map[int][]string{200: assertions},
+		},
+	}
+}
+
+func TestJSONPathAssertionsPass(t *testing.T) {
+	test := jsonPathTest(
+		"$.data.total == 5",
+		"$.data.total != 4",
+		"$.data.status == 'ok'",
+		`$.data.status == "ok"`,
+		"$.data.paid == true",
+		"$.data.coupon == null",
+		"$.items.length() >= 1",
+		"$.items.length() < 3",
+		"$.items[1].name == 'pen'",
+		`$.items[0] == {"id": 1, "name": "book"}`,
+		"$.data.coupon",
+		"!$.data.password",
+		"items.#.id == [1, 2]",
+	)
+
+	errs, err := NewChecker().Check(test, jsonResult(ordersJSON))
+	require.NoError(t, err)
+	assert.Empty(t, errs)
+}
+
+func TestJSONPathAssertionsFail(t *testing.T) {
+	test := jsonPathTest(
+		"$.data.total == 6",
+		"$.items.length() > 2",
+		"$.data.status > 1",
+		"$.data.password",
+		"!$.data.status",
+		"$.data.discount == 0",
+	)
+
+	errs, err := NewChecker().Check(test, jsonResult(ordersJSON))
+	require.NoError(t, err)
+	require.Len(t, errs, 6)
+	assert.EqualError(t, errs[0], "JSONPath $.data.total == 6 is not satisfied, actual value: 5")
+	assert.EqualError(t, errs[1], "JSONPath $.items.length() > 2 is not satisfied, actual value: 2")
+	assert.EqualError(t, errs[2], `JSONPath $.data.status > 1 is not satisfied, actual value: "ok"`)
+	assert.EqualError(t, errs[3], "JSONPath $.data.password is not satisfied, actual value: no value")
+	assert.EqualError(t, errs[4], `JSONPath !$.data.status is not satisfied, actual value: "ok"`)
+	assert.EqualError(t, errs[5], "JSONPath $.data.discount == 0 is not satisfied, actual value: no value")
+}
+
+func TestJSONPathInvalidAssertion(t *testing.T) {
+	for _, assertion := range []string{"$.data.total == five", "$.data.status >= 'a'"} {
+		_, err := NewChecker().Check(jsonPathTest(assertion), jsonResult(ordersJSON))
+		assert.Error(t, err, assertion)
+	}
+}
+
+func TestJSONPathInvalidResponse(t *testing.T) {
+	errs, err := NewChecker().Check(jsonPathTest("$.data"), jsonResult(`{"data":`))
+	require.NoError(t, err)
+	require.Len(t, errs, 1)
+	assert.EqualError(t, errs[0], "could not parse response")
+}
+
+func TestJSONPathWithResponseBody(t *testing.T) {
+	test := jsonPathTest("$.data.total == 5")
+	test.Responses = map[int]string{200: `{"data": {"status": "failed"}}`}
+
+	errs, err := NewChecker().Check(test, jsonResult(ordersJSON))
+	require.NoError(t, err)
+	assert.Len(t, errs, 1)
+}
@@ -0,0 +1,27 @@
+package response_body
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/lamoda/gonkey/models"
+	"github.com/lamoda/gonkey/output"
+)
+
+// regexpBodySize is how much of the body is shown when it doesn't match responseBodyMatchRegexp
+const regexpBodySize = 1024
+
+// checkBodyRegexp matches the whole raw body with the pattern, e.g. for HTML or plain text responses
+// which can't be compared structurally
+func checkBodyRegexp(t models.TestInterface, pattern string, result *models.Result) ([]error, error) {
+	rx, err := regexp.Compile(`\A(?:` + pattern + `)\z`)
+	if err != nil {
+		return nil, fmt.Errorf("invalid responseBodyMatchRegexp %s for test %s: %s", pattern, t.GetName(), err)
+	}
+	if rx.MatchString(result.ResponseBody) {
+		return nil, nil
+	}
+	return []error{fmt.Errorf(
+		"response body does not match %s:\n%s", pattern, output.TruncateBody(result.ResponseBody, regexpBodySize),
+	)}, nil
+}
@@ -0,0 +1,66 @@
+package response_body
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lamoda/gonkey/models"
+	"github.com/lamoda/gonkey/testloader/yaml_file"
+)
+
+const welcomePage = `<html>
+<head><title>Shop</title></head>
+<body>Welcome, John!</body>
+</html>`
+
+func regexpTest(pattern string) *yaml_file.Test {
+	return &yaml_file.Test{
+		TestDefinition: yaml_file.TestDefinition{
+			Name:                    "regexp",
+			ResponseBodyMatchRegexp: pattern,
+		},
+	}
+}
+
+func textResult(body string) *models.Result {
+	return &models.Result{
+		ResponseStatusCode:  200,
+		ResponseContentType: "text/html",
+		ResponseBody:        body,
+	}
+}
+
+func TestBodyRegexpMatches(t *testing.T) {
+	errs, err := NewChecker().Check(regexpTest(`(?s)<html>.*Welcome, \w+!.*</html>`), textResult(welcomePage))
+	require.NoError(t, err)
+	assert.Empty(t, errs)
+}
+
+func TestBodyRegexpMatchesWholeBody(t *testing.T) {
+	// the pattern matching a part of the body is not enough
+	errs, err := NewChecker().Check(regexpTest(`Welcome, \w+!`), textResult(welcomePage))
+	require.NoError(t, err)
+	require.Len(t, errs, 1)
+	assert.Equal(t, "response body does not match Welcome, \\w+!:\n"+welcomePage, errs[0].Error())
+}
+
+func TestBodyRegexpTruncatesBody(t *testing.T) {
+	body := strings.Repeat("a", 2000)
+
+	errs, err := NewChecker().Check(regexpTest(`b+`), textResult(body))
+	require.NoError(t, err)
+	require.Len(t, errs, 1)
+	assert.Equal(t,
+		"response body does not match b+:\n"+strings.Repeat("a", 1024)+"\n...truncated (1024 of 2000 bytes shown)",
+		errs[0].Error(),
+	)
+}
+
+func TestBodyRegexpInvalid(t *testing.T) {
+	_, err := NewChecker().Check(regexpTest(`(unclosed`), textResult(welcomePage))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid responseBodyMatchRegexp (unclosed for test regexp")
+}
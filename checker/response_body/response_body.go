@@ -4,48 +4,291 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"strings"
 
+	"github.com/tidwall/gjson"
+
 	"github.com/lamoda/gonkey/checker"
 	"github.com/lamoda/gonkey/compare"
 	"github.com/lamoda/gonkey/models"
+	"github.com/lamoda/gonkey/variables"
 )
 
-type ResponseBodyChecker struct{}
+// CustomCompareContext describes the test which is being checked by a custom compare function
+type CustomCompareContext struct {
+	Test      models.TestInterface
+	Result    *models.Result
+	Variables *variables.Variables
+	// Path of the checked value in the response body, e.g. $.data.token
+	Path string
+}
+
+// CustomCompareFunc can be referenced in the expected response body as "$custom:name",
+// it gets the actual value and returns non-nil error if the value doesn't satisfy the check.
+type CustomCompareFunc func(ctx CustomCompareContext, actual interface{}) error
+
+// ExpectedBodyFunc returns the expected response body of the test for the status as a Go value, e.g. a struct,
+// ok is false if the test has no expected body for the status
+type ExpectedBodyFunc func(status int) (body interface{}, ok bool)
+
+// ExpectedBody returns the ExpectedBodyFunc of the body expected with the status
+func ExpectedBody(status int, body interface{}) ExpectedBodyFunc {
+	return func(actual int) (interface{}, bool) {
+		return body, actual == status
+	}
+}
+
+// ExpectedBodyTransform returns the expected body of the test modified before it's compared, e.g. with
+// the checksum of the request body of the result. It gets the expected body with the variables substituted.
+type ExpectedBodyTransform func(result *models.Result, expectedBody string) (string, error)
+
+// Options of the checker
+type Options struct {
+	// CustomFuncs can be referenced in the expected response body as "$custom:name"
+	CustomFuncs map[string]CustomCompareFunc
+	// Variables are passed to the custom functions as a part of the context
+	Variables *variables.Variables
+	// UpdateGolden makes the checker rewrite golden files (responseBodyFile) with actual responses
+	// when they differ, instead of failing the test
+	UpdateGolden bool
+	// CaptureResponses makes the checker write the actual responses into the empty response placeholders
+	// of the test files, instead of checking the bodies
+	CaptureResponses bool
+	// ExpectedBodies are the expected response bodies as Go values by test name, they are marshaled to JSON
+	// and compared the same way as the JSON bodies of the test files. They are used for the statuses
+	// without response, responseOneOf and responseBodyFile in the test file.
+	ExpectedBodies map[string]ExpectedBodyFunc
+	// ExpectedBodyTransforms modify the expected bodies of the tests by test name: response, responseOneOf
+	// and responseBodyFile
+	ExpectedBodyTransforms map[string]ExpectedBodyTransform
+}
+
+type ResponseBodyChecker struct {
+	opts Options
+}
 
 func NewChecker() checker.CheckerInterface {
 	return &ResponseBodyChecker{}
 }
 
+func NewCheckerWithOptions(opts Options) checker.CheckerInterface {
+	return &ResponseBodyChecker{
+		opts: opts,
+	}
+}
+
+// WithoutUpdates returns the checker with the same options which doesn't update the golden files and doesn't
+// capture the responses, e.g. for the responses of the attempts expected by the retry policy
+func (c *ResponseBodyChecker) WithoutUpdates() *ResponseBodyChecker {
+	opts := c.opts
+	opts.UpdateGolden = false
+	opts.CaptureResponses = false
+	return &ResponseBodyChecker{opts: opts}
+}
+
+func (c *ResponseBodyChecker) Name() string {
+	return "response_body"
+}
+
 func (c *ResponseBodyChecker) Check(t models.TestInterface, result *models.Result) ([]error, error) {
 	var errs []error
 	var foundResponse bool
+	if stream := t.GetStreamResponse(); stream != nil {
+		switch stream.Type {
+		case "", models.StreamNDJSON, models.StreamSSE:
+		default:
+			return nil, fmt.Errorf("unknown type %s of responseStream in test %s", stream.Type, t.GetName())
+		}
+	}
+	if t.GetResponseBodyValidJSON() && !json.Valid([]byte(result.ResponseBody)) {
+		errs = append(errs, errors.New("response body is not valid JSON"))
+	}
+	if size := t.GetResponseBodySize(); size != nil && !size.Match(len(result.ResponseBody)) {
+		errs = append(errs, fmt.Errorf(
+			"response body size %d bytes is out of bounds %s", len(result.ResponseBody), size,
+		))
+	}
+	if encoding := t.GetResponseBodyEncoding(); encoding != nil {
+		checkErrs, err := checkEncoding(t, encoding, result)
+		if err != nil {
+			return nil, err
+		}
+		errs = append(errs, checkErrs...)
+	}
+	status := t.GetResponseStatus()
+	if status != nil && !status.Match(result.ResponseStatusCode) {
+		err := fmt.Errorf("server responded with status %d, expected %s", result.ResponseStatusCode, status)
+		return append(errs, err), nil
+	}
+	if c.opts.CaptureResponses {
+		captured, err := c.captureResponse(t, result)
+		if err != nil {
+			return nil, err
+		}
+		if captured {
+			return errs, nil
+		}
+	}
 	// test response with the expected response body
 	if expectedBody, ok := t.GetResponse(result.ResponseStatusCode); ok {
 		foundResponse = true
-		// is the response JSON document?
-		if strings.Contains(result.ResponseContentType, "json") && expectedBody != "" {
-			checkErrs, err := compareJsonBody(t, expectedBody, result)
-			if err != nil {
-				return nil, err
-			}
-			errs = append(errs, checkErrs...)
-		} else {
-			// compare bodies as leaf nodes
-			errs = append(errs, compare.Compare(expectedBody, result.ResponseBody, compare.CompareParams{})...)
+		if expectedBody == "" && t.GetResponseBodyValidJSON() {
+			// only the status and the validity of the body are checked
+			return errs, nil
+		}
+		checkErrs, err := c.compareBody(t, expectedBody, result)
+		if err != nil {
+			return nil, err
+		}
+		errs = append(errs, checkErrs...)
+	} else if candidates, ok := t.GetResponsesOneOf()[result.ResponseStatusCode]; ok {
+		foundResponse = true
+		checkErrs, err := c.compareOneOf(t, candidates, result)
+		if err != nil {
+			return nil, err
+		}
+		errs = append(errs, checkErrs...)
+	} else if goldenFile, ok := t.GetResponseBodyFile(result.ResponseStatusCode); ok {
+		foundResponse = true
+		checkErrs, err := c.checkGolden(t, goldenFile, result)
+		if err != nil {
+			return nil, err
+		}
+		errs = append(errs, checkErrs...)
+	} else if expectedValue, ok := c.expectedValue(t, result.ResponseStatusCode); ok {
+		foundResponse = true
+		checkErrs, err := c.compareValue(t, expectedValue, result)
+		if err != nil {
+			return nil, err
+		}
+		errs = append(errs, checkErrs...)
+	} else if expectedEvents, ok := streamEvents(t, result.ResponseStatusCode); ok {
+		foundResponse = true
+		checkErrs, err := c.checkEvents(t, expectedEvents, result)
+		if err != nil {
+			return nil, err
+		}
+		errs = append(errs, checkErrs...)
+	} else if expectedLines, ok := streamLines(t, result.ResponseStatusCode); ok {
+		foundResponse = true
+		checkErrs, err := c.checkStream(t, expectedLines, result)
+		if err != nil {
+			return nil, err
 		}
+		errs = append(errs, checkErrs...)
+	} else if protobuf := t.GetProtobufResponse(); protobuf != nil {
+		// protobuf responses are compared by response_protobuf checker
+		_, foundResponse = protobuf.Body[result.ResponseStatusCode]
 	}
-	if !foundResponse {
+	if assertions, ok := t.GetResponseXPaths()[result.ResponseStatusCode]; ok {
+		foundResponse = true
+		checkErrs, err := c.checkXPaths(t, assertions, result)
+		if err != nil {
+			return nil, err
+		}
+		errs = append(errs, checkErrs...)
+	}
+	if assertions, ok := t.GetResponseJSONPaths()[result.ResponseStatusCode]; ok {
+		foundResponse = true
+		checkErrs, err := c.checkJSONPaths(t, assertions, result)
+		if err != nil {
+			return nil, err
+		}
+		errs = append(errs, checkErrs...)
+	}
+	if pattern := t.GetResponseBodyMatchRegexp(); pattern != "" {
+		foundResponse = true
+		checkErrs, err := checkBodyRegexp(t, pattern, result)
+		if err != nil {
+			return nil, err
+		}
+		errs = append(errs, checkErrs...)
+	}
+	// the gRPC responses are checked by response_grpc checker
+	if t.GetGrpcStatus() != nil {
+		foundResponse = true
+	}
+	// the status matching responseStatus is enough when there are no expectations for it
+	if !foundResponse && status == nil {
 		err := fmt.Errorf("server responded with status %d", result.ResponseStatusCode)
 		errs = append(errs, err)
 	}
 	return errs, nil
 }
 
-func compareJsonBody(t models.TestInterface, expectedBody string, result *models.Result) ([]error, error) {
+func (c *ResponseBodyChecker) compareBody(t models.TestInterface, expectedBody string, result *models.Result) ([]error, error) {
+	if transform, ok := c.opts.ExpectedBodyTransforms[t.GetName()]; ok && transform != nil {
+		transformed, err := transform(result, expectedBody)
+		if err != nil {
+			return nil, fmt.Errorf("unable to transform expected response of test %s (status %d): %s",
+				t.GetName(), result.ResponseStatusCode, err)
+		}
+		expectedBody = transformed
+	}
+
+	// is the response JSON document?
+	if strings.Contains(result.ResponseContentType, "json") && expectedBody != "" {
+		return c.compareJsonBody(t, expectedBody, result)
+	}
+
+	// compare bodies as leaf nodes
+	params := compare.CompareParams{
+		CustomFuncs: c.compareFuncs(t, result),
+	}
+	return compare.Compare(expectedBody, result.ResponseBody, params), nil
+}
+
+// expectedValue returns the expected body of the test registered in ExpectedBodies
+func (c *ResponseBodyChecker) expectedValue(t models.TestInterface, status int) (interface{}, bool) {
+	expectedBody, ok := c.opts.ExpectedBodies[t.GetName()]
+	if !ok || expectedBody == nil {
+		return nil, false
+	}
+	return expectedBody(status)
+}
+
+// compareValue compares the JSON body of the response with the expected Go value
+func (c *ResponseBodyChecker) compareValue(t models.TestInterface, expected interface{}, result *models.Result) ([]error, error) {
+	expectedBody, err := json.Marshal(expected)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal expected response of test %s (status %d): %s",
+			t.GetName(), result.ResponseStatusCode, err)
+	}
+	return c.compareJsonBody(t, string(expectedBody), result)
+}
+
+// compareOneOf passes if the body matches any of the candidates, otherwise the errors and the diffs
+// of all the candidates are reported
+func (c *ResponseBodyChecker) compareOneOf(t models.TestInterface, candidates []string, result *models.Result) ([]error, error) {
+	errs := []error{fmt.Errorf("response body matches none of %d acceptable bodies", len(candidates))}
+	var diffs []string
+	for i, candidate := range candidates {
+		result.BodyDiff = ""
+		candidateErrs, err := c.compareBody(t, candidate, result)
+		if err != nil {
+			return nil, err
+		}
+		if len(candidateErrs) == 0 {
+			result.BodyDiff = ""
+			return nil, nil
+		}
+
+		for _, e := range candidateErrs {
+			errs = append(errs, fmt.Errorf("body #%d: %s", i+1, e))
+		}
+		if result.BodyDiff != "" {
+			diffs = append(diffs, fmt.Sprintf("body #%d:\n%s", i+1, result.BodyDiff))
+		}
+	}
+	result.BodyDiff = strings.Join(diffs, "\n")
+	return errs, nil
+}
+
+func (c *ResponseBodyChecker) compareJsonBody(t models.TestInterface, expectedBody string, result *models.Result) ([]error, error) {
 	// decode expected body
-	var expected interface{}
-	if err := json.Unmarshal([]byte(expectedBody), &expected); err != nil {
+	expected, err := decodeJSON(expectedBody, t.NumberTypeStrict())
+	if err != nil {
 		return nil, fmt.Errorf(
 			"invalid JSON in response for test %s (status %d): %s",
 			t.GetName(),
@@ -55,16 +298,113 @@ func compareJsonBody(t models.TestInterface, expectedBody string, result *models
 	}
 
 	// decode actual body
-	var actual interface{}
-	if err := json.Unmarshal([]byte(result.ResponseBody), &actual); err != nil {
+	if !gjson.Valid(result.ResponseBody) {
+		return []error{errors.New("could not parse response")}, nil
+	}
+	body, err := responseRoot(t.GetResponseRoot(), result.ResponseBody)
+	if err != nil {
+		return []error{err}, nil
+	}
+	actual, err := decodeJSON(body, t.NumberTypeStrict())
+	if err != nil {
 		return []error{errors.New("could not parse response")}, nil
 	}
 
+	expected, actual, errs, err := normalizeKeys(t, expected, actual)
+	if err != nil || len(errs) != 0 {
+		return errs, err
+	}
+
 	params := compare.CompareParams{
 		IgnoreValues:         !t.NeedsCheckingValues(),
 		IgnoreArraysOrdering: t.IgnoreArraysOrdering(),
 		DisallowExtraFields:  t.DisallowExtraFields(),
+		NumberTypeStrict:     t.NumberTypeStrict(),
+		CustomFuncs:          c.compareFuncs(t, result),
 	}
 
-	return compare.Compare(expected, actual, params), nil
+	return compareWithDiff(expected, actual, params, result), nil
+}
+
+// responseRoot returns the subtree of the valid JSON body at the JSONPath root, the whole body if root is empty
+func responseRoot(root, body string) (string, error) {
+	path := toGJSONPath(strings.TrimSpace(root))
+	if path == "" {
+		return body, nil
+	}
+	value := gjson.Get(body, path)
+	if !value.Exists() {
+		return "", fmt.Errorf("response has no root %s", root)
+	}
+	return value.Raw, nil
+}
+
+// decodeJSON decodes the body, the numbers are kept as json.Number to tell the integers and the floats apart
+// if the number types are compared
+func decodeJSON(body string, numberTypeStrict bool) (interface{}, error) {
+	var value interface{}
+	if !numberTypeStrict {
+		err := json.Unmarshal([]byte(body), &value)
+		return value, err
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, errors.New("invalid character after top-level value")
+	}
+	return value, nil
+}
+
+// compareWithDiff compares the decoded bodies and saves their diff to the result if they differ
+func compareWithDiff(expected, actual interface{}, params compare.CompareParams, result *models.Result) []error {
+	errs := compare.Compare(expected, actual, params)
+	if len(errs) != 0 {
+		result.BodyDiff = compare.Diff(expected, actual, params)
+	}
+	return errs
+}
+
+// normalizeKeys normalizes object keys of the expected and actual bodies if the test requires it,
+// collisions in the expected body are the errors of the test definition
+func normalizeKeys(t models.TestInterface, expected, actual interface{}) (interface{}, interface{}, []error, error) {
+	mode := t.NormalizeKeys()
+	if mode == "" {
+		return expected, actual, nil, nil
+	}
+
+	expected, err := compare.NormalizeKeys(expected, mode)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("expected response of test %s: %s", t.GetName(), err)
+	}
+	actual, err = compare.NormalizeKeys(actual, mode)
+	if err != nil {
+		return nil, nil, []error{fmt.Errorf("could not normalize response keys: %s", err)}, nil
+	}
+	return expected, actual, nil, nil
+}
+
+// compareFuncs binds the registered functions to the context of the test
+func (c *ResponseBodyChecker) compareFuncs(t models.TestInterface, result *models.Result) map[string]compare.CustomFunc {
+	if len(c.opts.CustomFuncs) == 0 {
+		return nil
+	}
+
+	funcs := make(map[string]compare.CustomFunc, len(c.opts.CustomFuncs))
+	for name, fn := range c.opts.CustomFuncs {
+		fn := fn
+		funcs[name] = func(path string, actual interface{}) error {
+			ctx := CustomCompareContext{
+				Test:      t,
+				Result:    result,
+				Variables: c.opts.Variables,
+				Path:      path,
+			}
+			return fn(ctx, actual)
+		}
+	}
+	return funcs
 }
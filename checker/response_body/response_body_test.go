@@ -0,0 +1,589 @@
+package response_body
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lamoda/gonkey/checker"
+	"github.com/lamoda/gonkey/compare"
+	"github.com/lamoda/gonkey/models"
+	"github.com/lamoda/gonkey/testloader/yaml_file"
+)
+
+func normalizeKeysTest(mode, expected string) *yaml_file.Test {
+	return &yaml_file.Test{
+		TestDefinition: yaml_file.TestDefinition{
+			Name:             "normalize keys",
+			ComparisonParams: yaml_file.ComparisonParams{CompareParams: compare.CompareParams{NormalizeKeys: mode}},
+		},
+		Responses: map[int]string{200: expected},
+	}
+}
+
+func TestNormalizeKeysMatches(t *testing.T) {
+	test := normalizeKeysTest(compare.KeysSnake, `{"user_id": 1, "profile": {"first_name": "john"}}`)
+
+	errs, err := NewChecker().Check(test, jsonResult(`{"userId": 1, "Profile": {"firstName": "john"}}`))
+	require.NoError(t, err)
+	assert.Empty(t, errs)
+}
+
+func TestNormalizeKeysCollisionInResponseFails(t *testing.T) {
+	test := normalizeKeysTest(compare.KeysLower, `{"userid": 1}`)
+
+	errs, err := NewChecker().Check(test, jsonResult(`{"userId": 1, "UserID": 1}`))
+	require.NoError(t, err)
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "both normalized to")
+}
+
+func TestNormalizeKeysCollisionInExpectedIsError(t *testing.T) {
+	test := normalizeKeysTest(compare.KeysCamel, `{"userId": 1, "user_id": 1}`)
+
+	_, err := NewChecker().Check(test, jsonResult(`{"userId": 1}`))
+	assert.Error(t, err)
+}
+
+func TestWithoutNormalizationKeysDiffer(t *testing.T) {
+	test := normalizeKeysTest("", `{"user_id": 1}`)
+
+	errs, err := NewChecker().Check(test, jsonResult(`{"userId": 1}`))
+	require.NoError(t, err)
+	assert.NotEmpty(t, errs)
+}
+
+func validJSONTest(responses map[int]string) *yaml_file.Test {
+	return &yaml_file.Test{
+		TestDefinition: yaml_file.TestDefinition{
+			Name:                  "valid json",
+			ResponseBodyValidJSON: true,
+		},
+		Responses: responses,
+	}
+}
+
+func TestValidJSONBody(t *testing.T) {
+	test := validJSONTest(map[int]string{200: ""})
+
+	for _, body := range []string{`{"status": "ok"}`, `[1, 2]`, `"up"`, `null`} {
+		errs, err := NewChecker().Check(test, jsonResult(body))
+		require.NoError(t, err)
+		assert.Empty(t, errs, body)
+	}
+}
+
+func TestInvalidJSONBodyFails(t *testing.T) {
+	test := validJSONTest(map[int]string{200: ""})
+
+	errs, err := NewChecker().Check(test, jsonResult(`{"status": `))
+	require.NoError(t, err)
+	require.Len(t, errs, 1)
+	assert.EqualError(t, errs[0], "response body is not valid JSON")
+
+	errs, err = NewChecker().Check(test, jsonResult(``))
+	require.NoError(t, err)
+	assert.Len(t, errs, 1)
+}
+
+func TestValidJSONBodyWithStatus(t *testing.T) {
+	test := validJSONTest(map[int]string{201: ""})
+
+	errs, err := NewChecker().Check(test, jsonResult(`{"status": "ok"}`))
+	require.NoError(t, err)
+	require.Len(t, errs, 1)
+	assert.EqualError(t, errs[0], "server responded with status 200")
+}
+
+func bodySizeTest(size models.BodySize, responses map[int]string) *yaml_file.Test {
+	return &yaml_file.Test{
+		TestDefinition: yaml_file.TestDefinition{
+			Name:             "body size",
+			ResponseStatus:   models.ResponseStatus{"200"},
+			ResponseBodySize: &size,
+		},
+		Responses: responses,
+	}
+}
+
+func TestBodySize(t *testing.T) {
+	test := bodySizeTest(models.BodySize{Min: 1, Max: 16}, nil)
+
+	errs, err := NewChecker().Check(test, jsonResult(`{"status": "ok"}`))
+	require.NoError(t, err)
+	assert.Empty(t, errs)
+
+	errs, err = NewChecker().Check(test, jsonResult(``))
+	require.NoError(t, err)
+	require.Len(t, errs, 1)
+	assert.EqualError(t, errs[0], "response body size 0 bytes is out of bounds [1, 16]")
+
+	errs, err = NewChecker().Check(test, jsonResult(`{"status": "down"}`))
+	require.NoError(t, err)
+	require.Len(t, errs, 1)
+	assert.EqualError(t, errs[0], "response body size 18 bytes is out of bounds [1, 16]")
+}
+
+func TestBodySizeWithExpectedBody(t *testing.T) {
+	test := bodySizeTest(models.BodySize{Min: 100}, map[int]string{200: `{"status": "ok"}`})
+
+	errs, err := NewChecker().Check(test, jsonResult(`{"status": "down"}`))
+	require.NoError(t, err)
+	require.Len(t, errs, 2)
+	assert.EqualError(t, errs[0], "response body size 18 bytes is out of bounds [100, ∞)")
+}
+
+func oneOfTest(candidates ...string) *yaml_file.Test {
+	return &yaml_file.Test{
+		TestDefinition: yaml_file.TestDefinition{
+			Name:           "one of",
+			ResponsesOneOf: map[int][]string{200: candidates},
+		},
+	}
+}
+
+func TestOneOfBodies(t *testing.T) {
+	test := oneOfTest(`{"status": "pending"}`, `{"status": "paid", "paidAt": "$matchRegexp(.+)"}`)
+
+	for _, body := range []string{`{"status": "pending"}`, `{"status": "paid", "paidAt": "2020-01-01"}`} {
+		result := jsonResult(body)
+		errs, err := NewChecker().Check(test, result)
+		require.NoError(t, err)
+		assert.Empty(t, errs, body)
+		assert.Empty(t, result.BodyDiff, body)
+	}
+}
+
+func TestOneOfBodiesNoneMatches(t *testing.T) {
+	test := oneOfTest(`{"status": "pending"}`, `{"status": "paid"}`)
+
+	result := jsonResult(`{"status": "failed"}`)
+	errs, err := NewChecker().Check(test, result)
+	require.NoError(t, err)
+	require.Len(t, errs, 3)
+	assert.EqualError(t, errs[0], "response body matches none of 2 acceptable bodies")
+	assert.Contains(t, errs[1].Error(), "body #1: ")
+	assert.Contains(t, errs[1].Error(), "pending")
+	assert.Contains(t, errs[2].Error(), "body #2: ")
+	assert.Contains(t, errs[2].Error(), "paid")
+	assert.Contains(t, result.BodyDiff, "body #1:\n")
+	assert.Contains(t, result.BodyDiff, "body #2:\n")
+}
+
+func TestValidJSONBodyWithExpectedBody(t *testing.T) {
+	test := validJSONTest(map[int]string{200: `{"status": "ok"}`})
+
+	errs, err := NewChecker().Check(test, jsonResult(`{"status": "down"}`))
+	require.NoError(t, err)
+	assert.Len(t, errs, 1)
+}
+
+func rootArrayTest(params compare.CompareParams, expected string) *yaml_file.Test {
+	return &yaml_file.Test{
+		TestDefinition: yaml_file.TestDefinition{
+			Name:             "root array",
+			ComparisonParams: yaml_file.ComparisonParams{CompareParams: params},
+		},
+		Responses: map[int]string{200: expected},
+	}
+}
+
+func TestRootArrayMatches(t *testing.T) {
+	tests := []struct {
+		name     string
+		params   compare.CompareParams
+		expected string
+		actual   string
+	}{
+		{
+			name:     "same order",
+			expected: `[{"id": 1}, {"id": 2}]`,
+			actual:   `[{"id": 1, "name": "book"}, {"id": 2, "name": "pen"}]`,
+		},
+		{
+			name:     "any order",
+			params:   compare.CompareParams{IgnoreArraysOrdering: true},
+			expected: `[{"id": 1}, {"id": 2}]`,
+			actual:   `[{"id": 2}, {"id": 1}]`,
+		},
+		{
+			name:     "any order of nested arrays",
+			params:   compare.CompareParams{IgnoreArraysOrdering: true},
+			expected: `[[1, 2], [3]]`,
+			actual:   `[[3], [2, 1]]`,
+		},
+		{
+			name:     "ignored values",
+			params:   compare.CompareParams{IgnoreValues: true},
+			expected: `[{"id": 1}]`,
+			actual:   `[{"id": 5}]`,
+		},
+		{
+			name:     "normalized keys",
+			params:   compare.CompareParams{NormalizeKeys: compare.KeysSnake},
+			expected: `[{"user_id": 1}]`,
+			actual:   `[{"userId": 1}]`,
+		},
+		{
+			name:     "empty",
+			params:   compare.CompareParams{IgnoreArraysOrdering: true, DisallowExtraFields: true},
+			expected: `[]`,
+			actual:   `[]`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs, err := NewChecker().Check(rootArrayTest(tt.params, tt.expected), jsonResult(tt.actual))
+			require.NoError(t, err)
+			assert.Empty(t, errs)
+		})
+	}
+}
+
+func TestRootArrayMismatches(t *testing.T) {
+	tests := []struct {
+		name     string
+		params   compare.CompareParams
+		expected string
+		actual   string
+		diff     string
+	}{
+		{
+			name:     "extra item",
+			expected: `[1, 2]`,
+			actual:   `[1, 2, 3]`,
+			diff:     "+ $[2]: 3",
+		},
+		{
+			name:     "extra item in any order",
+			params:   compare.CompareParams{IgnoreArraysOrdering: true},
+			expected: `[1, 2]`,
+			actual:   `[2, 1, 3]`,
+			diff:     "+ $[*]: 3",
+		},
+		{
+			name:     "extra fields of items",
+			params:   compare.CompareParams{IgnoreArraysOrdering: true, DisallowExtraFields: true},
+			expected: `[{"id": 1}, {"id": 2}]`,
+			actual:   `[{"id": 2, "name": "pen"}, {"id": 1}]`,
+			diff:     "- $[*]: {\"id\":2}\n+ $[*]: {\"id\":2,\"name\":\"pen\"}",
+		},
+		{
+			name:     "object instead of array",
+			expected: `[{"id": 1}]`,
+			actual:   `{"id": 1}`,
+			diff:     "- $: [{\"id\":1}]\n+ $: {\"id\":1}",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := jsonResult(tt.actual)
+			errs, err := NewChecker().Check(rootArrayTest(tt.params, tt.expected), result)
+			require.NoError(t, err)
+			assert.NotEmpty(t, errs)
+			assert.Equal(t, tt.diff, result.BodyDiff)
+		})
+	}
+}
+
+func responseStatusTest(status models.ResponseStatus, responses map[int]string) *yaml_file.Test {
+	return &yaml_file.Test{
+		TestDefinition: yaml_file.TestDefinition{
+			Name:           "response status",
+			ResponseStatus: status,
+		},
+		Responses: responses,
+	}
+}
+
+func TestResponseStatusMatches(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    models.ResponseStatus
+		responses map[int]string
+	}{
+		{name: "code", status: models.ResponseStatus{"201"}},
+		{name: "class", status: models.ResponseStatus{"2xx"}},
+		{name: "list", status: models.ResponseStatus{"200", "201"}},
+		{name: "with body", status: models.ResponseStatus{"2xx"}, responses: map[int]string{201: `{"id": 1}`}},
+		{name: "with body of another code", status: models.ResponseStatus{"2xx"}, responses: map[int]string{200: `{"id": 2}`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := jsonResult(`{"id": 1}`)
+			result.ResponseStatusCode = 201
+
+			errs, err := NewChecker().Check(responseStatusTest(tt.status, tt.responses), result)
+			require.NoError(t, err)
+			assert.Empty(t, errs)
+		})
+	}
+}
+
+func TestResponseStatusMismatch(t *testing.T) {
+	result := jsonResult(`{"error": "not found"}`)
+	result.ResponseStatusCode = 404
+	test := responseStatusTest(models.ResponseStatus{"200", "3xx"}, map[int]string{404: `{"error": "not found"}`})
+
+	errs, err := NewChecker().Check(test, result)
+	require.NoError(t, err)
+	require.Len(t, errs, 1)
+	assert.EqualError(t, errs[0], "server responded with status 404, expected 200, 3xx")
+}
+
+func TestResponseStatusBodyIsChecked(t *testing.T) {
+	result := jsonResult(`{"id": 1}`)
+	result.ResponseStatusCode = 201
+	test := responseStatusTest(models.ResponseStatus{"2xx"}, map[int]string{201: `{"id": 2}`})
+
+	errs, err := NewChecker().Check(test, result)
+	require.NoError(t, err)
+	assert.Len(t, errs, 1)
+}
+
+type expectedOrder struct {
+	ID     int      `json:"id"`
+	Status string   `json:"status"`
+	Items  []string `json:"items,omitempty"`
+}
+
+func expectedBodiesChecker() checker.CheckerInterface {
+	return NewCheckerWithOptions(Options{
+		ExpectedBodies: map[string]ExpectedBodyFunc{
+			"get order": ExpectedBody(200, expectedOrder{ID: 1, Status: "$matchRegexp(^(new|paid)$)"}),
+		},
+	})
+}
+
+func TestExpectedBodyMatches(t *testing.T) {
+	test := &yaml_file.Test{TestDefinition: yaml_file.TestDefinition{Name: "get order"}}
+
+	errs, err := expectedBodiesChecker().Check(test, jsonResult(`{"id": 1, "status": "paid", "total": 10}`))
+	require.NoError(t, err)
+	assert.Empty(t, errs)
+}
+
+func TestExpectedBodyMismatches(t *testing.T) {
+	test := &yaml_file.Test{TestDefinition: yaml_file.TestDefinition{Name: "get order"}}
+
+	result := jsonResult(`{"id": 2, "status": "paid"}`)
+	errs, err := expectedBodiesChecker().Check(test, result)
+	require.NoError(t, err)
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "at path $.id values do not match")
+	assert.NotEmpty(t, result.BodyDiff)
+
+	// the other statuses have no expected body
+	result = jsonResult(`{"error": "not found"}`)
+	result.ResponseStatusCode = 404
+	errs, err = expectedBodiesChecker().Check(test, result)
+	require.NoError(t, err)
+	assert.Equal(t, []error{errors.New("server responded with status 404")}, errs)
+}
+
+func TestExpectedBodyOfTestFileTakesPrecedence(t *testing.T) {
+	test := &yaml_file.Test{
+		TestDefinition: yaml_file.TestDefinition{Name: "get order"},
+		Responses:      map[int]string{200: `{"id": 2}`},
+	}
+
+	errs, err := expectedBodiesChecker().Check(test, jsonResult(`{"id": 2, "status": "cancelled"}`))
+	require.NoError(t, err)
+	assert.Empty(t, errs)
+}
+
+func TestExpectedBodyNotMarshaled(t *testing.T) {
+	test := &yaml_file.Test{TestDefinition: yaml_file.TestDefinition{Name: "get order"}}
+	c := NewCheckerWithOptions(Options{
+		ExpectedBodies: map[string]ExpectedBodyFunc{"get order": ExpectedBody(200, func() {})},
+	})
+
+	_, err := c.Check(test, jsonResult(`{"id": 1}`))
+	assert.EqualError(t, err,
+		"unable to marshal expected response of test get order (status 200): json: unsupported type: func()")
+}
+
+func numberTypeTest(strict bool, expected string) *yaml_file.Test {
+	return &yaml_file.Test{
+		TestDefinition: yaml_file.TestDefinition{
+			Name:             "number type",
+			ComparisonParams: yaml_file.ComparisonParams{CompareParams: compare.CompareParams{NumberTypeStrict: strict}},
+		},
+		Responses: map[int]string{200: expected},
+	}
+}
+
+func TestNumbersAreComparedByValue(t *testing.T) {
+	test := numberTypeTest(false, `{"price": 1.0, "count": 2, "ratio": 1.50}`)
+
+	errs, err := NewChecker().Check(test, jsonResult(`{"price": 1, "count": 2.0, "ratio": 1.5}`))
+	require.NoError(t, err)
+	assert.Empty(t, errs)
+}
+
+func TestNumberTypeStrict(t *testing.T) {
+	test := numberTypeTest(true, `{"price": 1.0, "count": 2, "ratio": 1.50, "ids": [1, 2], "total": "$gte:1"}`)
+
+	result := jsonResult(`{"price": 1, "count": 2, "ratio": 1.5, "ids": [1, 2.5], "total": 3.5}`)
+	errs, err := NewChecker().Check(test, result)
+	require.NoError(t, err)
+	require.Len(t, errs, 2)
+	assert.Contains(t, errs[0].Error(), "number types do not match")
+	assert.Contains(t, errs[1].Error(), "number types do not match")
+	assert.Contains(t, result.BodyDiff, "- $.price: 1.0\n+ $.price: 1")
+
+	errs, err = NewChecker().Check(test, jsonResult(`{"price": 1.0} }`))
+	require.NoError(t, err)
+	assert.EqualError(t, errs[0], "could not parse response")
+}
+
+func responseRootTest(root, expected string) *yaml_file.Test {
+	return &yaml_file.Test{
+		TestDefinition: yaml_file.TestDefinition{Name: "response root", ResponseRoot: root},
+		Responses:      map[int]string{200: expected},
+	}
+}
+
+func TestResponseRootMatches(t *testing.T) {
+	test := responseRootTest("$.data", `{"id": 1, "items": [{"name": "book"}]}`)
+
+	errs, err := NewChecker().Check(test, jsonResult(
+		`{"data": {"id": 1, "items": [{"name": "book"}]}, "meta": {"requestId": "f3a1", "took": 12}}`,
+	))
+	require.NoError(t, err)
+	assert.Empty(t, errs)
+
+	errs, err = NewChecker().Check(responseRootTest("$.data.items[0]", `{"name": "book"}`), jsonResult(
+		`{"data": {"items": [{"name": "book"}]}}`,
+	))
+	require.NoError(t, err)
+	assert.Empty(t, errs)
+}
+
+func TestResponseRootMismatches(t *testing.T) {
+	test := responseRootTest("$.data", `{"id": 2}`)
+
+	result := jsonResult(`{"data": {"id": 1}, "meta": {}}`)
+	errs, err := NewChecker().Check(test, result)
+	require.NoError(t, err)
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "values do not match")
+	assert.NotContains(t, result.BodyDiff, "meta")
+}
+
+func TestResponseRootMissing(t *testing.T) {
+	test := responseRootTest("$.data", `{"id": 1}`)
+
+	errs, err := NewChecker().Check(test, jsonResult(`{"error": "not found"}`))
+	require.NoError(t, err)
+	require.Len(t, errs, 1)
+	assert.EqualError(t, errs[0], "response has no root $.data")
+}
+
+func encodingTest(charset, text string) *yaml_file.Test {
+	return &yaml_file.Test{
+		TestDefinition: yaml_file.TestDefinition{
+			Name:                 "encoding",
+			ResponseStatus:       models.ResponseStatus{"200"},
+			ResponseBodyEncoding: &models.BodyEncoding{Charset: charset, Text: text},
+		},
+	}
+}
+
+func encodedResult(contentType, body string) *models.Result {
+	return &models.Result{ResponseStatusCode: 200, ResponseContentType: contentType, ResponseBody: body}
+}
+
+func TestBodyEncodingMatches(t *testing.T) {
+	errs, err := NewChecker().Check(encodingTest("", "Привет, мир"), encodedResult("text/plain; charset=UTF-8", "Привет, мир"))
+	require.NoError(t, err)
+	assert.Empty(t, errs)
+
+	errs, err = NewChecker().Check(encodingTest("utf-8", "$matchRegexp(^Привет)"), encodedResult("text/plain", "Привет, мир"))
+	require.NoError(t, err)
+	assert.Empty(t, errs)
+
+	errs, err = NewChecker().Check(encodingTest("", "café"), encodedResult("text/plain; charset=ISO-8859-1", "caf\xe9"))
+	require.NoError(t, err)
+	assert.Empty(t, errs)
+
+	errs, err = NewChecker().Check(encodingTest("", "€𝄞"), encodedResult("text/plain; charset=utf-16", "\xff\xfe\xac\x20\x34\xd8\x1e\xdd"))
+	require.NoError(t, err)
+	assert.Empty(t, errs)
+}
+
+func TestBodyEncodingMismatches(t *testing.T) {
+	errs, err := NewChecker().Check(encodingTest("", ""), encodedResult("text/plain", "Пр\xd0ивет"))
+	require.NoError(t, err)
+	require.Len(t, errs, 1)
+	assert.EqualError(t, errs[0], "response body is not valid utf-8: invalid byte sequence at offset 4")
+
+	errs, err = NewChecker().Check(encodingTest("utf-8", ""), encodedResult("text/plain; charset=windows-1251", "\xcf\xf0\xe8"))
+	require.NoError(t, err)
+	require.Len(t, errs, 1)
+	assert.EqualError(t, errs[0], "response charset windows-1251 does not match expected utf-8")
+
+	errs, err = NewChecker().Check(encodingTest("", ""), encodedResult("text/plain; charset=utf-16be", "\x00a\xdc\x00"))
+	require.NoError(t, err)
+	require.Len(t, errs, 1)
+	assert.EqualError(t, errs[0], "response body is not valid utf-16be: invalid byte sequence at offset 2")
+
+	errs, err = NewChecker().Check(encodingTest("", "Привет"), encodedResult("text/plain", "Пока"))
+	require.NoError(t, err)
+	require.Len(t, errs, 1)
+	assert.EqualError(t, errs[0], "decoded response body does not match expected \"Привет\":\nПока")
+
+	errs, err = NewChecker().Check(encodingTest("", ""), encodedResult("text/plain; charset=koi8-r", "\xf0\xd2"))
+	require.NoError(t, err)
+	assert.EqualError(t, errs[0], "response charset koi8-r is not supported")
+
+	_, err = NewChecker().Check(encodingTest("koi8-r", ""), encodedResult("text/plain", ""))
+	assert.EqualError(t, err, "unsupported charset koi8-r in responseBodyEncoding of test encoding")
+}
+
+func TestExpectedBodyTransform(t *testing.T) {
+	test := &yaml_file.Test{
+		TestDefinition: yaml_file.TestDefinition{Name: "upload"},
+		Responses:      map[int]string{200: `{"size": 5, "checksum": "CHECKSUM"}`},
+	}
+	checker := NewCheckerWithOptions(Options{
+		ExpectedBodyTransforms: map[string]ExpectedBodyTransform{
+			"upload": func(result *models.Result, expectedBody string) (string, error) {
+				checksum := fmt.Sprintf("%x", sha256.Sum256([]byte(result.RequestBody)))
+				return strings.Replace(expectedBody, "CHECKSUM", checksum, 1), nil
+			},
+		},
+	})
+
+	result := jsonResult(`{"size": 5, "checksum": "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"}`)
+	result.RequestBody = "hello"
+	errs, err := checker.Check(test, result)
+	require.NoError(t, err)
+	assert.Empty(t, errs)
+
+	result.RequestBody = "hello!"
+	errs, err = checker.Check(test, result)
+	require.NoError(t, err)
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "at path $.checksum values do not match")
+}
+
+func TestExpectedBodyTransformFails(t *testing.T) {
+	test := &yaml_file.Test{
+		TestDefinition: yaml_file.TestDefinition{Name: "upload"},
+		Responses:      map[int]string{200: `{"size": 5}`},
+	}
+	checker := NewCheckerWithOptions(Options{
+		ExpectedBodyTransforms: map[string]ExpectedBodyTransform{
+			"upload": func(*models.Result, string) (string, error) { return "", errors.New("no request body") },
+		},
+	})
+
+	_, err := checker.Check(test, jsonResult(`{"size": 5}`))
+	assert.EqualError(t, err, "unable to transform expected response of test upload (status 200): no request body")
+}
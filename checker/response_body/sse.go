@@ -0,0 +1,135 @@
+package response_body
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/lamoda/gonkey/compare"
+	"github.com/lamoda/gonkey/models"
+)
+
+// sseEvent is the event received from text/event-stream
+type sseEvent struct {
+	Event string
+	Data  string
+	ID    string
+}
+
+func streamEvents(t models.TestInterface, code int) ([]models.SSEEvent, bool) {
+	stream := t.GetStreamResponse()
+	if stream == nil || stream.Type != models.StreamSSE {
+		return nil, false
+	}
+	events, ok := stream.Events[code]
+	return events, ok
+}
+
+// parseEvents parses the events of text/event-stream according to the SSE spec:
+// the comments are skipped, the multi-line data is joined with "\n" and the event
+// which isn't terminated with a blank line is discarded
+func parseEvents(stream string) []sseEvent {
+	stream = strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(stream)
+
+	var events []sseEvent
+	var event sseEvent
+	var data strings.Builder
+	var hasData bool
+	for _, line := range strings.Split(stream, "\n") {
+		if line == "" {
+			if hasData {
+				if event.Event == "" {
+					event.Event = "message"
+				}
+				event.Data = strings.TrimSuffix(data.String(), "\n")
+				events = append(events, event)
+			}
+			event = sseEvent{ID: event.ID}
+			data.Reset()
+			hasData = false
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value := line, ""
+		if i := strings.Index(line, ":"); i != -1 {
+			field, value = line[:i], strings.TrimPrefix(line[i+1:], " ")
+		}
+		switch field {
+		case "event":
+			event.Event = value
+		case "data":
+			data.WriteString(value + "\n")
+			hasData = true
+		case "id":
+			if !strings.Contains(value, "\x00") {
+				event.ID = value
+			}
+		}
+	}
+	return events
+}
+
+// checkEvents compares the server-sent events with the expected ones in the given order,
+// the data is compared as JSON if the expected data is a JSON document and as a string otherwise
+func (c *ResponseBodyChecker) checkEvents(t models.TestInterface, expectedEvents []models.SSEEvent, result *models.Result) ([]error, error) {
+	events := parseEvents(result.ResponseBody)
+
+	expected := make([]interface{}, 0, len(expectedEvents))
+	actual := make([]interface{}, 0, len(events))
+	for i, expectedEvent := range expectedEvents {
+		item := map[string]interface{}{"event": expectedEvent.Event}
+		if expectedEvent.Event == "" {
+			item["event"] = "message"
+		}
+		var data interface{}
+		isJSON := json.Unmarshal([]byte(expectedEvent.Data), &data) == nil
+		if isJSON {
+			item["data"] = data
+		} else {
+			item["data"] = expectedEvent.Data
+		}
+		if expectedEvent.ID != "" {
+			item["id"] = expectedEvent.ID
+		}
+		expected = append(expected, item)
+
+		if i < len(events) {
+			actual = append(actual, actualEvent(events[i], isJSON, expectedEvent.ID != ""))
+		}
+	}
+	for _, event := range events[len(actual):] {
+		actual = append(actual, actualEvent(event, false, false))
+	}
+
+	normalizedExpected, normalizedActual, errs, err := normalizeKeys(t, expected, actual)
+	if err != nil || len(errs) != 0 {
+		return errs, err
+	}
+
+	params := compare.CompareParams{
+		IgnoreValues:         !t.NeedsCheckingValues(),
+		IgnoreArraysOrdering: t.IgnoreArraysOrdering(),
+		DisallowExtraFields:  t.DisallowExtraFields(),
+		CustomFuncs:          c.compareFuncs(t, result),
+	}
+
+	return compareWithDiff(normalizedExpected, normalizedActual, params, result), nil
+}
+
+// actualEvent converts the received event to the form of the expected one, the data is
+// parsed if the expected data is JSON, the data failing to parse is compared as a string
+func actualEvent(event sseEvent, parseData, withID bool) map[string]interface{} {
+	item := map[string]interface{}{"event": event.Event, "data": event.Data}
+	if parseData {
+		var data interface{}
+		if err := json.Unmarshal([]byte(event.Data), &data); err == nil {
+			item["data"] = data
+		}
+	}
+	if withID {
+		item["id"] = event.ID
+	}
+	return item
+}
@@ -0,0 +1,82 @@
+package response_body
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lamoda/gonkey/models"
+	"github.com/lamoda/gonkey/testloader/yaml_file"
+)
+
+func sseTest(events ...models.SSEEvent) *yaml_file.Test {
+	return &yaml_file.Test{
+		TestDefinition: yaml_file.TestDefinition{
+			Name: "sse",
+			StreamResponse: &models.StreamResponse{
+				Type:   models.StreamSSE,
+				Events: map[int][]models.SSEEvent{200: events},
+			},
+		},
+	}
+}
+
+func sseResult(body string) *models.Result {
+	return &models.Result{
+		ResponseStatusCode:  200,
+		ResponseContentType: "text/event-stream",
+		ResponseBody:        body,
+	}
+}
+
+func TestParseEvents(t *testing.T) {
+	stream := ": connected\r\n" +
+		"retry: 1000\n" +
+		"event: progress\n" +
+		"id: 1\n" +
+		"data:{\"percent\":\n" +
+		"data: 50}\n" +
+		"\n" +
+		"data: hello\r" +
+		"\r" +
+		"event: empty\n" +
+		"\n" +
+		"data: incomplete"
+
+	assert.Equal(t, []sseEvent{
+		{Event: "progress", Data: "{\"percent\":\n50}", ID: "1"},
+		{Event: "message", Data: "hello", ID: "1"},
+	}, parseEvents(stream))
+}
+
+func TestEventsMatch(t *testing.T) {
+	test := sseTest(
+		models.SSEEvent{Event: "progress", Data: `{"percent": 50}`, ID: "1"},
+		models.SSEEvent{Data: "$matchRegexp(^done)"},
+	)
+	body := "event: progress\nid: 1\ndata: {\"percent\":50}\n\n: ping\n\ndata: done at 12:00\n\n"
+
+	errs, err := NewChecker().Check(test, sseResult(body))
+	require.NoError(t, err)
+	assert.Empty(t, errs)
+}
+
+func TestEventsMismatchFails(t *testing.T) {
+	test := sseTest(
+		models.SSEEvent{Event: "progress", Data: `{"percent": 50}`},
+		models.SSEEvent{Event: "done", Data: "ok"},
+	)
+
+	errs, err := NewChecker().Check(test, sseResult("event: progress\ndata: {\"percent\":60}\n\n"))
+	require.NoError(t, err)
+	assert.NotEmpty(t, errs)
+}
+
+func TestUnknownStreamTypeFails(t *testing.T) {
+	test := sseTest()
+	test.StreamResponse.Type = "websocket"
+
+	_, err := NewChecker().Check(test, sseResult(""))
+	assert.EqualError(t, err, "unknown type websocket of responseStream in test sse")
+}
@@ -0,0 +1,70 @@
+package response_body
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/lamoda/gonkey/compare"
+	"github.com/lamoda/gonkey/models"
+)
+
+func streamLines(t models.TestInterface, code int) ([]string, bool) {
+	stream := t.GetStreamResponse()
+	if stream == nil || stream.Type == models.StreamSSE {
+		return nil, false
+	}
+	lines, ok := stream.Lines[code]
+	return lines, ok
+}
+
+// checkStream compares the lines of NDJSON streaming response with the expected ones in the given order
+func (c *ResponseBodyChecker) checkStream(t models.TestInterface, expectedLines []string, result *models.Result) ([]error, error) {
+	expected := make([]interface{}, 0, len(expectedLines))
+	for i, line := range expectedLines {
+		var item interface{}
+		if err := json.Unmarshal([]byte(line), &item); err != nil {
+			return nil, fmt.Errorf(
+				"invalid JSON in line %d of responseStream for test %s (status %d): %s",
+				i+1,
+				t.GetName(),
+				result.ResponseStatusCode,
+				err.Error(),
+			)
+		}
+		expected = append(expected, item)
+	}
+
+	var errs []error
+	actual := make([]interface{}, 0, len(expected))
+	for i, line := range strings.Split(result.ResponseBody, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		var item interface{}
+		if err := json.Unmarshal([]byte(line), &item); err != nil {
+			errs = append(errs, fmt.Errorf("could not parse line %d of the stream: %s", i+1, err.Error()))
+			continue
+		}
+		actual = append(actual, item)
+	}
+	if len(errs) != 0 {
+		return errs, nil
+	}
+
+	normalizedExpected, normalizedActual, errs, err := normalizeKeys(t, expected, actual)
+	if err != nil || len(errs) != 0 {
+		return errs, err
+	}
+
+	params := compare.CompareParams{
+		IgnoreValues:         !t.NeedsCheckingValues(),
+		IgnoreArraysOrdering: t.IgnoreArraysOrdering(),
+		DisallowExtraFields:  t.DisallowExtraFields(),
+		CustomFuncs:          c.compareFuncs(t, result),
+	}
+
+	return compareWithDiff(normalizedExpected, normalizedActual, params, result), nil
+}
@@ -0,0 +1,99 @@
+package response_body
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/antchfx/xmlquery"
+	"github.com/antchfx/xpath"
+
+	"github.com/lamoda/gonkey/models"
+)
+
+// checkXPaths evaluates XPath assertions against XML response, e.g. count(//item)=3 or //status/text()='OK'
+func (c *ResponseBodyChecker) checkXPaths(t models.TestInterface, assertions []string, result *models.Result) ([]error, error) {
+	exprs := make([]*xpath.Expr, len(assertions))
+	for i, assertion := range assertions {
+		expr, err := xpath.Compile("boolean(" + assertion + ")")
+		if err != nil {
+			return nil, fmt.Errorf(
+				"invalid XPath %s in responseXPath for test %s (status %d): %s",
+				assertion,
+				t.GetName(),
+				result.ResponseStatusCode,
+				err.Error(),
+			)
+		}
+		exprs[i] = expr
+	}
+
+	doc, err := xmlquery.Parse(strings.NewReader(result.ResponseBody))
+	if err != nil {
+		return []error{fmt.Errorf("could not parse response as XML: %s", err)}, nil
+	}
+
+	var errs []error
+	for i, expr := range exprs {
+		if passed, _ := expr.Evaluate(xmlquery.CreateXPathNavigator(doc)).(bool); passed {
+			continue
+		}
+		errs = append(errs, fmt.Errorf(
+			"XPath %s is not satisfied, actual value: %s",
+			assertions[i],
+			xpathActualValue(doc, assertions[i]),
+		))
+	}
+	return errs, nil
+}
+
+// xpathActualValue evaluates the left side of the comparison (or the whole assertion if it's not a comparison)
+// to show what the response holds
+func xpathActualValue(doc *xmlquery.Node, assertion string) string {
+	expr, err := xpath.Compile(comparedExpr(assertion))
+	if err != nil {
+		return "unknown"
+	}
+
+	switch value := expr.Evaluate(xmlquery.CreateXPathNavigator(doc)).(type) {
+	case *xpath.NodeIterator:
+		var values []string
+		for value.MoveNext() {
+			values = append(values, strconv.Quote(value.Current().Value()))
+		}
+		if len(values) == 0 {
+			return "no nodes"
+		}
+		return strings.Join(values, ", ")
+	case string:
+		return strconv.Quote(value)
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// comparedExpr returns the left side of the top level comparison of the expression (=, !=, <, <=, >, >=),
+// the operators inside of strings, predicates and function calls are skipped
+func comparedExpr(expr string) string {
+	var quote rune
+	depth := 0
+	for i, r := range expr {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == '(' || r == '[':
+			depth++
+		case r == ')' || r == ']':
+			depth--
+		case depth == 0 && strings.ContainsRune("=!<>", r):
+			return strings.TrimSpace(expr[:i])
+		}
+	}
+	return expr
+}
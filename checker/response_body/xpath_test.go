@@ -0,0 +1,96 @@
+package response_body
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lamoda/gonkey/models"
+	"github.com/lamoda/gonkey/testloader/yaml_file"
+)
+
+const ordersXML = `<?xml version="1.0"?>
+<orders>
+  <status>OK</status>
+  <item id="1">book</item>
+  <item id="2">pen</item>
+</orders>`
+
+func xpathTest(assertions ...string) *yaml_file.Test {
+	return &yaml_file.Test{
+		TestDefinition: yaml_file.TestDefinition{
+			Name:           "xpath",
+			ResponseXPaths: map[int][]string{200: assertions},
+		},
+	}
+}
+
+func xmlResult(body string) *models.Result {
+	return &models.Result{
+		ResponseStatusCode:  200,
+		ResponseContentType: "application/xml",
+		ResponseBody:        body,
+	}
+}
+
+func TestXPathAssertionsPass(t *testing.T) {
+	test := xpathTest(
+		"count(//item)=2",
+		"//status/text()='OK'",
+		"//item[@id='2']",
+		"//item[1] != 'pen'",
+	)
+
+	errs, err := NewChecker().Check(test, xmlResult(ordersXML))
+	require.NoError(t, err)
+	assert.Empty(t, errs)
+}
+
+func TestXPathAssertionsFail(t *testing.T) {
+	test := xpathTest(
+		"count(//item)=3",
+		"//status/text()='FAILED'",
+		"//item[@id='3']",
+		"string(/orders/status) = 'down'",
+	)
+
+	errs, err := NewChecker().Check(test, xmlResult(ordersXML))
+	require.NoError(t, err)
+	require.Len(t, errs, 4)
+	assert.EqualError(t, errs[0], "XPath count(//item)=3 is not satisfied, actual value: 2")
+	assert.EqualError(t, errs[1], `XPath //status/text()='FAILED' is not satisfied, actual value: "OK"`)
+	assert.EqualError(t, errs[2], "XPath //item[@id='3'] is not satisfied, actual value: no nodes")
+	assert.EqualError(t, errs[3], `XPath string(/orders/status) = 'down' is not satisfied, actual value: "OK"`)
+}
+
+func TestXPathInvalidResponse(t *testing.T) {
+	errs, err := NewChecker().Check(xpathTest("count(//item)=2"), xmlResult("<orders><item>"))
+	require.NoError(t, err)
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "could not parse response as XML")
+}
+
+func TestXPathInvalidExpression(t *testing.T) {
+	_, err := NewChecker().Check(xpathTest("count(//item"), xmlResult(ordersXML))
+	assert.Error(t, err)
+}
+
+func TestXPathOtherStatusFails(t *testing.T) {
+	result := xmlResult(ordersXML)
+	result.ResponseStatusCode = 500
+
+	errs, err := NewChecker().Check(xpathTest("count(//item)=3"), result)
+	require.NoError(t, err)
+	require.Len(t, errs, 1)
+	assert.EqualError(t, errs[0], "server responded with status 500")
+}
+
+func TestXPathWithResponseBody(t *testing.T) {
+	test := xpathTest("count(//item)=3")
+	test.Responses = map[int]string{200: "<orders/>"}
+
+	errs, err := NewChecker().Check(test, xmlResult(ordersXML))
+	require.NoError(t, err)
+	assert.Len(t, errs, 2)
+}
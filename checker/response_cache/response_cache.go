@@ -0,0 +1,159 @@
+package response_cache
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/lamoda/gonkey/checker"
+	"github.com/lamoda/gonkey/compare"
+	"github.com/lamoda/gonkey/models"
+)
+
+// ResponseCacheChecker checks the directives of the Cache-Control header of the response,
+// the order of the directives doesn't matter
+type ResponseCacheChecker struct{}
+
+func NewChecker() checker.CheckerInterface {
+	return &ResponseCacheChecker{}
+}
+
+func (c *ResponseCacheChecker) Name() string {
+	return "response_cache"
+}
+
+func (c *ResponseCacheChecker) Check(t models.TestInterface, result *models.Result) ([]error, error) {
+	expected, ok := t.GetResponseCacheControl(result.ResponseStatusCode)
+	if !ok || len(expected) == 0 {
+		return nil, nil
+	}
+
+	directives := parseCacheControl(result.ResponseHeaders["Cache-Control"])
+
+	var errs []error
+	for name, want := range expected {
+		name = strings.ToLower(name)
+		value, present := directives[name]
+		if err := checkDirective(name, want, value, present); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs, nil
+}
+
+// checkDirective checks the directive against the expectation: present (or true) and absent (or false)
+// check the presence only, a comparison like ">= 60" checks the number of seconds of the directive,
+// any other expectation is compared with the value the same way as the headers
+func checkDirective(name, want, value string, present bool) error {
+	switch want {
+	case "present", "true":
+		if !present {
+			return fmt.Errorf("Cache-Control directive %s is missing", name)
+		}
+		return nil
+	case "absent", "false":
+		if present {
+			return fmt.Errorf("Cache-Control directive %s must be absent", name)
+		}
+		return nil
+	}
+
+	if !present {
+		return fmt.Errorf("Cache-Control directive %s is missing", name)
+	}
+
+	if op, bound, ok := comparison(want); ok {
+		seconds, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("Cache-Control directive %s=%s is not a number of seconds", name, value)
+		}
+		if !compareSeconds(op, seconds, bound) {
+			return fmt.Errorf("Cache-Control directive %s=%s does not satisfy %s", name, value, want)
+		}
+		return nil
+	}
+
+	if len(compare.Compare(want, value, compare.CompareParams{})) != 0 {
+		return fmt.Errorf("Cache-Control directive %s value %s does not match expected %s", name, value, want)
+	}
+	return nil
+}
+
+// comparison parses the expectations like ">= 60", the operators are >=, <=, >, < and =
+func comparison(want string) (string, int64, bool) {
+	for _, op := range []string{">=", "<=", ">", "<", "="} {
+		if !strings.HasPrefix(want, op) {
+			continue
+		}
+		bound, err := strconv.ParseInt(strings.TrimSpace(want[len(op):]), 10, 64)
+		if err != nil {
+			return "", 0, false
+		}
+		return op, bound, true
+	}
+	return "", 0, false
+}
+
+func compareSeconds(op string, seconds, bound int64) bool {
+	switch op {
+	case ">=":
+		return seconds >= bound
+	case "<=":
+		return seconds <= bound
+	case ">":
+		return seconds > bound
+	case "<":
+		return seconds < bound
+	default:
+		return seconds == bound
+	}
+}
+
+// parseCacheControl returns the directives of the Cache-Control headers by lower-cased name,
+// the quoted values are unquoted, the first of the repeated directives is kept
+func parseCacheControl(headers []string) map[string]string {
+	directives := make(map[string]string)
+	for _, header := range headers {
+		for _, part := range splitDirectives(header) {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			name, value := part, ""
+			if i := strings.Index(part, "="); i >= 0 {
+				name, value = strings.TrimSpace(part[:i]), strings.TrimSpace(part[i+1:])
+				if unquoted, err := strconv.Unquote(value); err == nil && strings.HasPrefix(value, `"`) {
+					value = unquoted
+				}
+			}
+			name = strings.ToLower(name)
+			if _, ok := directives[name]; !ok {
+				directives[name] = value
+			}
+		}
+	}
+	return directives
+}
+
+// splitDirectives splits the header by the commas outside of the quoted values, e.g. no-cache="Set-Cookie, Vary"
+func splitDirectives(header string) []string {
+	var parts []string
+	quoted := false
+	start := 0
+	for i := 0; i < len(header); i++ {
+		switch header[i] {
+		case '\\':
+			if quoted {
+				i++
+			}
+		case '"':
+			quoted = !quoted
+		case ',':
+			if !quoted {
+				parts = append(parts, header[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, header[start:])
+}
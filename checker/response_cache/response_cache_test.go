@@ -0,0 +1,92 @@
+package response_cache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lamoda/gonkey/models"
+	"github.com/lamoda/gonkey/testloader/yaml_file"
+)
+
+func testWithCacheControl(expected map[string]string) *yaml_file.Test {
+	return &yaml_file.Test{
+		TestDefinition: yaml_file.TestDefinition{
+			ResponseCacheControl: map[int]map[string]string{200: expected},
+		},
+	}
+}
+
+func resultWithCacheControl(headers ...string) *models.Result {
+	return &models.Result{
+		ResponseStatusCode: 200,
+		ResponseHeaders:    map[string][]string{"Cache-Control": headers},
+	}
+}
+
+func TestCheckDirectivesInAnyOrder(t *testing.T) {
+	test := testWithCacheControl(map[string]string{
+		"max-age":         ">= 60",
+		"s-maxage":        "= 600",
+		"public":          "present",
+		"must-revalidate": "true",
+		"no-store":        "absent",
+		"private":         "false",
+		"no-cache":        "$matchRegexp(Set-Cookie)",
+	})
+
+	errs, err := NewChecker().Check(test, resultWithCacheControl(
+		`Public, no-cache="Set-Cookie, Vary", max-age=120`,
+		"s-maxage=600, must-revalidate",
+	))
+	require.NoError(t, err)
+	assert.Empty(t, errs)
+}
+
+func TestCheckDirectivesMismatch(t *testing.T) {
+	test := testWithCacheControl(map[string]string{
+		"max-age":  ">= 60",
+		"no-store": "absent",
+		"public":   "present",
+		"s-maxage": "< 100",
+		"no-cache": "Set-Cookie",
+	})
+
+	errs, err := NewChecker().Check(test, resultWithCacheControl("no-store, max-age=30, s-maxage=never, no-cache=Vary"))
+	require.NoError(t, err)
+
+	var messages []string
+	for _, e := range errs {
+		messages = append(messages, e.Error())
+	}
+	assert.ElementsMatch(t, []string{
+		"Cache-Control directive max-age=30 does not satisfy >= 60",
+		"Cache-Control directive no-store must be absent",
+		"Cache-Control directive public is missing",
+		"Cache-Control directive s-maxage=never is not a number of seconds",
+		"Cache-Control directive no-cache value Vary does not match expected Set-Cookie",
+	}, messages)
+}
+
+func TestCheckWithoutCacheControlHeader(t *testing.T) {
+	test := testWithCacheControl(map[string]string{
+		"no-store": "absent",
+		"max-age":  "> 0",
+	})
+
+	errs, err := NewChecker().Check(test, &models.Result{ResponseStatusCode: 200})
+	require.NoError(t, err)
+	require.Len(t, errs, 1)
+	assert.EqualError(t, errs[0], "Cache-Control directive max-age is missing")
+}
+
+func TestCheckOtherStatusIsSkipped(t *testing.T) {
+	test := testWithCacheControl(map[string]string{"max-age": ">= 60"})
+
+	result := resultWithCacheControl("no-store")
+	result.ResponseStatusCode = 404
+	errs, err := NewChecker().Check(test, result)
+	require.NoError(t, err)
+	assert.Empty(t, errs)
+}
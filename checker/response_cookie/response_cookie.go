@@ -0,0 +1,97 @@
+package response_cookie
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/lamoda/gonkey/checker"
+	"github.com/lamoda/gonkey/compare"
+	"github.com/lamoda/gonkey/models"
+)
+
+// ResponseCookieChecker checks the cookies set and cleared by the Set-Cookie headers of the response,
+// the cookies not listed in the test aren't checked
+type ResponseCookieChecker struct{}
+
+func NewChecker() checker.CheckerInterface {
+	return &ResponseCookieChecker{}
+}
+
+func (c *ResponseCookieChecker) Name() string {
+	return "response_cookie"
+}
+
+func (c *ResponseCookieChecker) Check(t models.TestInterface, result *models.Result) ([]error, error) {
+	expected, ok := t.GetResponseCookies(result.ResponseStatusCode)
+	if !ok || len(expected) == 0 {
+		return nil, nil
+	}
+
+	cookies := setCookies(result.ResponseHeaders)
+	now := time.Now()
+
+	var errs []error
+	for name, want := range expected {
+		if err := checkCookie(name, want, cookies[name], now); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs, nil
+}
+
+// setCookies returns the cookies of the Set-Cookie headers by name, the last header of the cookie wins
+// the same way as in the browsers
+func setCookies(headers map[string][]string) map[string]*http.Cookie {
+	res := (&http.Response{Header: headers}).Cookies()
+	cookies := make(map[string]*http.Cookie, len(res))
+	for _, cookie := range res {
+		cookies[cookie.Name] = cookie
+	}
+	return cookies
+}
+
+// checkCookie checks the cookie against the expectation: set requires a non-empty value of a cookie
+// which isn't cleared, cleared requires Max-Age <= 0 or Expires in the past, absent requires no Set-Cookie
+// of the cookie, any other expectation is compared with the value the same way as the headers
+func checkCookie(name, want string, cookie *http.Cookie, now time.Time) error {
+	if want == "absent" {
+		if cookie != nil {
+			return fmt.Errorf("response must not set cookie %s", name)
+		}
+		return nil
+	}
+	if cookie == nil {
+		return fmt.Errorf("response does not set cookie %s", name)
+	}
+
+	switch cleared := isCleared(cookie, now); want {
+	case "cleared":
+		if !cleared {
+			return fmt.Errorf("response sets cookie %s, expected it to be cleared with Max-Age <= 0 or Expires in the past", name)
+		}
+		return nil
+	case "set":
+		if cleared {
+			return fmt.Errorf("response clears cookie %s, expected it to be set", name)
+		}
+		if cookie.Value == "" {
+			return fmt.Errorf("response sets cookie %s with an empty value", name)
+		}
+		return nil
+	}
+
+	if len(compare.Compare(want, cookie.Value, compare.CompareParams{})) != 0 {
+		return fmt.Errorf("response cookie %s value %s does not match expected %s", name, cookie.Value, want)
+	}
+	return nil
+}
+
+// isCleared tells that the cookie is removed by the browser, MaxAge is negative for Max-Age <= 0
+func isCleared(cookie *http.Cookie, now time.Time) bool {
+	if cookie.MaxAge < 0 {
+		return true
+	}
+	// Max-Age takes priority over Expires
+	return cookie.MaxAge == 0 && !cookie.Expires.IsZero() && !cookie.Expires.After(now)
+}
@@ -0,0 +1,86 @@
+package response_cookie
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lamoda/gonkey/models"
+	"github.com/lamoda/gonkey/testloader/yaml_file"
+)
+
+func testWithCookies(expected map[string]string) *yaml_file.Test {
+	return &yaml_file.Test{
+		TestDefinition: yaml_file.TestDefinition{
+			ResponseCookies: map[int]map[string]string{200: expected},
+		},
+	}
+}
+
+func resultWithCookies(headers ...string) *models.Result {
+	return &models.Result{
+		ResponseStatusCode: 200,
+		ResponseHeaders:    map[string][]string{"Set-Cookie": headers},
+	}
+}
+
+func TestCheckCookies(t *testing.T) {
+	test := testWithCookies(map[string]string{
+		"session":     "set",
+		"remember_me": "cleared",
+		"legacy":      "cleared",
+		"theme":       "$matchRegexp(^(dark|light)$)",
+		"tracking":    "absent",
+	})
+
+	errs, err := NewChecker().Check(test, resultWithCookies(
+		"session=; Path=/",
+		"session=abc123; Path=/; HttpOnly; Secure",
+		"remember_me=; Max-Age=0",
+		"legacy=deleted; Expires=Thu, 01 Jan 1970 00:00:00 GMT",
+		"theme=dark",
+		"lang=en",
+	))
+	require.NoError(t, err)
+	assert.Empty(t, errs)
+}
+
+func TestCheckCookiesMismatch(t *testing.T) {
+	for expected, message := range map[string]string{
+		"set":     "response clears cookie session, expected it to be set",
+		"cleared": "response sets cookie remember_me, expected it to be cleared with Max-Age <= 0 or Expires in the past",
+		"absent":  "response must not set cookie theme",
+		"light":   "response cookie theme value dark does not match expected light",
+	} {
+		name := map[string]string{"set": "session", "cleared": "remember_me", "absent": "theme", "light": "theme"}[expected]
+		errs, err := NewChecker().Check(testWithCookies(map[string]string{name: expected}), resultWithCookies(
+			"session=abc123; Max-Age=-1",
+			"remember_me=token; Max-Age=3600; Expires=Thu, 01 Jan 1970 00:00:00 GMT",
+			"theme=dark",
+		))
+		require.NoError(t, err)
+		require.Len(t, errs, 1, expected)
+		assert.EqualError(t, errs[0], message)
+	}
+}
+
+func TestCheckMissingCookie(t *testing.T) {
+	errs, err := NewChecker().Check(testWithCookies(map[string]string{"session": "set"}), resultWithCookies("csrf=1"))
+	require.NoError(t, err)
+	require.Len(t, errs, 1)
+	assert.EqualError(t, errs[0], "response does not set cookie session")
+
+	errs, err = NewChecker().Check(testWithCookies(map[string]string{"session": "set"}), resultWithCookies("session="))
+	require.NoError(t, err)
+	require.Len(t, errs, 1)
+	assert.EqualError(t, errs[0], "response sets cookie session with an empty value")
+}
+
+func TestSkipOtherStatusCodes(t *testing.T) {
+	result := resultWithCookies()
+	result.ResponseStatusCode = 401
+	errs, err := NewChecker().Check(testWithCookies(map[string]string{"session": "set"}), result)
+	require.NoError(t, err)
+	assert.Empty(t, errs)
+}
@@ -0,0 +1,157 @@
+package response_db
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// readDbFile reads the expected DB response rows from YAML or CSV file,
+// the rows are returned as JSON strings, the same as inline dbResponse
+func readDbFile(path string) ([]string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []interface{}
+	if isCsvFile(path) {
+		rows, err = parseCsvRows(data)
+	} else {
+		err = yaml.Unmarshal(data, &rows)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse expected DB file %s: %s", path, err)
+	}
+
+	res := make([]string, 0, len(rows))
+	for i, row := range rows {
+		rowJson, err := json.Marshal(row)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse expected DB file %s: row #%d: %s", path, i, err)
+		}
+		res = append(res, string(rowJson))
+	}
+	return res, nil
+}
+
+// parseCsvRows reads CSV with column names in the first line,
+// the values which are valid JSON (numbers, booleans, null, quoted strings) are decoded
+func parseCsvRows(data []byte) ([]interface{}, error) {
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	columns := records[0]
+	rows := make([]interface{}, 0, len(records)-1)
+	for _, record := range records[1:] {
+		row := make(map[string]interface{}, len(columns))
+		for i, column := range columns {
+			var value interface{}
+			if err := json.Unmarshal([]byte(record[i]), &value); err != nil {
+				value = record[i]
+			}
+			row[column] = value
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// writeDbFile rewrites the expected DB file with the actual rows in the format chosen by the file extension
+func writeDbFile(path string, actual []string) error {
+	rows := make([]interface{}, 0, len(actual))
+	for _, rowJson := range actual {
+		var row interface{}
+		if err := json.Unmarshal([]byte(rowJson), &row); err != nil {
+			return fmt.Errorf("unable to update expected DB file %s: %s", path, err)
+		}
+		rows = append(rows, row)
+	}
+
+	var data []byte
+	var err error
+	if isCsvFile(path) {
+		data, err = formatCsvRows(rows)
+	} else {
+		data, err = yaml.Marshal(rows)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to update expected DB file %s: %s", path, err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("unable to update expected DB file %s: %s", path, err)
+	}
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("unable to update expected DB file %s: %s", path, err)
+	}
+
+	fmt.Printf("Expected DB file %s updated\n", path)
+	return nil
+}
+
+func formatCsvRows(rows []interface{}) ([]byte, error) {
+	columnsSet := map[string]bool{}
+	for _, row := range rows {
+		fields, ok := row.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("DB row is not an object: %v", row)
+		}
+		for column := range fields {
+			columnsSet[column] = true
+		}
+	}
+	columns := make([]string, 0, len(columnsSet))
+	for column := range columnsSet {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	buf := &bytes.Buffer{}
+	w := csv.NewWriter(buf)
+	if err := w.Write(columns); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		fields := row.(map[string]interface{})
+		record := make([]string, len(columns))
+		for i, column := range columns {
+			value, err := formatCsvValue(fields[column])
+			if err != nil {
+				return nil, err
+			}
+			record[i] = value
+		}
+		if err := w.Write(record); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// formatCsvValue writes strings as is unless they can be read back as another JSON value
+func formatCsvValue(value interface{}) (string, error) {
+	if s, ok := value.(string); ok && !json.Valid([]byte(s)) {
+		return s, nil
+	}
+	encoded, err := json.Marshal(value)
+	return string(encoded), err
+}
+
+func isCsvFile(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".csv")
+}
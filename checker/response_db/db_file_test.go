@@ -0,0 +1,54 @@
+package response_db
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadDbFileYaml(t *testing.T) {
+	rows, err := readDbFile("testdata/orders.yaml")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		`{"id":1,"status":"new"}`,
+		`{"comment":null,"id":2,"status":"shipped"}`,
+	}, rows)
+}
+
+func TestReadDbFileCsv(t *testing.T) {
+	rows, err := readDbFile("testdata/orders.csv")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		`{"comment":"","id":1,"status":"new"}`,
+		`{"comment":null,"id":2,"status":"shipped"}`,
+	}, rows)
+}
+
+func TestWriteDbFileRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gonkey-db-file")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	actual := []string{
+		`{"id":1,"code":"42","status":"new","tags":["a","b"]}`,
+		`{"id":2,"code":"x","status":null,"tags":[]}`,
+	}
+
+	for _, name := range []string{"rows.yaml", "rows.csv"} {
+		path := filepath.Join(dir, "expected", name)
+		require.NoError(t, writeDbFile(path, actual))
+
+		rows, err := readDbFile(path)
+		require.NoError(t, err)
+
+		errs, err := compareDbResponse("test", false, false, "SELECT 1", rows, actual)
+		require.NoError(t, err)
+		assert.Empty(t, errs, name)
+	}
+}
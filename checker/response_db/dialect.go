@@ -0,0 +1,132 @@
+package response_db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lamoda/gonkey/fixtures"
+)
+
+// Dialect tells how the rows of the DB are converted to JSON and which placeholders
+// the params of the queries are bound to
+type Dialect int
+
+const (
+	// Postgres converts the rows with row_to_json, the params are bound to $1, $2, ...
+	Postgres Dialect = iota
+	// Mysql converts the rows in Go by the types of the columns, the params are bound to ?
+	Mysql
+	// Clickhouse converts the rows with formatRowNoNewline, the params are bound to ?
+	Clickhouse
+	// Generic converts the rows in Go as they are scanned by the driver, e.g. for SQLite,
+	// the placeholders are up to the driver
+	Generic
+)
+
+// DialectOf returns the dialect of the DB of the fixtures, PostgreSQL for the custom loaders
+func DialectOf(dbType fixtures.DbType) Dialect {
+	switch dbType {
+	case fixtures.Mysql:
+		return Mysql
+	case fixtures.Clickhouse:
+		return Clickhouse
+	default:
+		return Postgres
+	}
+}
+
+// wrap returns the query selecting the rows of dbQuery as JSON with the functions of the DB,
+// it's empty if the rows are converted in Go
+func (d Dialect) wrap(dbQuery string) string {
+	switch d {
+	case Postgres:
+		return fmt.Sprintf("SELECT row_to_json(rows) FROM (%s) rows;", dbQuery)
+	case Clickhouse:
+		// the 64-bit integers are kept as numbers to be compared the same way as in Postgres
+		return fmt.Sprintf(
+			"SELECT formatRowNoNewline('JSONEachRow', *) FROM (%s) SETTINGS output_format_json_quote_64bit_integers = 0",
+			dbQuery,
+		)
+	default:
+		return ""
+	}
+}
+
+// scanJSONRows converts the rows to the JSON objects of their columns,
+// the values are converted by the types of the columns as the text protocol of MySQL returns them as bytes
+func scanJSONRows(rows *sql.Rows) ([]string, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	typeNames := make([]string, len(columns))
+	if types, err := rows.ColumnTypes(); err == nil && len(types) == len(columns) {
+		for i, ct := range types {
+			typeNames[i] = ct.DatabaseTypeName()
+		}
+	}
+
+	var dbResponse []string
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, err
+		}
+
+		// the columns are kept in the order of the query
+		var b strings.Builder
+		b.WriteByte('{')
+		for i, name := range columns {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			key, _ := json.Marshal(name)
+			value, err := json.Marshal(jsonValue(typeNames[i], values[i]))
+			if err != nil {
+				return nil, fmt.Errorf("unable to convert column %s to JSON: %s", name, err)
+			}
+			b.Write(key)
+			b.WriteByte(':')
+			b.Write(value)
+		}
+		b.WriteByte('}')
+		dbResponse = append(dbResponse, b.String())
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return dbResponse, nil
+}
+
+// jsonValue converts the value of the column to the value marshaled to JSON,
+// the numbers and the JSON documents returned as bytes are kept as numbers and documents
+func jsonValue(typeName string, value interface{}) interface{} {
+	switch v := value.(type) {
+	case []byte:
+		s := string(v)
+		switch typeName = strings.TrimPrefix(strings.ToUpper(typeName), "UNSIGNED "); typeName {
+		case "TINYINT", "SMALLINT", "MEDIUMINT", "INT", "INTEGER", "BIGINT", "YEAR",
+			"DECIMAL", "NUMERIC", "FLOAT", "DOUBLE", "REAL":
+			if _, err := strconv.ParseFloat(s, 64); err == nil {
+				return json.Number(s)
+			}
+		case "JSON":
+			if json.Valid(v) {
+				return json.RawMessage(v)
+			}
+		}
+		return s
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	default:
+		return v
+	}
+}
@@ -0,0 +1,140 @@
+package response_db
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+
+	"github.com/lamoda/gonkey/compare"
+)
+
+// compareRows compares the rows column by column, the error of a mismatched row lists only
+// the columns which differ, the full rows are added to it if verbose is set.
+// With ignoreOrdering the rows equal to the expected ones are matched first, the rest are paired in order.
+func compareRows(query string, expected, actual []interface{}, ignoreOrdering, verbose bool) []error {
+	indexes := make([]int, len(expected))
+	for i := range expected {
+		indexes[i] = i
+	}
+	if ignoreOrdering {
+		indexes = matchRows(expected, actual)
+	}
+
+	var errs []error
+	for i, row := range expected {
+		columns := diffColumns(row, actual[indexes[i]])
+		if len(columns) == 0 {
+			continue
+		}
+
+		msg := fmt.Sprintf(
+			"DB row #%d does not match\n     test query:\n%s\n   column diff:\n%s",
+			i,
+			color.CyanString("%v", query),
+			strings.Join(columns, "\n"),
+		)
+		if verbose {
+			msg += fmt.Sprintf(
+				"\n  expected row:\n%s\n    actual row:\n%s",
+				color.GreenString("%s", formatValue(row)),
+				color.RedString("%s", formatValue(actual[indexes[i]])),
+			)
+		}
+		errs = append(errs, errors.New(msg))
+	}
+	return errs
+}
+
+// matchRows returns the indexes of the actual rows paired with the expected ones
+func matchRows(expected, actual []interface{}) []int {
+	indexes := make([]int, len(expected))
+	matched := make([]bool, len(actual))
+	var unmatched []int
+	for i, row := range expected {
+		indexes[i] = -1
+		for j := range actual {
+			if !matched[j] && len(compare.Compare(row, actual[j], compare.CompareParams{})) == 0 {
+				indexes[i] = j
+				matched[j] = true
+				break
+			}
+		}
+		if indexes[i] == -1 {
+			unmatched = append(unmatched, i)
+		}
+	}
+
+	j := 0
+	for _, i := range unmatched {
+		for matched[j] {
+			j++
+		}
+		indexes[i] = j
+		matched[j] = true
+	}
+	return indexes
+}
+
+// diffColumns returns the lines describing the columns of the row which don't match the expected ones,
+// the rows which aren't JSON objects are compared as a whole
+func diffColumns(expected, actual interface{}) []string {
+	expectedRow, ok := expected.(map[string]interface{})
+	actualRow, actualOk := actual.(map[string]interface{})
+	if !ok || !actualOk {
+		if len(compare.Compare(expected, actual, compare.CompareParams{})) == 0 {
+			return nil
+		}
+		return []string{fmt.Sprintf(
+			"       expected %s, actual %s",
+			color.GreenString("%s", formatValue(expected)),
+			color.RedString("%s", formatValue(actual)),
+		)}
+	}
+
+	names := make([]string, 0, len(expectedRow))
+	for name := range expectedRow {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var lines []string
+	for _, name := range names {
+		expectedColumn := map[string]interface{}{name: expectedRow[name]}
+		actualColumn := map[string]interface{}{}
+		value, present := actualRow[name]
+		if present {
+			actualColumn[name] = value
+		}
+		if len(compare.Compare(expectedColumn, actualColumn, compare.CompareParams{})) == 0 {
+			continue
+		}
+
+		if !present {
+			lines = append(lines, fmt.Sprintf(
+				"       %s: expected %s, the column is missing",
+				name,
+				color.GreenString("%s", formatValue(expectedRow[name])),
+			))
+			continue
+		}
+		lines = append(lines, fmt.Sprintf(
+			"       %s: expected %s, actual %s",
+			name,
+			color.GreenString("%s", formatValue(expectedRow[name])),
+			color.RedString("%s", formatValue(value)),
+		))
+	}
+	return lines
+}
+
+func formatValue(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(data)
+}
@@ -0,0 +1,82 @@
+package response_db
+
+import (
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareDbResponseReportsChangedColumns(t *testing.T) {
+	color.NoColor = true
+
+	expected := []string{
+		`{"id": 1, "status": "paid", "total": 100, "comment": "$matchRegexp(^ok)"}`,
+		`{"id": 2, "status": "new", "total": 50, "deleted_at": null}`,
+	}
+	actual := []string{
+		`{"id":1,"status":"new","total":90,"comment":"ok, thanks"}`,
+		`{"id":2,"status":"new","total":50}`,
+	}
+
+	errs, err := compareDbResponse("test", false, false, "SELECT * FROM orders", expected, actual)
+	require.NoError(t, err)
+	require.Len(t, errs, 2)
+	assert.Equal(t, "DB row #0 does not match\n     test query:\nSELECT * FROM orders\n   column diff:\n"+
+		"       status: expected \"paid\", actual \"new\"\n"+
+		"       total: expected 100, actual 90", errs[0].Error())
+	assert.Equal(t, "DB row #1 does not match\n     test query:\nSELECT * FROM orders\n   column diff:\n"+
+		"       deleted_at: expected null, the column is missing", errs[1].Error())
+}
+
+func TestCompareDbResponseVerbose(t *testing.T) {
+	color.NoColor = true
+
+	errs, err := compareDbResponse("test", false, true, "SELECT 1", []string{`{"id": 1}`}, []string{`{"id":2}`})
+	require.NoError(t, err)
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "  expected row:\n{\"id\":1}\n    actual row:\n{\"id\":2}")
+}
+
+func TestCompareDbResponseIgnoreOrdering(t *testing.T) {
+	color.NoColor = true
+
+	expected := []string{`{"id": 1, "status": "paid"}`, `{"id": 2, "status": "new"}`}
+	actual := []string{`{"id":2,"status":"new"}`, `{"id":1,"status":"canceled"}`}
+
+	errs, err := compareDbResponse("test", true, false, "SELECT 1", expected, actual)
+	require.NoError(t, err)
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "DB row #0 does not match")
+	assert.Contains(t, errs[0].Error(), "status: expected \"paid\", actual \"canceled\"")
+}
+
+func TestCompareDbResponseNoRows(t *testing.T) {
+	color.NoColor = true
+
+	errs, err := compareDbResponse("test", false, false, "SELECT * FROM orders WHERE deleted_at IS NULL", []string{}, nil)
+	require.NoError(t, err)
+	assert.Empty(t, errs)
+
+	actual := []string{`{"id":1,"status":"new"}`, `{"id":2,"status":"paid"}`}
+	errs, err = compareDbResponse("test", false, false, "SELECT * FROM orders WHERE deleted_at IS NULL", []string{}, actual)
+	require.NoError(t, err)
+	require.Len(t, errs, 1)
+	assert.Equal(t, "DB query returned 2 rows, expected no rows\n     test query:\nSELECT * FROM orders WHERE deleted_at IS NULL\n"+
+		"   actual rows:\n{\"id\":1,\"status\":\"new\"}\n{\"id\":2,\"status\":\"paid\"}", errs[0].Error())
+}
+
+func TestCompareDbResponseLength(t *testing.T) {
+	color.NoColor = true
+
+	errs, err := compareDbResponse("test", false, false, "SELECT 1", []string{`{"id": 1}`}, nil)
+	require.NoError(t, err)
+	require.Len(t, errs, 1)
+	assert.NotContains(t, errs[0].Error(), "result diff")
+
+	errs, err = compareDbResponse("test", false, true, "SELECT 1", []string{`{"id": 1}`}, nil)
+	require.NoError(t, err)
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "result diff")
+}
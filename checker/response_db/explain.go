@@ -0,0 +1,51 @@
+package response_db
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// explains tells if the plan of the query is captured: only the checker of PostgreSQL captures the plans,
+// when the request of the test or the query of the check took longer than ExplainSlowerThan
+func (c *ResponseDbChecker) explains(requestDuration, queryDuration time.Duration) bool {
+	threshold := c.opts.ExplainSlowerThan
+	if threshold <= 0 || c.db == nil || c.opts.Clickhouse || c.opts.Dialect != Postgres {
+		return false
+	}
+	return requestDuration >= threshold || queryDuration >= threshold
+}
+
+// explain runs EXPLAIN ANALYZE of the query in a transaction which is rolled back, so that the query
+// can't change the data. The plan is a hint for the slow test and doesn't fail it, so the error
+// is returned in place of the plan.
+func (c *ResponseDbChecker) explain(dbQuery string, params []interface{}) string {
+	if idx := strings.IndexByte(dbQuery, ';'); idx >= 0 {
+		dbQuery = dbQuery[:idx]
+	}
+
+	tx, err := c.db.Begin()
+	if err != nil {
+		return fmt.Sprintf("unable to explain the query: %s", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	rows, err := tx.Query("EXPLAIN ANALYZE "+dbQuery, params...)
+	if err != nil {
+		return fmt.Sprintf("unable to explain the query: %s", err)
+	}
+	defer rows.Close()
+
+	var plan []string
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return fmt.Sprintf("unable to explain the query: %s", err)
+		}
+		plan = append(plan, line)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Sprintf("unable to explain the query: %s", err)
+	}
+	return strings.Join(plan, "\n")
+}
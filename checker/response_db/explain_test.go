@@ -0,0 +1,135 @@
+package response_db
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/lamoda/gonkey/models"
+	"github.com/lamoda/gonkey/testloader/yaml_file"
+)
+
+func explainTest() *yaml_file.Test {
+	return &yaml_file.Test{
+		TestDefinition: yaml_file.TestDefinition{DbQueryParamsVal: []interface{}{"paid"}},
+		DbQuery:        "SELECT id FROM orders WHERE status = $1;",
+		DbResponse:     []string{`{"id": 1}`},
+	}
+}
+
+func expectCheckQuery(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery("^" + regexp.QuoteMeta("SELECT row_to_json(rows) FROM (SELECT id FROM orders WHERE status = $1) rows;") + "$").
+		WithArgs("paid").
+		WillReturnRows(sqlmock.NewRows([]string{"row_to_json"}).AddRow(`{"id":1}`))
+}
+
+func TestExplainSlowTest(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	expectCheckQuery(mock)
+	mock.ExpectBegin()
+	mock.ExpectQuery("^" + regexp.QuoteMeta("EXPLAIN ANALYZE SELECT id FROM orders WHERE status = $1") + "$").
+		WithArgs("paid").
+		WillReturnRows(sqlmock.NewRows([]string{"QUERY PLAN"}).
+			AddRow("Seq Scan on orders  (cost=0.00..25.88 rows=6 width=4) (actual time=0.010..0.011 rows=1 loops=1)").
+			AddRow("  Filter: (status = 'paid'::text)"))
+	mock.ExpectRollback()
+
+	result := &models.Result{RequestDuration: 2 * time.Second}
+	checker := NewCheckerWithOptions(db, Options{ExplainSlowerThan: time.Second})
+	errs, err := checker.Check(explainTest(), result)
+	require.NoError(t, err)
+	assert.Empty(t, errs)
+
+	require.Len(t, result.DatabaseResult, 1)
+	assert.Equal(t,
+		"Seq Scan on orders  (cost=0.00..25.88 rows=6 width=4) (actual time=0.010..0.011 rows=1 loops=1)\n"+
+			"  Filter: (status = 'paid'::text)",
+		result.DatabaseResult[0].Plan,
+	)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExplainError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	expectCheckQuery(mock)
+	mock.ExpectBegin()
+	mock.ExpectQuery("^EXPLAIN ANALYZE").WillReturnError(errors.New("permission denied"))
+	mock.ExpectRollback()
+
+	result := &models.Result{RequestDuration: 2 * time.Second}
+	errs, err := NewCheckerWithOptions(db, Options{ExplainSlowerThan: time.Second}).Check(explainTest(), result)
+	require.NoError(t, err)
+	assert.Empty(t, errs)
+
+	require.Len(t, result.DatabaseResult, 1)
+	assert.Equal(t, "unable to explain the query: permission denied", result.DatabaseResult[0].Plan)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExplainSkipped(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   Options
+		result *models.Result
+	}{
+		{
+			name:   "disabled",
+			opts:   Options{},
+			result: &models.Result{RequestDuration: time.Hour},
+		},
+		{
+			name:   "fast test",
+			opts:   Options{ExplainSlowerThan: time.Hour},
+			result: &models.Result{RequestDuration: time.Millisecond},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer func() { _ = db.Close() }()
+			expectCheckQuery(mock)
+
+			errs, err := NewCheckerWithOptions(db, tt.opts).Check(explainTest(), tt.result)
+			require.NoError(t, err)
+			assert.Empty(t, errs)
+
+			require.Len(t, tt.result.DatabaseResult, 1)
+			assert.Empty(t, tt.result.DatabaseResult[0].Plan)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestExplainOnlyPostgres(t *testing.T) {
+	db, _, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	checker := &ResponseDbChecker{db: db, opts: Options{ExplainSlowerThan: time.Second}}
+	assert.True(t, checker.explains(time.Millisecond, time.Hour), "slow query")
+	assert.False(t, checker.explains(time.Millisecond, time.Millisecond))
+
+	for _, opts := range []Options{
+		{ExplainSlowerThan: time.Second, Dialect: Mysql},
+		{ExplainSlowerThan: time.Second, Dialect: Generic},
+		{ExplainSlowerThan: time.Second, Clickhouse: true},
+	} {
+		checker := &ResponseDbChecker{db: db, opts: opts}
+		assert.False(t, checker.explains(time.Hour, time.Hour))
+	}
+
+	selector := &ResponseDbChecker{selector: &selectorMock{}, opts: Options{ExplainSlowerThan: time.Second}}
+	assert.False(t, selector.explains(time.Hour, time.Hour), "selector")
+}
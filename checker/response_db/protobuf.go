@@ -0,0 +1,93 @@
+package response_db
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// decodeProtobufColumns replaces the bytea values of the columns with the JSON of the protobuf messages
+// decoded from them, the columns by the full names of their messages. The values of the columns whose messages
+// aren't registered in the options are replaced with base64 strings.
+func (c *ResponseDbChecker) decodeProtobufColumns(columns map[string]string, rows []string) ([]string, error) {
+	if len(columns) == 0 {
+		return rows, nil
+	}
+
+	decoded := make([]string, len(rows))
+	for i, row := range rows {
+		var values map[string]interface{}
+		decoder := json.NewDecoder(strings.NewReader(row))
+		decoder.UseNumber()
+		if err := decoder.Decode(&values); err != nil {
+			return nil, fmt.Errorf("invalid JSON in the actual DB response row #%d: %s", i, err)
+		}
+
+		for column, message := range columns {
+			value, ok := values[column].(string)
+			if !ok {
+				// the column isn't selected or is NULL
+				continue
+			}
+			decodedValue, err := c.decodeProtobufColumn(value, message)
+			if err != nil {
+				return nil, fmt.Errorf("unable to decode column %s of the DB response row #%d: %s", column, i, err)
+			}
+			values[column] = decodedValue
+		}
+
+		data, err := json.Marshal(values)
+		if err != nil {
+			return nil, err
+		}
+		decoded[i] = string(data)
+	}
+	return decoded, nil
+}
+
+func (c *ResponseDbChecker) decodeProtobufColumn(value, message string) (interface{}, error) {
+	// bytea is returned by row_to_json in the hex format
+	if !strings.HasPrefix(value, `\x`) {
+		return nil, fmt.Errorf("%q is not a bytea value in the hex format", value)
+	}
+	data, err := hex.DecodeString(value[2:])
+	if err != nil {
+		return nil, err
+	}
+
+	descriptor := c.protobufMessage(message)
+	if descriptor == nil {
+		return base64.StdEncoding.EncodeToString(data), nil
+	}
+
+	decoded := dynamicpb.NewMessage(descriptor)
+	if err := proto.Unmarshal(data, decoded); err != nil {
+		return nil, fmt.Errorf("could not parse the value as %s: %s", message, err)
+	}
+	jsonData, err := protojson.Marshal(decoded)
+	if err != nil {
+		return nil, err
+	}
+
+	var res interface{}
+	if err := json.Unmarshal(jsonData, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (c *ResponseDbChecker) protobufMessage(name string) protoreflect.MessageDescriptor {
+	for _, descriptor := range c.opts.ProtobufMessages {
+		if string(descriptor.FullName()) == name {
+			return descriptor
+		}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package response_db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/lamoda/gonkey/models"
+	"github.com/lamoda/gonkey/testloader/yaml_file"
+)
+
+// orderDescriptor describes the message
+//
+//	message Order {
+//	  int32 id = 1;
+//	  string name = 2;
+//	}
+func orderDescriptor(t *testing.T) protoreflect.MessageDescriptor {
+	field := func(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type) *descriptorpb.FieldDescriptorProto {
+		return &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			JsonName: proto.String(name),
+			Number:   proto.Int32(number),
+			Type:     typ.Enum(),
+			Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		}
+	}
+
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:    proto.String("shop/order.proto"),
+		Package: proto.String("shop"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("Order"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				field("id", 1, descriptorpb.FieldDescriptorProto_TYPE_INT32),
+				field("name", 2, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+			},
+		}},
+	}, nil)
+	require.NoError(t, err)
+	return file.Messages().ByName("Order")
+}
+
+func protobufColumnTest(dbResponse string) *yaml_file.Test {
+	return &yaml_file.Test{
+		TestDefinition: yaml_file.TestDefinition{
+			Name:              "protobuf column",
+			DbProtobufColumns: map[string]string{"payload": "shop.Order"},
+		},
+		DbQuery:    "SELECT id, payload FROM orders",
+		DbResponse: []string{dbResponse},
+	}
+}
+
+func TestProtobufColumn(t *testing.T) {
+	// {id: 42, name: "x"}
+	selector := &selectorMock{rows: []map[string]interface{}{{"id": 1, "payload": `\x082a120178`}}}
+	checker := NewSelectorChecker(selector, Options{ProtobufMessages: []protoreflect.MessageDescriptor{orderDescriptor(t)}})
+
+	test := protobufColumnTest(`{"id": 1, "payload": {"id": 42, "name": "$matchRegexp(^x$)"}}`)
+	errs, err := checker.Check(test, &models.Result{})
+	require.NoError(t, err)
+	assert.Empty(t, errs)
+
+	test = protobufColumnTest(`{"id": 1, "payload": {"id": 43, "name": "x"}}`)
+	errs, err = checker.Check(test, &models.Result{})
+	require.NoError(t, err)
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "payload")
+}
+
+func TestProtobufColumnWithoutDescriptor(t *testing.T) {
+	selector := &selectorMock{rows: []map[string]interface{}{{"id": 1, "payload": `\x082a120178`}}}
+
+	errs, err := NewSelectorChecker(selector, Options{}).Check(
+		protobufColumnTest(`{"id": 1, "payload": "CCoSAXg="}`), &models.Result{},
+	)
+	require.NoError(t, err)
+	assert.Empty(t, errs)
+}
+
+func TestProtobufColumnInvalidValue(t *testing.T) {
+	selector := &selectorMock{rows: []map[string]interface{}{{"id": 1, "payload": "text"}}}
+
+	errs, err := NewSelectorChecker(selector, Options{}).Check(
+		protobufColumnTest(`{"id": 1, "payload": "dGV4dA=="}`), &models.Result{},
+	)
+	require.NoError(t, err)
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "unable to decode column payload of the DB response row #0")
+}
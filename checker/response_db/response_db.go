@@ -3,19 +3,50 @@ package response_db
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
 	"strings"
+	"time"
 
 	"github.com/lamoda/gonkey/checker"
-	"github.com/lamoda/gonkey/compare"
 	"github.com/lamoda/gonkey/models"
 
 	"github.com/fatih/color"
 	"github.com/kylelemons/godebug/pretty"
+	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
+// Options of the checker
+type Options struct {
+	// UpdateGolden makes the checker rewrite expected DB files (expectedDbFile) with actual rows
+	// when they differ, instead of failing the test
+	UpdateGolden bool
+	// Clickhouse makes the checker convert the rows to JSON with the functions of ClickHouse,
+	// the same as Dialect: Clickhouse
+	Clickhouse bool
+	// Dialect of the DB, see DialectOf, by default row_to_json of Postgres is used
+	Dialect Dialect
+	// ProtobufMessages are the descriptors of the protobuf messages stored in the bytea columns
+	// of dbProtobufColumns, e.g. (&pb.Order{}).ProtoReflect().Descriptor()
+	ProtobufMessages []protoreflect.MessageDescriptor
+	// ExplainSlowerThan enables capturing the plans of the queries of PostgreSQL with EXPLAIN ANALYZE
+	// when the request of the test or the query took longer, the plans are attached to the DB results
+	ExplainSlowerThan time.Duration
+	// Verbose adds the full expected and actual rows to the errors,
+	// by default only the columns which differ are reported
+	Verbose bool
+}
+
+// Selector runs the queries on the storages without database/sql, e.g. storage/cassandra.Client
+type Selector interface {
+	Select(query string) ([]map[string]interface{}, error)
+}
+
 type ResponseDbChecker struct {
-	db *sql.DB
+	db       *sql.DB
+	selector Selector
+	opts     Options
 }
 
 func NewChecker(dbConnect *sql.DB) checker.CheckerInterface {
@@ -24,16 +55,36 @@ func NewChecker(dbConnect *sql.DB) checker.CheckerInterface {
 	}
 }
 
+func NewCheckerWithOptions(dbConnect *sql.DB, opts Options) checker.CheckerInterface {
+	return &ResponseDbChecker{
+		db:   dbConnect,
+		opts: opts,
+	}
+}
+
+// NewSelectorChecker makes the checker running the queries with the selector,
+// each row is compared as the JSON object of its columns
+func NewSelectorChecker(selector Selector, opts Options) checker.CheckerInterface {
+	return &ResponseDbChecker{
+		selector: selector,
+		opts:     opts,
+	}
+}
+
+func (c *ResponseDbChecker) Name() string {
+	return "response_db"
+}
+
 func (c *ResponseDbChecker) Check(t models.TestInterface, result *models.Result) ([]error, error) {
 	var errors []error
-	errs, err := c.check(t.GetName(), t.IgnoreDbOrdering(), t, result)
+	errs, err := c.check(t.GetName(), t.IgnoreDbOrdering(), t.GetDbProtobufColumns(), t, result)
 	if err != nil {
 		return nil, err
 	}
 	errors = append(errors, errs...)
 
 	for _, dbCheck := range t.GetDatabaseChecks() {
-		errs, err := c.check(t.GetName(), t.IgnoreDbOrdering(), dbCheck, result)
+		errs, err := c.check(t.GetName(), t.IgnoreDbOrdering(), t.GetDbProtobufColumns(), dbCheck, result)
 		if err != nil {
 			return nil, err
 		}
@@ -46,13 +97,14 @@ func (c *ResponseDbChecker) Check(t models.TestInterface, result *models.Result)
 func (c *ResponseDbChecker) check(
 	testName string,
 	ignoreOrdering bool,
+	protobufColumns map[string]string,
 	t models.DatabaseCheck,
 	result *models.Result,
 ) ([]error, error) {
 	var errors []error
 
 	// don't check if there are no data for db test
-	if t.DbQueryString() == "" && t.DbResponseJson() == nil {
+	if t.DbQueryString() == "" && t.DbResponseJson() == nil && t.DbResponseFile() == "" {
 		return errors, nil
 	}
 
@@ -62,41 +114,81 @@ func (c *ResponseDbChecker) check(
 	}
 
 	// check expected response exist
-	if t.DbResponseJson() == nil {
+	if t.DbResponseJson() == nil && t.DbResponseFile() == "" {
 		return nil, fmt.Errorf("expected DB response not found for test \"%s\"", testName)
 	}
+	if t.DbResponseJson() != nil && t.DbResponseFile() != "" {
+		return nil, fmt.Errorf("both dbResponse and expectedDbFile are set for test \"%s\"", testName)
+	}
 
 	// get DB response
-	actualDbResponse, err := newQuery(t.DbQueryString(), c.db)
+	queryStart := time.Now()
+	actualDbResponse, err := c.query(t.DbQueryString(), t.DbQueryParams())
 	if err != nil {
 		return nil, err
 	}
+	queryDuration := time.Since(queryStart)
+	actualDbResponse, err = c.decodeProtobufColumns(protobufColumns, actualDbResponse)
+	if err != nil {
+		return []error{err}, nil
+	}
 
-	result.DatabaseResult = append(
-		result.DatabaseResult,
-		models.DatabaseResult{Query: t.DbQueryString(), Response: actualDbResponse},
-	)
+	dbResult := models.DatabaseResult{Query: t.DbQueryString(), Response: actualDbResponse}
+	if c.explains(result.RequestDuration, queryDuration) {
+		dbResult.Plan = c.explain(t.DbQueryString(), t.DbQueryParams())
+	}
+	result.DatabaseResult = append(result.DatabaseResult, dbResult)
+
+	if t.DbResponseFile() == "" {
+		return compareDbResponse(testName, ignoreOrdering, c.opts.Verbose, t.DbQueryString(), t.DbResponseJson(), actualDbResponse)
+	}
+
+	expectedDbResponse, err := readDbFile(t.DbResponseFile())
+	if err != nil {
+		if os.IsNotExist(err) && c.opts.UpdateGolden {
+			return nil, writeDbFile(t.DbResponseFile(), actualDbResponse)
+		}
+		return nil, fmt.Errorf("unable to read expected DB file for test \"%s\": %s", testName, err)
+	}
+
+	errs, err := compareDbResponse(testName, ignoreOrdering, c.opts.Verbose, t.DbQueryString(), expectedDbResponse, actualDbResponse)
+	if err != nil || len(errs) == 0 || !c.opts.UpdateGolden {
+		return errs, err
+	}
+
+	return nil, writeDbFile(t.DbResponseFile(), actualDbResponse)
+}
+
+func compareDbResponse(
+	testName string,
+	ignoreOrdering, verbose bool,
+	query string,
+	expected, actual []string,
+) ([]error, error) {
+	var errors []error
+
+	// the empty expected response asserts that the query returns no rows, e.g. the deleted ones
+	if len(expected) == 0 && len(actual) != 0 {
+		errors = append(errors, unexpectedRowsError(query, actual))
+		return errors, nil
+	}
 
 	// compare responses length
-	if err := compareDbResponseLength(t.DbResponseJson(), actualDbResponse, t.DbQueryString()); err != nil {
+	if err := compareDbResponseLength(expected, actual, query, verbose); err != nil {
 		errors = append(errors, err)
 		return errors, nil
 	}
 	// compare responses as json lists
-	expectedItems, err := toJsonArray(t.DbResponseJson(), "expected", testName)
+	expectedItems, err := toJsonArray(expected, "expected", testName)
 	if err != nil {
 		return nil, err
 	}
-	actualItems, err := toJsonArray(actualDbResponse, "actual", testName)
+	actualItems, err := toJsonArray(actual, "actual", testName)
 	if err != nil {
 		return nil, err
 	}
 
-	errs := compare.Compare(expectedItems, actualItems, compare.CompareParams{
-		IgnoreArraysOrdering: ignoreOrdering,
-	})
-
-	errors = append(errors, errs...)
+	errors = append(errors, compareRows(query, expectedItems, actualItems, ignoreOrdering, verbose)...)
 
 	return errors, nil
 }
@@ -120,22 +212,48 @@ func toJsonArray(items []string, qual, testName string) ([]interface{}, error) {
 	return itemJSONs, nil
 }
 
-func compareDbResponseLength(expected, actual []string, query interface{}) error {
-	var err error
+// compareDbResponseLength checks the number of the rows, the diff of the rows is shown if verbose is set
+func unexpectedRowsError(query interface{}, actual []string) error {
+	return fmt.Errorf(
+		"DB query returned %s rows, expected no rows\n     test query:\n%s\n   actual rows:\n%s",
+		color.CyanString("%v", len(actual)),
+		color.CyanString("%v", query),
+		color.RedString("%s", strings.Join(actual, "\n")),
+	)
+}
+
+func compareDbResponseLength(expected, actual []string, query interface{}, verbose bool) error {
+	if len(expected) == len(actual) {
+		return nil
+	}
 
-	if len(expected) != len(actual) {
-		err = fmt.Errorf(
-			"quantity of items in database do not match (-expected: %s +actual: %s)\n     test query:\n%s\n    result diff:\n%s",
-			color.CyanString("%v", len(expected)),
-			color.CyanString("%v", len(actual)),
-			color.CyanString("%v", query),
-			color.CyanString("%v", pretty.Compare(expected, actual)),
-		)
+	msg := fmt.Sprintf(
+		"quantity of items in database do not match (-expected: %s +actual: %s)\n     test query:\n%s",
+		color.CyanString("%v", len(expected)),
+		color.CyanString("%v", len(actual)),
+		color.CyanString("%v", query),
+	)
+	if verbose {
+		msg += fmt.Sprintf("\n    result diff:\n%s", color.CyanString("%v", pretty.Compare(expected, actual)))
 	}
-	return err
+	return errors.New(msg)
 }
 
-func newQuery(dbQuery string, db *sql.DB) ([]string, error) {
+func (c *ResponseDbChecker) query(dbQuery string, params []interface{}) ([]string, error) {
+	if c.selector != nil {
+		if len(params) > 0 {
+			return nil, errors.New("dbQueryParams are not supported by the storage")
+		}
+		return selectQuery(dbQuery, c.selector)
+	}
+	dialect := c.opts.Dialect
+	if c.opts.Clickhouse {
+		dialect = Clickhouse
+	}
+	return newQuery(dbQuery, params, c.db, dialect)
+}
+
+func newQuery(dbQuery string, params []interface{}, db *sql.DB, dialect Dialect) ([]string, error) {
 
 	var dbResponse []string
 	var jsonString string
@@ -144,7 +262,17 @@ func newQuery(dbQuery string, db *sql.DB) ([]string, error) {
 		dbQuery = dbQuery[:idx]
 	}
 
-	rows, err := db.Query(fmt.Sprintf("SELECT row_to_json(rows) FROM (%s) rows;", dbQuery))
+	query := dialect.wrap(dbQuery)
+	if query == "" {
+		rows, err := db.Query(dbQuery, params...)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		return scanJSONRows(rows)
+	}
+
+	rows, err := db.Query(query, params...)
 	if err != nil {
 		return nil, err
 	}
@@ -164,3 +292,24 @@ func newQuery(dbQuery string, db *sql.DB) ([]string, error) {
 
 	return dbResponse, nil
 }
+
+func selectQuery(dbQuery string, selector Selector) ([]string, error) {
+	if idx := strings.IndexByte(dbQuery, ';'); idx >= 0 {
+		dbQuery = dbQuery[:idx]
+	}
+
+	rows, err := selector.Select(dbQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	dbResponse := make([]string, 0, len(rows))
+	for _, row := range rows {
+		jsonRow, err := json.Marshal(row)
+		if err != nil {
+			return nil, fmt.Errorf("unable to convert the row to JSON: %s", err)
+		}
+		dbResponse = append(dbResponse, string(jsonRow))
+	}
+	return dbResponse, nil
+}
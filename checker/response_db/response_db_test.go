@@ -0,0 +1,146 @@
+package response_db
+
+import (
+	"encoding/json"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/lamoda/gonkey/fixtures"
+	"github.com/lamoda/gonkey/models"
+	"github.com/lamoda/gonkey/testloader/yaml_file"
+)
+
+type selectorMock struct {
+	queries []string
+	rows    []map[string]interface{}
+}
+
+func (s *selectorMock) Select(query string) ([]map[string]interface{}, error) {
+	s.queries = append(s.queries, query)
+	return s.rows, nil
+}
+
+func TestNewQueryPostgres(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("^" + regexp.QuoteMeta("SELECT row_to_json(rows) FROM (SELECT id FROM orders) rows;") + "$").
+		WillReturnRows(sqlmock.NewRows([]string{"row_to_json"}).AddRow(`{"id":1}`))
+
+	rows, err := newQuery("SELECT id FROM orders;", nil, db, Postgres)
+	require.NoError(t, err)
+	assert.Equal(t, []string{`{"id":1}`}, rows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestNewQueryClickhouse(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("^" + regexp.QuoteMeta(
+		"SELECT formatRowNoNewline('JSONEachRow', *) FROM (SELECT id, name FROM events) "+
+			"SETTINGS output_format_json_quote_64bit_integers = 0",
+	) + "$").
+		WillReturnRows(sqlmock.NewRows([]string{"row"}).
+			AddRow(`{"id":1,"name":"view"}`).
+			AddRow(`{"id":2,"name":"click"}`))
+
+	rows, err := newQuery("SELECT id, name FROM events", nil, db, Clickhouse)
+	require.NoError(t, err)
+	assert.Equal(t, []string{`{"id":1,"name":"view"}`, `{"id":2,"name":"click"}`}, rows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestNewQueryPostgresParams(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("^" + regexp.QuoteMeta("SELECT row_to_json(rows) FROM (SELECT id FROM orders WHERE status = $1) rows;") + "$").
+		WithArgs("paid").
+		WillReturnRows(sqlmock.NewRows([]string{"row_to_json"}).AddRow(`{"id":1}`))
+
+	rows, err := newQuery("SELECT id FROM orders WHERE status = $1", []interface{}{"paid"}, db, Postgres)
+	require.NoError(t, err)
+	assert.Equal(t, []string{`{"id":1}`}, rows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestNewQueryMysql(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	// the query is run as is, rows is a reserved word in MySQL
+	mock.ExpectQuery("^" + regexp.QuoteMeta("SELECT name, id, deleted_at FROM users WHERE id > ?") + "$").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"name", "id", "deleted_at"}).
+			AddRow([]byte("john"), int64(2), nil).
+			AddRow("jane", int64(3), nil))
+
+	rows, err := newQuery("SELECT name, id, deleted_at FROM users WHERE id > ?;", []interface{}{1}, db, Mysql)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		`{"name":"john","id":2,"deleted_at":null}`,
+		`{"name":"jane","id":3,"deleted_at":null}`,
+	}, rows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestJSONValue(t *testing.T) {
+	created := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	for _, tc := range []struct {
+		typeName string
+		value    interface{}
+		expected string
+	}{
+		{"INT", []byte("42"), `42`},
+		{"UNSIGNED BIGINT", []byte("18446744073709551615"), `18446744073709551615`},
+		{"DECIMAL", []byte("10.50"), `10.50`},
+		{"JSON", []byte(`{"a": [1, 2]}`), `{"a":[1,2]}`},
+		{"VARCHAR", []byte("42"), `"42"`},
+		{"", []byte("text"), `"text"`},
+		{"INTEGER", int64(7), `7`},
+		{"REAL", 1.5, `1.5`},
+		{"DATETIME", created, `"2021-03-04T05:06:07Z"`},
+		{"TEXT", nil, `null`},
+	} {
+		actual, err := json.Marshal(jsonValue(tc.typeName, tc.value))
+		require.NoError(t, err)
+		assert.Equal(t, tc.expected, string(actual), tc.typeName)
+	}
+}
+
+func TestDialectOf(t *testing.T) {
+	assert.Equal(t, Postgres, DialectOf(fixtures.Postgres))
+	assert.Equal(t, Mysql, DialectOf(fixtures.Mysql))
+	assert.Equal(t, Clickhouse, DialectOf(fixtures.Clickhouse))
+	assert.Equal(t, Postgres, DialectOf(fixtures.CustomLoader))
+}
+
+func TestSelectorChecker(t *testing.T) {
+	selector := &selectorMock{rows: []map[string]interface{}{
+		{"id": 1, "tags": []string{"a", "b"}, "attrs": map[string]string{"color": "red"}},
+	}}
+	test := &yaml_file.Test{
+		DbQuery:    "SELECT id, tags, attrs FROM shop.users WHERE id = 1;",
+		DbResponse: []string{`{"id": 1, "tags": ["a", "b"], "attrs": {"color": "red"}}`},
+	}
+
+	errs, err := NewSelectorChecker(selector, Options{}).Check(test, &models.Result{})
+	require.NoError(t, err)
+	assert.Empty(t, errs)
+	assert.Equal(t, []string{"SELECT id, tags, attrs FROM shop.users WHERE id = 1"}, selector.queries)
+
+	test.DbResponse = []string{`{"id": 1, "tags": ["a"], "attrs": {"color": "red"}}`}
+	errs, err = NewSelectorChecker(selector, Options{}).Check(test, &models.Result{})
+	require.NoError(t, err)
+	assert.NotEmpty(t, errs)
+}
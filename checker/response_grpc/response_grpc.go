@@ -0,0 +1,289 @@
+package response_grpc
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/lamoda/gonkey/checker"
+	"github.com/lamoda/gonkey/compare"
+	"github.com/lamoda/gonkey/models"
+)
+
+// names of the gRPC status codes by number
+var codeNames = []string{
+	"OK",
+	"CANCELLED",
+	"UNKNOWN",
+	"INVALID_ARGUMENT",
+	"DEADLINE_EXCEEDED",
+	"NOT_FOUND",
+	"ALREADY_EXISTS",
+	"PERMISSION_DENIED",
+	"RESOURCE_EXHAUSTED",
+	"FAILED_PRECONDITION",
+	"ABORTED",
+	"OUT_OF_RANGE",
+	"UNIMPLEMENTED",
+	"INTERNAL",
+	"UNAVAILABLE",
+	"DATA_LOSS",
+	"UNAUTHENTICATED",
+}
+
+// ResponseGrpcStatusChecker compares the gRPC status of the response with responseGrpcStatus of the test:
+// the code, the message and the messages of the details decoded from grpc-status-details-bin
+type ResponseGrpcStatusChecker struct {
+	descriptors map[protoreflect.FullName]protoreflect.MessageDescriptor
+}
+
+// NewChecker creates the checker decoding the details of the statuses with the descriptors,
+// e.g. (&errdetails.ErrorInfo{}).ProtoReflect().Descriptor(). The messages without a descriptor
+// are looked up in the types linked into the binary.
+func NewChecker(descriptors ...protoreflect.MessageDescriptor) checker.CheckerInterface {
+	c := &ResponseGrpcStatusChecker{
+		descriptors: make(map[protoreflect.FullName]protoreflect.MessageDescriptor, len(descriptors)),
+	}
+	for _, descriptor := range descriptors {
+		c.descriptors[descriptor.FullName()] = descriptor
+	}
+	return c
+}
+
+func (c *ResponseGrpcStatusChecker) Name() string {
+	return "response_grpc"
+}
+
+func (c *ResponseGrpcStatusChecker) Check(t models.TestInterface, result *models.Result) ([]error, error) {
+	expected := t.GetGrpcStatus()
+	if expected == nil {
+		return nil, nil
+	}
+	expectedCode, err := parseCode(expected.Code)
+	if err != nil {
+		return nil, fmt.Errorf("invalid responseGrpcStatus of test %s: %s", t.GetName(), err)
+	}
+
+	value := grpcMetadata(result, "Grpc-Status")
+	if value == "" {
+		return []error{errors.New("response does not include the grpc-status trailer")}, nil
+	}
+	code, err := strconv.Atoi(value)
+	if err != nil {
+		return []error{fmt.Errorf("response grpc-status %s is not a number", value)}, nil
+	}
+
+	var errs []error
+	if code != expectedCode {
+		errs = append(errs, fmt.Errorf(
+			"response gRPC status %s does not match expected %s", codeName(code), codeName(expectedCode),
+		))
+	}
+
+	if expected.Message != "" {
+		message := decodeMessage(grpcMetadata(result, "Grpc-Message"))
+		if len(compare.Compare(expected.Message, message, compare.CompareParams{})) != 0 {
+			errs = append(errs, fmt.Errorf(
+				"response gRPC status message %q does not match expected %q", message, expected.Message,
+			))
+		}
+	}
+
+	if expected.Details != nil {
+		detailsErrs, err := c.checkDetails(t, expected.Details, result)
+		if err != nil {
+			return nil, err
+		}
+		errs = append(errs, detailsErrs...)
+	}
+	return errs, nil
+}
+
+// checkDetails compares the details of the status with the expected messages in order,
+// the diffs of the mismatched messages are saved to the result
+func (c *ResponseGrpcStatusChecker) checkDetails(
+	t models.TestInterface,
+	expected []models.GrpcStatusDetail,
+	result *models.Result,
+) ([]error, error) {
+	details, err := decodeDetails(grpcMetadata(result, "Grpc-Status-Details-Bin"))
+	if err != nil {
+		return []error{err}, nil
+	}
+
+	var errs []error
+	if len(details) != len(expected) {
+		errs = append(errs, fmt.Errorf(
+			"response gRPC status has %d details, expected %d", len(details), len(expected),
+		))
+	}
+
+	var diffs []string
+	for i := 0; i < len(expected) && i < len(details); i++ {
+		actualType := typeName(details[i].GetTypeUrl())
+		if actualType != expected[i].Type {
+			errs = append(errs, fmt.Errorf("detail #%d is %s, expected %s", i+1, actualType, expected[i].Type))
+			continue
+		}
+
+		descriptor, err := c.descriptor(protoreflect.FullName(actualType))
+		if err != nil {
+			return nil, fmt.Errorf("detail #%d of test %s: %s", i+1, t.GetName(), err)
+		}
+		var expectedValue interface{}
+		if err := json.Unmarshal([]byte(expected[i].Value), &expectedValue); err != nil {
+			return nil, fmt.Errorf("invalid JSON of detail #%d %s of test %s: %s", i+1, actualType, t.GetName(), err)
+		}
+		actualValue, err := decodeDetail(descriptor, details[i].GetValue())
+		if err != nil {
+			errs = append(errs, fmt.Errorf("detail #%d %s: %s", i+1, actualType, err))
+			continue
+		}
+
+		params := compare.CompareParams{}
+		detailErrs := compare.Compare(expectedValue, actualValue, params)
+		for _, e := range detailErrs {
+			errs = append(errs, fmt.Errorf("detail #%d %s: %s", i+1, actualType, e))
+		}
+		if len(detailErrs) != 0 {
+			diffs = append(diffs, fmt.Sprintf("detail #%d %s:\n%s", i+1, actualType, compare.Diff(expectedValue, actualValue, params)))
+		}
+	}
+	if len(diffs) != 0 {
+		result.BodyDiff = strings.Join(diffs, "\n")
+	}
+	return errs, nil
+}
+
+// descriptor returns the descriptor of the message passed to the checker or linked into the binary
+func (c *ResponseGrpcStatusChecker) descriptor(name protoreflect.FullName) (protoreflect.MessageDescriptor, error) {
+	if descriptor, ok := c.descriptors[name]; ok {
+		return descriptor, nil
+	}
+	messageType, err := protoregistry.GlobalTypes.FindMessageByName(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown message %s, pass its descriptor to response_grpc.NewChecker", name)
+	}
+	return messageType.Descriptor(), nil
+}
+
+// grpcMetadata returns the trailer, or the header of the trailers-only responses
+func grpcMetadata(result *models.Result, name string) string {
+	if value := http.Header(result.ResponseTrailers).Get(name); value != "" {
+		return value
+	}
+	return http.Header(result.ResponseHeaders).Get(name)
+}
+
+// parseCode parses the name or the number of the code
+func parseCode(code string) (int, error) {
+	if number, err := strconv.Atoi(code); err == nil {
+		return number, nil
+	}
+	for number, name := range codeNames {
+		if strings.EqualFold(name, code) {
+			return number, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown gRPC status code %q", code)
+}
+
+func codeName(code int) string {
+	if code >= 0 && code < len(codeNames) {
+		return fmt.Sprintf("%s (%d)", codeNames[code], code)
+	}
+	return strconv.Itoa(code)
+}
+
+// decodeMessage decodes the percent-encoded grpc-message
+func decodeMessage(message string) string {
+	if decoded, err := url.PathUnescape(message); err == nil {
+		return decoded
+	}
+	return message
+}
+
+// decodeDetails decodes the details of google.rpc.Status encoded in base64 in grpc-status-details-bin,
+// the message is parsed by its wire format since its type is not a part of the protobuf module:
+//
+//	message Status {
+//	  int32 code = 1;
+//	  string message = 2;
+//	  repeated google.protobuf.Any details = 3;
+//	}
+func decodeDetails(value string) ([]*anypb.Any, error) {
+	if value == "" {
+		return nil, nil
+	}
+	// the binary metadata are sent with or without padding
+	data, err := base64.RawStdEncoding.DecodeString(strings.TrimRight(value, "="))
+	if err != nil {
+		return nil, fmt.Errorf("response grpc-status-details-bin is not valid base64: %s", err)
+	}
+
+	var details []*anypb.Any
+	for len(data) > 0 {
+		number, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return nil, errors.New("response grpc-status-details-bin is not a valid google.rpc.Status")
+		}
+		data = data[n:]
+
+		if number == 3 && typ == protowire.BytesType {
+			value, n := protowire.ConsumeBytes(data)
+			if n < 0 {
+				return nil, errors.New("response grpc-status-details-bin is not a valid google.rpc.Status")
+			}
+			detail := &anypb.Any{}
+			if err := proto.Unmarshal(value, detail); err != nil {
+				return nil, fmt.Errorf("response grpc-status-details-bin has invalid details: %s", err)
+			}
+			details = append(details, detail)
+			data = data[n:]
+			continue
+		}
+
+		n = protowire.ConsumeFieldValue(number, typ, data)
+		if n < 0 {
+			return nil, errors.New("response grpc-status-details-bin is not a valid google.rpc.Status")
+		}
+		data = data[n:]
+	}
+	return details, nil
+}
+
+// typeName returns the full name of the message of the type URL, e.g. type.googleapis.com/google.rpc.ErrorInfo
+func typeName(typeURL string) string {
+	return typeURL[strings.LastIndex(typeURL, "/")+1:]
+}
+
+// decodeDetail decodes the message and returns it as a JSON value
+func decodeDetail(descriptor protoreflect.MessageDescriptor, value []byte) (interface{}, error) {
+	message := dynamicpb.NewMessage(descriptor)
+	if err := proto.Unmarshal(value, message); err != nil {
+		return nil, fmt.Errorf("could not parse the message: %s", err)
+	}
+	data, err := protojson.Marshal(message)
+	if err != nil {
+		return nil, err
+	}
+
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, err
+	}
+	return decoded, nil
+}
@@ -0,0 +1,176 @@
+package response_grpc
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/lamoda/gonkey/models"
+	"github.com/lamoda/gonkey/testloader/yaml_file"
+)
+
+// errorInfoDescriptor describes the message
+//
+//	message ErrorInfo {
+//	  string reason = 1;
+//	  string domain = 2;
+//	}
+func errorInfoDescriptor(t *testing.T) protoreflect.MessageDescriptor {
+	field := func(name string, number int32) *descriptorpb.FieldDescriptorProto {
+		return &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			JsonName: proto.String(name),
+			Number:   proto.Int32(number),
+			Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+			Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		}
+	}
+
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:    proto.String("google/rpc/error_details.proto"),
+		Package: proto.String("google.rpc"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name:  proto.String("ErrorInfo"),
+			Field: []*descriptorpb.FieldDescriptorProto{field("reason", 1), field("domain", 2)},
+		}},
+	}, nil)
+	require.NoError(t, err)
+	return file.Messages().ByName("ErrorInfo")
+}
+
+func errorInfo(t *testing.T, descriptor protoreflect.MessageDescriptor, reason, domain string) *anypb.Any {
+	message := dynamicpb.NewMessage(descriptor)
+	message.Set(descriptor.Fields().ByName("reason"), protoreflect.ValueOfString(reason))
+	message.Set(descriptor.Fields().ByName("domain"), protoreflect.ValueOfString(domain))
+	value, err := proto.Marshal(message)
+	require.NoError(t, err)
+	return &anypb.Any{TypeUrl: "type.googleapis.com/google.rpc.ErrorInfo", Value: value}
+}
+
+// statusDetails encodes google.rpc.Status for grpc-status-details-bin
+func statusDetails(t *testing.T, code int, message string, details ...*anypb.Any) string {
+	var data []byte
+	data = protowire.AppendTag(data, 1, protowire.VarintType)
+	data = protowire.AppendVarint(data, uint64(code))
+	data = protowire.AppendTag(data, 2, protowire.BytesType)
+	data = protowire.AppendString(data, message)
+	for _, detail := range details {
+		value, err := proto.Marshal(detail)
+		require.NoError(t, err)
+		data = protowire.AppendTag(data, 3, protowire.BytesType)
+		data = protowire.AppendBytes(data, value)
+	}
+	return base64.RawStdEncoding.EncodeToString(data)
+}
+
+func grpcTest(status models.GrpcStatus) *yaml_file.Test {
+	return &yaml_file.Test{
+		TestDefinition: yaml_file.TestDefinition{Name: "get order", GrpcStatus: &status},
+	}
+}
+
+func grpcResult(trailers map[string][]string) *models.Result {
+	return &models.Result{
+		ResponseStatusCode: 200,
+		ResponseHeaders:    map[string][]string{"Content-Type": {"application/grpc"}},
+		ResponseTrailers:   trailers,
+	}
+}
+
+func TestGrpcStatusMatches(t *testing.T) {
+	descriptor := errorInfoDescriptor(t)
+	test := grpcTest(models.GrpcStatus{
+		Code:    "NOT_FOUND",
+		Message: "$matchRegexp(^order \\d+ not found$)",
+		Details: []models.GrpcStatusDetail{
+			{Type: "google.rpc.ErrorInfo", Value: `{"reason": "ORDER_NOT_FOUND"}`},
+			{Type: "google.protobuf.Duration", Value: `"1.500s"`},
+		},
+	})
+
+	retryDelay, err := anypb.New(durationpb.New(1500 * 1000 * 1000))
+	require.NoError(t, err)
+	errs, err := NewChecker(descriptor).Check(test, grpcResult(map[string][]string{
+		"Grpc-Status":  {"5"},
+		"Grpc-Message": {"order 1 not found"},
+		"Grpc-Status-Details-Bin": {
+			statusDetails(t, 5, "order 1 not found", errorInfo(t, descriptor, "ORDER_NOT_FOUND", "shop"), retryDelay),
+		},
+	}))
+	require.NoError(t, err)
+	assert.Empty(t, errs)
+}
+
+func TestGrpcStatusOfTrailersOnlyResponse(t *testing.T) {
+	test := grpcTest(models.GrpcStatus{Code: "3", Message: "invalid id: -1"})
+
+	errs, err := NewChecker().Check(test, &models.Result{
+		ResponseHeaders: map[string][]string{"Grpc-Status": {"3"}, "Grpc-Message": {"invalid id: %2D1"}},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, errs)
+}
+
+func TestGrpcStatusMismatches(t *testing.T) {
+	descriptor := errorInfoDescriptor(t)
+	test := grpcTest(models.GrpcStatus{
+		Code:    "invalid_argument",
+		Message: "invalid id",
+		Details: []models.GrpcStatusDetail{
+			{Type: "google.rpc.ErrorInfo", Value: `{"reason": "INVALID_ID", "domain": "shop"}`},
+			{Type: "google.rpc.BadRequest", Value: `{}`},
+		},
+	})
+
+	result := grpcResult(map[string][]string{
+		"Grpc-Status":             {"5"},
+		"Grpc-Message":            {"order 1 not found"},
+		"Grpc-Status-Details-Bin": {statusDetails(t, 5, "order 1 not found", errorInfo(t, descriptor, "ORDER_NOT_FOUND", "shop"))},
+	})
+	errs, err := NewChecker(descriptor).Check(test, result)
+	require.NoError(t, err)
+
+	var messages []string
+	for _, e := range errs {
+		messages = append(messages, e.Error())
+	}
+	require.Len(t, messages, 4)
+	assert.Equal(t, "response gRPC status NOT_FOUND (5) does not match expected INVALID_ARGUMENT (3)", messages[0])
+	assert.Equal(t, `response gRPC status message "order 1 not found" does not match expected "invalid id"`, messages[1])
+	assert.Equal(t, "response gRPC status has 1 details, expected 2", messages[2])
+	assert.Contains(t, messages[3], "detail #1 google.rpc.ErrorInfo: at path $.reason values do not match")
+	assert.Contains(t, result.BodyDiff, "detail #1 google.rpc.ErrorInfo:\n")
+	assert.Contains(t, result.BodyDiff, "ORDER_NOT_FOUND")
+}
+
+func TestGrpcStatusErrors(t *testing.T) {
+	errs, err := NewChecker().Check(grpcTest(models.GrpcStatus{Code: "OK"}), grpcResult(nil))
+	require.NoError(t, err)
+	assert.EqualError(t, errs[0], "response does not include the grpc-status trailer")
+
+	_, err = NewChecker().Check(grpcTest(models.GrpcStatus{Code: "GONE"}), grpcResult(nil))
+	assert.EqualError(t, err, `invalid responseGrpcStatus of test get order: unknown gRPC status code "GONE"`)
+
+	descriptor := errorInfoDescriptor(t)
+	test := grpcTest(models.GrpcStatus{
+		Code:    "NOT_FOUND",
+		Details: []models.GrpcStatusDetail{{Type: "google.rpc.ErrorInfo", Value: `{}`}},
+	})
+	_, err = NewChecker().Check(test, grpcResult(map[string][]string{
+		"Grpc-Status":             {"5"},
+		"Grpc-Status-Details-Bin": {statusDetails(t, 5, "", errorInfo(t, descriptor, "ORDER_NOT_FOUND", "shop"))},
+	}))
+	assert.EqualError(t, err,
+		"detail #1 of test get order: unknown message google.rpc.ErrorInfo, pass its descriptor to response_grpc.NewChecker")
+}
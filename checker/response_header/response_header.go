@@ -15,21 +15,54 @@ func NewChecker() checker.CheckerInterface {
 	return &ResponseHeaderChecker{}
 }
 
+func (c *ResponseHeaderChecker) Name() string {
+	return "response_header"
+}
+
 func (c *ResponseHeaderChecker) Check(t models.TestInterface, result *models.Result) ([]error, error) {
 	// test response headers with the expected headers
-	expectedHeaders, ok := t.GetResponseHeaders(result.ResponseStatusCode)
-	if !ok || len(expectedHeaders) == 0 {
+	expectedHeaders, _ := t.GetResponseHeaders(result.ResponseStatusCode)
+	orderedHeaders, _ := t.GetResponseHeadersOrdered(result.ResponseStatusCode)
+	expectedTrailers, _ := t.GetResponseTrailers(result.ResponseStatusCode)
+	expectedProto := t.GetResponseProto()
+	if len(expectedHeaders) == 0 && len(orderedHeaders) == 0 && len(expectedTrailers) == 0 && expectedProto == "" {
 		return nil, nil
 	}
 
-	var errs []error
-	for k, v := range expectedHeaders {
+	errs := checkValues("header", expectedHeaders, result.ResponseHeaders)
+
+	for k, values := range orderedHeaders {
 		k = textproto.CanonicalMIMEHeaderKey(k)
 		actualValues, ok := result.ResponseHeaders[k]
 		if !ok {
 			errs = append(errs, fmt.Errorf("response does not include expected header %s", k))
 			continue
 		}
+		if err := checkOrderedValues(k, values, actualValues); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	// the trailers are checked the same way as the headers
+	errs = append(errs, checkValues("trailer", expectedTrailers, result.ResponseTrailers)...)
+
+	if expectedProto != "" && expectedProto != result.ResponseProto {
+		errs = append(errs, fmt.Errorf("response protocol %s does not match expected %s", result.ResponseProto, expectedProto))
+	}
+
+	return errs, nil
+}
+
+// checkValues checks that one of the values of each header (or trailer) matches the expected value
+func checkValues(kind string, expected map[string]string, actual map[string][]string) []error {
+	var errs []error
+	for k, v := range expected {
+		k = textproto.CanonicalMIMEHeaderKey(k)
+		actualValues, ok := actual[k]
+		if !ok {
+			errs = append(errs, fmt.Errorf("response does not include expected %s %s", kind, k))
+			continue
+		}
 		found := false
 		for _, actualValue := range actualValues {
 			e := compare.Compare(v, actualValue, compare.CompareParams{})
@@ -38,9 +71,40 @@ func (c *ResponseHeaderChecker) Check(t models.TestInterface, result *models.Res
 			}
 		}
 		if !found {
-			errs = append(errs, fmt.Errorf("response header %s value does not match expected %s", k, v))
+			errs = append(errs, fmt.Errorf("response %s %s value does not match expected %s", kind, k, v))
 		}
 	}
+	return errs
+}
 
-	return errs, nil
+// checkOrderedValues reports the first index where the values of the header diverge from the expected ones
+func checkOrderedValues(header string, expected, actual []string) error {
+	for i, expectedValue := range expected {
+		if i >= len(actual) {
+			return fmt.Errorf(
+				"response header %s values diverge at index %d: expected %s, actual values end",
+				header,
+				i,
+				expectedValue,
+			)
+		}
+		if len(compare.Compare(expectedValue, actual[i], compare.CompareParams{})) != 0 {
+			return fmt.Errorf(
+				"response header %s values diverge at index %d: expected %s, actual %s",
+				header,
+				i,
+				expectedValue,
+				actual[i],
+			)
+		}
+	}
+	if len(actual) > len(expected) {
+		return fmt.Errorf(
+			"response header %s values diverge at index %d: unexpected value %s",
+			header,
+			len(expected),
+			actual[len(expected)],
+		)
+	}
+	return nil
 }
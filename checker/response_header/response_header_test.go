@@ -79,3 +79,135 @@ func TestCheckWhenNotMatchedShouldReturnError(t *testing.T) {
 		},
 	)
 }
+
+func TestCheckOrderedHeaders(t *testing.T) {
+	result := &models.Result{
+		ResponseStatusCode: 200,
+		ResponseHeaders: map[string][]string{
+			"Via": {
+				"1.1 edge",
+				"1.1 gateway",
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		expected []string
+		err      string
+	}{
+		{
+			name:     "same order",
+			expected: []string{"1.1 edge", "$matchRegexp(^1.1 gate)"},
+		},
+		{
+			name:     "different order",
+			expected: []string{"1.1 gateway", "1.1 edge"},
+			err:      "response header Via values diverge at index 0: expected 1.1 gateway, actual 1.1 edge",
+		},
+		{
+			name:     "more expected values",
+			expected: []string{"1.1 edge", "1.1 gateway", "1.1 backend"},
+			err:      "response header Via values diverge at index 2: expected 1.1 backend, actual values end",
+		},
+		{
+			name:     "less expected values",
+			expected: []string{"1.1 edge"},
+			err:      "response header Via values diverge at index 1: unexpected value 1.1 gateway",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			test := &yaml_file.Test{
+				TestDefinition: yaml_file.TestDefinition{
+					ResponseHeadersOrdered: yaml_file.OrderedResponseHeaders{
+						200: {"via": tt.expected},
+					},
+				},
+			}
+
+			errs, err := NewChecker().Check(test, result)
+
+			assert.NoError(t, err, "Check must not result with an error")
+			if tt.err == "" {
+				assert.Empty(t, errs)
+			} else {
+				assert.Equal(t, []error{errors.New(tt.err)}, errs)
+			}
+		})
+	}
+}
+
+func TestCheckOrderedHeadersMissing(t *testing.T) {
+	test := &yaml_file.Test{
+		TestDefinition: yaml_file.TestDefinition{
+			ResponseHeadersOrdered: yaml_file.OrderedResponseHeaders{
+				200: {"set-cookie": {"a=1", "b=2"}},
+			},
+		},
+	}
+
+	errs, err := NewChecker().Check(test, &models.Result{ResponseStatusCode: 200})
+
+	assert.NoError(t, err, "Check must not result with an error")
+	assert.Equal(t, []error{errors.New("response does not include expected header Set-Cookie")}, errs)
+}
+
+func TestCheckTrailers(t *testing.T) {
+	test := &yaml_file.Test{
+		TestDefinition: yaml_file.TestDefinition{
+			ResponseTrailers: map[int]map[string]string{
+				200: {
+					"grpc-status":  "0",
+					"grpc-message": "$matchRegexp(^$)",
+				},
+			},
+		},
+	}
+
+	result := &models.Result{
+		ResponseStatusCode: 200,
+		ResponseTrailers: map[string][]string{
+			"Grpc-Status":  {"0"},
+			"Grpc-Message": {""},
+		},
+	}
+	errs, err := NewChecker().Check(test, result)
+	assert.NoError(t, err, "Check must not result with an error")
+	assert.Empty(t, errs)
+
+	// the trailers are not headers
+	result = &models.Result{
+		ResponseStatusCode: 200,
+		ResponseHeaders: map[string][]string{
+			"Grpc-Status":  {"0"},
+			"Grpc-Message": {""},
+		},
+		ResponseTrailers: map[string][]string{
+			"Grpc-Status": {"13"},
+		},
+	}
+	errs, err = NewChecker().Check(test, result)
+	assert.NoError(t, err, "Check must not result with an error")
+	assert.ElementsMatch(t, []error{
+		errors.New("response trailer Grpc-Status value does not match expected 0"),
+		errors.New("response does not include expected trailer Grpc-Message"),
+	}, errs)
+}
+
+func TestCheckShouldMatchProto(t *testing.T) {
+	test := &yaml_file.Test{
+		TestDefinition: yaml_file.TestDefinition{
+			ResponseProto: "HTTP/2.0",
+		},
+	}
+
+	errs, err := NewChecker().Check(test, &models.Result{ResponseStatusCode: 200, ResponseProto: "HTTP/2.0"})
+	assert.NoError(t, err, "Check must not result with an error")
+	assert.Empty(t, errs)
+
+	errs, err = NewChecker().Check(test, &models.Result{ResponseStatusCode: 200, ResponseProto: "HTTP/1.1"})
+	assert.NoError(t, err, "Check must not result with an error")
+	assert.Equal(t, []error{errors.New("response protocol HTTP/1.1 does not match expected HTTP/2.0")}, errs)
+}
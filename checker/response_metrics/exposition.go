@@ -0,0 +1,125 @@
+package response_metrics
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// sample is a line of the Prometheus text exposition format, e.g. http_requests_total{code="200"} 3
+type sample struct {
+	name   string
+	labels map[string]string
+	value  float64
+}
+
+// parseExposition parses the samples of the Prometheus text exposition format,
+// the comments (# HELP and # TYPE) and the timestamps are skipped
+func parseExposition(r io.Reader) ([]sample, error) {
+	var samples []sample
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		s, err := parseSample(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", line, err)
+		}
+		samples = append(samples, s)
+	}
+	return samples, scanner.Err()
+}
+
+func parseSample(text string) (sample, error) {
+	name, labels, rest, err := parseSeries(text)
+	if err != nil {
+		return sample{}, err
+	}
+
+	fields := strings.Fields(rest)
+	if len(fields) == 0 || len(fields) > 2 {
+		return sample{}, fmt.Errorf("invalid sample %s", text)
+	}
+	// ParseFloat accepts +Inf, -Inf and NaN as well
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return sample{}, fmt.Errorf("invalid value %s of %s", fields[0], name)
+	}
+	return sample{name: name, labels: labels, value: value}, nil
+}
+
+// parseSeries parses the name and the labels of the series, the rest of the text is returned
+func parseSeries(text string) (name string, labels map[string]string, rest string, err error) {
+	end := strings.IndexAny(text, "{ \t")
+	if end < 0 {
+		end = len(text)
+	}
+	name = text[:end]
+	if name == "" {
+		return "", nil, "", errors.New("metric name is missing")
+	}
+	rest = text[end:]
+	if !strings.HasPrefix(rest, "{") {
+		return name, nil, rest, nil
+	}
+
+	labels = make(map[string]string)
+	rest = rest[1:]
+	for {
+		rest = strings.TrimLeft(rest, " \t")
+		if strings.HasPrefix(rest, "}") {
+			return name, labels, rest[1:], nil
+		}
+
+		eq := strings.IndexByte(rest, '=')
+		if eq <= 0 {
+			return "", nil, "", fmt.Errorf("invalid labels of %s", name)
+		}
+		label := strings.TrimSpace(rest[:eq])
+		value, tail, ok := parseLabelValue(strings.TrimLeft(rest[eq+1:], " \t"))
+		if !ok {
+			return "", nil, "", fmt.Errorf("invalid value of label %s of %s", label, name)
+		}
+		labels[label] = value
+
+		rest = strings.TrimLeft(tail, " \t")
+		if strings.HasPrefix(rest, ",") {
+			rest = rest[1:]
+		} else if !strings.HasPrefix(rest, "}") {
+			return "", nil, "", fmt.Errorf("invalid labels of %s", name)
+		}
+	}
+}
+
+// parseLabelValue parses the quoted value of the label with the \\, \" and \n escapes
+func parseLabelValue(text string) (value, rest string, ok bool) {
+	if !strings.HasPrefix(text, `"`) {
+		return "", "", false
+	}
+	var b strings.Builder
+	for i := 1; i < len(text); i++ {
+		switch c := text[i]; c {
+		case '"':
+			return b.String(), text[i+1:], true
+		case '\\':
+			if i+1 == len(text) {
+				return "", "", false
+			}
+			i++
+			if text[i] == 'n' {
+				b.WriteByte('\n')
+			} else {
+				b.WriteByte(text[i])
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", "", false
+}
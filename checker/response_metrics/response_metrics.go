@@ -0,0 +1,189 @@
+package response_metrics
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lamoda/gonkey/checker"
+	"github.com/lamoda/gonkey/models"
+)
+
+// scrapeTimeout limits the requests of the metrics
+const scrapeTimeout = 10 * time.Second
+
+// ResponseMetricsChecker checks how the Prometheus metrics of the service are changed by the request of the test,
+// the metrics are scraped before the request and after the response
+type ResponseMetricsChecker struct {
+	url    string
+	client *http.Client
+
+	mu     sync.Mutex
+	before map[string][]sample
+}
+
+// NewChecker makes the checker scraping the metrics of the service in the text exposition format from the URL,
+// e.g. http://localhost:8080/metrics
+func NewChecker(url string) checker.CheckerInterface {
+	return &ResponseMetricsChecker{
+		url:    url,
+		client: &http.Client{Timeout: scrapeTimeout},
+		before: make(map[string][]sample),
+	}
+}
+
+func (c *ResponseMetricsChecker) Name() string {
+	return "response_metrics"
+}
+
+// Prepare scrapes the metrics before the request of the test
+func (c *ResponseMetricsChecker) Prepare(t models.TestInterface) error {
+	if len(t.GetMetricsDelta()) == 0 {
+		return nil
+	}
+	samples, err := c.scrape()
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.before[testKey(t)] = samples
+	return nil
+}
+
+func (c *ResponseMetricsChecker) Check(t models.TestInterface, _ *models.Result) ([]error, error) {
+	expected := t.GetMetricsDelta()
+	if len(expected) == 0 {
+		return nil, nil
+	}
+
+	// the scrape is kept until the next request of the test, assertRetry checks the metrics several times
+	c.mu.Lock()
+	before, ok := c.before[testKey(t)]
+	c.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("metrics of test %s were not scraped before the request", t.GetName())
+	}
+	after, err := c.scrape()
+	if err != nil {
+		return nil, err
+	}
+
+	// the selectors are sorted for the errors to be reported in the same order
+	selectors := make([]string, 0, len(expected))
+	for selector := range expected {
+		selectors = append(selectors, selector)
+	}
+	sort.Strings(selectors)
+
+	var errs []error
+	for _, selector := range selectors {
+		name, labels, rest, err := parseSeries(strings.TrimSpace(selector))
+		if err != nil || strings.TrimSpace(rest) != "" {
+			return nil, fmt.Errorf("invalid metric selector %s", selector)
+		}
+		want := expected[selector]
+		op, bound, ok := comparison(want)
+		if !ok {
+			return nil, fmt.Errorf("invalid expected delta %s of metric %s", want, selector)
+		}
+
+		// the series are exposed after their first change, the missing ones are counted as 0
+		valueAfter, found := sum(after, name, labels)
+		if !found && !compareDelta(op, 0, bound) {
+			errs = append(errs, fmt.Errorf("metric %s is not exposed by the service", selector))
+			continue
+		}
+		valueBefore, _ := sum(before, name, labels)
+		if delta := valueAfter - valueBefore; !compareDelta(op, delta, bound) {
+			errs = append(errs, fmt.Errorf("metric %s changed by %s, expected %s", selector, formatValue(delta), want))
+		}
+	}
+	return errs, nil
+}
+
+func (c *ResponseMetricsChecker) scrape() ([]sample, error) {
+	resp, err := c.client.Get(c.url)
+	if err != nil {
+		return nil, fmt.Errorf("unable to scrape metrics: %s", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unable to scrape metrics: %s responded with status %d", c.url, resp.StatusCode)
+	}
+
+	samples, err := parseExposition(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse metrics of %s: %s", c.url, err)
+	}
+	return samples, nil
+}
+
+// testKey tells the scrapes of the tests apart, the name isn't changed by the variables
+func testKey(t models.TestInterface) string {
+	return t.GetFileName() + "\x00" + t.GetName()
+}
+
+// sum sums the values of the series of the metric having the labels, the labels not listed can have any values
+func sum(samples []sample, name string, labels map[string]string) (float64, bool) {
+	total, found := 0.0, false
+	for _, s := range samples {
+		if s.name != name || !hasLabels(s, labels) {
+			continue
+		}
+		total += s.value
+		found = true
+	}
+	return total, found
+}
+
+func hasLabels(s sample, labels map[string]string) bool {
+	for label, value := range labels {
+		if s.labels[label] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// comparison parses the expected delta, a number or a comparison like ">= 1",
+// the operators are >=, <=, >, < and =
+func comparison(want string) (string, float64, bool) {
+	op := "="
+	for _, candidate := range []string{">=", "<=", ">", "<", "="} {
+		if strings.HasPrefix(want, candidate) {
+			op = candidate
+			want = want[len(candidate):]
+			break
+		}
+	}
+	bound, err := strconv.ParseFloat(strings.TrimSpace(want), 64)
+	if err != nil {
+		return "", 0, false
+	}
+	return op, bound, true
+}
+
+func compareDelta(op string, delta, bound float64) bool {
+	switch op {
+	case ">=":
+		return delta >= bound
+	case "<=":
+		return delta <= bound
+	case ">":
+		return delta > bound
+	case "<":
+		return delta < bound
+	default:
+		return delta == bound
+	}
+}
+
+func formatValue(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
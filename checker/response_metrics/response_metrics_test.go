@@ -0,0 +1,155 @@
+package response_metrics
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lamoda/gonkey/checker"
+	"github.com/lamoda/gonkey/models"
+	"github.com/lamoda/gonkey/testloader/yaml_file"
+)
+
+func TestParseExposition(t *testing.T) {
+	samples, err := parseExposition(strings.NewReader(`
+# HELP http_requests_total The total number of HTTP requests.
+# TYPE http_requests_total counter
+http_requests_total{method="post",code="200"} 1027 1395066363000
+http_requests_total{ method = "post", code="400", } 3
+msdos_file_access_time_seconds{path="C:\\DIR\\FILE.TXT",error="Cannot find file:\n\"FILE.TXT\""} 1.458255915e9
+http_request_duration_seconds_bucket{le="+Inf"} 144320
+process_start_time_seconds +Inf
+`))
+	require.NoError(t, err)
+	require.Len(t, samples, 5)
+
+	assert.Equal(t, sample{
+		name:   "http_requests_total",
+		labels: map[string]string{"method": "post", "code": "200"},
+		value:  1027,
+	}, samples[0])
+	assert.Equal(t, map[string]string{"method": "post", "code": "400"}, samples[1].labels)
+	assert.Equal(t, map[string]string{
+		"path":  `C:\DIR\FILE.TXT`,
+		"error": "Cannot find file:\n\"FILE.TXT\"",
+	}, samples[2].labels)
+	assert.Equal(t, 1.458255915e9, samples[2].value)
+	assert.Equal(t, "+Inf", samples[3].labels["le"])
+	assert.Nil(t, samples[4].labels)
+	assert.True(t, math.IsInf(samples[4].value, 1))
+}
+
+func TestParseExpositionErrors(t *testing.T) {
+	for text, expected := range map[string]string{
+		"requests_total{code=200} 1":      "line 2: invalid value of label code of requests_total",
+		`requests_total{code="200" 1`:     "line 2: invalid labels of requests_total",
+		`requests_total{code="200"} many`: "line 2: invalid value many of requests_total",
+		"requests_total":                  "line 2: invalid sample requests_total",
+	} {
+		_, err := parseExposition(strings.NewReader("# TYPE requests_total counter\n" + text))
+		assert.EqualError(t, err, expected, text)
+	}
+}
+
+func TestCheckMetricsDelta(t *testing.T) {
+	srv, request := testMetricsServer()
+	defer srv.Close()
+
+	test := testWithMetricsDelta(map[string]string{
+		`http_requests_total{code="200"}`: "1",
+		"http_requests_total":             ">= 2",
+		`http_requests_total{code="500"}`: "0",
+		"orders_created_total":            "1",
+	})
+	c := NewChecker(srv.URL + "/metrics")
+
+	require.NoError(t, c.(checker.PreparingChecker).Prepare(test))
+	request("200")
+	request("404")
+	errs, err := c.Check(test, &models.Result{})
+	require.NoError(t, err)
+	assert.Equal(t, []error{
+		fmt.Errorf("metric orders_created_total is not exposed by the service"),
+	}, errs)
+}
+
+func TestCheckMetricsDeltaMismatch(t *testing.T) {
+	srv, request := testMetricsServer()
+	defer srv.Close()
+
+	test := testWithMetricsDelta(map[string]string{
+		`http_requests_total{code="200"}`: "1",
+		`http_requests_total{code="404"}`: "> 1",
+	})
+	c := NewChecker(srv.URL + "/metrics")
+
+	require.NoError(t, c.(checker.PreparingChecker).Prepare(test))
+	request("200")
+	request("200")
+	request("404")
+	errs, err := c.Check(test, &models.Result{})
+	require.NoError(t, err)
+	assert.Equal(t, []error{
+		fmt.Errorf(`metric http_requests_total{code="200"} changed by 2, expected 1`),
+		fmt.Errorf(`metric http_requests_total{code="404"} changed by 1, expected > 1`),
+	}, errs)
+}
+
+func TestCheckMetricsDeltaErrors(t *testing.T) {
+	srv, _ := testMetricsServer()
+	defer srv.Close()
+
+	c := NewChecker(srv.URL + "/metrics")
+	test := testWithMetricsDelta(map[string]string{"http_requests_total": "1"})
+	_, err := c.Check(test, &models.Result{})
+	assert.EqualError(t, err, "metrics of test metrics were not scraped before the request")
+
+	test = testWithMetricsDelta(map[string]string{"http_requests_total": "more"})
+	require.NoError(t, c.(checker.PreparingChecker).Prepare(test))
+	_, err = c.Check(test, &models.Result{})
+	assert.EqualError(t, err, "invalid expected delta more of metric http_requests_total")
+
+	c = NewChecker(srv.URL + "/missing")
+	err = c.(checker.PreparingChecker).Prepare(test)
+	assert.EqualError(t, err, "unable to scrape metrics: "+srv.URL+"/missing responded with status 404")
+}
+
+func testWithMetricsDelta(expected map[string]string) *yaml_file.Test {
+	return &yaml_file.Test{
+		TestDefinition: yaml_file.TestDefinition{Name: "metrics", MetricsDelta: expected},
+	}
+}
+
+// testMetricsServer exposes the numbers of the requests made with the returned function by code
+func testMetricsServer() (*httptest.Server, func(code string)) {
+	var mu sync.Mutex
+	requests := map[string]int{"200": 5}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/metrics" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+
+		_, _ = fmt.Fprintln(w, "# TYPE http_requests_total counter")
+		for _, code := range []string{"200", "404", "500"} {
+			if count, ok := requests[code]; ok {
+				_, _ = fmt.Fprintf(w, "http_requests_total{code=%q,method=\"get\"} %d\n", code, count)
+			}
+		}
+	}))
+	return srv, func(code string) {
+		mu.Lock()
+		defer mu.Unlock()
+		requests[code]++
+	}
+}
@@ -0,0 +1,123 @@
+package response_protobuf
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/lamoda/gonkey/checker"
+	"github.com/lamoda/gonkey/compare"
+	"github.com/lamoda/gonkey/models"
+)
+
+// formats of the expected messages
+const (
+	FormatJson = "json"
+	FormatText = "text"
+)
+
+// ResponseProtobufChecker compares protobuf responses of the tests expecting the message
+// of its descriptor, the other tests are left to the other checkers
+type ResponseProtobufChecker struct {
+	descriptor protoreflect.MessageDescriptor
+}
+
+// NewChecker creates the checker of the messages described by the descriptor,
+// e.g. (&pb.Order{}).ProtoReflect().Descriptor(). Register a checker for each message.
+func NewChecker(descriptor protoreflect.MessageDescriptor) checker.CheckerInterface {
+	return &ResponseProtobufChecker{
+		descriptor: descriptor,
+	}
+}
+
+func (c *ResponseProtobufChecker) Name() string {
+	return "response_protobuf"
+}
+
+func (c *ResponseProtobufChecker) Check(t models.TestInterface, result *models.Result) ([]error, error) {
+	expectedResponse := t.GetProtobufResponse()
+	if expectedResponse == nil || expectedResponse.Message != string(c.descriptor.FullName()) {
+		return nil, nil
+	}
+
+	expectedBody, ok := expectedResponse.Body[result.ResponseStatusCode]
+	if !ok {
+		// unexpected statuses are reported by response_body checker
+		return nil, nil
+	}
+
+	expected := dynamicpb.NewMessage(c.descriptor)
+	if err := unmarshalExpected(expectedResponse.Format, expectedBody, expected); err != nil {
+		return nil, fmt.Errorf(
+			"invalid %s message in responseProtobuf for test %s (status %d): %s",
+			c.descriptor.FullName(),
+			t.GetName(),
+			result.ResponseStatusCode,
+			err.Error(),
+		)
+	}
+
+	actual := dynamicpb.NewMessage(c.descriptor)
+	unmarshal := proto.UnmarshalOptions{DiscardUnknown: expectedResponse.IgnoreUnknownFields}
+	if err := unmarshal.Unmarshal([]byte(result.ResponseBody), actual); err != nil {
+		return []error{fmt.Errorf("could not parse response as %s: %s", c.descriptor.FullName(), err)}, nil
+	}
+
+	if proto.Equal(expected, actual) {
+		return nil, nil
+	}
+
+	return compareFields(expected, actual, result)
+}
+
+func unmarshalExpected(format, body string, message proto.Message) error {
+	switch format {
+	case "", FormatJson:
+		return protojson.Unmarshal([]byte(body), message)
+	case FormatText:
+		return prototext.Unmarshal([]byte(body), message)
+	default:
+		return fmt.Errorf("unknown format %q, expected %s or %s", format, FormatJson, FormatText)
+	}
+}
+
+// compareFields reports the differences of the messages by the paths of their fields
+func compareFields(expected, actual proto.Message, result *models.Result) ([]error, error) {
+	expectedValue, err := toJsonValue(expected)
+	if err != nil {
+		return nil, err
+	}
+	actualValue, err := toJsonValue(actual)
+	if err != nil {
+		return nil, err
+	}
+
+	params := compare.CompareParams{DisallowExtraFields: true}
+	errs := compare.Compare(expectedValue, actualValue, params)
+	if len(errs) == 0 {
+		// the messages only differ by the fields unknown to the descriptor
+		return []error{errors.New("response has unknown fields, set ignoreUnknownFields to ignore them")}, nil
+	}
+
+	result.BodyDiff = compare.Diff(expectedValue, actualValue, params)
+	return errs, nil
+}
+
+func toJsonValue(message proto.Message) (interface{}, error) {
+	data, err := protojson.Marshal(message)
+	if err != nil {
+		return nil, err
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
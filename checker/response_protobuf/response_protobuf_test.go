@@ -0,0 +1,147 @@
+package response_protobuf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/lamoda/gonkey/models"
+	"github.com/lamoda/gonkey/testloader/yaml_file"
+)
+
+// orderDescriptor describes the message
+//
+//	message Order {
+//	  int32 id = 1;
+//	  string name = 2;
+//	  repeated string tags = 3;
+//	}
+func orderDescriptor(t *testing.T) protoreflect.MessageDescriptor {
+	field := func(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type, label descriptorpb.FieldDescriptorProto_Label) *descriptorpb.FieldDescriptorProto {
+		return &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			JsonName: proto.String(name),
+			Number:   proto.Int32(number),
+			Type:     typ.Enum(),
+			Label:    label.Enum(),
+		}
+	}
+
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:    proto.String("shop/order.proto"),
+		Package: proto.String("shop"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("Order"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				field("id", 1, descriptorpb.FieldDescriptorProto_TYPE_INT32, descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL),
+				field("name", 2, descriptorpb.FieldDescriptorProto_TYPE_STRING, descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL),
+				field("tags", 3, descriptorpb.FieldDescriptorProto_TYPE_STRING, descriptorpb.FieldDescriptorProto_LABEL_REPEATED),
+			},
+		}},
+	}, nil)
+	require.NoError(t, err)
+	return file.Messages().ByName("Order")
+}
+
+func orderBytes(t *testing.T, descriptor protoreflect.MessageDescriptor, id int32, name string, tags ...string) []byte {
+	message := dynamicpb.NewMessage(descriptor)
+	message.Set(descriptor.Fields().ByName("id"), protoreflect.ValueOfInt32(id))
+	message.Set(descriptor.Fields().ByName("name"), protoreflect.ValueOfString(name))
+	list := message.Mutable(descriptor.Fields().ByName("tags")).List()
+	for _, tag := range tags {
+		list.Append(protoreflect.ValueOfString(tag))
+	}
+
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(message)
+	require.NoError(t, err)
+	return data
+}
+
+func protobufTest(response *models.ProtobufResponse) *yaml_file.Test {
+	return &yaml_file.Test{
+		TestDefinition: yaml_file.TestDefinition{
+			Name:             "protobuf",
+			ProtobufResponse: response,
+		},
+	}
+}
+
+func protobufResult(body []byte) *models.Result {
+	return &models.Result{
+		ResponseStatusCode:  200,
+		ResponseContentType: "application/x-protobuf",
+		ResponseBody:        string(body),
+	}
+}
+
+func TestProtobufMatches(t *testing.T) {
+	descriptor := orderDescriptor(t)
+	body := orderBytes(t, descriptor, 1, "book", "new", "sale")
+
+	tests := map[string]*models.ProtobufResponse{
+		"json": {Message: "shop.Order", Body: map[int]string{200: `{"tags": ["new", "sale"], "name": "book", "id": 1}`}},
+		"text": {Message: "shop.Order", Format: FormatText, Body: map[int]string{200: `name: "book" id: 1 tags: ["new", "sale"]`}},
+	}
+	for name, response := range tests {
+		t.Run(name, func(t *testing.T) {
+			errs, err := NewChecker(descriptor).Check(protobufTest(response), protobufResult(body))
+			require.NoError(t, err)
+			assert.Empty(t, errs)
+		})
+	}
+}
+
+func TestProtobufMismatchIsReportedByFields(t *testing.T) {
+	descriptor := orderDescriptor(t)
+	response := &models.ProtobufResponse{Message: "shop.Order", Body: map[int]string{200: `{"id": 1, "name": "book"}`}}
+	result := protobufResult(orderBytes(t, descriptor, 2, "book"))
+
+	errs, err := NewChecker(descriptor).Check(protobufTest(response), result)
+	require.NoError(t, err)
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "values do not match")
+	assert.NotEmpty(t, result.BodyDiff)
+}
+
+func TestProtobufUnknownFields(t *testing.T) {
+	descriptor := orderDescriptor(t)
+	body := orderBytes(t, descriptor, 1, "book")
+	body = protowire.AppendTag(body, 10, protowire.VarintType)
+	body = protowire.AppendVarint(body, 42)
+
+	response := &models.ProtobufResponse{Message: "shop.Order", Body: map[int]string{200: `{"id": 1, "name": "book"}`}}
+	errs, err := NewChecker(descriptor).Check(protobufTest(response), protobufResult(body))
+	require.NoError(t, err)
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "unknown fields")
+
+	response.IgnoreUnknownFields = true
+	errs, err = NewChecker(descriptor).Check(protobufTest(response), protobufResult(body))
+	require.NoError(t, err)
+	assert.Empty(t, errs)
+}
+
+func TestProtobufOtherMessagesAreSkipped(t *testing.T) {
+	descriptor := orderDescriptor(t)
+	response := &models.ProtobufResponse{Message: "shop.Customer", Body: map[int]string{200: `{"id": 1}`}}
+
+	errs, err := NewChecker(descriptor).Check(protobufTest(response), protobufResult([]byte("garbage")))
+	require.NoError(t, err)
+	assert.Empty(t, errs)
+}
+
+func TestProtobufInvalidExpectedMessage(t *testing.T) {
+	descriptor := orderDescriptor(t)
+	response := &models.ProtobufResponse{Message: "shop.Order", Body: map[int]string{200: `{"price": 1}`}}
+
+	_, err := NewChecker(descriptor).Check(protobufTest(response), protobufResult(orderBytes(t, descriptor, 1, "book")))
+	assert.Error(t, err)
+}
@@ -0,0 +1,61 @@
+package response_snapshot
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ignorePatterns compiles the ignored paths, [*] matches any index of an array and * matches any key of an object
+func ignorePatterns(paths []string) ([]*regexp.Regexp, error) {
+	patterns := make([]*regexp.Regexp, 0, len(paths))
+	for _, path := range paths {
+		if !strings.HasPrefix(path, "$") {
+			return nil, fmt.Errorf("invalid ignored path %s, expected a path like $.items[*].id", path)
+		}
+		expr := regexp.QuoteMeta(path)
+		expr = strings.ReplaceAll(expr, `\[\*\]`, `\[\d+\]`)
+		expr = strings.ReplaceAll(expr, `\*`, `[^.\[]+`)
+		patterns = append(patterns, regexp.MustCompile("^"+expr+"$"))
+	}
+	return patterns, nil
+}
+
+// withoutPaths returns a copy of the value without the ignored fields, the ignored items of arrays
+// are replaced with nulls to keep the indexes of the rest
+func withoutPaths(path string, value interface{}, patterns []*regexp.Regexp) interface{} {
+	if len(patterns) == 0 {
+		return value
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			itemPath := path + "." + key
+			if !ignored(itemPath, patterns) {
+				copied[key] = withoutPaths(itemPath, item, patterns)
+			}
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, item := range v {
+			itemPath := fmt.Sprintf("%s[%d]", path, i)
+			if !ignored(itemPath, patterns) {
+				copied[i] = withoutPaths(itemPath, item, patterns)
+			}
+		}
+		return copied
+	default:
+		return value
+	}
+}
+
+func ignored(path string, patterns []*regexp.Regexp) bool {
+	for _, pattern := range patterns {
+		if pattern.MatchString(path) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,146 @@
+package response_snapshot
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/lamoda/gonkey/checker"
+	"github.com/lamoda/gonkey/compare"
+	"github.com/lamoda/gonkey/models"
+)
+
+// Options of the checker
+type Options struct {
+	// Dir is the directory of the snapshots, a snapshot per test is stored in the subdirectory named after the test file
+	Dir string
+	// Update makes the checker rewrite the snapshots which differ from the responses instead of failing the tests
+	Update bool
+	// IgnorePaths are the paths of the volatile fields of JSON responses which are not compared in all the tests,
+	// e.g. $.requestId or $.items[*].createdAt
+	IgnorePaths []string
+}
+
+// ResponseSnapshotChecker compares the status and the body of the response with the ones stored by the previous run,
+// the missing snapshots are created
+type ResponseSnapshotChecker struct {
+	opts Options
+}
+
+// snapshot is the stored response, JSON bodies are stored decoded to keep the files readable
+type snapshot struct {
+	Status int         `json:"status"`
+	Body   interface{} `json:"body"`
+}
+
+func NewChecker(opts Options) checker.CheckerInterface {
+	return &ResponseSnapshotChecker{opts: opts}
+}
+
+func (c *ResponseSnapshotChecker) Name() string {
+	return "response_snapshot"
+}
+
+func (c *ResponseSnapshotChecker) Check(t models.TestInterface, result *models.Result) ([]error, error) {
+	paths := make([]string, 0, len(c.opts.IgnorePaths)+len(t.GetSnapshotIgnorePaths()))
+	paths = append(append(paths, c.opts.IgnorePaths...), t.GetSnapshotIgnorePaths()...)
+	ignored, err := ignorePatterns(paths)
+	if err != nil {
+		return nil, fmt.Errorf("test %s: %s", t.GetName(), err)
+	}
+
+	actual := snapshot{Status: result.ResponseStatusCode, Body: responseBody(result)}
+	file := c.file(t)
+	data, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil, writeSnapshot(file, actual, "created")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read snapshot %s: %s", file, err)
+	}
+	var expected snapshot
+	if err := json.Unmarshal(data, &expected); err != nil {
+		return nil, fmt.Errorf("invalid snapshot %s: %s", file, err)
+	}
+
+	var errs []error
+	if expected.Status != actual.Status {
+		errs = append(errs, fmt.Errorf(
+			"server responded with status %d, snapshot %s has %d", actual.Status, file, expected.Status,
+		))
+	}
+	expectedBody := withoutPaths("$", expected.Body, ignored)
+	actualBody := withoutPaths("$", actual.Body, ignored)
+	params := compare.CompareParams{
+		IgnoreArraysOrdering: t.IgnoreArraysOrdering(),
+		DisallowExtraFields:  true,
+	}
+	bodyErrs := compare.Compare(expectedBody, actualBody, params)
+	for _, e := range bodyErrs {
+		errs = append(errs, fmt.Errorf("response differs from snapshot %s: %s", file, e))
+	}
+
+	if len(errs) == 0 {
+		return nil, nil
+	}
+	if c.opts.Update {
+		return nil, writeSnapshot(file, actual, "updated")
+	}
+	if len(bodyErrs) != 0 && result.BodyDiff == "" {
+		result.BodyDiff = compare.Diff(expectedBody, actualBody, params)
+	}
+	return errs, nil
+}
+
+// file returns the path of the snapshot of the test, e.g. <dir>/orders/get_order_1.json
+// for the test "get order #1" of orders.yaml
+func (c *ResponseSnapshotChecker) file(t models.TestInterface) string {
+	base := filepath.Base(t.GetFileName())
+	return filepath.Join(c.opts.Dir, strings.TrimSuffix(base, filepath.Ext(base)), fileName(t.GetName())+".json")
+}
+
+var unsafeRx = regexp.MustCompile(`[^\w.-]+`)
+
+func fileName(testName string) string {
+	name := strings.Trim(unsafeRx.ReplaceAllString(testName, "_"), "_")
+	if name == "" {
+		return "_"
+	}
+	return name
+}
+
+// responseBody returns the decoded JSON body, or the body as a string if it's not JSON
+func responseBody(result *models.Result) interface{} {
+	if strings.Contains(result.ResponseContentType, "json") {
+		var value interface{}
+		if err := json.Unmarshal([]byte(result.ResponseBody), &value); err == nil {
+			return value
+		}
+	}
+	return result.ResponseBody
+}
+
+func writeSnapshot(file string, s snapshot, action string) error {
+	buf := &bytes.Buffer{}
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(s); err != nil {
+		return fmt.Errorf("unable to write snapshot %s: %s", file, err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return fmt.Errorf("unable to write snapshot %s: %s", file, err)
+	}
+	if err := ioutil.WriteFile(file, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("unable to write snapshot %s: %s", file, err)
+	}
+
+	fmt.Printf("Snapshot %s %s\n", file, action)
+	return nil
+}
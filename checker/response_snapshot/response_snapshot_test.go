@@ -0,0 +1,84 @@
+package response_snapshot
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lamoda/gonkey/models"
+	"github.com/lamoda/gonkey/testloader/yaml_file"
+)
+
+func snapshotTest(ignorePaths ...string) *yaml_file.Test {
+	return &yaml_file.Test{
+		TestDefinition: yaml_file.TestDefinition{Name: "get order #1", SnapshotIgnorePaths: ignorePaths},
+		Filename:       "cases/orders.yaml",
+	}
+}
+
+func jsonResult(status int, body string) *models.Result {
+	return &models.Result{ResponseStatusCode: status, ResponseContentType: "application/json", ResponseBody: body}
+}
+
+func TestSnapshotIsCreatedAndCompared(t *testing.T) {
+	dir, err := ioutil.TempDir("", "snapshots")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	c := NewChecker(Options{Dir: dir, IgnorePaths: []string{"$.requestId"}})
+	test := snapshotTest("$.items[*].createdAt")
+
+	errs, err := c.Check(test, jsonResult(200, `{"requestId": "a1", "items": [{"id": 1, "createdAt": "10:00"}]}`))
+	require.NoError(t, err)
+	assert.Empty(t, errs)
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "orders", "get_order_1.json"))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"status": 200, "body": {"requestId": "a1", "items": [{"id": 1, "createdAt": "10:00"}]}}`, string(data))
+
+	errs, err = c.Check(test, jsonResult(200, `{"requestId": "b2", "items": [{"id": 1, "createdAt": "11:00"}]}`))
+	require.NoError(t, err)
+	assert.Empty(t, errs, "the volatile fields are ignored")
+
+	result := jsonResult(404, `{"requestId": "c3", "items": [{"id": 2, "createdAt": "12:00"}], "total": 1}`)
+	errs, err = c.Check(test, result)
+	require.NoError(t, err)
+	require.Len(t, errs, 2)
+	file := filepath.Join(dir, "orders", "get_order_1.json")
+	assert.EqualError(t, errs[0], "server responded with status 404, snapshot "+file+" has 200")
+	assert.Contains(t, errs[1].Error(), "response differs from snapshot "+file+": at path $ map lengths do not match")
+	assert.Contains(t, result.BodyDiff, "$.total")
+	assert.Contains(t, result.BodyDiff, "$.items[0].id")
+	assert.NotContains(t, result.BodyDiff, "createdAt")
+}
+
+func TestSnapshotIsUpdated(t *testing.T) {
+	dir, err := ioutil.TempDir("", "snapshots")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	test := snapshotTest()
+	_, err = NewChecker(Options{Dir: dir}).Check(test, &models.Result{ResponseStatusCode: 200, ResponseBody: "pong"})
+	require.NoError(t, err)
+
+	errs, err := NewChecker(Options{Dir: dir}).Check(test, &models.Result{ResponseStatusCode: 200, ResponseBody: "ping"})
+	require.NoError(t, err)
+	assert.Len(t, errs, 1)
+
+	errs, err = NewChecker(Options{Dir: dir, Update: true}).Check(test, &models.Result{ResponseStatusCode: 200, ResponseBody: "ping"})
+	require.NoError(t, err)
+	assert.Empty(t, errs)
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "orders", "get_order_1.json"))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"status": 200, "body": "ping"}`, string(data))
+}
+
+func TestSnapshotInvalidIgnorePath(t *testing.T) {
+	_, err := NewChecker(Options{Dir: "snapshots"}).Check(snapshotTest("items.id"), jsonResult(200, `{}`))
+	assert.EqualError(t, err, "test get order #1: invalid ignored path items.id, expected a path like $.items[*].id")
+}
@@ -0,0 +1,39 @@
+package response_status_text
+
+import (
+	"fmt"
+
+	"github.com/lamoda/gonkey/checker"
+	"github.com/lamoda/gonkey/compare"
+	"github.com/lamoda/gonkey/models"
+)
+
+// ResponseStatusTextChecker compares the reason phrase of the status line of the response,
+// e.g. "Not Found" of "404 Not Found", with the text expected for the status code of the response
+type ResponseStatusTextChecker struct{}
+
+func NewChecker() checker.CheckerInterface {
+	return &ResponseStatusTextChecker{}
+}
+
+func (c *ResponseStatusTextChecker) Name() string {
+	return "response_status_text"
+}
+
+func (c *ResponseStatusTextChecker) Check(t models.TestInterface, result *models.Result) ([]error, error) {
+	expected, ok := t.GetResponseStatusText(result.ResponseStatusCode)
+	if !ok {
+		return nil, nil
+	}
+
+	actual := result.ResponseStatusText()
+	if len(compare.Compare(expected, actual, compare.CompareParams{})) != 0 {
+		return []error{fmt.Errorf(
+			"response status text %q of status %d does not match expected %q",
+			actual,
+			result.ResponseStatusCode,
+			expected,
+		)}, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,51 @@
+package response_status_text
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lamoda/gonkey/models"
+	"github.com/lamoda/gonkey/testloader/yaml_file"
+)
+
+func statusTextTest(texts map[int]string) *yaml_file.Test {
+	return &yaml_file.Test{
+		TestDefinition: yaml_file.TestDefinition{Name: "status text", ResponseStatusTexts: texts},
+	}
+}
+
+func TestStatusTextMatches(t *testing.T) {
+	test := statusTextTest(map[int]string{
+		200: "Everything Is Fine",
+		404: "$matchRegexp(^No Such .+$)",
+	})
+
+	errs, err := NewChecker().Check(test, &models.Result{ResponseStatusCode: 200, ResponseStatus: "200 Everything Is Fine"})
+	require.NoError(t, err)
+	assert.Empty(t, errs)
+
+	errs, err = NewChecker().Check(test, &models.Result{ResponseStatusCode: 404, ResponseStatus: "404 No Such Order"})
+	require.NoError(t, err)
+	assert.Empty(t, errs)
+
+	// the statuses without the expected text aren't checked
+	errs, err = NewChecker().Check(test, &models.Result{ResponseStatusCode: 500, ResponseStatus: "500 Oops"})
+	require.NoError(t, err)
+	assert.Empty(t, errs)
+}
+
+func TestStatusTextMismatches(t *testing.T) {
+	test := statusTextTest(map[int]string{200: "Everything Is Fine", 204: "No Content"})
+
+	errs, err := NewChecker().Check(test, &models.Result{ResponseStatusCode: 200, ResponseStatus: "200 OK"})
+	require.NoError(t, err)
+	require.Len(t, errs, 1)
+	assert.EqualError(t, errs[0], `response status text "OK" of status 200 does not match expected "Everything Is Fine"`)
+
+	errs, err = NewChecker().Check(test, &models.Result{ResponseStatusCode: 204, ResponseStatus: "204"})
+	require.NoError(t, err)
+	require.Len(t, errs, 1)
+	assert.EqualError(t, errs[0], `response status text "" of status 204 does not match expected "No Content"`)
+}
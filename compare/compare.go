@@ -13,42 +13,68 @@ type CompareParams struct {
 	IgnoreArraysOrdering bool `json:"ignoreArraysOrdering" yaml:"ignoreArraysOrdering"`
 	DisallowExtraFields  bool `json:"disallowExtraFields" yaml:"disallowExtraFields"`
 	IgnoreDbOrdering     bool `json:"IgnoreDbOrdering" yaml:"ignoreDbOrdering"`
-	failFast             bool // End compare operation after first error
+	// NormalizeKeys is the mode of object keys normalization applied to the response body
+	// before comparing: snake, camel or lower
+	NormalizeKeys string `json:"normalizeKeys" yaml:"normalizeKeys"`
+	// NumberTypeStrict makes the integers and the floats of the same value differ, e.g. 1 and 1.0,
+	// the numbers must be decoded with json.Decoder.UseNumber
+	NumberTypeStrict bool `json:"numberTypeStrict" yaml:"numberTypeStrict"`
+	// CustomFuncs are the functions which can be referenced in 'expected' as $custom:name
+	CustomFuncs map[string]CustomFunc `json:"-" yaml:"-"`
+	failFast    bool                  // End compare operation after first error
+	references  *references           // values of $capture and $ref
 }
 
+// CustomFunc checks the actual value, non-nil error means that the value doesn't satisfy the check
+type CustomFunc func(path string, actual interface{}) error
+
 type leafsMatchType int
 
 const (
 	pure leafsMatchType = iota
 	regex
+	custom
+	numberRange
+	similar
+	reference
 )
 
-var regexExprRx = regexp.MustCompile(`^\$matchRegexp\((.+)\)$`)
+// absentValue is the expected value of a key which must not be present in the actual map
+const absentValue = "$absent"
+
+var (
+	regexExprRx  = regexp.MustCompile(`^\$matchRegexp\((.+)\)$`)
+	customExprRx = regexp.MustCompile(`^\$custom:(\w+)$`)
+)
 
 // Compare compares values as plain text
 // It can be compared several ways:
-// - Pure values: should be equal
-// - Regex: try to compile 'expected' as regex and match 'actual' with it
+//   - Pure values: should be equal
+//   - Regex: try to compile 'expected' as regex and match 'actual' with it
 //     It activates on following syntax: $matchRegexp(%EXPECTED_VALUE%)
+//   - Custom: call the function registered in params.CustomFuncs with 'actual'
+//     It activates on following syntax: $custom:%FUNCTION_NAME%
+//   - Absent: the key of a map must not be present in 'actual'
+//     It activates on following syntax: $absent
+//   - Range: 'actual' must be a number in the range
+//     It activates on following syntax: $gt:N, $gte:N, $lt:N, $lte:N, $between:MIN,MAX
+//   - Similar: 'actual' must be a string whose normalized Levenshtein similarity to the text is at least THRESHOLD
+//     It activates on following syntax: $matchSimilar:THRESHOLD:TEXT
+//   - Reference: 'actual' of any type is captured by the name, the other values referencing the name must be equal to it
+//     It activates on following syntax: $capture:%NAME% and $ref:%NAME%
 func Compare(expected, actual interface{}, params CompareParams) []error {
-	return compareBranch("$", expected, actual, &params)
+	params.references = newReferences()
+	errors := compareBranch("$", expected, actual, &params)
+	return append(errors, params.references.errors()...)
 }
 
 func compareBranch(path string, expected, actual interface{}, params *CompareParams) []error {
-	expectedType := getType(expected)
-	actualType := getType(actual)
-	var errors []error
-
-	// compare types
-	if leafMatchType(expected) != regex && expectedType != actualType {
-		errors = append(errors, makeError(path, "types do not match", expectedType, actualType))
+	if errors, ok := compareLeaf(path, expected, actual, params); ok {
 		return errors
 	}
 
-	// compare scalars
-	if isScalarType(actualType) && !params.IgnoreValues {
-		return compareLeafs(path, expected, actual)
-	}
+	actualType := getType(actual)
+	var errors []error
 
 	// compare arrays
 	if actualType == "array" {
@@ -61,12 +87,16 @@ func compareBranch(path string, expected, actual interface{}, params *ComparePar
 		}
 
 		if params.IgnoreArraysOrdering {
-			expectedArray, actualArray = getUnmatchedArrays(expectedArray, actualArray, params)
+			expectedArray, actualArray = getUnmatchedArrays(path, expectedArray, actualArray, params)
 		}
 
 		// iterate over children
 		for i, item := range expectedArray {
 			subPath := fmt.Sprintf("%s[%d]", path, i)
+			if params.IgnoreArraysOrdering {
+				// the indexes of the unmatched items are not their positions in the arrays
+				subPath = path + "[*]"
+			}
 			res := compareBranch(subPath, item, actualArray[i], params)
 			errors = append(errors, res...)
 			if params.failFast && len(errors) != 0 {
@@ -80,12 +110,24 @@ func compareBranch(path string, expected, actual interface{}, params *ComparePar
 		expectedRef := reflect.ValueOf(expected)
 		actualRef := reflect.ValueOf(actual)
 
-		if params.DisallowExtraFields && expectedRef.Len() != actualRef.Len() {
-			errors = append(errors, makeError(path, "map lengths do not match", expectedRef.Len(), actualRef.Len()))
+		expectedLen := expectedRef.Len() - countAbsentKeys(expectedRef)
+		if params.DisallowExtraFields && expectedLen != actualRef.Len() {
+			errors = append(errors, makeError(path, "map lengths do not match", expectedLen, actualRef.Len()))
 			return errors
 		}
 
 		for _, key := range expectedRef.MapKeys() {
+			// check keys absence
+			if isAbsent(expectedRef.MapIndex(key).Interface()) {
+				if ok := actualRef.MapIndex(key); ok.IsValid() {
+					errors = append(errors, makeError(path, "key must be absent", "<absent>", key.String()))
+					if params.failFast {
+						return errors
+					}
+				}
+				continue
+			}
+
 			// check keys presence
 			if ok := actualRef.MapIndex(key); !ok.IsValid() {
 				errors = append(errors, makeError(path, "key is missing", key.String(), "<missing>"))
@@ -113,6 +155,58 @@ func compareBranch(path string, expected, actual interface{}, params *ComparePar
 	return errors
 }
 
+// compareLeaf compares the values matched as a whole: the number types, the references, the custom functions,
+// the ranges, the similar strings, the types and the scalars. It returns false if the values are arrays or maps
+// of the same type, which are compared item by item. The same matching is used by Compare and Diff.
+func compareLeaf(path string, expected, actual interface{}, params *CompareParams) ([]error, bool) {
+	if params.NumberTypeStrict {
+		if errs := compareNumberTypes(path, expected, actual); len(errs) != 0 {
+			return errs, true
+		}
+		expected, actual = numberValue(expected), numberValue(actual)
+	}
+
+	switch leafMatchType(expected) {
+	case reference:
+		// the references are checked after the whole value is compared
+		if !params.IgnoreValues && params.references != nil {
+			params.references.add(path, expected, actual)
+		}
+		return nil, true
+	case custom:
+		// custom functions check values of any type
+		if params.IgnoreValues {
+			return nil, true
+		}
+		return compareCustom(path, expected, actual, params), true
+	case numberRange:
+		// ranges are compared with numbers of any type
+		if params.IgnoreValues {
+			return nil, true
+		}
+		return compareRange(path, expected, actual), true
+	case similar:
+		if params.IgnoreValues {
+			return nil, true
+		}
+		return compareSimilar(path, expected, actual), true
+	}
+
+	expectedType := getType(expected)
+	actualType := getType(actual)
+	if leafMatchType(expected) != regex && expectedType != actualType {
+		return []error{makeError(path, "types do not match", expectedType, actualType)}, true
+	}
+
+	if isScalarType(actualType) {
+		if params.IgnoreValues {
+			return nil, true
+		}
+		return compareLeafs(path, expected, actual), true
+	}
+	return nil, false
+}
+
 func getType(value interface{}) string {
 	if value == nil {
 		return "nil"
@@ -182,6 +276,23 @@ func compareRegex(path string, expected, actual interface{}) (errors []error) {
 	return nil
 }
 
+func compareCustom(path string, expected, actual interface{}, params *CompareParams) (errors []error) {
+	name := customExprRx.FindStringSubmatch(expected.(string))[1]
+
+	fn, ok := params.CustomFuncs[name]
+	if !ok {
+		errors = append(errors, makeError(path, "custom compare function is not registered", name, "<missing>"))
+		return errors
+	}
+
+	if err := fn(path, actual); err != nil {
+		errors = append(errors, makeError(path, "custom compare function "+name+" failed: "+err.Error(), expected, actual))
+		return errors
+	}
+
+	return nil
+}
+
 func retrieveRegexStr(expr string) string {
 
 	if matches := regexExprRx.FindStringSubmatch(expr); matches != nil {
@@ -201,9 +312,40 @@ func leafMatchType(expected interface{}) leafsMatchType {
 		return regex
 	}
 
+	if matches := customExprRx.FindStringSubmatch(val); matches != nil {
+		return custom
+	}
+
+	if matches := rangeExprRx.FindStringSubmatch(val); matches != nil {
+		return numberRange
+	}
+
+	if matches := similarExprRx.FindStringSubmatch(val); matches != nil {
+		return similar
+	}
+
+	if matches := referenceExprRx.FindStringSubmatch(val); matches != nil {
+		return reference
+	}
+
 	return pure
 }
 
+func isAbsent(expected interface{}) bool {
+	val, ok := expected.(string)
+	return ok && val == absentValue
+}
+
+func countAbsentKeys(m reflect.Value) int {
+	count := 0
+	for _, key := range m.MapKeys() {
+		if isAbsent(m.MapIndex(key).Interface()) {
+			count++
+		}
+	}
+	return count
+}
+
 func makeError(path, msg string, expected, actual interface{}) error {
 	return fmt.Errorf(
 		"at path %s %s:\n     expected: %s\n       actual: %s",
@@ -225,7 +367,7 @@ func convertToArray(array interface{}) []interface{} {
 }
 
 // For every elem in "expected" try to find elem in "actual". Returns arrays without matching.
-func getUnmatchedArrays(expected, actual []interface{}, params *CompareParams) ([]interface{}, []interface{}) {
+func getUnmatchedArrays(path string, expected, actual []interface{}, params *CompareParams) ([]interface{}, []interface{}) {
 	expectedError := make([]interface{}, 0)
 
 	failfastParams := *params
@@ -234,9 +376,18 @@ func getUnmatchedArrays(expected, actual []interface{}, params *CompareParams) (
 	for _, expectedElem := range expected {
 		found := false
 		for i, actualElem := range actual {
-			if len(compareBranch("", expectedElem, actualElem, &failfastParams)) == 0 {
+			// the values referenced by the items are kept only if the items match,
+			// the items whose references diverge from the recorded ones don't match
+			if params.references != nil {
+				failfastParams.references = params.references.child()
+			}
+			if len(compareBranch(path+"[*]", expectedElem, actualElem, &failfastParams)) == 0 &&
+				(params.references == nil || !failfastParams.references.conflicts()) {
 				// expectedElem match actualElem
 				found = true
+				if params.references != nil {
+					params.references.merge(failfastParams.references)
+				}
 				// remove actualElem from  actual
 				if len(actual) != 1 {
 					actual[i] = actual[len(actual)-1]
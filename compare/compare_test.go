@@ -2,11 +2,14 @@ package compare
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
 	"testing"
 
 	"github.com/fatih/color"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func makeErrorString(path, msg string, expected, actual interface{}) string {
@@ -119,6 +122,285 @@ func TestCheckRegexCantCompile(t *testing.T) {
 	}
 }
 
+func TestCheckCustomFunc(t *testing.T) {
+	var gotPath string
+	var gotValue interface{}
+	params := CompareParams{
+		CustomFuncs: map[string]CustomFunc{
+			"positive": func(path string, actual interface{}) error {
+				gotPath, gotValue = path, actual
+				if v, ok := actual.(float64); !ok || v <= 0 {
+					return errors.New("value is not positive")
+				}
+				return nil
+			},
+		},
+	}
+
+	errs := Compare(map[string]interface{}{"a": "$custom:positive"}, map[string]interface{}{"a": 1.5}, params)
+	assert.Empty(t, errs)
+	assert.Equal(t, "$.a", gotPath)
+	assert.Equal(t, 1.5, gotValue)
+
+	errs = Compare(map[string]interface{}{"a": "$custom:positive"}, map[string]interface{}{"a": "str"}, params)
+	assert.Len(t, errs, 1)
+	assert.Equal(t, makeErrorString("$.a", "custom compare function positive failed: value is not positive",
+		"$custom:positive", "str"), errs[0].Error())
+}
+
+func TestCheckCustomFuncNotRegistered(t *testing.T) {
+	errs := Compare("$custom:unknown", "1", CompareParams{})
+	assert.Len(t, errs, 1)
+	assert.Equal(t, makeErrorString("$", "custom compare function is not registered", "unknown", "<missing>"),
+		errs[0].Error())
+}
+
+func TestCompareMatchersWithIgnoreValues(t *testing.T) {
+	expected := map[string]interface{}{
+		"id":      "$custom:unknown",
+		"score":   "$between:0,1",
+		"message": "$matchSimilar:0.9:Your order #1234 has been shipped",
+		"name":    "$matchRegexp(^j)",
+		"total":   2.0,
+	}
+	actual := map[string]interface{}{
+		"id":      1.0,
+		"score":   5.0,
+		"message": "Your order has been cancelled",
+		"name":    "mary",
+		"total":   3.0,
+	}
+
+	// the values are ignored for all the kinds of the expectations
+	assert.Empty(t, Compare(expected, actual, CompareParams{IgnoreValues: true}))
+	assert.Empty(t, Diff(expected, actual, CompareParams{IgnoreValues: true}))
+	assert.Len(t, Compare(expected, actual, CompareParams{}), 5)
+}
+
+func TestCompareRootArraysWithIgnoreArraysOrdering(t *testing.T) {
+	expected := []interface{}{
+		map[string]interface{}{"id": 1.0},
+		map[string]interface{}{"id": 2.0},
+	}
+	actual := []interface{}{
+		map[string]interface{}{"id": 2.0, "name": "pen"},
+		map[string]interface{}{"id": 1.0},
+	}
+
+	errs := Compare(expected, actual, CompareParams{IgnoreArraysOrdering: true})
+	assert.Empty(t, errs)
+
+	errs = Compare(expected, actual, CompareParams{IgnoreArraysOrdering: true, DisallowExtraFields: true})
+	require.Len(t, errs, 1)
+	// the unmatched item is the second one of the expected array, its index is not reported as $[0]
+	assert.Equal(t, makeErrorString("$[*]", "map lengths do not match", 1, 2), errs[0].Error())
+}
+
+func TestCompareRanges(t *testing.T) {
+	expected := map[string]interface{}{
+		"score":  "$between:0,1",
+		"count":  "$gt:0",
+		"errors": "$lte:0",
+		"items":  []interface{}{"$lt:10", "$gte:10"},
+	}
+
+	errs := Compare(expected, map[string]interface{}{
+		"score":  0.75,
+		"count":  3.0,
+		"errors": 0.0,
+		"items":  []interface{}{9.5, 10.0},
+	}, CompareParams{})
+	assert.Empty(t, errs)
+
+	errs = Compare(expected, map[string]interface{}{
+		"score":  1.5,
+		"count":  0.0,
+		"errors": "none",
+		"items":  []interface{}{10.0, 9.5},
+	}, CompareParams{})
+	assert.Len(t, errs, 5)
+
+	errs = Compare(map[string]interface{}{"score": "$between:0,1"}, map[string]interface{}{"score": 1.5}, CompareParams{})
+	assert.Len(t, errs, 1)
+	assert.Equal(t, makeErrorString("$.score", "value is out of range", "$between:0,1", 1.5), errs[0].Error())
+
+	errs = Compare(map[string]interface{}{"count": "$gt:0"}, map[string]interface{}{"count": "3"}, CompareParams{})
+	assert.Len(t, errs, 1)
+	assert.Equal(t, makeErrorString("$.count", "value is not a number", "$gt:0", "3"), errs[0].Error())
+}
+
+func TestCompareRangesWithIgnoreArraysOrdering(t *testing.T) {
+	errs := Compare(
+		[]interface{}{"$gte:10", "$lt:10"},
+		[]interface{}{9.5, 10.0},
+		CompareParams{IgnoreArraysOrdering: true},
+	)
+	assert.Empty(t, errs)
+}
+
+func TestValidateRanges(t *testing.T) {
+	assert.NoError(t, ValidateRanges(`{"a": "$gt:0", "b": "$between:-1.5, 2", "c": "$lt:{{ $max }}"}`))
+	assert.EqualError(t, ValidateRanges(`{"a": "$gt:"}`), `range $gt:: operand "" is not a number`)
+	assert.EqualError(t, ValidateRanges(`{"a": "$between:1"}`),
+		`range $between:1: $between expects 2 numeric operand(s), got "1"`)
+	assert.EqualError(t, ValidateRanges(`{"a": "$between:2,1"}`),
+		"range $between:2,1: the lower bound is greater than the upper one")
+}
+
+func TestCompareSimilar(t *testing.T) {
+	expected := map[string]interface{}{
+		"message": "$matchSimilar:0.9:Your order #1234 has been shipped",
+		"note":    "$matchSimilar:0.5:Thanks: see you soon",
+	}
+
+	errs := Compare(expected, map[string]interface{}{
+		"message": "Your order #1235 has been shipped!",
+		"note":    "Thanks, see you later",
+	}, CompareParams{})
+	assert.Empty(t, errs)
+
+	errs = Compare(expected, map[string]interface{}{
+		"message": "Your order has been cancelled",
+		"note":    7.0,
+	}, CompareParams{})
+	require.Len(t, errs, 2)
+	messages := []string{errs[0].Error(), errs[1].Error()}
+	sort.Strings(messages)
+	assert.Equal(t, makeErrorString(
+		"$.message",
+		"value is not similar enough: similarity 0.61 is less than 0.9",
+		"Your order #1234 has been shipped",
+		"Your order has been cancelled",
+	), messages[0])
+	assert.Equal(t, makeErrorString("$.note", "value is not a string", "$matchSimilar:0.5:Thanks: see you soon", 7.0), messages[1])
+}
+
+func TestSimilarity(t *testing.T) {
+	assert.Equal(t, 1.0, Similarity("", ""))
+	assert.Equal(t, 0.0, Similarity("abc", ""))
+	assert.Equal(t, 1.0, Similarity("привет", "привет"))
+	assert.InDelta(t, 1-1.0/6, Similarity("привет", "привед"), 1e-9)
+	assert.InDelta(t, 1-3.0/7, Similarity("kitten", "sitting"), 1e-9)
+}
+
+func TestValidateSimilarities(t *testing.T) {
+	assert.NoError(t, ValidateSimilarities(`{"a": "$matchSimilar:0.8:text: with colons", "b": "$matchSimilar:{{ $min }}:text"}`))
+	assert.EqualError(t, ValidateSimilarities(`{"a": "$matchSimilar:1.5:text"}`),
+		`similarity threshold "1.5" must be a number from 0 to 1`)
+	assert.EqualError(t, ValidateSimilarities(`{"a": "$matchSimilar:high:text"}`),
+		`similarity threshold "high" must be a number from 0 to 1`)
+}
+
+func TestCompareReferences(t *testing.T) {
+	expected := map[string]interface{}{
+		"requestId": "$capture:rid",
+		"meta":      map[string]interface{}{"requestId": "$ref:rid", "trace": []interface{}{"$ref:rid"}},
+		"user":      "$capture:user",
+		"owner":     "$ref:user",
+	}
+
+	errs := Compare(expected, map[string]interface{}{
+		"requestId": "f3a1",
+		"meta":      map[string]interface{}{"requestId": "f3a1", "trace": []interface{}{"f3a1"}},
+		"user":      map[string]interface{}{"id": 1.0},
+		"owner":     map[string]interface{}{"id": 1.0},
+	}, CompareParams{})
+	assert.Empty(t, errs)
+
+	errs = Compare(expected, map[string]interface{}{
+		"requestId": "f3a1",
+		"meta":      map[string]interface{}{"requestId": "f3a1", "trace": []interface{}{"e9b2"}},
+		"user":      map[string]interface{}{"id": 1.0},
+		"owner":     map[string]interface{}{"id": 2.0},
+	}, CompareParams{})
+	require.Len(t, errs, 2)
+	assert.Equal(t, makeErrorString("$.meta.trace[0]", "value differs from $capture:rid at path $.requestId", "f3a1", "e9b2"),
+		errs[0].Error())
+	assert.Equal(t, makeErrorString("$.owner", "value differs from $capture:user at path $.user", "map[id:1]", "map[id:2]"),
+		errs[1].Error())
+}
+
+func TestCompareReferenceWithoutCapture(t *testing.T) {
+	errs := Compare(map[string]interface{}{"id": "$ref:rid"}, map[string]interface{}{"id": "f3a1"}, CompareParams{})
+	require.Len(t, errs, 1)
+	assert.Equal(t, makeErrorString("$.id", "value is not captured", "$capture:rid", "<missing>"), errs[0].Error())
+}
+
+func TestCompareReferencesOfArraysWithIgnoreArraysOrdering(t *testing.T) {
+	expected := []interface{}{
+		map[string]interface{}{"kind": "order", "id": "$capture:order"},
+		map[string]interface{}{"kind": "payment", "orderId": "$ref:order"},
+	}
+	params := CompareParams{IgnoreArraysOrdering: true}
+
+	errs := Compare(expected, []interface{}{
+		map[string]interface{}{"kind": "payment", "orderId": 7.0},
+		map[string]interface{}{"kind": "order", "id": 7.0},
+	}, params)
+	assert.Empty(t, errs)
+
+	errs = Compare(expected, []interface{}{
+		map[string]interface{}{"kind": "payment", "orderId": 8.0},
+		map[string]interface{}{"kind": "order", "id": 7.0},
+	}, params)
+	require.Len(t, errs, 1)
+	assert.Equal(t, makeErrorString("$[*].orderId", "value differs from $capture:order at path $[*].id", 7.0, 8.0),
+		errs[0].Error())
+}
+
+func TestCompareReferencesPairItemsWithIgnoreArraysOrdering(t *testing.T) {
+	// the lines differ only in the referenced field, the first one matching the other fields isn't the right one
+	expected := []interface{}{
+		map[string]interface{}{"kind": "order", "id": "$capture:order"},
+		map[string]interface{}{"kind": "line", "orderId": "$ref:order"},
+		map[string]interface{}{"kind": "line"},
+	}
+	actual := []interface{}{
+		map[string]interface{}{"kind": "line", "orderId": 8.0},
+		map[string]interface{}{"kind": "line", "orderId": 7.0},
+		map[string]interface{}{"kind": "order", "id": 7.0},
+	}
+
+	assert.Empty(t, Compare(expected, actual, CompareParams{IgnoreArraysOrdering: true}))
+}
+
+func TestCompareAbsentKeys(t *testing.T) {
+	expected := map[string]interface{}{
+		"id":   1,
+		"user": map[string]interface{}{"name": "john", "password": "$absent"},
+	}
+
+	errs := Compare(expected, map[string]interface{}{
+		"id":   1,
+		"user": map[string]interface{}{"name": "john"},
+	}, CompareParams{DisallowExtraFields: true})
+	assert.Empty(t, errs)
+
+	errs = Compare(expected, map[string]interface{}{
+		"id":   1,
+		"user": map[string]interface{}{"name": "john", "password": nil},
+	}, CompareParams{})
+	assert.Len(t, errs, 1)
+	assert.Equal(t, makeErrorString("$.user", "key must be absent", "<absent>", "password"), errs[0].Error())
+}
+
+func TestCompareAbsentKeysInArrays(t *testing.T) {
+	expected := []interface{}{
+		map[string]interface{}{"id": 1, "token": "$absent"},
+		map[string]interface{}{"id": 2, "token": "$absent"},
+	}
+	actual := []interface{}{
+		map[string]interface{}{"id": 2},
+		map[string]interface{}{"id": 1, "token": "secret"},
+	}
+
+	errs := Compare(expected, actual, CompareParams{})
+	assert.Len(t, errs, 3)
+
+	errs = Compare(expected, actual, CompareParams{IgnoreArraysOrdering: true})
+	assert.Len(t, errs, 1)
+}
+
 func TestCompareEqualArrays(t *testing.T) {
 	array1 := []string{"1", "2"}
 	array2 := []string{"1", "2"}
@@ -0,0 +1,135 @@
+package compare
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Diff returns the differences between the expected and actual values as lines prefixed with
+// the JSON path: "-" lines hold the expected values and "+" lines hold the actual ones.
+// Values are compared the same way as by Compare, so matching regexps produce no lines.
+// The lines are plain text, the outputs colorize them.
+// Empty string is returned if there are no differences.
+func Diff(expected, actual interface{}, params CompareParams) string {
+	var lines []string
+	params.references = newReferences()
+	diffBranch("$", expected, actual, &params, &lines)
+	params.references.diverged(func(path string, name string, captured *referencedValue, actual interface{}) {
+		if captured == nil {
+			changed(path, "$capture:"+name, actual, &lines)
+			return
+		}
+		changed(path, captured.value, actual, &lines)
+	})
+	return strings.Join(lines, "\n")
+}
+
+func diffBranch(path string, expected, actual interface{}, params *CompareParams, lines *[]string) {
+	if errors, ok := compareLeaf(path, expected, actual, params); ok {
+		if len(errors) != 0 {
+			changed(path, expected, actual, lines)
+		}
+		return
+	}
+
+	if getType(actual) == "array" {
+		diffArrays(path, convertToArray(expected), convertToArray(actual), params, lines)
+		return
+	}
+
+	diffMaps(path, expected, actual, params, lines)
+}
+
+func diffArrays(path string, expected, actual []interface{}, params *CompareParams, lines *[]string) {
+	if params.IgnoreArraysOrdering {
+		// the copy is needed because the function reorders the actual array
+		actualCopy := append([]interface{}{}, actual...)
+		unmatchedExpected, unmatchedActual := getUnmatchedArrays(path, expected, actualCopy, params)
+		for _, item := range unmatchedExpected {
+			removed(path+"[*]", item, lines)
+		}
+		for _, item := range unmatchedActual {
+			added(path+"[*]", item, lines)
+		}
+		return
+	}
+
+	for i := 0; i < len(expected) || i < len(actual); i++ {
+		subPath := fmt.Sprintf("%s[%d]", path, i)
+		switch {
+		case i >= len(actual):
+			removed(subPath, expected[i], lines)
+		case i >= len(expected):
+			added(subPath, actual[i], lines)
+		default:
+			diffBranch(subPath, expected[i], actual[i], params, lines)
+		}
+	}
+}
+
+func diffMaps(path string, expected, actual interface{}, params *CompareParams, lines *[]string) {
+	expectedRef := reflect.ValueOf(expected)
+	actualRef := reflect.ValueOf(actual)
+
+	keys := map[string]reflect.Value{}
+	for _, key := range expectedRef.MapKeys() {
+		keys[key.String()] = key
+	}
+	if params.DisallowExtraFields {
+		for _, key := range actualRef.MapKeys() {
+			keys[key.String()] = key
+		}
+	}
+
+	names := make([]string, 0, len(keys))
+	for name := range keys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		key := keys[name]
+		subPath := path + "." + name
+		expectedValue := expectedRef.MapIndex(key)
+		actualValue := actualRef.MapIndex(key)
+		switch {
+		case expectedValue.IsValid() && isAbsent(expectedValue.Interface()):
+			if actualValue.IsValid() {
+				added(subPath, actualValue.Interface(), lines)
+			}
+		case !actualValue.IsValid():
+			removed(subPath, expectedValue.Interface(), lines)
+		case !expectedValue.IsValid():
+			added(subPath, actualValue.Interface(), lines)
+		default:
+			diffBranch(subPath, expectedValue.Interface(), actualValue.Interface(), params, lines)
+		}
+	}
+}
+
+func changed(path string, expected, actual interface{}, lines *[]string) {
+	removed(path, expected, lines)
+	added(path, actual, lines)
+}
+
+func removed(path string, value interface{}, lines *[]string) {
+	*lines = append(*lines, fmt.Sprintf("- %s: %s", path, diffValue(value)))
+}
+
+func added(path string, value interface{}, lines *[]string) {
+	*lines = append(*lines, fmt.Sprintf("+ %s: %s", path, diffValue(value)))
+}
+
+func diffValue(value interface{}) string {
+	buf := &bytes.Buffer{}
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
@@ -0,0 +1,111 @@
+package compare
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decodeJson(t *testing.T, data string) interface{} {
+	var value interface{}
+	require.NoError(t, json.Unmarshal([]byte(data), &value))
+	return value
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+		actual   string
+		params   CompareParams
+		diff     string
+	}{
+		{
+			name:     "equal",
+			expected: `{"id": 1, "name": "$matchRegexp(^j)"}`,
+			actual:   `{"id": 1, "name": "john", "extra": true}`,
+		},
+		{
+			name:     "changed, removed and extra fields",
+			expected: `{"user": {"id": 1, "name": "john", "email": "john@example.com"}}`,
+			actual:   `{"user": {"id": 2, "name": "john", "role": "admin"}}`,
+			diff: "- $.user.email: \"john@example.com\"\n" +
+				"- $.user.id: 1\n" +
+				"+ $.user.id: 2",
+		},
+		{
+			name:     "disallowed extra fields",
+			expected: `{"id": 1}`,
+			actual:   `{"id": 1, "role": "admin"}`,
+			params:   CompareParams{DisallowExtraFields: true},
+			diff:     `+ $.role: "admin"`,
+		},
+		{
+			name:     "arrays",
+			expected: `{"items": [1, {"id": 2}, 3]}`,
+			actual:   `{"items": [1, {"id": "2"}]}`,
+			diff: "- $.items[1].id: 2\n" +
+				"+ $.items[1].id: \"2\"\n" +
+				"- $.items[2]: 3",
+		},
+		{
+			name:     "arrays in any order",
+			expected: `[1, 2, 3]`,
+			actual:   `[3, 4, 1]`,
+			params:   CompareParams{IgnoreArraysOrdering: true},
+			diff: "- $[*]: 2\n" +
+				"+ $[*]: 4",
+		},
+		{
+			name:     "ranges",
+			expected: `{"score": "$between:0,1", "count": "$gt:0"}`,
+			actual:   `{"score": 0.5, "count": 0}`,
+			diff: "- $.count: \"$gt:0\"\n" +
+				"+ $.count: 0",
+		},
+		{
+			name:     "custom functions",
+			expected: `{"id": "$custom:positive", "count": "$custom:positive"}`,
+			actual:   `{"id": 1, "count": -1}`,
+			params: CompareParams{CustomFuncs: map[string]CustomFunc{
+				"positive": func(path string, actual interface{}) error {
+					if n, ok := actual.(float64); !ok || n <= 0 {
+						return fmt.Errorf("%s must be positive", path)
+					}
+					return nil
+				},
+			}},
+			diff: "- $.count: \"$custom:positive\"\n" +
+				"+ $.count: -1",
+		},
+		{
+			name:     "references",
+			expected: `{"requestId": "$capture:rid", "meta": {"requestId": "$ref:rid"}, "total": 2}`,
+			actual:   `{"requestId": "f3a1", "meta": {"requestId": "e9b2"}, "total": 3}`,
+			diff: "- $.total: 2\n" +
+				"+ $.total: 3\n" +
+				"- $.meta.requestId: \"f3a1\"\n" +
+				"+ $.meta.requestId: \"e9b2\"",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff := Diff(decodeJson(t, tt.expected), decodeJson(t, tt.actual), tt.params)
+			assert.Equal(t, tt.diff, diff)
+		})
+	}
+}
+
+func TestDiffIsPlainText(t *testing.T) {
+	noColor := color.NoColor
+	color.NoColor = false
+	defer func() { color.NoColor = noColor }()
+
+	// the diff is stored in the result and the reports, so it's colorized by the outputs only
+	diff := Diff(decodeJson(t, `{"id": 1}`), decodeJson(t, `{"id": 2}`), CompareParams{})
+	assert.Equal(t, "- $.id: 1\n+ $.id: 2", diff)
+}
@@ -0,0 +1,136 @@
+package compare
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Modes of object keys normalization
+const (
+	KeysSnake = "snake" // userId, UserID -> user_id
+	KeysCamel = "camel" // user_id, UserId -> userId
+	KeysLower = "lower" // userId, User_ID -> userid, user_id
+)
+
+// KeyNormalizer returns the function which normalizes object keys in the given mode,
+// nil function is returned for the empty mode
+func KeyNormalizer(mode string) (func(string) string, error) {
+	switch mode {
+	case "":
+		return nil, nil
+	case KeysSnake:
+		return toSnakeCase, nil
+	case KeysCamel:
+		return toCamelCase, nil
+	case KeysLower:
+		return strings.ToLower, nil
+	default:
+		return nil, fmt.Errorf(
+			"unknown keys normalization %q, expected one of: %s, %s, %s",
+			mode, KeysSnake, KeysCamel, KeysLower,
+		)
+	}
+}
+
+// NormalizeKeys returns a copy of the decoded JSON value with the keys of all nested objects
+// normalized in the given mode. It fails if different keys of an object become the same.
+func NormalizeKeys(value interface{}, mode string) (interface{}, error) {
+	normalize, err := KeyNormalizer(mode)
+	if err != nil || normalize == nil {
+		return value, err
+	}
+	return normalizeKeys("$", value, normalize)
+}
+
+func normalizeKeys(path string, value interface{}, normalize func(string) string) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		// iterate in the stable order to always report the same collision
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		res := make(map[string]interface{}, len(v))
+		origin := make(map[string]string, len(v))
+		for _, key := range keys {
+			normalized := normalize(key)
+			if other, ok := origin[normalized]; ok {
+				return nil, fmt.Errorf(
+					"path %s: keys %q and %q are both normalized to %q",
+					path, other, key, normalized,
+				)
+			}
+			origin[normalized] = key
+
+			item, err := normalizeKeys(path+"."+normalized, v[key], normalize)
+			if err != nil {
+				return nil, err
+			}
+			res[normalized] = item
+		}
+		return res, nil
+	case []interface{}:
+		res := make([]interface{}, len(v))
+		for i, item := range v {
+			var err error
+			res[i], err = normalizeKeys(fmt.Sprintf("%s[%d]", path, i), item, normalize)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return res, nil
+	default:
+		return value, nil
+	}
+}
+
+// splitWords splits the key into lowercased words by separators and case changes,
+// abbreviations are kept together: "UserID" -> ["user", "id"], "HTTPServer" -> ["http", "server"]
+func splitWords(key string) []string {
+	var words []string
+	var word []rune
+
+	flush := func() {
+		if len(word) != 0 {
+			words = append(words, strings.ToLower(string(word)))
+			word = word[:0]
+		}
+	}
+
+	runes := []rune(key)
+	for i, r := range runes {
+		switch {
+		case r == '_' || r == '-' || r == ' ':
+			flush()
+			continue
+		case unicode.IsUpper(r) && len(word) != 0:
+			prev := runes[i-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if !unicode.IsUpper(prev) || nextIsLower {
+				flush()
+			}
+		}
+		word = append(word, r)
+	}
+	flush()
+
+	return words
+}
+
+func toSnakeCase(key string) string {
+	return strings.Join(splitWords(key), "_")
+}
+
+func toCamelCase(key string) string {
+	words := splitWords(key)
+	for i := 1; i < len(words); i++ {
+		runes := []rune(words[i])
+		runes[0] = unicode.ToUpper(runes[0])
+		words[i] = string(runes)
+	}
+	return strings.Join(words, "")
+}
@@ -0,0 +1,66 @@
+package compare
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeKeyModes(t *testing.T) {
+	tests := []struct {
+		key   string
+		snake string
+		camel string
+		lower string
+	}{
+		{key: "userId", snake: "user_id", camel: "userId", lower: "userid"},
+		{key: "user_id", snake: "user_id", camel: "userId", lower: "user_id"},
+		{key: "UserID", snake: "user_id", camel: "userId", lower: "userid"},
+		{key: "HTTPServerName", snake: "http_server_name", camel: "httpServerName", lower: "httpservername"},
+		{key: "created-at", snake: "created_at", camel: "createdAt", lower: "created-at"},
+		{key: "id", snake: "id", camel: "id", lower: "id"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			assert.Equal(t, tt.snake, toSnakeCase(tt.key))
+			assert.Equal(t, tt.camel, toCamelCase(tt.key))
+
+			lower, err := KeyNormalizer(KeysLower)
+			require.NoError(t, err)
+			assert.Equal(t, tt.lower, lower(tt.key))
+		})
+	}
+}
+
+func TestNormalizeKeysOfNestedObjects(t *testing.T) {
+	value := map[string]interface{}{
+		"orderId": 1,
+		"Items": []interface{}{
+			map[string]interface{}{"itemName": "book", "unitPrice": map[string]interface{}{"AmountCents": 100}},
+		},
+	}
+
+	normalized, err := NormalizeKeys(value, KeysSnake)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"order_id": 1,
+		"items": []interface{}{
+			map[string]interface{}{"item_name": "book", "unit_price": map[string]interface{}{"amount_cents": 100}},
+		},
+	}, normalized)
+}
+
+func TestNormalizeKeysCollision(t *testing.T) {
+	value := map[string]interface{}{
+		"data": map[string]interface{}{"userId": 1, "user_id": 2},
+	}
+
+	_, err := NormalizeKeys(value, KeysSnake)
+	assert.EqualError(t, err, `path $.data: keys "userId" and "user_id" are both normalized to "user_id"`)
+}
+
+func TestNormalizeKeysUnknownMode(t *testing.T) {
+	_, err := NormalizeKeys(map[string]interface{}{}, "kebab")
+	assert.Error(t, err)
+}
@@ -0,0 +1,44 @@
+package compare
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// compareNumberTypes compares the representations of the numbers decoded with json.Decoder.UseNumber,
+// an integer doesn't match a float of the same value, e.g. 1 and 1.0
+func compareNumberTypes(path string, expected, actual interface{}) []error {
+	expectedNumber, ok := expected.(json.Number)
+	if !ok {
+		return nil
+	}
+	actualNumber, ok := actual.(json.Number)
+	if !ok {
+		return nil
+	}
+	if expectedType, actualType := numberType(expectedNumber), numberType(actualNumber); expectedType != actualType {
+		return []error{makeError(path, "number types do not match", expectedType, actualType)}
+	}
+	return nil
+}
+
+// numberType returns integer for the numbers without a fraction and an exponent, float otherwise
+func numberType(n json.Number) string {
+	if strings.ContainsAny(n.String(), ".eE") {
+		return "float"
+	}
+	return "integer"
+}
+
+// numberValue converts the number decoded with json.Decoder.UseNumber to float64
+// for the values to be compared the same way as the numbers decoded by default
+func numberValue(value interface{}) interface{} {
+	n, ok := value.(json.Number)
+	if !ok {
+		return value
+	}
+	if f, err := n.Float64(); err == nil {
+		return f
+	}
+	return value
+}
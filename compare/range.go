@@ -0,0 +1,124 @@
+package compare
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	rangeExprRx = regexp.MustCompile(`^\$(gt|gte|lt|lte|between):(.*)$`)
+	// rangeExprInTextRx finds the range expressions in the text of the expected body
+	rangeExprInTextRx = regexp.MustCompile(`"\$(?:gt|gte|lt|lte|between):[^"]*"`)
+)
+
+// numRange is the range of numbers parsed from $gt:N, $gte:N, $lt:N, $lte:N or $between:MIN,MAX
+type numRange struct {
+	op       string
+	operands []float64
+}
+
+func parseRange(expr string) (*numRange, error) {
+	matches := rangeExprRx.FindStringSubmatch(expr)
+	if matches == nil {
+		return nil, fmt.Errorf("%s is not a range expression", expr)
+	}
+
+	op, args := matches[1], strings.Split(matches[2], ",")
+	want := 1
+	if op == "between" {
+		want = 2
+	}
+	if len(args) != want {
+		return nil, fmt.Errorf("range %s: $%s expects %d numeric operand(s), got %q", expr, op, want, matches[2])
+	}
+
+	r := &numRange{op: op}
+	for _, arg := range args {
+		n, err := strconv.ParseFloat(strings.TrimSpace(arg), 64)
+		if err != nil {
+			return nil, fmt.Errorf("range %s: operand %q is not a number", expr, arg)
+		}
+		r.operands = append(r.operands, n)
+	}
+	if op == "between" && r.operands[0] > r.operands[1] {
+		return nil, fmt.Errorf("range %s: the lower bound is greater than the upper one", expr)
+	}
+	return r, nil
+}
+
+// contains tells if the number is in the range, the bounds of $between are included
+func (r *numRange) contains(n float64) bool {
+	switch r.op {
+	case "gt":
+		return n > r.operands[0]
+	case "gte":
+		return n >= r.operands[0]
+	case "lt":
+		return n < r.operands[0]
+	case "lte":
+		return n <= r.operands[0]
+	default:
+		return n >= r.operands[0] && n <= r.operands[1]
+	}
+}
+
+// ValidateRanges checks the operands of the range expressions found in the expected body,
+// the expressions with variables in them are checked only when compared
+func ValidateRanges(text string) error {
+	for _, quoted := range rangeExprInTextRx.FindAllString(text, -1) {
+		expr := strings.Trim(quoted, `"`)
+		if strings.Contains(expr, "{{") {
+			continue
+		}
+		if _, err := parseRange(expr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func compareRange(path string, expected, actual interface{}) (errors []error) {
+	r, err := parseRange(expected.(string))
+	if err != nil {
+		errors = append(errors, makeError(path, "can not parse range: "+err.Error(), expected, actual))
+		return errors
+	}
+
+	n, ok := toNumber(actual)
+	if !ok {
+		errors = append(errors, makeError(path, "value is not a number", expected, actual))
+		return errors
+	}
+
+	if !r.contains(n) {
+		errors = append(errors, makeError(path, "value is out of range", expected, actual))
+		return errors
+	}
+
+	return nil
+}
+
+func toNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case json.Number:
+		n, err := v.Float64()
+		return n, err == nil
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,147 @@
+package compare
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+)
+
+// referenceExprRx matches $capture:name, which captures the actual value, and $ref:name,
+// which must be equal to the captured value
+var referenceExprRx = regexp.MustCompile(`^\$(capture|ref):(\w+)$`)
+
+type referencedValue struct {
+	path  string
+	value interface{}
+}
+
+// references are the values captured by $capture and referenced by $ref while comparing. The references
+// are resolved after the whole value is compared since the keys of the maps are visited in random order.
+type references struct {
+	captured map[string]referencedValue
+	refs     map[string][]referencedValue
+	// parent has the values recorded before the item of the array compared regardless of the order
+	parent *references
+}
+
+func newReferences() *references {
+	return &references{
+		captured: map[string]referencedValue{},
+		refs:     map[string][]referencedValue{},
+	}
+}
+
+// add records the actual value of $capture or $ref, the values captured under the same name again
+// are checked as the references
+func (r *references) add(path string, expected, actual interface{}) {
+	matches := referenceExprRx.FindStringSubmatch(expected.(string))
+	kind, name := matches[1], matches[2]
+	value := referencedValue{path: path, value: actual}
+	if _, ok := r.captured[name]; kind == "capture" && !ok {
+		r.captured[name] = value
+		return
+	}
+	r.refs[name] = append(r.refs[name], value)
+}
+
+// child returns the references of an item of the array compared regardless of the order,
+// they are merged into r only if the item matches
+func (r *references) child() *references {
+	child := newReferences()
+	child.parent = r
+	return child
+}
+
+// capturedValue returns the value captured under the name by r or by its parents, the first capture wins
+func (r *references) capturedValue(name string) (referencedValue, bool) {
+	var value referencedValue
+	found := false
+	for ; r != nil; r = r.parent {
+		if captured, ok := r.captured[name]; ok {
+			value, found = captured, true
+		}
+	}
+	return value, found
+}
+
+// conflicts tells if the values recorded by the item of the array diverge from each other or from the values
+// recorded by its parents, so the item doesn't match. The values captured later can't be checked yet.
+func (r *references) conflicts() bool {
+	for name, refs := range r.refs {
+		if captured, ok := r.capturedValue(name); ok {
+			for _, ref := range refs {
+				if !reflect.DeepEqual(captured.value, ref.value) {
+					return true
+				}
+			}
+		}
+	}
+	for name, value := range r.captured {
+		// the value captured by a parent is the one referenced by the capture of the item
+		if captured, ok := r.parent.capturedValue(name); ok {
+			if !reflect.DeepEqual(captured.value, value.value) {
+				return true
+			}
+			continue
+		}
+		for parent := r.parent; parent != nil; parent = parent.parent {
+			for _, ref := range parent.refs[name] {
+				if !reflect.DeepEqual(value.value, ref.value) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// merge adds the values recorded while comparing the items of the arrays regardless of their order
+func (r *references) merge(other *references) {
+	for _, name := range sortedNames(other.captured) {
+		r.add(other.captured[name].path, "$capture:"+name, other.captured[name].value)
+	}
+	for name, refs := range other.refs {
+		r.refs[name] = append(r.refs[name], refs...)
+	}
+}
+
+// diverged calls fn for each reference which is not equal to its captured value, expected is the captured value.
+// The references without the captured value are reported with an error.
+func (r *references) diverged(fn func(path string, name string, captured *referencedValue, actual interface{})) {
+	for _, name := range sortedNames(r.refs) {
+		captured, ok := r.captured[name]
+		for _, ref := range r.refs[name] {
+			switch {
+			case !ok:
+				fn(ref.path, name, nil, ref.value)
+			case !reflect.DeepEqual(captured.value, ref.value):
+				fn(ref.path, name, &captured, ref.value)
+			}
+		}
+	}
+}
+
+// errors returns the errors of the references which are not equal to their captured values
+func (r *references) errors() []error {
+	var errors []error
+	r.diverged(func(path string, name string, captured *referencedValue, actual interface{}) {
+		if captured == nil {
+			errors = append(errors, makeError(path, "value is not captured", "$capture:"+name, "<missing>"))
+			return
+		}
+		msg := fmt.Sprintf("value differs from $capture:%s at path %s", name, captured.path)
+		errors = append(errors, makeError(path, msg, captured.value, actual))
+	})
+	return errors
+}
+
+func sortedNames(values interface{}) []string {
+	ref := reflect.ValueOf(values)
+	names := make([]string, 0, ref.Len())
+	for _, key := range ref.MapKeys() {
+		names = append(names, key.String())
+	}
+	sort.Strings(names)
+	return names
+}
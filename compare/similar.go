@@ -0,0 +1,119 @@
+package compare
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// the expected text may have any characters, including colons and line breaks
+	similarExprRx = regexp.MustCompile(`(?s)^\$matchSimilar:([^:]*):(.*)$`)
+	// similarThresholdInTextRx finds the thresholds of the similarity expressions in the text of the expected body
+	similarThresholdInTextRx = regexp.MustCompile(`"\$matchSimilar:([^:"]*):`)
+)
+
+// parseSimilar parses $matchSimilar:THRESHOLD:TEXT, the threshold is the minimal similarity from 0 to 1
+func parseSimilar(expr string) (float64, string, error) {
+	matches := similarExprRx.FindStringSubmatch(expr)
+	if matches == nil {
+		return 0, "", fmt.Errorf("%s is not a similarity expression", expr)
+	}
+	threshold, err := parseThreshold(matches[1])
+	if err != nil {
+		return 0, "", err
+	}
+	return threshold, matches[2], nil
+}
+
+func parseThreshold(value string) (float64, error) {
+	threshold, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || threshold < 0 || threshold > 1 {
+		return 0, fmt.Errorf("similarity threshold %q must be a number from 0 to 1", value)
+	}
+	return threshold, nil
+}
+
+// ValidateSimilarities checks the thresholds of the similarity expressions found in the expected body,
+// the thresholds with variables in them are checked only when compared
+func ValidateSimilarities(text string) error {
+	for _, matches := range similarThresholdInTextRx.FindAllStringSubmatch(text, -1) {
+		if strings.Contains(matches[1], "{{") {
+			continue
+		}
+		if _, err := parseThreshold(matches[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func compareSimilar(path string, expected, actual interface{}) (errors []error) {
+	threshold, text, err := parseSimilar(expected.(string))
+	if err != nil {
+		errors = append(errors, makeError(path, "can not parse similarity: "+err.Error(), expected, actual))
+		return errors
+	}
+
+	value, ok := actual.(string)
+	if !ok {
+		errors = append(errors, makeError(path, "value is not a string", expected, actual))
+		return errors
+	}
+
+	if similarity := Similarity(text, value); similarity < threshold {
+		msg := fmt.Sprintf("value is not similar enough: similarity %.2f is less than %s", similarity,
+			strconv.FormatFloat(threshold, 'f', -1, 64))
+		errors = append(errors, makeError(path, msg, text, value))
+		return errors
+	}
+
+	return nil
+}
+
+// Similarity is the normalized Levenshtein similarity of the strings from 0 to 1:
+// 1 minus the number of the edits of the characters turning one string into the other
+// divided by the length of the longer string, the empty strings are equal
+func Similarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+// levenshtein counts the insertions, deletions and substitutions turning a into b
+func levenshtein(a, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min3(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
@@ -0,0 +1,51 @@
+// Package files reads and writes the data files of the tests, e.g. fixtures and expected bodies,
+// the files with .gz extension are compressed with gzip transparently
+package files
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// GzipExt is the extension of the gzip compressed files, e.g. users.yaml.gz
+const GzipExt = ".gz"
+
+// ReadFile reads the file decompressing it if it has .gz extension
+func ReadFile(path string) ([]byte, error) {
+	return ReadFileFS(OS, path)
+}
+
+// ReadFileFS reads the file from the filesystem decompressing it if it has .gz extension
+func ReadFileFS(fsys FS, path string) ([]byte, error) {
+	data, err := fsys.ReadFile(path)
+	if err != nil || !strings.HasSuffix(path, GzipExt) {
+		return data, err
+	}
+
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
+
+// WriteFile writes the data to the file compressing it if the file has .gz extension
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	if !strings.HasSuffix(path, GzipExt) {
+		return ioutil.WriteFile(path, data, perm)
+	}
+
+	buf := &bytes.Buffer{}
+	w := gzip.NewWriter(buf)
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, buf.Bytes(), perm)
+}
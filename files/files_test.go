@@ -0,0 +1,131 @@
+package files
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadWriteFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gonkey-files")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	data := []byte("users:\n  - name: john\n")
+	for _, name := range []string{"users.yaml", "users.yaml.gz"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, name)
+			require.NoError(t, WriteFile(path, data, 0644))
+
+			actual, err := ReadFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, data, actual)
+		})
+	}
+
+	raw, err := ioutil.ReadFile(filepath.Join(dir, "users.yaml.gz"))
+	require.NoError(t, err)
+	assert.NotEqual(t, data, raw)
+}
+
+func TestReadFileNotCompressed(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gonkey-files")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "users.yaml.gz")
+	require.NoError(t, ioutil.WriteFile(path, []byte("users: []"), 0644))
+
+	_, err = ReadFile(path)
+	assert.Error(t, err)
+}
+
+func TestLocateFixture(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gonkey-fixtures")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "staging"), 0755))
+	for _, name := range []string{"users.yaml", "orders.yml.gz", "staging/users.yml"} {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), nil, 0644))
+	}
+
+	path, err := LocateFixture(OS, []string{dir}, "", "users")
+	require.NoError(t, err)
+	assert.Equal(t, dir+"/users.yaml", path)
+
+	path, err = LocateFixture(OS, []string{dir}, "staging", "users")
+	require.NoError(t, err)
+	assert.Equal(t, dir+"/staging/users.yml", path)
+
+	path, err = LocateFixture(OS, []string{dir}, "staging", "orders")
+	require.NoError(t, err)
+	assert.Equal(t, dir+"/orders.yml.gz", path)
+
+	_, err = LocateFixture(OS, []string{dir}, "staging", "customers")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestLocateFixtureInSharedDirectories(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gonkey-fixtures")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	local, shared := filepath.Join(dir, "orders"), filepath.Join(dir, "shared")
+	require.NoError(t, os.MkdirAll(filepath.Join(local, "staging"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(shared, "common"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(shared, "staging", "common"), 0755))
+	for _, name := range []string{
+		"orders/users.yaml",
+		"orders/staging/orders.yaml",
+		"shared/users.yaml",
+		"shared/orders.yaml",
+		"shared/common/base.yaml",
+		"shared/staging/common/base.yaml",
+	} {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), nil, 0644))
+	}
+	locations := []string{local, shared}
+
+	// the fixture of the first directory shadows the shared one
+	path, err := LocateFixture(OS, locations, "", "users")
+	require.NoError(t, err)
+	assert.Equal(t, local+"/users.yaml", path)
+
+	path, err = LocateFixture(OS, locations, "", "common/base")
+	require.NoError(t, err)
+	assert.Equal(t, shared+"/common/base.yaml", path)
+
+	path, err = LocateFixture(OS, locations, "", "orders")
+	require.NoError(t, err)
+	assert.Equal(t, shared+"/orders.yaml", path)
+
+	// the environment subdirectory of a directory is tried before the directory, not before the previous ones
+	path, err = LocateFixture(OS, locations, "staging", "orders")
+	require.NoError(t, err)
+	assert.Equal(t, local+"/staging/orders.yaml", path)
+
+	path, err = LocateFixture(OS, locations, "staging", "common/base")
+	require.NoError(t, err)
+	assert.Equal(t, shared+"/staging/common/base.yaml", path)
+}
+
+func TestResolvePath(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gonkey-files")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// the path relative to the working directory is kept while there is no such file in the base directory
+	assert.Equal(t, "files.go", ResolvePath(OS, dir, "files.go"))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "files.go"), nil, 0644))
+	assert.Equal(t, filepath.Join(dir, "files.go"), ResolvePath(OS, dir, "files.go"))
+
+	// the files which don't exist yet, e.g. the golden files, are resolved against the base directory
+	assert.Equal(t, filepath.Join(dir, "body.json"), ResolvePath(OS, dir, "body.json"))
+	assert.Equal(t, "/tmp/body.json", ResolvePath(OS, dir, "/tmp/body.json"))
+	assert.Equal(t, "body.json", ResolvePath(OS, "", "body.json"))
+}
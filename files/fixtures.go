@@ -0,0 +1,26 @@
+package files
+
+// LocateFixture returns the path of the fixture file of the name in the fixtures directories, they are tried
+// in order and the first one having the file wins. The name is tried as is and with the .yml and .yaml
+// extensions, compressed or not. If the environment is set, the file in the subdirectory of the environment
+// of a directory, e.g. fixtures/staging/users.yaml, takes priority over the file of that directory.
+func LocateFixture(fsys FS, locations []string, env, name string) (string, error) {
+	var dirs []string
+	for _, location := range locations {
+		if env != "" {
+			dirs = append(dirs, location+"/"+env)
+		}
+		dirs = append(dirs, location)
+	}
+
+	var err error
+	for _, dir := range dirs {
+		for _, ext := range []string{"", ".yml", ".yaml", ".yml" + GzipExt, ".yaml" + GzipExt} {
+			candidate := dir + "/" + name + ext
+			if _, err = fsys.Stat(candidate); err == nil {
+				return candidate, nil
+			}
+		}
+	}
+	return "", err
+}
@@ -0,0 +1,32 @@
+package files
+
+import (
+	"io/ioutil"
+	"os"
+)
+
+// FS is the filesystem the files of the tests are read from, the names are slash-separated
+// paths like the locations of the tests and the fixtures, see FromFS to read them from
+// an io/fs filesystem, e.g. embedded with go:embed
+type FS interface {
+	ReadFile(name string) ([]byte, error)
+	Stat(name string) (os.FileInfo, error)
+	ReadDir(name string) ([]os.FileInfo, error)
+}
+
+// OS is the default FS reading the files from the OS filesystem
+var OS FS = osFS{}
+
+type osFS struct{}
+
+func (osFS) ReadFile(name string) ([]byte, error) {
+	return ioutil.ReadFile(name)
+}
+
+func (osFS) Stat(name string) (os.FileInfo, error) {
+	return os.Stat(name)
+}
+
+func (osFS) ReadDir(name string) ([]os.FileInfo, error) {
+	return ioutil.ReadDir(name)
+}
@@ -0,0 +1,53 @@
+//go:build go1.16
+// +build go1.16
+
+package files
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+)
+
+// FromFS adapts the io/fs filesystem, e.g. embed.FS, to read the files of the tests from it,
+// the leading "./" and "/" of the names are dropped as io/fs requires unrooted paths
+func FromFS(fsys fs.FS) FS {
+	return ioFS{fsys}
+}
+
+type ioFS struct {
+	fsys fs.FS
+}
+
+func (f ioFS) ReadFile(name string) ([]byte, error) {
+	return fs.ReadFile(f.fsys, fsName(name))
+}
+
+func (f ioFS) Stat(name string) (os.FileInfo, error) {
+	return fs.Stat(f.fsys, fsName(name))
+}
+
+func (f ioFS) ReadDir(name string) ([]os.FileInfo, error) {
+	entries, err := fs.ReadDir(f.fsys, fsName(name))
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]os.FileInfo, 0, len(entries))
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+func fsName(name string) string {
+	name = strings.TrimLeft(path.Clean(name), "/")
+	if name == "" {
+		return "."
+	}
+	return name
+}
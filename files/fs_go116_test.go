@@ -0,0 +1,49 @@
+//go:build go1.16
+// +build go1.16
+
+package files
+
+import (
+	"bytes"
+	"compress/gzip"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromFS(t *testing.T) {
+	data := []byte("users:\n  - name: john\n")
+
+	gz := &bytes.Buffer{}
+	w := gzip.NewWriter(gz)
+	_, err := w.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	fsys := FromFS(fstest.MapFS{
+		"fixtures/users.yaml":    {Data: data},
+		"fixtures/users.yaml.gz": {Data: gz.Bytes()},
+	})
+
+	for _, name := range []string{"fixtures/users.yaml", "./fixtures/users.yaml", "/fixtures/users.yaml.gz"} {
+		t.Run(name, func(t *testing.T) {
+			actual, err := ReadFileFS(fsys, name)
+			require.NoError(t, err)
+			assert.Equal(t, data, actual)
+		})
+	}
+
+	stat, err := fsys.Stat("fixtures")
+	require.NoError(t, err)
+	assert.True(t, stat.IsDir())
+
+	entries, err := fsys.ReadDir("./fixtures/")
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "users.yaml", entries[0].Name())
+
+	_, err = fsys.Stat("fixtures/orders.yaml")
+	assert.Error(t, err)
+}
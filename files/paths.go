@@ -0,0 +1,22 @@
+package files
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// ResolvePath joins the relative path with baseDir, e.g. the directory of the test file. The path relative
+// to the working directory is kept if only it exists, so the suites written when the paths were relative
+// to the working directory keep working.
+func ResolvePath(fsys FS, baseDir, path string) string {
+	if path == "" || baseDir == "" || filepath.IsAbs(path) {
+		return path
+	}
+	joined := filepath.Join(baseDir, path)
+	if _, err := fsys.Stat(joined); os.IsNotExist(err) {
+		if _, err := fsys.Stat(path); err == nil {
+			return path
+		}
+	}
+	return joined
+}
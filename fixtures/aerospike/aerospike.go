@@ -4,10 +4,10 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io/ioutil"
-	"os"
 
 	"gopkg.in/yaml.v2"
+
+	"github.com/lamoda/gonkey/files"
 )
 
 type aerospikeClient interface {
@@ -16,9 +16,11 @@ type aerospikeClient interface {
 }
 
 type LoaderAerospike struct {
-	client   aerospikeClient
-	location string
-	debug    bool
+	client    aerospikeClient
+	locations []string
+	env       string
+	debug     bool
+	fs        files.FS
 }
 
 type binMap map[string]interface{}
@@ -42,12 +44,18 @@ type loadContext struct {
 
 func New(client aerospikeClient, location string, debug bool) *LoaderAerospike {
 	return &LoaderAerospike{
-		client:   client,
-		location: location,
-		debug:    debug,
+		client:    client,
+		locations: []string{location},
+		debug:     debug,
+		fs:        files.OS,
 	}
 }
 
+// SetFS sets the filesystem the fixtures are read from, the OS filesystem by default
+func (l *LoaderAerospike) SetFS(fsys files.FS) {
+	l.fs = fsys
+}
+
 func (l *LoaderAerospike) Load(names []string) error {
 	ctx := loadContext{
 		refsDefinition: make(set),
@@ -63,20 +71,36 @@ func (l *LoaderAerospike) Load(names []string) error {
 	return l.loadSets(&ctx)
 }
 
-func (l *LoaderAerospike) loadFile(name string, ctx *loadContext) error {
-	candidates := []string{
-		l.location + "/" + name,
-		l.location + "/" + name + ".yml",
-		l.location + "/" + name + ".yaml",
+// Validate reads and parses fixtures files without loading them into the storage
+func (l *LoaderAerospike) Validate(names []string) error {
+	ctx := loadContext{
+		refsDefinition: make(set),
 	}
-	var err error
-	var file string
-	for _, candidate := range candidates {
-		if _, err = os.Stat(candidate); err == nil {
-			file = candidate
-			break
+	for _, name := range names {
+		if err := l.loadFile(name, &ctx); err != nil {
+			return fmt.Errorf("unable to load fixture %s: %s", name, err.Error())
 		}
 	}
+	return nil
+}
+
+// Locate returns the file of the fixture, the name may have no extension
+func (l *LoaderAerospike) Locate(name string) (string, error) {
+	return files.LocateFixture(l.fs, l.locations, l.env, name)
+}
+
+// SetEnvironment sets the environment whose subdirectory of the fixtures directory is looked up first
+func (l *LoaderAerospike) SetEnvironment(env string) {
+	l.env = env
+}
+
+// SetSharedLocations sets the directories of the shared fixtures looked up after the fixtures directory
+func (l *LoaderAerospike) SetSharedLocations(locations []string) {
+	l.locations = append(l.locations[:1], locations...)
+}
+
+func (l *LoaderAerospike) loadFile(name string, ctx *loadContext) error {
+	file, err := l.Locate(name)
 	if err != nil {
 		return err
 	}
@@ -87,7 +111,7 @@ func (l *LoaderAerospike) loadFile(name string, ctx *loadContext) error {
 	if l.debug {
 		fmt.Println("Loading", file)
 	}
-	data, err := ioutil.ReadFile(file)
+	data, err := files.ReadFileFS(l.fs, file)
 	if err != nil {
 		return err
 	}
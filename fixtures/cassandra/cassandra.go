@@ -0,0 +1,453 @@
+package cassandra
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/lamoda/gonkey/files"
+)
+
+type cassandraClient interface {
+	Exec(stmt string, values ...interface{}) error
+}
+
+// LoaderCassandra loads the fixtures into Cassandra or Scylla with CQL. The rows are inserted
+// one by one and are not read back: the references to the named rows resolve to the values
+// defined in the fixtures.
+type LoaderCassandra struct {
+	client    cassandraClient
+	locations []string
+	env       string
+	debug     bool
+	fs        files.FS
+}
+
+type row map[string]interface{}
+
+type table []row
+
+type rowsDict map[string]row
+
+// expression is the CQL expression of $eval() put into the statement as is
+type expression string
+
+type fixture struct {
+	Inherits  []string
+	Tables    yaml.MapSlice
+	Templates yaml.MapSlice
+}
+
+type loadedTable struct {
+	name string
+	rows table
+}
+
+type loadContext struct {
+	files          []string
+	tables         []loadedTable
+	refsDefinition rowsDict
+	// refsValues are the resolved values of the fields of the named rows
+	refsValues rowsDict
+}
+
+var evalRx = regexp.MustCompile(`^\$eval\((.+)\)$`)
+
+func New(client cassandraClient, location string, debug bool) *LoaderCassandra {
+	return &LoaderCassandra{
+		client:    client,
+		locations: []string{location},
+		debug:     debug,
+		fs:        files.OS,
+	}
+}
+
+// SetFS sets the filesystem the fixtures are read from, the OS filesystem by default
+func (l *LoaderCassandra) SetFS(fsys files.FS) {
+	l.fs = fsys
+}
+
+func newLoadContext() *loadContext {
+	return &loadContext{
+		refsDefinition: make(rowsDict),
+		refsValues:     make(rowsDict),
+	}
+}
+
+func (l *LoaderCassandra) Load(names []string) error {
+	ctx, err := l.parse(names)
+	if err != nil {
+		return err
+	}
+	if err := l.truncateTables(ctx); err != nil {
+		return err
+	}
+	for _, lt := range ctx.tables {
+		if err := l.loadTable(ctx, lt.name, lt.rows); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Clean truncates the tables of the fixtures
+func (l *LoaderCassandra) Clean(names []string) error {
+	ctx, err := l.parse(names)
+	if err != nil {
+		return err
+	}
+	return l.truncateTables(ctx)
+}
+
+// Validate reads and parses fixtures files without loading them into the database
+func (l *LoaderCassandra) Validate(names []string) error {
+	_, err := l.parse(names)
+	return err
+}
+
+// Tables returns names of the tables the fixtures are loaded into
+func (l *LoaderCassandra) Tables(names []string) ([]string, error) {
+	ctx, err := l.parse(names)
+	if err != nil {
+		return nil, err
+	}
+	tables := make([]string, 0, len(ctx.tables))
+	for _, lt := range ctx.tables {
+		tables = append(tables, lt.name)
+	}
+	return tables, nil
+}
+
+func (l *LoaderCassandra) parse(names []string) (*loadContext, error) {
+	ctx := newLoadContext()
+	for _, name := range names {
+		if err := l.loadFile(name, ctx); err != nil {
+			return nil, fmt.Errorf("unable to load fixture %s: %s", name, err.Error())
+		}
+	}
+	return ctx, nil
+}
+
+// Locate returns the file of the fixture, the name may have no extension
+func (l *LoaderCassandra) Locate(name string) (string, error) {
+	return files.LocateFixture(l.fs, l.locations, l.env, name)
+}
+
+// SetEnvironment sets the environment whose subdirectory of the fixtures directory is looked up first
+func (l *LoaderCassandra) SetEnvironment(env string) {
+	l.env = env
+}
+
+// SetSharedLocations sets the directories of the shared fixtures looked up after the fixtures directory
+func (l *LoaderCassandra) SetSharedLocations(locations []string) {
+	l.locations = append(l.locations[:1], locations...)
+}
+
+func (l *LoaderCassandra) loadFile(name string, ctx *loadContext) error {
+	file, err := l.Locate(name)
+	if err != nil {
+		return err
+	}
+
+	// skip previously loaded files
+	if inArray(file, ctx.files) {
+		return nil
+	}
+
+	l.printDebug("Loading", file)
+
+	data, err := files.ReadFileFS(l.fs, file)
+	if err != nil {
+		return err
+	}
+	ctx.files = append(ctx.files, file)
+	return l.loadYml(data, ctx)
+}
+
+func (l *LoaderCassandra) loadYml(data []byte, ctx *loadContext) error {
+	// read yml into struct
+	var loadedFixture fixture
+	if err := yaml.Unmarshal(data, &loadedFixture); err != nil {
+		return err
+	}
+
+	// load inherits
+	for _, inheritFile := range loadedFixture.Inherits {
+		if err := l.loadFile(inheritFile, ctx); err != nil {
+			return err
+		}
+	}
+
+	for _, template := range loadedFixture.Templates {
+		name := template.Key.(string)
+		if _, ok := ctx.refsDefinition[name]; ok {
+			return fmt.Errorf("unable to load template %s: duplicating ref name", name)
+		}
+
+		fields := template.Value.(yaml.MapSlice)
+		row := make(row, len(fields))
+		for _, field := range fields {
+			row[field.Key.(string)] = field.Value
+		}
+
+		if base, ok := row["$extend"]; ok {
+			baseRow, err := l.resolveReference(ctx.refsDefinition, base.(string))
+			if err != nil {
+				return err
+			}
+			for k, v := range row {
+				baseRow[k] = v
+			}
+			row = baseRow
+		}
+
+		ctx.refsDefinition[name] = row
+		if l.debug {
+			rowJson, _ := json.Marshal(row)
+			fmt.Printf("Populating ref %s as %s from template\n", name, string(rowJson))
+		}
+	}
+
+	for _, sourceTable := range loadedFixture.Tables {
+		sourceRows, ok := sourceTable.Value.([]interface{})
+		if !ok {
+			return errors.New("expected array at root level")
+		}
+		rows := make(table, len(sourceRows))
+		for i := range sourceRows {
+			sourceFields := sourceRows[i].(yaml.MapSlice)
+			fields := make(row, len(sourceFields))
+			for j := range sourceFields {
+				fields[sourceFields[j].Key.(string)] = sourceFields[j].Value
+			}
+			rows[i] = fields
+		}
+		ctx.tables = append(ctx.tables, loadedTable{
+			name: sourceTable.Key.(string),
+			rows: rows,
+		})
+	}
+	return nil
+}
+
+func (l *LoaderCassandra) truncateTables(ctx *loadContext) error {
+	truncatedTables := make(map[string]bool)
+	for _, lt := range ctx.tables {
+		if truncatedTables[lt.name] {
+			continue
+		}
+		stmt := "TRUNCATE " + lt.name
+		l.printDebug("Issuing CQL:", stmt)
+		if err := l.client.Exec(stmt); err != nil {
+			return fmt.Errorf("unable to truncate table %s: %s", lt.name, err)
+		}
+		truncatedTables[lt.name] = true
+	}
+	return nil
+}
+
+func (l *LoaderCassandra) loadTable(ctx *loadContext, t string, rows table) error {
+	for _, row := range rows {
+		// $extend keyword allows to import values from a named row
+		if base, ok := row["$extend"]; ok {
+			baseRow, err := l.resolveReference(ctx.refsDefinition, base.(string))
+			if err != nil {
+				return err
+			}
+			for k, v := range row {
+				baseRow[k] = v
+			}
+			row = baseRow
+		}
+
+		values, err := l.rowValues(ctx, t, row)
+		if err != nil {
+			return err
+		}
+
+		if name, ok := row["$name"]; ok {
+			name := name.(string)
+			if _, ok := ctx.refsValues[name]; ok {
+				return fmt.Errorf("duplicating ref name %s", name)
+			}
+			ctx.refsDefinition[name] = row
+			ctx.refsValues[name] = values
+		}
+
+		stmt, args, err := buildInsertStatement(t, row, values)
+		if err != nil {
+			return fmt.Errorf("unable to insert into table %s: %s", t, err)
+		}
+		l.printDebug("Issuing CQL:", stmt)
+		if err := l.client.Exec(stmt, args...); err != nil {
+			return fmt.Errorf("unable to insert into table %s: %s", t, err)
+		}
+	}
+	return nil
+}
+
+// rowValues resolves the values of the fields of the row without the $-keywords
+func (l *LoaderCassandra) rowValues(ctx *loadContext, t string, source row) (row, error) {
+	values := make(row, len(source))
+	for name, val := range source {
+		if strings.HasPrefix(name, "$") {
+			continue
+		}
+		v, err := l.resolveValue(ctx, val)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"unable to process %s value (of %s): %s",
+				name, t, err.Error(),
+			)
+		}
+		values[name] = v
+	}
+	return values, nil
+}
+
+// buildInsertStatement builds CQL statement inserting the row, the values are bound
+// to the placeholders except the expressions of $eval(), the TTL is set with $ttl in seconds
+func buildInsertStatement(t string, row row, values row) (string, []interface{}, error) {
+	fields := make([]string, 0, len(values))
+	for name := range values {
+		fields = append(fields, name)
+	}
+	sort.Strings(fields)
+
+	placeholders := make([]string, len(fields))
+	args := make([]interface{}, 0, len(fields))
+	for i, name := range fields {
+		if expr, ok := values[name].(expression); ok {
+			placeholders[i] = string(expr)
+			continue
+		}
+		placeholders[i] = "?"
+		args = append(args, values[name])
+	}
+
+	stmt := fmt.Sprintf(
+		"INSERT INTO %s (%s) VALUES (%s)",
+		t,
+		strings.Join(fields, ", "),
+		strings.Join(placeholders, ", "),
+	)
+
+	if ttl, ok := row["$ttl"]; ok {
+		seconds, ok := ttl.(int)
+		if !ok || seconds <= 0 {
+			return "", nil, fmt.Errorf("$ttl must be a positive number of seconds, got %v", ttl)
+		}
+		stmt += fmt.Sprintf(" USING TTL %d", seconds)
+	}
+	return stmt, args, nil
+}
+
+// resolveValue converts the value read from yaml into the value bound to the statement:
+// - $eval()               - executes a CQL expression, e.g. $eval(toTimestamp(now()))
+// - $recordName.fieldName - using value of previously inserted named record
+// the lists are bound to the list and set columns and the maps to the map columns
+func (l *LoaderCassandra) resolveValue(ctx *loadContext, val interface{}) (interface{}, error) {
+	switch val := val.(type) {
+	case string:
+		if !strings.HasPrefix(val, "$") {
+			return val, nil
+		}
+		if strings.HasPrefix(val, "$eval") {
+			matches := evalRx.FindStringSubmatch(val)
+			if matches == nil {
+				return nil, fmt.Errorf("icorrect $eval() usage: %s", val)
+			}
+			return expression(matches[1]), nil
+		}
+		return l.resolveFieldReference(ctx.refsValues, val)
+	case []interface{}:
+		items := make([]interface{}, len(val))
+		for i, item := range val {
+			v, err := l.resolveValue(ctx, item)
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := v.(expression); ok {
+				return nil, errors.New("$eval() can't be used in collections")
+			}
+			items[i] = v
+		}
+		return items, nil
+	case yaml.MapSlice:
+		items := make(map[interface{}]interface{}, len(val))
+		for _, item := range val {
+			v, err := l.resolveValue(ctx, item.Value)
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := v.(expression); ok {
+				return nil, errors.New("$eval() can't be used in collections")
+			}
+			items[item.Key] = v
+		}
+		return items, nil
+	default:
+		return val, nil
+	}
+}
+
+// resolveReference finds previously stored reference by its name
+func (l *LoaderCassandra) resolveReference(refs rowsDict, refName string) (row, error) {
+	target, ok := refs[refName]
+	if !ok {
+		return nil, fmt.Errorf("undefined reference %s", refName)
+	}
+	// make a copy of referencing data to prevent spoiling the source
+	// by the way removing $-records from base row
+	targetCopy := make(row, len(target))
+	for k, v := range target {
+		if len(k) == 0 || k[0] != '$' {
+			targetCopy[k] = v
+		}
+	}
+	return targetCopy, nil
+}
+
+// resolveFieldReference finds previously stored reference by name
+// and return value of its field
+func (l *LoaderCassandra) resolveFieldReference(refs rowsDict, ref string) (interface{}, error) {
+	parts := strings.SplitN(ref, ".", 2)
+	if len(parts) < 2 || len(parts[0]) < 2 || len(parts[1]) < 1 {
+		return nil, fmt.Errorf("invalid reference %s, correct form is $refName.field", ref)
+	}
+
+	// remove leading $
+	refName := parts[0][1:]
+
+	target, ok := refs[refName]
+	if !ok {
+		return nil, fmt.Errorf("undefined reference %s", refName)
+	}
+
+	value, ok := target[parts[1]]
+	if !ok {
+		return nil, fmt.Errorf("undefined reference field %s", parts[1])
+	}
+	return value, nil
+}
+
+// inArray checks whether the needle is present in haystack slice
+func inArray(needle string, haystack []string) bool {
+	for _, e := range haystack {
+		if needle == e {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *LoaderCassandra) printDebug(a ...interface{}) {
+	if l.debug {
+		fmt.Println(a...)
+	}
+}
@@ -0,0 +1,127 @@
+package cassandra
+
+import (
+	"errors"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type statement struct {
+	stmt   string
+	values []interface{}
+}
+
+type clientMock struct {
+	statements []statement
+	err        error
+}
+
+func (c *clientMock) Exec(stmt string, values ...interface{}) error {
+	c.statements = append(c.statements, statement{stmt: stmt, values: values})
+	return c.err
+}
+
+func TestLoadShouldInsertCollectionsWithTTL(t *testing.T) {
+	yml := []byte(`
+tables:
+  shop.users:
+    - $name: john
+      id: 1
+      name: John
+      tags: [a, b]
+      attrs:
+        color: red
+        size: 42
+      $ttl: 3600
+    - id: 2
+      name: $john.name
+      created_at: $eval(toTimestamp(now()))
+`)
+
+	client := &clientMock{}
+	l := New(client, "", false)
+	ctx := newLoadContext()
+	require.NoError(t, l.loadYml(yml, ctx))
+	require.NoError(t, l.truncateTables(ctx))
+	require.NoError(t, l.loadTable(ctx, ctx.tables[0].name, ctx.tables[0].rows))
+
+	assert.Equal(t, []statement{
+		{stmt: "TRUNCATE shop.users"},
+		{
+			stmt: "INSERT INTO shop.users (attrs, id, name, tags) VALUES (?, ?, ?, ?) USING TTL 3600",
+			values: []interface{}{
+				map[interface{}]interface{}{"color": "red", "size": 42},
+				1,
+				"John",
+				[]interface{}{"a", "b"},
+			},
+		},
+		{
+			stmt:   "INSERT INTO shop.users (created_at, id, name) VALUES (toTimestamp(now()), ?, ?)",
+			values: []interface{}{2, "John"},
+		},
+	}, client.statements)
+}
+
+func TestLoadShouldExtendRows(t *testing.T) {
+	yml, err := ioutil.ReadFile("../testdata/sql_extend.yaml")
+	require.NoError(t, err)
+
+	client := &clientMock{}
+	l := New(client, "", false)
+	ctx := newLoadContext()
+	require.NoError(t, l.loadYml(yml, ctx))
+	require.NoError(t, l.truncateTables(ctx))
+	for _, lt := range ctx.tables {
+		require.NoError(t, l.loadTable(ctx, lt.name, lt.rows))
+	}
+
+	assert.Equal(t, []statement{
+		{stmt: "TRUNCATE table1"},
+		{stmt: "TRUNCATE table2"},
+		{stmt: "TRUNCATE table3"},
+		{stmt: "INSERT INTO table1 (f1, f2) VALUES (?, ?)", values: []interface{}{"value1", "value2"}},
+		{stmt: `INSERT INTO table2 (f1, f2, f3) VALUES (?, ?, "1" || "2" || 3 + 5)`, values: []interface{}{"value1 overwritten", "value2"}},
+		{stmt: `INSERT INTO table3 (f1, f2, f3) VALUES (?, ?, "1" || "2" || 3 + 5)`, values: []interface{}{"value1 overwritten", "value2"}},
+		{stmt: "INSERT INTO table3 (f1, f2) VALUES (?, ?)", values: []interface{}{"tplVal1", "tplVal2"}},
+	}, client.statements)
+}
+
+func TestLoadShouldFailOnInvalidTTL(t *testing.T) {
+	yml := []byte(`
+tables:
+  users:
+    - id: 1
+      $ttl: soon
+`)
+
+	l := New(&clientMock{}, "", false)
+	ctx := newLoadContext()
+	require.NoError(t, l.loadYml(yml, ctx))
+
+	err := l.loadTable(ctx, ctx.tables[0].name, ctx.tables[0].rows)
+	assert.EqualError(t, err, "unable to insert into table users: $ttl must be a positive number of seconds, got soon")
+}
+
+func TestCleanShouldTruncateTables(t *testing.T) {
+	client := &clientMock{}
+	l := New(client, "../testdata", false)
+
+	require.NoError(t, l.Clean([]string{"sql_refs"}))
+
+	assert.Equal(t, []statement{
+		{stmt: "TRUNCATE table1"},
+		{stmt: "TRUNCATE table2"},
+		{stmt: "TRUNCATE table3"},
+	}, client.statements)
+}
+
+func TestLoadShouldReturnClientErrors(t *testing.T) {
+	l := New(&clientMock{err: errors.New("keyspace does not exist")}, "../testdata", false)
+
+	err := l.Load([]string{"sql_refs"})
+	assert.EqualError(t, err, "unable to truncate table table1: keyspace does not exist")
+}
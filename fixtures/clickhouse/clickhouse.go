@@ -0,0 +1,480 @@
+package clickhouse
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/lamoda/gonkey/files"
+)
+
+// LoaderClickhouse loads the fixtures into ClickHouse. There are no transactions in ClickHouse,
+// so the tables are truncated and filled one by one, and the rows are not read back after the insert:
+// the references to the named rows resolve to the values defined in the fixtures.
+type LoaderClickhouse struct {
+	db        *sql.DB
+	locations []string
+	env       string
+	debug     bool
+	fs        files.FS
+}
+
+type row map[string]interface{}
+
+type table []row
+
+type rowsDict map[string]row
+
+type fixture struct {
+	Inherits  []string
+	Tables    yaml.MapSlice
+	Templates yaml.MapSlice
+}
+
+type loadedTable struct {
+	name string
+	rows table
+}
+
+type loadContext struct {
+	files          []string
+	tables         []loadedTable
+	refsDefinition rowsDict
+	// refsValues are the SQL literals of the fields of the named rows
+	refsValues map[string]map[string]string
+}
+
+func New(db *sql.DB, location string, debug bool) *LoaderClickhouse {
+	return &LoaderClickhouse{
+		db:        db,
+		locations: []string{location},
+		debug:     debug,
+		fs:        files.OS,
+	}
+}
+
+// SetFS sets the filesystem the fixtures are read from, the OS filesystem by default
+func (l *LoaderClickhouse) SetFS(fsys files.FS) {
+	l.fs = fsys
+}
+
+func newLoadContext() *loadContext {
+	return &loadContext{
+		refsDefinition: make(rowsDict),
+		refsValues:     make(map[string]map[string]string),
+	}
+}
+
+func (l *LoaderClickhouse) Load(names []string) error {
+	ctx, err := l.parse(names)
+	if err != nil {
+		return err
+	}
+	return l.loadTables(ctx)
+}
+
+// Validate reads and parses fixtures files without loading them into the database
+func (l *LoaderClickhouse) Validate(names []string) error {
+	_, err := l.parse(names)
+	return err
+}
+
+// Tables returns names of the tables the fixtures are loaded into
+func (l *LoaderClickhouse) Tables(names []string) ([]string, error) {
+	ctx, err := l.parse(names)
+	if err != nil {
+		return nil, err
+	}
+	tables := make([]string, 0, len(ctx.tables))
+	for _, lt := range ctx.tables {
+		tables = append(tables, lt.name)
+	}
+	return tables, nil
+}
+
+func (l *LoaderClickhouse) parse(names []string) (*loadContext, error) {
+	ctx := newLoadContext()
+	for _, name := range names {
+		if err := l.loadFile(name, ctx); err != nil {
+			return nil, fmt.Errorf("unable to load fixture %s: %s", name, err.Error())
+		}
+	}
+	return ctx, nil
+}
+
+// Locate returns the file of the fixture, the name may have no extension
+func (l *LoaderClickhouse) Locate(name string) (string, error) {
+	return files.LocateFixture(l.fs, l.locations, l.env, name)
+}
+
+// SetEnvironment sets the environment whose subdirectory of the fixtures directory is looked up first
+func (l *LoaderClickhouse) SetEnvironment(env string) {
+	l.env = env
+}
+
+// SetSharedLocations sets the directories of the shared fixtures looked up after the fixtures directory
+func (l *LoaderClickhouse) SetSharedLocations(locations []string) {
+	l.locations = append(l.locations[:1], locations...)
+}
+
+func (l *LoaderClickhouse) loadFile(name string, ctx *loadContext) error {
+	file, err := l.Locate(name)
+	if err != nil {
+		return err
+	}
+
+	// skip previously loaded files
+	if inArray(file, ctx.files) {
+		return nil
+	}
+
+	l.printDebug("Loading", file)
+
+	data, err := files.ReadFileFS(l.fs, file)
+	if err != nil {
+		return err
+	}
+	ctx.files = append(ctx.files, file)
+	return l.loadYml(data, ctx)
+}
+
+func (l *LoaderClickhouse) loadYml(data []byte, ctx *loadContext) error {
+	// read yml into struct
+	var loadedFixture fixture
+	if err := yaml.Unmarshal(data, &loadedFixture); err != nil {
+		return err
+	}
+
+	// load inherits
+	for _, inheritFile := range loadedFixture.Inherits {
+		if err := l.loadFile(inheritFile, ctx); err != nil {
+			return err
+		}
+	}
+
+	for _, template := range loadedFixture.Templates {
+		name := template.Key.(string)
+		if _, ok := ctx.refsDefinition[name]; ok {
+			return fmt.Errorf("unable to load template %s: duplicating ref name", name)
+		}
+
+		fields := template.Value.(yaml.MapSlice)
+		row := make(row, len(fields))
+		for _, field := range fields {
+			row[field.Key.(string)] = field.Value
+		}
+
+		if base, ok := row["$extend"]; ok {
+			baseRow, err := l.resolveReference(ctx.refsDefinition, base.(string))
+			if err != nil {
+				return err
+			}
+			for k, v := range row {
+				baseRow[k] = v
+			}
+			row = baseRow
+		}
+
+		ctx.refsDefinition[name] = row
+		if l.debug {
+			rowJson, _ := json.Marshal(row)
+			fmt.Printf("Populating ref %s as %s from template\n", name, string(rowJson))
+		}
+	}
+
+	for _, sourceTable := range loadedFixture.Tables {
+		sourceRows, ok := sourceTable.Value.([]interface{})
+		if !ok {
+			return errors.New("expected array at root level")
+		}
+		rows := make(table, len(sourceRows))
+		for i := range sourceRows {
+			sourceFields := sourceRows[i].(yaml.MapSlice)
+			fields := make(row, len(sourceFields))
+			for j := range sourceFields {
+				fields[sourceFields[j].Key.(string)] = sourceFields[j].Value
+			}
+			rows[i] = fields
+		}
+		ctx.tables = append(ctx.tables, loadedTable{
+			name: sourceTable.Key.(string),
+			rows: rows,
+		})
+	}
+	return nil
+}
+
+func (l *LoaderClickhouse) loadTables(ctx *loadContext) error {
+	// truncate first
+	truncatedTables := make(map[string]bool)
+	for _, lt := range ctx.tables {
+		if truncatedTables[lt.name] {
+			continue
+		}
+		if err := l.exec(fmt.Sprintf("TRUNCATE TABLE %s", quoteTable(lt.name))); err != nil {
+			return fmt.Errorf("unable to truncate table %s: %s", lt.name, err)
+		}
+		truncatedTables[lt.name] = true
+	}
+
+	// then load data
+	for _, lt := range ctx.tables {
+		if err := l.loadTable(ctx, lt.name, lt.rows); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadTable inserts the rows of the table, the consecutive rows with the same fields
+// are inserted with one query as ClickHouse prefers the inserts in batches
+func (l *LoaderClickhouse) loadTable(ctx *loadContext, t string, rows table) error {
+	var fields []string
+	var values []string
+	flush := func() error {
+		if len(values) == 0 {
+			return nil
+		}
+		query := buildInsertQuery(t, fields, values)
+		values = nil
+		if err := l.exec(query); err != nil {
+			return fmt.Errorf("unable to insert into table %s: %s", t, err)
+		}
+		return nil
+	}
+
+	for _, row := range rows {
+		// $extend keyword allows to import values from a named row
+		if base, ok := row["$extend"]; ok {
+			baseRow, err := l.resolveReference(ctx.refsDefinition, base.(string))
+			if err != nil {
+				return err
+			}
+			for k, v := range row {
+				baseRow[k] = v
+			}
+			row = baseRow
+		}
+
+		names := rowFields(row)
+		literals, err := l.rowValues(ctx, t, row, names)
+		if err != nil {
+			return err
+		}
+
+		if name, ok := row["$name"]; ok {
+			name := name.(string)
+			if _, ok := ctx.refsValues[name]; ok {
+				return fmt.Errorf("duplicating ref name %s", name)
+			}
+			ctx.refsDefinition[name] = row
+			ctx.refsValues[name] = make(map[string]string, len(names))
+			for i, field := range names {
+				ctx.refsValues[name][field] = literals[i]
+			}
+		}
+
+		if strings.Join(names, ",") != strings.Join(fields, ",") {
+			if err := flush(); err != nil {
+				return err
+			}
+			fields = names
+		}
+		values = append(values, "("+strings.Join(literals, ", ")+")")
+	}
+
+	return flush()
+}
+
+func (l *LoaderClickhouse) exec(query string) error {
+	l.printDebug("Issuing SQL:", query)
+	_, err := l.db.Exec(query)
+	return err
+}
+
+// rowFields returns the sorted names of the fields of the row without the $-keywords
+func rowFields(row row) []string {
+	var fields []string
+	for name := range row {
+		if strings.HasPrefix(name, "$") {
+			continue
+		}
+		fields = append(fields, name)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+func (l *LoaderClickhouse) rowValues(ctx *loadContext, t string, row row, fields []string) ([]string, error) {
+	values := make([]string, len(fields))
+	for i, name := range fields {
+		v, err := l.rowInsertValue(ctx, row[name])
+		if err != nil {
+			return nil, fmt.Errorf(
+				"unable to process %s value (of %s): %s",
+				name, t, err.Error(),
+			)
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+// buildInsertQuery builds SQL query inserting the rows given as the lists of values
+func buildInsertQuery(t string, fields, values []string) string {
+	quoted := make([]string, len(fields))
+	for i, field := range fields {
+		quoted[i] = quoteIdentifier(field)
+	}
+	return fmt.Sprintf(
+		"INSERT INTO %s (%s) VALUES %s",
+		quoteTable(t),
+		strings.Join(quoted, ", "),
+		strings.Join(values, ", "),
+	)
+}
+
+func (l *LoaderClickhouse) rowInsertValue(ctx *loadContext, val interface{}) (string, error) {
+	// resolve references
+	if stringValue, ok := val.(string); ok {
+		if strings.HasPrefix(stringValue, "$") {
+			return l.resolveExpression(stringValue, ctx)
+		}
+	}
+	return toDbValue(val)
+}
+
+// resolveExpression converts expressions starting with dollar sign into a value
+// supporting expressions:
+// - $eval()               - executes an SQL expression, e.g. $eval(today())
+// - $recordName.fieldName - using value of previously inserted named record
+func (l *LoaderClickhouse) resolveExpression(expr string, ctx *loadContext) (string, error) {
+	if strings.HasPrefix(expr, "$eval") {
+		re := regexp.MustCompile(`^\$eval\((.+)\)$`)
+		if matches := re.FindStringSubmatch(expr); matches != nil {
+			return "(" + matches[1] + ")", nil
+		}
+		return "", fmt.Errorf("icorrect $eval() usage: %s", expr)
+	}
+
+	parts := strings.SplitN(expr, ".", 2)
+	if len(parts) < 2 || len(parts[0]) < 2 || len(parts[1]) < 1 {
+		return "", fmt.Errorf("invalid reference %s, correct form is $refName.field", expr)
+	}
+
+	// remove leading $
+	refName := parts[0][1:]
+
+	target, ok := ctx.refsValues[refName]
+	if !ok {
+		return "", fmt.Errorf("undefined reference %s", refName)
+	}
+	value, ok := target[parts[1]]
+	if !ok {
+		return "", fmt.Errorf("undefined reference field %s", parts[1])
+	}
+	return value, nil
+}
+
+// resolveReference finds previously stored reference by its name
+func (l *LoaderClickhouse) resolveReference(refs rowsDict, refName string) (row, error) {
+	target, ok := refs[refName]
+	if !ok {
+		return nil, fmt.Errorf("undefined reference %s", refName)
+	}
+	// make a copy of referencing data to prevent spoiling the source
+	// by the way removing $-records from base row
+	targetCopy := make(row, len(target))
+	for k, v := range target {
+		if len(k) == 0 || k[0] != '$' {
+			targetCopy[k] = v
+		}
+	}
+	return targetCopy, nil
+}
+
+// inArray checks whether the needle is present in haystack slice
+func inArray(needle string, haystack []string) bool {
+	for _, e := range haystack {
+		if needle == e {
+			return true
+		}
+	}
+	return false
+}
+
+// toDbValue prepares value to be passed in SQL query
+// with respect to its type and converts it to string
+func toDbValue(value interface{}) (string, error) {
+	switch value := value.(type) {
+	case nil:
+		return "NULL", nil
+	case string:
+		return quoteLiteral(value), nil
+	case int:
+		return strconv.Itoa(value), nil
+	case float64:
+		return strconv.FormatFloat(value, 'g', -1, 64), nil
+	case bool:
+		// 1 and 0 are accepted by both Bool and UInt8 columns
+		if value {
+			return "1", nil
+		}
+		return "0", nil
+	case []interface{}:
+		// the lists are inserted as arrays
+		items := make([]string, len(value))
+		for i, item := range value {
+			v, err := toDbValue(item)
+			if err != nil {
+				return "", err
+			}
+			items[i] = v
+		}
+		return "[" + strings.Join(items, ", ") + "]", nil
+	}
+	// the value is a map, so insert it as JSON string
+	// fixme: marshaller doesn't know how to encode map[interface{}]interface{}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return quoteLiteral(string(encoded)), nil
+}
+
+// quoteLiteral escapes the string to be safely passed as a value in SQL query,
+// ClickHouse escapes the quotes with backslashes
+func quoteLiteral(s string) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+	s = strings.Replace(s, `'`, `\'`, -1)
+	return "'" + s + "'"
+}
+
+// quoteTable quotes the name of the table which may be qualified with the database,
+// e.g. analytics.events
+func quoteTable(name string) string {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = quoteIdentifier(part)
+	}
+	return strings.Join(parts, ".")
+}
+
+// quoteIdentifier quotes the name as a whole, so the fields of the nested columns
+// are kept, e.g. `items.price`
+func quoteIdentifier(name string) string {
+	return "`" + strings.Replace(name, "`", "\\`", -1) + "`"
+}
+
+func (l *LoaderClickhouse) printDebug(a ...interface{}) {
+	if l.debug {
+		fmt.Println(a...)
+	}
+}
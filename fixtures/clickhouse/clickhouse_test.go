@@ -0,0 +1,138 @@
+package clickhouse
+
+import (
+	"io/ioutil"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestLoadTablesShouldBatchRows(t *testing.T) {
+	yml, err := ioutil.ReadFile("../testdata/sql.yaml")
+	require.NoError(t, err)
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	ctx := newLoadContext()
+	l := New(db, "", false)
+	require.NoError(t, l.loadYml(yml, ctx))
+
+	expectExec(mock, "TRUNCATE TABLE `table`")
+	expectExec(mock, "INSERT INTO `table` (`field1`, `field2`) VALUES ('value1', 1)")
+	expectExec(mock, "INSERT INTO `table` (`field1`, `field2`, `field3`) VALUES ('value2', 2, 2.5699477736545666)")
+	expectExec(mock, "INSERT INTO `table` (`field1`, `field4`, `field5`) VALUES ('\"', 0, NULL)")
+	expectExec(mock, "INSERT INTO `table` (`field1`, `field5`) VALUES ('\\'', [1, '2'])")
+
+	require.NoError(t, l.loadTables(ctx))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLoadTablesShouldResolveRefs(t *testing.T) {
+	yml, err := ioutil.ReadFile("../testdata/sql_refs.yaml")
+	require.NoError(t, err)
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	ctx := newLoadContext()
+	l := New(db, "", true)
+	require.NoError(t, l.loadYml(yml, ctx))
+
+	expectExec(mock, "TRUNCATE TABLE `table1`")
+	expectExec(mock, "TRUNCATE TABLE `table2`")
+	expectExec(mock, "TRUNCATE TABLE `table3`")
+	expectExec(mock, "INSERT INTO `table1` (`f1`, `f2`) VALUES ('value1', 'value2')")
+	expectExec(mock, "INSERT INTO `table2` (`f1`, `f2`) VALUES ('value2', 'value1')")
+	expectExec(mock, "INSERT INTO `table3` (`f1`, `f2`) VALUES ('value1', 'value2')")
+
+	require.NoError(t, l.loadTables(ctx))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLoadTablesShouldExtendRows(t *testing.T) {
+	yml, err := ioutil.ReadFile("../testdata/sql_extend.yaml")
+	require.NoError(t, err)
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	ctx := newLoadContext()
+	l := New(db, "", false)
+	require.NoError(t, l.loadYml(yml, ctx))
+
+	expectExec(mock, "TRUNCATE TABLE `table1`")
+	expectExec(mock, "TRUNCATE TABLE `table2`")
+	expectExec(mock, "TRUNCATE TABLE `table3`")
+	expectExec(mock, "INSERT INTO `table1` (`f1`, `f2`) VALUES ('value1', 'value2')")
+	expectExec(mock, "INSERT INTO `table2` (`f1`, `f2`, `f3`) VALUES ('value1 overwritten', 'value2', (\"1\" || \"2\" || 3 + 5))")
+	expectExec(mock, "INSERT INTO `table3` (`f1`, `f2`, `f3`) VALUES ('value1 overwritten', 'value2', (\"1\" || \"2\" || 3 + 5))")
+	expectExec(mock, "INSERT INTO `table3` (`f1`, `f2`) VALUES ('tplVal1', 'tplVal2')")
+
+	require.NoError(t, l.loadTables(ctx))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLoadTablesShouldInsertRowsWithSameFieldsAtOnce(t *testing.T) {
+	yml := []byte(`
+tables:
+  analytics.events:
+    - name: view
+      user_id: 1
+    - name: click
+      user_id: 2
+    - name: view
+      items.price: [10, 20]
+`)
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	ctx := newLoadContext()
+	l := New(db, "", false)
+	require.NoError(t, l.loadYml(yml, ctx))
+
+	expectExec(mock, "TRUNCATE TABLE `analytics`.`events`")
+	expectExec(mock, "INSERT INTO `analytics`.`events` (`name`, `user_id`) VALUES ('view', 1), ('click', 2)")
+	expectExec(mock, "INSERT INTO `analytics`.`events` (`items.price`, `name`) VALUES ([10, 20], 'view')")
+
+	require.NoError(t, l.loadTables(ctx))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLoadTablesShouldFailOnUndefinedRef(t *testing.T) {
+	yml := []byte(`
+tables:
+  table1:
+    - f1: $unknown.f1
+`)
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	ctx := newLoadContext()
+	l := New(db, "", false)
+	require.NoError(t, l.loadYml(yml, ctx))
+
+	expectExec(mock, "TRUNCATE TABLE `table1`")
+
+	assert.EqualError(t, l.loadTables(ctx), "unable to process f1 value (of table1): undefined reference unknown")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestQuoteLiteral(t *testing.T) {
+	assert.Equal(t, `'it\'s a \\ path'`, quoteLiteral(`it's a \ path`))
+}
+
+func expectExec(mock sqlmock.Sqlmock, query string) {
+	mock.ExpectExec("^" + regexp.QuoteMeta(query) + "$").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+}
@@ -0,0 +1,52 @@
+package fixtures
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// DBOptions are the connection pool settings of the database opened by OpenDBWithOptions,
+// zero values keep the defaults of database/sql
+type DBOptions struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+}
+
+// OpenDB opens the database of the type with the driver for it, the drivers of MySQL
+// (github.com/go-sql-driver/mysql) and ClickHouse (github.com/ClickHouse/clickhouse-go)
+// have to be imported by the caller
+func OpenDB(dbType DbType, dsn string) (*sql.DB, error) {
+	return OpenDBWithOptions(dbType, dsn, DBOptions{})
+}
+
+func OpenDBWithOptions(dbType DbType, dsn string, opts DBOptions) (*sql.DB, error) {
+	var driver string
+	switch dbType {
+	case Postgres:
+		driver = PostgresParam
+	case Mysql:
+		driver = MysqlParam
+	case Clickhouse:
+		driver = ClickhouseParam
+	default:
+		return nil, fmt.Errorf("db type %d is not an SQL database", dbType)
+	}
+
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.MaxOpenConns != 0 {
+		db.SetMaxOpenConns(opts.MaxOpenConns)
+	}
+	if opts.MaxIdleConns != 0 {
+		db.SetMaxIdleConns(opts.MaxIdleConns)
+	}
+	if opts.ConnMaxLifetime != 0 {
+		db.SetConnMaxLifetime(opts.ConnMaxLifetime)
+	}
+	return db, nil
+}
@@ -5,11 +5,16 @@ import (
 	"strings"
 
 	_ "github.com/lib/pq"
+	"google.golang.org/protobuf/reflect/protoreflect"
 
+	"github.com/lamoda/gonkey/files"
 	"github.com/lamoda/gonkey/fixtures/aerospike"
+	"github.com/lamoda/gonkey/fixtures/cassandra"
+	"github.com/lamoda/gonkey/fixtures/clickhouse"
 	"github.com/lamoda/gonkey/fixtures/mysql"
 	"github.com/lamoda/gonkey/fixtures/postgres"
 	aerospikeClient "github.com/lamoda/gonkey/storage/aerospike"
+	cassandraClient "github.com/lamoda/gonkey/storage/cassandra"
 )
 
 type DbType int
@@ -20,63 +25,192 @@ const (
 	Aerospike
 	Redis
 	CustomLoader // using external loader if gonkey used as a library
+	Clickhouse
+	Cassandra
 )
 
 const (
-	PostgresParam  = "postgres"
-	MysqlParam     = "mysql"
-	AerospikeParam = "aerospike"
-	RedisParam     = "redis"
+	PostgresParam   = "postgres"
+	MysqlParam      = "mysql"
+	AerospikeParam  = "aerospike"
+	RedisParam      = "redis"
+	ClickhouseParam = "clickhouse"
+	CassandraParam  = "cassandra"
 )
 
 type Config struct {
 	DB            *sql.DB
 	Aerospike     *aerospikeClient.Client
+	Cassandra     *cassandraClient.Client
 	DbType        DbType
 	Location      string
 	Debug         bool
 	FixtureLoader Loader
+	// SchemaPerTest makes the Postgres loader load the fixtures of each test into its own schema
+	SchemaPerTest *postgres.SchemaOptions
+	// FS is the filesystem the fixtures are read from, the OS filesystem by default
+	FS files.FS
+	// CopyThreshold makes the Postgres loader copy the tables having at least the number of rows with COPY
+	CopyThreshold int
+	// SharedLocations are the directories of the fixtures shared by the test suites, e.g. the common seeds,
+	// they are looked up in order after Location
+	SharedLocations []string
+	// Environment selects the subdirectory of the fixtures directory whose files take priority, e.g. GONKEY_ENV
+	Environment string
+	// ProtobufMessages describe the messages the Postgres loader serializes the values marked with $protobuf to
+	ProtobufMessages []protoreflect.MessageDescriptor
 }
 
+// Loader loads the fixtures of a test into the storage, the data left by the previous tests
+// is expected to be removed by Load. An implementation for another storage can be passed
+// in FixtureLoader, see Cleaner and Configurable for the optional features.
 type Loader interface {
 	Load(names []string) error
 }
 
+// Cleaner is implemented by the loaders which are able to remove the data of the fixtures,
+// Clean is called after the test with the same fixtures as Load
+type Cleaner interface {
+	Clean(names []string) error
+}
+
+// Configurable is implemented by the custom loaders which need the location of the fixtures
+// and the debug flag, Configure is called by NewLoader before the loader is used
+type Configurable interface {
+	Configure(location string, debug bool)
+}
+
+// TablesLister is implemented by the loaders which are able to tell
+// which tables are touched by the fixtures
+type TablesLister interface {
+	Tables(names []string) ([]string, error)
+}
+
+// FSReader is implemented by the loaders which are able to read the fixtures from another filesystem,
+// SetFS is called by NewLoader if FS is set in the config
+type FSReader interface {
+	SetFS(fsys files.FS)
+}
+
+// Validator is implemented by the loaders which are able to check fixtures files
+// without touching the storage
+type Validator interface {
+	Validate(names []string) error
+}
+
+// Locator is implemented by the loaders reading the fixtures from files, the runner checks that the fixtures
+// of all the tests exist before running them
+type Locator interface {
+	Locate(name string) (string, error)
+}
+
+// EnvironmentAware is implemented by the loaders which are able to pick the fixtures of the environment,
+// SetEnvironment is called by NewLoader if Environment is set in the config
+type EnvironmentAware interface {
+	SetEnvironment(env string)
+}
+
+// SharedLocationsAware is implemented by the loaders which are able to look up the fixtures in several directories,
+// SetSharedLocations is called by NewLoader if SharedLocations are set in the config
+type SharedLocationsAware interface {
+	SetSharedLocations(locations []string)
+}
+
 func NewLoader(cfg *Config) Loader {
 
 	var loader Loader
 
 	location := strings.TrimRight(cfg.Location, "/")
 
+	// the custom loader is used whatever the type of database is
+	if cfg.FixtureLoader != nil {
+		if c, ok := cfg.FixtureLoader.(Configurable); ok {
+			c.Configure(location, cfg.Debug)
+		}
+		setFS(cfg.FixtureLoader, cfg.FS)
+		setEnvironment(cfg.FixtureLoader, cfg.Environment)
+		setSharedLocations(cfg.FixtureLoader, cfg.SharedLocations)
+		return cfg.FixtureLoader
+	}
+
 	switch cfg.DbType {
 	case Postgres:
-		loader = postgres.New(
+		pgLoader := postgres.New(
 			cfg.DB,
 			location,
 			cfg.Debug,
 		)
+		if cfg.SchemaPerTest != nil {
+			pgLoader.SetSchemaPerTest(*cfg.SchemaPerTest)
+		}
+		pgLoader.SetProtobufMessages(cfg.ProtobufMessages)
+		pgLoader.SetCopyThreshold(cfg.CopyThreshold)
+		loader = pgLoader
 	case Mysql:
 		loader = mysql.New(
 			cfg.DB,
 			location,
 			cfg.Debug,
 		)
+	case Clickhouse:
+		loader = clickhouse.New(
+			cfg.DB,
+			location,
+			cfg.Debug,
+		)
 	case Aerospike:
 		loader = aerospike.New(
 			cfg.Aerospike,
 			location,
 			cfg.Debug,
 		)
+	case Cassandra:
+		loader = cassandra.New(
+			cfg.Cassandra,
+			location,
+			cfg.Debug,
+		)
 	default:
-		if cfg.FixtureLoader != nil {
-			return cfg.FixtureLoader
-		}
 		panic("unknown db type")
 	}
 
+	setFS(loader, cfg.FS)
+	setEnvironment(loader, cfg.Environment)
+	setSharedLocations(loader, cfg.SharedLocations)
 	return loader
 }
 
+func setFS(loader Loader, fsys files.FS) {
+	if fsys == nil {
+		return
+	}
+	if r, ok := loader.(FSReader); ok {
+		r.SetFS(fsys)
+	}
+}
+
+func setEnvironment(loader Loader, env string) {
+	if env == "" {
+		return
+	}
+	if e, ok := loader.(EnvironmentAware); ok {
+		e.SetEnvironment(env)
+	}
+}
+
+func setSharedLocations(loader Loader, locations []string) {
+	if len(locations) == 0 {
+		return
+	}
+	if s, ok := loader.(SharedLocationsAware); ok {
+		trimmed := make([]string, len(locations))
+		for i, location := range locations {
+			trimmed[i] = strings.TrimRight(location, "/")
+		}
+		s.SetSharedLocations(trimmed)
+	}
+}
+
 func FetchDbType(dbType string) DbType {
 	switch dbType {
 	case PostgresParam:
@@ -87,6 +221,10 @@ func FetchDbType(dbType string) DbType {
 		return Aerospike
 	case RedisParam:
 		return Redis
+	case ClickhouseParam:
+		return Clickhouse
+	case CassandraParam:
+		return Cassandra
 	default:
 		panic("unknown db type param")
 	}
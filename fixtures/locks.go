@@ -0,0 +1,77 @@
+package fixtures
+
+import (
+	"database/sql"
+	"sort"
+	"sync"
+)
+
+// TableLocks serializes the tests which load fixtures into the same tables,
+// the tests with disjoint sets of tables are not blocked by each other
+type TableLocks struct {
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+func NewTableLocks() *TableLocks {
+	return &TableLocks{
+		locks: make(map[string]*sync.Mutex),
+	}
+}
+
+var (
+	dbLocksMu sync.Mutex
+	dbLocks   = map[*sql.DB]*TableLocks{}
+)
+
+// TableLocksFor returns the locks shared by all runners using the same DB connection
+func TableLocksFor(db *sql.DB) *TableLocks {
+	dbLocksMu.Lock()
+	defer dbLocksMu.Unlock()
+
+	locks, ok := dbLocks[db]
+	if !ok {
+		locks = NewTableLocks()
+		dbLocks[db] = locks
+	}
+	return locks
+}
+
+// Lock acquires the locks of all given tables and returns the function releasing them.
+// The locks are always acquired in the same order, so concurrent calls can't deadlock.
+func (l *TableLocks) Lock(tables []string) (unlock func()) {
+	names := make([]string, 0, len(tables))
+	seen := make(map[string]bool, len(tables))
+	for _, table := range tables {
+		if !seen[table] {
+			seen[table] = true
+			names = append(names, table)
+		}
+	}
+	sort.Strings(names)
+
+	acquired := make([]*sync.Mutex, 0, len(names))
+	for _, name := range names {
+		lock := l.tableLock(name)
+		lock.Lock()
+		acquired = append(acquired, lock)
+	}
+
+	return func() {
+		for i := len(acquired) - 1; i >= 0; i-- {
+			acquired[i].Unlock()
+		}
+	}
+}
+
+func (l *TableLocks) tableLock(name string) *sync.Mutex {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	lock, ok := l.locks[name]
+	if !ok {
+		lock = &sync.Mutex{}
+		l.locks[name] = lock
+	}
+	return lock
+}
@@ -0,0 +1,61 @@
+package fixtures
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTableLocksSerializeOverlappingTables(t *testing.T) {
+	locks := NewTableLocks()
+
+	unlock := locks.Lock([]string{"orders", "users"})
+
+	acquired := make(chan struct{})
+	go func() {
+		defer close(acquired)
+		locks.Lock([]string{"users", "users", "comments"})()
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("overlapping tables were locked twice")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	unlock()
+
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("tables were not unlocked")
+	}
+}
+
+func TestTableLocksDisjointTables(t *testing.T) {
+	locks := NewTableLocks()
+
+	unlock := locks.Lock([]string{"orders"})
+	defer unlock()
+
+	acquired := make(chan struct{})
+	go func() {
+		defer close(acquired)
+		locks.Lock([]string{"users"})()
+	}()
+
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("disjoint tables should not block each other")
+	}
+}
+
+func TestTableLocksForSameConnection(t *testing.T) {
+	db := &sql.DB{}
+
+	assert.Same(t, TableLocksFor(db), TableLocksFor(db))
+	assert.NotSame(t, TableLocksFor(db), TableLocksFor(&sql.DB{}))
+}
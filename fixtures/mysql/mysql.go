@@ -5,20 +5,22 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io/ioutil"
-	"os"
 	"regexp"
 	"sort"
 	"strconv"
 	"strings"
 
 	"gopkg.in/yaml.v2"
+
+	"github.com/lamoda/gonkey/files"
 )
 
 type LoaderMysql struct {
-	db       *sql.DB
-	location string
-	debug    bool
+	db        *sql.DB
+	locations []string
+	env       string
+	debug     bool
+	fs        files.FS
 }
 
 const errNoIdColumn = "Error 1054: Unknown column 'id' in 'where clause'"
@@ -49,12 +51,18 @@ type loadContext struct {
 
 func New(db *sql.DB, location string, debug bool) *LoaderMysql {
 	return &LoaderMysql{
-		db:       db,
-		location: location,
-		debug:    debug,
+		db:        db,
+		locations: []string{location},
+		debug:     debug,
+		fs:        files.OS,
 	}
 }
 
+// SetFS sets the filesystem the fixtures are read from, the OS filesystem by default
+func (l *LoaderMysql) SetFS(fsys files.FS) {
+	l.fs = fsys
+}
+
 func (l *LoaderMysql) Load(names []string) error {
 	ctx := loadContext{
 		refsDefinition: make(rowsDict),
@@ -72,22 +80,55 @@ func (l *LoaderMysql) Load(names []string) error {
 	return l.loadTables(&ctx)
 }
 
-func (l *LoaderMysql) loadFile(name string, ctx *loadContext) error {
-	candidates := []string{
-		l.location + "/" + name,
-		l.location + "/" + name + ".yml",
-		l.location + "/" + name + ".yaml",
-	}
+// Validate reads and parses fixtures files without loading them into the database
+func (l *LoaderMysql) Validate(names []string) error {
+	_, err := l.parse(names)
+	return err
+}
 
-	var err error
-	var file string
+// Tables returns names of the tables the fixtures are loaded into
+func (l *LoaderMysql) Tables(names []string) ([]string, error) {
+	ctx, err := l.parse(names)
+	if err != nil {
+		return nil, err
+	}
+	tables := make([]string, 0, len(ctx.tables))
+	for _, lt := range ctx.tables {
+		tables = append(tables, lt.name)
+	}
+	return tables, nil
+}
 
-	for _, candidate := range candidates {
-		if _, err = os.Stat(candidate); err == nil {
-			file = candidate
-			break
+func (l *LoaderMysql) parse(names []string) (*loadContext, error) {
+	ctx := loadContext{
+		refsDefinition: make(rowsDict),
+		refsInserted:   make(rowsDict),
+	}
+	for _, name := range names {
+		if err := l.loadFile(name, &ctx); err != nil {
+			return nil, fmt.Errorf("unable to load fixture %s: %s", name, err.Error())
 		}
 	}
+	return &ctx, nil
+}
+
+// Locate returns the file of the fixture, the name may have no extension
+func (l *LoaderMysql) Locate(name string) (string, error) {
+	return files.LocateFixture(l.fs, l.locations, l.env, name)
+}
+
+// SetEnvironment sets the environment whose subdirectory of the fixtures directory is looked up first
+func (l *LoaderMysql) SetEnvironment(env string) {
+	l.env = env
+}
+
+// SetSharedLocations sets the directories of the shared fixtures looked up after the fixtures directory
+func (l *LoaderMysql) SetSharedLocations(locations []string) {
+	l.locations = append(l.locations[:1], locations...)
+}
+
+func (l *LoaderMysql) loadFile(name string, ctx *loadContext) error {
+	file, err := l.Locate(name)
 	if err != nil {
 		return err
 	}
@@ -99,7 +140,7 @@ func (l *LoaderMysql) loadFile(name string, ctx *loadContext) error {
 
 	l.printDebug("Loading", file)
 
-	data, err := ioutil.ReadFile(file)
+	data, err := files.ReadFileFS(l.fs, file)
 	if err != nil {
 		return err
 	}
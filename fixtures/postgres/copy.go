@@ -0,0 +1,124 @@
+package postgres
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/lib/pq"
+)
+
+// SetCopyThreshold makes the loader copy the rows of the tables having at least the number of rows
+// with COPY FROM STDIN instead of the INSERT, it's much faster for the large tables. The database
+// must be opened with the lib/pq driver. COPY is disabled if the threshold is 0, it's the default.
+func (f *LoaderPostgres) SetCopyThreshold(rows int) {
+	f.copyThreshold = rows
+}
+
+// copyRows copies the rows of the table, false is returned if the rows can't be copied: the named rows
+// need the inserted values, $eval and the protobuf values are SQL expressions, the missing columns
+// need their defaults and the references to the rows not inserted yet can't be resolved
+func (f *LoaderPostgres) copyRows(ctx *loadContext, tx *sql.Tx, t tableName, rows table) (bool, error) {
+	if f.copyThreshold <= 0 || len(rows) < f.copyThreshold {
+		return false, nil
+	}
+	fields, values, ok := f.copyValues(ctx, rows)
+	if !ok {
+		return false, nil
+	}
+
+	if f.debug {
+		fmt.Printf("Copying %d rows into %s\n", len(rows), t.getFullName())
+	}
+	stmt, err := tx.Prepare(pq.CopyInSchema(t.schema, t.name, fields...))
+	if err != nil {
+		return true, err
+	}
+	defer func() { _ = stmt.Close() }()
+	for _, rowValues := range values {
+		if _, err := stmt.Exec(rowValues...); err != nil {
+			return true, err
+		}
+	}
+	// the rows are sent by the final Exec without arguments
+	if _, err := stmt.Exec(); err != nil {
+		return true, fmt.Errorf("failed to copy rows. DB returned error:\n%s", err)
+	}
+	return true, nil
+}
+
+// copyValues returns the sorted columns of the rows and the text values of the rows for COPY
+func (f *LoaderPostgres) copyValues(ctx *loadContext, rows table) ([]string, [][]interface{}, bool) {
+	var fields []string
+	for name := range rows[0] {
+		if len(name) > 0 && name[0] == '$' {
+			continue
+		}
+		fields = append(fields, name)
+	}
+	sort.Strings(fields)
+
+	values := make([][]interface{}, len(rows))
+	for i, row := range rows {
+		if _, ok := row["$name"]; ok {
+			return nil, nil, false
+		}
+		// all the rows must have the same columns
+		if len(row) != len(fields) {
+			return nil, nil, false
+		}
+		rowValues := make([]interface{}, len(fields))
+		for k, name := range fields {
+			value, present := row[name]
+			if !present {
+				return nil, nil, false
+			}
+			copyValue, ok := f.copyValue(ctx, value)
+			if !ok {
+				return nil, nil, false
+			}
+			rowValues[k] = copyValue
+		}
+		values[i] = rowValues
+	}
+	return fields, values, true
+}
+
+// copyValue converts the value to its text for COPY the same way as toDbValue, the references
+// are resolved to the inserted values
+func (f *LoaderPostgres) copyValue(ctx *loadContext, value interface{}) (interface{}, bool) {
+	if _, _, ok := protobufValue(value); ok {
+		return nil, false
+	}
+	if s, ok := value.(string); ok && len(s) > 0 && s[0] == '$' {
+		if len(s) >= 5 && s[:5] == "$eval" {
+			return nil, false
+		}
+		resolved, err := f.resolveFieldReference(ctx.refsInserted, s)
+		if err != nil {
+			return nil, false
+		}
+		value = resolved
+	}
+
+	switch value := value.(type) {
+	case nil:
+		return nil, true
+	case string:
+		return value, true
+	case int:
+		return strconv.Itoa(value), true
+	case float64:
+		// the inserted integers are decoded from JSON as float64, e.g. the ids
+		return strconv.FormatFloat(value, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(value), true
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, false
+	}
+	return string(encoded), true
+}
@@ -0,0 +1,57 @@
+package postgres
+
+import (
+	"database/sql/driver"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestLoadTablesWithCopy(t *testing.T) {
+	yml, err := ioutil.ReadFile("../testdata/sql_copy.yaml")
+	require.NoError(t, err)
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	ctx := loadContext{
+		refsDefinition: make(rowsDict),
+		refsInserted:   make(rowsDict),
+	}
+	l := New(db, "", false)
+	l.SetCopyThreshold(2)
+	require.NoError(t, l.loadYml(yml, &ctx))
+
+	mock.ExpectBegin()
+	mock.ExpectExec("^TRUNCATE TABLE").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	// the table of the named row is inserted below the threshold
+	mock.ExpectQuery(`^INSERT INTO "public"."users"`).
+		WillReturnRows(sqlmock.NewRows([]string{"json"}).AddRow(`{"id": 1234567, "name": "admin"}`))
+
+	// the references are resolved to the inserted values
+	copyOrders := mock.ExpectPrepare(`^COPY "public"."orders" \("amount", "comment", "user_id"\) FROM STDIN$`)
+	for _, args := range [][]driver.Value{
+		{"10", "tab\tand \\ slash", "1234567"},
+		{"20.5", nil, "1234567"},
+		{"30", `{"gift": true}`, "3"},
+	} {
+		copyOrders.ExpectExec().WithArgs(args...).WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	copyOrders.ExpectExec().WithArgs().WillReturnResult(sqlmock.NewResult(0, 3))
+
+	// $eval and the missing columns need the INSERT
+	mock.ExpectQuery(`^INSERT INTO "public"."items" AS row \("order_id", "price"\) VALUES \(1, \(10 \* 2\)\), \(2, 5\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"json"}))
+	mock.ExpectQuery(`^INSERT INTO "public"."tags" AS row \("color", "name"\) VALUES \(default, 'a'\), \('red', 'b'\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"json"}))
+
+	mock.ExpectExec("^DO").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	require.NoError(t, l.loadTables(&ctx))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
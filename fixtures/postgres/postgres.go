@@ -5,20 +5,30 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io/ioutil"
-	"os"
 	"regexp"
 	"sort"
 	"strconv"
 	"strings"
 
+	"google.golang.org/protobuf/reflect/protoreflect"
 	"gopkg.in/yaml.v2"
+
+	"github.com/lamoda/gonkey/files"
 )
 
 type LoaderPostgres struct {
-	db       *sql.DB
-	location string
-	debug    bool
+	db        *sql.DB
+	locations []string
+	env       string
+	debug     bool
+	fs        files.FS
+	// schemaOpts are set if each test has its own schema, schema is the one of the current test
+	schemaOpts *SchemaOptions
+	schema     string
+	// protobufMessages describe the messages of the values marked with $protobuf
+	protobufMessages []protoreflect.MessageDescriptor
+	// copyThreshold is the number of rows of the tables copied with COPY, see SetCopyThreshold
+	copyThreshold int
 }
 
 type row map[string]interface{}
@@ -31,11 +41,25 @@ type fixture struct {
 	Inherits  []string
 	Tables    yaml.MapSlice
 	Templates yaml.MapSlice
+	// Strategy is the loading strategy of the tables of the file, Strategies overrides it by table
+	Strategy   string
+	Strategies map[string]string
 }
 
+// the strategies of loading the rows of the fixtures into the tables
+const (
+	// StrategyTruncate removes all the rows of the table before the insert, it's the default
+	StrategyTruncate = "truncate"
+	// StrategyDelete removes the rows having the primary keys of the fixtures rows before the insert
+	StrategyDelete = "delete"
+	// StrategyUpsert inserts the rows or updates the ones having the same primary keys
+	StrategyUpsert = "upsert"
+)
+
 type loadedTable struct {
-	name tableName
-	rows table
+	name     tableName
+	rows     table
+	strategy string
 }
 type tableName struct {
 	name   string
@@ -68,12 +92,18 @@ type loadContext struct {
 
 func New(db *sql.DB, location string, debug bool) *LoaderPostgres {
 	return &LoaderPostgres{
-		db:       db,
-		location: location,
-		debug:    debug,
+		db:        db,
+		locations: []string{location},
+		debug:     debug,
+		fs:        files.OS,
 	}
 }
 
+// SetFS sets the filesystem the fixtures are read from, the OS filesystem by default
+func (f *LoaderPostgres) SetFS(fsys files.FS) {
+	f.fs = fsys
+}
+
 func (f *LoaderPostgres) Load(names []string) error {
 	ctx := loadContext{
 		refsDefinition: make(rowsDict),
@@ -89,20 +119,55 @@ func (f *LoaderPostgres) Load(names []string) error {
 	return f.loadTables(&ctx)
 }
 
-func (f *LoaderPostgres) loadFile(name string, ctx *loadContext) error {
-	candidates := []string{
-		f.location + "/" + name,
-		f.location + "/" + name + ".yml",
-		f.location + "/" + name + ".yaml",
-	}
-	var err error
-	var file string
-	for _, candidate := range candidates {
-		if _, err = os.Stat(candidate); err == nil {
-			file = candidate
-			break
+// Validate reads and parses fixtures files without loading them into the database
+func (f *LoaderPostgres) Validate(names []string) error {
+	_, err := f.parse(names)
+	return err
+}
+
+// Tables returns full names of the tables the fixtures are loaded into
+func (f *LoaderPostgres) Tables(names []string) ([]string, error) {
+	ctx, err := f.parse(names)
+	if err != nil {
+		return nil, err
+	}
+	tables := make([]string, 0, len(ctx.tables))
+	for _, lt := range ctx.tables {
+		tables = append(tables, lt.name.getFullName())
+	}
+	return tables, nil
+}
+
+func (f *LoaderPostgres) parse(names []string) (*loadContext, error) {
+	ctx := loadContext{
+		refsDefinition: make(rowsDict),
+		refsInserted:   make(rowsDict),
+	}
+	for _, name := range names {
+		if err := f.loadFile(name, &ctx); err != nil {
+			return nil, fmt.Errorf("unable to load fixture %s: %s", name, err.Error())
 		}
 	}
+	return &ctx, nil
+}
+
+// Locate returns the file of the fixture, the name may have no extension
+func (f *LoaderPostgres) Locate(name string) (string, error) {
+	return files.LocateFixture(f.fs, f.locations, f.env, name)
+}
+
+// SetEnvironment sets the environment whose subdirectory of the fixtures directory is looked up first
+func (f *LoaderPostgres) SetEnvironment(env string) {
+	f.env = env
+}
+
+// SetSharedLocations sets the directories of the shared fixtures looked up after the fixtures directory
+func (f *LoaderPostgres) SetSharedLocations(locations []string) {
+	f.locations = append(f.locations[:1], locations...)
+}
+
+func (f *LoaderPostgres) loadFile(name string, ctx *loadContext) error {
+	file, err := f.Locate(name)
 	if err != nil {
 		return err
 	}
@@ -113,7 +178,7 @@ func (f *LoaderPostgres) loadFile(name string, ctx *loadContext) error {
 	if f.debug {
 		fmt.Println("Loading", file)
 	}
-	data, err := ioutil.ReadFile(file)
+	data, err := files.ReadFileFS(f.fs, file)
 	if err != nil {
 		return err
 	}
@@ -179,6 +244,19 @@ func (f *LoaderPostgres) loadYml(data []byte, ctx *loadContext) error {
 	//        }
 	//    }
 	// }
+	strategy := loadedFixture.Strategy
+	if strategy == "" {
+		strategy = StrategyTruncate
+	}
+	for table, tableStrategy := range loadedFixture.Strategies {
+		if !validStrategy(tableStrategy) {
+			return fmt.Errorf("unknown strategy %s of table %s, expected truncate, delete or upsert", tableStrategy, table)
+		}
+	}
+	if !validStrategy(strategy) {
+		return fmt.Errorf("unknown strategy %s, expected truncate, delete or upsert", strategy)
+	}
+
 	for _, sourceTable := range loadedFixture.Tables {
 		sourceRows, ok := sourceTable.Value.([]interface{})
 		if !ok {
@@ -194,8 +272,12 @@ func (f *LoaderPostgres) loadYml(data []byte, ctx *loadContext) error {
 			rows[i] = fields
 		}
 		lt := loadedTable{
-			name: newTableName(sourceTable.Key.(string)),
-			rows: rows,
+			name:     newTableName(sourceTable.Key.(string)),
+			rows:     rows,
+			strategy: strategy,
+		}
+		if tableStrategy, ok := loadedFixture.Strategies[sourceTable.Key.(string)]; ok {
+			lt.strategy = tableStrategy
 		}
 		ctx.tables = append(ctx.tables, lt)
 	}
@@ -203,13 +285,27 @@ func (f *LoaderPostgres) loadYml(data []byte, ctx *loadContext) error {
 }
 
 func (f *LoaderPostgres) loadTables(ctx *loadContext) error {
+	if f.schemaOpts != nil {
+		// the schema of the previous test is left if it was interrupted
+		if err := f.Clean(nil); err != nil {
+			return err
+		}
+	}
+
 	tx, err := f.db.Begin()
 	if err != nil {
 		return err
 	}
 	defer func() { _ = tx.Rollback() }()
 
-	// truncate first
+	var schema string
+	if f.schemaOpts != nil {
+		if schema, err = f.createTestSchema(tx, ctx); err != nil {
+			return fmt.Errorf("failed to create schema of the test: %s", err)
+		}
+	}
+
+	// truncate first, the tables loaded with the other strategies keep their rows
 	if err := f.truncateTables(tx, ctx.tables...); err != nil {
 		return err
 	}
@@ -219,23 +315,35 @@ func (f *LoaderPostgres) loadTables(ctx *loadContext) error {
 		if len(lt.rows) == 0 {
 			continue
 		}
-		if err := f.loadTable(ctx, tx, lt.name, lt.rows); err != nil {
+		if err := f.loadTable(ctx, tx, lt); err != nil {
 			return fmt.Errorf("failed to load table '%s' because:\n%s", lt.name.getFullName(), err)
 		}
 	}
 	// alter the sequences so they contain max id + 1
-	if err := f.fixSequences(tx); err != nil {
+	if err := f.fixSequences(tx, schema); err != nil {
 		return err
 	}
 
-	return tx.Commit()
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	if schema != "" {
+		f.schema = schema
+		if f.schemaOpts.OnCreate != nil {
+			f.schemaOpts.OnCreate(schema)
+		}
+	}
+	return nil
 }
 
-// truncateTables truncates table
+// truncateTables truncates the tables loaded with the truncate strategy
 func (f *LoaderPostgres) truncateTables(tx *sql.Tx, tables ...loadedTable) error {
 	set := make(map[string]struct{})
 	tablesToTruncate := make([]string, 0, len(tables))
 	for _, t := range tables {
+		if t.strategy != StrategyTruncate {
+			continue
+		}
 		tableName := t.name.getFullName()
 		if _, ok := set[tableName]; ok {
 			// already truncated
@@ -245,6 +353,9 @@ func (f *LoaderPostgres) truncateTables(tx *sql.Tx, tables ...loadedTable) error
 		tablesToTruncate = append(tablesToTruncate, tableName)
 		set[tableName] = struct{}{}
 	}
+	if len(tablesToTruncate) == 0 && len(tables) != 0 {
+		return nil
+	}
 
 	query := fmt.Sprintf("TRUNCATE TABLE %s CASCADE", strings.Join(tablesToTruncate, ","))
 	if f.debug {
@@ -257,7 +368,8 @@ func (f *LoaderPostgres) truncateTables(tx *sql.Tx, tables ...loadedTable) error
 	return nil
 }
 
-func (f *LoaderPostgres) loadTable(ctx *loadContext, tx *sql.Tx, t tableName, rows table) error {
+func (f *LoaderPostgres) loadTable(ctx *loadContext, tx *sql.Tx, lt loadedTable) error {
+	t, rows := lt.name, lt.rows
 	// $extend keyword allows to import values from a named row
 	for i, row := range rows {
 		if base, ok := row["$extend"]; ok {
@@ -272,11 +384,49 @@ func (f *LoaderPostgres) loadTable(ctx *loadContext, tx *sql.Tx, t tableName, ro
 			rows[i] = baseRow
 		}
 	}
+
+	switch lt.strategy {
+	case StrategyDelete:
+		key, err := f.primaryKey(tx, t, lt.strategy)
+		if err != nil {
+			return err
+		}
+		if err := f.deleteRows(ctx, tx, t, rows, key); err != nil {
+			return err
+		}
+	case StrategyUpsert:
+		key, err := f.primaryKey(tx, t, lt.strategy)
+		if err != nil {
+			return err
+		}
+		// each row is upserted on its own so the columns missing in it aren't updated with their defaults
+		for _, row := range rows {
+			query, err := f.buildUpsertQuery(ctx, t, row, key)
+			if err != nil {
+				return err
+			}
+			if err := f.insertRows(ctx, tx, query, table{row}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// the large tables are copied if their rows allow it
+	if copied, err := f.copyRows(ctx, tx, t, rows); copied || err != nil {
+		return err
+	}
+
 	// build SQL
 	query, err := f.buildInsertQuery(ctx, t, rows)
 	if err != nil {
 		return err
 	}
+	return f.insertRows(ctx, tx, query, rows)
+}
+
+// insertRows issues the insert query of the rows and saves the inserted values of the named ones
+func (f *LoaderPostgres) insertRows(ctx *loadContext, tx *sql.Tx, query string, rows table) error {
 	if f.debug {
 		fmt.Println("Issuing SQL:", query)
 	}
@@ -332,7 +482,12 @@ func (f *LoaderPostgres) loadTable(ctx *loadContext, tx *sql.Tx, t tableName, ro
 	return err
 }
 
-func (f *LoaderPostgres) fixSequences(tx *sql.Tx) error {
+// fixSequences alters the sequences of the tables of the schema, or of all tables if the schema is empty
+func (f *LoaderPostgres) fixSequences(tx *sql.Tx, schema string) error {
+	var filter string
+	if schema != "" {
+		filter = " AND tbl_ns.nspname = " + quoteLiteral(schema)
+	}
 	query := `
 DO $$
 DECLARE
@@ -349,7 +504,7 @@ BEGIN
             JOIN pg_namespace tbl_ns ON (tbl.relnamespace = tbl_ns.oid)
             JOIN pg_attribute col ON (col.attrelid = tbl.oid AND dep.refobjsubid = col.attnum)
         WHERE
-            seq.relkind = 'S'
+            seq.relkind = 'S'` + filter + `
         ORDER BY seq.relname
     ) LOOP
         EXECUTE r.q;
@@ -366,6 +521,119 @@ END$$
 // buildInsertQuery builds SQL query for data insertion
 // based on values read from yaml
 func (f *LoaderPostgres) buildInsertQuery(ctx *loadContext, t tableName, rows table) (string, error) {
+	fields, dbValues, err := f.buildValues(ctx, t, rows)
+	if err != nil {
+		return "", err
+	}
+	// quote fields
+	for i, field := range fields {
+		fields[i] = "\"" + field + "\""
+	}
+
+	query := "INSERT INTO %s AS row (%s) VALUES %s RETURNING row_to_json(row)"
+	return fmt.Sprintf(query, t.getFullName(), strings.Join(fields, ", "), strings.Join(dbValues, ", ")), nil
+}
+
+// buildUpsertQuery builds SQL query inserting the row or updating its columns in the row with the same key
+func (f *LoaderPostgres) buildUpsertQuery(ctx *loadContext, t tableName, r row, key []string) (string, error) {
+	fields, dbValues, err := f.buildValues(ctx, t, table{r})
+	if err != nil {
+		return "", err
+	}
+	var updates []string
+	for i, field := range fields {
+		fields[i] = "\"" + field + "\""
+		if !inArray(field, key) {
+			updates = append(updates, fmt.Sprintf("%s = EXCLUDED.%s", fields[i], fields[i]))
+		}
+	}
+	quotedKey := make([]string, len(key))
+	for i, column := range key {
+		quotedKey[i] = quoteIdent(column)
+	}
+	// the row of the key is updated anyway for it to be returned
+	if len(updates) == 0 {
+		for _, column := range quotedKey {
+			updates = append(updates, fmt.Sprintf("%s = EXCLUDED.%s", column, column))
+		}
+	}
+
+	query := "INSERT INTO %s AS row (%s) VALUES %s ON CONFLICT (%s) DO UPDATE SET %s RETURNING row_to_json(row)"
+	return fmt.Sprintf(
+		query,
+		t.getFullName(),
+		strings.Join(fields, ", "),
+		strings.Join(dbValues, ", "),
+		strings.Join(quotedKey, ", "),
+		strings.Join(updates, ", "),
+	), nil
+}
+
+// deleteRows deletes the rows of the table having the keys of the fixtures rows
+func (f *LoaderPostgres) deleteRows(ctx *loadContext, tx *sql.Tx, t tableName, rows table, key []string) error {
+	tuples := make([]string, len(rows))
+	for i, row := range rows {
+		values := make([]string, len(key))
+		for k, column := range key {
+			value, ok := row[column]
+			if !ok {
+				return fmt.Errorf("row %d of %s has no value of the primary key column %s", i, t.getFullName(), column)
+			}
+			dbValue, err := f.dbValue(ctx, t, i, column, value)
+			if err != nil {
+				return err
+			}
+			values[k] = dbValue
+		}
+		tuples[i] = "(" + strings.Join(values, ", ") + ")"
+	}
+	quotedKey := make([]string, len(key))
+	for i, column := range key {
+		quotedKey[i] = quoteIdent(column)
+	}
+
+	query := fmt.Sprintf(
+		"DELETE FROM %s WHERE (%s) IN (%s)", t.getFullName(), strings.Join(quotedKey, ", "), strings.Join(tuples, ", "),
+	)
+	if f.debug {
+		fmt.Println("Issuing SQL:", query)
+	}
+	_, err := tx.Exec(query)
+	return err
+}
+
+// primaryKey returns the columns of the primary key of the table the strategy needs
+func (f *LoaderPostgres) primaryKey(tx *sql.Tx, t tableName, strategy string) ([]string, error) {
+	rows, err := tx.Query(
+		"SELECT a.attname FROM pg_index i "+
+			"JOIN pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = ANY(i.indkey) "+
+			"WHERE i.indrelid = $1::regclass AND i.indisprimary ORDER BY a.attnum",
+		t.getFullName(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var key []string
+	for rows.Next() {
+		var column string
+		if err := rows.Scan(&column); err != nil {
+			return nil, err
+		}
+		key = append(key, column)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(key) == 0 {
+		return nil, fmt.Errorf("table %s has no primary key for the %s strategy", t.getFullName(), strategy)
+	}
+	return key, nil
+}
+
+// buildValues returns the sorted columns of the rows and their SQL values, default for the missing columns
+func (f *LoaderPostgres) buildValues(ctx *loadContext, t tableName, rows table) ([]string, []string, error) {
 	// first pass, collecting all the fields
 	var fields []string
 	fieldPresence := make(map[string]bool)
@@ -391,32 +659,37 @@ func (f *LoaderPostgres) buildInsertQuery(ctx *loadContext, t tableName, rows ta
 				dbValuesRow[k] = "default" // default is a PostgreSQL keyword
 				continue
 			}
-			// resolve references
-			if stringValue, ok := value.(string); ok {
-				if len(stringValue) > 0 && stringValue[0] == '$' {
-					var err error
-					dbValuesRow[k], err = f.resolveExpression(stringValue, ctx)
-					if err != nil {
-						return "", err
-					}
-					continue
-				}
-			}
-			dbValue, err := toDbValue(value)
+			dbValue, err := f.dbValue(ctx, t, i, name, value)
 			if err != nil {
-				return "", fmt.Errorf("unable to process %s value (row %d of %s): %s", name, i, t.getFullName(), err.Error())
+				return nil, nil, err
 			}
 			dbValuesRow[k] = dbValue
 		}
 		dbValues[i] = "(" + strings.Join(dbValuesRow, ", ") + ")"
 	}
-	// quote fields
-	for i, field := range fields {
-		fields[i] = "\"" + field + "\""
-	}
+	return fields, dbValues, nil
+}
 
-	query := "INSERT INTO %s AS row (%s) VALUES %s RETURNING row_to_json(row)"
-	return fmt.Sprintf(query, t.getFullName(), strings.Join(fields, ", "), strings.Join(dbValues, ", ")), nil
+// dbValue converts the value of the column of the row to SQL resolving the expressions
+func (f *LoaderPostgres) dbValue(ctx *loadContext, t tableName, i int, name string, value interface{}) (string, error) {
+	// resolve references
+	if stringValue, ok := value.(string); ok {
+		if len(stringValue) > 0 && stringValue[0] == '$' {
+			return f.resolveExpression(stringValue, ctx)
+		}
+	}
+	if fields, message, ok := protobufValue(value); ok {
+		dbValue, err := f.protobufDbValue(fields, message)
+		if err != nil {
+			return "", fmt.Errorf("unable to process %s value (row %d of %s): %s", name, i, t.getFullName(), err.Error())
+		}
+		return dbValue, nil
+	}
+	dbValue, err := toDbValue(value)
+	if err != nil {
+		return "", fmt.Errorf("unable to process %s value (row %d of %s): %s", name, i, t.getFullName(), err.Error())
+	}
+	return dbValue, nil
 }
 
 // resolveExpression converts expressions starting with dollar sign into a value
@@ -477,6 +750,10 @@ func (f *LoaderPostgres) resolveFieldReference(refs rowsDict, ref string) (inter
 	return value, nil
 }
 
+func validStrategy(strategy string) bool {
+	return strategy == StrategyTruncate || strategy == StrategyDelete || strategy == StrategyUpsert
+}
+
 // inArray checks whether the needle is present in haystack slice
 func inArray(needle string, haystack []string) bool {
 	for _, e := range haystack {
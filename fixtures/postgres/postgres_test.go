@@ -3,6 +3,7 @@ package postgres
 import (
 	"database/sql"
 	"io/ioutil"
+	"regexp"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -231,3 +232,170 @@ func TestLoadTablesShouldExtendRows(t *testing.T) {
 	err = mock.ExpectationsWereMet()
 	require.NoError(t, err)
 }
+
+func TestTablesShouldReturnFixturesTables(t *testing.T) {
+	l := New(&sql.DB{}, "../testdata", false)
+
+	tables, err := l.Tables([]string{"sql_schema"})
+	require.NoError(t, err)
+
+	require.Equal(t, []string{`"schema1"."table1"`, `"schema2"."table2"`, `"public"."table3"`}, tables)
+}
+
+func TestTablesFromCompressedFixture(t *testing.T) {
+	l := New(&sql.DB{}, "../testdata", false)
+
+	tables, err := l.Tables([]string{"sql_compressed"})
+	require.NoError(t, err)
+
+	require.Equal(t, []string{`"schema1"."table1"`, `"schema2"."table2"`, `"public"."table3"`}, tables)
+}
+
+func TestTablesOfEnvironmentFixtures(t *testing.T) {
+	l := New(&sql.DB{}, "../testdata", false)
+	l.SetEnvironment("staging")
+
+	// the fixture of the environment replaces the common one, the others are shared
+	tables, err := l.Tables([]string{"sql_schema", "sql_compressed"})
+	require.NoError(t, err)
+
+	require.Equal(t, []string{`"public"."tenants"`, `"schema1"."table1"`, `"schema2"."table2"`, `"public"."table3"`}, tables)
+}
+
+func TestTablesOfSharedFixtures(t *testing.T) {
+	l := New(&sql.DB{}, "../testdata/staging", false)
+	l.SetSharedLocations([]string{"../testdata"})
+
+	// the fixture of the fixtures directory shadows the shared one, the others are found in the shared directory
+	tables, err := l.Tables([]string{"sql_schema", "sql_compressed"})
+	require.NoError(t, err)
+
+	require.Equal(t, []string{`"public"."tenants"`, `"schema1"."table1"`, `"schema2"."table2"`, `"public"."table3"`}, tables)
+}
+
+func TestLoadTablesWithStrategies(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	l := New(db, "../testdata", false)
+	primaryKey := "^SELECT a.attname FROM pg_index i"
+
+	mock.ExpectBegin()
+	// only the tables of the inherited fixture are truncated
+	mock.ExpectExec(`^TRUNCATE TABLE "schema1"."table1","schema2"."table2","public"."table3" CASCADE$`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	for _, table := range []string{`"schema1"."table1"`, `"schema2"."table2"`, `"public"."table3"`} {
+		mock.ExpectQuery("^INSERT INTO " + regexp.QuoteMeta(table) + " AS row").
+			WillReturnRows(sqlmock.NewRows([]string{"json"}).AddRow("{}"))
+	}
+
+	mock.ExpectQuery(primaryKey).WithArgs(`"public"."table3"`).
+		WillReturnRows(sqlmock.NewRows([]string{"attname"}).AddRow("id"))
+	mock.ExpectQuery("^" + regexp.QuoteMeta(`INSERT INTO "public"."table3" AS row ("field", "id") VALUES ('overridden', 1) `+
+		`ON CONFLICT ("id") DO UPDATE SET "field" = EXCLUDED."field" RETURNING row_to_json(row)`) + "$").
+		WillReturnRows(sqlmock.NewRows([]string{"json"}).AddRow(`{"id": 1, "field": "overridden"}`))
+	mock.ExpectQuery("^" + regexp.QuoteMeta(`INSERT INTO "public"."table3" AS row ("id") VALUES (2) `+
+		`ON CONFLICT ("id") DO UPDATE SET "id" = EXCLUDED."id" RETURNING row_to_json(row)`) + "$").
+		WillReturnRows(sqlmock.NewRows([]string{"json"}).AddRow(`{"id": 2}`))
+
+	mock.ExpectQuery(primaryKey).WithArgs(`"public"."orders"`).
+		WillReturnRows(sqlmock.NewRows([]string{"attname"}).AddRow("id"))
+	mock.ExpectExec("^" + regexp.QuoteMeta(`DELETE FROM "public"."orders" WHERE ("id") IN ((10), (11))`) + "$").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectQuery("^" + regexp.QuoteMeta(`INSERT INTO "public"."orders" AS row ("customer_id", "id", "status") `+
+		`VALUES (7, 10, default), (default, 11, 'new') RETURNING row_to_json(row)`) + "$").
+		WillReturnRows(sqlmock.NewRows([]string{"json"}).AddRow(`{"id": 10}`).AddRow(`{"id": 11}`))
+
+	mock.ExpectExec("^DO").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	require.NoError(t, l.Load([]string{"sql_strategies"}))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLoadTablesWithStrategiesErrors(t *testing.T) {
+	l := New(&sql.DB{}, "", false)
+	ctx := loadContext{refsDefinition: make(rowsDict), refsInserted: make(rowsDict)}
+	err := l.loadYml([]byte("strategy: replace\ntables:\n  orders:\n    - id: 1\n"), &ctx)
+	require.EqualError(t, err, "unknown strategy replace, expected truncate, delete or upsert")
+	err = l.loadYml([]byte("strategies:\n  orders: merge\n"), &ctx)
+	require.EqualError(t, err, "unknown strategy merge of table orders, expected truncate, delete or upsert")
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+	l = New(db, "", false)
+
+	ctx = loadContext{refsDefinition: make(rowsDict), refsInserted: make(rowsDict)}
+	require.NoError(t, l.loadYml([]byte("strategy: delete\ntables:\n  orders:\n    - status: new\n"), &ctx))
+	mock.ExpectBegin()
+	mock.ExpectQuery("^SELECT a.attname FROM pg_index i").
+		WillReturnRows(sqlmock.NewRows([]string{"attname"}).AddRow("id"))
+	mock.ExpectRollback()
+	err = l.loadTables(&ctx)
+	require.EqualError(t, err, "failed to load table '\"public\".\"orders\"' because:\n"+
+		"row 0 of \"public\".\"orders\" has no value of the primary key column id")
+
+	ctx = loadContext{refsDefinition: make(rowsDict), refsInserted: make(rowsDict)}
+	require.NoError(t, l.loadYml([]byte("strategy: upsert\ntables:\n  events:\n    - name: created\n"), &ctx))
+	mock.ExpectBegin()
+	mock.ExpectQuery("^SELECT a.attname FROM pg_index i").WillReturnRows(sqlmock.NewRows([]string{"attname"}))
+	mock.ExpectRollback()
+	err = l.loadTables(&ctx)
+	require.EqualError(t, err, "failed to load table '\"public\".\"events\"' because:\n"+
+		"table \"public\".\"events\" has no primary key for the upsert strategy")
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLoadTablesIntoSchemaPerTest(t *testing.T) {
+	yml, err := ioutil.ReadFile("../testdata/sql_schema.yaml")
+	require.NoError(t, err)
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	ctx := loadContext{
+		refsDefinition: make(map[string]row),
+		refsInserted:   make(map[string]row),
+	}
+
+	var created string
+	l := New(db, "", false)
+	l.SetSchemaPerTest(SchemaOptions{OnCreate: func(schema string) { created = schema }})
+
+	err = l.loadYml(yml, &ctx)
+	require.NoError(t, err)
+
+	schema := `"gonkey_[0-9a-f]{16}"`
+	mock.ExpectBegin()
+	mock.ExpectExec("^CREATE SCHEMA " + schema + "$").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("^SET LOCAL search_path TO " + schema + `, "public"$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("^CREATE TABLE " + schema + `."table3" \(LIKE "public"."table3" INCLUDING ALL\)$`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("^SELECT table_name, column_name FROM information_schema.columns").
+		WillReturnRows(sqlmock.NewRows([]string{"table_name", "column_name"}).AddRow("table3", "id"))
+	mock.ExpectExec("^CREATE SEQUENCE " + schema + `."table3_id_seq" OWNED BY ` + schema + `."table3"."id"$`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("^ALTER TABLE " + schema + `."table3" ALTER COLUMN "id" SET DEFAULT nextval`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`^TRUNCATE TABLE "schema1"."table1","schema2"."table2",` + schema + `."table3" CASCADE$`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	for _, table := range []string{`"schema1"."table1"`, `"schema2"."table2"`, schema + `."table3"`} {
+		mock.ExpectQuery("^INSERT INTO " + table).
+			WillReturnRows(sqlmock.NewRows([]string{"json"}).AddRow("{}"))
+	}
+	mock.ExpectExec(`^DO(.|\n)*AND tbl_ns.nspname = 'gonkey_`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	require.NoError(t, l.loadTables(&ctx))
+	require.Regexp(t, "^gonkey_[0-9a-f]{16}$", created)
+	require.Equal(t, created, l.Schema())
+
+	mock.ExpectExec(`^DROP SCHEMA "` + created + `" CASCADE$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	require.NoError(t, l.Clean([]string{"sql_schema"}))
+	require.Empty(t, l.Schema())
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
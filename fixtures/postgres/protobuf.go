@@ -0,0 +1,94 @@
+package postgres
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"gopkg.in/yaml.v2"
+)
+
+// protobufKey marks the value of a bytea column written as JSON of a protobuf message:
+//
+//	payload:
+//	  $protobuf: shop.v1.Order
+//	  id: 42
+const protobufKey = "$protobuf"
+
+// SetProtobufMessages sets the descriptors of the protobuf messages the values marked with $protobuf
+// are serialized to, e.g. (&pb.Order{}).ProtoReflect().Descriptor()
+func (f *LoaderPostgres) SetProtobufMessages(descriptors []protoreflect.MessageDescriptor) {
+	f.protobufMessages = descriptors
+}
+
+// protobufValue returns the fields of the message and its full name if the value is marked with $protobuf
+func protobufValue(value interface{}) (map[string]interface{}, string, bool) {
+	if _, ok := value.(yaml.MapSlice); !ok {
+		return nil, "", false
+	}
+	fields := jsonCompatible(value).(map[string]interface{})
+	name, ok := fields[protobufKey].(string)
+	if !ok {
+		return nil, "", false
+	}
+	delete(fields, protobufKey)
+	return fields, name, true
+}
+
+// protobufDbValue serializes the fields to the binary form of the message and returns the bytea literal
+func (f *LoaderPostgres) protobufDbValue(fields map[string]interface{}, name string) (string, error) {
+	var descriptor protoreflect.MessageDescriptor
+	for _, d := range f.protobufMessages {
+		if string(d.FullName()) == name {
+			descriptor = d
+		}
+	}
+	if descriptor == nil {
+		return "", fmt.Errorf("protobuf message %s is not registered", name)
+	}
+
+	jsonData, err := json.Marshal(fields)
+	if err != nil {
+		return "", err
+	}
+	message := dynamicpb.NewMessage(descriptor)
+	if err := protojson.Unmarshal(jsonData, message); err != nil {
+		return "", fmt.Errorf("invalid %s message: %s", name, err)
+	}
+	// the deterministic form keeps the queries stable
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(message)
+	if err != nil {
+		return "", err
+	}
+	return quoteLiteral(`\x`+hex.EncodeToString(data)) + "::bytea", nil
+}
+
+// jsonCompatible converts the maps decoded from YAML to the maps with string keys
+func jsonCompatible(value interface{}) interface{} {
+	switch v := value.(type) {
+	case yaml.MapSlice:
+		res := make(map[string]interface{}, len(v))
+		for _, item := range v {
+			res[fmt.Sprintf("%v", item.Key)] = jsonCompatible(item.Value)
+		}
+		return res
+	case map[interface{}]interface{}:
+		res := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			res[fmt.Sprintf("%v", k)] = jsonCompatible(item)
+		}
+		return res
+	case []interface{}:
+		res := make([]interface{}, len(v))
+		for i, item := range v {
+			res[i] = jsonCompatible(item)
+		}
+		return res
+	default:
+		return v
+	}
+}
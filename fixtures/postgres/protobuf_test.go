@@ -0,0 +1,79 @@
+package postgres
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func orderDescriptor(t *testing.T) protoreflect.MessageDescriptor {
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:    proto.String("shop/order.proto"),
+		Package: proto.String("shop"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("Order"),
+			Field: []*descriptorpb.FieldDescriptorProto{{
+				Name:     proto.String("id"),
+				JsonName: proto.String("id"),
+				Number:   proto.Int32(1),
+				Type:     descriptorpb.FieldDescriptorProto_TYPE_INT32.Enum(),
+				Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			}, {
+				Name:     proto.String("name"),
+				JsonName: proto.String("name"),
+				Number:   proto.Int32(2),
+				Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+				Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			}},
+		}},
+	}, nil)
+	require.NoError(t, err)
+	return file.Messages().ByName("Order")
+}
+
+const protobufFixture = `
+tables:
+  orders:
+    - id: 1
+      payload:
+        $protobuf: shop.Order
+        id: 42
+        name: x
+`
+
+func TestBuildInsertQueryWithProtobuf(t *testing.T) {
+	ctx := loadContext{
+		refsDefinition: make(map[string]row),
+		refsInserted:   make(map[string]row),
+	}
+
+	l := New(&sql.DB{}, "", false)
+	l.SetProtobufMessages([]protoreflect.MessageDescriptor{orderDescriptor(t)})
+	require.NoError(t, l.loadYml([]byte(protobufFixture), &ctx))
+
+	query, err := l.buildInsertQuery(&ctx, newTableName("orders"), ctx.tables[0].rows)
+	require.NoError(t, err)
+	assert.Equal(t, `INSERT INTO "public"."orders" AS row ("id", "payload") VALUES `+
+		`(1, E'\\x082a120178'::bytea) RETURNING row_to_json(row)`, query)
+}
+
+func TestBuildInsertQueryWithUnknownProtobuf(t *testing.T) {
+	ctx := loadContext{
+		refsDefinition: make(map[string]row),
+		refsInserted:   make(map[string]row),
+	}
+
+	l := New(&sql.DB{}, "", false)
+	require.NoError(t, l.loadYml([]byte(protobufFixture), &ctx))
+
+	_, err := l.buildInsertQuery(&ctx, newTableName("orders"), ctx.tables[0].rows)
+	assert.EqualError(t, err, `unable to process payload value (row 0 of "public"."orders"): `+
+		`protobuf message shop.Order is not registered`)
+}
@@ -0,0 +1,146 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// SchemaOptions of the schema created for each test, see SetSchemaPerTest
+type SchemaOptions struct {
+	// Template is the schema whose tables are copied into the schema of the test, "public" by default
+	Template string
+	// OnCreate is called with the name of the schema when the fixtures are loaded into it,
+	// the service under test has to set its search_path to the schema (e.g. "<schema>, public")
+	OnCreate func(schema string)
+}
+
+// SetSchemaPerTest makes the loader create a uniquely named schema for each test and load the fixtures
+// of the template schema into it, the schema is dropped by Clean. The tables of the fixtures are copied
+// from the template schema with their columns, defaults, indexes and constraints except foreign keys.
+func (f *LoaderPostgres) SetSchemaPerTest(opts SchemaOptions) {
+	if opts.Template == "" {
+		opts.Template = "public"
+	}
+	f.schemaOpts = &opts
+}
+
+// Schema returns the schema of the current test, it's empty unless the schema per test is used
+func (f *LoaderPostgres) Schema() string {
+	return f.schema
+}
+
+// Clean drops the schema of the test, the tables of the shared schemas are left as is
+func (f *LoaderPostgres) Clean(names []string) error {
+	if f.schema == "" {
+		return nil
+	}
+	query := fmt.Sprintf("DROP SCHEMA %s CASCADE", quoteIdent(f.schema))
+	if f.debug {
+		fmt.Println("Issuing SQL:", query)
+	}
+	if _, err := f.db.Exec(query); err != nil {
+		return err
+	}
+	f.schema = ""
+	return nil
+}
+
+func newSchemaName() string {
+	return "gonkey_" + strings.ReplaceAll(uuid.New().String(), "-", "")[:16]
+}
+
+// createTestSchema creates the schema of the test with the copies of the fixtures tables from the template schema,
+// the tables are switched to the new schema
+func (f *LoaderPostgres) createTestSchema(tx *sql.Tx, ctx *loadContext) (string, error) {
+	schema := newSchemaName()
+	queries := []string{
+		fmt.Sprintf("CREATE SCHEMA %s", quoteIdent(schema)),
+		fmt.Sprintf("SET LOCAL search_path TO %s, %s", quoteIdent(schema), quoteIdent(f.schemaOpts.Template)),
+	}
+
+	created := make(map[string]bool)
+	for i, lt := range ctx.tables {
+		if lt.name.schema != f.schemaOpts.Template {
+			continue
+		}
+		copied := tableName{schema: schema, name: lt.name.name}
+		if !created[lt.name.name] {
+			queries = append(queries, fmt.Sprintf(
+				"CREATE TABLE %s (LIKE %s INCLUDING ALL)", copied.getFullName(), lt.name.getFullName(),
+			))
+			created[lt.name.name] = true
+		}
+		ctx.tables[i].name = copied
+	}
+
+	for _, query := range queries {
+		if f.debug {
+			fmt.Println("Issuing SQL:", query)
+		}
+		if _, err := tx.Exec(query); err != nil {
+			return "", err
+		}
+	}
+
+	if err := f.ownSequences(tx, schema); err != nil {
+		return "", err
+	}
+	return schema, nil
+}
+
+// ownSequences replaces the sequences of the template tables in the defaults of serial columns
+// with the sequences of the test schema, so the inserts of the service don't touch the template
+func (f *LoaderPostgres) ownSequences(tx *sql.Tx, schema string) error {
+	rows, err := tx.Query(
+		"SELECT table_name, column_name FROM information_schema.columns "+
+			"WHERE table_schema = $1 AND column_default LIKE 'nextval(%' ORDER BY table_name, column_name",
+		schema,
+	)
+	if err != nil {
+		return err
+	}
+	var columns [][2]string
+	for rows.Next() {
+		var column [2]string
+		if err := rows.Scan(&column[0], &column[1]); err != nil {
+			_ = rows.Close()
+			return err
+		}
+		columns = append(columns, column)
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, column := range columns {
+		table := tableName{schema: schema, name: column[0]}
+		sequence := tableName{schema: schema, name: column[0] + "_" + column[1] + "_seq"}
+		queries := []string{
+			fmt.Sprintf(
+				"CREATE SEQUENCE %s OWNED BY %s.%s",
+				sequence.getFullName(), table.getFullName(), quoteIdent(column[1]),
+			),
+			fmt.Sprintf(
+				"ALTER TABLE %s ALTER COLUMN %s SET DEFAULT nextval(%s)",
+				table.getFullName(), quoteIdent(column[1]), quoteLiteral(sequence.getFullName()),
+			),
+		}
+		for _, query := range queries {
+			if f.debug {
+				fmt.Println("Issuing SQL:", query)
+			}
+			if _, err := tx.Exec(query); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func quoteIdent(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
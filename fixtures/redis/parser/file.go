@@ -5,6 +5,8 @@ import (
     "fmt"
     "path/filepath"
     "strings"
+
+    "github.com/lamoda/gonkey/files"
 )
 
 var (
@@ -46,7 +48,7 @@ func (l *fileParser) ParseFiles(ctx *context, names []string) ([]*Fixture, error
                 continue
             }
 
-            extension := strings.Replace(filepath.Ext(filename), ".", "", -1)
+            extension := strings.Replace(filepath.Ext(strings.TrimSuffix(filename, files.GzipExt)), ".", "", -1)
             fixtureParser := GetParser(extension)
             if fixtureParser == nil {
                 return nil, ErrParserNotFound
@@ -71,6 +73,8 @@ func (l *fileParser) getFirstExistsFileName(name string, location string) (strin
         name,
         fmt.Sprintf("%s.yaml", name),
         fmt.Sprintf("%s.yml", name),
+        fmt.Sprintf("%s.yaml%s", name, files.GzipExt),
+        fmt.Sprintf("%s.yml%s", name, files.GzipExt),
     }
 
     for _, p := range candidates {
@@ -3,9 +3,10 @@ package parser
 import (
     "errors"
     "fmt"
-    "io/ioutil"
 
     "gopkg.in/yaml.v3"
+
+    "github.com/lamoda/gonkey/files"
 )
 
 type redisYamlParser struct {
@@ -435,7 +436,7 @@ func (p *redisYamlParser) buildZSets(ctx *context, data *ZSets) error {
 }
 
 func (p *redisYamlParser) Parse(ctx *context, filename string) (*Fixture, error) {
-    data, err := ioutil.ReadFile(filename)
+    data, err := files.ReadFile(filename)
     if err != nil {
         return nil, err
     }
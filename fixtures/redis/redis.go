@@ -35,6 +35,14 @@ func (l *loader) Load(names []string) error {
     return l.loadData(fixtureList)
 }
 
+// Validate parses fixtures files without loading them into the storage
+func (l *loader) Validate(names []string) error {
+    ctx := parser.NewContext()
+    fileParser := parser.New(l.locations)
+    _, err := fileParser.ParseFiles(ctx, names)
+    return err
+}
+
 func (l *loader) loadKeys(ctx context.Context, pipe redis.Pipeliner, db parser.Database) error {
     if db.Keys == nil {
         return nil
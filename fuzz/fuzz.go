@@ -0,0 +1,498 @@
+// Package fuzz generates random values valid against JSON Schema for the fuzz tests,
+// which assert that the tested service handles any valid request without a server error
+package fuzz
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// maxDepth protects from the schemas referring to themselves
+const maxDepth = 16
+
+// maxRandomLength is how much longer than minLength the strings and than minItems the arrays are
+// when their maximum isn't set
+const maxRandomLength = 16
+
+// unsupportedKeywords can't be satisfied by the generated values, the schemas using them are rejected
+// rather than producing invalid values
+var unsupportedKeywords = []string{"pattern", "multipleOf", "not", "patternProperties", "dependencies"}
+
+// the characters of the strings include the ones services tend to mishandle
+var stringRunes = []rune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789 _-.,:;!?\"'\\/<>&%{}[]()\tйЖ€ü中😀")
+
+// LoadSchema reads the JSON Schema from the JSON or YAML file
+func LoadSchema(path string) (map[string]interface{}, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read schema: %s", err)
+	}
+
+	var schema map[string]interface{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &schema)
+	default:
+		err = json.Unmarshal(data, &schema)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema %s: %s", path, err)
+	}
+	return schema, nil
+}
+
+// Generator generates random values valid against the JSON Schema. The generators with the same seed
+// produce the same values, so a failing value can be reproduced.
+type Generator struct {
+	root map[string]interface{}
+	rnd  *rand.Rand
+}
+
+func NewGenerator(schema map[string]interface{}, seed int64) *Generator {
+	return &Generator{
+		root: schema,
+		rnd:  rand.New(rand.NewSource(seed)),
+	}
+}
+
+// Generate returns the next random value of the schema
+func (g *Generator) Generate() (interface{}, error) {
+	return g.generate(g.root, 0)
+}
+
+func (g *Generator) generate(schema map[string]interface{}, depth int) (interface{}, error) {
+	if depth > maxDepth {
+		return nil, errors.New("the schema is nested too deep, probably it refers to itself")
+	}
+	for _, keyword := range unsupportedKeywords {
+		if _, ok := schema[keyword]; ok {
+			return nil, fmt.Errorf("keyword %s of the schema is not supported", keyword)
+		}
+	}
+
+	if ref, ok := schema["$ref"].(string); ok {
+		resolved, err := g.resolve(ref)
+		if err != nil {
+			return nil, err
+		}
+		return g.generate(resolved, depth+1)
+	}
+	if value, ok := schema["const"]; ok {
+		return value, nil
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok && len(enum) > 0 {
+		return enum[g.rnd.Intn(len(enum))], nil
+	}
+	if allOf, ok := schema["allOf"].([]interface{}); ok {
+		merged, err := g.mergeAllOf(schema, allOf)
+		if err != nil {
+			return nil, err
+		}
+		return g.generate(merged, depth+1)
+	}
+	for _, keyword := range []string{"oneOf", "anyOf"} {
+		if variants, ok := schema[keyword].([]interface{}); ok && len(variants) > 0 {
+			variant, ok := variants[g.rnd.Intn(len(variants))].(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("the variants of %s must be schemas", keyword)
+			}
+			return g.generate(variant, depth+1)
+		}
+	}
+
+	switch typ := schemaType(schema, g.rnd); typ {
+	case "object":
+		return g.object(schema, depth)
+	case "array":
+		return g.array(schema, depth)
+	case "string":
+		return g.string(schema)
+	case "integer":
+		return g.integer(schema)
+	case "number":
+		return g.number(schema)
+	case "boolean":
+		return g.rnd.Intn(2) == 1, nil
+	case "null":
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown type %s of the schema", typ)
+	}
+}
+
+// schemaType returns the type of the schema, a random one of the list of the types,
+// the type is guessed by the keywords if it's not set
+func schemaType(schema map[string]interface{}, rnd *rand.Rand) string {
+	switch typ := schema["type"].(type) {
+	case string:
+		return typ
+	case []interface{}:
+		if len(typ) > 0 {
+			return fmt.Sprint(typ[rnd.Intn(len(typ))])
+		}
+	}
+	if _, ok := schema["properties"]; ok {
+		return "object"
+	}
+	if _, ok := schema["items"]; ok {
+		return "array"
+	}
+	return "string"
+}
+
+// resolve returns the schema referred to by the local JSON pointer, e.g. #/definitions/item
+func (g *Generator) resolve(ref string) (map[string]interface{}, error) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, fmt.Errorf("only the local references are supported, got %s", ref)
+	}
+
+	var node interface{} = g.root
+	for _, token := range strings.Split(strings.TrimPrefix(ref, "#"), "/") {
+		if token == "" {
+			continue
+		}
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+		object, ok := node.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("unresolved reference %s", ref)
+		}
+		if node, ok = object[token]; !ok {
+			return nil, fmt.Errorf("unresolved reference %s", ref)
+		}
+	}
+	schema, ok := node.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("reference %s doesn't point to a schema", ref)
+	}
+	return schema, nil
+}
+
+// mergeAllOf merges the schemas of allOf into the schema: the properties and the required
+// properties are joined, the other keywords of the later schemas win
+func (g *Generator) mergeAllOf(schema map[string]interface{}, allOf []interface{}) (map[string]interface{}, error) {
+	merged := make(map[string]interface{}, len(schema))
+	for keyword, value := range schema {
+		if keyword != "allOf" {
+			merged[keyword] = value
+		}
+	}
+
+	for _, item := range allOf {
+		part, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, errors.New("the items of allOf must be schemas")
+		}
+		if ref, ok := part["$ref"].(string); ok {
+			resolved, err := g.resolve(ref)
+			if err != nil {
+				return nil, err
+			}
+			part = resolved
+		}
+		for keyword, value := range part {
+			switch keyword {
+			case "properties":
+				properties := map[string]interface{}{}
+				if existing, ok := merged["properties"].(map[string]interface{}); ok {
+					for name, property := range existing {
+						properties[name] = property
+					}
+				}
+				if added, ok := value.(map[string]interface{}); ok {
+					for name, property := range added {
+						properties[name] = property
+					}
+				}
+				merged["properties"] = properties
+			case "required":
+				required, _ := merged["required"].([]interface{})
+				added, _ := value.([]interface{})
+				merged["required"] = append(append([]interface{}{}, required...), added...)
+			default:
+				merged[keyword] = value
+			}
+		}
+	}
+	return merged, nil
+}
+
+// object generates the required properties and a random half of the optional ones,
+// the properties are generated in the order of their names to keep the values reproducible
+func (g *Generator) object(schema map[string]interface{}, depth int) (interface{}, error) {
+	properties, _ := schema["properties"].(map[string]interface{})
+	required := map[string]bool{}
+	var requiredNames []string
+	if items, ok := schema["required"].([]interface{}); ok {
+		for _, item := range items {
+			required[fmt.Sprint(item)] = true
+			requiredNames = append(requiredNames, fmt.Sprint(item))
+		}
+	}
+
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	object := map[string]interface{}{}
+	for _, name := range names {
+		if !required[name] && g.rnd.Intn(2) == 0 {
+			continue
+		}
+		property, ok := properties[name].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("property %s must be a schema", name)
+		}
+		value, err := g.generate(property, depth+1)
+		if err != nil {
+			return nil, fmt.Errorf("property %s: %s", name, err)
+		}
+		object[name] = value
+	}
+
+	// the required properties without schemas can hold any value
+	for _, name := range requiredNames {
+		if _, ok := object[name]; !ok {
+			object[name] = g.randomString(0, maxRandomLength)
+		}
+	}
+	return object, nil
+}
+
+func (g *Generator) array(schema map[string]interface{}, depth int) (interface{}, error) {
+	minItems := intKeyword(schema, "minItems", 0)
+	maxItems := intKeyword(schema, "maxItems", minItems+maxRandomLength/2)
+	if maxItems < minItems {
+		return nil, fmt.Errorf("maxItems %d is less than minItems %d", maxItems, minItems)
+	}
+	items, _ := schema["items"].(map[string]interface{})
+	if items == nil {
+		items = map[string]interface{}{"type": "string"}
+	}
+	unique, _ := schema["uniqueItems"].(bool)
+
+	count := minItems + g.rnd.Intn(maxItems-minItems+1)
+	array := make([]interface{}, 0, count)
+	// the duplicates of the unique items are generated again a limited number of times
+	for attempts := 0; len(array) < count && attempts < count*10; attempts++ {
+		item, err := g.generate(items, depth+1)
+		if err != nil {
+			return nil, fmt.Errorf("items: %s", err)
+		}
+		if unique && contains(array, item) {
+			continue
+		}
+		array = append(array, item)
+	}
+	if len(array) < minItems {
+		return nil, fmt.Errorf("unable to generate %d unique items", minItems)
+	}
+	return array, nil
+}
+
+func (g *Generator) string(schema map[string]interface{}) (interface{}, error) {
+	switch format, _ := schema["format"].(string); format {
+	case "date-time":
+		return g.time().Format(time.RFC3339), nil
+	case "date":
+		return g.time().Format("2006-01-02"), nil
+	case "time":
+		return g.time().Format("15:04:05Z"), nil
+	case "email":
+		return g.word() + "@" + g.word() + ".com", nil
+	case "uuid":
+		buf := make([]byte, 16)
+		g.rnd.Read(buf)
+		buf[6] = buf[6]&0x0f | 0x40
+		buf[8] = buf[8]&0x3f | 0x80
+		return fmt.Sprintf("%x-%x-%x-%x-%x", buf[0:4], buf[4:6], buf[6:8], buf[8:10], buf[10:]), nil
+	case "uri", "url":
+		return "https://" + g.word() + ".com/" + g.word(), nil
+	case "ipv4":
+		return fmt.Sprintf("%d.%d.%d.%d", g.rnd.Intn(256), g.rnd.Intn(256), g.rnd.Intn(256), g.rnd.Intn(256)), nil
+	}
+
+	minLength := intKeyword(schema, "minLength", 0)
+	maxLength := intKeyword(schema, "maxLength", minLength+maxRandomLength)
+	if maxLength < minLength {
+		return nil, fmt.Errorf("maxLength %d is less than minLength %d", maxLength, minLength)
+	}
+	return g.randomString(minLength, maxLength), nil
+}
+
+func (g *Generator) integer(schema map[string]interface{}) (interface{}, error) {
+	b, err := boundsOf(schema, -1000000, 1000000)
+	if err != nil {
+		return nil, err
+	}
+	low, high := int64(math.Ceil(b.min)), int64(math.Floor(b.max))
+	if b.exclusiveMin && float64(low) == b.min {
+		low++
+	}
+	if b.exclusiveMax && float64(high) == b.max {
+		high--
+	}
+	if high < low {
+		return nil, fmt.Errorf("no integers between %v and %v", b.min, b.max)
+	}
+
+	// the bounds and zero are picked more often than the other values
+	if g.rnd.Intn(4) == 0 {
+		edges := []int64{low, high}
+		if low < 0 && high > 0 {
+			edges = append(edges, 0)
+		}
+		return edges[g.rnd.Intn(len(edges))], nil
+	}
+	return low + g.rnd.Int63n(high-low+1), nil
+}
+
+func (g *Generator) number(schema map[string]interface{}) (interface{}, error) {
+	b, err := boundsOf(schema, -1000000, 1000000)
+	if err != nil {
+		return nil, err
+	}
+	if b.max < b.min || b.max == b.min && (b.exclusiveMin || b.exclusiveMax) {
+		return nil, fmt.Errorf("no numbers between %v and %v", b.min, b.max)
+	}
+
+	value := b.min + g.rnd.Float64()*(b.max-b.min)
+	if value == b.min && b.exclusiveMin || value == b.max && b.exclusiveMax {
+		value = (b.min + b.max) / 2
+	}
+	return value, nil
+}
+
+func (g *Generator) randomString(minLength, maxLength int) string {
+	length := minLength + g.rnd.Intn(maxLength-minLength+1)
+	runes := make([]rune, length)
+	for i := range runes {
+		runes[i] = stringRunes[g.rnd.Intn(len(stringRunes))]
+	}
+	return string(runes)
+}
+
+func (g *Generator) word() string {
+	const letters = "abcdefghijklmnopqrstuvwxyz"
+	word := make([]byte, 3+g.rnd.Intn(8))
+	for i := range word {
+		word[i] = letters[g.rnd.Intn(len(letters))]
+	}
+	return string(word)
+}
+
+func (g *Generator) time() time.Time {
+	return time.Unix(g.rnd.Int63n(4102444800), 0).UTC()
+}
+
+// numberBounds are the bounds of the numbers of the schema
+type numberBounds struct {
+	min, max                   float64
+	exclusiveMin, exclusiveMax bool
+}
+
+// boundsOf returns the bounds of the numbers, the default bounds are shifted to the bound
+// which is set when the other one is not
+func boundsOf(schema map[string]interface{}, defaultMin, defaultMax float64) (numberBounds, error) {
+	var b numberBounds
+	var hasMin, hasMax bool
+	var err error
+	b.min, b.exclusiveMin, hasMin, err = bound(schema, "minimum", "exclusiveMinimum", func(x, y float64) bool { return x > y })
+	if err != nil {
+		return b, err
+	}
+	b.max, b.exclusiveMax, hasMax, err = bound(schema, "maximum", "exclusiveMaximum", func(x, y float64) bool { return x < y })
+	if err != nil {
+		return b, err
+	}
+
+	spread := defaultMax - defaultMin
+	if !hasMin {
+		b.min = math.Min(defaultMin, b.max-spread)
+	}
+	if !hasMax {
+		b.max = math.Max(defaultMax, b.min+spread)
+	}
+	return b, nil
+}
+
+// bound returns the tightest of the inclusive and the exclusive bounds: the numeric exclusive keyword
+// of the later drafts is a bound itself, the boolean one of draft 4 excludes the inclusive bound
+func bound(
+	schema map[string]interface{},
+	inclusiveKeyword, exclusiveKeyword string,
+	tighter func(x, y float64) bool,
+) (value float64, isExclusive, ok bool, err error) {
+	if v, found := schema[inclusiveKeyword]; found {
+		if value, err = toFloat(v); err != nil {
+			return 0, false, false, fmt.Errorf("%s: %s", inclusiveKeyword, err)
+		}
+		ok = true
+	}
+
+	switch v := schema[exclusiveKeyword].(type) {
+	case nil:
+	case bool:
+		isExclusive = v && ok
+	default:
+		number, err := toFloat(v)
+		if err != nil {
+			return 0, false, false, fmt.Errorf("%s: %s", exclusiveKeyword, err)
+		}
+		if !ok || !tighter(value, number) {
+			value, isExclusive, ok = number, true, true
+		}
+	}
+	return value, isExclusive, ok, nil
+}
+
+func intKeyword(schema map[string]interface{}, keyword string, defaultValue int) int {
+	value, ok := schema[keyword]
+	if !ok {
+		return defaultValue
+	}
+	number, err := toFloat(value)
+	if err != nil || number < 0 {
+		return defaultValue
+	}
+	return int(number)
+}
+
+func toFloat(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case uint64:
+		return float64(v), nil
+	case string:
+		return strconv.ParseFloat(v, 64)
+	default:
+		return 0, fmt.Errorf("%v is not a number", value)
+	}
+}
+
+func contains(items []interface{}, item interface{}) bool {
+	for _, existing := range items {
+		if reflect.DeepEqual(existing, item) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,135 @@
+package fuzz
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const orderSchema = `{
+	"type": "object",
+	"required": ["id", "items", "status"],
+	"properties": {
+		"id": {"type": "string", "format": "uuid"},
+		"status": {"enum": ["new", "paid"]},
+		"comment": {"type": "string", "minLength": 2, "maxLength": 5},
+		"email": {"type": "string", "format": "email"},
+		"created_at": {"type": "string", "format": "date-time"},
+		"total": {"type": "number", "minimum": 0, "maximum": 10, "exclusiveMaximum": true},
+		"items": {
+			"type": "array",
+			"minItems": 1,
+			"maxItems": 3,
+			"uniqueItems": true,
+			"items": {
+				"type": "object",
+				"required": ["quantity"],
+				"properties": {
+					"quantity": {"type": "integer", "minimum": 1, "maximum": 5},
+					"gift": {"type": "boolean"}
+				}
+			}
+		}
+	}
+}`
+
+func parseSchema(t *testing.T, data string) map[string]interface{} {
+	var schema map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(data), &schema))
+	return schema
+}
+
+func generateJSON(t *testing.T, g *Generator) []byte {
+	value, err := g.Generate()
+	require.NoError(t, err)
+	body, err := json.Marshal(value)
+	require.NoError(t, err)
+	return body
+}
+
+func TestGenerateValidValues(t *testing.T) {
+	var validator openapi3.Schema
+	require.NoError(t, json.Unmarshal([]byte(orderSchema), &validator))
+
+	g := NewGenerator(parseSchema(t, orderSchema), 1)
+	for i := 0; i < 200; i++ {
+		body := generateJSON(t, g)
+
+		var value interface{}
+		require.NoError(t, json.Unmarshal(body, &value))
+		assert.NoError(t, validator.VisitJSON(value), string(body))
+	}
+}
+
+func TestGenerateReproducible(t *testing.T) {
+	schema := parseSchema(t, orderSchema)
+	first, second, other := NewGenerator(schema, 42), NewGenerator(schema, 42), NewGenerator(schema, 43)
+
+	var differs bool
+	for i := 0; i < 10; i++ {
+		body := generateJSON(t, first)
+		assert.Equal(t, string(body), string(generateJSON(t, second)))
+		differs = differs || string(body) != string(generateJSON(t, other))
+	}
+	assert.True(t, differs, "the values of the other seed must differ")
+}
+
+func TestGenerateExclusiveBounds(t *testing.T) {
+	// exclusiveMinimum is a number since draft 6
+	g := NewGenerator(parseSchema(t, `{"type": "integer", "exclusiveMinimum": 1, "maximum": 3}`), 1)
+	for i := 0; i < 50; i++ {
+		value, err := g.Generate()
+		require.NoError(t, err)
+		assert.Contains(t, []interface{}{int64(2), int64(3)}, value)
+	}
+}
+
+func TestGenerateReferences(t *testing.T) {
+	schema := parseSchema(t, `{
+		"definitions": {
+			"base": {"required": ["id"], "properties": {"id": {"const": 1}}}
+		},
+		"allOf": [
+			{"$ref": "#/definitions/base"},
+			{"required": ["kind"], "properties": {"kind": {"oneOf": [{"const": "a"}, {"const": "b"}]}}}
+		]
+	}`)
+
+	g := NewGenerator(schema, 1)
+	for i := 0; i < 20; i++ {
+		value, err := g.Generate()
+		require.NoError(t, err)
+
+		object, ok := value.(map[string]interface{})
+		require.True(t, ok)
+		assert.Equal(t, float64(1), object["id"])
+		assert.Contains(t, []interface{}{"a", "b"}, object["kind"])
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	tests := []struct {
+		schema string
+		err    string
+	}{
+		{`{"type": "string", "pattern": "^a+$"}`, "keyword pattern of the schema is not supported"},
+		{`{"$ref": "other.json#/item"}`, "only the local references are supported, got other.json#/item"},
+		{`{"$ref": "#/definitions/missing"}`, "unresolved reference #/definitions/missing"},
+		{`{"$ref": "#"}`, "the schema is nested too deep, probably it refers to itself"},
+		{`{"type": "integer", "minimum": 2, "maximum": 1}`, "no integers between 2 and 1"},
+		{`{"type": "date"}`, "unknown type date of the schema"},
+		{
+			`{"properties": {"name": {"type": "string", "minLength": 3, "maxLength": 1}}, "required": ["name"]}`,
+			"property name: maxLength 1 is less than minLength 3",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.schema, func(t *testing.T) {
+			_, err := NewGenerator(parseSchema(t, tt.schema), 1).Generate()
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+}
@@ -3,10 +3,16 @@ module github.com/lamoda/gonkey
 go 1.14
 
 require (
+	github.com/ClickHouse/clickhouse-go v1.5.4
 	github.com/Masterminds/sprig/v3 v3.2.2
 	github.com/aerospike/aerospike-client-go/v5 v5.8.0
+	github.com/antchfx/xmlquery v1.3.11
+	github.com/antchfx/xpath v1.2.1
 	github.com/fatih/color v1.7.0
+	github.com/getkin/kin-openapi v0.80.0
 	github.com/go-redis/redis/v9 v9.0.0-beta.2
+	github.com/go-sql-driver/mysql v1.6.0
+	github.com/gocql/gocql v1.6.0
 	github.com/google/go-cmp v0.5.8
 	github.com/google/uuid v1.1.1
 	github.com/huandu/xstrings v1.3.2 // indirect
@@ -22,6 +28,7 @@ require (
 	github.com/yuin/gopher-lua v0.0.0-20220504180219-658193537a64 // indirect
 	golang.org/x/crypto v0.0.0-20220525230936-793ad666bf5e // indirect
 	golang.org/x/sync v0.0.0-20210220032951-036812b2e83c
+	google.golang.org/protobuf v1.28.0
 	gopkg.in/DATA-DOG/go-sqlmock.v1 v1.3.0
 	gopkg.in/yaml.v2 v2.4.0
 	gopkg.in/yaml.v3 v3.0.1
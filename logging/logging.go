@@ -0,0 +1,74 @@
+// Package logging passes the structured events of the runner internals to a logger of the user,
+// e.g. to route them into slog or zap:
+//
+//	logging.Func(func(event string, keysAndValues ...interface{}) {
+//		slog.Debug(event, keysAndValues...)
+//	})
+package logging
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Logger receives the events, e.g. "test finished", with the alternating keys and values of their fields
+// the same way as slog.Logger.Info
+type Logger interface {
+	Log(event string, keysAndValues ...interface{})
+}
+
+// Func adapts a function to Logger
+type Func func(event string, keysAndValues ...interface{})
+
+func (f Func) Log(event string, keysAndValues ...interface{}) {
+	f(event, keysAndValues...)
+}
+
+// Nop drops the events
+type Nop struct{}
+
+func (Nop) Log(string, ...interface{}) {}
+
+type textLogger struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewText writes the events one per line as `gonkey: event key=value ...`, the values with spaces are quoted
+func NewText(w io.Writer) Logger {
+	return &textLogger{w: w}
+}
+
+func (l *textLogger) Log(event string, keysAndValues ...interface{}) {
+	var b strings.Builder
+	b.WriteString("gonkey: ")
+	b.WriteString(event)
+	for i := 0; i < len(keysAndValues); i += 2 {
+		key := fmt.Sprint(keysAndValues[i])
+		value := "<missing>"
+		if i+1 < len(keysAndValues) {
+			value = fmt.Sprint(keysAndValues[i+1])
+		}
+		if value == "" || strings.ContainsAny(value, " \t\n\"=") {
+			value = strconv.Quote(value)
+		}
+		b.WriteString(" " + key + "=" + value)
+	}
+	b.WriteString("\n")
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, _ = io.WriteString(l.w, b.String())
+}
+
+// FromEnv is the default logger: the events are written to stdout if GONKEY_DEBUG is set and dropped otherwise
+func FromEnv() Logger {
+	if os.Getenv("GONKEY_DEBUG") != "" {
+		return NewText(os.Stdout)
+	}
+	return Nop{}
+}
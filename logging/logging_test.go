@@ -0,0 +1,51 @@
+package logging
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTextLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewText(&buf)
+
+	logger.Log("test finished", "test", "create order", "status", "passed", "duration", 15*time.Millisecond)
+	logger.Log("checker finished", "checker", "response_body", "errors", 0, "odd")
+	logger.Log("fixtures failed", "error", errors.New(`table "orders" is missing`), "fixtures", "")
+
+	assert.Equal(t,
+		"gonkey: test finished test=\"create order\" status=passed duration=15ms\n"+
+			"gonkey: checker finished checker=response_body errors=0 odd=<missing>\n"+
+			"gonkey: fixtures failed error=\"table \\\"orders\\\" is missing\" fixtures=\"\"\n",
+		buf.String(),
+	)
+}
+
+func TestFunc(t *testing.T) {
+	var events []string
+	var fields []interface{}
+	logger := Func(func(event string, keysAndValues ...interface{}) {
+		events = append(events, event)
+		fields = append(fields, keysAndValues...)
+	})
+
+	logger.Log("test started", "test", "list orders")
+
+	assert.Equal(t, []string{"test started"}, events)
+	assert.Equal(t, []interface{}{"test", "list orders"}, fields)
+}
+
+func TestFromEnv(t *testing.T) {
+	defer os.Setenv("GONKEY_DEBUG", os.Getenv("GONKEY_DEBUG"))
+
+	os.Setenv("GONKEY_DEBUG", "")
+	assert.Equal(t, Nop{}, FromEnv())
+
+	os.Setenv("GONKEY_DEBUG", "1")
+	assert.IsType(t, &textLogger{}, FromEnv())
+}
@@ -9,19 +9,36 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/aerospike/aerospike-client-go/v5"
 	"github.com/go-redis/redis/v9"
+	"github.com/gocql/gocql"
 	"github.com/joho/godotenv"
 
+	// the drivers of MySQL and ClickHouse for -db_dsn, the driver of PostgreSQL is imported by the fixtures
+	_ "github.com/ClickHouse/clickhouse-go"
+	_ "github.com/go-sql-driver/mysql"
+
 	"github.com/lamoda/gonkey/checker/response_body"
+	"github.com/lamoda/gonkey/checker/response_cache"
+	"github.com/lamoda/gonkey/checker/response_cookie"
 	"github.com/lamoda/gonkey/checker/response_db"
+	"github.com/lamoda/gonkey/checker/response_metrics"
+	"github.com/lamoda/gonkey/checker/response_snapshot"
+	"github.com/lamoda/gonkey/checker/response_status_text"
 	"github.com/lamoda/gonkey/fixtures"
 	redisLoader "github.com/lamoda/gonkey/fixtures/redis"
+	"github.com/lamoda/gonkey/logging"
+	"github.com/lamoda/gonkey/mocks"
+	"github.com/lamoda/gonkey/openapi"
+	"github.com/lamoda/gonkey/output"
 	"github.com/lamoda/gonkey/output/allure_report"
 	"github.com/lamoda/gonkey/output/console_colored"
+	"github.com/lamoda/gonkey/output/json_report"
 	"github.com/lamoda/gonkey/runner"
 	aerospikeAdapter "github.com/lamoda/gonkey/storage/aerospike"
+	cassandraAdapter "github.com/lamoda/gonkey/storage/cassandra"
 	"github.com/lamoda/gonkey/testloader/yaml_file"
 	"github.com/lamoda/gonkey/variables"
 )
@@ -31,6 +48,8 @@ type config struct {
 	TestsLocation    string
 	DbDsn            string
 	AerospikeHost    string
+	CassandraHosts   string
+	CassandraRead    string
 	RedisURL         string
 	FixturesLocation string
 	EnvFile          string
@@ -38,11 +57,49 @@ type config struct {
 	Verbose          bool
 	Debug            bool
 	DbType           string
+	DryRun           bool
+	ConsoleBodySize  int
+	AllureBodySize   int
+	Env              string
+	EnvOverridesDir  string
+	ProfilesFile     string
+	JsonReport       string
+	MaxFailures      int
+	MaxFailureRate   float64
+	RequireTags      string
+	Sample           string
+	SampleSeed       int64
+	OpenAPISpec      string
+	OpenAPIResponses bool
+	Mocks            string
+	MocksSeed        int64
+	UniqueSeed       int64
+	FailUnusedMocks  bool
+	DbOptions        fixtures.DBOptions
+	DbExplain        time.Duration
+	CopyThreshold    int
+	SharedFixtures   string
+	BaseDir          string
+	CacheDir         string
+	HTTP2            bool
+	NoKeepAlives     bool
+	MaxIdleConns     int
+	Redact           stringsFlag
+	MetricsURL       string
+	Snapshots        string
+	SnapshotIgnore   stringsFlag
+	Update           bool
+	WaitTimeout      time.Duration
+	WaitFor          string
+	SlowestTests     int
+	RateLimit        float64
+	RateLimitJitter  time.Duration
 }
 
 type storages struct {
 	db        *sql.DB
 	aerospike *aerospikeAdapter.Client
+	cassandra *cassandraAdapter.Client
 }
 
 func main() {
@@ -50,6 +107,7 @@ func main() {
 	validateConfig(&cfg)
 
 	storages := initStorages(cfg)
+	waitForDependencies(cfg, storages.db)
 
 	testHandler := runner.NewConsoleHandler()
 	fixturesLoader := initLoaders(storages, cfg)
@@ -59,21 +117,35 @@ func main() {
 		log.Fatal(err)
 	}
 
-	testsRunner := initRunner(cfg, fixturesLoader, testHandler, proxyURL)
+	serviceMocks := initMocks(cfg)
+	if serviceMocks != nil {
+		defer serviceMocks.Shutdown()
+	}
+
+	testsRunner := initRunner(cfg, fixturesLoader, serviceMocks, testHandler, proxyURL, storages.db)
 
 	consoleOutput := console_colored.NewOutput(cfg.Verbose)
+	consoleOutput.SetMaxBodySize(cfg.ConsoleBodySize)
 	testsRunner.AddOutput(consoleOutput)
 
-	addCheckers(testsRunner, storages.db)
+	addCheckers(testsRunner, storages, cfg)
 
 	var allureOutput *allure_report.AllureReportOutput
 	if cfg.Allure {
 		allureOutput = allure_report.NewOutput("Gonkey", "./allure-results")
+		allureOutput.SetMaxBodySize(cfg.AllureBodySize)
 		testsRunner.AddOutput(allureOutput)
 	}
 
+	var jsonOutput *json_report.JsonReportOutput
+	if cfg.JsonReport != "" {
+		jsonOutput = json_report.NewOutput(cfg.JsonReport)
+		testsRunner.AddOutput(jsonOutput)
+	}
+
 	err = testsRunner.Run()
-	if err != nil {
+	var gateErr *runner.SummaryGateError
+	if err != nil && !errors.As(err, &gateErr) {
 		log.Fatal(err)
 	}
 
@@ -81,32 +153,70 @@ func main() {
 		allureOutput.Finalize()
 	}
 
+	if jsonOutput != nil {
+		if err := jsonOutput.Finalize(); err != nil {
+			log.Fatal(err)
+		}
+	}
+
 	summary := testHandler.Summary()
 	consoleOutput.ShowSummary(summary)
+	consoleOutput.ShowRepeatedTests(testsRunner.RepeatedTests())
+	if cfg.SlowestTests > 0 {
+		consoleOutput.ShowSlowestTests(testsRunner.SlowestTests(cfg.SlowestTests))
+	}
+	if gateErr != nil {
+		log.Println(gateErr)
+		os.Exit(1)
+	}
 	if !summary.Success {
 		os.Exit(1)
 	}
 }
 
+// waitForDependencies waits for the database and the addresses of -wait-for to respond
+func waitForDependencies(cfg config, db *sql.DB) {
+	if cfg.WaitTimeout == 0 && cfg.WaitFor == "" {
+		return
+	}
+
+	var addrs []string
+	for _, addr := range strings.Split(cfg.WaitFor, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			addrs = append(addrs, addr)
+		}
+	}
+	if err := runner.WaitForDependencies(db, addrs, cfg.WaitTimeout); err != nil {
+		log.Fatal(err)
+	}
+}
+
 func initStorages(cfg config) storages {
 	db := initDB(cfg)
 	aerospikeClient := initAerospike(cfg)
+	cassandraClient := initCassandra(cfg)
 	return storages{
 		db:        db,
 		aerospike: aerospikeClient,
+		cassandra: cassandraClient,
 	}
 }
 
 func initLoaders(storages storages, cfg config) fixtures.Loader {
 	var fixturesLoader fixtures.Loader
 	if cfg.FixturesLocation != "" {
-		if storages.db != nil || storages.aerospike != nil {
+		if storages.db != nil || storages.aerospike != nil || storages.cassandra != nil {
 			fixturesLoader = fixtures.NewLoader(&fixtures.Config{
-				DB:        storages.db,
-				Aerospike: storages.aerospike,
-				Location:  cfg.FixturesLocation,
-				Debug:     cfg.Debug,
-				DbType:    fixtures.FetchDbType(cfg.DbType),
+				DB:            storages.db,
+				Aerospike:     storages.aerospike,
+				Cassandra:     storages.cassandra,
+				Location:      cfg.FixturesLocation,
+				Debug:         cfg.Debug,
+				DbType:        fixtures.FetchDbType(cfg.DbType),
+				Environment:   cfg.Env,
+				CopyThreshold: cfg.CopyThreshold,
+
+				SharedLocations: sharedFixturesLocations(cfg.SharedFixtures),
 			})
 		} else if cfg.DbType == fixtures.RedisParam {
 			redisOptions, err := redis.ParseURL(cfg.RedisURL)
@@ -145,34 +255,204 @@ func validateConfig(cfg *config) {
 	}
 }
 
-func addCheckers(r *runner.Runner, db *sql.DB) {
-	r.AddCheckers(response_body.NewChecker())
-	if db != nil {
-		r.AddCheckers(response_db.NewChecker(db))
+func addCheckers(r *runner.Runner, storages storages, cfg config) {
+	update := updateGolden(cfg)
+	r.AddCheckers(response_body.NewCheckerWithOptions(response_body.Options{
+		UpdateGolden:     update,
+		CaptureResponses: os.Getenv(response_body.CaptureResponsesEnv) != "",
+	}))
+	r.AddCheckers(response_cache.NewChecker())
+	r.AddCheckers(response_cookie.NewChecker())
+	r.AddCheckers(response_status_text.NewChecker())
+	if cfg.MetricsURL != "" {
+		r.AddCheckers(response_metrics.NewChecker(cfg.MetricsURL))
+	}
+	if cfg.Snapshots != "" {
+		r.AddCheckers(response_snapshot.NewChecker(response_snapshot.Options{
+			Dir:         cfg.Snapshots,
+			Update:      update,
+			IgnorePaths: cfg.SnapshotIgnore,
+		}))
+	}
+	if storages.cassandra != nil {
+		r.AddCheckers(response_db.NewSelectorChecker(storages.cassandra, response_db.Options{
+			UpdateGolden: update,
+			Verbose:      cfg.Verbose,
+		}))
+	} else if storages.db != nil {
+		r.AddCheckers(response_db.NewCheckerWithOptions(storages.db, response_db.Options{
+			UpdateGolden:      update,
+			Dialect:           response_db.DialectOf(fixtures.FetchDbType(cfg.DbType)),
+			Verbose:           cfg.Verbose,
+			ExplainSlowerThan: cfg.DbExplain,
+		}))
+	}
+}
+
+// initMocks starts the mocks listed as name=host:port, the tested service should be configured
+// to call its dependencies at these addresses
+func initMocks(cfg config) *mocks.Mocks {
+	if cfg.Mocks == "" {
+		return nil
 	}
+
+	addrs := make(map[string]string)
+	for _, item := range strings.Split(cfg.Mocks, ",") {
+		parts := strings.SplitN(strings.TrimSpace(item), "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			log.Fatalf("couldn't parse mock %q, should be in form of name=host:port", item)
+		}
+		addrs[parts[0]] = parts[1]
+	}
+
+	names := make([]string, 0, len(addrs))
+	for name := range addrs {
+		names = append(names, name)
+	}
+	serviceMocks := mocks.NewNop(names...)
+	if cfg.MocksSeed != 0 {
+		serviceMocks.SetRandomSeed(cfg.MocksSeed)
+	}
+	serviceMocks.SetFailOnUnused(cfg.FailUnusedMocks)
+	if err := serviceMocks.StartWithAddrs(addrs); err != nil {
+		log.Fatal(err)
+	}
+	return serviceMocks
 }
 
 func initRunner(
 	cfg config,
 	fixturesLoader fixtures.Loader,
+	serviceMocks *mocks.Mocks,
 	handler *runner.ConsoleHandler,
 	proxyURL *url.URL,
+	db *sql.DB,
 ) *runner.Runner {
+	yamlLoader := yaml_file.NewLoader(cfg.TestsLocation)
+	yamlLoader.SetBaseDir(cfg.BaseDir)
+	yamlLoader.SetCacheDir(cfg.CacheDir)
+	if cfg.Env != "" {
+		overrides, err := yaml_file.LoadOverrides(yaml_file.OverridesFile(cfg.EnvOverridesDir, cfg.Env))
+		if err != nil {
+			log.Fatal(err)
+		}
+		yamlLoader.SetOverrides(overrides)
+	}
+	if cfg.ProfilesFile != "" {
+		profiles, err := yaml_file.LoadProfiles(cfg.ProfilesFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		yamlLoader.SetProfiles(profiles)
+	}
+	if cfg.Sample != "" {
+		rate, err := yaml_file.ParseSampleRate(cfg.Sample)
+		if err != nil {
+			log.Fatal(err)
+		}
+		yamlLoader.SetSample(rate, cfg.SampleSeed)
+	}
+
+	var validator *openapi.Validator
+	if cfg.OpenAPISpec != "" {
+		var err error
+		validator, err = openapi.NewValidator(cfg.OpenAPISpec)
+		if err != nil {
+			log.Fatal(err)
+		}
+		validator.SetValidateResponses(cfg.OpenAPIResponses)
+	}
+
+	var mocksLoader *mocks.Loader
+	if serviceMocks != nil {
+		mocksLoader = mocks.NewLoader(serviceMocks)
+		mocksLoader.SetEnvironment(cfg.Env)
+	}
+
 	return runner.New(
 		&runner.Config{
-			Host:           cfg.Host,
-			FixturesLoader: fixturesLoader,
-			Variables:      variables.New(),
-			HttpProxyURL:   proxyURL,
+			Host:                cfg.Host,
+			FixturesLoader:      fixturesLoader,
+			Mocks:               serviceMocks,
+			MocksLoader:         mocksLoader,
+			Variables:           variables.New(),
+			HttpProxyURL:        proxyURL,
+			HTTP2:               cfg.HTTP2,
+			DisableKeepAlives:   cfg.NoKeepAlives,
+			MaxIdleConnsPerHost: cfg.MaxIdleConns,
+			DryRun:              cfg.DryRun,
+			SummaryGate:         summaryGate(cfg),
+			OpenAPI:             validator,
+			UpdateGolden:        updateGolden(cfg),
+			RateLimit:           rateLimit(cfg),
+			UniqueValues:        uniqueValues(cfg),
+			Logger:              logger(cfg),
+			Redaction:           redaction(cfg),
+			DB:                  db,
 		},
-		yaml_file.NewLoader(cfg.TestsLocation),
+		yamlLoader,
 		handler.HandleTest,
 	)
 }
 
+// uniqueValues are seeded with -unique-seed to reproduce the values of {{ $uniqueInt }}
+func uniqueValues(cfg config) *variables.UniqueValues {
+	if cfg.UniqueSeed == 0 {
+		return nil
+	}
+	return variables.NewUniqueValues(cfg.UniqueSeed)
+}
+
+// logger writes the events of the runner to stdout with -debug, GONKEY_DEBUG is used otherwise
+func logger(cfg config) logging.Logger {
+	if cfg.Debug {
+		return logging.NewText(os.Stdout)
+	}
+	return logging.FromEnv()
+}
+
+// updateGolden tells whether the golden files and the snapshots which differ are rewritten
+func updateGolden(cfg config) bool {
+	return cfg.Update || os.Getenv("GONKEY_UPDATE_GOLDEN") != ""
+}
+
+func redaction(cfg config) *output.Redaction {
+	redaction, err := output.NewRedaction(cfg.Redact)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return redaction
+}
+
+func rateLimit(cfg config) *runner.RateLimiter {
+	if cfg.RateLimit <= 0 {
+		return nil
+	}
+	return runner.NewRateLimiter(cfg.RateLimit, 1, cfg.RateLimitJitter)
+}
+
+func summaryGate(cfg config) *runner.SummaryGate {
+	if cfg.MaxFailures == 0 && cfg.MaxFailureRate == 0 && cfg.RequireTags == "" {
+		return nil
+	}
+
+	gate := &runner.SummaryGate{
+		MaxFailures:    cfg.MaxFailures,
+		MaxFailureRate: cfg.MaxFailureRate,
+	}
+	if cfg.RequireTags != "" {
+		gate.RequireTags = strings.Split(cfg.RequireTags, ",")
+	}
+	return gate
+}
+
 func initAerospike(cfg config) *aerospikeAdapter.Client {
 	if cfg.AerospikeHost != "" {
 		address, port, namespace := parseAerospikeHost(cfg.AerospikeHost)
+		if cfg.DryRun {
+			// fixtures are only validated, there is no need to connect
+			return aerospikeAdapter.New(nil, namespace)
+		}
 		client, err := aerospike.NewClient(address, port)
 		if err != nil {
 			log.Fatal("Couldn't connect to aerospike: ", err)
@@ -183,10 +463,33 @@ func initAerospike(cfg config) *aerospikeAdapter.Client {
 	return nil
 }
 
+func initCassandra(cfg config) *cassandraAdapter.Client {
+	if cfg.CassandraHosts == "" {
+		return nil
+	}
+
+	hosts, keyspace := parseCassandraHosts(cfg.CassandraHosts)
+	readConsistency, err := gocql.ParseConsistencyWrapper(cfg.CassandraRead)
+	if err != nil {
+		log.Fatal("couldn't parse cassandra read consistency: ", err)
+	}
+	if cfg.DryRun {
+		// fixtures are only validated, there is no need to connect
+		return cassandraAdapter.New(nil, readConsistency)
+	}
+
+	cluster := gocql.NewCluster(hosts...)
+	cluster.Keyspace = keyspace
+	session, err := cluster.CreateSession()
+	if err != nil {
+		log.Fatal("Couldn't connect to cassandra: ", err)
+	}
+	return cassandraAdapter.New(session, readConsistency)
+}
+
 func initDB(cfg config) *sql.DB {
 	if cfg.DbDsn != "" {
-		var err error
-		db, err := sql.Open("postgres", cfg.DbDsn)
+		db, err := fixtures.OpenDBWithOptions(fixtures.FetchDbType(cfg.DbType), cfg.DbDsn, cfg.DbOptions)
 		if err != nil {
 			log.Fatal(err)
 		}
@@ -204,22 +507,97 @@ func getConfig() config {
 	flag.StringVar(&cfg.DbDsn, "db_dsn", "", "DSN for the fixtures database (WARNING! Db tables will be truncated)")
 	flag.StringVar(&cfg.AerospikeHost, "aerospike_host", "", "Aerospike host for fixtures in form of 'host:port/namespace' (WARNING! Aerospike sets will be truncated)")
 	flag.StringVar(&cfg.RedisURL, "redis_url", "", "Redis server URL for fixture loading")
+	flag.StringVar(&cfg.CassandraHosts, "cassandra_hosts", "", "Cassandra hosts for fixtures in form of 'host:port,host:port/keyspace' (WARNING! Cassandra tables will be truncated)")
+	flag.StringVar(&cfg.CassandraRead, "cassandra-read-consistency", "QUORUM", "Consistency of the dbQuery checks on Cassandra, e.g. LOCAL_QUORUM")
+	flag.IntVar(&cfg.DbOptions.MaxOpenConns, "db-max-open-conns", 0, "Max number of open connections to the fixtures database, 0 means no limit")
+	flag.IntVar(&cfg.DbOptions.MaxIdleConns, "db-max-idle-conns", 0, "Max number of idle connections to the fixtures database, 0 means the default (2)")
+	flag.DurationVar(&cfg.DbOptions.ConnMaxLifetime, "db-conn-max-lifetime", 0, "Max lifetime of a connection to the fixtures database, e.g. 5m, 0 means no limit")
+	flag.StringVar(&cfg.SharedFixtures, "fixtures-shared", "", "Comma-separated paths to the directories of the shared fixtures, looked up in order after -fixtures")
+	flag.IntVar(&cfg.CopyThreshold, "fixtures-copy-threshold", 0, "Load the Postgres fixtures of the tables having at least this number of rows with COPY instead of INSERT, 0 disables COPY")
+	flag.DurationVar(&cfg.DbExplain, "db-explain-slower-than", 0, "Attach EXPLAIN ANALYZE plans of the dbQuery of the tests whose request or query took longer, e.g. 500ms (PostgreSQL only)")
 	flag.StringVar(&cfg.FixturesLocation, "fixtures", "", "Path to fixtures directory")
 	flag.StringVar(&cfg.EnvFile, "env-file", "", "Path to env-file")
+	flag.StringVar(&cfg.Env, "env", os.Getenv("GONKEY_ENV"), "Name of the environment whose overrides file is applied to the tests (GONKEY_ENV by default)")
+	flag.StringVar(&cfg.EnvOverridesDir, "env-overrides-dir", "environments", "Directory with environment overrides files")
+	flag.StringVar(&cfg.ProfilesFile, "profiles", "", "Path to the file with variable profiles the tests refer to with useProfile")
 	flag.BoolVar(&cfg.Allure, "allure", true, "Make Allure report")
 	flag.BoolVar(&cfg.Verbose, "v", false, "Verbose output")
 	flag.BoolVar(&cfg.Debug, "debug", false, "Debug output")
+	flag.IntVar(&cfg.ConsoleBodySize, "console-max-body-size", 0, "Max size in bytes of the response body shown in the console output, 0 means no limit")
+	flag.StringVar(&cfg.JsonReport, "json-report", "", "Path to the JSON report with the results of every checker")
+	flag.IntVar(&cfg.AllureBodySize, "allure-max-body-size", 0, "Max size in bytes of the response body attached to the Allure report, 0 means no limit")
+	flag.IntVar(&cfg.MaxFailures, "max-failures", 0, "Fail the run if more tests fail, 0 means no limit")
+	flag.Float64Var(&cfg.MaxFailureRate, "max-failure-rate", 0, "Fail the run if the share of failed tests is greater (e.g. 0.05 for 5%), 0 means no limit")
+	flag.StringVar(&cfg.RequireTags, "require-tags", "", "Comma-separated tags of the tests which must pass, e.g. critical")
+	flag.StringVar(&cfg.Sample, "sample", os.Getenv("GONKEY_SAMPLE"), "Share of the tests to run, e.g. 10% (GONKEY_SAMPLE by default), the tests tagged with always-run are always run")
+	flag.Int64Var(&cfg.SampleSeed, "sample-seed", 0, "Seed of the tests sample, the same seed selects the same tests")
+	flag.StringVar(&cfg.OpenAPISpec, "openapi-spec", "", "Path to the OpenAPI 3 spec, the tests with the requests not conforming to it fail without being sent")
+	flag.BoolVar(&cfg.OpenAPIResponses, "openapi-validate-responses", false, "Validate the responses against the OpenAPI spec as well")
+	flag.StringVar(&cfg.Mocks, "mocks", "", "Comma-separated mocks started for the tests in form of name=host:port, e.g. payments=localhost:8081")
+	flag.Int64Var(&cfg.MocksSeed, "mocks-seed", 0, "Seed of the random strategies of the mocks, the same seed gives the same responses, 0 means a random seed")
+	flag.Int64Var(&cfg.UniqueSeed, "unique-seed", 0, "Seed of the {{ $uniqueInt }} values, the same seed gives the same values, 0 means a random seed")
+	flag.BoolVar(&cfg.FailUnusedMocks, "fail-unused-mocks", false, "Fail the tests whose mocks are never called, except the mocks with optional: true")
+	flag.StringVar(&cfg.BaseDir, "base-dir", os.Getenv("GONKEY_BASE_DIR"), "Directory for the relative paths of the files referenced by the tests (GONKEY_BASE_DIR by default), by default they are relative to the test file")
+	flag.StringVar(&cfg.CacheDir, "cache-dir", os.Getenv(yaml_file.CacheDirEnv), "Directory of the cache of the parsed test files (GONKEY_CACHE_DIR by default), the unchanged files are not parsed again")
+	flag.DurationVar(&cfg.WaitTimeout, "wait-timeout", 0, "Wait for the DB and the -wait-for addresses to respond before running the tests, e.g. 1m (30s if only -wait-for is set)")
+	flag.StringVar(&cfg.WaitFor, "wait-for", "", "Comma-separated TCP addresses of the dependencies to wait for, e.g. localhost:5672,localhost:8081")
+	flag.BoolVar(&cfg.NoKeepAlives, "disable-keep-alives", false, "Open a new connection for every request instead of reusing the connections")
+	flag.IntVar(&cfg.MaxIdleConns, "max-idle-conns", 0, "Maximum number of idle connections kept for reuse to each host (2 by default)")
+	flag.BoolVar(&cfg.HTTP2, "http2", false, "Negotiate HTTP/2 with the TLS servers supporting it, HTTP/1.1 is used otherwise")
+	flag.StringVar(&cfg.MetricsURL, "metrics-url", "", "URL of the Prometheus metrics of the service for metricsDelta of the tests, e.g. http://localhost:8080/metrics")
+	flag.StringVar(&cfg.Snapshots, "snapshots", "", "Directory of the snapshots of the responses, the responses which differ from the snapshots of the previous run fail the tests")
+	flag.Var(&cfg.SnapshotIgnore, "snapshot-ignore-path", "Path of the volatile field of the responses not compared with the snapshots, e.g. $.items[*].createdAt, can be repeated")
+	flag.BoolVar(&cfg.Update, "update", false, "Rewrite the golden files and the snapshots which differ, the same as GONKEY_UPDATE_GOLDEN")
+	flag.Var(&cfg.Redact, "redact", "Rule redacting the personal data in the outputs, a JSON path of the bodies (e.g. $.customer.email) or a regular expression, can be repeated")
+	flag.Float64Var(&cfg.RateLimit, "rate-limit", 0, "Max number of requests per second sent to the tested service, 0 means no limit")
+	flag.DurationVar(&cfg.RateLimitJitter, "rate-limit-jitter", 0, "Random delay up to the duration added to the requests waiting for the rate limit, e.g. 20ms")
+	flag.IntVar(&cfg.SlowestTests, "slowest", 0, "Show the N slowest tests after the summary, the time of the fixtures is shown separately")
+	flag.BoolVar(&cfg.DryRun, "dry-run", false, "Validate tests, fixtures and mocks without sending requests and touching the DB")
 	flag.StringVar(
 		&cfg.DbType,
 		"db-type",
 		fixtures.PostgresParam,
-		"Type of database (options: postgres, mysql, aerospike, redis)",
+		"Type of database (options: postgres, mysql, clickhouse, cassandra, aerospike, redis)",
 	)
 
 	flag.Parse()
 	return cfg
 }
 
+// stringsFlag collects the values of the repeated flag
+type stringsFlag []string
+
+func (f *stringsFlag) String() string {
+	return strings.Join(*f, ", ")
+}
+
+func (f *stringsFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// sharedFixturesLocations splits the comma-separated directories of the shared fixtures
+func sharedFixturesLocations(value string) []string {
+	if value == "" {
+		return nil
+	}
+	var locations []string
+	for _, location := range strings.Split(value, ",") {
+		if location = strings.TrimSpace(location); location != "" {
+			locations = append(locations, location)
+		}
+	}
+	return locations
+}
+
+func parseCassandraHosts(dsn string) (hosts []string, keyspace string) {
+	parts := strings.Split(dsn, "/")
+	if len(parts) != 2 || parts[0] == "" {
+		log.Fatalf("couldn't parse cassandra hosts %v, should be in form of host:port,host:port/keyspace", dsn)
+	}
+	return strings.Split(parts[0], ","), parts[1]
+}
+
 func parseAerospikeHost(dsn string) (address string, port int, namespace string) {
 	parts := strings.Split(dsn, "/")
 	if len(parts) != 2 {
@@ -0,0 +1,58 @@
+package mocks
+
+import (
+	"fmt"
+	"sync"
+)
+
+// callsCounter counts the requests served by each service mock during the test,
+// it is shared by all service mocks and reset with their running context
+type callsCounter struct {
+	sync.Mutex
+	calls map[string]int
+}
+
+func newCallsCounter() *callsCounter {
+	return &callsCounter{calls: make(map[string]int)}
+}
+
+func (c *callsCounter) add(serviceName string) {
+	if c == nil {
+		return
+	}
+	c.Lock()
+	defer c.Unlock()
+	c.calls[serviceName]++
+}
+
+func (c *callsCounter) get(serviceName string) int {
+	c.Lock()
+	defer c.Unlock()
+	return c.calls[serviceName]
+}
+
+func (c *callsCounter) reset() {
+	c.Lock()
+	defer c.Unlock()
+	c.calls = make(map[string]int)
+}
+
+// activation makes a definition serve the requests only after the service mock
+// has been called the given number of times during the test
+type activation struct {
+	service string
+	calls   int
+	counter *callsCounter
+}
+
+func (a *activation) check() error {
+	if actual := a.counter.get(a.service); actual < a.calls {
+		return fmt.Errorf(
+			"mock is not active: expected at least %d calls of %s before, actual %d",
+			a.calls,
+			a.service,
+			actual,
+		)
+	}
+	return nil
+}
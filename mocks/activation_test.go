@@ -0,0 +1,112 @@
+package mocks
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+func loadTestMocks(t *testing.T, definition string) *Mocks {
+	var raw map[string]interface{}
+	require.NoError(t, yaml.Unmarshal([]byte(definition), &raw))
+
+	m := NewNop("orders", "payments")
+	require.NoError(t, NewLoader(m).Load(raw))
+	m.ResetRunningContext()
+	return m
+}
+
+func callMock(m *Mocks, serviceName string) string {
+	w := httptest.NewRecorder()
+	m.Service(serviceName).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/status", nil))
+	return w.Body.String()
+}
+
+func TestActiveAfterAnotherService(t *testing.T) {
+	m := loadTestMocks(t, `
+orders:
+  strategy: basedOnRequest
+  uris:
+    - strategy: constant
+      body: paid
+      activeAfter:
+        service: payments
+    - strategy: constant
+      body: pending
+payments:
+  strategy: constant
+  body: ok
+`)
+
+	assert.Equal(t, "pending", callMock(m, "orders"))
+	assert.Equal(t, "pending", callMock(m, "orders"))
+	assert.Equal(t, "ok", callMock(m, "payments"))
+	assert.Equal(t, "paid", callMock(m, "orders"))
+	assert.Empty(t, m.EndRunningContext())
+
+	// the state is reset for the next test
+	m.ResetRunningContext()
+	assert.Equal(t, "pending", callMock(m, "orders"))
+}
+
+func TestActiveAfterCallsThreshold(t *testing.T) {
+	m := loadTestMocks(t, `
+orders:
+  strategy: basedOnRequest
+  uris:
+    - strategy: constant
+      body: ready
+      activeAfter:
+        service: orders
+        calls: 2
+    - strategy: constant
+      body: processing
+`)
+
+	assert.Equal(t, "processing", callMock(m, "orders"))
+	assert.Equal(t, "processing", callMock(m, "orders"))
+	assert.Equal(t, "ready", callMock(m, "orders"))
+	assert.Equal(t, "ready", callMock(m, "orders"))
+	assert.Empty(t, m.EndRunningContext())
+}
+
+func TestInactiveDefinition(t *testing.T) {
+	m := loadTestMocks(t, `
+orders:
+  strategy: constant
+  body: paid
+  activeAfter:
+    service: payments
+`)
+
+	assert.Empty(t, callMock(m, "orders"))
+
+	errs := m.EndRunningContext()
+	require.Len(t, errs, 2)
+	assert.Contains(t, errs[0].Error(), "at path $: mock is not active: expected at least 1 calls of payments before, actual 0")
+	assert.Contains(t, errs[1].Error(), "unhandled request to mock")
+}
+
+func TestActiveAfterValidation(t *testing.T) {
+	tests := map[string]string{
+		"not a map":       "{strategy: nop, activeAfter: payments}",
+		"without service": "{strategy: nop, activeAfter: {calls: 1}}",
+		"unknown service": "{strategy: nop, activeAfter: {service: billing}}",
+		"invalid calls":   "{strategy: nop, activeAfter: {service: payments, calls: 0}}",
+		"unexpected key":  "{strategy: nop, activeAfter: {service: payments, after: 1}}",
+	}
+
+	for name, definition := range tests {
+		t.Run(name, func(t *testing.T) {
+			var raw interface{}
+			require.NoError(t, yaml.Unmarshal([]byte(definition), &raw))
+
+			_, err := NewLoader(NewNop("payments")).loadDefinition("$", raw)
+			assert.Error(t, err)
+		})
+	}
+}
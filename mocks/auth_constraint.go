@@ -0,0 +1,244 @@
+package mocks
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/lamoda/gonkey/compare"
+)
+
+// authorizationConstraint checks the scheme and the credentials of the Authorization header,
+// the claims of the JWT sent as the credentials and, if the key is set, its signature
+type authorizationConstraint struct {
+	scheme string
+	token  string
+	claims map[string]string
+	// key is the HMAC secret ([]byte), *rsa.PublicKey or *ecdsa.PublicKey
+	key interface{}
+}
+
+func newAuthorizationConstraint(scheme, token string, claims map[string]string, key string) (verifier, error) {
+	if scheme == "" {
+		scheme = "Bearer"
+	}
+	c := &authorizationConstraint{scheme: scheme, token: token, claims: claims}
+	if key != "" {
+		var err error
+		if c.key, err = parseVerificationKey(key); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func (c *authorizationConstraint) Verify(r *http.Request) []error {
+	value := r.Header.Get("Authorization")
+	if value == "" {
+		return []error{errors.New("request doesn't have header Authorization")}
+	}
+	scheme, credentials := value, ""
+	if i := strings.IndexByte(value, ' '); i >= 0 {
+		scheme, credentials = value[:i], strings.TrimSpace(value[i+1:])
+	}
+	if !strings.EqualFold(scheme, c.scheme) {
+		return []error{fmt.Errorf("Authorization scheme %s doesn't match expected %s", scheme, c.scheme)}
+	}
+	if c.token != "" && len(compare.Compare(c.token, credentials, compare.CompareParams{})) != 0 {
+		return []error{fmt.Errorf("Authorization credentials %s don't match expected %s", credentials, c.token)}
+	}
+	if len(c.claims) == 0 && c.key == nil {
+		return nil
+	}
+
+	token, err := parseJWT(credentials)
+	if err != nil {
+		return []error{fmt.Errorf("Authorization credentials are not a JWT: %s", err)}
+	}
+	var errs []error
+	if c.key != nil {
+		if err := token.verify(c.key); err != nil {
+			errs = append(errs, fmt.Errorf("JWT signature is not valid: %s", err))
+		}
+	}
+
+	// the claims are sorted for the errors to be reported in the same order
+	names := make([]string, 0, len(c.claims))
+	for name := range c.claims {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		actual, ok := token.claims[name]
+		if !ok {
+			errs = append(errs, fmt.Errorf("JWT doesn't have claim %s", name))
+			continue
+		}
+		if !claimMatches(c.claims[name], actual) {
+			errs = append(errs, fmt.Errorf("JWT claim %s %s doesn't match expected %s", name, claimString(actual), c.claims[name]))
+		}
+	}
+	return errs
+}
+
+// claimMatches compares the claim with the expected value, the matchers can be used in it.
+// The claims which are arrays (e.g. aud) match if one of the items matches.
+func claimMatches(expected string, actual interface{}) bool {
+	if items, ok := actual.([]interface{}); ok {
+		for _, item := range items {
+			if claimMatches(expected, item) {
+				return true
+			}
+		}
+		return false
+	}
+	return len(compare.Compare(expected, claimString(actual), compare.CompareParams{})) == 0
+}
+
+func claimString(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+type jwt struct {
+	alg       string
+	claims    map[string]interface{}
+	signed    string
+	signature []byte
+}
+
+func parseJWT(token string) (*jwt, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("expected three parts separated by dots")
+	}
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeJWTPart(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("invalid header: %s", err)
+	}
+	var claims map[string]interface{}
+	if err := decodeJWTPart(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("invalid claims: %s", err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature: %s", err)
+	}
+	return &jwt{alg: header.Alg, claims: claims, signed: parts[0] + "." + parts[1], signature: signature}, nil
+}
+
+func decodeJWTPart(part string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+var jwtHashes = map[string]crypto.Hash{
+	"256": crypto.SHA256,
+	"384": crypto.SHA384,
+	"512": crypto.SHA512,
+}
+
+// verify verifies the signature of the token with the key, the HS, RS, PS and ES algorithms are supported
+func (t *jwt) verify(key interface{}) error {
+	hash, ok := jwtHashes[strings.TrimLeft(t.alg, "HSRPE")]
+	if !ok || len(t.alg) != 5 {
+		return fmt.Errorf("algorithm %s is not supported", t.alg)
+	}
+	h := hash.New()
+	h.Write([]byte(t.signed))
+	digest := h.Sum(nil)
+
+	switch k := key.(type) {
+	case []byte:
+		if t.alg[:2] != "HS" {
+			break
+		}
+		mac := hmac.New(hash.New, k)
+		mac.Write([]byte(t.signed))
+		if !hmac.Equal(mac.Sum(nil), t.signature) {
+			return errors.New("signature doesn't match the secret")
+		}
+		return nil
+	case *rsa.PublicKey:
+		switch t.alg[:2] {
+		case "RS":
+			return rsa.VerifyPKCS1v15(k, hash, digest, t.signature)
+		case "PS":
+			return rsa.VerifyPSS(k, hash, digest, t.signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		}
+	case *ecdsa.PublicKey:
+		if t.alg[:2] != "ES" {
+			break
+		}
+		size := (k.Curve.Params().BitSize + 7) / 8
+		if len(t.signature) != 2*size {
+			return errors.New("signature has invalid length")
+		}
+		r := new(big.Int).SetBytes(t.signature[:size])
+		s := new(big.Int).SetBytes(t.signature[size:])
+		if !ecdsa.Verify(k, digest, r, s) {
+			return errors.New("signature doesn't match the public key")
+		}
+		return nil
+	}
+	return fmt.Errorf("algorithm %s can't be verified with the key", t.alg)
+}
+
+// parseVerificationKey parses the public key or the certificate in PEM, the other keys are the HMAC secrets
+func parseVerificationKey(key string) (interface{}, error) {
+	if !strings.HasPrefix(strings.TrimSpace(key), "-----BEGIN") {
+		return []byte(key), nil
+	}
+	block, _ := pem.Decode([]byte(strings.TrimSpace(key)))
+	if block == nil {
+		return nil, errors.New("`key` is not a valid PEM")
+	}
+
+	var publicKey interface{}
+	var err error
+	switch block.Type {
+	case "PUBLIC KEY":
+		publicKey, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		publicKey, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	case "CERTIFICATE":
+		var cert *x509.Certificate
+		if cert, err = x509.ParseCertificate(block.Bytes); err == nil {
+			publicKey = cert.PublicKey
+		}
+	default:
+		return nil, fmt.Errorf("`key` has unsupported PEM block %s, expected a public key or a certificate", block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid `key`: %s", err)
+	}
+
+	switch publicKey.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey:
+		return publicKey, nil
+	default:
+		return nil, errors.New("`key` must be an RSA or ECDSA public key")
+	}
+}
@@ -0,0 +1,125 @@
+package mocks
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/antchfx/xmlquery"
+	"github.com/antchfx/xpath"
+	"github.com/tidwall/gjson"
+
+	"github.com/lamoda/gonkey/compare"
+)
+
+var jsonPathIndexRx = regexp.MustCompile(`\[(\d+)\]`)
+
+// bodyJSONPathConstraint checks the value at the JSONPath of the JSON body, or that the path exists
+// if no value is expected
+type bodyJSONPathConstraint struct {
+	path          string
+	gjsonPath     string
+	expected      interface{}
+	compareParams compare.CompareParams
+}
+
+func newBodyJSONPathConstraint(path string, expected *string, params compare.CompareParams) (verifier, error) {
+	c := &bodyJSONPathConstraint{path: path, gjsonPath: toGJSONPath(path), compareParams: params}
+	if expected != nil {
+		if err := json.Unmarshal([]byte(*expected), &c.expected); err != nil {
+			return nil, fmt.Errorf("`value` of JSONPath %s is not JSON: %s", path, err)
+		}
+	}
+	return c, nil
+}
+
+func (c *bodyJSONPathConstraint) Verify(r *http.Request) []error {
+	body, err := readRequestBody(r)
+	if err != nil {
+		return []error{err}
+	}
+	if !gjson.ValidBytes(body) {
+		return []error{errors.New("request body is not JSON")}
+	}
+
+	value := gjson.ParseBytes(body)
+	if c.gjsonPath != "" {
+		value = value.Get(c.gjsonPath)
+	}
+	if !value.Exists() {
+		return []error{fmt.Errorf("JSONPath %s doesn't exist in the request body", c.path)}
+	}
+	if c.expected == nil {
+		return nil
+	}
+
+	var errs []error
+	for _, e := range compare.Compare(c.expected, value.Value(), c.compareParams) {
+		errs = append(errs, fmt.Errorf("JSONPath %s: %s", c.path, e))
+	}
+	return errs
+}
+
+// toGJSONPath converts JSONPath ($.items[0].id) to the path of gjson (items.0.id) as responseJSONPath does,
+// the paths of gjson are accepted as is
+func toGJSONPath(path string) string {
+	path = strings.TrimPrefix(path, "$")
+	path = jsonPathIndexRx.ReplaceAllString(path, ".$1")
+	return strings.TrimPrefix(path, ".")
+}
+
+// bodyXPathConstraint checks the text of the first node selected by the XPath in the XML body,
+// or that the XPath selects a node if no value is expected
+type bodyXPathConstraint struct {
+	xpath    string
+	expr     *xpath.Expr
+	expected *string
+}
+
+func newBodyXPathConstraint(expr string, expected *string) (verifier, error) {
+	compiled, err := xpath.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid XPath %s: %s", expr, err)
+	}
+	return &bodyXPathConstraint{xpath: expr, expr: compiled, expected: expected}, nil
+}
+
+func (c *bodyXPathConstraint) Verify(r *http.Request) []error {
+	body, err := readRequestBody(r)
+	if err != nil {
+		return []error{err}
+	}
+	doc, err := xmlquery.Parse(bytes.NewReader(body))
+	if err != nil {
+		return []error{fmt.Errorf("request body is not XML: %s", err)}
+	}
+
+	node := xmlquery.QuerySelector(doc, c.expr)
+	if node == nil {
+		return []error{fmt.Errorf("XPath %s doesn't select any node of the request body", c.xpath)}
+	}
+	if c.expected == nil {
+		return nil
+	}
+
+	actual := node.InnerText()
+	if len(compare.Compare(*c.expected, actual, compare.CompareParams{})) != 0 {
+		return []error{fmt.Errorf("XPath %s value %q doesn't match expected %q", c.xpath, actual, *c.expected)}
+	}
+	return nil
+}
+
+// readRequestBody reads the body and puts it back for the other constraints and the reply strategy
+func readRequestBody(r *http.Request) ([]byte, error) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
@@ -0,0 +1,90 @@
+package mocks
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+func requestWithBody(body string) *http.Request {
+	r, _ := http.NewRequest(http.MethodPost, "http://localhost/orders", strings.NewReader(body))
+	return r
+}
+
+func TestBodyJSONPathMatches(t *testing.T) {
+	body := `{"order": {"id": 7, "items": [{"sku": "A-1", "qty": 2}], "comment": "call me"}, "extra": true}`
+
+	for _, definition := range []string{
+		`{kind: bodyJSONPathMatches, path: $.order.id, value: "7"}`,
+		`{kind: bodyJSONPathMatches, path: "$.order.items[0]", value: '{"sku": "A-1"}'}`,
+		`{kind: bodyJSONPathMatches, path: $.order.comment, value: '"$matchRegexp(^call)"'}`,
+		`{kind: bodyJSONPathMatches, path: $.order.comment}`,
+		`{kind: bodyJSONPathMatches, path: order.items.#, value: "1"}`,
+	} {
+		r := requestWithBody(body)
+		assert.Empty(t, loadTestConstraint(t, definition).Verify(r), definition)
+	}
+
+	r := requestWithBody(body)
+	c := loadTestConstraint(t, `{kind: bodyJSONPathMatches, path: "$.order.items[0]", value: '{"sku": "A-1"}', comparisonParams: {disallowExtraFields: true}}`)
+	assert.NotEmpty(t, c.Verify(r))
+
+	// the body can be read again
+	data, err := readRequestBody(r)
+	require.NoError(t, err)
+	assert.Equal(t, body, string(data))
+}
+
+func TestBodyJSONPathMismatches(t *testing.T) {
+	body := `{"order": {"id": 7}}`
+	for definition, expected := range map[string]string{
+		`{kind: bodyJSONPathMatches, path: $.order.id, value: "8"}`: "JSONPath $.order.id: at path $ values do not match:\n" +
+			"     expected: 8\n       actual: 7",
+		`{kind: bodyJSONPathMatches, path: $.order.status}`: "JSONPath $.order.status doesn't exist in the request body",
+	} {
+		assert.Equal(t, []string{expected}, errorStrings(loadTestConstraint(t, definition).Verify(requestWithBody(body))))
+	}
+
+	c := loadTestConstraint(t, `{kind: bodyJSONPathMatches, path: $.order.id}`)
+	assert.Equal(t, []string{"request body is not JSON"}, errorStrings(c.Verify(requestWithBody("<order/>"))))
+}
+
+func TestBodyXPathMatches(t *testing.T) {
+	body := `<order id="7"><items><item sku="A-1">2</item><item sku="B-2">1</item></items><comment>call me</comment></order>`
+
+	for _, definition := range []string{
+		`{kind: bodyXPathMatches, xpath: /order/@id, value: "7"}`,
+		`{kind: bodyXPathMatches, xpath: "//item[@sku='B-2']", value: "1"}`,
+		`{kind: bodyXPathMatches, xpath: //comment, value: "$matchRegexp(^call)"}`,
+		`{kind: bodyXPathMatches, xpath: //comment}`,
+	} {
+		assert.Empty(t, loadTestConstraint(t, definition).Verify(requestWithBody(body)), definition)
+	}
+
+	for definition, expected := range map[string]string{
+		`{kind: bodyXPathMatches, xpath: //item/@sku, value: "B-2"}`: `XPath //item/@sku value "A-1" doesn't match expected "B-2"`,
+		`{kind: bodyXPathMatches, xpath: //status}`:                  "XPath //status doesn't select any node of the request body",
+	} {
+		assert.Equal(t, []string{expected}, errorStrings(loadTestConstraint(t, definition).Verify(requestWithBody(body))))
+	}
+}
+
+func TestBodyPathConstraintsDefinitionErrors(t *testing.T) {
+	for definition, expected := range map[string]string{
+		`{kind: bodyJSONPathMatches}`:                          "`bodyJSONPathMatches` requires string `path` key",
+		`{kind: bodyJSONPathMatches, path: $.id, value: 7}`:    "`value` must be string",
+		`{kind: bodyJSONPathMatches, path: $.id, value: "{"}`:  "`value` of JSONPath $.id is not JSON: unexpected end of JSON input",
+		`{kind: bodyXPathMatches, path: //id}`:                 "`bodyXPathMatches` requires string `xpath` key",
+		`{kind: bodyXPathMatches, xpath: "//item["}`:           "invalid XPath //item[: expression must evaluate to a node-set",
+		`{kind: bodyXPathMatches, xpath: //id, regexp: "\\d"}`: "unexpected key regexp (expecting [kind xpath value])",
+	} {
+		var def map[interface{}]interface{}
+		require.NoError(t, yaml.Unmarshal([]byte(definition), &def))
+		_, err := NewLoader(nil).loadConstraint(def)
+		assert.EqualError(t, err, expected, definition)
+	}
+}
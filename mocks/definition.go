@@ -11,11 +11,15 @@ const CallsNoConstraint = -1
 
 type Definition struct {
 	path               string
+	method             *methodConstraint
+	activeAfter        *activation
 	requestConstraints []verifier
 	replyStrategy      ReplyStrategy
 	sync.Mutex
 	calls           int
 	callsConstraint int
+	// optional is set if the service mock isn't required to be called, see Mocks.SetFailOnUnused
+	optional bool
 }
 
 func NewDefinition(path string, constraints []verifier, strategy ReplyStrategy, callsConstraint int) *Definition {
@@ -27,7 +31,33 @@ func NewDefinition(path string, constraints []verifier, strategy ReplyStrategy,
 	}
 }
 
+// verifyMethod checks the `method` of the definition, requests with other methods are not served by it
+func (d *Definition) verifyMethod(r *http.Request) []error {
+	if d.method == nil {
+		return nil
+	}
+	return verifyRequestConstraints([]verifier{d.method}, r)
+}
+
+// verifyActivation checks the `activeAfter` of the definition, it doesn't serve requests until activated
+func (d *Definition) verifyActivation() []error {
+	if d.activeAfter == nil {
+		return nil
+	}
+	if err := d.activeAfter.check(); err != nil {
+		return []error{fmt.Errorf("at path %s: %w", d.path, err)}
+	}
+	return nil
+}
+
 func (d *Definition) Execute(w http.ResponseWriter, r *http.Request) []error {
+	if errs := d.verifyMethod(r); errs != nil {
+		return append(errs, unhandledRequestError(r)...)
+	}
+	if errs := d.verifyActivation(); errs != nil {
+		return append(errs, unhandledRequestError(r)...)
+	}
+
 	d.Lock()
 	d.calls++
 	d.Unlock()
@@ -64,6 +94,17 @@ func (d *Definition) ResetRunningContext() {
 	d.Unlock()
 }
 
+// ResetCalls resets the number of the calls of the definition and the nested ones, e.g. before another attempt
+// of the test, the state of the strategies like the position of the sequence is kept
+func (d *Definition) ResetCalls() {
+	if s, ok := d.replyStrategy.(contextAwareStrategy); ok {
+		s.ResetCalls()
+	}
+	d.Lock()
+	d.calls = 0
+	d.Unlock()
+}
+
 func (d *Definition) EndRunningContext() []error {
 	d.Lock()
 	defer d.Unlock()
@@ -0,0 +1,121 @@
+package mocks
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+func loadTestDefinition(t *testing.T, definition string) *Definition {
+	var raw interface{}
+	require.NoError(t, yaml.Unmarshal([]byte(definition), &raw))
+
+	def, err := NewLoader(NewNop()).loadDefinition("$", raw)
+	require.NoError(t, err)
+	return def
+}
+
+func TestDefinitionMethodFallsThrough(t *testing.T) {
+	def := loadTestDefinition(t, `
+strategy: basedOnRequest
+uris:
+  - method: GET
+    strategy: constant
+    body: "read"
+    requestConstraints:
+      - kind: pathMatches
+        path: /orders
+  - method: POST
+    strategy: constant
+    body: "created"
+    requestConstraints:
+      - kind: pathMatches
+        path: /orders
+`)
+
+	for method, body := range map[string]string{"GET": "read", "POST": "created"} {
+		w := httptest.NewRecorder()
+		errs := def.Execute(w, httptest.NewRequest(method, "/orders", nil))
+		assert.Empty(t, errs)
+
+		resp, _ := ioutil.ReadAll(w.Result().Body)
+		assert.Equal(t, body, string(resp))
+	}
+}
+
+func TestDefinitionMethodMismatch(t *testing.T) {
+	def := loadTestDefinition(t, `
+method: GET
+strategy: constant
+body: "read"
+calls: 0
+`)
+
+	w := httptest.NewRecorder()
+	errs := def.Execute(w, httptest.NewRequest(http.MethodDelete, "/orders", nil))
+
+	require.Len(t, errs, 2)
+	assert.Contains(t, errs[0].Error(), "method does not match: expected GET, actual DELETE")
+	assert.True(t, strings.HasPrefix(errs[1].Error(), "unhandled request to mock"))
+	assert.Empty(t, w.Body.String())
+	assert.Empty(t, def.EndRunningContext())
+}
+
+func TestDefinitionMethodMustBeString(t *testing.T) {
+	var raw interface{}
+	require.NoError(t, yaml.Unmarshal([]byte("{method: [GET], strategy: nop}"), &raw))
+
+	_, err := NewLoader(NewNop()).loadDefinition("$", raw)
+	assert.Error(t, err)
+}
+
+func TestFileStrategyWithBaseDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gonkey-mocks")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "books.json"), []byte(`["alice"]`), 0644))
+
+	var raw interface{}
+	require.NoError(t, yaml.Unmarshal([]byte("{strategy: file, filename: books.json}"), &raw))
+
+	loader := NewLoader(NewNop())
+	_, err = loader.loadDefinition("$", raw)
+	assert.Error(t, err, "the file is relative to the working directory without the base dir")
+
+	def, err := loader.WithBaseDir(dir).loadDefinition("$", raw)
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+	def.Execute(w, httptest.NewRequest(http.MethodGet, "/books", nil))
+	assert.Equal(t, `["alice"]`, w.Body.String())
+}
+
+func TestDefinitionResetCallsKeepsSequence(t *testing.T) {
+	def := loadTestDefinition(t, `
+strategy: sequence
+sequence:
+  - strategy: constant
+    body: "1"
+  - strategy: constant
+    body: "2"
+    calls: 1
+calls: 1
+`)
+
+	w := httptest.NewRecorder()
+	assert.Empty(t, def.Execute(w, httptest.NewRequest(http.MethodGet, "/", nil)))
+	def.ResetCalls()
+
+	// the next item of the sequence replies, the calls are counted since the reset
+	w = httptest.NewRecorder()
+	assert.Empty(t, def.Execute(w, httptest.NewRequest(http.MethodGet, "/", nil)))
+	assert.Equal(t, "2", w.Body.String())
+	assert.Empty(t, def.EndRunningContext())
+}
@@ -0,0 +1,59 @@
+package mocks
+
+import (
+	"errors"
+	"sort"
+)
+
+// SetEnvironment sets the environment the mocks are loaded for, the definitions of the service mocks
+// with `environments` not listing it are skipped and the requests go to the real dependencies.
+// All the mocks are loaded if the environment isn't set.
+func (l *Loader) SetEnvironment(env string) {
+	l.env = env
+}
+
+// Active returns the sorted names of the service mocks of the definition loaded in the environment
+func (l *Loader) Active(mocksDefinition map[string]interface{}) []string {
+	names := make([]string, 0, len(mocksDefinition))
+	for serviceName, definition := range mocksDefinition {
+		if enabled, _, err := l.enabled(definition); err == nil && enabled {
+			names = append(names, serviceName)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// enabled tells if the definition of the service mock is loaded in the environment
+// and returns the definition without `environments`
+func (l *Loader) enabled(rawDef interface{}) (bool, interface{}, error) {
+	def, ok := rawDef.(map[interface{}]interface{})
+	if !ok {
+		return true, rawDef, nil
+	}
+	rawEnvs, ok := def["environments"]
+	if !ok {
+		return true, rawDef, nil
+	}
+
+	envs, ok := rawEnvs.([]interface{})
+	if !ok || len(envs) == 0 {
+		return false, nil, errors.New("`environments` requires array of names")
+	}
+	enabled := l.env == ""
+	for _, e := range envs {
+		name, ok := e.(string)
+		if !ok || name == "" {
+			return false, nil, errors.New("`environments` requires array of names")
+		}
+		enabled = enabled || name == l.env
+	}
+
+	stripped := make(map[interface{}]interface{}, len(def)-1)
+	for k, v := range def {
+		if k != "environments" {
+			stripped[k] = v
+		}
+	}
+	return enabled, stripped, nil
+}
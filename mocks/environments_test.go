@@ -0,0 +1,57 @@
+package mocks
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+const environmentsDefinition = `
+orders:
+  strategy: constant
+  body: mocked
+  environments: [local, ci]
+payments:
+  strategy: constant
+  body: ok
+`
+
+func TestEnvironments(t *testing.T) {
+	var raw map[string]interface{}
+	require.NoError(t, yaml.Unmarshal([]byte(environmentsDefinition), &raw))
+
+	for env, active := range map[string][]string{
+		"":        {"orders", "payments"},
+		"ci":      {"orders", "payments"},
+		"staging": {"payments"},
+	} {
+		m := NewNop("orders", "payments")
+		loader := NewLoader(m)
+		loader.SetEnvironment(env)
+		require.NoError(t, loader.Load(raw), env)
+		m.ResetRunningContext()
+
+		assert.Equal(t, active, loader.Active(raw), env)
+		assert.Equal(t, "ok", callMock(m, "payments"), env)
+		// the disabled mock keeps its default definition, the service calls the real dependency
+		if len(active) == 2 {
+			assert.Equal(t, "mocked", callMock(m, "orders"), env)
+		}
+		assert.Empty(t, m.EndRunningContext(), env)
+	}
+}
+
+func TestEnvironmentsInvalid(t *testing.T) {
+	var raw map[string]interface{}
+	require.NoError(t, yaml.Unmarshal([]byte(`
+orders:
+  strategy: nop
+  environments: local
+`), &raw))
+
+	err := NewLoader(NewNop("orders")).Validate(raw)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "`environments` requires array of names")
+}
@@ -4,33 +4,78 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
 
 	"github.com/lamoda/gonkey/compare"
+	"github.com/lamoda/gonkey/files"
 )
 
 type Loader struct {
 	mocks *Mocks
+	// baseDir is the directory the relative file names of the definitions are resolved against
+	baseDir string
+	// env is the environment the definitions are loaded for, see SetEnvironment
+	env string
+	// fs is the filesystem the files of the file strategy are read from
+	fs files.FS
 }
 
 func NewLoader(mocks *Mocks) *Loader {
 	return &Loader{
 		mocks: mocks,
+		fs:    files.OS,
 	}
 }
 
+// SetFS sets the filesystem the files of the file strategy are read from, the OS filesystem by default
+func (l *Loader) SetFS(fsys files.FS) {
+	l.fs = fsys
+}
+
+// WithBaseDir returns the loader resolving the relative file names of the definitions against the directory,
+// e.g. the directory of the test file
+func (l *Loader) WithBaseDir(dir string) *Loader {
+	loader := *l
+	loader.baseDir = dir
+	return &loader
+}
+
 func (l *Loader) Load(mocksDefinition map[string]interface{}) error {
+	return l.load(mocksDefinition, true)
+}
+
+// Validate checks mocks definitions without loading them into the mocks
+func (l *Loader) Validate(mocksDefinition map[string]interface{}) error {
+	return l.load(mocksDefinition, false)
+}
+
+func (l *Loader) load(mocksDefinition map[string]interface{}, apply bool) error {
 	for serviceName, definition := range mocksDefinition {
 		service := l.mocks.Service(serviceName)
 		if service == nil {
 			return fmt.Errorf("service mock not defined: %s", serviceName)
 		}
+		enabled, definition, err := l.enabled(definition)
+		if err != nil {
+			return fmt.Errorf("unable to load Definition for %s: at path $: %v", serviceName, err)
+		}
+		if !enabled {
+			continue
+		}
+		isOptional, definition, err := optional(definition)
+		if err != nil {
+			return fmt.Errorf("unable to load Definition for %s: at path $: %v", serviceName, err)
+		}
 		def, err := l.loadDefinition("$", definition)
 		if err != nil {
 			return fmt.Errorf("unable to load Definition for %s: %v", serviceName, err)
 		}
+		def.optional = isOptional
 		// load the Definition into the mock
-		service.SetDefinition(def)
+		if apply {
+			service.SetDefinition(def)
+		}
 	}
 	return nil
 }
@@ -62,6 +107,28 @@ func (l *Loader) loadDefinition(path string, rawDef interface{}) (*Definition, e
 		"requestConstraints",
 		"strategy",
 		"calls",
+		"method",
+		"activeAfter",
+	}
+
+	// load method
+	var method *methodConstraint
+	if m, ok := def["method"]; ok {
+		methodName, ok := m.(string)
+		if !ok || methodName == "" {
+			return nil, fmt.Errorf("at path %s: `method` must be string", path)
+		}
+		method = &methodConstraint{method: methodName}
+	}
+
+	// load activation
+	var activeAfter *activation
+	if a, ok := def["activeAfter"]; ok {
+		var err error
+		activeAfter, err = l.loadActivation(a)
+		if err != nil {
+			return nil, fmt.Errorf("at path %s: %v", path, err)
+		}
 	}
 
 	// load reply strategy
@@ -89,7 +156,38 @@ func (l *Loader) loadDefinition(path string, rawDef interface{}) (*Definition, e
 		return nil, err
 	}
 
-	return NewDefinition(path, requestConstraints, replyStrategy, callsConstraint), nil
+	definition := NewDefinition(path, requestConstraints, replyStrategy, callsConstraint)
+	definition.method = method
+	definition.activeAfter = activeAfter
+	return definition, nil
+}
+
+func (l *Loader) loadActivation(definition interface{}) (*activation, error) {
+	def, ok := definition.(map[interface{}]interface{})
+	if !ok {
+		return nil, errors.New("`activeAfter` must be a map")
+	}
+	if err := validateMapKeys(def, "service", "calls"); err != nil {
+		return nil, err
+	}
+
+	service, ok := def["service"].(string)
+	if !ok || service == "" {
+		return nil, errors.New("`activeAfter` requires `service` string key")
+	}
+	if l.mocks.Service(service) == nil {
+		return nil, fmt.Errorf("`activeAfter` refers to undefined service mock: %s", service)
+	}
+
+	calls := 1
+	if c, ok := def["calls"]; ok {
+		calls, ok = c.(int)
+		if !ok || calls < 1 {
+			return nil, errors.New("`calls` of `activeAfter` must be a positive integer")
+		}
+	}
+
+	return &activation{service: service, calls: calls, counter: l.mocks.calls}, nil
 }
 
 func (l *Loader) loadStrategy(path, strategyName string, definition map[interface{}]interface{}, ak *[]string) (ReplyStrategy, error) {
@@ -119,6 +217,12 @@ func (l *Loader) loadStrategy(path, strategyName string, definition map[interfac
 		return l.loadBasedOnRequestStrategy(path, definition)
 	case "dropRequest":
 		return l.loadDropRequestStrategy(path, definition)
+	case "random":
+		*ak = append(*ak, "variants", "seed")
+		return l.loadRandomStrategy(path, definition)
+	case "spy":
+		*ak = append(*ak, "upstream")
+		return l.loadSpyStrategy(path, definition)
 	default:
 		return nil, fmt.Errorf("unknown strategy: %s", strategyName)
 	}
@@ -183,7 +287,12 @@ func (l *Loader) loadFileStrategy(path string, def map[interface{}]interface{})
 	if err != nil {
 		return nil, err
 	}
-	return NewFileReplyWithCode(filename, statusCode, headers)
+	filename = files.ResolvePath(l.fs, l.baseDir, filename)
+	content, err := l.fs.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return NewConstantReplyWithCode(content, statusCode, headers), nil
 }
 
 func (l *Loader) loadConstantStrategy(path string, def map[interface{}]interface{}) (ReplyStrategy, error) {
@@ -210,6 +319,18 @@ func (l *Loader) loadDropRequestStrategy(path string, def map[interface{}]interf
 	return NewDropRequestReply(), nil
 }
 
+func (l *Loader) loadSpyStrategy(path string, def map[interface{}]interface{}) (ReplyStrategy, error) {
+	value, ok := def["upstream"].(string)
+	if !ok || value == "" {
+		return nil, errors.New("`spy` requires `upstream` string key")
+	}
+	upstream, err := url.Parse(value)
+	if err != nil || upstream.Scheme == "" || upstream.Host == "" {
+		return nil, fmt.Errorf("`upstream` of `spy` must be an absolute URL, got %q", value)
+	}
+	return NewSpyReply(upstream), nil
+}
+
 func (l *Loader) loadTemplateStrategy(path string, def map[interface{}]interface{}) (ReplyStrategy, error) {
 	c, ok := def["body"]
 	if !ok {
@@ -272,6 +393,53 @@ func (l *Loader) loadBasedOnRequestStrategy(path string, def map[interface{}]int
 	return newBasedOnRequestReply(uris), nil
 }
 
+func (l *Loader) loadRandomStrategy(path string, def map[interface{}]interface{}) (ReplyStrategy, error) {
+	variantsList, ok := def["variants"].([]interface{})
+	if !ok || len(variantsList) == 0 {
+		return nil, errors.New("`random` requires list under `variants` key")
+	}
+
+	seed := l.mocks.randomSeed
+	if s, ok := def["seed"]; ok {
+		value, ok := s.(int)
+		if !ok {
+			return nil, errors.New("`seed` must be integer")
+		}
+		seed = int64(value)
+	}
+
+	variants := make([]*Definition, 0, len(variantsList))
+	weights := make([]int, 0, len(variantsList))
+	for i, v := range variantsList {
+		v, ok := v.(map[interface{}]interface{})
+		if !ok {
+			return nil, errors.New("`variants` list item must be a map")
+		}
+
+		// the weight belongs to the variant, not to its definition
+		weight := 1
+		variant := make(map[interface{}]interface{}, len(v))
+		for key, value := range v {
+			if key != "weight" {
+				variant[key] = value
+				continue
+			}
+			weight, ok = value.(int)
+			if !ok || weight < 1 {
+				return nil, errors.New("`weight` must be a positive integer")
+			}
+		}
+
+		def, err := l.loadDefinition(path+"."+strconv.Itoa(i), variant)
+		if err != nil {
+			return nil, err
+		}
+		variants = append(variants, def)
+		weights = append(weights, weight)
+	}
+	return newRandomReply(variants, weights, seed), nil
+}
+
 func (l *Loader) loadHeaders(def map[interface{}]interface{}) (map[string]string, error) {
 	var headers map[string]string
 	if h, ok := def["headers"]; ok {
@@ -344,6 +512,9 @@ func (l *Loader) loadConstraintOfKind(kind string, def map[interface{}]interface
 	case "headerIs":
 		*ak = append(*ak, "header", "value", "regexp")
 		return l.loadHeaderIsConstraint(def)
+	case "authorizationMatches":
+		*ak = append(*ak, "scheme", "token", "claims", "key", "keyFile")
+		return l.loadAuthorizationConstraint(def)
 	case "bodyMatchesText":
 		*ak = append(*ak, "body", "regexp")
 		return l.loadBodyMatchesTextConstraint(def)
@@ -353,6 +524,12 @@ func (l *Loader) loadConstraintOfKind(kind string, def map[interface{}]interface
 	case "bodyMatchesXML":
 		*ak = append(*ak, "body", "comparisonParams")
 		return l.loadBodyMatchesXMLConstraint(def)
+	case "bodyJSONPathMatches":
+		*ak = append(*ak, "path", "value", "comparisonParams")
+		return l.loadBodyJSONPathConstraint(def)
+	case "bodyXPathMatches":
+		*ak = append(*ak, "xpath", "value")
+		return l.loadBodyXPathConstraint(def)
 	default:
 		return nil, fmt.Errorf("unknown constraint: %s", kind)
 	}
@@ -461,6 +638,49 @@ func (l *Loader) loadBodyMatchesXMLConstraint(def map[interface{}]interface{}) (
 	return newBodyMatchesXMLConstraint(body, params)
 }
 
+func (l *Loader) loadBodyJSONPathConstraint(def map[interface{}]interface{}) (verifier, error) {
+	path, ok := def["path"].(string)
+	if !ok || path == "" {
+		return nil, errors.New("`bodyJSONPathMatches` requires string `path` key")
+	}
+	value, err := optionalString(def, "value")
+	if err != nil {
+		return nil, err
+	}
+
+	params, err := readCompareParams(def)
+	if err != nil {
+		return nil, err
+	}
+
+	return newBodyJSONPathConstraint(path, value, params)
+}
+
+func (l *Loader) loadBodyXPathConstraint(def map[interface{}]interface{}) (verifier, error) {
+	expr, ok := def["xpath"].(string)
+	if !ok || expr == "" {
+		return nil, errors.New("`bodyXPathMatches` requires string `xpath` key")
+	}
+	value, err := optionalString(def, "value")
+	if err != nil {
+		return nil, err
+	}
+	return newBodyXPathConstraint(expr, value)
+}
+
+// optionalString returns the string value of the key, or nil if the key is missing
+func optionalString(def map[interface{}]interface{}, key string) (*string, error) {
+	c, ok := def[key]
+	if !ok {
+		return nil, nil
+	}
+	value, ok := c.(string)
+	if !ok {
+		return nil, fmt.Errorf("`%s` must be string", key)
+	}
+	return &value, nil
+}
+
 func (l *Loader) loadPathMatchesConstraint(def map[interface{}]interface{}) (verifier, error) {
 	var pathStr, regexpStr string
 	if path, ok := def["path"]; ok {
@@ -539,6 +759,50 @@ func (l *Loader) loadHeaderIsConstraint(def map[interface{}]interface{}) (verifi
 	return newHeaderConstraint(header, valueStr, regexpStr)
 }
 
+func (l *Loader) loadAuthorizationConstraint(def map[interface{}]interface{}) (verifier, error) {
+	var scheme, token, key string
+	for name, value := range map[string]*string{"scheme": &scheme, "token": &token, "key": &key} {
+		if v, ok := def[name]; ok {
+			if *value, ok = v.(string); !ok {
+				return nil, fmt.Errorf("`%s` must be string", name)
+			}
+		}
+	}
+
+	var claims map[string]string
+	if v, ok := def["claims"]; ok {
+		values, ok := v.(map[interface{}]interface{})
+		if !ok {
+			return nil, errors.New("`claims` must be map")
+		}
+		claims = make(map[string]string, len(values))
+		for name, value := range values {
+			switch value.(type) {
+			case map[interface{}]interface{}, []interface{}, nil:
+				return nil, fmt.Errorf("claim `%v` must be scalar", name)
+			}
+			claims[fmt.Sprint(name)] = fmt.Sprint(value)
+		}
+	}
+
+	if v, ok := def["keyFile"]; ok {
+		if key != "" {
+			return nil, errors.New("`key` and `keyFile` can't be used together")
+		}
+		filename, ok := v.(string)
+		if !ok {
+			return nil, errors.New("`keyFile` must be string")
+		}
+		filename = files.ResolvePath(l.fs, l.baseDir, filename)
+		content, err := l.fs.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		key = string(content)
+	}
+	return newAuthorizationConstraint(scheme, token, claims, key)
+}
+
 func (l *Loader) loadBodyMatchesTextConstraint(def map[interface{}]interface{}) (verifier, error) {
 	var bodyStr, regexpStr string
 	if body, ok := def["body"]; ok {
@@ -0,0 +1,21 @@
+package mocks
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lamoda/gonkey/logging"
+)
+
+func TestLoggerReceivesCalls(t *testing.T) {
+	var buf bytes.Buffer
+	m := NewNop("orders")
+	m.SetLogger(logging.NewText(&buf))
+
+	m.ResetRunningContext()
+	callMock(m, "orders")
+
+	assert.Equal(t, "gonkey: mock called service=orders method=GET path=/status errors=1\n", buf.String())
+}
@@ -0,0 +1,61 @@
+package mocks
+
+import (
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/lamoda/gonkey/files"
+)
+
+// MissingFiles returns the files referenced by the mocks definitions which don't exist: the files
+// of the file strategy and the keyFile of authorizationMatches, the names with variables are not checked
+func (l *Loader) MissingFiles(mocksDefinition map[string]interface{}) []string {
+	// the services are walked in order for the files to be reported in the same order
+	services := make([]string, 0, len(mocksDefinition))
+	for service := range mocksDefinition {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+
+	var missing []string
+	for _, service := range services {
+		walkFiles(mocksDefinition[service], func(filename string) {
+			if strings.Contains(filename, "{{") {
+				return
+			}
+			filename = files.ResolvePath(l.fs, l.baseDir, filename)
+			if _, err := l.fs.Stat(filename); os.IsNotExist(err) {
+				missing = append(missing, filename)
+			}
+		})
+	}
+	return missing
+}
+
+// walkFiles calls f with the file names of the definition and of the nested ones
+func walkFiles(definition interface{}, f func(filename string)) {
+	switch def := definition.(type) {
+	case map[interface{}]interface{}:
+		if filename, ok := def["filename"].(string); ok && def["strategy"] == "file" {
+			f(filename)
+		}
+		if filename, ok := def["keyFile"].(string); ok && def["kind"] == "authorizationMatches" {
+			f(filename)
+		}
+		keys := make([]string, 0, len(def))
+		for key := range def {
+			if key, ok := key.(string); ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			walkFiles(def[key], f)
+		}
+	case []interface{}:
+		for _, item := range def {
+			walkFiles(item, f)
+		}
+	}
+}
@@ -5,32 +5,72 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
+
+	"github.com/lamoda/gonkey/logging"
+	"github.com/lamoda/gonkey/models"
 )
 
 type Mocks struct {
 	mocks map[string]*ServiceMock
+	calls *callsCounter
+	spied *spyRecorder
+	// randomSeed is the seed of the `random` strategies without their own seed
+	randomSeed int64
+	// failOnUnused is set if the service mocks defined in the test must be called, see SetFailOnUnused
+	failOnUnused bool
 }
 
 func New(mocks ...*ServiceMock) *Mocks {
+	calls := newCallsCounter()
+	spied := newSpyRecorder()
 	mocksMap := make(map[string]*ServiceMock, len(mocks))
 	for _, v := range mocks {
+		v.calls = calls
+		v.spied = spied
 		mocksMap[v.ServiceName] = v
 	}
 	return &Mocks{
-		mocks: mocksMap,
+		mocks:      mocksMap,
+		calls:      calls,
+		spied:      spied,
+		randomSeed: time.Now().UnixNano(),
 	}
 }
 
 func NewNop(serviceNames ...string) *Mocks {
+	calls := newCallsCounter()
+	spied := newSpyRecorder()
 	mocksMap := make(map[string]*ServiceMock, len(serviceNames))
 	for _, name := range serviceNames {
-		mocksMap[name] = NewServiceMock(name, NewDefinition("$", nil, &failReply{}, CallsNoConstraint))
+		mock := NewServiceMock(name, NewDefinition("$", nil, &failReply{}, CallsNoConstraint))
+		mock.calls = calls
+		mock.spied = spied
+		mocksMap[name] = mock
 	}
 	return &Mocks{
-		mocks: mocksMap,
+		mocks:      mocksMap,
+		calls:      calls,
+		spied:      spied,
+		randomSeed: time.Now().UnixNano(),
+	}
+}
+
+// SetLogger passes the calls of the service mocks to the logger, e.g. to see which mock received a request
+func (m *Mocks) SetLogger(logger logging.Logger) {
+	for _, v := range m.mocks {
+		v.Lock()
+		v.logger = logger
+		v.Unlock()
 	}
 }
 
+// SetRandomSeed sets the seed of the `random` strategies without their own seed,
+// by default it's chosen at random
+func (m *Mocks) SetRandomSeed(seed int64) {
+	m.randomSeed = seed
+}
+
 func (m *Mocks) ResetDefinitions() {
 	for _, v := range m.mocks {
 		v.ResetDefinition()
@@ -48,6 +88,23 @@ func (m *Mocks) Start() error {
 	return nil
 }
 
+// StartWithAddrs starts the mocks on the addresses by service name, e.g. when the tested service
+// is deployed and calls its dependencies at the fixed addresses
+func (m *Mocks) StartWithAddrs(addrs map[string]string) error {
+	for name, addr := range addrs {
+		mock := m.Service(name)
+		if mock == nil {
+			m.Shutdown()
+			return fmt.Errorf("service mock not defined: %s", name)
+		}
+		if err := mock.StartServerWithAddr(addr); err != nil {
+			m.Shutdown()
+			return err
+		}
+	}
+	return nil
+}
+
 // Stops immediately, with no gracefully closing connections
 func (m *Mocks) Shutdown() {
 	ctx, cancel := context.WithCancel(context.TODO())
@@ -69,6 +126,8 @@ func (m *Mocks) ShutdownContext(ctx context.Context) error {
 }
 
 func (m *Mocks) SetMock(mock *ServiceMock) {
+	mock.calls = m.calls
+	mock.spied = m.spied
 	m.mocks[mock.ServiceName] = mock
 }
 
@@ -78,15 +137,35 @@ func (m *Mocks) Service(serviceName string) *ServiceMock {
 }
 
 func (m *Mocks) ResetRunningContext() {
+	m.calls.reset()
+	m.spied.reset()
 	for _, v := range m.mocks {
 		v.ResetRunningContext()
 	}
 }
 
+// ResetCalls resets the numbers of the calls checked by the definitions with calls, so they are counted again,
+// e.g. for another attempt of the test
+func (m *Mocks) ResetCalls() {
+	for _, v := range m.mocks {
+		v.ResetCalls()
+	}
+}
+
+// SpiedRequests returns the requests proxied by the spy strategies since the running context was reset
+func (m *Mocks) SpiedRequests() []models.SpiedRequest {
+	return m.spied.get()
+}
+
 func (m *Mocks) EndRunningContext() []error {
 	var errors []error
 	for _, v := range m.mocks {
 		errors = append(errors, v.EndRunningContext()...)
 	}
+	if m.failOnUnused {
+		if err := m.unusedError(); err != nil {
+			errors = append(errors, err)
+		}
+	}
 	return errors
 }
@@ -0,0 +1,30 @@
+package mocks
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartWithAddrs(t *testing.T) {
+	m := NewNop("payments")
+	require.NoError(t, m.StartWithAddrs(map[string]string{"payments": "127.0.0.1:0"}))
+	defer m.Shutdown()
+
+	m.ResetRunningContext()
+	resp, err := http.Get("http://" + m.Service("payments").ServerAddr() + "/pay")
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+
+	// the nop mock doesn't expect any requests
+	assert.NotEmpty(t, m.EndRunningContext())
+}
+
+func TestStartWithAddrsUnknownService(t *testing.T) {
+	m := NewNop("payments")
+
+	err := m.StartWithAddrs(map[string]string{"billing": "127.0.0.1:0"})
+	assert.EqualError(t, err, "service mock not defined: billing")
+}
@@ -0,0 +1,69 @@
+package mocks
+
+import (
+	"math/rand"
+	"net/http"
+	"sync"
+)
+
+// randomReply serves each request with one of the variants chosen randomly by their weights,
+// the same seed gives the same choices in every test
+type randomReply struct {
+	sync.Mutex
+	seed     int64
+	rnd      *rand.Rand
+	variants []*Definition
+	weights  []int
+	total    int
+}
+
+func newRandomReply(variants []*Definition, weights []int, seed int64) ReplyStrategy {
+	total := 0
+	for _, w := range weights {
+		total += w
+	}
+	return &randomReply{
+		seed:     seed,
+		rnd:      rand.New(rand.NewSource(seed)),
+		variants: variants,
+		weights:  weights,
+		total:    total,
+	}
+}
+
+func (s *randomReply) HandleRequest(w http.ResponseWriter, r *http.Request) []error {
+	s.Lock()
+	defer s.Unlock()
+
+	n := s.rnd.Intn(s.total)
+	for i, weight := range s.weights {
+		if n < weight {
+			return s.variants[i].Execute(w, r)
+		}
+		n -= weight
+	}
+	return unhandledRequestError(r)
+}
+
+func (s *randomReply) ResetRunningContext() {
+	s.Lock()
+	s.rnd = rand.New(rand.NewSource(s.seed))
+	s.Unlock()
+	for _, def := range s.variants {
+		def.ResetRunningContext()
+	}
+}
+
+func (s *randomReply) ResetCalls() {
+	for _, def := range s.variants {
+		def.ResetCalls()
+	}
+}
+
+func (s *randomReply) EndRunningContext() []error {
+	var errs []error
+	for _, def := range s.variants {
+		errs = append(errs, def.EndRunningContext()...)
+	}
+	return errs
+}
@@ -0,0 +1,99 @@
+package mocks
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+const randomDefinition = `
+strategy: random
+seed: 42
+variants:
+  - weight: 9
+    strategy: constant
+    body: ok
+  - strategy: constant
+    body: error
+    statusCode: 500
+`
+
+func randomStatuses(def *Definition, n int) []int {
+	statuses := make([]int, 0, n)
+	for i := 0; i < n; i++ {
+		w := httptest.NewRecorder()
+		def.Execute(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		statuses = append(statuses, w.Code)
+	}
+	return statuses
+}
+
+func TestRandomReplyWeights(t *testing.T) {
+	statuses := randomStatuses(loadTestDefinition(t, randomDefinition), 1000)
+
+	failed := 0
+	for _, status := range statuses {
+		if status == http.StatusInternalServerError {
+			failed++
+		}
+	}
+	assert.InDelta(t, 100, failed, 30)
+}
+
+func TestRandomReplySeed(t *testing.T) {
+	def := loadTestDefinition(t, randomDefinition)
+	statuses := randomStatuses(def, 50)
+
+	// the same seed gives the same responses, in another run and in the next test
+	assert.Equal(t, statuses, randomStatuses(loadTestDefinition(t, randomDefinition), 50))
+	def.ResetRunningContext()
+	assert.Equal(t, statuses, randomStatuses(def, 50))
+}
+
+func TestRandomReplyRunSeed(t *testing.T) {
+	definition := `
+strategy: random
+variants:
+  - strategy: constant
+    body: ok
+  - strategy: constant
+    body: error
+    statusCode: 500
+`
+	load := func(seed int64) *Definition {
+		var raw interface{}
+		require.NoError(t, yaml.Unmarshal([]byte(definition), &raw))
+
+		m := NewNop()
+		m.SetRandomSeed(seed)
+		def, err := NewLoader(m).loadDefinition("$", raw)
+		require.NoError(t, err)
+		return def
+	}
+
+	assert.Equal(t, randomStatuses(load(7), 50), randomStatuses(load(7), 50))
+	assert.NotEqual(t, randomStatuses(load(7), 50), randomStatuses(load(8), 50))
+}
+
+func TestRandomReplyValidation(t *testing.T) {
+	tests := map[string]string{
+		"without variants": "{strategy: random}",
+		"invalid seed":     "{strategy: random, seed: abc, variants: [{strategy: nop}]}",
+		"invalid weight":   "{strategy: random, variants: [{strategy: nop, weight: 0}]}",
+		"invalid variant":  "{strategy: random, variants: [{strategy: nop, unknown: 1}]}",
+	}
+
+	for name, definition := range tests {
+		t.Run(name, func(t *testing.T) {
+			var raw interface{}
+			require.NoError(t, yaml.Unmarshal([]byte(definition), &raw))
+
+			_, err := NewLoader(NewNop()).loadDefinition("$", raw)
+			assert.Error(t, err)
+		})
+	}
+}
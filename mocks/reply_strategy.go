@@ -15,6 +15,8 @@ type ReplyStrategy interface {
 
 type contextAwareStrategy interface {
 	ResetRunningContext()
+	// ResetCalls resets the calls of the nested definitions keeping the rest of the running context
+	ResetCalls()
 	EndRunningContext() []error
 }
 
@@ -123,6 +125,12 @@ func (s *uriVaryReply) ResetRunningContext() {
 	}
 }
 
+func (s *uriVaryReply) ResetCalls() {
+	for _, def := range s.variants {
+		def.ResetCalls()
+	}
+}
+
 func (s *uriVaryReply) EndRunningContext() []error {
 	var errs []error
 	for _, def := range s.variants {
@@ -156,6 +164,12 @@ func (s *methodVaryReply) ResetRunningContext() {
 	}
 }
 
+func (s *methodVaryReply) ResetCalls() {
+	for _, def := range s.variants {
+		def.ResetCalls()
+	}
+}
+
 func (s *methodVaryReply) EndRunningContext() []error {
 	var errs []error
 	for _, def := range s.variants {
@@ -185,6 +199,12 @@ func (s *sequentialReply) ResetRunningContext() {
 	}
 }
 
+func (s *sequentialReply) ResetCalls() {
+	for _, def := range s.sequence {
+		def.ResetCalls()
+	}
+}
+
 func (s *sequentialReply) EndRunningContext() []error {
 	var errs []error
 	for _, def := range s.sequence {
@@ -222,7 +242,13 @@ func (s *basedOnRequestReply) HandleRequest(w http.ResponseWriter, r *http.Reque
 
 	var errors []error
 	for _, def := range s.variants {
-		errs := verifyRequestConstraints(def.requestConstraints, r)
+		errs := def.verifyMethod(r)
+		if errs == nil {
+			errs = def.verifyActivation()
+		}
+		if errs == nil {
+			errs = verifyRequestConstraints(def.requestConstraints, r)
+		}
 		if errs == nil {
 			return def.ExecuteWithoutVerifying(w, r)
 		}
@@ -237,6 +263,12 @@ func (s *basedOnRequestReply) ResetRunningContext() {
 	}
 }
 
+func (s *basedOnRequestReply) ResetCalls() {
+	for _, def := range s.variants {
+		def.ResetCalls()
+	}
+}
+
 func (s *basedOnRequestReply) EndRunningContext() []error {
 	var errs []error
 	for _, def := range s.variants {
@@ -152,7 +152,7 @@ type methodConstraint struct {
 
 func (c *methodConstraint) Verify(r *http.Request) []error {
 	if !strings.EqualFold(r.Method, c.method) {
-		return []error{fmt.Errorf("method does not match: expected %s, actual %s", r.Method, c.method)}
+		return []error{fmt.Errorf("method does not match: expected %s, actual %s", c.method, r.Method)}
 	}
 	return nil
 }
@@ -5,6 +5,8 @@ import (
 	"net"
 	"net/http"
 	"sync"
+
+	"github.com/lamoda/gonkey/logging"
 )
 
 type ServiceMock struct {
@@ -14,6 +16,9 @@ type ServiceMock struct {
 	defaultDefinition *Definition
 	sync.RWMutex
 	errors []error
+	calls  *callsCounter
+	spied  *spyRecorder
+	logger logging.Logger
 
 	ServiceName string
 }
@@ -42,6 +47,10 @@ func (m *ServiceMock) StartServerWithAddr(addr string) error {
 }
 
 func (m *ServiceMock) ShutdownServer(ctx context.Context) error {
+	// the mock may be not started if the other mocks failed to start
+	if m.server == nil {
+		return nil
+	}
 	err := m.server.Shutdown(ctx)
 	m.listener = nil
 	m.server = nil
@@ -60,9 +69,15 @@ func (m *ServiceMock) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	defer m.Unlock()
 
 	if m.mock != nil {
+		r = r.WithContext(context.WithValue(r.Context(), spyContextKey{}, spyTarget{service: m.ServiceName, recorder: m.spied}))
 		errs := m.mock.Execute(w, r)
 		m.errors = append(m.errors, errs...)
+		if m.logger != nil {
+			m.logger.Log("mock called", "service", m.ServiceName, "method", r.Method, "path", r.URL.Path,
+				"errors", len(errs))
+		}
 	}
+	m.calls.add(m.ServiceName)
 }
 
 func (m *ServiceMock) SetDefinition(newDefinition *Definition) {
@@ -84,6 +99,12 @@ func (m *ServiceMock) ResetRunningContext() {
 	m.mock.ResetRunningContext()
 }
 
+func (m *ServiceMock) ResetCalls() {
+	m.Lock()
+	defer m.Unlock()
+	m.mock.ResetCalls()
+}
+
 func (m *ServiceMock) EndRunningContext() []error {
 	m.RLock()
 	defer m.RUnlock()
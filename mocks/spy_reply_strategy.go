@@ -0,0 +1,143 @@
+package mocks
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sync"
+
+	"github.com/lamoda/gonkey/models"
+)
+
+// spyRecorder records the requests proxied by the spy strategies during the test in the order they are received,
+// it is shared by all service mocks and reset with their running context
+type spyRecorder struct {
+	sync.Mutex
+	requests []models.SpiedRequest
+}
+
+func newSpyRecorder() *spyRecorder {
+	return &spyRecorder{}
+}
+
+func (s *spyRecorder) add(request models.SpiedRequest) {
+	if s == nil {
+		return
+	}
+	s.Lock()
+	defer s.Unlock()
+	s.requests = append(s.requests, request)
+}
+
+func (s *spyRecorder) get() []models.SpiedRequest {
+	if s == nil {
+		return nil
+	}
+	s.Lock()
+	defer s.Unlock()
+	return append([]models.SpiedRequest(nil), s.requests...)
+}
+
+func (s *spyRecorder) reset() {
+	s.Lock()
+	defer s.Unlock()
+	s.requests = nil
+}
+
+// spyContextKey is the key of the spy target of the request in its context
+type spyContextKey struct{}
+
+// spyTarget is the service mock receiving the request, the spy records the request under its name
+type spyTarget struct {
+	service  string
+	recorder *spyRecorder
+}
+
+// proxyErrorKey is the key of the error of the proxied request in its context
+type proxyErrorKey struct{}
+
+// spyReply proxies the requests to the real upstream recording them, the request constraints
+// and the calls of the definition are checked as usual without replacing the response
+type spyReply struct {
+	upstream *url.URL
+	proxy    *httputil.ReverseProxy
+}
+
+func NewSpyReply(upstream *url.URL) ReplyStrategy {
+	proxy := httputil.NewSingleHostReverseProxy(upstream)
+	direct := proxy.Director
+	proxy.Director = func(r *http.Request) {
+		direct(r)
+		// the upstreams behind the virtual hosts expect their own host
+		r.Host = upstream.Host
+	}
+	proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		if target, ok := r.Context().Value(proxyErrorKey{}).(*error); ok {
+			*target = err
+		}
+		w.WriteHeader(http.StatusBadGateway)
+	}
+	return &spyReply{upstream: upstream, proxy: proxy}
+}
+
+func (s *spyReply) HandleRequest(w http.ResponseWriter, r *http.Request) []error {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return []error{fmt.Errorf("Gonkey internal error during request read: %s", err)}
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	var proxyErr error
+	status := &statusRecorder{ResponseWriter: w}
+	s.proxy.ServeHTTP(status, r.WithContext(context.WithValue(r.Context(), proxyErrorKey{}, &proxyErr)))
+
+	request := models.SpiedRequest{
+		Method: r.Method,
+		URL:    r.URL.RequestURI(),
+		Body:   string(body),
+		Status: status.code,
+	}
+	if proxyErr != nil {
+		request.Status = 0
+	}
+	if target, ok := r.Context().Value(spyContextKey{}).(spyTarget); ok {
+		request.Service = target.service
+		target.recorder.add(request)
+	}
+
+	if proxyErr != nil {
+		return []error{fmt.Errorf("unable to proxy request %s %s to %s: %s", r.Method, r.URL.RequestURI(), s.upstream, proxyErr)}
+	}
+	return nil
+}
+
+// statusRecorder remembers the status of the response written by the proxy
+type statusRecorder struct {
+	http.ResponseWriter
+	code int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	if r.code == 0 {
+		r.code = code
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *statusRecorder) Write(data []byte) (int, error) {
+	if r.code == 0 {
+		r.code = http.StatusOK
+	}
+	return r.ResponseWriter.Write(data)
+}
+
+// Flush passes the streamed responses of the upstream through as they arrive
+func (r *statusRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -0,0 +1,131 @@
+package mocks
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+
+	"github.com/lamoda/gonkey/models"
+)
+
+func startSpy(t *testing.T, definition string) *Mocks {
+	var raw interface{}
+	require.NoError(t, yaml.Unmarshal([]byte(definition), &raw))
+
+	m := NewNop("payments")
+	require.NoError(t, NewLoader(m).Load(map[string]interface{}{"payments": raw}))
+	require.NoError(t, m.Start())
+	return m
+}
+
+func TestSpyReplyProxiesRequests(t *testing.T) {
+	var upstreamHost string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamHost = r.Host
+		body, _ := ioutil.ReadAll(r.Body)
+		w.WriteHeader(http.StatusCreated)
+		_, _ = io.WriteString(w, `{"charged": `+string(body)+`}`)
+	}))
+	defer upstream.Close()
+
+	m := startSpy(t, `
+strategy: spy
+upstream: `+upstream.URL+`
+requestConstraints:
+  - kind: bodyMatchesJSON
+    body: '{"amount": 100}'
+calls: 1
+`)
+	defer m.Shutdown()
+
+	resp, err := http.Post("http://"+m.Service("payments").ServerAddr()+"/charges?currency=usd", "application/json",
+		strings.NewReader(`{"amount": 100}`))
+	require.NoError(t, err)
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, `{"charged": {"amount": 100}}`, string(body))
+	assert.Equal(t, strings.TrimPrefix(upstream.URL, "http://"), upstreamHost)
+	assert.Equal(t, []models.SpiedRequest{{
+		Service: "payments",
+		Method:  http.MethodPost,
+		URL:     "/charges?currency=usd",
+		Body:    `{"amount": 100}`,
+		Status:  http.StatusCreated,
+	}}, m.SpiedRequests())
+	assert.Empty(t, m.EndRunningContext())
+
+	m.ResetRunningContext()
+	assert.Empty(t, m.SpiedRequests())
+}
+
+func TestSpyReplyChecksRequests(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer upstream.Close()
+
+	m := startSpy(t, `
+strategy: spy
+upstream: `+upstream.URL+`
+requestConstraints:
+  - kind: bodyMatchesJSON
+    body: '{"amount": 100}'
+calls: 1
+`)
+	defer m.Shutdown()
+
+	for i := 0; i < 2; i++ {
+		resp, err := http.Post("http://"+m.Service("payments").ServerAddr()+"/charges", "application/json",
+			strings.NewReader(`{"amount": 200}`))
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		// the response of the upstream is not replaced
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+
+	errs := m.EndRunningContext()
+	require.Len(t, errs, 3)
+	assert.Contains(t, errs[0].Error(), "values do not match")
+	assert.Contains(t, errs[1].Error(), "values do not match")
+	assert.Contains(t, errs[2].Error(), "number of calls does not match: expected 1, actual 2")
+	assert.Len(t, m.SpiedRequests(), 2)
+}
+
+func TestSpyReplyUpstreamFailed(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	upstream.Close()
+
+	m := startSpy(t, `
+strategy: spy
+upstream: `+upstream.URL+`
+`)
+	defer m.Shutdown()
+
+	resp, err := http.Get("http://" + m.Service("payments").ServerAddr() + "/charges/1")
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+
+	assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+	assert.Equal(t, []models.SpiedRequest{{Service: "payments", Method: http.MethodGet, URL: "/charges/1"}},
+		m.SpiedRequests())
+	errs := m.EndRunningContext()
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "unable to proxy request GET /charges/1 to "+upstream.URL)
+}
+
+func TestSpyReplyRequiresUpstream(t *testing.T) {
+	for _, definition := range []string{"strategy: spy", "strategy: spy\nupstream: /payments"} {
+		var raw interface{}
+		require.NoError(t, yaml.Unmarshal([]byte(definition), &raw))
+		_, err := NewLoader(NewNop()).loadDefinition("$", raw)
+		assert.Error(t, err, definition)
+	}
+}
@@ -0,0 +1,63 @@
+package mocks
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SetFailOnUnused makes EndRunningContext fail when a service mock defined in the test received no calls,
+// the definitions with `optional: true` or `calls: 0` are not required to be called
+func (m *Mocks) SetFailOnUnused(fail bool) {
+	m.failOnUnused = fail
+}
+
+// unusedError lists the service mocks defined in the test and never called
+func (m *Mocks) unusedError() error {
+	var names []string
+	for name, mock := range m.mocks {
+		if mock.unused() {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	sort.Strings(names)
+	return fmt.Errorf("mocks defined in the test were never called: %s", strings.Join(names, ", "))
+}
+
+func (m *ServiceMock) unused() bool {
+	m.RLock()
+	defer m.RUnlock()
+	if m.mock == nil || m.mock == m.defaultDefinition || m.mock.optional || m.mock.callsConstraint == 0 {
+		return false
+	}
+	return m.calls != nil && m.calls.get(m.ServiceName) == 0
+}
+
+// optional tells if the definition of the service mock is marked with `optional: true`
+// and returns the definition without `optional`
+func optional(rawDef interface{}) (bool, interface{}, error) {
+	def, ok := rawDef.(map[interface{}]interface{})
+	if !ok {
+		return false, rawDef, nil
+	}
+	rawOptional, ok := def["optional"]
+	if !ok {
+		return false, rawDef, nil
+	}
+	value, ok := rawOptional.(bool)
+	if !ok {
+		return false, nil, errors.New("`optional` must be boolean")
+	}
+
+	stripped := make(map[interface{}]interface{}, len(def)-1)
+	for k, v := range def {
+		if k != "optional" {
+			stripped[k] = v
+		}
+	}
+	return value, stripped, nil
+}
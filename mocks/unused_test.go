@@ -0,0 +1,66 @@
+package mocks
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+const unusedDefinition = `
+orders:
+  strategy: constant
+  body: ok
+payments:
+  strategy: constant
+  body: ok
+notifications:
+  strategy: nop
+  optional: true
+audit:
+  strategy: nop
+  calls: 0
+`
+
+func TestFailOnUnused(t *testing.T) {
+	var raw map[string]interface{}
+	require.NoError(t, yaml.Unmarshal([]byte(unusedDefinition), &raw))
+
+	m := NewNop("orders", "payments", "notifications", "audit", "users")
+	m.SetFailOnUnused(true)
+	require.NoError(t, NewLoader(m).Load(raw))
+	m.ResetRunningContext()
+
+	errs := m.EndRunningContext()
+	require.Len(t, errs, 1)
+	assert.EqualError(t, errs[0], "mocks defined in the test were never called: orders, payments")
+
+	m.ResetRunningContext()
+	assert.Equal(t, "ok", callMock(m, "orders"))
+	assert.Equal(t, "ok", callMock(m, "payments"))
+	assert.Empty(t, m.EndRunningContext())
+}
+
+func TestUnusedAllowedByDefault(t *testing.T) {
+	var raw map[string]interface{}
+	require.NoError(t, yaml.Unmarshal([]byte(unusedDefinition), &raw))
+
+	m := NewNop("orders", "payments", "notifications", "audit")
+	require.NoError(t, NewLoader(m).Load(raw))
+	m.ResetRunningContext()
+	assert.Empty(t, m.EndRunningContext())
+}
+
+func TestOptionalInvalid(t *testing.T) {
+	var raw map[string]interface{}
+	require.NoError(t, yaml.Unmarshal([]byte(`
+orders:
+  strategy: nop
+  optional: sometimes
+`), &raw))
+
+	err := NewLoader(NewNop("orders")).Validate(raw)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "`optional` must be boolean")
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MissingFile is a file referenced by a test which doesn't exist
+type MissingFile struct {
+	// Field is the field of the test referring to the file, e.g. requestFile or fixtures
+	Field    string
+	Path     string
+	Test     string
+	TestFile string
+}
+
+// MissingFilesError lists the missing files referenced by the tests, they are reported at once
+// before any test is run
+type MissingFilesError struct {
+	Files []MissingFile
+}
+
+func (e *MissingFilesError) Error() string {
+	lines := []string{fmt.Sprintf("the tests reference %d missing files:", len(e.Files))}
+	for _, f := range e.Files {
+		lines = append(lines, fmt.Sprintf("  %s %s of test %s (%s)", f.Field, f.Path, f.Test, f.TestFile))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// CollectMissingFiles appends the files of MissingFilesError to missing,
+// false is returned for the other errors
+func CollectMissingFiles(err error, missing *[]MissingFile) bool {
+	e, ok := err.(*MissingFilesError)
+	if ok {
+		*missing = append(*missing, e.Files...)
+	}
+	return ok
+}
@@ -1,10 +1,38 @@
 package models
 
-import "errors"
+import (
+	"errors"
+	"strings"
+	"time"
+)
 
 type DatabaseResult struct {
 	Query    string
 	Response []string
+	// Plan is the output of EXPLAIN ANALYZE of the query, it's captured for the slow tests only
+	Plan string
+}
+
+// SpiedRequest is a request received by a service mock with the spy strategy and proxied to the upstream
+type SpiedRequest struct {
+	Service string
+	Method  string
+	// URL is the path and the query of the request
+	URL  string
+	Body string
+	// Status is the status of the response of the upstream, 0 if the request failed
+	Status int
+}
+
+// CheckResult is the outcome of one checker of the test
+type CheckResult struct {
+	Checker string
+	Errors  []error
+}
+
+// Passed returns true if the check passed (false otherwise)
+func (c *CheckResult) Passed() bool {
+	return len(c.Errors) == 0
 }
 
 // Result of test execution
@@ -20,6 +48,56 @@ type Result struct {
 	Errors              []error
 	Test                TestInterface
 	DatabaseResult      []DatabaseResult
+	// ServerLogs are the logs written by the tested service during the test
+	ServerLogs string
+	// Checks are the outcomes of the checkers, Errors contain the errors of all the checks
+	Checks []CheckResult
+	// BodyDiff is the difference between the expected and actual JSON bodies, line by line
+	BodyDiff string
+	// Redirects are the redirects followed before the response, if the test expects redirects
+	Redirects []RedirectHop
+	// ResponseTrailers are the HTTP trailers sent after the body, they are known only when
+	// the body is read to the end
+	ResponseTrailers map[string][]string
+	// ResponseProto is the protocol of the response, e.g. HTTP/1.1 or HTTP/2.0
+	ResponseProto string
+	// ActiveMocks are the service mocks loaded for the test, the mocks disabled in the environment
+	// are not included
+	ActiveMocks []string
+	// SpiedRequests are the requests of the service proxied by the service mocks with the spy strategy
+	// to the real upstreams, in the order they were received
+	SpiedRequests []SpiedRequest
+	// Duration is the time the test took without loading and cleaning the fixtures,
+	// FixturesDuration is the time of the fixtures
+	Duration         time.Duration
+	FixturesDuration time.Duration
+	// RequestDuration is the time from sending the request to reading the whole body of the response
+	RequestDuration time.Duration
+	// RequestCurl is the request sent by the test as a curl command, the outputs show it for the failed tests
+	RequestCurl string
+}
+
+// TestDuration is the time an executed test took, see Result.Duration
+type TestDuration struct {
+	Name             string
+	FileName         string
+	Duration         time.Duration
+	FixturesDuration time.Duration
+}
+
+// RepeatedTest is the tally of the iterations of a test with the repeat directive
+type RepeatedTest struct {
+	Name     string
+	FileName string
+	// Count is the number of the iterations requested, Runs is less than Count
+	// if the iterations were stopped on a failure
+	Count  int
+	Runs   int
+	Passed int
+}
+
+func (t RepeatedTest) Failed() int {
+	return t.Runs - t.Passed
 }
 
 func allureStatus(status string) bool {
@@ -43,6 +121,9 @@ func notRunnedStatus(status string) bool {
 func (r *Result) AllureStatus() (string, error) {
 	testStatus := r.Test.GetStatus()
 	if testStatus != "" && allureStatus(testStatus) && notRunnedStatus(testStatus) {
+		if reason := r.Test.GetReason(); reason != "" {
+			return testStatus, errors.New(reason)
+		}
 		return testStatus, nil
 	}
 
@@ -71,3 +152,12 @@ func (r *Result) AllureStatus() (string, error) {
 func (r *Result) Passed() bool {
 	return len(r.Errors) == 0
 }
+
+// ResponseStatusText returns the reason phrase of the status line of the response, e.g. "Not Found",
+// the HTTP/2 responses have no reason phrase and get the standard text of the status code
+func (r *Result) ResponseStatusText() string {
+	if i := strings.IndexByte(r.ResponseStatus, ' '); i >= 0 {
+		return r.ResponseStatus[i+1:]
+	}
+	return ""
+}
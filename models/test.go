@@ -1,27 +1,133 @@
 package models
 
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
 type DatabaseCheck interface {
 	DbQueryString() string
 	DbResponseJson() []string
+	DbResponseFile() string
+	// DbQueryParams are bound to the placeholders of the query, $1 in PostgreSQL and ? in MySQL or ClickHouse
+	DbQueryParams() []interface{}
 
 	SetDbQueryString(string)
 	SetDbResponseJson([]string)
+	SetDbQueryParams([]interface{})
 }
 
 // Common Test interface
 type TestInterface interface {
 	ToQuery() string
 	GetRequest() string
+	// GetRequestBuilder returns the name of the function building the request body, empty if it isn't built
+	GetRequestBuilder() string
 	ToJSON() ([]byte, error)
 	GetMethod() string
 	Path() string
 	GetResponses() map[int]string
+	// GetResponseStatus returns the expected status of the response, nil if it's not set
+	GetResponseStatus() ResponseStatus
 	GetResponse(code int) (string, bool)
+	// GetResponsesOneOf returns the acceptable response bodies by status code, the body must match any of them
+	GetResponsesOneOf() map[int][]string
 	GetResponseHeaders(code int) (map[string]string, bool)
+	// GetResponseHeadersOrdered returns the headers whose values must match in the given order
+	GetResponseHeadersOrdered(code int) (map[string][]string, bool)
+	// GetResponseTrailers returns the expected HTTP trailers sent after the response body
+	GetResponseTrailers(code int) (map[string]string, bool)
+	// GetResponseCacheControl returns the expected directives of the Cache-Control header by name,
+	// e.g. "max-age": ">= 60" or "no-store": "present"
+	GetResponseCacheControl(code int) (map[string]string, bool)
+	// GetResponseCookies returns the expectations of the cookies of the Set-Cookie headers by name,
+	// e.g. "session": "set" or "remember_me": "cleared"
+	GetResponseCookies(code int) (map[string]string, bool)
+	// GetResponseStatusText returns the expected reason phrase of the status line by status code
+	GetResponseStatusText(code int) (string, bool)
+	GetResponseBodyFile(code int) (string, bool)
+	// GetRequestSnapshotFile returns the golden file the request is compared with byte by byte,
+	// empty if the request isn't snapshotted
+	GetRequestSnapshotFile() string
+	// GetResponseBodyValidJSON tells that the response body must be valid JSON of any content
+	GetResponseBodyValidJSON() bool
+	// GetResponseBodyMatchRegexp returns the regular expression the whole response body must match,
+	// empty if the body isn't matched
+	GetResponseBodyMatchRegexp() string
+	// GetResponseBodySize returns the bounds of the size of the response body, nil if it isn't checked
+	GetResponseBodySize() *BodySize
+	// GetResponseBodyEncoding returns the expected encoding of the response body, nil if it isn't checked
+	GetResponseBodyEncoding() *BodyEncoding
+	// GetResponseProto returns the expected protocol of the response, e.g. HTTP/2.0,
+	// empty if it isn't checked
+	GetResponseProto() string
+	GetStreamResponse() *StreamResponse
+	GetProtobufResponse() *ProtobufResponse
+	// GetResponseXPaths returns the XPath assertions of XML responses by status code
+	GetResponseXPaths() map[int][]string
+	// GetResponseJSONPaths returns the JSONPath assertions of JSON responses by status code
+	GetResponseJSONPaths() map[int][]string
+	// GetResponseRoot returns the JSONPath of the subtree of the JSON response compared with the expected body,
+	// empty if the whole body is compared
+	GetResponseRoot() string
+	// GetAssertions returns the expectations applied depending on the status of the response
+	GetAssertions() []Assertions
+	GetRetryPolicy() *RetryPolicy
+	// GetAssertRetry returns how the checks are repeated until the state of the service settles,
+	// nil if they are run once
+	GetAssertRetry() *AssertRetry
+	// GetRepeat returns how many times the test is run in a row, nil if it's run once
+	GetRepeat() *Repeat
+	// GetRedirects returns the expected redirects, the redirects are followed only if they are set
+	GetRedirects() []RedirectHop
+	// GetResponseLocations returns the expected Location headers by status code
+	GetResponseLocations() ResponseLocations
+	// GetMaxDbQueries returns the limit of the DB queries run by the service during the request,
+	// nil if the queries aren't counted
+	GetMaxDbQueries() *int
+	// GetIdempotency returns how the request is repeated to check that it's idempotent,
+	// nil if it's sent once
+	GetIdempotency() *Idempotency
+	// GetConditionalRequest returns how the request is sent again with the validators of the response,
+	// nil if it's sent once
+	GetConditionalRequest() *Conditional
+	// GetMetricsDelta returns the expected changes of the metrics of the service made by the request
+	// by the metric selectors, e.g. http_requests_total{code="200"}
+	GetMetricsDelta() map[string]string
+	// GetSnapshotIgnorePaths returns the paths of the volatile fields of the response which are not compared
+	// with the snapshot, e.g. $.items[*].createdAt
+	GetSnapshotIgnorePaths() []string
+	// GetGrpcStatus returns the expected gRPC status of the response, nil if it's not checked
+	GetGrpcStatus() *GrpcStatus
+	// GetFuzz returns how the request bodies of the fuzz test are generated, nil if it's not a fuzz test
+	GetFuzz() *Fuzz
+	// GetSteps returns the steps of the scenario run in order as tests of their own,
+	// nil if the test is not a scenario
+	GetSteps() []TestInterface
+	GetEnv() map[string]string
+	GetServer() string
+	// GetProxy returns the proxy of the requests of the test overriding the one of the runner,
+	// "none" sends them directly, empty if the proxy of the runner is used
+	GetProxy() string
 	GetName() string
+	// GetDefinitionName returns the name of the test in its file, the tests of its cases are named
+	// after it with the suffix of the case
+	GetDefinitionName() string
 	GetDescription() string
 	GetStatus() string
+	// GetReason explains why the test is skipped or broken, e.g. a link to the ticket
+	GetReason() string
+	GetTags() []string
+	// GetDisabledCheckers returns the names of the checkers skipped for the test, e.g. response_header
+	GetDisabledCheckers() []string
+	// GetDependsOn returns the names of the tests which must pass before the test is run
+	GetDependsOn() []string
 	SetStatus(string)
+	SetReason(string)
+	SetName(string)
 	Fixtures() []string
 	ServiceMocks() map[string]interface{}
 	Pause() int
@@ -35,13 +141,20 @@ type TestInterface interface {
 	GetForm() *Form
 	DbQueryString() string
 	DbResponseJson() []string
+	DbResponseFile() string
+	DbQueryParams() []interface{}
 	GetVariables() map[string]string
 	GetCombinedVariables() map[string]string
 	GetVariablesToSet() map[int]map[string]string
 	GetDatabaseChecks() []DatabaseCheck
+	// GetDbProtobufColumns returns the full names of the protobuf messages stored in the bytea columns
+	// of the DB responses by the names of the columns
+	GetDbProtobufColumns() map[string]string
 	SetDatabaseChecks([]DatabaseCheck)
 
 	GetFileName() string
+	// GetBaseDir returns the directory the relative paths of the files referenced by the test are resolved against
+	GetBaseDir() string
 
 	// setters
 	SetQuery(string)
@@ -50,23 +163,293 @@ type TestInterface interface {
 	SetRequest(string)
 	SetForm(form *Form)
 	SetResponses(map[int]string)
+	SetResponseHeaders(map[int]map[string]string)
 	SetHeaders(map[string]string)
 	SetDbQueryString(string)
+	SetDbQueryParams([]interface{})
 	SetDbResponseJson([]string)
+	SetServiceMocks(map[string]interface{})
+	SetStreamResponse(*StreamResponse)
+	SetProtobufResponse(*ProtobufResponse)
+	SetResponseXPaths(map[int][]string)
+	SetResponseJSONPaths(map[int][]string)
+	SetResponsesOneOf(map[int][]string)
+	SetRedirects([]RedirectHop)
+	SetProxy(string)
+	SetResponseLocations(ResponseLocations)
+	SetEnv(map[string]string)
 
 	// comparison properties
 	NeedsCheckingValues() bool
 	IgnoreArraysOrdering() bool
 	DisallowExtraFields() bool
 	IgnoreDbOrdering() bool
+	NormalizeKeys() string
+	// NumberTypeStrict tells if the integers and the floats of the same value differ in the JSON bodies
+	NumberTypeStrict() bool
+	// GetComparisonParamsTemplates returns the templates of the comparison params by their names,
+	// they are evaluated with the variables and the request before the checks
+	GetComparisonParamsTemplates() map[string]string
+	// SetComparisonParam sets the comparison param to the value evaluated from its template
+	SetComparisonParam(name, value string) error
 
 	// Clone returns copy of current object
 	Clone() TestInterface
 }
 
+const (
+	StreamNDJSON = "ndjson"
+	StreamSSE    = "sse"
+)
+
+// StreamResponse describes the expected streaming response, line-delimited (NDJSON) by default
+// or server-sent events (SSE)
+type StreamResponse struct {
+	// Type is the format of the stream, StreamNDJSON if empty
+	Type string `json:"type" yaml:"type"`
+	// Timeout in seconds for reading the stream, when it expires the lines received so far are checked.
+	// Zero timeout means reading until the server closes the stream.
+	Timeout int `json:"timeout" yaml:"timeout"`
+	// Lines are the expected JSON documents of the stream lines for each HTTP status code
+	Lines map[int][]string `json:"lines" yaml:"lines"`
+	// Events are the expected server-sent events for each HTTP status code
+	Events map[int][]SSEEvent `json:"events" yaml:"events"`
+}
+
+// SSEEvent is the expected server-sent event, the empty Event means the default "message" type
+// and the empty ID isn't checked
+type SSEEvent struct {
+	Event string `json:"event" yaml:"event"`
+	Data  string `json:"data" yaml:"data"`
+	ID    string `json:"id" yaml:"id"`
+}
+
+// ProtobufResponse describes the expected protobuf response, it's checked by response_protobuf checker
+type ProtobufResponse struct {
+	// Message is the full name of the expected message, e.g. shop.v1.Order
+	Message string `json:"message" yaml:"message"`
+	// Format of the expected messages: json (by default) or text
+	Format string `json:"format" yaml:"format"`
+	// IgnoreUnknownFields makes the fields of the response unknown to the message descriptor ignored
+	IgnoreUnknownFields bool `json:"ignoreUnknownFields" yaml:"ignoreUnknownFields"`
+	// Body are the expected messages for each HTTP status code
+	Body map[int]string `json:"body" yaml:"body"`
+}
+
+// GrpcStatus is the expected gRPC status of the response sent in the grpc-status, grpc-message
+// and grpc-status-details-bin trailers, or in the headers of the trailers-only responses
+type GrpcStatus struct {
+	// Code is the name (NOT_FOUND) or the number (5) of the status code
+	Code string `json:"code" yaml:"code"`
+	// Message is compared with the decoded message the same way as the headers, it's not checked if empty
+	Message string `json:"message" yaml:"message"`
+	// Details are the expected messages of the details of the status in order, they aren't checked if nil
+	Details []GrpcStatusDetail `json:"details" yaml:"details"`
+}
+
+// GrpcStatusDetail is an expected message of the details of the gRPC status
+type GrpcStatusDetail struct {
+	// Type is the full name of the message, e.g. google.rpc.ErrorInfo
+	Type string `json:"type" yaml:"type"`
+	// Value is the expected message in JSON, compared the same way as the JSON bodies
+	Value string `json:"value" yaml:"value"`
+}
+
+// RetryPolicy describes repeating of the request until the response passes the checks
+type RetryPolicy struct {
+	// Attempts is the max number of requests
+	Attempts int `json:"attempts" yaml:"attempts"`
+	// Delay in seconds between the attempts
+	Delay int `json:"delay" yaml:"delay"`
+	// MaxRetryAfter in seconds caps the wait of Retry-After of the 429 responses replacing the delay,
+	// 60 seconds by default
+	MaxRetryAfter int `json:"maxRetryAfter" yaml:"maxRetryAfter"`
+	// Responses are the expected responses of the attempts in their order
+	Responses []AttemptResponse `json:"responses" yaml:"responses"`
+}
+
+// AssertRetry describes repeating of the checks of the response until they pass, the request isn't sent again
+type AssertRetry struct {
+	// Interval between the runs of the checks, e.g. 200ms
+	Interval time.Duration `json:"interval" yaml:"interval"`
+	// Timeout after which the failures of the last run are reported, e.g. 5s
+	Timeout time.Duration `json:"timeout" yaml:"timeout"`
+}
+
+// Repeat runs the test several times in a row, e.g. to reproduce a flaky failure
+type Repeat struct {
+	Count int `json:"count" yaml:"count"`
+	// StopOnFailure stops the iterations after the first failed one
+	StopOnFailure bool `json:"stopOnFailure" yaml:"stopOnFailure"`
+}
+
+// Idempotency makes the runner send the request twice with the same idempotency key,
+// the second response must match the first one and the numbers of the rows of Tables must stay the same
+type Idempotency struct {
+	// Header is the header of the idempotency key, the value set by the test is kept,
+	// a random key is used otherwise
+	Header string   `json:"header" yaml:"header"`
+	Tables []string `json:"tables" yaml:"tables"`
+}
+
+// Conditional makes the runner send the request once more with the validators of the response
+// in If-None-Match and If-Modified-Since, the service must answer 304 Not Modified with an empty body
+type Conditional struct {
+	// Validators are ValidatorETag and ValidatorLastModified, the ones returned by the service are used if empty
+	Validators []string `json:"validators" yaml:"validators"`
+}
+
+// validators of the responses sent by the conditional requests
+const (
+	ValidatorETag         = "etag"
+	ValidatorLastModified = "lastModified"
+)
+
+// TestTypeFuzz is the type of the tests sending the request bodies generated from the JSON Schema
+const TestTypeFuzz = "fuzz"
+
+// Fuzz sends Iterations requests with the random bodies valid against the JSON Schema,
+// the bodies of the same seed are the same, so a failing body can be reproduced
+type Fuzz struct {
+	// Schema is the path to the JSON Schema of the request body in JSON or YAML
+	Schema     string `json:"schema" yaml:"schema"`
+	Iterations int    `json:"iterations" yaml:"iterations"`
+	// Seed makes the bodies random for every run if it's zero
+	Seed int64 `json:"seed" yaml:"seed"`
+}
+
+// AttemptResponse is the expected response of an attempt, the body is checked only if it's set
+type AttemptResponse struct {
+	Status int    `json:"status" yaml:"status"`
+	Body   string `json:"body" yaml:"body"`
+}
+
+// RedirectHop is a redirect response of the chain: its status and Location header
+type RedirectHop struct {
+	Status   int    `json:"status" yaml:"status"`
+	Location string `json:"location" yaml:"location"`
+}
+
+// ResponseLocation is the expected Location header resolved against the URL of the request,
+// so the path and the query are asserted regardless of the host and of the relative form of the header
+type ResponseLocation struct {
+	Path  string            `json:"path" yaml:"path"`
+	Query map[string]string `json:"query" yaml:"query"`
+	// Variable is the name of the variable the path with the query of the location is saved to,
+	// e.g. for the path of the next test
+	Variable string `json:"variable" yaml:"variable"`
+}
+
+// ResponseLocations are the expected locations by status code
+type ResponseLocations map[int]ResponseLocation
+
+// responseStatusRx matches a status code (200) or a class of codes (2xx)
+var responseStatusRx = regexp.MustCompile(`^[1-5]([0-9]{2}|xx)$`)
+
+// ResponseStatus is the expected status of the response: a code (200), a class of codes (2xx)
+// or a list of them, the status must match any of them
+type ResponseStatus []string
+
+func (s *ResponseStatus) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var items []string
+	if err := unmarshal(&items); err != nil {
+		var item string
+		if err := unmarshal(&item); err != nil {
+			return err
+		}
+		items = []string{item}
+	}
+
+	for _, item := range items {
+		if !responseStatusRx.MatchString(item) {
+			return fmt.Errorf("invalid response status %q, expected a code (200) or a class of codes (2xx)", item)
+		}
+	}
+	*s = items
+	return nil
+}
+
+// Match tells if the status code matches any of the expected codes or classes
+func (s ResponseStatus) Match(code int) bool {
+	actual := strconv.Itoa(code)
+	for _, item := range s {
+		if item == actual || strings.HasSuffix(item, "xx") && item[0] == actual[0] && len(actual) == 3 {
+			return true
+		}
+	}
+	return false
+}
+
+func (s ResponseStatus) String() string {
+	return strings.Join(s, ", ")
+}
+
+// BodySize is the bounds of the size of the body in bytes, zero Max means no upper bound
+type BodySize struct {
+	Min int `json:"min" yaml:"min"`
+	Max int `json:"max" yaml:"max"`
+}
+
+func (s *BodySize) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain BodySize
+	if err := unmarshal((*plain)(s)); err != nil {
+		return err
+	}
+	if s.Min < 0 || s.Max < 0 {
+		return fmt.Errorf("invalid body size bounds %s, expected non-negative numbers", s)
+	}
+	if s.Max > 0 && s.Min > s.Max {
+		return fmt.Errorf("invalid body size bounds %s, min is greater than max", s)
+	}
+	return nil
+}
+
+// Match tells if the size is within the bounds
+func (s BodySize) Match(size int) bool {
+	return size >= s.Min && (s.Max == 0 || size <= s.Max)
+}
+
+func (s BodySize) String() string {
+	if s.Max == 0 {
+		return fmt.Sprintf("[%d, ∞)", s.Min)
+	}
+	return fmt.Sprintf("[%d, %d]", s.Min, s.Max)
+}
+
+// BodyEncoding is the expected encoding of the body and its text after decoding
+type BodyEncoding struct {
+	// Charset the body must be encoded in, the charset of Content-Type by default or UTF-8 if it has none
+	Charset string `json:"charset" yaml:"charset"`
+	// Text is the expected decoded body, the body isn't compared if it's empty
+	Text string `json:"text" yaml:"text"`
+}
+
+// Assertions are the expectations of the response applied only if its status matches When,
+// they replace the expectations of the test for the status
+type Assertions struct {
+	When             ResponseStatus    `json:"when" yaml:"when"`
+	Response         string            `json:"response" yaml:"response"`
+	ResponseHeaders  map[string]string `json:"responseHeaders" yaml:"responseHeaders"`
+	ResponseXPath    []string          `json:"responseXPath" yaml:"responseXPath"`
+	ResponseJSONPath []string          `json:"responseJSONPath" yaml:"responseJSONPath"`
+}
+
 // TODO: add support for form fields
 type Form struct {
 	Files map[string]string `json:"files" yaml:"files"`
+	Parts []FormPart        `json:"parts" yaml:"parts"`
+}
+
+// FormPart is a part of the multipart request with its own content type, the parts are written
+// in the given order after the fields of the request and the files
+type FormPart struct {
+	Name string `json:"name" yaml:"name"`
+	// ContentType is text/plain for the body and application/octet-stream for the file by default
+	ContentType string `json:"contentType" yaml:"contentType"`
+	// Body is the content of the part, e.g. JSON metadata, the variables are substituted in it
+	Body string `json:"body" yaml:"body"`
+	// File is the path to the file sent as is if there is no Body
+	File string `json:"file" yaml:"file"`
 }
 
 type Summary struct {
@@ -76,3 +459,9 @@ type Summary struct {
 	Broken  int
 	Total   int
 }
+
+// IsCaseOf tells if the test is produced by a case of the test named base, the tests of the cases
+// are named "<base> #<number or name of the case>"
+func IsCaseOf(name, base string) bool {
+	return strings.HasPrefix(name, base+" #")
+}
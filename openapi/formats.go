@@ -0,0 +1,22 @@
+package openapi
+
+import (
+	"fmt"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// FormatValidator validates the strings of a custom format of the schemas, e.g. format: sku
+type FormatValidator func(value string) error
+
+// DefineFormat registers the validator of the custom string format of the schemas of all the specs,
+// the specs using unknown formats are not loaded, so the formats have to be defined before NewValidator
+// is called. The violations name the format whose validator failed.
+func DefineFormat(name string, validate FormatValidator) {
+	openapi3.DefineStringFormatCallback(name, func(value string) error {
+		if err := validate(value); err != nil {
+			return fmt.Errorf("string doesn't match the format %q: %s", name, err)
+		}
+		return nil
+	})
+}
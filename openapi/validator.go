@@ -0,0 +1,207 @@
+package openapi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+)
+
+// Validator validates the requests of the tests and the responses to them against an OpenAPI 3 spec
+type Validator struct {
+	router            routers.Router
+	basePaths         []string
+	validateResponses bool
+}
+
+// NewValidator loads the spec from the file (YAML or JSON)
+func NewValidator(specPath string) (*Validator, error) {
+	loader := openapi3.NewLoader()
+	loader.IsExternalRefsAllowed = true
+
+	doc, err := loader.LoadFromFile(specPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load OpenAPI spec %s: %w", specPath, err)
+	}
+	if err := doc.Validate(loader.Context); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI spec %s: %w", specPath, err)
+	}
+
+	// the requests are matched by their paths only, the tested service is usually
+	// not deployed on the hosts listed in the spec
+	var basePaths []string
+	for _, server := range doc.Servers {
+		u, err := url.Parse(server.URL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid server url %q in OpenAPI spec %s: %w", server.URL, specPath, err)
+		}
+		if basePath := strings.TrimRight(u.Path, "/"); basePath != "" {
+			basePaths = append(basePaths, basePath)
+		}
+	}
+	doc.Servers = nil
+
+	router, err := gorillamux.NewRouter(doc)
+	if err != nil {
+		return nil, fmt.Errorf("unable to route OpenAPI spec %s: %w", specPath, err)
+	}
+
+	return &Validator{router: router, basePaths: basePaths}, nil
+}
+
+// SetValidateResponses enables the validation of the responses against the schemas of the operations
+func (v *Validator) SetValidateResponses(validate bool) {
+	v.validateResponses = validate
+}
+
+func (v *Validator) ValidatesResponses() bool {
+	return v.validateResponses
+}
+
+var options = &openapi3filter.Options{
+	MultiError: true,
+	// security schemes are the business of the tested service
+	AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+}
+
+// ValidateRequest returns a violation per invalid parameter or field of the request body,
+// the body of the request is kept readable
+func (v *Validator) ValidateRequest(req *http.Request) []error {
+	input, err := v.requestInput(req)
+	if err != nil {
+		return []error{err}
+	}
+
+	return violations(openapi3filter.ValidateRequest(context.Background(), input))
+}
+
+// ValidateResponse returns a violation per invalid field of the response to the request
+func (v *Validator) ValidateResponse(req *http.Request, status int, header http.Header, body []byte) []error {
+	input, err := v.requestInput(req)
+	if err != nil {
+		return []error{err}
+	}
+
+	responseOptions := *options
+	responseOptions.IncludeResponseStatus = true
+
+	err = openapi3filter.ValidateResponse(context.Background(), &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: input,
+		Status:                 status,
+		Header:                 header,
+		Body:                   ioutil.NopCloser(bytes.NewReader(body)),
+		Options:                &responseOptions,
+	})
+	return violations(err)
+}
+
+func (v *Validator) requestInput(req *http.Request) (*openapi3filter.RequestValidationInput, error) {
+	route, pathParams, err := v.router.FindRoute(v.withoutBasePath(req))
+	switch {
+	case errors.Is(err, routers.ErrPathNotFound):
+		return nil, fmt.Errorf("%s %s: path is not described in the spec", req.Method, req.URL.Path)
+	case errors.Is(err, routers.ErrMethodNotAllowed):
+		return nil, fmt.Errorf("%s %s: method is not described in the spec", req.Method, req.URL.Path)
+	case err != nil:
+		return nil, err
+	}
+
+	return &openapi3filter.RequestValidationInput{
+		Request:    req,
+		PathParams: pathParams,
+		Route:      route,
+		Options:    options,
+	}, nil
+}
+
+func (v *Validator) withoutBasePath(req *http.Request) *http.Request {
+	for _, basePath := range v.basePaths {
+		if req.URL.Path != basePath && !strings.HasPrefix(req.URL.Path, basePath+"/") {
+			continue
+		}
+
+		routed := req.Clone(req.Context())
+		routed.URL.Path = strings.TrimPrefix(req.URL.Path, basePath)
+		routed.URL.RawPath = ""
+		if routed.URL.Path == "" {
+			routed.URL.Path = "/"
+		}
+		return routed
+	}
+	return req
+}
+
+// violations splits the error of the validation into the violations of the separate fields
+func violations(err error) []error {
+	if err == nil {
+		return nil
+	}
+
+	var errs []error
+	switch e := err.(type) {
+	case openapi3.MultiError:
+		for _, err := range e {
+			errs = append(errs, violations(err)...)
+		}
+	case *openapi3filter.RequestError:
+		prefix := "request"
+		switch {
+		case e.Parameter != nil:
+			prefix = fmt.Sprintf("%s parameter %q", e.Parameter.In, e.Parameter.Name)
+		case e.RequestBody != nil:
+			prefix = "request body"
+		}
+		errs = append(errs, withPrefix(prefix, e.Reason, e.Err)...)
+	case *openapi3filter.ResponseError:
+		errs = append(errs, withPrefix("response", e.Reason, e.Err)...)
+	case *openapi3.SchemaError:
+		errs = append(errs, schemaViolation(e))
+	default:
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+func withPrefix(prefix, reason string, err error) []error {
+	switch err.(type) {
+	case *openapi3.SchemaError, openapi3.MultiError:
+	default:
+		switch {
+		case err == nil || err.Error() == reason:
+		case reason == "":
+			reason = err.Error()
+		default:
+			reason = fmt.Sprintf("%s: %s", reason, err)
+		}
+		return []error{fmt.Errorf("%s: %s", prefix, reason)}
+	}
+
+	// the reason only tells that the value doesn't match the schema
+	var errs []error
+	for _, violation := range violations(err) {
+		errs = append(errs, fmt.Errorf("%s: %s", prefix, violation))
+	}
+	return errs
+}
+
+func schemaViolation(err *openapi3.SchemaError) error {
+	reason := err.Reason
+	if reason == "" {
+		reason = fmt.Sprintf("doesn't match schema %q", err.SchemaField)
+	}
+
+	pointer := err.JSONPointer()
+	if len(pointer) == 0 {
+		return errors.New(reason)
+	}
+	return fmt.Errorf("field %s: %s", strings.Join(pointer, "."), reason)
+}
@@ -0,0 +1,183 @@
+package openapi
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestValidator(t *testing.T) *Validator {
+	v, err := NewValidator(filepath.Join("testdata", "spec.yaml"))
+	require.NoError(t, err)
+	return v
+}
+
+func errorStrings(errs []error) []string {
+	var s []string
+	for _, err := range errs {
+		s = append(s, err.Error())
+	}
+	return s
+}
+
+func TestValidateRequest(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		body       string
+		violations []string
+	}{
+		{
+			name:   "valid request",
+			method: http.MethodGet,
+			target: "/v1/users/1?verbose=true",
+		},
+		{
+			name:   "valid body",
+			method: http.MethodPost,
+			target: "/v1/users",
+			body:   `{"name": "alice", "age": 30}`,
+		},
+		{
+			name:       "invalid path parameter",
+			method:     http.MethodGet,
+			target:     "/v1/users/abc",
+			violations: []string{`path parameter "id": value abc: an invalid integer: strconv.ParseFloat: parsing "abc": invalid syntax`},
+		},
+		{
+			name:       "invalid query parameter",
+			method:     http.MethodGet,
+			target:     "/v1/users/1?verbose=yes",
+			violations: []string{`query parameter "verbose": value yes: an invalid number: strconv.ParseBool: parsing "yes": invalid syntax`},
+		},
+		{
+			name:   "invalid body fields",
+			method: http.MethodPost,
+			target: "/v1/users",
+			body:   `{"age": -1}`,
+			violations: []string{
+				`request body: field name: property "name" is missing`,
+				`request body: field age: number must be at least 0`,
+			},
+		},
+		{
+			name:       "unknown path",
+			method:     http.MethodGet,
+			target:     "/v1/orders",
+			violations: []string{"GET /v1/orders: path is not described in the spec"},
+		},
+		{
+			name:       "unknown method",
+			method:     http.MethodDelete,
+			target:     "/v1/users/1",
+			violations: []string{"DELETE /v1/users/1: method is not described in the spec"},
+		},
+	}
+
+	v := newTestValidator(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "http://localhost:8080"+tt.target, strings.NewReader(tt.body))
+			if tt.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+
+			assert.ElementsMatch(t, tt.violations, errorStrings(v.ValidateRequest(req)))
+		})
+	}
+}
+
+func TestValidateRequestKeepsBody(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/v1/users", strings.NewReader(`{"name": "alice"}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	require.Empty(t, newTestValidator(t).ValidateRequest(req))
+
+	buf := new(strings.Builder)
+	_, err := io.Copy(buf, req.Body)
+	require.NoError(t, err)
+	assert.Equal(t, `{"name": "alice"}`, buf.String())
+}
+
+func TestValidateResponse(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		body       string
+		violations []string
+	}{
+		{
+			name:   "valid response",
+			status: http.StatusOK,
+			body:   `{"id": 1, "name": "alice"}`,
+		},
+		{
+			name:   "invalid fields",
+			status: http.StatusOK,
+			body:   `{"id": "1"}`,
+			violations: []string{
+				`response: field name: property "name" is missing`,
+				`response: field id: Field must be set to integer or not be present`,
+			},
+		},
+		{
+			name:       "undeclared status",
+			status:     http.StatusNotFound,
+			body:       `{}`,
+			violations: []string{"response: status is not supported"},
+		},
+	}
+
+	v := newTestValidator(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/v1/users/1", nil)
+			header := http.Header{"Content-Type": []string{"application/json"}}
+
+			assert.ElementsMatch(t, tt.violations, errorStrings(v.ValidateResponse(req, tt.status, header, []byte(tt.body))))
+		})
+	}
+}
+
+func TestCustomFormats(t *testing.T) {
+	spec := filepath.Join("testdata", "formats.yaml")
+	_, err := NewValidator(spec)
+	require.Error(t, err, "the formats are not defined yet")
+
+	DefineFormat("sku", func(value string) error {
+		if len(value) != 8 || !strings.HasPrefix(value, "SKU-") {
+			return errors.New("expected SKU- and 4 characters")
+		}
+		return nil
+	})
+	DefineFormat("supplier-code", func(value string) error {
+		if strings.ToUpper(value) != value {
+			return errors.New("expected upper case")
+		}
+		return nil
+	})
+	v, err := NewValidator(spec)
+	require.NoError(t, err)
+
+	assert.Empty(t, v.ValidateRequest(httptest.NewRequest(http.MethodGet, "/products/SKU-0001", nil)))
+	assert.Equal(t,
+		[]string{`path parameter "sku": string doesn't match the format "sku": expected SKU- and 4 characters`},
+		errorStrings(v.ValidateRequest(httptest.NewRequest(http.MethodGet, "/products/0001", nil))),
+	)
+
+	req := httptest.NewRequest(http.MethodGet, "/products/SKU-0001", nil)
+	header := http.Header{"Content-Type": []string{"application/json"}}
+	assert.Empty(t, v.ValidateResponse(req, http.StatusOK, header, []byte(`{"sku": "SKU-0001", "supplier": "ACME"}`)))
+	assert.ElementsMatch(t, []string{
+		`response: field sku: string doesn't match the format "sku": expected SKU- and 4 characters`,
+		`response: field supplier: string doesn't match the format "supplier-code": expected upper case`,
+	}, errorStrings(v.ValidateResponse(req, http.StatusOK, header, []byte(`{"sku": "SKU-1", "supplier": "acme"}`))))
+}
@@ -8,11 +8,14 @@ import (
 	"time"
 
 	"github.com/lamoda/gonkey/models"
+	"github.com/lamoda/gonkey/output"
 )
 
 type AllureReportOutput struct {
 	reportLocation string
 	allure         Allure
+	maxBodySize    int
+	formatters     output.BodyFormatters
 }
 
 func NewOutput(suiteName, reportLocation string) *AllureReportOutput {
@@ -28,10 +31,23 @@ func NewOutput(suiteName, reportLocation string) *AllureReportOutput {
 	return &AllureReportOutput{
 		reportLocation: reportLocation,
 		allure:         a,
+		formatters:     output.DefaultBodyFormatters(),
 	}
 }
 
+// SetMaxBodySize limits the size of the response body attached to the report (0 means no limit)
+func (o *AllureReportOutput) SetMaxBodySize(size int) {
+	o.maxBodySize = size
+}
+
+// SetBodyFormatters replaces the formatters of the bodies by content type, nil attaches the bodies as is
+func (o *AllureReportOutput) SetBodyFormatters(formatters output.BodyFormatters) {
+	o.formatters = formatters
+}
+
 func (o *AllureReportOutput) Process(t models.TestInterface, result *models.Result) error {
+	result = o.formatters.FormatResult(result)
+
 	testCase := o.allure.StartCase(t.GetName(), time.Now())
 	testCase.SetDescriptionOrDefaultValue(t.GetDescription(), "No description")
 	testCase.AddLabel("story", result.Path)
@@ -42,7 +58,7 @@ func (o *AllureReportOutput) Process(t models.TestInterface, result *models.Resu
 		"txt")
 	o.allure.AddAttachment(
 		*bytes.NewBufferString("Response"),
-		*bytes.NewBufferString(fmt.Sprintf(`Body: %s`, result.ResponseBody)),
+		*bytes.NewBufferString(fmt.Sprintf(`Body: %s`, output.TruncateBody(result.ResponseBody, o.maxBodySize))),
 		"txt")
 
 	for i, dbresult := range result.DatabaseResult {
@@ -55,9 +71,30 @@ func (o *AllureReportOutput) Process(t models.TestInterface, result *models.Resu
 				*bytes.NewBufferString(fmt.Sprintf("Db Response #%d", i+1)),
 				*bytes.NewBufferString(fmt.Sprintf(`Response: %s`, dbresult.Response)),
 				"txt")
+			if dbresult.Plan != "" {
+				o.allure.AddAttachment(
+					*bytes.NewBufferString(fmt.Sprintf("Db Plan #%d", i+1)),
+					*bytes.NewBufferString(dbresult.Plan),
+					"txt")
+			}
 		}
 	}
 
+	// the request is attached as a curl command to reproduce the failure outside of gonkey
+	if result.RequestCurl != "" && !result.Passed() {
+		o.allure.AddAttachment(
+			*bytes.NewBufferString("Curl"),
+			*bytes.NewBufferString(result.RequestCurl),
+			"txt")
+	}
+
+	if result.ServerLogs != "" && !result.Passed() {
+		o.allure.AddAttachment(
+			*bytes.NewBufferString("Server logs"),
+			*bytes.NewBufferString(result.ServerLogs),
+			"txt")
+	}
+
 	status, err := result.AllureStatus()
 	o.allure.EndCase(status, err, time.Now())
 
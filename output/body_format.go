@@ -0,0 +1,182 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"net/textproto"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/lamoda/gonkey/models"
+)
+
+// BodyFormatter pretty-prints a body for the outputs, an error makes the output show the body as is
+type BodyFormatter func(body string) (string, error)
+
+// BodyFormatters are the formatters of the bodies by media type, e.g. application/json.
+// The media types with the +json and +xml suffixes are formatted as JSON and XML if they have no formatter of their own.
+type BodyFormatters map[string]BodyFormatter
+
+// DefaultBodyFormatters indent JSON and XML and list the form-encoded values one per line,
+// more formatters can be added to the map, e.g. for protobuf
+func DefaultBodyFormatters() BodyFormatters {
+	return BodyFormatters{
+		"application/json":                  FormatJSON,
+		"application/xml":                   FormatXML,
+		"text/xml":                          FormatXML,
+		"application/x-www-form-urlencoded": FormatForm,
+	}
+}
+
+// Format formats the body by the media type of the content type, the body is returned as is
+// if the type is unknown or the body can't be formatted
+func (f BodyFormatters) Format(body, contentType string) string {
+	if body == "" || contentType == "" {
+		return body
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return body
+	}
+
+	formatter, ok := f[mediaType]
+	if !ok {
+		switch {
+		case strings.HasSuffix(mediaType, "+json"):
+			formatter, ok = f["application/json"]
+		case strings.HasSuffix(mediaType, "+xml"):
+			formatter, ok = f["application/xml"]
+		}
+	}
+	if !ok || formatter == nil {
+		return body
+	}
+
+	formatted, err := formatter(body)
+	if err != nil {
+		return body
+	}
+	return formatted
+}
+
+// FormatResult returns a copy of the result with the request and the response bodies formatted,
+// the original result is left intact
+func (f BodyFormatters) FormatResult(result *models.Result) *models.Result {
+	if len(f) == 0 || result == nil {
+		return result
+	}
+
+	formatted := *result
+	formatted.ResponseBody = f.Format(result.ResponseBody, result.ResponseContentType)
+	if result.Test != nil {
+		formatted.RequestBody = f.Format(result.RequestBody, requestContentType(result.Test))
+	}
+	return &formatted
+}
+
+// requestContentType looks up the Content-Type among the headers of the test regardless of the case
+func requestContentType(test models.TestInterface) string {
+	for name, value := range test.Headers() {
+		if textproto.CanonicalMIMEHeaderKey(name) == "Content-Type" {
+			return value
+		}
+	}
+	return ""
+}
+
+func FormatJSON(body string) (string, error) {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, []byte(body), "", "  "); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// FormatXML indents the elements, the prefixes of the namespaces are kept as they are in the body
+func FormatXML(body string) (string, error) {
+	decoder := xml.NewDecoder(strings.NewReader(body))
+	var buf bytes.Buffer
+	encoder := xml.NewEncoder(&buf)
+	encoder.Indent("", "  ")
+
+	for {
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+
+		switch t := token.(type) {
+		case xml.ProcInst:
+			// the encoder doesn't break the line after the declaration
+			if err := encoder.Flush(); err != nil {
+				return "", err
+			}
+			fmt.Fprintf(&buf, "<?%s %s?>\n", t.Target, t.Inst)
+			continue
+		case xml.CharData:
+			if len(bytes.TrimSpace(t)) == 0 {
+				continue
+			}
+		case xml.StartElement:
+			t.Name = prefixedName(t.Name)
+			attrs := make([]xml.Attr, len(t.Attr))
+			for i, attr := range t.Attr {
+				attrs[i] = xml.Attr{Name: prefixedName(attr.Name), Value: attr.Value}
+			}
+			t.Attr = attrs
+			token = t
+		case xml.EndElement:
+			t.Name = prefixedName(t.Name)
+			token = t
+		}
+
+		if err := encoder.EncodeToken(token); err != nil {
+			return "", err
+		}
+	}
+	if err := encoder.Flush(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// prefixedName puts the prefix into the local name, otherwise the encoder takes it for the namespace URI
+func prefixedName(name xml.Name) xml.Name {
+	if name.Space == "" {
+		return name
+	}
+	return xml.Name{Local: name.Space + ":" + name.Local}
+}
+
+// FormatForm lists the form-encoded values one per line sorted by key, the values are decoded
+func FormatForm(body string) (string, error) {
+	values, err := url.ParseQuery(body)
+	if err != nil {
+		return "", err
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for i, key := range keys {
+		for j, value := range values[key] {
+			if i > 0 || j > 0 {
+				b.WriteString("\n")
+			}
+			fmt.Fprintf(&b, "%s: %s", key, value)
+		}
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,75 @@
+package output
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lamoda/gonkey/models"
+	"github.com/lamoda/gonkey/testloader/yaml_file"
+)
+
+func TestFormatBody(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		contentType string
+		want        string
+	}{
+		{"json", `{"id":1,"tags":["a"]}`, "application/json; charset=utf-8", "{\n  \"id\": 1,\n  \"tags\": [\n    \"a\"\n  ]\n}"},
+		{"json suffix", `{"title":"Not Found"}`, "application/problem+json", "{\n  \"title\": \"Not Found\"\n}"},
+		{"invalid json", `{"id":`, "application/json", `{"id":`},
+		{
+			"xml",
+			`<?xml version="1.0"?><soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body><item id="1">a &amp; b</item></soap:Body></soap:Envelope>`,
+			"text/xml",
+			strings.Join([]string{
+				`<?xml version="1.0"?>`,
+				`<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/">`,
+				`  <soap:Body>`,
+				`    <item id="1">a &amp; b</item>`,
+				`  </soap:Body>`,
+				`</soap:Envelope>`,
+			}, "\n"),
+		},
+		{"invalid xml", `<a><b></a>`, "application/xml", `<a><b></a>`},
+		{"form", "name=John+Doe&city=New%20York&tag=a&tag=b", "application/x-www-form-urlencoded", "city: New York\nname: John Doe\ntag: a\ntag: b"},
+		{"unknown type", "\x08\x96\x01", "application/x-protobuf", "\x08\x96\x01"},
+		{"no content type", `{"id":1}`, "", `{"id":1}`},
+	}
+
+	formatters := DefaultBodyFormatters()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, formatters.Format(tt.body, tt.contentType))
+		})
+	}
+}
+
+func TestFormatResult(t *testing.T) {
+	formatters := DefaultBodyFormatters()
+	formatters["application/x-protobuf"] = func(body string) (string, error) {
+		return "decoded protobuf", nil
+	}
+
+	result := &models.Result{
+		Test: &yaml_file.Test{
+			TestDefinition: yaml_file.TestDefinition{
+				HeadersVal: map[string]string{"content-type": "application/x-www-form-urlencoded"},
+			},
+		},
+		RequestBody:         "a=1&b=2",
+		ResponseBody:        "\x08\x96\x01",
+		ResponseContentType: "application/x-protobuf",
+	}
+
+	formatted := formatters.FormatResult(result)
+	require.NotSame(t, result, formatted)
+	assert.Equal(t, "a: 1\nb: 2", formatted.RequestBody)
+	assert.Equal(t, "decoded protobuf", formatted.ResponseBody)
+	assert.Equal(t, "a=1&b=2", result.RequestBody)
+
+	assert.Same(t, result, BodyFormatters(nil).FormatResult(result))
+}
@@ -6,12 +6,14 @@ import (
 
 	"github.com/fatih/color"
 	"github.com/lamoda/gonkey/models"
+	"github.com/lamoda/gonkey/output"
 )
 
 const dotsPerLine = 80
 
 type ConsoleColoredOutput struct {
 	verbose       bool
+	maxBodySize   int
 	dots          int
 	coloredPrintf func(format string, a ...interface{})
 }
@@ -23,9 +25,21 @@ func NewOutput(verbose bool) *ConsoleColoredOutput {
 	}
 }
 
+// SetMaxBodySize limits the size of the response body shown in the output (0 means no limit)
+func (o *ConsoleColoredOutput) SetMaxBodySize(size int) {
+	o.maxBodySize = size
+}
+
 func (o *ConsoleColoredOutput) Process(t models.TestInterface, result *models.Result) error {
+	if status := t.GetStatus(); status == "skipped" || status == "broken" {
+		if t.GetReason() != "" || o.verbose {
+			o.coloredPrintf("\n%s %s: %s\n", color.YellowString(status), t.GetName(), t.GetReason())
+		}
+		return nil
+	}
+
 	if !result.Passed() || o.verbose {
-		text, err := renderResult(result)
+		text, err := renderResult(output.TruncateResult(result, o.maxBodySize))
 		if err != nil {
 			return err
 		}
@@ -62,9 +76,24 @@ Request:
 {{- range $key, $value := .Test.Cookies }}
       {{ $key }}: {{ $value }}
 {{- end }}
+{{- end }}
+{{- if .ActiveMocks }}
+      Mocks:
+{{- range $name := .ActiveMocks }} {{ cyan $name }}{{ end }}
+{{- end }}
+{{- if .SpiedRequests }}
+      Spied:
+{{- range $spied := .SpiedRequests }}
+      {{ $spied.Service }}: {{ cyan "%s %s" $spied.Method $spied.URL }} {{ if $spied.Status }}{{ $spied.Status }}{{ else }}{{ yellow "failed" }}{{ end }}
+{{- end }}
 {{- end }}
        Body:
 {{ if .RequestBody }}{{ cyan .RequestBody }}{{ else }}{{ cyan "<no body>" }}{{ end }}
+{{- if .RequestCurl }}
+
+       Curl:
+{{ cyan .RequestCurl }}
+{{- end }}
 
 Response:
      Status: {{ cyan .ResponseStatus }}
@@ -78,9 +107,20 @@ Response:
        Db Response #{{ inc $i }}:
 {{ range $value := $dbr.Response }}
 {{ yellow $value }}{{ end }}
+{{ if $dbr.Plan }}
+       Db Plan #{{ inc $i }}:
+{{ yellow $dbr.Plan }}
 {{ end }}
 {{ end }}
-
+{{ end }}
+{{ if and .ServerLogs (not .Passed) }}
+       Server logs:
+{{ yellow .ServerLogs }}
+{{ end }}
+{{ if .BodyDiff }}
+       Diff:
+{{ diff .BodyDiff }}
+{{ end }}
 {{ if .Errors }}
      Result: {{ danger "ERRORS!" }}
 
@@ -108,6 +148,7 @@ func templateFuncMap() template.FuncMap {
 		"yellow":  color.YellowString,
 		"danger":  color.New(color.FgHiWhite, color.BgRed).Sprint,
 		"success": color.New(color.FgHiWhite, color.BgGreen).Sprint,
+		"diff":    output.ColorizeDiff,
 		"inc":     func(i int) int { return i + 1 },
 	}
 }
@@ -122,3 +163,19 @@ func (o *ConsoleColoredOutput) ShowSummary(summary *models.Summary) {
 		summary.Total,
 	)
 }
+
+// ShowRepeatedTests prints the tallies of the tests with the repeat directive, see Runner.RepeatedTests
+func (o *ConsoleColoredOutput) ShowRepeatedTests(tests []models.RepeatedTest) {
+	if len(tests) == 0 {
+		return
+	}
+	o.coloredPrintf("\n%s", output.FormatRepeatedTests(tests))
+}
+
+// ShowSlowestTests prints the tests which took the longest time, see Runner.SlowestTests
+func (o *ConsoleColoredOutput) ShowSlowestTests(tests []models.TestDuration) {
+	if len(tests) == 0 {
+		return
+	}
+	o.coloredPrintf("\n%s", output.FormatSlowestTests(tests))
+}
@@ -0,0 +1,22 @@
+package output
+
+import (
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// ColorizeDiff colorizes the lines of the body diff of the result: the expected values ("-" lines) red
+// and the actual ones ("+" lines) green, the other lines are kept as is
+func ColorizeDiff(diff string) string {
+	lines := strings.Split(diff, "\n")
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "- "):
+			lines[i] = color.RedString("%s", line)
+		case strings.HasPrefix(line, "+ "):
+			lines[i] = color.GreenString("%s", line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
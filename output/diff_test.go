@@ -0,0 +1,22 @@
+package output
+
+import (
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestColorizeDiff(t *testing.T) {
+	noColor := color.NoColor
+	color.NoColor = false
+	defer func() { color.NoColor = noColor }()
+
+	assert.Equal(t,
+		"body #1:\n\x1b[31m- $.id: 1\x1b[0m\n\x1b[32m+ $.id: 2\x1b[0m",
+		ColorizeDiff("body #1:\n- $.id: 1\n+ $.id: 2"),
+	)
+
+	color.NoColor = true
+	assert.Equal(t, "- $.id: 1\n+ $.id: 2", ColorizeDiff("- $.id: 1\n+ $.id: 2"))
+}
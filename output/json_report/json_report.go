@@ -0,0 +1,125 @@
+package json_report
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/lamoda/gonkey/models"
+)
+
+// Report is the content of the JSON report
+type Report struct {
+	Tests []TestReport `json:"tests"`
+}
+
+type TestReport struct {
+	Name           string        `json:"name"`
+	File           string        `json:"file"`
+	Status         string        `json:"status"`
+	Reason         string        `json:"reason,omitempty"`
+	ResponseStatus int           `json:"responseStatus,omitempty"`
+	Checks         []CheckReport `json:"checks"`
+	Errors         []string      `json:"errors,omitempty"`
+	// DbPlans are the plans of the DB checks captured for the slow tests
+	DbPlans []DbPlanReport `json:"dbPlans,omitempty"`
+	// SpiedRequests are the requests of the service proxied by the spy mocks
+	SpiedRequests []SpiedRequestReport `json:"spiedRequests,omitempty"`
+}
+
+type SpiedRequestReport struct {
+	Service string `json:"service"`
+	Method  string `json:"method"`
+	URL     string `json:"url"`
+	Body    string `json:"body,omitempty"`
+	Status  int    `json:"status"`
+}
+
+type DbPlanReport struct {
+	Query string `json:"query"`
+	Plan  string `json:"plan"`
+}
+
+type CheckReport struct {
+	Checker string   `json:"checker"`
+	Passed  bool     `json:"passed"`
+	Errors  []string `json:"errors,omitempty"`
+}
+
+// JsonReportOutput writes the results of the tests with the outcomes of every checker to the JSON file
+type JsonReportOutput struct {
+	mu     sync.Mutex
+	path   string
+	report Report
+}
+
+func NewOutput(path string) *JsonReportOutput {
+	return &JsonReportOutput{
+		path:   path,
+		report: Report{Tests: []TestReport{}},
+	}
+}
+
+func (o *JsonReportOutput) Process(t models.TestInterface, result *models.Result) error {
+	status, _ := result.AllureStatus()
+
+	testReport := TestReport{
+		Name:           t.GetName(),
+		File:           t.GetFileName(),
+		Status:         status,
+		Reason:         t.GetReason(),
+		ResponseStatus: result.ResponseStatusCode,
+		Checks:         make([]CheckReport, 0, len(result.Checks)),
+		Errors:         errorStrings(result.Errors),
+	}
+	for _, check := range result.Checks {
+		testReport.Checks = append(testReport.Checks, CheckReport{
+			Checker: check.Checker,
+			Passed:  check.Passed(),
+			Errors:  errorStrings(check.Errors),
+		})
+	}
+
+	for _, dbResult := range result.DatabaseResult {
+		if dbResult.Plan != "" {
+			testReport.DbPlans = append(testReport.DbPlans, DbPlanReport{Query: dbResult.Query, Plan: dbResult.Plan})
+		}
+	}
+
+	for _, spied := range result.SpiedRequests {
+		testReport.SpiedRequests = append(testReport.SpiedRequests, SpiedRequestReport(spied))
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.report.Tests = append(o.report.Tests, testReport)
+	return nil
+}
+
+// Finalize writes the report to the file
+func (o *JsonReportOutput) Finalize() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	data, err := json.MarshalIndent(o.report, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(o.path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(o.path, data, 0644)
+}
+
+func errorStrings(errs []error) []string {
+	if len(errs) == 0 {
+		return nil
+	}
+	res := make([]string, len(errs))
+	for i, err := range errs {
+		res[i] = err.Error()
+	}
+	return res
+}
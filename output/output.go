@@ -1,9 +1,218 @@
 package output
 
 import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
 	"github.com/lamoda/gonkey/models"
 )
 
 type OutputInterface interface {
 	Process(models.TestInterface, *models.Result) error
 }
+
+// TruncateBody cuts the body to maxSize bytes for reporting and appends a marker
+// saying how much was cut off. Zero or negative maxSize means no limit.
+func TruncateBody(body string, maxSize int) string {
+	if maxSize <= 0 || len(body) <= maxSize {
+		return body
+	}
+
+	// don't split a multibyte character
+	size := maxSize
+	for size > 0 && !utf8.RuneStart(body[size]) {
+		size--
+	}
+
+	return fmt.Sprintf("%s\n...truncated (%d of %d bytes shown)", body[:size], size, len(body))
+}
+
+// TruncateResult returns a copy of the result with the response body truncated to maxSize bytes.
+// The original result is left intact.
+func TruncateResult(result *models.Result, maxSize int) *models.Result {
+	if maxSize <= 0 || len(result.ResponseBody) <= maxSize {
+		return result
+	}
+
+	truncated := *result
+	truncated.ResponseBody = TruncateBody(result.ResponseBody, maxSize)
+	return &truncated
+}
+
+// MaskedValue replaces the secrets in the outputs
+const MaskedValue = "******"
+
+// MaskResult returns a copy of the result with the secrets replaced by MaskedValue in the request,
+// the response, the errors and the logs. The original result is left intact.
+func MaskResult(result *models.Result, secrets []string) *models.Result {
+	replacer := secretsReplacer(secrets)
+	if replacer == nil || result == nil {
+		return result
+	}
+
+	masked := *result
+	masked.Path = replacer.Replace(result.Path)
+	masked.Query = replacer.Replace(result.Query)
+	masked.RequestBody = replacer.Replace(result.RequestBody)
+	masked.RequestCurl = replacer.Replace(result.RequestCurl)
+	masked.ResponseBody = replacer.Replace(result.ResponseBody)
+	masked.BodyDiff = replacer.Replace(result.BodyDiff)
+	masked.ServerLogs = replacer.Replace(result.ServerLogs)
+	masked.ResponseHeaders = maskHeaders(replacer, result.ResponseHeaders)
+	masked.ResponseTrailers = maskHeaders(replacer, result.ResponseTrailers)
+
+	masked.Errors = nil
+	for _, err := range result.Errors {
+		masked.Errors = append(masked.Errors, MaskError(err, secrets))
+	}
+
+	masked.SpiedRequests = nil
+	for _, spied := range result.SpiedRequests {
+		spied.URL = replacer.Replace(spied.URL)
+		spied.Body = replacer.Replace(spied.Body)
+		masked.SpiedRequests = append(masked.SpiedRequests, spied)
+	}
+
+	masked.DatabaseResult = nil
+	for _, dbResult := range result.DatabaseResult {
+		response := make([]string, len(dbResult.Response))
+		for i, row := range dbResult.Response {
+			response[i] = replacer.Replace(row)
+		}
+		masked.DatabaseResult = append(masked.DatabaseResult, models.DatabaseResult{
+			Query:    replacer.Replace(dbResult.Query),
+			Response: response,
+			Plan:     replacer.Replace(dbResult.Plan),
+		})
+	}
+
+	masked.Checks = nil
+	for _, check := range result.Checks {
+		errs := make([]error, len(check.Errors))
+		for i, err := range check.Errors {
+			errs[i] = MaskError(err, secrets)
+		}
+		masked.Checks = append(masked.Checks, models.CheckResult{Checker: check.Checker, Errors: errs})
+	}
+
+	if result.Test != nil {
+		test := result.Test.Clone()
+		test.SetPath(replacer.Replace(test.Path()))
+		test.SetQuery(replacer.Replace(test.ToQuery()))
+		test.SetRequest(replacer.Replace(test.GetRequest()))
+		headers := make(map[string]string, len(test.Headers()))
+		for name, value := range test.Headers() {
+			headers[name] = replacer.Replace(value)
+		}
+		test.SetHeaders(headers)
+		masked.Test = test
+	}
+	return &masked
+}
+
+// MaskError returns the error with the secrets replaced by MaskedValue in its message
+func MaskError(err error, secrets []string) error {
+	replacer := secretsReplacer(secrets)
+	if err == nil || replacer == nil {
+		return err
+	}
+
+	msg := replacer.Replace(err.Error())
+	if msg == err.Error() {
+		return err
+	}
+	return errors.New(msg)
+}
+
+// MaskString returns the string with the secrets replaced by MaskedValue
+func MaskString(s string, secrets []string) string {
+	replacer := secretsReplacer(secrets)
+	if replacer == nil {
+		return s
+	}
+	return replacer.Replace(s)
+}
+
+// secretsReplacer replaces the longer secrets first, so a secret containing another one is masked entirely,
+// nil is returned if there is nothing to mask
+func secretsReplacer(secrets []string) *strings.Replacer {
+	sorted := make([]string, 0, len(secrets))
+	for _, s := range secrets {
+		if s != "" {
+			sorted = append(sorted, s)
+		}
+	}
+	if len(sorted) == 0 {
+		return nil
+	}
+	sort.Slice(sorted, func(i, j int) bool { return len(sorted[i]) > len(sorted[j]) })
+
+	pairs := make([]string, 0, 2*len(sorted))
+	for _, s := range sorted {
+		pairs = append(pairs, s, MaskedValue)
+	}
+	return strings.NewReplacer(pairs...)
+}
+
+func maskHeaders(replacer *strings.Replacer, headers map[string][]string) map[string][]string {
+	if headers == nil {
+		return nil
+	}
+
+	masked := make(map[string][]string, len(headers))
+	for name, values := range headers {
+		maskedValues := make([]string, len(values))
+		for i, value := range values {
+			maskedValues[i] = replacer.Replace(value)
+		}
+		masked[name] = maskedValues
+	}
+	return masked
+}
+
+// FormatRepeatedTests tallies the iterations of the repeated tests, one test per line
+func FormatRepeatedTests(tests []models.RepeatedTest) string {
+	if len(tests) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("Repeated tests:\n")
+	for _, test := range tests {
+		fmt.Fprintf(&b, "%s: %d passed, %d failed of %d runs", test.Name, test.Passed, test.Failed(), test.Runs)
+		if test.Runs < test.Count {
+			fmt.Fprintf(&b, ", stopped on failure, %d runs requested", test.Count)
+		}
+		if test.FileName != "" {
+			fmt.Fprintf(&b, " (%s)", test.FileName)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// FormatSlowestTests lists the tests with their durations, one per line, the time of the fixtures
+// is shown separately if they were loaded
+func FormatSlowestTests(tests []models.TestDuration) string {
+	if len(tests) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("Slowest tests:\n")
+	for i, test := range tests {
+		fmt.Fprintf(&b, "%d. %s %s", i+1, test.Duration.Round(time.Millisecond), test.Name)
+		if test.FileName != "" {
+			fmt.Fprintf(&b, " (%s)", test.FileName)
+		}
+		if test.FixturesDuration > 0 {
+			fmt.Fprintf(&b, ", fixtures %s", test.FixturesDuration.Round(time.Millisecond))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
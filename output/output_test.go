@@ -0,0 +1,114 @@
+package output
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lamoda/gonkey/models"
+	"github.com/lamoda/gonkey/testloader/yaml_file"
+)
+
+func TestTruncateBody(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		maxSize int
+		want    string
+	}{
+		{"no limit", "abcdef", 0, "abcdef"},
+		{"short body", "abc", 3, "abc"},
+		{"long body", "abcdef", 4, "abcd\n...truncated (4 of 6 bytes shown)"},
+		{"multibyte character", "абв", 3, "а\n...truncated (2 of 6 bytes shown)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, TruncateBody(tt.body, tt.maxSize))
+		})
+	}
+}
+
+func TestTruncateResultKeepsOriginal(t *testing.T) {
+	result := &models.Result{ResponseBody: "abcdef"}
+
+	truncated := TruncateResult(result, 2)
+
+	assert.Equal(t, "ab\n...truncated (2 of 6 bytes shown)", truncated.ResponseBody)
+	assert.Equal(t, "abcdef", result.ResponseBody)
+}
+
+func TestMaskResult(t *testing.T) {
+	test := &yaml_file.Test{
+		TestDefinition: yaml_file.TestDefinition{
+			HeadersVal: map[string]string{"Authorization": "Bearer s3cr3t-token"},
+		},
+		Request: `{"password": "pwd"}`,
+	}
+	result := &models.Result{
+		Test:            test,
+		RequestBody:     `{"password": "pwd"}`,
+		RequestCurl:     `curl -H 'Authorization: Bearer s3cr3t-token'`,
+		ResponseBody:    `{"token": "s3cr3t-token"}`,
+		ResponseHeaders: map[string][]string{"X-Token": {"s3cr3t-token"}},
+		Errors:          []error{errors.New("token s3cr3t-token is expired")},
+		Checks: []models.CheckResult{
+			{Checker: "response_body", Errors: []error{errors.New("token s3cr3t-token is expired")}},
+		},
+	}
+
+	// the longer secret is masked entirely
+	masked := MaskResult(result, []string{"s3cr3t", "s3cr3t-token", "pwd", ""})
+
+	assert.Equal(t, `{"password": "******"}`, masked.RequestBody)
+	assert.Equal(t, `{"token": "******"}`, masked.ResponseBody)
+	assert.Equal(t, `curl -H 'Authorization: Bearer ******'`, masked.RequestCurl)
+	assert.Equal(t, map[string][]string{"X-Token": {"******"}}, masked.ResponseHeaders)
+	assert.EqualError(t, masked.Errors[0], "token ****** is expired")
+	assert.EqualError(t, masked.Checks[0].Errors[0], "token ****** is expired")
+	assert.Equal(t, "Bearer ******", masked.Test.Headers()["Authorization"])
+	assert.Equal(t, `{"password": "******"}`, masked.Test.GetRequest())
+
+	// the original result is left intact
+	assert.Equal(t, `{"token": "s3cr3t-token"}`, result.ResponseBody)
+	assert.Equal(t, "Bearer s3cr3t-token", result.Test.Headers()["Authorization"])
+
+	assert.Same(t, result, MaskResult(result, nil))
+}
+
+func TestFormatSlowestTests(t *testing.T) {
+	tests := []models.TestDuration{
+		{Name: "create order", FileName: "cases/orders.yaml", Duration: 1520400 * time.Microsecond, FixturesDuration: 320 * time.Millisecond},
+		{Name: "list orders", Duration: 45 * time.Millisecond},
+	}
+
+	assert.Equal(t,
+		"Slowest tests:\n"+
+			"1. 1.52s create order (cases/orders.yaml), fixtures 320ms\n"+
+			"2. 45ms list orders\n",
+		FormatSlowestTests(tests),
+	)
+	assert.Equal(t, "", FormatSlowestTests(nil))
+}
+
+func TestFormatRepeatedTests(t *testing.T) {
+	tests := []models.RepeatedTest{
+		{Name: "create order", FileName: "cases/orders.yaml", Count: 50, Runs: 50, Passed: 48},
+		{Name: "pay order", Count: 50, Runs: 3, Passed: 2},
+	}
+
+	assert.Equal(t,
+		"Repeated tests:\n"+
+			"create order: 48 passed, 2 failed of 50 runs (cases/orders.yaml)\n"+
+			"pay order: 2 passed, 1 failed of 3 runs, stopped on failure, 50 runs requested\n",
+		FormatRepeatedTests(tests),
+	)
+	assert.Equal(t, "", FormatRepeatedTests(nil))
+}
+
+func TestMaskString(t *testing.T) {
+	assert.Equal(t, "token=******&user=bob", MaskString("token=s3cr3t&user=bob", []string{"s3cr3t", ""}))
+	assert.Equal(t, "nothing to mask", MaskString("nothing to mask", nil))
+}
@@ -0,0 +1,287 @@
+package output
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tidwall/gjson"
+
+	"github.com/lamoda/gonkey/models"
+)
+
+// RedactedValue replaces the values matched by the redaction rules in the outputs
+const RedactedValue = "***"
+
+// anyDepth is the segment of the JSON path matching the value itself and all the values nested in it ($..email)
+const anyDepth = ".."
+
+// Redaction removes the personal data from the results passed to the outputs, e.g. the emails or the card numbers.
+// The checks are made before, so the redaction doesn't affect them.
+type Redaction struct {
+	jsonPaths [][]string
+	patterns  []*regexp.Regexp
+}
+
+// NewRedaction parses the redaction rules: the rules starting with $ are JSON paths of the values of the bodies,
+// e.g. $.customer.email, $.cards[*].number or $..email, the other rules are regular expressions matched against
+// the bodies, the diffs and the errors, only the groups are replaced if the expression has them.
+// Nil is returned if there are no rules.
+func NewRedaction(rules []string) (*Redaction, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+
+	r := &Redaction{}
+	for _, rule := range rules {
+		if strings.HasPrefix(rule, "$") {
+			path, err := parseRedactionPath(rule)
+			if err != nil {
+				return nil, fmt.Errorf("invalid redaction rule %s: %s", rule, err)
+			}
+			r.jsonPaths = append(r.jsonPaths, path)
+			continue
+		}
+
+		pattern, err := regexp.Compile(rule)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction rule %s: %s", rule, err)
+		}
+		r.patterns = append(r.patterns, pattern)
+	}
+	return r, nil
+}
+
+// parseRedactionPath splits the JSON path into the names of the fields, the indexes of the items,
+// * for any field or item and anyDepth
+func parseRedactionPath(path string) ([]string, error) {
+	var segments []string
+	rest := strings.TrimPrefix(path, "$")
+	for rest != "" {
+		switch {
+		case strings.HasPrefix(rest, anyDepth):
+			segments = append(segments, anyDepth)
+			rest = rest[1:]
+		case rest[0] == '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			if end == 0 {
+				return nil, errors.New("empty field name")
+			}
+			segments = append(segments, rest[1:end+1])
+			rest = rest[end+1:]
+		case rest[0] == '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, errors.New("unclosed [")
+			}
+			segments = append(segments, strings.Trim(rest[1:end], `'"`))
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("unexpected %q", rest)
+		}
+	}
+	if len(segments) == 0 || segments[len(segments)-1] == anyDepth {
+		return nil, errors.New("the path must end with a field")
+	}
+	return segments, nil
+}
+
+// RedactResult returns a copy of the result with the redaction rules applied to the request, the response,
+// the DB checks, the diff, the errors and the logs. The original result is left intact.
+func (r *Redaction) RedactResult(result *models.Result) *models.Result {
+	if r == nil || result == nil {
+		return result
+	}
+
+	// the string values at the JSON paths are masked in the diff and the errors as well
+	var values []string
+	redacted := *result
+	redacted.RequestBody = r.redactBody(result.RequestBody, &values)
+	redacted.ResponseBody = r.redactBody(result.ResponseBody, &values)
+
+	redacted.DatabaseResult = nil
+	for _, dbResult := range result.DatabaseResult {
+		response := make([]string, len(dbResult.Response))
+		for i, row := range dbResult.Response {
+			response[i] = r.redactBody(row, &values)
+		}
+		dbResult.Response = response
+		redacted.DatabaseResult = append(redacted.DatabaseResult, dbResult)
+	}
+
+	redacted.SpiedRequests = nil
+	for _, spied := range result.SpiedRequests {
+		spied.Body = r.redactBody(spied.Body, &values)
+		redacted.SpiedRequests = append(redacted.SpiedRequests, spied)
+	}
+
+	if result.Test != nil {
+		test := result.Test.Clone()
+		test.SetRequest(r.redactBody(test.GetRequest(), &values))
+		redacted.Test = test
+	}
+
+	replacer := valuesReplacer(values)
+	redacted.RequestCurl = r.redactText(replacer, result.RequestCurl)
+	redacted.BodyDiff = r.redactText(replacer, result.BodyDiff)
+	redacted.ServerLogs = r.redactText(replacer, result.ServerLogs)
+
+	redacted.Errors = nil
+	for _, err := range result.Errors {
+		redacted.Errors = append(redacted.Errors, r.redactError(replacer, err))
+	}
+	redacted.Checks = nil
+	for _, check := range result.Checks {
+		errs := make([]error, len(check.Errors))
+		for i, err := range check.Errors {
+			errs[i] = r.redactError(replacer, err)
+		}
+		redacted.Checks = append(redacted.Checks, models.CheckResult{Checker: check.Checker, Errors: errs})
+	}
+	return &redacted
+}
+
+// redactBody replaces the values at the JSON paths of the JSON body with RedactedValue keeping the rest
+// of the body as is and applies the regular expressions, the redacted strings are added to values
+func (r *Redaction) redactBody(body string, values *[]string) string {
+	if len(r.jsonPaths) != 0 && gjson.Valid(body) {
+		var spans [][2]int
+		for _, path := range r.jsonPaths {
+			collectSpans(body, 0, path, &spans)
+		}
+		for _, span := range spans {
+			if value := gjson.Parse(body[span[0]:span[1]]); value.Type == gjson.String && value.Str != "" {
+				*values = append(*values, value.Str)
+			}
+		}
+		body = replaceSpans(body, spans, strconv.Quote(RedactedValue))
+	}
+	return r.redactText(nil, body)
+}
+
+// collectSpans collects the positions of the values at the path in raw, offset is the position of raw in the body
+func collectSpans(raw string, offset int, path []string, spans *[][2]int) {
+	if len(path) == 0 {
+		*spans = append(*spans, [2]int{offset, offset + len(raw)})
+		return
+	}
+
+	// ForEach calls the iterator with the value itself for the scalars
+	value := gjson.Parse(raw)
+	if !value.IsObject() && !value.IsArray() {
+		if path[0] == anyDepth {
+			collectSpans(raw, offset, path[1:], spans)
+		}
+		return
+	}
+	if path[0] == anyDepth {
+		collectSpans(raw, offset, path[1:], spans)
+		value.ForEach(func(_, child gjson.Result) bool {
+			collectSpans(child.Raw, offset+child.Index, path, spans)
+			return true
+		})
+		return
+	}
+
+	i := 0
+	value.ForEach(func(key, child gjson.Result) bool {
+		match := path[0] == "*"
+		if value.IsArray() {
+			match = match || path[0] == strconv.Itoa(i)
+		} else {
+			match = match || key.String() == path[0]
+		}
+		i++
+		if match {
+			collectSpans(child.Raw, offset+child.Index, path[1:], spans)
+		}
+		return true
+	})
+}
+
+// replaceSpans replaces the spans of the body with the replacement, the spans overlapping the replaced ones
+// are skipped
+func replaceSpans(body string, spans [][2]int, replacement string) string {
+	if len(spans) == 0 {
+		return body
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i][0] < spans[j][0] })
+
+	var b strings.Builder
+	pos := 0
+	for _, span := range spans {
+		if span[0] < pos {
+			continue
+		}
+		b.WriteString(body[pos:span[0]])
+		b.WriteString(replacement)
+		pos = span[1]
+	}
+	b.WriteString(body[pos:])
+	return b.String()
+}
+
+// redactText masks the values with the replacer and applies the regular expressions
+func (r *Redaction) redactText(replacer *strings.Replacer, text string) string {
+	if replacer != nil {
+		text = replacer.Replace(text)
+	}
+	for _, pattern := range r.patterns {
+		text = redactMatches(pattern, text)
+	}
+	return text
+}
+
+func (r *Redaction) redactError(replacer *strings.Replacer, err error) error {
+	if err == nil {
+		return nil
+	}
+	msg := r.redactText(replacer, err.Error())
+	if msg == err.Error() {
+		return err
+	}
+	return errors.New(msg)
+}
+
+// redactMatches replaces the matches of the pattern, or their groups if the pattern has groups
+func redactMatches(pattern *regexp.Regexp, text string) string {
+	matches := pattern.FindAllStringSubmatchIndex(text, -1)
+	if matches == nil {
+		return text
+	}
+
+	var spans [][2]int
+	for _, match := range matches {
+		if len(match) == 2 {
+			spans = append(spans, [2]int{match[0], match[1]})
+			continue
+		}
+		for i := 2; i < len(match); i += 2 {
+			if match[i] >= 0 {
+				spans = append(spans, [2]int{match[i], match[i+1]})
+			}
+		}
+	}
+	return replaceSpans(text, spans, RedactedValue)
+}
+
+// valuesReplacer replaces the longer values first like secretsReplacer, nil is returned if there is nothing to mask
+func valuesReplacer(values []string) *strings.Replacer {
+	if len(values) == 0 {
+		return nil
+	}
+	sorted := append([]string(nil), values...)
+	sort.Slice(sorted, func(i, j int) bool { return len(sorted[i]) > len(sorted[j]) })
+
+	pairs := make([]string, 0, 2*len(sorted))
+	for _, value := range sorted {
+		pairs = append(pairs, value, RedactedValue)
+	}
+	return strings.NewReplacer(pairs...)
+}
@@ -0,0 +1,140 @@
+package output
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lamoda/gonkey/models"
+	"github.com/lamoda/gonkey/testloader/yaml_file"
+)
+
+func TestRedactBodyJSONPaths(t *testing.T) {
+	tests := []struct {
+		name string
+		rule string
+		body string
+		want string
+	}{
+		{
+			name: "field",
+			rule: "$.customer.email",
+			body: `{"customer": {"email": "john@example.com", "name": "John"}}`,
+			want: `{"customer": {"email": "***", "name": "John"}}`,
+		},
+		{
+			name: "all items",
+			rule: "$.cards[*].number",
+			body: `{"cards": [{"number": 4111111111111111}, {"number": "5500000000000004"}]}`,
+			want: `{"cards": [{"number": "***"}, {"number": "***"}]}`,
+		},
+		{
+			name: "item",
+			rule: "$.cards[1].number",
+			body: `{"cards": [{"number": "4111"}, {"number": "5500"}]}`,
+			want: `{"cards": [{"number": "4111"}, {"number": "***"}]}`,
+		},
+		{
+			name: "any depth",
+			rule: "$..email",
+			body: `{"email": "a@example.com", "orders": [{"customer": {"email": "b@example.com"}}]}`,
+			want: `{"email": "***", "orders": [{"customer": {"email": "***"}}]}`,
+		},
+		{
+			name: "whole object",
+			rule: "$.customer",
+			body: "{\n  \"customer\": {\"email\": \"john@example.com\"},\n  \"id\": 1\n}",
+			want: "{\n  \"customer\": \"***\",\n  \"id\": 1\n}",
+		},
+		{
+			name: "missing field",
+			rule: "$.customer.phone",
+			body: `{"customer": {"email": "john@example.com"}}`,
+			want: `{"customer": {"email": "john@example.com"}}`,
+		},
+		{
+			name: "not JSON",
+			rule: "$.email",
+			body: `email=john@example.com`,
+			want: `email=john@example.com`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			redaction, err := NewRedaction([]string{tt.rule})
+			require.NoError(t, err)
+
+			result := redaction.RedactResult(&models.Result{ResponseBody: tt.body})
+			assert.Equal(t, tt.want, result.ResponseBody)
+		})
+	}
+}
+
+func TestRedactPatterns(t *testing.T) {
+	redaction, err := NewRedaction([]string{`\b\d{12}(\d{4})\b`, `[\w.]+@example\.com`})
+	require.NoError(t, err)
+
+	result := redaction.RedactResult(&models.Result{
+		ResponseBody: "card 411111111111 1111, card 4111111111111111, john@example.com",
+		Errors:       []error{errors.New("unexpected email john.doe@example.com")},
+	})
+	assert.Equal(t, "card 411111111111 1111, card 411111111111***, ***", result.ResponseBody)
+	assert.Equal(t, []error{errors.New("unexpected email ***")}, result.Errors)
+}
+
+func TestRedactResult(t *testing.T) {
+	redaction, err := NewRedaction([]string{"$.email"})
+	require.NoError(t, err)
+
+	test := &yaml_file.Test{Request: `{"email": "john@example.com"}`}
+	bodyErr := errors.New("at path $.email values do not match:\n     expected: jane@example.com\n       actual: john@example.com")
+	result := &models.Result{
+		Test:         test,
+		RequestBody:  `{"email": "john@example.com"}`,
+		ResponseBody: `{"email": "john@example.com", "id": 1}`,
+		BodyDiff:     `-  "email": "jane@example.com"` + "\n" + `+  "email": "john@example.com"`,
+		Errors:       []error{bodyErr},
+		Checks:       []models.CheckResult{{Checker: "response_body", Errors: []error{bodyErr}}},
+		DatabaseResult: []models.DatabaseResult{
+			{Query: "SELECT email FROM customers", Response: []string{`{"email": "john@example.com"}`}},
+		},
+	}
+
+	redacted := redaction.RedactResult(result)
+
+	assert.Equal(t, `{"email": "***"}`, redacted.RequestBody)
+	assert.Equal(t, `{"email": "***", "id": 1}`, redacted.ResponseBody)
+	assert.Equal(t, `{"email": "***"}`, redacted.Test.GetRequest())
+	assert.Equal(t, []string{`{"email": "***"}`}, redacted.DatabaseResult[0].Response)
+	// the values found at the paths are masked everywhere
+	assert.Equal(t, `-  "email": "jane@example.com"`+"\n"+`+  "email": "***"`, redacted.BodyDiff)
+	redactedErr := errors.New("at path $.email values do not match:\n     expected: jane@example.com\n       actual: ***")
+	assert.Equal(t, []error{redactedErr}, redacted.Errors)
+	assert.Equal(t, []error{redactedErr}, redacted.Checks[0].Errors)
+
+	// the original result is left intact
+	assert.Equal(t, `{"email": "john@example.com", "id": 1}`, result.ResponseBody)
+	assert.Equal(t, `{"email": "john@example.com"}`, test.GetRequest())
+	assert.Equal(t, []error{bodyErr}, result.Errors)
+}
+
+func TestNewRedactionErrors(t *testing.T) {
+	for rule, err := range map[string]string{
+		"$.":           "invalid redaction rule $.: empty field name",
+		"$.items[0":    "invalid redaction rule $.items[0: unclosed [",
+		"$..":          "invalid redaction rule $..: empty field name",
+		"$":            "invalid redaction rule $: the path must end with a field",
+		"card-(\\d{4}": "invalid redaction rule card-(\\d{4}: error parsing regexp: missing closing ): `card-(\\d{4}`",
+	} {
+		_, actual := NewRedaction([]string{rule})
+		assert.EqualError(t, actual, err, rule)
+	}
+
+	redaction, err := NewRedaction(nil)
+	require.NoError(t, err)
+	assert.Nil(t, redaction)
+	result := &models.Result{ResponseBody: "{}"}
+	assert.Same(t, result, redaction.RedactResult(result))
+}
@@ -0,0 +1,49 @@
+package output
+
+import (
+	"sync"
+
+	"github.com/lamoda/gonkey/models"
+)
+
+// Reporter receives the result of every test as soon as the test finishes, e.g. to stream the results
+// to a live dashboard or to alert on the first failures. Unlike the outputs, the reporter can't fail the run.
+// The test of the result is result.Test, the results are masked and redacted the same way as for the outputs.
+type Reporter interface {
+	OnTestResult(*models.Result)
+}
+
+// ReporterFunc is the function receiving the results of the tests as Reporter
+type ReporterFunc func(*models.Result)
+
+func (f ReporterFunc) OnTestResult(result *models.Result) {
+	f(result)
+}
+
+// SyncReporter serializes the calls of the reporter, a runner calls its reporters from the goroutine
+// running its tests, so the reporter shared by several runners in parallel (e.g. by the parallel
+// Go tests) must be wrapped once and the wrapper passed to all of them
+func SyncReporter(reporter Reporter) Reporter {
+	return &syncReporter{reporter: reporter}
+}
+
+type syncReporter struct {
+	mu       sync.Mutex
+	reporter Reporter
+}
+
+func (r *syncReporter) OnTestResult(result *models.Result) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reporter.OnTestResult(result)
+}
+
+// OutputReporter passes the results of the tests to the output as they are reported, the errors
+// of the output are passed to onError if it's set
+func OutputReporter(o OutputInterface, onError func(error)) Reporter {
+	return ReporterFunc(func(result *models.Result) {
+		if err := o.Process(result.Test, result); err != nil && onError != nil {
+			onError(err)
+		}
+	})
+}
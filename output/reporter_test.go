@@ -0,0 +1,54 @@
+package output
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lamoda/gonkey/models"
+	"github.com/lamoda/gonkey/testloader/yaml_file"
+)
+
+func TestSyncReporter(t *testing.T) {
+	reported := 0
+	reporter := SyncReporter(ReporterFunc(func(*models.Result) {
+		reported++
+	}))
+
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				reporter.OnTestResult(&models.Result{})
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1000, reported)
+}
+
+func TestOutputReporter(t *testing.T) {
+	test := &yaml_file.Test{TestDefinition: yaml_file.TestDefinition{Name: "orders"}}
+	var processed []string
+	var errs []error
+	o := processFunc(func(test models.TestInterface, _ *models.Result) error {
+		processed = append(processed, test.GetName())
+		return errors.New("output failed")
+	})
+
+	OutputReporter(o, func(err error) { errs = append(errs, err) }).OnTestResult(&models.Result{Test: test})
+
+	assert.Equal(t, []string{"orders"}, processed)
+	assert.Equal(t, []error{errors.New("output failed")}, errs)
+}
+
+type processFunc func(models.TestInterface, *models.Result) error
+
+func (f processFunc) Process(test models.TestInterface, result *models.Result) error {
+	return f(test, result)
+}
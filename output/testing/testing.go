@@ -6,17 +6,31 @@ import (
 	"text/template"
 
 	"github.com/lamoda/gonkey/models"
+	"github.com/lamoda/gonkey/output"
 )
 
-type TestingOutput struct{}
+type TestingOutput struct {
+	maxBodySize int
+	formatters  output.BodyFormatters
+}
 
 func NewOutput() *TestingOutput {
-	return &TestingOutput{}
+	return &TestingOutput{formatters: output.DefaultBodyFormatters()}
+}
+
+// SetMaxBodySize limits the size of the response body shown in the output (0 means no limit)
+func (o *TestingOutput) SetMaxBodySize(size int) {
+	o.maxBodySize = size
+}
+
+// SetBodyFormatters replaces the formatters of the bodies by content type, nil shows the bodies as is
+func (o *TestingOutput) SetBodyFormatters(formatters output.BodyFormatters) {
+	o.formatters = formatters
 }
 
 func (o *TestingOutput) Process(t models.TestInterface, result *models.Result) error {
 	if !result.Passed() {
-		text, err := renderResult(result)
+		text, err := renderResult(output.TruncateResult(o.formatters.FormatResult(result), o.maxBodySize))
 		if err != nil {
 			return err
 		}
@@ -47,9 +61,24 @@ Request:
 {{- range $key, $value := .Test.Cookies }}
       {{ $key }}: {{ $value }}
 {{- end }}
+{{- end }}
+{{- if .ActiveMocks }}
+      Mocks:
+{{- range $name := .ActiveMocks }} {{ $name }}{{ end }}
+{{- end }}
+{{- if .SpiedRequests }}
+      Spied:
+{{- range $spied := .SpiedRequests }}
+      {{ $spied.Service }}: {{ $spied.Method }} {{ $spied.URL }} {{ if $spied.Status }}{{ $spied.Status }}{{ else }}{{ "failed" }}{{ end }}
+{{- end }}
 {{- end }}
        Body:
 {{ if .RequestBody }}{{ .RequestBody }}{{ else }}{{ "<no body>" }}{{ end }}
+{{- if .RequestCurl }}
+
+       Curl:
+{{ .RequestCurl }}
+{{- end }}
 
 Response:
      Status: {{ .ResponseStatus }}
@@ -63,9 +92,20 @@ Response:
        Db Response #{{ inc $i }}:
 {{ range $value := $dbr.Response }}
 {{ $value }}{{ end }}
+{{ if $dbr.Plan }}
+       Db Plan #{{ inc $i }}:
+{{ $dbr.Plan }}
 {{ end }}
 {{ end }}
-
+{{ end }}
+{{ if and .ServerLogs (not .Passed) }}
+       Server logs:
+{{ .ServerLogs }}
+{{ end }}
+{{ if .BodyDiff }}
+       Diff:
+{{ diff .BodyDiff }}
+{{ end }}
 {{ if .Errors }}
      Result: {{ "ERRORS!" }}
 
@@ -79,7 +119,8 @@ Errors:
 `
 
 	funcMap := template.FuncMap{
-		"inc": func(i int) int { return i + 1 },
+		"diff": output.ColorizeDiff,
+		"inc":  func(i int) int { return i + 1 },
 	}
 
 	var buffer bytes.Buffer
@@ -0,0 +1,77 @@
+// Package querycount counts the queries run by the tested service, e.g. to detect N+1 queries
+// with maxDbQueries of the tests. The service has to open its *sql.DB with the connector of the package:
+//
+//	counter := querycount.NewCounter()
+//	db := sql.OpenDB(querycount.NewConnector(pq.NewConnector(dsn), counter))
+//
+// The queries and the executions of the statements are counted, the transactions themselves are not.
+package querycount
+
+import (
+	"context"
+	"database/sql/driver"
+	"sync/atomic"
+)
+
+// Counter counts the queries run through the connectors sharing it
+type Counter struct {
+	count int64
+}
+
+func NewCounter() *Counter {
+	return &Counter{}
+}
+
+// Count returns the number of the queries run since the last Reset
+func (c *Counter) Count() int {
+	return int(atomic.LoadInt64(&c.count))
+}
+
+func (c *Counter) Reset() {
+	atomic.StoreInt64(&c.count, 0)
+}
+
+func (c *Counter) add() {
+	atomic.AddInt64(&c.count, 1)
+}
+
+type connector struct {
+	connector driver.Connector
+	counter   *Counter
+}
+
+// NewConnector wraps the connector of the driver counting the queries of its connections
+func NewConnector(c driver.Connector, counter *Counter) driver.Connector {
+	return &connector{connector: c, counter: counter}
+}
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &countingConn{Conn: conn, counter: c.counter}, nil
+}
+
+func (c *connector) Driver() driver.Driver {
+	return c.connector.Driver()
+}
+
+type dsnConnector struct {
+	driver driver.Driver
+	dsn    string
+}
+
+// DSNConnector returns the connector of the driver without a connector of its own,
+// the connections are opened with the DSN
+func DSNConnector(d driver.Driver, dsn string) driver.Connector {
+	return &dsnConnector{driver: d, dsn: dsn}
+}
+
+func (c *dsnConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+func (c *dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
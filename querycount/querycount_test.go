@@ -0,0 +1,53 @@
+package querycount
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestCounter(t *testing.T) {
+	mockDB, mock, err := sqlmock.NewWithDSN("querycount_counter")
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	counter := NewCounter()
+	db := sql.OpenDB(NewConnector(DSNConnector(mockDB.Driver(), "querycount_counter"), counter))
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT id FROM users").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE users").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectPrepare("DELETE FROM users").
+		ExpectExec().
+		WithArgs(2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	var id int
+	require.NoError(t, db.QueryRow("SELECT id FROM users WHERE id = $1", 1).Scan(&id))
+
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	_, err = tx.Exec("UPDATE users SET name = 'john'")
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+
+	stmt, err := db.Prepare("DELETE FROM users WHERE id = $1")
+	require.NoError(t, err)
+	_, err = stmt.Exec(2)
+	require.NoError(t, err)
+	require.NoError(t, stmt.Close())
+
+	// the transaction and the preparation of the statement aren't counted
+	assert.Equal(t, 3, counter.Count())
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	counter.Reset()
+	assert.Equal(t, 0, counter.Count())
+}
@@ -0,0 +1,79 @@
+package runner
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lamoda/gonkey/models"
+)
+
+// assertRetryCheck is the check failed when the checks don't pass before the timeout of assertRetry
+const assertRetryCheck = "assertRetry"
+
+// retryAssertions runs the checks of the response, with assertRetry they are repeated until they pass
+// along with the checks of the mocks or until the timeout expires, then the failures of the last run are reported
+func (r *Runner) retryAssertions(
+	v models.TestInterface,
+	result *models.Result,
+	check func() ([]error, error),
+) ([]error, error) {
+	retry := v.GetAssertRetry()
+	if retry == nil {
+		return check()
+	}
+	if retry.Timeout <= 0 {
+		return nil, fmt.Errorf("assertRetry of test %s has no timeout", v.GetName())
+	}
+
+	deadline := time.Now().Add(retry.Timeout)
+	for attempt := 1; ; attempt++ {
+		// the results of the previous run are replaced, the checkers fill them again
+		result.Checks = nil
+		result.BodyDiff = ""
+		result.DatabaseResult = nil
+
+		checkErrs, err := check()
+		if err != nil {
+			return nil, err
+		}
+		// the mocks are checked once more after the request, their failures are reported there
+		var mocksErrs []error
+		if r.config.Mocks != nil {
+			mocksErrs = r.config.Mocks.EndRunningContext()
+		}
+		if len(checkErrs) == 0 && len(mocksErrs) == 0 {
+			return nil, nil
+		}
+
+		if !time.Now().Add(retry.Interval).Before(deadline) {
+			err := fmt.Errorf(
+				"checks did not pass within %s after %d attempts, failed: %s",
+				retry.Timeout,
+				attempt,
+				strings.Join(failedChecks(result, mocksErrs), ", "),
+			)
+			result.Checks = append(result.Checks, models.CheckResult{Checker: assertRetryCheck, Errors: []error{err}})
+			return append(checkErrs, err), nil
+		}
+
+		r.logger.Log("assertions failed", "test", v.GetName(), "attempt", attempt,
+			"errors", len(checkErrs)+len(mocksErrs))
+
+		time.Sleep(retry.Interval)
+	}
+}
+
+// failedChecks returns the names of the failed checks of the result
+func failedChecks(result *models.Result, mocksErrs []error) []string {
+	var names []string
+	for _, c := range result.Checks {
+		if len(c.Errors) != 0 {
+			names = append(names, c.Checker)
+		}
+	}
+	if len(mocksErrs) != 0 {
+		names = append(names, mocksCheck)
+	}
+	return names
+}
@@ -0,0 +1,42 @@
+package runner
+
+import (
+	"github.com/lamoda/gonkey/models"
+)
+
+// withAssertions applies the first assertions block of the test matching the status of the response,
+// the expectations of the block replace the ones of the test for the status.
+// false is returned if the test has the blocks, but none of them matches.
+func withAssertions(v models.TestInterface, code int) (models.TestInterface, bool) {
+	for _, block := range v.GetAssertions() {
+		if !block.When.Match(code) {
+			continue
+		}
+
+		// the checkers look at the expectations for the actual status code only
+		responses := map[int]string{}
+		if block.Response != "" {
+			responses[code] = block.Response
+		}
+		headers := map[int]map[string]string{}
+		if block.ResponseHeaders != nil {
+			headers[code] = block.ResponseHeaders
+		}
+		xpaths := map[int][]string{}
+		if len(block.ResponseXPath) != 0 {
+			xpaths[code] = block.ResponseXPath
+		}
+		jsonPaths := map[int][]string{}
+		if len(block.ResponseJSONPath) != 0 {
+			jsonPaths[code] = block.ResponseJSONPath
+		}
+
+		res := v.Clone()
+		res.SetResponses(responses)
+		res.SetResponseHeaders(headers)
+		res.SetResponseXPaths(xpaths)
+		res.SetResponseJSONPaths(jsonPaths)
+		return res, true
+	}
+	return v, len(v.GetAssertions()) == 0
+}
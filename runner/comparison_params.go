@@ -0,0 +1,75 @@
+package runner
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/lamoda/gonkey/models"
+	"github.com/lamoda/gonkey/variables"
+)
+
+var templateVariableRx = regexp.MustCompile(`\$(\w+)`)
+
+// comparisonParamsRequest is the request the templates of the comparison params are evaluated with,
+// e.g. {{ eq (.Header.Get "X-Api-Version") "v1" }}
+type comparisonParamsRequest struct {
+	Method string
+	Path   string
+	Query  url.Values
+	Header http.Header
+}
+
+// evaluateComparisonParams evaluates the templates of the comparison params of the test, the variables
+// are available in the templates as $name
+func evaluateComparisonParams(v models.TestInterface, vs *variables.Variables, req *http.Request) error {
+	templates := v.GetComparisonParamsTemplates()
+	names := make([]string, 0, len(templates))
+	for name := range templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	data := comparisonParamsRequest{
+		Method: req.Method,
+		Path:   req.URL.Path,
+		Query:  req.URL.Query(),
+		Header: req.Header,
+	}
+	for _, name := range names {
+		value, err := evaluateComparisonParam(templates[name], vs, data)
+		if err == nil {
+			err = v.SetComparisonParam(name, value)
+		}
+		if err != nil {
+			return fmt.Errorf("unable to evaluate comparisonParams.%s: %s", name, err)
+		}
+	}
+	return nil
+}
+
+func evaluateComparisonParam(text string, vs *variables.Variables, data comparisonParamsRequest) (string, error) {
+	// the variables are declared in front of the template, so they are referred to as the template variables
+	var declarations strings.Builder
+	for _, match := range templateVariableRx.FindAllStringSubmatch(text, -1) {
+		if value, ok := vs.Value(match[1]); ok {
+			fmt.Fprintf(&declarations, "{{ $%s := %s }}", match[1], strconv.Quote(value))
+		}
+	}
+
+	tmpl, err := template.New("").Parse(declarations.String() + text)
+	if err != nil {
+		return "", err
+	}
+	buf := &bytes.Buffer{}
+	if err := tmpl.Execute(buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,79 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/lamoda/gonkey/models"
+)
+
+// repeatConditional sends the request of the test once more with the validators of the first response,
+// the errors tell how the service failed to answer 304 Not Modified with an empty body
+func (r *Runner) repeatConditional(v models.TestInterface, host string, first *http.Response) ([]error, error) {
+	validators := v.GetConditionalRequest().Validators
+	if len(validators) == 0 {
+		validators = []string{models.ValidatorETag, models.ValidatorLastModified}
+	}
+	required := len(v.GetConditionalRequest().Validators) != 0
+
+	req, err := newRequest(host, v)
+	if err != nil {
+		return nil, err
+	}
+	req, err = withProxy(req, v.GetProxy())
+	if err != nil {
+		return nil, err
+	}
+
+	var errs []error
+	sent := 0
+	for _, validator := range validators {
+		header, conditionHeader := "ETag", "If-None-Match"
+		if validator == models.ValidatorLastModified {
+			header, conditionHeader = "Last-Modified", "If-Modified-Since"
+		}
+		value := first.Header.Get(header)
+		if value == "" {
+			if required {
+				errs = append(errs, fmt.Errorf("the response has no %s header for the conditional request", header))
+			}
+			continue
+		}
+		req.Header.Set(conditionHeader, value)
+		sent++
+	}
+	if sent == 0 {
+		if !required {
+			errs = append(errs, errors.New("the response has neither ETag nor Last-Modified header for the conditional request"))
+		}
+		return errs, nil
+	}
+
+	var resp *http.Response
+	if v.GetRedirects() != nil {
+		resp, _, err = r.doFollowingRedirects(req)
+	} else {
+		resp, err = r.do(req)
+	}
+	if err != nil {
+		return nil, err
+	}
+	body, err := readBody(v, resp)
+	if err != nil {
+		return nil, err
+	}
+	r.logger.Log("conditional request sent", "test", v.GetName(), "status", resp.StatusCode)
+
+	if resp.StatusCode != http.StatusNotModified {
+		errs = append(errs, fmt.Errorf(
+			"the conditional request got status %d, expected %d", resp.StatusCode, http.StatusNotModified,
+		))
+	}
+	if len(body) != 0 {
+		errs = append(errs, fmt.Errorf(
+			"the conditional request got a body of %d bytes, expected an empty body", len(body),
+		))
+	}
+	return errs, nil
+}
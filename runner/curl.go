@@ -0,0 +1,46 @@
+package runner
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// curlCommand renders the request as it's sent, after the interceptor and the signer, as an equivalent
+// curl command: the method, the URL, the headers sorted by name and the body. The outputs mask the secrets.
+func curlCommand(req *http.Request) string {
+	command := "curl "
+	switch req.Method {
+	case http.MethodGet, "":
+	case http.MethodHead:
+		command += "--head "
+	default:
+		command += "-X " + req.Method + " "
+	}
+	// the options are put on their own lines
+	parts := []string{command + shellQuote(req.URL.String())}
+
+	if req.Host != "" && req.Host != req.URL.Host {
+		parts = append(parts, "-H "+shellQuote("Host: "+req.Host))
+	}
+	names := make([]string, 0, len(req.Header))
+	for name := range req.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range req.Header[name] {
+			parts = append(parts, "-H "+shellQuote(name+": "+value))
+		}
+	}
+
+	if body := actualRequestBody(req); body != "" {
+		parts = append(parts, "--data-binary "+shellQuote(body))
+	}
+	return strings.Join(parts, " \\\n  ")
+}
+
+// shellQuote quotes the value for the POSIX shells, the single quotes are the only ones needing an escape
+func shellQuote(value string) string {
+	return "'" + strings.Replace(value, "'", `'\''`, -1) + "'"
+}
@@ -0,0 +1,35 @@
+package runner
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCurlCommand(t *testing.T) {
+	req, err := http.NewRequest(http.MethodPost, "http://localhost:8080/orders?dry=1", strings.NewReader(`{"note": "it's fine"}`))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Add("X-Tag", "a")
+	req.Header.Add("X-Tag", "b")
+	req.Host = "shop.local"
+
+	assert.Equal(t, `curl -X POST 'http://localhost:8080/orders?dry=1' \
+  -H 'Host: shop.local' \
+  -H 'Content-Type: application/json' \
+  -H 'X-Tag: a' \
+  -H 'X-Tag: b' \
+  --data-binary '{"note": "it'\''s fine"}'`, curlCommand(req))
+}
+
+func TestCurlCommandWithoutBody(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "http://localhost/orders/1", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "curl 'http://localhost/orders/1'", curlCommand(req))
+
+	req.Method = http.MethodHead
+	assert.Equal(t, "curl --head 'http://localhost/orders/1'", curlCommand(req))
+}
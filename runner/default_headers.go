@@ -0,0 +1,34 @@
+package runner
+
+import (
+	"net/http"
+
+	"github.com/lamoda/gonkey/models"
+)
+
+// withDefaultHeaders returns a copy of the test with the default headers of the runner added,
+// the headers of the test win over the defaults with the same name
+func (r *Runner) withDefaultHeaders(v models.TestInterface) models.TestInterface {
+	if len(r.config.Headers) == 0 {
+		return v
+	}
+
+	testHeaders := make(map[string]bool, len(v.Headers()))
+	for name := range v.Headers() {
+		testHeaders[http.CanonicalHeaderKey(name)] = true
+	}
+
+	headers := make(map[string]string, len(r.config.Headers)+len(v.Headers()))
+	for name, value := range r.config.Headers {
+		if !testHeaders[http.CanonicalHeaderKey(name)] {
+			headers[name] = value
+		}
+	}
+	for name, value := range v.Headers() {
+		headers[name] = value
+	}
+
+	res := v.Clone()
+	res.SetHeaders(headers)
+	return res
+}
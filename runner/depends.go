@@ -0,0 +1,119 @@
+package runner
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lamoda/gonkey/models"
+)
+
+// orderByDependencies moves the tests listed in dependsOn before the tests depending on them,
+// the other tests keep their order. The dependency naming a test with cases stands for all its cases.
+// The dependencies filtered out by the loader (see testloader.FilteringLoaderInterface, filteredOut may be nil)
+// are skipped here, the dependencies on unknown tests and the cycles are errors.
+func orderByDependencies(tests []models.TestInterface, filteredOut func(name string) bool) ([]models.TestInterface, error) {
+	byName := make(map[string][]int, len(tests))
+	hasDependencies := false
+	for i, test := range tests {
+		byName[test.GetName()] = append(byName[test.GetName()], i)
+		hasDependencies = hasDependencies || len(test.GetDependsOn()) != 0
+	}
+	if !hasDependencies {
+		return tests, nil
+	}
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make([]int, len(tests))
+	ordered := make([]models.TestInterface, 0, len(tests))
+	var path []string
+
+	var visit func(i int) error
+	visit = func(i int) error {
+		switch state[i] {
+		case visited:
+			return nil
+		case visiting:
+			cycle := path
+			for start, name := range path {
+				if name == tests[i].GetName() {
+					cycle = path[start:]
+					break
+				}
+			}
+			return fmt.Errorf("tests depend on each other: %s -> %s", strings.Join(cycle, " -> "), tests[i].GetName())
+		}
+
+		state[i] = visiting
+		path = append(path, tests[i].GetName())
+		for _, dependency := range tests[i].GetDependsOn() {
+			indexes := dependencyIndexes(tests, byName, dependency)
+			if len(indexes) == 0 && (filteredOut == nil || !filteredOut(dependency)) {
+				return fmt.Errorf("test %s depends on unknown test %s", tests[i].GetName(), dependency)
+			}
+			for _, j := range indexes {
+				if err := visit(j); err != nil {
+					return err
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[i] = visited
+
+		ordered = append(ordered, tests[i])
+		return nil
+	}
+
+	for i := range tests {
+		if err := visit(i); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
+
+// dependencyIndexes returns the indexes of the tests named the dependency or, if there are none,
+// of the cases of the test named so
+func dependencyIndexes(tests []models.TestInterface, byName map[string][]int, dependency string) []int {
+	if indexes, ok := byName[dependency]; ok {
+		return indexes
+	}
+	var indexes []int
+	for i, test := range tests {
+		if models.IsCaseOf(test.GetName(), dependency) {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+// dependencySkipReason returns the reason to skip the test if any of its dependencies didn't pass
+// or isn't loaded, empty if all of them passed. The dependencies are run before the test, so passed
+// has all the loaded ones.
+func dependencySkipReason(test models.TestInterface, passed map[string]bool) string {
+	for _, dependency := range test.GetDependsOn() {
+		if testPassed, ok := passed[dependency]; ok {
+			if !testPassed {
+				return "dependency failed: " + dependency
+			}
+			continue
+		}
+
+		loaded := false
+		for name, testPassed := range passed {
+			if !models.IsCaseOf(name, dependency) {
+				continue
+			}
+			if !testPassed {
+				return "dependency failed: " + dependency
+			}
+			loaded = true
+		}
+		if !loaded {
+			return "dependency not loaded: " + dependency
+		}
+	}
+	return ""
+}
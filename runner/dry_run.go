@@ -0,0 +1,81 @@
+package runner
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/lamoda/gonkey/fixtures"
+	"github.com/lamoda/gonkey/models"
+)
+
+// validateTest loads everything the test refers to and reports the problems as the test errors,
+// no requests are sent and no storages are touched
+func (r *Runner) validateTest(v models.TestInterface) (*models.Result, error) {
+	if err := notRunError(v); err != nil {
+		return &models.Result{Test: v}, err
+	}
+
+	r.config.Variables.ResetUniqueNames()
+	r.config.Variables.Load(v.GetCombinedVariables())
+	v = r.config.Variables.Apply(r.withDefaultHeaders(v))
+
+	result := &models.Result{Test: v}
+	if err := r.config.Variables.SecretsErr(); err != nil {
+		result.Errors = append(result.Errors, err)
+	}
+
+	if validator, ok := r.config.FixturesLoader.(fixtures.Validator); ok && v.Fixtures() != nil {
+		if err := validator.Validate(v.Fixtures()); err != nil {
+			result.Errors = append(result.Errors, fmt.Errorf(
+				"unable to load fixtures [%s], error:\n%s", strings.Join(v.Fixtures(), ", "), err))
+		}
+	}
+
+	if r.config.MocksLoader != nil && v.ServiceMocks() != nil {
+		if err := r.config.MocksLoader.WithBaseDir(v.GetBaseDir()).Validate(v.ServiceMocks()); err != nil {
+			result.Errors = append(result.Errors, err)
+		}
+	}
+
+	for _, script := range []string{v.BeforeScriptPath(), v.AfterRequestScriptPath()} {
+		if script == "" {
+			continue
+		}
+		if _, err := exec.LookPath(strings.TrimRight(script, "\n")); err != nil {
+			result.Errors = append(result.Errors, fmt.Errorf("unable to find script: %s", err))
+		}
+	}
+
+	if v.GetRequestBuilder() != "" {
+		if _, err := r.requestBuilder(v); err != nil {
+			result.Errors = append(result.Errors, err)
+		}
+	}
+
+	if _, err := parseProxy(v.GetProxy()); err != nil {
+		result.Errors = append(result.Errors, err)
+	}
+
+	host, err := r.hostOf(v)
+	if err != nil {
+		result.Errors = append(result.Errors, err)
+		return result, nil
+	}
+
+	req, err := newRequest(host, v)
+	if err != nil {
+		result.Errors = append(result.Errors, fmt.Errorf("unable to build request: %s", err))
+		return result, nil
+	}
+
+	result.Path = req.URL.Path
+	result.Query = req.URL.RawQuery
+	result.RequestBody = actualRequestBody(req)
+
+	if file := v.GetRequestSnapshotFile(); file != "" {
+		result.Errors = append(result.Errors, r.checkRequestSnapshot(file, requestSnapshot(req, r.config.Variables.Secrets()))...)
+	}
+
+	return result, nil
+}
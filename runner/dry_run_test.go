@@ -0,0 +1,49 @@
+package runner
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lamoda/gonkey/fixtures"
+	"github.com/lamoda/gonkey/mocks"
+	"github.com/lamoda/gonkey/testloader/yaml_file"
+	"github.com/lamoda/gonkey/variables"
+)
+
+func TestDryRun(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request in dry-run mode: %s %s", r.Method, r.URL)
+	}))
+	defer srv.Close()
+
+	m := mocks.NewNop("backend")
+
+	handler := NewConsoleHandler()
+	r := New(
+		&Config{
+			Host: srv.URL,
+			FixturesLoader: fixtures.NewLoader(&fixtures.Config{
+				Location: filepath.Join("testdata", "dry-run", "fixtures"),
+				DbType:   fixtures.Postgres,
+			}),
+			Mocks:       m,
+			MocksLoader: mocks.NewLoader(m),
+			Variables:   variables.New(),
+			DryRun:      true,
+		},
+		yaml_file.NewLoader(filepath.Join("testdata", "dry-run", "cases")),
+		handler.HandleTest,
+	)
+
+	require.NoError(t, r.Run())
+
+	summary := handler.Summary()
+	assert.False(t, summary.Success)
+	assert.Equal(t, 4, summary.Total)
+	assert.Equal(t, 3, summary.Failed)
+}
@@ -0,0 +1,50 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// processEnvLock guards the process environment when tests are run in parallel
+// (e.g. by several runners from parallel go tests): a test setting env variables
+// runs exclusively, the other tests may run concurrently with each other
+var processEnvLock sync.RWMutex
+
+// setEnv sets the env variables of the test, the returned function restores the previous values
+// and must be called when the test is finished
+func setEnv(env map[string]string) (restore func(), err error) {
+	if len(env) == 0 {
+		processEnvLock.RLock()
+		return processEnvLock.RUnlock, nil
+	}
+
+	processEnvLock.Lock()
+
+	type previousValue struct {
+		value string
+		isSet bool
+	}
+	previous := make(map[string]previousValue, len(env))
+	restore = func() {
+		for name, prev := range previous {
+			if prev.isSet {
+				_ = os.Setenv(name, prev.value)
+			} else {
+				_ = os.Unsetenv(name)
+			}
+		}
+		processEnvLock.Unlock()
+	}
+
+	for name, value := range env {
+		prev, isSet := os.LookupEnv(name)
+		previous[name] = previousValue{value: prev, isSet: isSet}
+		if err := os.Setenv(name, value); err != nil {
+			restore()
+			return nil, fmt.Errorf("unable to set env variable %s: %s", name, err)
+		}
+	}
+
+	return restore, nil
+}
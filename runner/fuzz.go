@@ -0,0 +1,59 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/lamoda/gonkey/fuzz"
+	"github.com/lamoda/gonkey/models"
+)
+
+// runFuzz runs the fuzz test: each iteration sends a body generated from the schema and is a test
+// of its own for the handler and the outputs, the seed is in the names of the iterations, so the outputs
+// of the failed ones have everything to reproduce them
+func (r *Runner) runFuzz(test models.TestInterface, stats *summaryStats) error {
+	// the skipped and broken tests are reported once
+	if test.GetStatus() != "" {
+		return r.runRepeated(test, stats)
+	}
+
+	params := test.GetFuzz()
+	schema, err := fuzz.LoadSchema(params.Schema)
+	if err != nil {
+		return fmt.Errorf("test %s error: %s", test.GetName(), err)
+	}
+
+	seed := params.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	iterations := params.Iterations
+	if r.config.DryRun {
+		iterations = 1
+	}
+
+	generator := fuzz.NewGenerator(schema, seed)
+	for i := 1; i <= iterations; i++ {
+		value, err := generator.Generate()
+		if err != nil {
+			return fmt.Errorf("test %s error: unable to generate request: %s", test.GetName(), err)
+		}
+		body, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("test %s error: unable to generate request: %s", test.GetName(), err)
+		}
+
+		iteration := test.Clone()
+		iteration.SetName(fmt.Sprintf("%s [fuzz %d of %d, seed %d]", test.GetName(), i, iterations, seed))
+		iteration.SetRequest(string(body))
+
+		testExecutor := func(models.TestInterface) (*models.Result, error) {
+			return r.executeAndOutput(iteration, stats)
+		}
+		if err := r.testExecutionHandler(iteration, testExecutor); err != nil {
+			return fmt.Errorf("test %s error: %s", iteration.GetName(), err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package runner
+
+import (
+	"fmt"
+	"runtime"
+	"time"
+)
+
+// goroutinesCheck is the check failed when the test leaves more goroutines running than GoroutineLeaks allows
+const goroutinesCheck = "goroutines"
+
+const (
+	defaultGoroutinesSettleDelay = time.Second
+	goroutinesPollInterval       = 10 * time.Millisecond
+)
+
+// GoroutineLeaks fails the tests leaving more goroutines running in the process than before them,
+// it's meant for the services run in the process of the tests, e.g. with httptest.Server.
+// The goroutines of the whole process are counted, so the tests must not run in parallel.
+type GoroutineLeaks struct {
+	// Allowance is how many goroutines a test may add, e.g. the background workers started
+	// by the first request to the service
+	Allowance int
+	// SettleDelay is how long the goroutines are waited to finish after the test, 1s if 0
+	SettleDelay time.Duration
+}
+
+// checkGoroutineLeaks waits for the number of the goroutines to drop to the number before the test
+// and the allowance until the settle delay expires
+func (r *Runner) checkGoroutineLeaks(before int) []error {
+	// the goroutines serving the idle connections of the client, on both sides, aren't the leaks of the test
+	r.client.CloseIdleConnections()
+
+	leaks := r.config.GoroutineLeaks
+	delay := leaks.SettleDelay
+	if delay <= 0 {
+		delay = defaultGoroutinesSettleDelay
+	}
+	deadline := time.Now().Add(delay)
+	for {
+		after := runtime.NumGoroutine()
+		if after <= before+leaks.Allowance {
+			return nil
+		}
+		if !time.Now().Before(deadline) {
+			return []error{fmt.Errorf(
+				"the number of goroutines grew from %d to %d after the test and didn't settle within %s, allowance %d",
+				before,
+				after,
+				delay,
+				leaks.Allowance,
+			)}
+		}
+		time.Sleep(goroutinesPollInterval)
+	}
+}
@@ -0,0 +1,116 @@
+package runner
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/lamoda/gonkey/compare"
+	"github.com/lamoda/gonkey/models"
+)
+
+// idempotencyKey returns the idempotency key of the request, the key set by the test is kept,
+// otherwise a random key is added to the request
+func idempotencyKey(req *http.Request, idempotency *models.Idempotency) (string, error) {
+	if idempotency.Header == "" {
+		return "", errors.New("idempotency header is not set")
+	}
+	if key := req.Header.Get(idempotency.Header); key != "" {
+		return key, nil
+	}
+
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("unable to generate idempotency key: %s", err)
+	}
+	key := hex.EncodeToString(buf)
+	req.Header.Set(idempotency.Header, key)
+	return key, nil
+}
+
+// repeatIdempotent sends the request of the test once more with the same idempotency key,
+// the errors tell how the second run diverged from the first one
+func (r *Runner) repeatIdempotent(v models.TestInterface, host, key string, first *models.Result) ([]error, error) {
+	idempotency := v.GetIdempotency()
+	rowsBefore, err := r.countRows(idempotency.Tables)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := newRequest(host, v)
+	if err != nil {
+		return nil, err
+	}
+	req, err = withProxy(req, v.GetProxy())
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set(idempotency.Header, key)
+
+	var resp *http.Response
+	if v.GetRedirects() != nil {
+		resp, _, err = r.doFollowingRedirects(req)
+	} else {
+		resp, err = r.do(req)
+	}
+	if err != nil {
+		return nil, err
+	}
+	body, err := readBody(v, resp)
+	if err != nil {
+		return nil, err
+	}
+	r.logger.Log("idempotent request repeated", "test", v.GetName(), "status", resp.StatusCode)
+
+	var errs []error
+	if resp.StatusCode != first.ResponseStatusCode {
+		errs = append(errs, fmt.Errorf(
+			"the second run got status %d, the first run got %d", resp.StatusCode, first.ResponseStatusCode,
+		))
+	} else {
+		errs = append(errs, compareRunBodies(first.ResponseBody, string(body))...)
+	}
+
+	rowsAfter, err := r.countRows(idempotency.Tables)
+	if err != nil {
+		return nil, err
+	}
+	for i, table := range idempotency.Tables {
+		if rowsAfter[i] != rowsBefore[i] {
+			errs = append(errs, fmt.Errorf(
+				"the second run changed the number of rows in table %s from %d to %d", table, rowsBefore[i], rowsAfter[i],
+			))
+		}
+	}
+	return errs, nil
+}
+
+// compareRunBodies compares the JSON bodies of the runs structurally and the other bodies as text
+func compareRunBodies(first, second string) []error {
+	var firstJSON, secondJSON interface{}
+	if json.Unmarshal([]byte(first), &firstJSON) != nil || json.Unmarshal([]byte(second), &secondJSON) != nil {
+		if first != second {
+			return []error{errors.New("the body of the second run differs from the body of the first run")}
+		}
+		return nil
+	}
+
+	var errs []error
+	for _, err := range compare.Compare(firstJSON, secondJSON, compare.CompareParams{DisallowExtraFields: true}) {
+		errs = append(errs, fmt.Errorf("the body of the second run differs from the first run %s", err))
+	}
+	return errs
+}
+
+func (r *Runner) countRows(tables []string) ([]int, error) {
+	counts := make([]int, len(tables))
+	for i, table := range tables {
+		if err := r.config.DB.QueryRow("SELECT COUNT(*) FROM " + table).Scan(&counts[i]); err != nil {
+			return nil, fmt.Errorf("unable to count rows in table %s: %s", table, err)
+		}
+	}
+	return counts, nil
+}
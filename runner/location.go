@@ -0,0 +1,68 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+
+	"github.com/lamoda/gonkey/compare"
+	"github.com/lamoda/gonkey/models"
+)
+
+// resolveLocation resolves the Location header of the response against the URL of the request which got it,
+// i.e. the last request if the redirects are followed
+func resolveLocation(req *http.Request, resp *http.Response) (*url.URL, error) {
+	header := resp.Header.Get("Location")
+	if header == "" {
+		return nil, errors.New("response does not include Location header")
+	}
+	location, err := url.Parse(header)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Location header %s: %s", header, err)
+	}
+
+	base := req.URL
+	if resp.Request != nil && resp.Request.URL != nil {
+		base = resp.Request.URL
+	}
+	return base.ResolveReference(location), nil
+}
+
+// checkLocation compares the path and the query params of the resolved location with the expected ones,
+// the params not listed aren't checked
+func checkLocation(expected models.ResponseLocation, location *url.URL) []error {
+	var errs []error
+	if expected.Path != "" && len(compare.Compare(expected.Path, location.Path, compare.CompareParams{})) != 0 {
+		errs = append(errs, fmt.Errorf("Location path %s does not match expected %s", location.Path, expected.Path))
+	}
+
+	names := make([]string, 0, len(expected.Query))
+	for name := range expected.Query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	query := location.Query()
+	for _, name := range names {
+		values, ok := query[name]
+		if !ok {
+			errs = append(errs, fmt.Errorf("Location query does not include expected param %s", name))
+			continue
+		}
+		found := false
+		for _, value := range values {
+			if len(compare.Compare(expected.Query[name], value, compare.CompareParams{})) == 0 {
+				found = true
+			}
+		}
+		if !found {
+			errs = append(errs, fmt.Errorf(
+				"Location query param %s value %s does not match expected %s",
+				name, query.Get(name), expected.Query[name],
+			))
+		}
+	}
+	return errs
+}
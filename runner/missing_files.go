@@ -0,0 +1,77 @@
+package runner
+
+import (
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/lamoda/gonkey/fixtures"
+	"github.com/lamoda/gonkey/models"
+)
+
+// checkMissingFiles reports the missing files referenced by the tests at once before any test is run:
+// the files of the forms, the fuzz schemas, the files of the mocks and the fixtures. The skipped and the broken
+// tests are not checked, the golden files aren't checked either as they are created by the update mode.
+func (r *Runner) checkMissingFiles(tests []models.TestInterface) error {
+	var missing []models.MissingFile
+	for _, test := range tests {
+		if status := test.GetStatus(); status == "skipped" || status == "broken" {
+			continue
+		}
+		missing = append(missing, r.missingFiles(test)...)
+		for _, step := range test.GetSteps() {
+			missing = append(missing, r.missingFiles(step)...)
+		}
+	}
+	if len(missing) != 0 {
+		return &models.MissingFilesError{Files: missing}
+	}
+	return nil
+}
+
+func (r *Runner) missingFiles(v models.TestInterface) []models.MissingFile {
+	var missing []models.MissingFile
+	add := func(field, path string) {
+		missing = append(missing, models.MissingFile{Field: field, Path: path, Test: v.GetName(), TestFile: v.GetFileName()})
+	}
+	check := func(field, path string) {
+		if path == "" || strings.Contains(path, "{{") {
+			return
+		}
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			add(field, path)
+		}
+	}
+
+	if form := v.GetForm(); form != nil {
+		fields := make([]string, 0, len(form.Files))
+		for field := range form.Files {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+		for _, field := range fields {
+			check("form.files", form.Files[field])
+		}
+		for _, part := range form.Parts {
+			check("form.parts", part.File)
+		}
+	}
+	if fuzz := v.GetFuzz(); fuzz != nil {
+		check("fuzz.schema", fuzz.Schema)
+	}
+
+	if r.config.MocksLoader != nil && v.ServiceMocks() != nil {
+		for _, file := range r.config.MocksLoader.WithBaseDir(v.GetBaseDir()).MissingFiles(v.ServiceMocks()) {
+			add("mocks", file)
+		}
+	}
+
+	if locator, ok := r.config.FixturesLoader.(fixtures.Locator); ok {
+		for _, name := range v.Fixtures() {
+			if _, err := locator.Locate(name); os.IsNotExist(err) {
+				add("fixtures", name)
+			}
+		}
+	}
+	return missing
+}
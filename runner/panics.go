@@ -0,0 +1,42 @@
+package runner
+
+import (
+	"fmt"
+	"runtime/debug"
+
+	"github.com/lamoda/gonkey/models"
+)
+
+// PanicError is the panic of a checker or a hook recovered by the runner,
+// the test fails with it and the run goes on with the next test
+type PanicError struct {
+	// Name is the name of the checker or the hook, e.g. response_body or RequestInterceptor
+	Name  string
+	Value interface{}
+	// Stack is the stack of the goroutine at the moment of the panic
+	Stack string
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("%s panicked: %v\n%s", e.Name, e.Value, e.Stack)
+}
+
+// recovered calls the checker or the hook and returns its panic
+func recovered(name string, f func()) (p *PanicError) {
+	defer func() {
+		if value := recover(); value != nil {
+			p = &PanicError{Name: name, Value: value, Stack: string(debug.Stack())}
+		}
+	}()
+	f()
+	return nil
+}
+
+// panicResult is the result of the test interrupted by the panic of the hook
+func panicResult(v models.TestInterface, p *PanicError) *models.Result {
+	return &models.Result{
+		Test:   v,
+		Errors: []error{p},
+		Checks: []models.CheckResult{{Checker: p.Name, Errors: []error{p}}},
+	}
+}
@@ -0,0 +1,61 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// noProxy is the proxy of the tests sending the requests directly
+const noProxy = "none"
+
+type proxyKey struct{}
+
+// testProxy is the proxy of the test overriding the one of the runner, nil URL means no proxy
+type testProxy struct {
+	url *url.URL
+}
+
+// parseProxy validates the proxy of the test, the proxy of the runner is used if it's empty
+func parseProxy(proxy string) (*testProxy, error) {
+	switch proxy {
+	case "":
+		return nil, nil
+	case noProxy:
+		return &testProxy{}, nil
+	}
+
+	u, err := url.Parse(proxy)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy %q: %s", proxy, err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("invalid proxy %q: the scheme must be http, https or socks5", proxy)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid proxy %q: the host is missing", proxy)
+	}
+	return &testProxy{url: u}, nil
+}
+
+// withProxy makes the request go through the proxy of the test, the redirects followed keep it
+func withProxy(req *http.Request, proxy string) (*http.Request, error) {
+	p, err := parseProxy(proxy)
+	if err != nil || p == nil {
+		return req, err
+	}
+	return req.WithContext(context.WithValue(req.Context(), proxyKey{}, p)), nil
+}
+
+// proxyFunc returns the proxy of the test if it's set and the proxy of the runner otherwise
+func proxyFunc(proxyURL *url.URL) func(*http.Request) (*url.URL, error) {
+	return func(req *http.Request) (*url.URL, error) {
+		if p, ok := req.Context().Value(proxyKey{}).(*testProxy); ok {
+			return p.url, nil
+		}
+		return proxyURL, nil
+	}
+}
@@ -0,0 +1,74 @@
+package runner
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// RateLimiter paces the requests of the tests with a token bucket, the same limiter can be shared
+// by several runners, e.g. by the parallel Go tests, to limit their requests in total
+type RateLimiter struct {
+	sync.Mutex
+	rps    float64
+	burst  float64
+	jitter time.Duration
+	// tokens is negative if the requests waiting for the tokens have reserved them in advance
+	tokens float64
+	last   time.Time
+	rnd    *rand.Rand
+	now    func() time.Time
+	sleep  func(time.Duration)
+}
+
+// NewRateLimiter returns the limiter allowing rps requests per second on average and up to burst requests
+// at once (1 if not positive), each request waiting for a token is delayed by a random jitter up to jitter
+func NewRateLimiter(rps float64, burst int, jitter time.Duration) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		rps:    rps,
+		burst:  float64(burst),
+		jitter: jitter,
+		tokens: float64(burst),
+		rnd:    rand.New(rand.NewSource(time.Now().UnixNano())),
+		now:    time.Now,
+		sleep:  time.Sleep,
+	}
+}
+
+// Wait blocks until the request can be sent
+func (l *RateLimiter) Wait() {
+	if l == nil || l.rps <= 0 {
+		return
+	}
+	if delay := l.reserve(); delay > 0 {
+		l.sleep(delay)
+	}
+}
+
+// reserve takes a token and returns the time to wait for it
+func (l *RateLimiter) reserve() time.Duration {
+	l.Lock()
+	defer l.Unlock()
+
+	now := l.now()
+	if !l.last.IsZero() {
+		l.tokens += now.Sub(l.last).Seconds() * l.rps
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+	}
+	l.last = now
+
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+	delay := time.Duration(-l.tokens / l.rps * float64(time.Second))
+	if l.jitter > 0 {
+		delay += time.Duration(l.rnd.Int63n(int64(l.jitter)))
+	}
+	return delay
+}
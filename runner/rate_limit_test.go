@@ -0,0 +1,76 @@
+package runner
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	var delays []time.Duration
+
+	l := NewRateLimiter(10, 2, 0)
+	l.now = func() time.Time { return now }
+	l.sleep = func(d time.Duration) { delays = append(delays, d) }
+
+	// the burst is sent at once, the next requests wait for the tokens one after another
+	for i := 0; i < 4; i++ {
+		l.Wait()
+	}
+	assert.Equal(t, []time.Duration{100 * time.Millisecond, 200 * time.Millisecond}, delays)
+
+	// the tokens are refilled up to the burst
+	delays = nil
+	now = now.Add(time.Hour)
+	for i := 0; i < 3; i++ {
+		l.Wait()
+	}
+	assert.Equal(t, []time.Duration{100 * time.Millisecond}, delays)
+}
+
+func TestRateLimiterJitter(t *testing.T) {
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	var delays []time.Duration
+
+	l := NewRateLimiter(1, 1, 50*time.Millisecond)
+	l.now = func() time.Time { return now }
+	l.sleep = func(d time.Duration) { delays = append(delays, d) }
+
+	l.Wait()
+	l.Wait()
+	require.Len(t, delays, 1)
+	assert.True(t, delays[0] >= time.Second && delays[0] < time.Second+50*time.Millisecond, delays[0])
+}
+
+func TestRateLimiterDisabled(t *testing.T) {
+	var l *RateLimiter
+	l.Wait()
+	NewRateLimiter(0, 1, 0).Wait()
+}
+
+func TestRunnerRateLimit(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	var delays []time.Duration
+	limiter := NewRateLimiter(5, 1, 0)
+	limiter.sleep = func(d time.Duration) { delays = append(delays, d) }
+
+	r := &Runner{config: &Config{RateLimit: limiter}, client: srv.Client()}
+	for i := 0; i < 3; i++ {
+		req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+		require.NoError(t, err)
+		resp, err := r.do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+	// the requests are sent immediately as the sleeps are faked, so each one waits for one more token
+	require.Len(t, delays, 2)
+	assert.True(t, delays[0] > 150*time.Millisecond, delays[0])
+	assert.True(t, delays[1] > delays[0], delays[1])
+}
@@ -0,0 +1,164 @@
+package runner
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/lamoda/gonkey/compare"
+	"github.com/lamoda/gonkey/models"
+)
+
+// maxRedirects limits the redirects followed for a test, the same as the default of http.Client
+const maxRedirects = 10
+
+// credentialHeaders are not sent to the other hosts the requests are redirected to: the ones
+// dropped by http.Client and the headers of the signatures
+var credentialHeaders = []string{
+	"Authorization",
+	"Www-Authenticate",
+	"Cookie",
+	"Cookie2",
+	"X-Amz-Date",
+	"X-Amz-Security-Token",
+	"X-Amz-Content-Sha256",
+}
+
+// redirectChain is the redirects followed for a test
+type redirectChain struct {
+	hops []models.RedirectHop
+	// err tells why the chain is not followed to the end, e.g. a loop
+	err error
+}
+
+// doFollowingRedirects sends the request and follows the redirects recording them,
+// the response of the last request is returned. The requests to the other hosts are not signed.
+func (r *Runner) doFollowingRedirects(req *http.Request) (*http.Response, *redirectChain, error) {
+	chain := &redirectChain{}
+	host := req.URL.Host
+	visited := []string{req.URL.String()}
+	for {
+		resp, err := r.send(req, req.URL.Host == host)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		location := resp.Header.Get("Location")
+		if !isRedirect(resp.StatusCode) || location == "" {
+			return resp, chain, nil
+		}
+		chain.hops = append(chain.hops, models.RedirectHop{Status: resp.StatusCode, Location: location})
+
+		next, err := redirectRequest(req, resp)
+		if err != nil {
+			_ = resp.Body.Close()
+			return nil, nil, err
+		}
+
+		for _, u := range visited {
+			if u == next.URL.String() {
+				chain.err = fmt.Errorf("redirect loop: %s -> %s", strings.Join(visited, " -> "), u)
+				return resp, chain, nil
+			}
+		}
+		if len(chain.hops) >= maxRedirects {
+			chain.err = fmt.Errorf("stopped after %d redirects", maxRedirects)
+			return resp, chain, nil
+		}
+		visited = append(visited, next.URL.String())
+
+		if r.config.ResponseInterceptor != nil {
+			if p := recovered("ResponseInterceptor", func() { r.config.ResponseInterceptor(resp) }); p != nil {
+				_ = resp.Body.Close()
+				return nil, nil, p
+			}
+		}
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		_ = resp.Body.Close()
+		req = next
+	}
+}
+
+func isRedirect(status int) bool {
+	switch status {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	default:
+		return false
+	}
+}
+
+// redirectRequest makes the request to the location of the redirect the same way as http.Client does:
+// the method and the body are kept only for 307 and 308 redirects, the credentials aren't sent to another host
+func redirectRequest(req *http.Request, resp *http.Response) (*http.Request, error) {
+	location, err := req.URL.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Location header %q: %s", resp.Header.Get("Location"), err)
+	}
+
+	method := req.Method
+	keepBody := resp.StatusCode == http.StatusTemporaryRedirect || resp.StatusCode == http.StatusPermanentRedirect
+	if !keepBody && method != http.MethodHead {
+		method = http.MethodGet
+	}
+
+	var body io.ReadCloser
+	if keepBody && req.GetBody != nil {
+		if body, err = req.GetBody(); err != nil {
+			return nil, err
+		}
+	}
+
+	// the context keeps the proxy of the test
+	next, err := http.NewRequestWithContext(req.Context(), method, location.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	next.Header = req.Header.Clone()
+	if next.URL.Host != req.URL.Host {
+		for _, name := range credentialHeaders {
+			next.Header.Del(name)
+		}
+	}
+	if keepBody {
+		next.GetBody = req.GetBody
+		next.ContentLength = req.ContentLength
+	} else {
+		next.Header.Del("Content-Type")
+		next.Header.Del("Content-Length")
+	}
+	return next, nil
+}
+
+// checkRedirects compares the followed redirects with the expected ones hop by hop
+func checkRedirects(expected []models.RedirectHop, chain *redirectChain) []error {
+	var errs []error
+	if chain.err != nil {
+		errs = append(errs, chain.err)
+	}
+
+	for i := 0; i < len(expected) || i < len(chain.hops); i++ {
+		switch {
+		case i >= len(chain.hops):
+			errs = append(errs, fmt.Errorf(
+				"redirect %d is expected: status %d, location %s, but there is no more redirects",
+				i+1, expected[i].Status, expected[i].Location,
+			))
+		case i >= len(expected):
+			errs = append(errs, fmt.Errorf(
+				"unexpected redirect %d: status %d, location %s",
+				i+1, chain.hops[i].Status, chain.hops[i].Location,
+			))
+		case expected[i].Status != chain.hops[i].Status ||
+			len(compare.Compare(expected[i].Location, chain.hops[i].Location, compare.CompareParams{})) != 0:
+			errs = append(errs, fmt.Errorf(
+				"redirect %d does not match: expected status %d, location %s, actual status %d, location %s",
+				i+1, expected[i].Status, expected[i].Location, chain.hops[i].Status, chain.hops[i].Location,
+			))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,79 @@
+package runner
+
+import (
+	"fmt"
+
+	"github.com/lamoda/gonkey/models"
+)
+
+// runRepeated runs the test as many times as its repeat directive says, each iteration is a test
+// of its own for the handler and the outputs, the fixtures and the mocks are set up again every time
+func (r *Runner) runRepeated(test models.TestInterface, stats *summaryStats) error {
+	count, stopOnFailure, err := repeatOf(test)
+	if err != nil {
+		return fmt.Errorf("test %s error: %s", test.GetName(), err)
+	}
+	if r.config.DryRun {
+		count = 1
+	}
+
+	repeated := models.RepeatedTest{
+		Name:     test.GetName(),
+		FileName: test.GetFileName(),
+		Count:    count,
+	}
+	for i := 1; i <= count; i++ {
+		iteration := test
+		if count > 1 {
+			iteration = test.Clone()
+			iteration.SetName(fmt.Sprintf("%s [%d of %d]", test.GetName(), i, count))
+		}
+
+		passed := false
+		testExecutor := func(models.TestInterface) (*models.Result, error) {
+			result, err := r.executeAndOutput(iteration, stats)
+			passed = err == nil && result.Passed()
+			return result, err
+		}
+		if err := r.testExecutionHandler(iteration, testExecutor); err != nil {
+			return fmt.Errorf("test %s error: %s", iteration.GetName(), err)
+		}
+
+		repeated.Runs++
+		if passed {
+			repeated.Passed++
+		}
+		if !passed && stopOnFailure {
+			break
+		}
+	}
+
+	if count > 1 {
+		r.repeated = append(r.repeated, repeated)
+	}
+	return nil
+}
+
+// repeatOf returns the number of the iterations of the test, the skipped and broken tests
+// as well as the dry runs are run once
+func repeatOf(test models.TestInterface) (int, bool, error) {
+	repeat := test.GetRepeat()
+	if repeat == nil {
+		return 1, false, nil
+	}
+	if repeat.Count < 1 {
+		return 0, false, fmt.Errorf("repeat count must be positive, got %d", repeat.Count)
+	}
+	if test.GetStatus() != "" {
+		return 1, false, nil
+	}
+	return repeat.Count, repeat.StopOnFailure, nil
+}
+
+// RepeatedTests returns the tallies of the tests run several times with the repeat directive
+// in the order they were run
+func (r *Runner) RepeatedTests() []models.RepeatedTest {
+	tests := make([]models.RepeatedTest, len(r.repeated))
+	copy(tests, r.repeated)
+	return tests
+}
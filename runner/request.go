@@ -8,18 +8,25 @@ import (
 	"io/ioutil"
 	"mime/multipart"
 	"net/http"
+	"net/textproto"
 	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/lamoda/gonkey/models"
 )
 
-func newClient(proxyURL *url.URL) *http.Client {
+func newClient(config *Config) *http.Client {
 	transport := &http.Transport{
 		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-		Proxy:           http.ProxyURL(proxyURL),
+		Proxy:           proxyFunc(config.HttpProxyURL),
+		// the custom TLS config disables HTTP/2 unless it's forced
+		ForceAttemptHTTP2:   config.HTTP2,
+		DisableKeepAlives:   config.DisableKeepAlives,
+		MaxIdleConns:        config.MaxIdleConns,
+		MaxIdleConnsPerHost: config.MaxIdleConnsPerHost,
 	}
 
 	return &http.Client{
@@ -78,6 +85,11 @@ func newMultipartRequest(host string, test models.TestInterface) (*http.Request,
 		return nil, err
 	}
 
+	err = addParts(test.GetForm().Parts, w)
+	if err != nil {
+		return nil, err
+	}
+
 	_ = w.Close()
 
 	req, err := request(test, &b, host)
@@ -93,9 +105,16 @@ func newMultipartRequest(host string, test models.TestInterface) (*http.Request,
 }
 
 func addFiles(files map[string]string, w *multipart.Writer) error {
-	for name, path := range files {
+	// the files are written in the order of their names for the request to be the same every time
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
 
-		err := addFile(path, w, name)
+		err := addFile(files[name], w, name)
 		if err != nil {
 			return err
 		}
@@ -123,9 +142,61 @@ func addFile(path string, w *multipart.Writer, name string) error {
 	return nil
 }
 
+// quoteEscaper escapes the names in the headers of the parts, as mime/multipart does
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+func addParts(parts []models.FormPart, w *multipart.Writer) error {
+	for _, part := range parts {
+		if err := addPart(part, w); err != nil {
+			return fmt.Errorf("unable to add part %s: %s", part.Name, err)
+		}
+	}
+	return nil
+}
+
+func addPart(part models.FormPart, w *multipart.Writer) error {
+	disposition := fmt.Sprintf(`form-data; name="%s"`, quoteEscaper.Replace(part.Name))
+	contentType := part.ContentType
+	var content io.Reader
+	if part.Body != "" || part.File == "" {
+		if contentType == "" {
+			contentType = "text/plain"
+		}
+		content = strings.NewReader(part.Body)
+	} else {
+		f, err := os.Open(part.File)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = f.Close() }()
+
+		disposition += fmt.Sprintf(`; filename="%s"`, quoteEscaper.Replace(filepath.Base(part.File)))
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		content = f
+	}
+
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", disposition)
+	header.Set("Content-Type", contentType)
+	pw, err := w.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(pw, content)
+	return err
+}
+
 func addFields(params url.Values, w *multipart.Writer) error {
-	for k, vv := range params {
-		for _, v := range vv {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		for _, v := range params[k] {
 			fw, err := w.CreateFormField(k)
 			if err != nil {
 				return err
@@ -0,0 +1,49 @@
+package runner
+
+import (
+	"fmt"
+
+	"github.com/lamoda/gonkey/models"
+	"github.com/lamoda/gonkey/variables"
+)
+
+// RequestBuilderContext describes the test whose request body is built
+type RequestBuilderContext struct {
+	// Test is the test with the variables substituted, its request body is passed as GetRequest
+	// and can be used as the template of the built body
+	Test      models.TestInterface
+	Variables *variables.Variables
+}
+
+// RequestBuilder builds the body of the request of the tests referring to it as "requestBuilder: name",
+// e.g. to sign the payload or to compute its fields, the returned bytes are sent as is
+type RequestBuilder func(ctx RequestBuilderContext) ([]byte, error)
+
+func (r *Runner) requestBuilder(v models.TestInterface) (RequestBuilder, error) {
+	builder, ok := r.config.RequestBuilders[v.GetRequestBuilder()]
+	if !ok {
+		return nil, fmt.Errorf("unknown requestBuilder %s", v.GetRequestBuilder())
+	}
+	return builder, nil
+}
+
+// buildRequest replaces the request body of the test with the body built by its builder
+func (r *Runner) buildRequest(v models.TestInterface) error {
+	builder, err := r.requestBuilder(v)
+	if err != nil {
+		return err
+	}
+
+	var body []byte
+	p := recovered("requestBuilder "+v.GetRequestBuilder(), func() {
+		body, err = builder(RequestBuilderContext{Test: v, Variables: r.config.Variables})
+	})
+	if p != nil {
+		return p
+	}
+	if err != nil {
+		return fmt.Errorf("requestBuilder %s failed: %s", v.GetRequestBuilder(), err)
+	}
+	v.SetRequest(string(body))
+	return nil
+}
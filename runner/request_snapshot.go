@@ -0,0 +1,96 @@
+package runner
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/kylelemons/godebug/diff"
+
+	"github.com/lamoda/gonkey/files"
+	"github.com/lamoda/gonkey/output"
+)
+
+// snapshotBoundary replaces the random boundary of the multipart requests in the snapshots
+const snapshotBoundary = "gonkey-boundary"
+
+// requestSnapshot renders the request as it's composed by the test: the method, the path with the query,
+// the headers sorted by name and the body as is. The headers added later by the HTTP client,
+// the interceptors and the signers are not included, the secrets are masked.
+func requestSnapshot(req *http.Request, secrets []string) []byte {
+	body := actualRequestBody(req)
+
+	names := make([]string, 0, len(req.Header))
+	for name := range req.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString(req.Method + " " + req.URL.RequestURI() + "\n")
+	// the host of the server differs between the environments, only the one set by the test is kept
+	if req.Host != "" && req.Host != req.URL.Host {
+		b.WriteString("Host: " + req.Host + "\n")
+	}
+	for _, name := range names {
+		for _, value := range req.Header[name] {
+			b.WriteString(name + ": " + value + "\n")
+		}
+	}
+	b.WriteString("\n")
+	b.WriteString(body)
+
+	snapshot := b.String()
+	if _, params, err := mime.ParseMediaType(req.Header.Get("Content-Type")); err == nil && params["boundary"] != "" {
+		snapshot = strings.Replace(snapshot, params["boundary"], snapshotBoundary, -1)
+	}
+	return []byte(output.MaskString(snapshot, secrets))
+}
+
+// checkRequestSnapshot compares the request with the snapshot byte by byte,
+// in update mode the snapshot is rewritten when they differ, the dry run only tells it would be
+func (r *Runner) checkRequestSnapshot(file string, snapshot []byte) []error {
+	update := func() []error {
+		if r.config.DryRun {
+			fmt.Printf("Request snapshot %s would be updated\n", file)
+			return nil
+		}
+		return writeRequestSnapshot(file, snapshot)
+	}
+
+	expected, err := files.ReadFile(file)
+	if err != nil {
+		if os.IsNotExist(err) && r.config.UpdateGolden {
+			return update()
+		}
+		return []error{fmt.Errorf("unable to read request snapshot %s: %s", file, err)}
+	}
+
+	if bytes.Equal(expected, snapshot) {
+		return nil
+	}
+	if r.config.UpdateGolden {
+		return update()
+	}
+	return []error{fmt.Errorf(
+		"request does not match snapshot %s (-expected +actual):\n%s",
+		file, diff.Diff(string(expected), string(snapshot)),
+	)}
+}
+
+func writeRequestSnapshot(file string, snapshot []byte) []error {
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return []error{fmt.Errorf("unable to update request snapshot %s: %s", file, err)}
+	}
+	if err := files.WriteFile(file, snapshot, 0644); err != nil {
+		return []error{fmt.Errorf("unable to update request snapshot %s: %s", file, err)}
+	}
+
+	fmt.Printf("Request snapshot %s updated\n", file)
+	return nil
+}
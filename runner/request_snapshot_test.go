@@ -0,0 +1,105 @@
+package runner
+
+import (
+	"bytes"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestSnapshot(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status": "created"}`))
+	}))
+	defer srv.Close()
+
+	RunWithTesting(t, &RunWithTestingParams{
+		Server:   srv,
+		TestsDir: filepath.Join("testdata", "request-snapshot"),
+	})
+}
+
+func TestRequestSnapshotMasksSecrets(t *testing.T) {
+	req, err := http.NewRequest(http.MethodPost, "http://localhost/login", strings.NewReader(`{"password": "s3cret"}`))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer s3cret")
+	req.Header.Set("Content-Type", "application/json")
+
+	snapshot := requestSnapshot(req, []string{"s3cret"})
+
+	assert.Equal(t, "POST /login\nAuthorization: Bearer ******\nContent-Type: application/json\n\n{\"password\": \"******\"}", string(snapshot))
+}
+
+func TestRequestSnapshotReplacesMultipartBoundary(t *testing.T) {
+	body := &bytes.Buffer{}
+	w := multipart.NewWriter(body)
+	require.NoError(t, w.WriteField("name", "report"))
+	require.NoError(t, w.Close())
+
+	req, err := http.NewRequest(http.MethodPost, "http://localhost/upload", body)
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+
+	snapshot := string(requestSnapshot(req, nil))
+
+	assert.NotContains(t, snapshot, w.Boundary())
+	assert.Contains(t, snapshot, "Content-Type: multipart/form-data; boundary=gonkey-boundary\n")
+	assert.Contains(t, snapshot, "--gonkey-boundary--")
+}
+
+func TestCheckRequestSnapshot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gonkey-request-snapshot")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "snapshots", "order.snapshot")
+
+	r := &Runner{config: &Config{}}
+	errs := r.checkRequestSnapshot(file, []byte("GET /orders\n\n"))
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "unable to read request snapshot")
+
+	// the missing snapshot is created in update mode
+	r.config.UpdateGolden = true
+	assert.Empty(t, r.checkRequestSnapshot(file, []byte("GET /orders\n\n")))
+	assert.Empty(t, r.checkRequestSnapshot(file, []byte("GET /orders\n\n")))
+
+	r.config.UpdateGolden = false
+	errs = r.checkRequestSnapshot(file, []byte("GET /orders?page=2\n\n"))
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "request does not match snapshot")
+	assert.Contains(t, errs[0].Error(), "-GET /orders\n+GET /orders?page=2")
+
+	r.config.UpdateGolden = true
+	assert.Empty(t, r.checkRequestSnapshot(file, []byte("GET /orders?page=2\n\n")))
+	content, err := ioutil.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, "GET /orders?page=2\n\n", string(content))
+}
+
+func TestCheckRequestSnapshotDryRun(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gonkey-request-snapshot")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "order.snapshot")
+
+	// the dry run doesn't create or rewrite the snapshots in update mode
+	r := &Runner{config: &Config{UpdateGolden: true, DryRun: true}}
+	assert.Empty(t, r.checkRequestSnapshot(file, []byte("GET /orders\n\n")))
+	_, err = os.Stat(file)
+	assert.True(t, os.IsNotExist(err))
+
+	require.NoError(t, ioutil.WriteFile(file, []byte("GET /orders\n\n"), 0644))
+	assert.Empty(t, r.checkRequestSnapshot(file, []byte("GET /orders?page=2\n\n")))
+	content, err := ioutil.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, "GET /orders\n\n", string(content))
+}
@@ -0,0 +1,58 @@
+package runner
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/lamoda/gonkey/compare"
+)
+
+// HeaderPolicy lists the headers every response must have, e.g. the security headers,
+// a response missing one of them fails the test with the name of the policy
+type HeaderPolicy struct {
+	Name string
+	// Headers are the required values by name, the matchers like $matchRegexp(...) can be used,
+	// an empty value requires the header with any value
+	Headers map[string]string
+}
+
+// checkRequiredHeaders checks the headers of the response against the policies
+func checkRequiredHeaders(policies []HeaderPolicy, headers http.Header) []error {
+	var errs []error
+	for _, policy := range policies {
+		names := make([]string, 0, len(policy.Headers))
+		for name := range policy.Headers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			values, ok := headers[http.CanonicalHeaderKey(name)]
+			if !ok {
+				errs = append(errs, fmt.Errorf("response has no header %s required by policy %s", name, policy.Name))
+				continue
+			}
+			expected := policy.Headers[name]
+			if expected == "" {
+				continue
+			}
+			if !matchesAny(expected, values) {
+				errs = append(errs, fmt.Errorf(
+					"response header %s %q does not match %q required by policy %s",
+					name, values[0], expected, policy.Name,
+				))
+			}
+		}
+	}
+	return errs
+}
+
+func matchesAny(expected string, values []string) bool {
+	for _, value := range values {
+		if len(compare.Compare(expected, value, compare.CompareParams{})) == 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+package runner
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lamoda/gonkey/checker"
+	"github.com/lamoda/gonkey/checker/response_body"
+	"github.com/lamoda/gonkey/models"
+)
+
+// retryPolicyOf returns the retry policy of the test, a test without the policy is executed once
+func retryPolicyOf(t models.TestInterface) models.RetryPolicy {
+	policy := models.RetryPolicy{Attempts: 1}
+	if p := t.GetRetryPolicy(); p != nil {
+		policy = *p
+		if policy.Attempts < 1 {
+			policy.Attempts = 1
+		}
+	}
+	return policy
+}
+
+// defaultMaxRetryAfter caps the wait of Retry-After unless the retry policy sets MaxRetryAfter
+const defaultMaxRetryAfter = 60 * time.Second
+
+// retryDelay returns the pause before the next attempt: the delay of the policy, or the wait of Retry-After
+// in seconds or as an HTTP date if the service throttled the attempt with 429 Too Many Requests
+func retryDelay(policy models.RetryPolicy, result *models.Result, now time.Time) time.Duration {
+	delay := time.Duration(policy.Delay) * time.Second
+	if result.ResponseStatusCode != http.StatusTooManyRequests {
+		return delay
+	}
+	retryAfter, ok := parseRetryAfter(http.Header(result.ResponseHeaders).Get("Retry-After"), now)
+	if !ok {
+		return delay
+	}
+
+	max := defaultMaxRetryAfter
+	if policy.MaxRetryAfter > 0 {
+		max = time.Duration(policy.MaxRetryAfter) * time.Second
+	}
+	if retryAfter > max {
+		return max
+	}
+	return retryAfter
+}
+
+// parseRetryAfter parses the number of seconds or the HTTP date of Retry-After, the dates in the past
+// don't make the attempt wait
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	if wait := date.Sub(now); wait > 0 {
+		return wait, true
+	}
+	return 0, true
+}
+
+// attemptBodyChecker returns the checker of the bodies of the attempts configured the same way
+// as the response_body checker of the runner
+func (r *Runner) attemptBodyChecker() checker.CheckerInterface {
+	for _, c := range r.checkers {
+		if bodyChecker, ok := c.(*response_body.ResponseBodyChecker); ok {
+			return bodyChecker.WithoutUpdates()
+		}
+	}
+	return response_body.NewChecker()
+}
+
+// checkAttemptResponse compares the response of the attempt with the response expected for it by the retry policy
+func (r *Runner) checkAttemptResponse(t models.TestInterface, attempt int, expected models.AttemptResponse, result *models.Result) []error {
+	if expected.Status != 0 && expected.Status != result.ResponseStatusCode {
+		return []error{fmt.Errorf(
+			"attempt %d: server responded with status %d, expected %d",
+			attempt,
+			result.ResponseStatusCode,
+			expected.Status,
+		)}
+	}
+	if expected.Body == "" {
+		return nil
+	}
+
+	attemptTest := t.Clone()
+	attemptTest.SetResponses(map[int]string{result.ResponseStatusCode: expected.Body})
+
+	errs, err := r.attemptBodyChecker().Check(attemptTest, result)
+	if err != nil {
+		errs = []error{err}
+	}
+	for i, e := range errs {
+		errs[i] = fmt.Errorf("attempt %d: %s", attempt, e)
+	}
+	return errs
+}
@@ -1,20 +1,28 @@
 package runner
 
 import (
+	"bytes"
+	"database/sql"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"runtime"
 	"strings"
 	"time"
 
 	"github.com/lamoda/gonkey/checker"
 	"github.com/lamoda/gonkey/cmd_runner"
 	"github.com/lamoda/gonkey/fixtures"
+	"github.com/lamoda/gonkey/logging"
 	"github.com/lamoda/gonkey/mocks"
 	"github.com/lamoda/gonkey/models"
+	"github.com/lamoda/gonkey/openapi"
 	"github.com/lamoda/gonkey/output"
+	"github.com/lamoda/gonkey/querycount"
+	"github.com/lamoda/gonkey/signing"
 	"github.com/lamoda/gonkey/testloader"
 	"github.com/lamoda/gonkey/variables"
 )
@@ -26,6 +34,81 @@ type Config struct {
 	MocksLoader    *mocks.Loader
 	Variables      *variables.Variables
 	HttpProxyURL   *url.URL
+	// HTTP2 makes the client negotiate HTTP/2 with the TLS servers supporting it,
+	// the requests are sent with HTTP/1.1 otherwise
+	HTTP2 bool
+	// DisableKeepAlives makes the client open a new connection for every request, including the retries
+	// and the redirects, the connections are reused by default
+	DisableKeepAlives bool
+	// MaxIdleConns limits the idle connections kept for reuse to all the hosts, no limit if 0
+	MaxIdleConns int
+	// MaxIdleConnsPerHost limits the idle connections kept for reuse to each host,
+	// http.DefaultMaxIdleConnsPerHost (2) if 0
+	MaxIdleConnsPerHost int
+	// DryRun only loads and validates tests, fixtures and mocks without sending requests
+	DryRun bool
+	// ServerLogs is the source of the tested service logs, the logs written during a test
+	// are added to its result
+	ServerLogs io.Reader
+	// FixturesLocks serializes the tests loading fixtures into the same tables,
+	// it allows to run several runners in parallel against the same database
+	FixturesLocks *fixtures.TableLocks
+	// ServerLogsMaxSize limits the size of the logs kept for a test (64KB by default),
+	// only the latest logs are kept
+	ServerLogsMaxSize int
+	// Hosts of the named servers, a test targets one of them with "server: <name>",
+	// the tests without server are sent to Host
+	Hosts map[string]string
+	// SummaryGate makes Run return SummaryGateError if the results of the tests as a whole
+	// don't satisfy it
+	SummaryGate *SummaryGate
+	// Headers are added to the requests of all tests, variables can be used in the values,
+	// the headers of a test win over the defaults with the same name
+	Headers map[string]string
+	// HeaderPolicies are the headers required in the responses of all tests, e.g. HSTS,
+	// the tests opt out with disableCheckers: [required_headers]
+	HeaderPolicies []HeaderPolicy
+	// OpenAPI validates the requests of the tests before they are sent, the tests with
+	// the requests not conforming to the spec fail. The responses are validated too if enabled.
+	OpenAPI *openapi.Validator
+	// RequestInterceptor is called with every request of the tests right before it's sent
+	// (after the variables are substituted), e.g. to sign the request
+	RequestInterceptor func(*http.Request)
+	// ResponseInterceptor is called with every response before the checkers, the body of the response
+	// can be read by the interceptor, it doesn't affect the checks
+	ResponseInterceptor func(*http.Response)
+	// RequestSigner signs every request of the tests after the request interceptor,
+	// e.g. signing.NewSigV4 for AWS services or signing.NewOAuth2ClientCredentials for OAuth2,
+	// several signers are combined with signing.Chain
+	RequestSigner signing.Signer
+	// VariableProvider resolves the references {{ $secret:path }} in the tests, e.g. from Vault,
+	// the environment variables are used by default. The secrets are masked in the outputs.
+	VariableProvider variables.Provider
+	// UniqueValues generate {{ $uniqueInt }} in the tests, the process-wide generator is used by default,
+	// so the values are unique across the runners in parallel
+	UniqueValues *variables.UniqueValues
+	// UpdateGolden makes the runner rewrite the request snapshots (requestSnapshotFile) when they differ
+	// instead of failing the test, the checkers have the same option of their own
+	UpdateGolden bool
+	// RateLimit paces the requests of the tests, including the retries and the redirects followed,
+	// the limiter can be shared by the runners in parallel to limit their requests in total
+	RateLimit *RateLimiter
+	// QueryCounter counts the DB queries of the service for maxDbQueries of the tests,
+	// the service must open its DB with querycount.NewConnector sharing the counter
+	QueryCounter *querycount.Counter
+	// DB is the database of the service, the rows of its tables are counted for idempotency of the tests
+	DB *sql.DB
+	// GoroutineLeaks fails the tests leaving more goroutines running than before them,
+	// for the services run in the process of the tests
+	GoroutineLeaks *GoroutineLeaks
+	// Redaction removes the personal data from the results passed to the outputs, the checks
+	// see the original results
+	Redaction *output.Redaction
+	// RequestBuilders build the request bodies of the tests referring to them as "requestBuilder: name"
+	RequestBuilders map[string]RequestBuilder
+	// Logger receives the structured events of the runner and the mocks, e.g. "test finished"
+	// or "mock called", logging.FromEnv by default
+	Logger logging.Logger
 }
 
 type testExecutor func(models.TestInterface) (*models.Result, error)
@@ -35,25 +118,54 @@ type Runner struct {
 	loader               testloader.LoaderInterface
 	testExecutionHandler testHandler
 	output               []output.OutputInterface
+	reporters            []output.Reporter
 	checkers             []checker.CheckerInterface
 	client               *http.Client
+	serverLogs           *serverLogs
+	durations            []models.TestDuration
+	repeated             []models.RepeatedTest
+	logger               logging.Logger
+	// notPassed counts the executions of the tests which failed or weren't run
+	notPassed int
 
 	config *Config
 }
 
 func New(config *Config, loader testloader.LoaderInterface, handler testHandler) *Runner {
-	return &Runner{
+	r := &Runner{
 		config:               config,
 		loader:               loader,
 		testExecutionHandler: handler,
-		client:               newClient(config.HttpProxyURL),
+		client:               newClient(config),
+		logger:               config.Logger,
 	}
+	if r.logger == nil {
+		r.logger = logging.FromEnv()
+	}
+	if config.Mocks != nil {
+		config.Mocks.SetLogger(r.logger)
+	}
+	if config.ServerLogs != nil {
+		r.serverLogs = newServerLogs(config.ServerLogs, config.ServerLogsMaxSize)
+	}
+	if config.VariableProvider != nil {
+		config.Variables.SetProvider(config.VariableProvider)
+	}
+	if config.UniqueValues != nil {
+		config.Variables.SetUniqueValues(config.UniqueValues)
+	}
+	return r
 }
 
 func (r *Runner) AddOutput(o ...output.OutputInterface) {
 	r.output = append(r.output, o...)
 }
 
+// AddReporters adds the reporters receiving the result of every test right after it finishes
+func (r *Runner) AddReporters(reporters ...output.Reporter) {
+	r.reporters = append(r.reporters, reporters...)
+}
+
 func (r *Runner) AddCheckers(c ...checker.CheckerInterface) {
 	r.checkers = append(r.checkers, c...)
 }
@@ -63,8 +175,26 @@ func (r *Runner) Run() error {
 	if err != nil {
 		return err
 	}
+	var filteredOut func(name string) bool
+	if loader, ok := r.loader.(testloader.FilteringLoaderInterface); ok {
+		filteredOut = loader.IsFilteredOut
+	}
+	if tests, err = orderByDependencies(tests, filteredOut); err != nil {
+		return err
+	}
+	// the dry run reports the missing files as the errors of the tests referencing them
+	if !r.config.DryRun {
+		if err := r.checkMissingFiles(tests); err != nil {
+			return err
+		}
+	}
 
+	r.logger.Log("tests loaded", "tests", len(tests), "uniqueSeed", r.config.Variables.UniqueValues().Seed())
+
+	stats := &summaryStats{}
 	hasFocused := checkHasFocused(tests)
+	// passed tells the tests passed by name, a name is passed if all its tests and their runs passed
+	passed := make(map[string]bool, len(tests))
 	for _, t := range tests {
 		// make a copy because go test runner runs tests in separate goroutines
 		// and without copy tests will override each other
@@ -79,55 +209,228 @@ func (r *Runner) Run() error {
 				test.SetStatus("skipped")
 			}
 		}
+		if reason := dependencySkipReason(test, passed); reason != "" && test.GetStatus() == "" {
+			test.SetStatus("skipped")
+			test.SetReason(reason)
+		}
 
-		testExecutor := func(testInterface models.TestInterface) (*models.Result, error) {
-			testResult, err := r.executeTest(test)
-			if err != nil {
-				return nil, err
-			}
-
-			for _, o := range r.output {
-				if err := o.Process(test, testResult); err != nil {
-					return nil, err
-				}
-			}
-			return testResult, nil
+		run := r.runRepeated
+		switch {
+		case test.GetFuzz() != nil:
+			run = r.runFuzz
+		case test.GetSteps() != nil:
+			run = r.runSteps
 		}
-		err := r.testExecutionHandler(test, testExecutor)
-		if err != nil {
-			return fmt.Errorf("test %s error: %s", test.GetName(), err)
+		notPassed := r.notPassed
+		if err := run(test, stats); err != nil {
+			return err
+		}
+		testPassed := r.notPassed == notPassed
+		if previous, ok := passed[test.GetName()]; ok {
+			testPassed = testPassed && previous
 		}
+		passed[test.GetName()] = testPassed
+	}
 
+	if r.config.SummaryGate != nil {
+		return r.config.SummaryGate.check(stats)
 	}
 
 	return nil
 }
 
+// executeAndOutput executes the test and passes the result to the outputs
+func (r *Runner) executeAndOutput(test models.TestInterface, stats *summaryStats) (*models.Result, error) {
+	execute := r.executeTest
+	if r.config.DryRun {
+		execute = r.validateTest
+	}
+
+	unlock, err := r.lockFixtures(test)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if r.serverLogs != nil {
+		r.serverLogs.reset()
+	}
+
+	r.logger.Log("test started", "test", test.GetName(), "file", test.GetFileName())
+
+	// skipped and broken tests are reported by the outputs as well
+	testResult, execErr := execute(test)
+	secrets := r.config.Variables.Secrets()
+	if execErr != nil && !isNotRun(execErr) {
+		err := output.MaskError(execErr, secrets)
+		r.logger.Log("test finished", "test", test.GetName(), "status", "error", "error", err)
+		return nil, err
+	}
+	if execErr != nil || !testResult.Passed() {
+		r.notPassed++
+	}
+	testResult = r.config.Redaction.RedactResult(output.MaskResult(testResult, secrets))
+	r.logger.Log("test finished", "test", test.GetName(), "status", resultStatus(test, testResult, execErr),
+		"duration", testResult.Duration)
+
+	if execErr == nil && !r.config.DryRun {
+		r.durations = append(r.durations, models.TestDuration{
+			Name:             test.GetName(),
+			FileName:         test.GetFileName(),
+			Duration:         testResult.Duration,
+			FixturesDuration: testResult.FixturesDuration,
+		})
+	}
+
+	if execErr == nil {
+		if r.serverLogs != nil {
+			testResult.ServerLogs = r.serverLogs.collect()
+		}
+
+		if r.config.SummaryGate != nil {
+			stats.add(r.config.SummaryGate, test, testResult)
+		}
+	}
+
+	for _, reporter := range r.reporters {
+		reporter.OnTestResult(testResult)
+	}
+	for _, o := range r.output {
+		if err := o.Process(test, testResult); err != nil {
+			return nil, err
+		}
+	}
+	return testResult, execErr
+}
+
+// names of the checks made by the runner itself
+const (
+	mocksCheck           = "mocks"
+	retryPolicyCheck     = "retryPolicy"
+	openAPIRequestCheck  = "openapi_request"
+	openAPIResponseCheck = "openapi_response"
+	redirectsCheck       = "redirects"
+	locationCheck        = "response_location"
+	requiredHeadersCheck = "required_headers"
+	dbQueriesCheck       = "db_queries"
+	idempotencyCheck     = "idempotency"
+	conditionalCheck     = "conditional_request"
+	requestSnapshotCheck = "request_snapshot"
+	assertionsCheck      = "assertions"
+)
+
+// allTablesLock is used when the loader can't tell which tables are touched by the fixtures
+const allTablesLock = "*"
+
+// lockFixtures acquires the locks of the tables touched by the fixtures of the test
+func (r *Runner) lockFixtures(test models.TestInterface) (unlock func(), err error) {
+	if r.config.FixturesLocks == nil || r.config.FixturesLoader == nil || r.config.DryRun {
+		return func() {}, nil
+	}
+	// skipped and broken tests don't load fixtures
+	if len(test.Fixtures()) == 0 || test.GetStatus() != "" {
+		return func() {}, nil
+	}
+
+	tables := []string{allTablesLock}
+	if lister, ok := r.config.FixturesLoader.(fixtures.TablesLister); ok {
+		tables, err = lister.Tables(test.Fixtures())
+		if err != nil {
+			return nil, fmt.Errorf("unable to load fixtures [%s], error:\n%s", strings.Join(test.Fixtures(), ", "), err)
+		}
+	}
+
+	return r.config.FixturesLocks.Lock(tables), nil
+}
+
 var (
 	errTestSkipped = errors.New("test was skipped")
 	errTestBroken  = errors.New("test was broken")
 )
 
+// notRunError returns the error telling that the test is skipped or broken with its reason,
+// nil is returned for the tests which should be run
+func notRunError(v models.TestInterface) error {
+	var err error
+	switch v.GetStatus() {
+	case "broken":
+		err = errTestBroken
+	case "skipped":
+		err = errTestSkipped
+	default:
+		return nil
+	}
+
+	if reason := v.GetReason(); reason != "" {
+		return fmt.Errorf("%w: %s", err, reason)
+	}
+	return err
+}
+
+func isNotRun(err error) bool {
+	return errors.Is(err, errTestSkipped) || errors.Is(err, errTestBroken)
+}
+
+// resultStatus is the status of the executed test for the logger
+func resultStatus(test models.TestInterface, result *models.Result, execErr error) string {
+	switch {
+	case isNotRun(execErr):
+		return test.GetStatus()
+	case result.Passed():
+		return "passed"
+	default:
+		return "failed"
+	}
+}
+
 func (r *Runner) executeTest(v models.TestInterface) (*models.Result, error) {
 
-	if v.GetStatus() != "" {
-		if v.GetStatus() == "broken" {
-			return &models.Result{Test: v}, errTestBroken
-		}
+	if err := notRunError(v); err != nil {
+		return &models.Result{Test: v}, err
+	}
+
+	start := time.Now()
+	var fixturesDuration time.Duration
+
+	r.config.Variables.ResetUniqueNames()
+	r.config.Variables.Load(v.GetCombinedVariables())
+	v = r.config.Variables.Apply(r.withDefaultHeaders(v))
+	if err := r.config.Variables.SecretsErr(); err != nil {
+		return nil, err
+	}
 
-		if v.GetStatus() == "skipped" {
-			return &models.Result{Test: v}, errTestSkipped
+	// the body is built once, the retries and the repeated idempotent request send the same body
+	if v.GetRequestBuilder() != "" {
+		if err := r.buildRequest(v); err != nil {
+			if p, ok := err.(*PanicError); ok {
+				return panicResult(v, p), nil
+			}
+			return nil, err
 		}
 	}
 
-	r.config.Variables.Load(v.GetCombinedVariables())
-	v = r.config.Variables.Apply(v)
+	restoreEnv, err := setEnv(v.GetEnv())
+	if err != nil {
+		return nil, err
+	}
+	defer restoreEnv()
+
+	if r.config.OpenAPI != nil {
+		result, err := r.validateRequest(v)
+		if err != nil || result != nil {
+			return result, err
+		}
+	}
 
 	// load fixtures
 	if r.config.FixturesLoader != nil && v.Fixtures() != nil {
+		fixturesStart := time.Now()
 		if err := r.config.FixturesLoader.Load(v.Fixtures()); err != nil {
 			return nil, fmt.Errorf("unable to load fixtures [%s], error:\n%s", strings.Join(v.Fixtures(), ", "), err)
 		}
+		fixturesDuration = time.Since(fixturesStart)
+		r.logger.Log("fixtures loaded", "test", v.GetName(), "fixtures", strings.Join(v.Fixtures(), ","),
+			"duration", fixturesDuration)
 	}
 
 	// reset mocks
@@ -138,10 +441,13 @@ func (r *Runner) executeTest(v models.TestInterface) (*models.Result, error) {
 	}
 
 	// load mocks
+	var activeMocks []string
 	if r.config.MocksLoader != nil && v.ServiceMocks() != nil {
-		if err := r.config.MocksLoader.Load(v.ServiceMocks()); err != nil {
+		if err := r.config.MocksLoader.WithBaseDir(v.GetBaseDir()).Load(v.ServiceMocks()); err != nil {
 			return nil, err
 		}
+		activeMocks = r.config.MocksLoader.Active(v.ServiceMocks())
+		r.logger.Log("mocks loaded", "test", v.GetName(), "mocks", strings.Join(activeMocks, ","))
 	}
 
 	// launch script in cmd interface
@@ -158,66 +464,434 @@ func (r *Runner) executeTest(v models.TestInterface) (*models.Result, error) {
 		fmt.Printf("Sleep %ds before requests\n", pause)
 	}
 
-	req, err := newRequest(r.config.Host, v)
+	policy := retryPolicyOf(v)
+	if len(policy.Responses) > policy.Attempts {
+		return nil, fmt.Errorf(
+			"retryPolicy has %d expected responses, but only %d attempts",
+			len(policy.Responses),
+			policy.Attempts,
+		)
+	}
+
+	goroutinesBefore := runtime.NumGoroutine()
+
+	var result *models.Result
+	for attempt := 1; ; attempt++ {
+		if attempt > 1 && r.config.Mocks != nil {
+			// the calls of the mocks are checked for the last attempt only
+			r.config.Mocks.ResetCalls()
+		}
+
+		var checkErrs []error
+		var err error
+		result, checkErrs, err = r.executeAttempt(v)
+		if p, ok := err.(*PanicError); ok {
+			// the panicked hooks aren't retried, the mocks and the fixtures are finished as usual
+			result = panicResult(v, p)
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if attempt <= len(policy.Responses) {
+			if errs := r.checkAttemptResponse(v, attempt, policy.Responses[attempt-1], result); len(errs) != 0 {
+				// the checks of the attempt are irrelevant as it didn't get the expected response
+				result.Checks = []models.CheckResult{{Checker: retryPolicyCheck, Errors: errs}}
+				result.Errors = append(result.Errors, errs...)
+				break
+			}
+		}
+
+		if len(checkErrs) == 0 {
+			if attempt < len(policy.Responses) {
+				err := fmt.Errorf(
+					"response matched after %d attempts, but %d attempts are expected by retryPolicy",
+					attempt,
+					len(policy.Responses),
+				)
+				result.Checks = append(result.Checks, models.CheckResult{Checker: retryPolicyCheck, Errors: []error{err}})
+				result.Errors = append(result.Errors, err)
+			}
+			break
+		}
+
+		if attempt >= policy.Attempts {
+			result.Errors = append(result.Errors, checkErrs...)
+			break
+		}
+
+		r.logger.Log("attempt failed", "test", v.GetName(), "attempt", attempt, "errors", len(checkErrs))
+
+		time.Sleep(retryDelay(policy, result, time.Now()))
+	}
+
+	if r.config.GoroutineLeaks != nil && !checkerDisabled(v, goroutinesCheck) {
+		errs := r.checkGoroutineLeaks(goroutinesBefore)
+		result.Checks = append(result.Checks, models.CheckResult{Checker: goroutinesCheck, Errors: errs})
+		result.Errors = append(result.Errors, errs...)
+	}
+
+	if r.config.Mocks != nil {
+		result.SpiedRequests = r.config.Mocks.SpiedRequests()
+		errs := r.config.Mocks.EndRunningContext()
+		result.Checks = append([]models.CheckResult{{Checker: mocksCheck, Errors: errs}}, result.Checks...)
+		result.Errors = append(errs, result.Errors...)
+	}
+
+	if cleaner, ok := r.config.FixturesLoader.(fixtures.Cleaner); ok && v.Fixtures() != nil {
+		cleanStart := time.Now()
+		if err := cleaner.Clean(v.Fixtures()); err != nil {
+			return nil, fmt.Errorf("unable to clean fixtures [%s], error:\n%s", strings.Join(v.Fixtures(), ", "), err)
+		}
+		fixturesDuration += time.Since(cleanStart)
+	}
+
+	result.ActiveMocks = activeMocks
+	result.FixturesDuration = fixturesDuration
+	result.Duration = time.Since(start) - fixturesDuration
+	return result, nil
+}
+
+// hostOf returns the host of the server targeted by the test
+func (r *Runner) hostOf(v models.TestInterface) (string, error) {
+	name := v.GetServer()
+	if name == "" {
+		return r.config.Host, nil
+	}
+
+	host, ok := r.config.Hosts[name]
+	if !ok {
+		return "", fmt.Errorf("unknown server %q", name)
+	}
+	return host, nil
+}
+
+// do sends the request passing it to the request interceptor and the signer first,
+// the request waits for the rate limit before it is signed as the signatures may expire
+func (r *Runner) do(req *http.Request) (*http.Response, error) {
+	return r.send(req, true)
+}
+
+// send sends the request after the request interceptor, the request is signed with RequestSigner if sign is set
+func (r *Runner) send(req *http.Request, sign bool) (*http.Response, error) {
+	r.config.RateLimit.Wait()
+	if r.config.RequestInterceptor != nil {
+		if p := recovered("RequestInterceptor", func() { r.config.RequestInterceptor(req) }); p != nil {
+			return nil, p
+		}
+	}
+	if sign && r.config.RequestSigner != nil {
+		var err error
+		if p := recovered("RequestSigner", func() { err = r.config.RequestSigner.Sign(req) }); p != nil {
+			return nil, p
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign the request: %s", err)
+		}
+	}
+	return r.client.Do(req)
+}
+
+// executeAttempt sends the request of the test and checks the response,
+// the errors of the checkers are returned separately to decide whether the request should be retried
+func (r *Runner) executeAttempt(v models.TestInterface) (*models.Result, []error, error) {
+	host, err := r.hostOf(v)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	resp, err := r.client.Do(req)
+	req, err := newRequest(host, v)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	req, err = withProxy(req, v.GetProxy())
+	if err != nil {
+		return nil, nil, err
 	}
 
-	body, err := ioutil.ReadAll(resp.Body)
+	var idempotencyKeyValue string
+	if idempotency := v.GetIdempotency(); idempotency != nil {
+		if len(idempotency.Tables) != 0 && r.config.DB == nil {
+			return nil, nil, errors.New("idempotency tables are set, but the runner has no DB")
+		}
+		idempotencyKeyValue, err = idempotencyKey(req, idempotency)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
 
-	_ = resp.Body.Close()
+	// the snapshot is taken before the request is passed to the interceptor and the signer
+	var snapshot []byte
+	if v.GetRequestSnapshotFile() != "" {
+		snapshot = requestSnapshot(req, r.config.Variables.Secrets())
+	}
 
+	if v.GetMaxDbQueries() != nil {
+		if r.config.QueryCounter == nil {
+			return nil, nil, errors.New("maxDbQueries is set, but the runner has no query counter")
+		}
+		r.config.QueryCounter.Reset()
+	}
+
+	if err := r.prepareCheckers(v); err != nil {
+		return nil, nil, err
+	}
+
+	var resp *http.Response
+	var redirects *redirectChain
+	requestStart := time.Now()
+	if v.GetRedirects() != nil {
+		resp, redirects, err = r.doFollowingRedirects(req)
+	} else {
+		resp, err = r.do(req)
+	}
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	body, err := readBody(v, resp)
+	if err != nil {
+		return nil, nil, err
+	}
+	requestDuration := time.Since(requestStart)
+
+	// the queries are counted until the body is read, the service may query the DB while streaming it
+	dbQueries := 0
+	if v.GetMaxDbQueries() != nil {
+		dbQueries = r.config.QueryCounter.Count()
+	}
+
+	if r.config.ResponseInterceptor != nil {
+		resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+		if p := recovered("ResponseInterceptor", func() { r.config.ResponseInterceptor(resp) }); p != nil {
+			return nil, nil, p
+		}
 	}
 
 	bodyStr := string(body)
+	r.logger.Log("response received", "test", v.GetName(), "method", req.Method, "url", req.URL.String(),
+		"status", resp.StatusCode)
 
 	result := models.Result{
 		Path:                req.URL.Path,
 		Query:               req.URL.RawQuery,
 		RequestBody:         actualRequestBody(req),
+		RequestCurl:         curlCommand(req),
 		ResponseBody:        bodyStr,
 		ResponseContentType: resp.Header.Get("Content-Type"),
 		ResponseStatusCode:  resp.StatusCode,
 		ResponseStatus:      resp.Status,
 		ResponseHeaders:     resp.Header,
+		ResponseTrailers:    resp.Trailer,
+		ResponseProto:       resp.Proto,
+		RequestDuration:     requestDuration,
 		Test:                v,
 	}
+	if redirects != nil {
+		result.Redirects = redirects.hops
+	}
+
+	// the requests are repeated before the script and the checks, they see the state after all the runs
+	var idempotencyErrs []error
+	if v.GetIdempotency() != nil {
+		idempotencyErrs, err = r.repeatIdempotent(v, host, idempotencyKeyValue, &result)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var conditionalErrs []error
+	if v.GetConditionalRequest() != nil {
+		conditionalErrs, err = r.repeatConditional(v, host, resp)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
 
 	// launch script in cmd interface
 	if v.AfterRequestScriptPath() != "" {
 		if err := cmd_runner.CmdRun(v.AfterRequestScriptPath(), v.AfterRequestScriptTimeout()); err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 	}
 
-	if r.config.Mocks != nil {
-		errs := r.config.Mocks.EndRunningContext()
-		result.Errors = append(result.Errors, errs...)
+	if err := r.setVariablesFromResponse(v, result.ResponseContentType, bodyStr, resp.StatusCode); err != nil {
+		return nil, nil, err
 	}
 
-	if err := r.setVariablesFromResponse(v, result.ResponseContentType, bodyStr, resp.StatusCode); err != nil {
-		return nil, err
+	// the location is saved to the variable before they are applied, so the test can use it as well
+	var location *url.URL
+	var locationErr error
+	if expected, ok := v.GetResponseLocations()[resp.StatusCode]; ok {
+		location, locationErr = resolveLocation(req, resp)
+		if expected.Variable != "" && location != nil {
+			r.config.Variables.Merge(variables.New().Add(variables.NewVariable(expected.Variable, location.RequestURI())))
+		}
 	}
 
+	v, assertionsMatched := withAssertions(v, resp.StatusCode)
+
 	r.config.Variables.Load(v.GetCombinedVariables())
 	v = r.config.Variables.Apply(v)
+	if err := r.config.Variables.SecretsErr(); err != nil {
+		return nil, nil, err
+	}
+	if err := evaluateComparisonParams(v, r.config.Variables, req); err != nil {
+		return nil, nil, err
+	}
+
+	// the checks are repeated by assertRetry on the same response
+	checkErrs, err := r.retryAssertions(v, &result, func() ([]error, error) {
+		var checkErrs []error
+		for _, c := range r.checkers {
+			if checkerDisabled(v, checker.Name(c)) {
+				continue
+			}
+			var errs []error
+			var err error
+			// the panic fails the check, the other checkers are run as usual
+			if p := recovered(checker.Name(c), func() { errs, err = c.Check(v, &result) }); p != nil {
+				errs = []error{p}
+			}
+			if err != nil {
+				return nil, err
+			}
+			r.logger.Log("checker finished", "test", v.GetName(), "checker", checker.Name(c), "errors", len(errs))
+			result.Checks = append(result.Checks, models.CheckResult{Checker: checker.Name(c), Errors: errs})
+			checkErrs = append(checkErrs, errs...)
+		}
+
+		// the status not matching any block fails the response_body checker unless responseStatus is set
+		if status := v.GetResponseStatus(); !assertionsMatched && status.Match(resp.StatusCode) {
+			errs := []error{fmt.Errorf("no assertions block matches status %d", resp.StatusCode)}
+			result.Checks = append(result.Checks, models.CheckResult{Checker: assertionsCheck, Errors: errs})
+			checkErrs = append(checkErrs, errs...)
+		}
+
+		if snapshot != nil && !checkerDisabled(v, requestSnapshotCheck) {
+			errs := r.checkRequestSnapshot(v.GetRequestSnapshotFile(), snapshot)
+			result.Checks = append(result.Checks, models.CheckResult{Checker: requestSnapshotCheck, Errors: errs})
+			checkErrs = append(checkErrs, errs...)
+		}
+
+		if redirects != nil && !checkerDisabled(v, redirectsCheck) {
+			errs := checkRedirects(v.GetRedirects(), redirects)
+			result.Checks = append(result.Checks, models.CheckResult{Checker: redirectsCheck, Errors: errs})
+			checkErrs = append(checkErrs, errs...)
+		}
+
+		if expected, ok := v.GetResponseLocations()[resp.StatusCode]; ok && !checkerDisabled(v, locationCheck) {
+			errs := []error{locationErr}
+			if locationErr == nil {
+				errs = checkLocation(expected, location)
+			}
+			result.Checks = append(result.Checks, models.CheckResult{Checker: locationCheck, Errors: errs})
+			checkErrs = append(checkErrs, errs...)
+		}
+
+		if len(r.config.HeaderPolicies) != 0 && !checkerDisabled(v, requiredHeadersCheck) {
+			errs := checkRequiredHeaders(r.config.HeaderPolicies, resp.Header)
+			result.Checks = append(result.Checks, models.CheckResult{Checker: requiredHeadersCheck, Errors: errs})
+			checkErrs = append(checkErrs, errs...)
+		}
+
+		if limit := v.GetMaxDbQueries(); limit != nil && !checkerDisabled(v, dbQueriesCheck) {
+			var errs []error
+			if dbQueries > *limit {
+				errs = append(errs, fmt.Errorf("the service ran %d DB queries, expected at most %d", dbQueries, *limit))
+			}
+			result.Checks = append(result.Checks, models.CheckResult{Checker: dbQueriesCheck, Errors: errs})
+			checkErrs = append(checkErrs, errs...)
+		}
+
+		if v.GetIdempotency() != nil && !checkerDisabled(v, idempotencyCheck) {
+			result.Checks = append(result.Checks, models.CheckResult{Checker: idempotencyCheck, Errors: idempotencyErrs})
+			checkErrs = append(checkErrs, idempotencyErrs...)
+		}
+
+		if v.GetConditionalRequest() != nil && !checkerDisabled(v, conditionalCheck) {
+			result.Checks = append(result.Checks, models.CheckResult{Checker: conditionalCheck, Errors: conditionalErrs})
+			checkErrs = append(checkErrs, conditionalErrs...)
+		}
+
+		if r.config.OpenAPI != nil && r.config.OpenAPI.ValidatesResponses() && !checkerDisabled(v, openAPIResponseCheck) {
+			errs := r.config.OpenAPI.ValidateResponse(req, resp.StatusCode, resp.Header, body)
+			result.Checks = append(result.Checks, models.CheckResult{Checker: openAPIResponseCheck, Errors: errs})
+			checkErrs = append(checkErrs, errs...)
+		}
 
+		return checkErrs, nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return &result, checkErrs, nil
+}
+
+// prepareCheckers lets the checkers take the state of the service before the request
+func (r *Runner) prepareCheckers(v models.TestInterface) error {
 	for _, c := range r.checkers {
-		errs, err := c.Check(v, &result)
+		preparing, ok := c.(checker.PreparingChecker)
+		if !ok || checkerDisabled(v, checker.Name(c)) {
+			continue
+		}
+		var err error
+		if p := recovered(checker.Name(c), func() { err = preparing.Prepare(v) }); p != nil {
+			return p
+		}
 		if err != nil {
-			return nil, err
+			return err
 		}
-		result.Errors = append(result.Errors, errs...)
+	}
+	return nil
+}
+
+// readBody reads the body of the response, the stream of the test is read until its timeout expires
+func readBody(v models.TestInterface, resp *http.Response) ([]byte, error) {
+	defer func() { _ = resp.Body.Close() }()
+	if stream := v.GetStreamResponse(); stream != nil {
+		return readStream(resp.Body, time.Duration(stream.Timeout)*time.Second)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+// checkerDisabled tells if the test skips the checker with the name
+func checkerDisabled(v models.TestInterface, name string) bool {
+	for _, disabled := range v.GetDisabledCheckers() {
+		if disabled == name {
+			return true
+		}
+	}
+	return false
+}
+
+// validateRequest checks the request of the test against the OpenAPI spec,
+// the result is returned only if the request doesn't conform to it and mustn't be sent
+func (r *Runner) validateRequest(v models.TestInterface) (*models.Result, error) {
+	host, err := r.hostOf(v)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := newRequest(host, v)
+	if err != nil {
+		return nil, err
+	}
+
+	errs := r.config.OpenAPI.ValidateRequest(req)
+	if len(errs) == 0 {
+		return nil, nil
 	}
 
-	return &result, nil
+	return &models.Result{
+		Path:        req.URL.Path,
+		Query:       req.URL.RawQuery,
+		RequestBody: actualRequestBody(req),
+		Test:        v,
+		Checks:      []models.CheckResult{{Checker: openAPIRequestCheck, Errors: errs}},
+		Errors:      errs,
+	}, nil
 }
 
 func (r *Runner) setVariablesFromResponse(t models.TestInterface, contentType, body string, statusCode int) error {
@@ -0,0 +1,90 @@
+package runner
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lamoda/gonkey/models"
+	"github.com/lamoda/gonkey/testloader/yaml_file"
+	"github.com/lamoda/gonkey/variables"
+)
+
+// shippedChecker passes on the third check of the order, the order /orders/never is never shipped
+type shippedChecker struct {
+	mu     sync.Mutex
+	checks map[string]int
+}
+
+func (c *shippedChecker) Name() string {
+	return "shipped"
+}
+
+func (c *shippedChecker) Check(t models.TestInterface, _ *models.Result) ([]error, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks[t.Path()]++
+	if t.Path() == "/orders/never" || c.checks[t.Path()] < 3 {
+		return []error{errors.New("order is not shipped")}, nil
+	}
+	return nil, nil
+}
+
+func TestAssertRetry(t *testing.T) {
+	var mu sync.Mutex
+	requests := map[string]int{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests[r.URL.Path]++
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"ok": true}`))
+	}))
+	defer srv.Close()
+
+	out := &resultsOutput{}
+	handler := NewConsoleHandler()
+	r := New(
+		&Config{
+			Host:      srv.URL,
+			Variables: variables.New(),
+		},
+		yaml_file.NewLoader(filepath.Join("testdata", "assert_retry")),
+		handler.HandleTest,
+	)
+	addCheckers(r, &RunWithTestingParams{})
+	checker := &shippedChecker{checks: map[string]int{}}
+	r.AddCheckers(checker)
+	r.AddOutput(out)
+
+	require.NoError(t, r.Run())
+
+	assert.Equal(t, 2, handler.Summary().Total)
+	assert.Equal(t, 1, handler.Summary().Failed)
+	require.Len(t, out.results, 2)
+
+	// the checks are repeated, the request is sent once
+	assert.True(t, out.results[0].Passed())
+	assert.Equal(t, 3, checker.checks["/orders"])
+	assert.Equal(t, 1, requests["/orders"])
+	assert.Len(t, out.results[0].Checks, 6)
+
+	failed := out.results[1]
+	assert.Equal(t, 1, requests["/orders/never"])
+	assert.Greater(t, checker.checks["/orders/never"], 1)
+	checks := failed.Checks
+	require.Len(t, checks, 7)
+	assert.Equal(t, "shipped", checks[5].Checker)
+	assert.Equal(t, []error{errors.New("order is not shipped")}, checks[5].Errors)
+	assert.Equal(t, "assertRetry", checks[6].Checker)
+	require.Len(t, checks[6].Errors, 1)
+	assert.Contains(t, checks[6].Errors[0].Error(), "checks did not pass within 50ms after ")
+	assert.Contains(t, checks[6].Errors[0].Error(), "failed: shipped")
+	assert.Len(t, failed.Errors, 2)
+}
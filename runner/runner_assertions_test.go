@@ -0,0 +1,60 @@
+package runner
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lamoda/gonkey/models"
+	"github.com/lamoda/gonkey/testloader/yaml_file"
+	"github.com/lamoda/gonkey/variables"
+)
+
+func TestAssertions(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status, _ := strconv.Atoi(r.URL.Query().Get("status"))
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Status", strconv.Itoa(status))
+		w.WriteHeader(status)
+		if status < 400 {
+			_, _ = w.Write([]byte(`{"id": 1}`))
+		} else {
+			_, _ = w.Write([]byte(`{"error": "not found"}`))
+		}
+	}))
+	defer srv.Close()
+
+	results := map[string]*models.Result{}
+	r := New(
+		&Config{
+			Host:      srv.URL,
+			Variables: variables.New(),
+		},
+		yaml_file.NewLoader(filepath.Join("testdata", "assertions")),
+		func(test models.TestInterface, executeTest testExecutor) error {
+			result, err := executeTest(test)
+			if err != nil {
+				return err
+			}
+			results[test.GetName()] = result
+			return nil
+		},
+	)
+	addCheckers(r, &RunWithTestingParams{})
+	require.NoError(t, r.Run())
+	require.Len(t, results, 4)
+
+	assert.True(t, results["success"].Passed(), "%v", results["success"].Errors)
+	assert.True(t, results["failure"].Passed(), "%v", results["failure"].Errors)
+
+	require.Len(t, results["unexpected status"].Errors, 1)
+	assert.EqualError(t, results["unexpected status"].Errors[0], "server responded with status 500, expected 2xx, 400, 404")
+
+	require.Len(t, results["no matching block"].Errors, 1)
+	assert.EqualError(t, results["no matching block"].Errors[0], "no assertions block matches status 500")
+}
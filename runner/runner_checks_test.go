@@ -0,0 +1,70 @@
+package runner
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lamoda/gonkey/output/json_report"
+	"github.com/lamoda/gonkey/testloader/yaml_file"
+	"github.com/lamoda/gonkey/variables"
+)
+
+func TestChecksResultsInJsonReport(t *testing.T) {
+	srv := testStreamServer()
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "gonkey-json-report")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	reportPath := filepath.Join(dir, "report.json")
+
+	handler := NewConsoleHandler()
+	r := New(
+		&Config{
+			Host:      srv.URL,
+			Variables: variables.New(),
+		},
+		yaml_file.NewLoader(filepath.Join("testdata", "stream", "failing")),
+		handler.HandleTest,
+	)
+	addCheckers(r, &RunWithTestingParams{})
+	jsonOutput := json_report.NewOutput(reportPath)
+	r.AddOutput(jsonOutput)
+
+	require.NoError(t, r.Run())
+	require.NoError(t, jsonOutput.Finalize())
+
+	data, err := ioutil.ReadFile(reportPath)
+	require.NoError(t, err)
+	var report json_report.Report
+	require.NoError(t, json.Unmarshal(data, &report))
+
+	require.Len(t, report.Tests, 2)
+	for _, test := range report.Tests {
+		assert.Equal(t, "failed", test.Status)
+		require.Len(t, test.Checks, 5)
+
+		assert.Equal(t, "response_body", test.Checks[0].Checker)
+		assert.False(t, test.Checks[0].Passed)
+		assert.NotEmpty(t, test.Checks[0].Errors)
+
+		assert.Equal(t, "response_header", test.Checks[1].Checker)
+		assert.True(t, test.Checks[1].Passed)
+		assert.Empty(t, test.Checks[1].Errors)
+
+		assert.Equal(t, "response_cache", test.Checks[2].Checker)
+		assert.True(t, test.Checks[2].Passed)
+
+		assert.Equal(t, "response_cookie", test.Checks[3].Checker)
+		assert.True(t, test.Checks[3].Passed)
+
+		assert.Equal(t, "response_status_text", test.Checks[4].Checker)
+		assert.True(t, test.Checks[4].Passed)
+	}
+}
@@ -0,0 +1,98 @@
+package runner
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lamoda/gonkey/models"
+	"github.com/lamoda/gonkey/output/json_report"
+	"github.com/lamoda/gonkey/testloader/yaml_file"
+	"github.com/lamoda/gonkey/variables"
+)
+
+func TestComparisonParamsTemplates(t *testing.T) {
+	srv := testLegacyOrderServer()
+	defer srv.Close()
+
+	RunWithTesting(t, &RunWithTestingParams{
+		Server:   srv,
+		TestsDir: filepath.Join("testdata", "comparison-params", "passing"),
+	})
+}
+
+func TestComparisonParamsTemplatesMismatch(t *testing.T) {
+	srv := testLegacyOrderServer()
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "gonkey-comparison-params")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	reportPath := filepath.Join(dir, "report.json")
+
+	handler := NewConsoleHandler()
+	r := New(
+		&Config{
+			Host:      srv.URL,
+			Variables: variables.New(),
+		},
+		yaml_file.NewLoader(filepath.Join("testdata", "comparison-params", "failing")),
+		handler.HandleTest,
+	)
+	addCheckers(r, &RunWithTestingParams{})
+	jsonOutput := json_report.NewOutput(reportPath)
+	r.AddOutput(jsonOutput)
+
+	require.NoError(t, r.Run())
+	require.NoError(t, jsonOutput.Finalize())
+	assert.Equal(t, 1, handler.Summary().Failed)
+
+	data, err := ioutil.ReadFile(reportPath)
+	require.NoError(t, err)
+	var report json_report.Report
+	require.NoError(t, json.Unmarshal(data, &report))
+	require.Len(t, report.Tests, 1)
+	require.Len(t, report.Tests[0].Errors, 1)
+	assert.Contains(t, report.Tests[0].Errors[0], "map lengths do not match")
+}
+
+func TestComparisonParamsTemplatesInvalid(t *testing.T) {
+	srv := testLegacyOrderServer()
+	defer srv.Close()
+
+	r := New(
+		&Config{
+			Host:      srv.URL,
+			Variables: variables.New(),
+		},
+		yaml_file.NewLoader(filepath.Join("testdata", "comparison-params", "invalid")),
+		func(test models.TestInterface, executeTest testExecutor) error {
+			_, err := executeTest(test)
+			return err
+		},
+	)
+
+	err := r.Run()
+	require.Error(t, err)
+	assert.Equal(t,
+		`test the template is not a boolean error: unable to evaluate comparisonParams.ignoreArraysOrdering: `+
+			`ignoreArraysOrdering must be true or false, got "v2"`,
+		err.Error(),
+	)
+}
+
+// testLegacyOrderServer responds with the order having the field of the legacy API version
+func testLegacyOrderServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"id": 1, "legacy": true}`)
+	}))
+}
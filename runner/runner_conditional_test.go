@@ -0,0 +1,119 @@
+package runner
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lamoda/gonkey/testloader/yaml_file"
+	"github.com/lamoda/gonkey/variables"
+)
+
+const testLastModified = "Wed, 14 Oct 2026 10:00:00 GMT"
+
+func TestConditionalRequest(t *testing.T) {
+	srv, conditions := testConditionalServer()
+	defer srv.Close()
+
+	handler := NewConsoleHandler()
+	r := New(
+		&Config{
+			Host:      srv.URL,
+			Variables: variables.New(),
+		},
+		yaml_file.NewLoader(filepath.Join("testdata", "conditional", "passing")),
+		handler.HandleTest,
+	)
+	addCheckers(r, &RunWithTestingParams{})
+
+	require.NoError(t, r.Run())
+	assert.Equal(t, 3, handler.Summary().Total)
+	assert.Equal(t, 0, handler.Summary().Failed)
+	assert.Equal(t, []string{
+		`If-None-Match: "v1", If-Modified-Since: ` + testLastModified,
+		`If-None-Match: "v1", If-Modified-Since: `,
+		`If-None-Match: , If-Modified-Since: ` + testLastModified,
+	}, *conditions)
+}
+
+func TestConditionalRequestFailed(t *testing.T) {
+	srv, _ := testConditionalServer()
+	defer srv.Close()
+
+	handler := NewConsoleHandler()
+	r := New(
+		&Config{
+			Host:      srv.URL,
+			Variables: variables.New(),
+		},
+		yaml_file.NewLoader(filepath.Join("testdata", "conditional", "failing")),
+		handler.HandleTest,
+	)
+	addCheckers(r, &RunWithTestingParams{})
+	results := &resultsOutput{}
+	r.AddOutput(results)
+
+	require.NoError(t, r.Run())
+	assert.Equal(t, 3, handler.Summary().Failed)
+	require.Len(t, results.results, 3)
+
+	var errs [][]string
+	for _, result := range results.results {
+		var messages []string
+		for _, err := range result.Errors {
+			messages = append(messages, err.Error())
+		}
+		errs = append(errs, messages)
+	}
+	assert.Equal(t, [][]string{
+		{
+			"the conditional request got status 200, expected 304",
+			"the conditional request got a body of 9 bytes, expected an empty body",
+		},
+		{"the response has neither ETag nor Last-Modified header for the conditional request"},
+		{
+			"the response has no ETag header for the conditional request",
+			"the response has no Last-Modified header for the conditional request",
+		},
+	}, errs)
+}
+
+// testConditionalServer serves the products, the conditions of the conditional requests are recorded
+func testConditionalServer() (*httptest.Server, *[]string) {
+	var mu sync.Mutex
+	var conditions []string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		noneMatch, modifiedSince := r.Header.Get("If-None-Match"), r.Header.Get("If-Modified-Since")
+		if noneMatch != "" || modifiedSince != "" {
+			conditions = append(conditions, fmt.Sprintf("If-None-Match: %s, If-Modified-Since: %s", noneMatch, modifiedSince))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/products/1":
+			w.Header().Set("ETag", `"v1"`)
+			w.Header().Set("Last-Modified", testLastModified)
+			if noneMatch == `"v1"` || noneMatch == "" && modifiedSince == testLastModified {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			_, _ = fmt.Fprint(w, `{"id": 1}`)
+		case "/products/uncached":
+			w.Header().Set("ETag", `"v2"`)
+			_, _ = fmt.Fprint(w, `{"id": 2}`)
+		default:
+			_, _ = fmt.Fprint(w, `{"id": 3}`)
+		}
+	}))
+	return srv, &conditions
+}
@@ -0,0 +1,59 @@
+package runner
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lamoda/gonkey/testloader/yaml_file"
+	"github.com/lamoda/gonkey/variables"
+)
+
+func TestKeepAlives(t *testing.T) {
+	tests := []struct {
+		name              string
+		disableKeepAlives bool
+		connections       int64
+	}{
+		{name: "connections are reused", connections: 1},
+		{name: "new connection for every request", disableKeepAlives: true, connections: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var connections int64
+			srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = fmt.Fprint(w, "pong")
+			}))
+			srv.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+				if state == http.StateNew {
+					atomic.AddInt64(&connections, 1)
+				}
+			}
+			srv.Start()
+			defer srv.Close()
+
+			handler := NewConsoleHandler()
+			r := New(
+				&Config{
+					Host:              srv.URL,
+					Variables:         variables.New(),
+					DisableKeepAlives: tt.disableKeepAlives,
+				},
+				yaml_file.NewLoader(filepath.Join("testdata", "connections")),
+				handler.HandleTest,
+			)
+			addCheckers(r, &RunWithTestingParams{})
+
+			require.NoError(t, r.Run())
+			assert.Equal(t, 0, handler.Summary().Failed)
+			assert.Equal(t, tt.connections, atomic.LoadInt64(&connections))
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package runner
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lamoda/gonkey/models"
+	"github.com/lamoda/gonkey/output/json_report"
+	"github.com/lamoda/gonkey/querycount"
+	"github.com/lamoda/gonkey/testloader/yaml_file"
+	"github.com/lamoda/gonkey/variables"
+)
+
+func TestMaxDbQueries(t *testing.T) {
+	counter := querycount.NewCounter()
+	srv := testDbQueriesServer(counter)
+	defer srv.Close()
+
+	RunWithTesting(t, &RunWithTestingParams{
+		Server:       srv,
+		TestsDir:     filepath.Join("testdata", "db-queries", "passing"),
+		QueryCounter: counter,
+	})
+}
+
+func TestMaxDbQueriesExceeded(t *testing.T) {
+	counter := querycount.NewCounter()
+	srv := testDbQueriesServer(counter)
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "gonkey-db-queries")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	reportPath := filepath.Join(dir, "report.json")
+
+	handler := NewConsoleHandler()
+	r := New(
+		&Config{
+			Host:         srv.URL,
+			Variables:    variables.New(),
+			QueryCounter: counter,
+		},
+		yaml_file.NewLoader(filepath.Join("testdata", "db-queries", "failing")),
+		handler.HandleTest,
+	)
+	addCheckers(r, &RunWithTestingParams{})
+	jsonOutput := json_report.NewOutput(reportPath)
+	r.AddOutput(jsonOutput)
+
+	require.NoError(t, r.Run())
+	require.NoError(t, jsonOutput.Finalize())
+	assert.Equal(t, 1, handler.Summary().Failed)
+
+	data, err := ioutil.ReadFile(reportPath)
+	require.NoError(t, err)
+	var report json_report.Report
+	require.NoError(t, json.Unmarshal(data, &report))
+	require.Len(t, report.Tests, 1)
+	assert.Equal(t, []string{"the service ran 5 DB queries, expected at most 1"}, report.Tests[0].Errors)
+}
+
+func TestMaxDbQueriesWithoutCounter(t *testing.T) {
+	srv := testDbQueriesServer(querycount.NewCounter())
+	defer srv.Close()
+
+	r := New(
+		&Config{
+			Host:      srv.URL,
+			Variables: variables.New(),
+		},
+		yaml_file.NewLoader(filepath.Join("testdata", "db-queries", "failing")),
+		func(test models.TestInterface, executeTest testExecutor) error {
+			_, err := executeTest(test)
+			return err
+		},
+	)
+
+	err := r.Run()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "maxDbQueries is set, but the runner has no query counter")
+}
+
+// testDbQueriesServer runs the number of the queries passed in the query string
+func testDbQueriesServer(counter *querycount.Counter) *httptest.Server {
+	db := sql.OpenDB(querycount.NewConnector(querycount.DSNConnector(fakeDriver{}, ""), counter))
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries, _ := strconv.Atoi(r.URL.Query().Get("queries"))
+		for i := 0; i < queries; i++ {
+			if _, err := db.Exec("UPDATE users SET visited = true WHERE id = $1", i); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		}
+	}))
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) {
+	return fakeConn{}, nil
+}
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(string) (driver.Stmt, error) {
+	return fakeStmt{}, nil
+}
+
+func (fakeConn) Close() error {
+	return nil
+}
+
+func (fakeConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("transactions are not supported")
+}
+
+type fakeStmt struct{}
+
+func (fakeStmt) Close() error {
+	return nil
+}
+
+func (fakeStmt) NumInput() int {
+	return -1
+}
+
+func (fakeStmt) Exec([]driver.Value) (driver.Result, error) {
+	return driver.RowsAffected(1), nil
+}
+
+func (fakeStmt) Query([]driver.Value) (driver.Rows, error) {
+	return nil, errors.New("queries are not supported")
+}
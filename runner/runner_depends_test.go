@@ -0,0 +1,148 @@
+package runner
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lamoda/gonkey/models"
+	"github.com/lamoda/gonkey/testloader/yaml_file"
+	"github.com/lamoda/gonkey/variables"
+)
+
+func TestDependsOn(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/orders":
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"id": 1}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/orders/1":
+			_, _ = w.Write([]byte(`{"id": 1}`))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	handler := NewConsoleHandler()
+	r := New(
+		&Config{
+			Host:      srv.URL,
+			Variables: variables.New(),
+		},
+		yaml_file.NewLoader(filepath.Join("testdata", "depends")),
+		handler.HandleTest,
+	)
+	addCheckers(r, &RunWithTestingParams{})
+	results := &resultsOutput{}
+	r.AddOutput(results)
+
+	require.NoError(t, r.Run())
+	// the prerequisite is moved before the test depending on it
+	assert.Equal(t, []string{
+		"depends: create order",
+		"depends: read order",
+		"depends: create broken order",
+		"depends: read broken order",
+		"depends: delete broken order",
+	}, testNames(results))
+
+	summary := handler.Summary()
+	assert.Equal(t, 1, summary.Failed)
+	assert.Equal(t, 2, summary.Skipped)
+	assert.Equal(t, "dependency failed: depends: create broken order", results.results[3].Test.GetReason())
+	assert.Equal(t, "dependency failed: depends: read broken order", results.results[4].Test.GetReason())
+}
+
+func TestDependsOnCycle(t *testing.T) {
+	r := New(
+		&Config{Variables: variables.New()},
+		yaml_file.NewLoader(filepath.Join("testdata", "depends-cycle")),
+		NewConsoleHandler().HandleTest,
+	)
+	assert.EqualError(t, r.Run(), "tests depend on each other: cycle: second -> cycle: third -> cycle: second")
+}
+
+func TestDependsOnUnknownTest(t *testing.T) {
+	tests := []models.TestInterface{
+		&yaml_file.Test{TestDefinition: yaml_file.TestDefinition{Name: "read order", DependsOn: []string{"create order"}}},
+	}
+	_, err := orderByDependencies(tests, nil)
+	assert.EqualError(t, err, "test read order depends on unknown test create order")
+
+	_, err = orderByDependencies(tests, func(name string) bool { return name == "create order" })
+	assert.NoError(t, err)
+}
+
+func TestDependsOnUnknownTestWithFileFilter(t *testing.T) {
+	// the dependency is looked up in the test files left out by the filter too
+	loader := yaml_file.NewLoader(filepath.Join("testdata", "depends-unknown"))
+	loader.SetFileFilter("read.yaml")
+	r := New(&Config{Variables: variables.New()}, loader, NewConsoleHandler().HandleTest)
+	assert.EqualError(t, r.Run(),
+		"test depends-unknown: read order depends on unknown test depends-unknown: craete order")
+}
+
+func TestDependsOnCases(t *testing.T) {
+	srv := testDependsLoadedServer()
+	defer srv.Close()
+
+	handler := NewConsoleHandler()
+	r := New(
+		&Config{
+			Host:      srv.URL,
+			Variables: variables.New(),
+		},
+		yaml_file.NewLoader(filepath.Join("testdata", "depends-loaded")),
+		handler.HandleTest,
+	)
+	addCheckers(r, &RunWithTestingParams{})
+	results := &resultsOutput{}
+	r.AddOutput(results)
+
+	require.NoError(t, r.Run())
+	assert.Equal(t, []string{
+		"depends-loaded: create order #1",
+		"depends-loaded: create order #2",
+		"depends-loaded: read order",
+	}, testNames(results))
+	assert.Equal(t, &models.Summary{Success: true, Total: 3}, handler.Summary())
+}
+
+func TestDependsOnFilteredOut(t *testing.T) {
+	srv := testDependsLoadedServer()
+	defer srv.Close()
+
+	loader := yaml_file.NewLoader(filepath.Join("testdata", "depends-loaded"))
+	loader.SetFileFilter("read.yaml")
+	handler := NewConsoleHandler()
+	r := New(
+		&Config{
+			Host:      srv.URL,
+			Variables: variables.New(),
+		},
+		loader,
+		handler.HandleTest,
+	)
+	addCheckers(r, &RunWithTestingParams{})
+	results := &resultsOutput{}
+	r.AddOutput(results)
+
+	require.NoError(t, r.Run())
+	require.Equal(t, []string{"depends-loaded: read order"}, testNames(results))
+	assert.Equal(t, 1, handler.Summary().Skipped)
+	assert.Equal(t, "dependency not loaded: depends-loaded: create order", results.results[0].Test.GetReason())
+}
+
+func testDependsLoadedServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusCreated)
+		}
+		_, _ = w.Write([]byte(`{"id": 1}`))
+	}))
+}
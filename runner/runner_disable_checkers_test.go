@@ -0,0 +1,50 @@
+package runner
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lamoda/gonkey/models"
+	"github.com/lamoda/gonkey/testloader/yaml_file"
+	"github.com/lamoda/gonkey/variables"
+)
+
+func TestDisableCheckers(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "generated-id")
+		_, _ = w.Write([]byte(`{"status": "ok"}`))
+	}))
+	defer srv.Close()
+
+	var results []*models.Result
+	r := New(
+		&Config{
+			Host:      srv.URL,
+			Variables: variables.New(),
+		},
+		yaml_file.NewLoader(filepath.Join("testdata", "disable-checkers")),
+		func(test models.TestInterface, executeTest testExecutor) error {
+			result, err := executeTest(test)
+			if err != nil {
+				return err
+			}
+			results = append(results, result)
+			return nil
+		},
+	)
+	addCheckers(r, &RunWithTestingParams{})
+
+	require.NoError(t, r.Run())
+	require.Len(t, results, 1)
+	assert.True(t, results[0].Passed())
+	require.Len(t, results[0].Checks, 4)
+	assert.Equal(t, "response_body", results[0].Checks[0].Checker)
+	assert.Equal(t, "response_cache", results[0].Checks[1].Checker)
+	assert.Equal(t, "response_cookie", results[0].Checks[2].Checker)
+	assert.Equal(t, "response_status_text", results[0].Checks[3].Checker)
+}
@@ -0,0 +1,44 @@
+package runner
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvOfTest(t *testing.T) {
+	require.NoError(t, os.Setenv("GONKEY_TEST_FEATURE", "default"))
+	defer os.Unsetenv("GONKEY_TEST_FEATURE")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]string{"feature": os.Getenv("GONKEY_TEST_FEATURE")})
+	}))
+	defer srv.Close()
+
+	RunWithTesting(t, &RunWithTestingParams{
+		Server:   srv,
+		TestsDir: filepath.Join("testdata", "env"),
+	})
+
+	assert.Equal(t, "default", os.Getenv("GONKEY_TEST_FEATURE"))
+}
+
+func TestSetEnvRestoresUnsetVariables(t *testing.T) {
+	_, isSet := os.LookupEnv("GONKEY_TEST_UNSET")
+	require.False(t, isSet)
+
+	restore, err := setEnv(map[string]string{"GONKEY_TEST_UNSET": "value"})
+	require.NoError(t, err)
+	assert.Equal(t, "value", os.Getenv("GONKEY_TEST_UNSET"))
+
+	restore()
+	_, isSet = os.LookupEnv("GONKEY_TEST_UNSET")
+	assert.False(t, isSet)
+}
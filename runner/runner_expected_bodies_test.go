@@ -0,0 +1,32 @@
+package runner
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/lamoda/gonkey/checker/response_body"
+)
+
+type testOrder struct {
+	ID     int    `json:"id"`
+	Status string `json:"status"`
+}
+
+func TestExpectedBodies(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprint(w, `{"id": 1, "status": "paid"}`)
+	}))
+	defer srv.Close()
+
+	RunWithTesting(t, &RunWithTestingParams{
+		Server:   srv,
+		TestsDir: filepath.Join("testdata", "expected-bodies"),
+		ExpectedBodies: map[string]response_body.ExpectedBodyFunc{
+			"get order": response_body.ExpectedBody(http.StatusOK, testOrder{ID: 1, Status: "paid"}),
+		},
+	})
+}
@@ -0,0 +1,99 @@
+package runner
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+
+	"github.com/lamoda/gonkey/fixtures"
+)
+
+// memoryLoader is an example of a custom fixtures loader, the fixtures files are maps of
+// the records loaded into an in-memory store
+type memoryLoader struct {
+	sync.Mutex
+	location string
+	debug    bool
+	records  map[string]string
+	cleaned  []string
+}
+
+var (
+	_ fixtures.Loader       = (*memoryLoader)(nil)
+	_ fixtures.Cleaner      = (*memoryLoader)(nil)
+	_ fixtures.Configurable = (*memoryLoader)(nil)
+)
+
+func (l *memoryLoader) Configure(location string, debug bool) {
+	l.location = location
+	l.debug = debug
+}
+
+func (l *memoryLoader) Load(names []string) error {
+	l.Lock()
+	defer l.Unlock()
+
+	l.records = make(map[string]string)
+	for _, name := range names {
+		data, err := ioutil.ReadFile(filepath.Join(l.location, name+".yaml"))
+		if err != nil {
+			return err
+		}
+		var records map[string]string
+		if err := yaml.Unmarshal(data, &records); err != nil {
+			return err
+		}
+		for key, value := range records {
+			l.records[key] = value
+		}
+	}
+	return nil
+}
+
+func (l *memoryLoader) Clean(names []string) error {
+	l.Lock()
+	defer l.Unlock()
+
+	l.records = nil
+	l.cleaned = append(l.cleaned, names...)
+	return nil
+}
+
+func (l *memoryLoader) get(key string) (string, bool) {
+	l.Lock()
+	defer l.Unlock()
+
+	value, ok := l.records[key]
+	return value, ok
+}
+
+func TestCustomFixtureLoader(t *testing.T) {
+	loader := &memoryLoader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		role, ok := loader.get(strings.TrimPrefix(r.URL.Path, "/users/"))
+		w.Header().Set("Content-Type", "application/json")
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"role": role})
+	}))
+	defer srv.Close()
+
+	RunWithTesting(t, &RunWithTestingParams{
+		Server:        srv,
+		TestsDir:      filepath.Join("testdata", "custom-fixtures", "cases"),
+		FixturesDir:   filepath.Join("testdata", "custom-fixtures", "fixtures") + "/",
+		FixtureLoader: loader,
+	})
+
+	assert.Equal(t, filepath.Join("testdata", "custom-fixtures", "fixtures"), loader.location)
+	assert.Equal(t, []string{"users"}, loader.cleaned)
+}
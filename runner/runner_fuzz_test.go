@@ -0,0 +1,119 @@
+package runner
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lamoda/gonkey/output/json_report"
+	"github.com/lamoda/gonkey/testloader/yaml_file"
+	"github.com/lamoda/gonkey/variables"
+)
+
+func TestFuzz(t *testing.T) {
+	var bodies []map[string]interface{}
+	srv := testFuzzServer(&bodies)
+	defer srv.Close()
+
+	handler := NewConsoleHandler()
+	r := New(
+		&Config{
+			Host:      srv.URL,
+			Variables: variables.New(),
+		},
+		yaml_file.NewLoader(filepath.Join("testdata", "fuzz", "passing")),
+		handler.HandleTest,
+	)
+	addCheckers(r, &RunWithTestingParams{})
+
+	require.NoError(t, r.Run())
+	assert.Equal(t, 20, handler.Summary().Total)
+	assert.Equal(t, 0, handler.Summary().Failed)
+
+	require.Len(t, bodies, 20)
+	for _, body := range bodies {
+		assert.Contains(t, []interface{}{float64(1), float64(2), float64(3)}, body["quantity"])
+		assert.IsType(t, "", body["sku"])
+	}
+}
+
+func TestFuzzFailingInputs(t *testing.T) {
+	var bodies []map[string]interface{}
+	srv := testFuzzServer(&bodies)
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "gonkey-fuzz")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	reportPath := filepath.Join(dir, "report.json")
+
+	handler := NewConsoleHandler()
+	r := New(
+		&Config{
+			Host:      srv.URL,
+			Variables: variables.New(),
+		},
+		yaml_file.NewLoader(filepath.Join("testdata", "fuzz", "failing")),
+		handler.HandleTest,
+	)
+	addCheckers(r, &RunWithTestingParams{})
+	jsonOutput := json_report.NewOutput(reportPath)
+	r.AddOutput(jsonOutput)
+
+	require.NoError(t, r.Run())
+	require.NoError(t, jsonOutput.Finalize())
+	assert.Equal(t, 10, handler.Summary().Total)
+	assert.NotZero(t, handler.Summary().Failed)
+
+	data, err := ioutil.ReadFile(reportPath)
+	require.NoError(t, err)
+	var report json_report.Report
+	require.NoError(t, json.Unmarshal(data, &report))
+	require.Len(t, report.Tests, 10)
+	for i, test := range report.Tests {
+		assert.True(t, strings.HasPrefix(test.Name, "order fails on large quantities [fuzz "), test.Name)
+		assert.True(t, strings.HasSuffix(test.Name, " of 10, seed 7]"), test.Name)
+		if bodies[i]["quantity"].(float64) <= 3 {
+			assert.Equal(t, "passed", test.Status)
+			continue
+		}
+		assert.Equal(t, "failed", test.Status)
+		require.NotEmpty(t, test.Errors)
+		assert.Contains(t, test.Errors[0], "expected 2xx")
+	}
+}
+
+func testFuzzServer(bodies *[]map[string]interface{}) *httptest.Server {
+	var mu sync.Mutex
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		*bodies = append(*bodies, body)
+
+		quantity, _ := body["quantity"].(float64)
+		switch {
+		case quantity > 10:
+			w.WriteHeader(http.StatusInternalServerError)
+		case quantity > 3:
+			w.WriteHeader(http.StatusUnprocessableEntity)
+		default:
+			w.WriteHeader(http.StatusCreated)
+		}
+	}))
+}
@@ -0,0 +1,114 @@
+package runner
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lamoda/gonkey/output/json_report"
+	"github.com/lamoda/gonkey/testloader/yaml_file"
+	"github.com/lamoda/gonkey/variables"
+)
+
+func TestGoroutineLeaks(t *testing.T) {
+	srv, stop := testGoroutinesServer()
+	defer srv.Close()
+	defer stop()
+
+	RunWithTesting(t, &RunWithTestingParams{
+		Server:         srv,
+		TestsDir:       filepath.Join("testdata", "goroutines", "passing"),
+		GoroutineLeaks: &GoroutineLeaks{SettleDelay: 5 * time.Second},
+	})
+}
+
+func TestGoroutineLeaksAllowance(t *testing.T) {
+	srv, stop := testGoroutinesServer()
+	defer srv.Close()
+	defer stop()
+
+	RunWithTesting(t, &RunWithTestingParams{
+		Server:         srv,
+		TestsDir:       filepath.Join("testdata", "goroutines", "leaking"),
+		GoroutineLeaks: &GoroutineLeaks{Allowance: 10, SettleDelay: 5 * time.Second},
+	})
+}
+
+func TestGoroutineLeaksFound(t *testing.T) {
+	srv, stop := testGoroutinesServer()
+	defer srv.Close()
+	defer stop()
+
+	dir, err := ioutil.TempDir("", "gonkey-goroutines")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	reportPath := filepath.Join(dir, "report.json")
+
+	handler := NewConsoleHandler()
+	r := New(
+		&Config{
+			Host:           srv.URL,
+			Variables:      variables.New(),
+			GoroutineLeaks: &GoroutineLeaks{SettleDelay: 50 * time.Millisecond},
+		},
+		yaml_file.NewLoader(filepath.Join("testdata", "goroutines", "leaking")),
+		handler.HandleTest,
+	)
+	addCheckers(r, &RunWithTestingParams{})
+	jsonOutput := json_report.NewOutput(reportPath)
+	r.AddOutput(jsonOutput)
+
+	require.NoError(t, r.Run())
+	require.NoError(t, jsonOutput.Finalize())
+	assert.Equal(t, 1, handler.Summary().Failed)
+
+	data, err := ioutil.ReadFile(reportPath)
+	require.NoError(t, err)
+	var report json_report.Report
+	require.NoError(t, json.Unmarshal(data, &report))
+	require.Len(t, report.Tests, 1)
+	require.Len(t, report.Tests[0].Errors, 1)
+	assert.Regexp(t,
+		`^the number of goroutines grew from \d+ to \d+ after the test and didn't settle within 50ms, allowance 0$`,
+		report.Tests[0].Errors[0],
+	)
+}
+
+// testGoroutinesServer starts the goroutines running until stop is called (leak) or for a while (background),
+// stop waits for all of them to finish, so they aren't counted by the next test
+func testGoroutinesServer() (*httptest.Server, func()) {
+	done := make(chan struct{})
+	wg := &sync.WaitGroup{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		leak, _ := strconv.Atoi(r.URL.Query().Get("leak"))
+		background, _ := strconv.Atoi(r.URL.Query().Get("background"))
+		wg.Add(leak + background)
+		for i := 0; i < leak; i++ {
+			go func() {
+				defer wg.Done()
+				<-done
+			}()
+		}
+		for i := 0; i < background; i++ {
+			go func() {
+				defer wg.Done()
+				time.Sleep(100 * time.Millisecond)
+			}()
+		}
+	}))
+	stop := func() {
+		close(done)
+		wg.Wait()
+	}
+	return srv, stop
+}
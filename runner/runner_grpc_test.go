@@ -0,0 +1,45 @@
+package runner
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/lamoda/gonkey/checker"
+	"github.com/lamoda/gonkey/checker/response_grpc"
+)
+
+func TestGrpcStatus(t *testing.T) {
+	detail, err := anypb.New(durationpb.New(30 * time.Second))
+	require.NoError(t, err)
+	value, err := proto.Marshal(detail)
+	require.NoError(t, err)
+	// google.rpc.Status{code: 5, details: [detail]}
+	status := protowire.AppendVarint(protowire.AppendTag(nil, 1, protowire.VarintType), 5)
+	status = protowire.AppendBytes(protowire.AppendTag(status, 3, protowire.BytesType), value)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Trailer", "Grpc-Status, Grpc-Message, Grpc-Status-Details-Bin")
+		w.Header().Set("Content-Type", "application/grpc")
+		w.WriteHeader(http.StatusOK)
+		w.Header().Set("Grpc-Status", "5")
+		w.Header().Set("Grpc-Message", "order 1 not found")
+		w.Header().Set("Grpc-Status-Details-Bin", base64.RawStdEncoding.EncodeToString(status))
+	}))
+	defer srv.Close()
+
+	RunWithTesting(t, &RunWithTestingParams{
+		Server:   srv,
+		TestsDir: filepath.Join("testdata", "grpc"),
+		Checkers: []checker.CheckerInterface{response_grpc.NewChecker()},
+	})
+}
@@ -0,0 +1,31 @@
+package runner
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestDefaultHeaders(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"tenant":    r.Header.Get("X-Tenant"),
+			"agent":     r.Header.Get("User-Agent"),
+			"requestId": r.Header.Get("X-Request-Id"),
+		})
+	}))
+	defer srv.Close()
+
+	RunWithTesting(t, &RunWithTestingParams{
+		Server:   srv,
+		TestsDir: filepath.Join("testdata", "default-headers"),
+		Headers: map[string]string{
+			"X-Tenant":     "{{ $tenant }}",
+			"User-Agent":   "gonkey-test",
+			"X-Request-Id": "req-1",
+		},
+	})
+}
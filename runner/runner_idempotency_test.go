@@ -0,0 +1,135 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/lamoda/gonkey/output/json_report"
+	"github.com/lamoda/gonkey/testloader/yaml_file"
+	"github.com/lamoda/gonkey/variables"
+)
+
+func TestIdempotency(t *testing.T) {
+	srv := testIdempotencyServer()
+	defer srv.Close()
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+	countQuery := "^" + regexp.QuoteMeta("SELECT COUNT(*) FROM orders") + "$"
+	mock.ExpectQuery(countQuery).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery(countQuery).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	handler := NewConsoleHandler()
+	r := New(
+		&Config{
+			Host:      srv.URL,
+			Variables: variables.New(),
+			DB:        db,
+		},
+		yaml_file.NewLoader(filepath.Join("testdata", "idempotency", "passing")),
+		handler.HandleTest,
+	)
+	addCheckers(r, &RunWithTestingParams{})
+
+	require.NoError(t, r.Run())
+	assert.Equal(t, 2, handler.Summary().Total)
+	assert.Equal(t, 0, handler.Summary().Failed)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestIdempotencyDiverged(t *testing.T) {
+	srv := testIdempotencyServer()
+	defer srv.Close()
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+	countQuery := "^" + regexp.QuoteMeta("SELECT COUNT(*) FROM orders") + "$"
+	mock.ExpectQuery(countQuery).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery(countQuery).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+
+	dir, err := ioutil.TempDir("", "gonkey-idempotency")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	reportPath := filepath.Join(dir, "report.json")
+
+	handler := NewConsoleHandler()
+	r := New(
+		&Config{
+			Host:      srv.URL,
+			Variables: variables.New(),
+			DB:        db,
+		},
+		yaml_file.NewLoader(filepath.Join("testdata", "idempotency", "failing")),
+		handler.HandleTest,
+	)
+	addCheckers(r, &RunWithTestingParams{})
+	jsonOutput := json_report.NewOutput(reportPath)
+	r.AddOutput(jsonOutput)
+
+	require.NoError(t, r.Run())
+	require.NoError(t, jsonOutput.Finalize())
+	assert.Equal(t, 1, handler.Summary().Failed)
+
+	data, err := ioutil.ReadFile(reportPath)
+	require.NoError(t, err)
+	var report json_report.Report
+	require.NoError(t, json.Unmarshal(data, &report))
+	require.Len(t, report.Tests, 1)
+	require.Len(t, report.Tests[0].Errors, 2)
+	assert.Contains(t, report.Tests[0].Errors[0], "the body of the second run differs from the first run at path $.id")
+	assert.Equal(t, "the second run changed the number of rows in table orders from 1 to 2", report.Tests[0].Errors[1])
+}
+
+func TestIdempotencyWithoutDB(t *testing.T) {
+	srv := testIdempotencyServer()
+	defer srv.Close()
+
+	r := New(
+		&Config{
+			Host:      srv.URL,
+			Variables: variables.New(),
+		},
+		yaml_file.NewLoader(filepath.Join("testdata", "idempotency", "failing")),
+		NewConsoleHandler().HandleTest,
+	)
+
+	err := r.Run()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "idempotency tables are set, but the runner has no DB")
+}
+
+func testIdempotencyServer() *httptest.Server {
+	var mu sync.Mutex
+	lastID := 0
+	ids := make(map[string]int)
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		key := r.Header.Get("Idempotency-Key")
+		id, ok := ids[key]
+		if !ok || r.URL.Path == "/orders-without-key" {
+			lastID++
+			id = lastID
+			ids[key] = id
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = fmt.Fprintf(w, `{"id": %d}`, id)
+	}))
+}
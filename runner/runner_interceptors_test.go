@@ -0,0 +1,94 @@
+package runner
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lamoda/gonkey/signing"
+)
+
+func sign(body []byte) string {
+	mac := hmac.New(sha256.New, []byte("secret"))
+	_, _ = mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestInterceptors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if r.Header.Get("X-Signature") != sign(body) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+	}))
+	defer srv.Close()
+
+	var signedBodies []string
+	var responses []string
+	RunWithTesting(t, &RunWithTestingParams{
+		Server:   srv,
+		TestsDir: filepath.Join("testdata", "interceptors"),
+		RequestInterceptor: func(req *http.Request) {
+			body, err := req.GetBody()
+			require.NoError(t, err)
+			data, err := ioutil.ReadAll(body)
+			require.NoError(t, err)
+			signedBodies = append(signedBodies, string(data))
+			req.Header.Set("X-Signature", sign(data))
+		},
+		ResponseInterceptor: func(resp *http.Response) {
+			body, err := ioutil.ReadAll(resp.Body)
+			require.NoError(t, err)
+			responses = append(responses, resp.Status+" "+string(body))
+		},
+	})
+
+	// the variables are substituted before the request is intercepted
+	assert.Equal(t, []string{`{"customer": "john"}`}, signedBodies)
+	assert.Equal(t, []string{`200 OK {"customer": "john"}`}, responses)
+}
+
+func TestRequestSignerAfterInterceptor(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		// the signature covers the header set by the interceptor
+		if r.Header.Get("X-Signature") != sign([]byte(r.Header.Get("X-Customer")+string(body))) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+	}))
+	defer srv.Close()
+
+	RunWithTesting(t, &RunWithTestingParams{
+		Server:   srv,
+		TestsDir: filepath.Join("testdata", "interceptors"),
+		RequestInterceptor: func(req *http.Request) {
+			req.Header.Set("X-Customer", "john")
+		},
+		RequestSigner: signing.SignerFunc(func(req *http.Request) error {
+			body, err := req.GetBody()
+			if err != nil {
+				return err
+			}
+			data, err := ioutil.ReadAll(body)
+			if err != nil {
+				return err
+			}
+			req.Header.Set("X-Signature", sign([]byte(req.Header.Get("X-Customer")+string(data))))
+			return nil
+		}),
+	})
+}
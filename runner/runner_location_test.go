@@ -0,0 +1,87 @@
+package runner
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lamoda/gonkey/output/json_report"
+	"github.com/lamoda/gonkey/testloader/yaml_file"
+	"github.com/lamoda/gonkey/variables"
+)
+
+func TestResponseLocation(t *testing.T) {
+	srv := testLocationServer()
+	defer srv.Close()
+
+	RunWithTesting(t, &RunWithTestingParams{
+		Server:   srv,
+		TestsDir: filepath.Join("testdata", "location", "passing"),
+	})
+}
+
+func TestResponseLocationMismatch(t *testing.T) {
+	srv := testLocationServer()
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "gonkey-location")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	reportPath := filepath.Join(dir, "report.json")
+
+	handler := NewConsoleHandler()
+	r := New(
+		&Config{
+			Host:      srv.URL,
+			Variables: variables.New(),
+		},
+		yaml_file.NewLoader(filepath.Join("testdata", "location", "failing")),
+		handler.HandleTest,
+	)
+	addCheckers(r, &RunWithTestingParams{})
+	jsonOutput := json_report.NewOutput(reportPath)
+	r.AddOutput(jsonOutput)
+
+	require.NoError(t, r.Run())
+	require.NoError(t, jsonOutput.Finalize())
+	assert.Equal(t, 2, handler.Summary().Failed)
+
+	data, err := ioutil.ReadFile(reportPath)
+	require.NoError(t, err)
+	var report json_report.Report
+	require.NoError(t, json.Unmarshal(data, &report))
+	require.Len(t, report.Tests, 2)
+
+	assert.Equal(t, []string{
+		"Location path /api/orders/42 does not match expected /api/orders/43",
+		"Location query does not include expected param lang",
+		"Location query param view value full does not match expected short",
+	}, report.Tests[0].Errors)
+	assert.Equal(t, []string{"response does not include Location header"}, report.Tests[1].Errors)
+}
+
+func testLocationServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/api/orders":
+			// the absolute location of another host is compared regardless of the host
+			w.Header().Set("Location", "http://orders.example.com/api/orders/42?view=full")
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == http.MethodPost && r.URL.Path == "/api/orders/42/items":
+			w.Header().Set("Location", "items/7")
+			w.WriteHeader(http.StatusCreated)
+		case r.URL.Path == "/api/orders/42":
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"id": 42, "view": r.URL.Query().Get("view")})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
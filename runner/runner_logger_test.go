@@ -0,0 +1,62 @@
+package runner
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lamoda/gonkey/logging"
+	"github.com/lamoda/gonkey/testloader/yaml_file"
+	"github.com/lamoda/gonkey/variables"
+)
+
+func TestLoggerEvents(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"status": "ok"}`))
+	}))
+	defer srv.Close()
+
+	var events []string
+	fields := map[string]map[string]interface{}{}
+	logger := logging.Func(func(event string, keysAndValues ...interface{}) {
+		events = append(events, event)
+		eventFields := map[string]interface{}{}
+		for i := 0; i+1 < len(keysAndValues); i += 2 {
+			eventFields[fmt.Sprint(keysAndValues[i])] = keysAndValues[i+1]
+		}
+		fields[event] = eventFields
+	})
+
+	r := New(
+		&Config{
+			Host:      srv.URL,
+			Variables: variables.New(),
+			Logger:    logger,
+		},
+		yaml_file.NewLoader(filepath.Join("testdata", "disable-checkers")),
+		NewConsoleHandler().HandleTest,
+	)
+	addCheckers(r, &RunWithTestingParams{})
+
+	require.NoError(t, r.Run())
+	assert.Equal(t, []string{
+		"tests loaded",
+		"test started",
+		"response received",
+		"checker finished",
+		"checker finished",
+		"checker finished",
+		"checker finished",
+		"test finished",
+	}, events)
+	assert.Equal(t, 1, fields["tests loaded"]["tests"])
+	assert.Equal(t, "dynamic headers", fields["test started"]["test"])
+	assert.Equal(t, http.StatusOK, fields["response received"]["status"])
+	assert.Equal(t, "response_status_text", fields["checker finished"]["checker"])
+	assert.Equal(t, "passed", fields["test finished"]["status"])
+}
@@ -0,0 +1,50 @@
+package runner
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lamoda/gonkey/testloader/yaml_file"
+	"github.com/lamoda/gonkey/variables"
+)
+
+func TestMetricsDelta(t *testing.T) {
+	var mu sync.Mutex
+	orders, requests := 0, 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if r.URL.Path == "/metrics" {
+			_, _ = fmt.Fprintf(w, "orders_created_total{status=\"new\"} %d\nhttp_requests_total %d\n", orders, requests)
+			return
+		}
+		orders++
+		requests++
+		w.WriteHeader(http.StatusCreated)
+		_, _ = fmt.Fprint(w, `{"id": 1}`)
+	}))
+	defer srv.Close()
+
+	handler := NewConsoleHandler()
+	r := New(
+		&Config{
+			Host:      srv.URL,
+			Variables: variables.New(),
+		},
+		yaml_file.NewLoader(filepath.Join("testdata", "metrics")),
+		handler.HandleTest,
+	)
+	addCheckers(r, &RunWithTestingParams{MetricsURL: srv.URL + "/metrics"})
+
+	require.NoError(t, r.Run())
+	assert.Equal(t, 1, handler.Summary().Total)
+	assert.Equal(t, 0, handler.Summary().Failed)
+}
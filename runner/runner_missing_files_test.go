@@ -0,0 +1,72 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lamoda/gonkey/mocks"
+	"github.com/lamoda/gonkey/models"
+	"github.com/lamoda/gonkey/testloader/yaml_file"
+	"github.com/lamoda/gonkey/variables"
+)
+
+// locatingFixturesLoader has the files of the fixtures in the map
+type locatingFixturesLoader map[string]string
+
+func (l locatingFixturesLoader) Load([]string) error {
+	return nil
+}
+
+func (l locatingFixturesLoader) Locate(name string) (string, error) {
+	file, ok := l[name]
+	if !ok {
+		return "", os.ErrNotExist
+	}
+	return file, nil
+}
+
+func TestMissingFiles(t *testing.T) {
+	m := mocks.NewNop("backend")
+	dir := filepath.Join("testdata", "missing-files")
+	r := New(
+		&Config{
+			Host:           "http://localhost",
+			Variables:      variables.New(),
+			Mocks:          m,
+			MocksLoader:    mocks.NewLoader(m),
+			FixturesLoader: locatingFixturesLoader{"orders": "fixtures/orders.yaml"},
+		},
+		yaml_file.NewLoader(dir),
+		NewConsoleHandler().HandleTest,
+	)
+	out := &resultsOutput{}
+	r.AddOutput(out)
+
+	err := r.Run()
+	require.Error(t, err)
+	// nothing is run
+	assert.Empty(t, out.results)
+
+	missingErr, ok := err.(*models.MissingFilesError)
+	require.True(t, ok, err.Error())
+	file := dir + "/missing-files.yaml"
+	assert.Equal(t, []models.MissingFile{
+		{
+			Field:    "form.files",
+			Path:     filepath.Join(dir, "bodies", "invoice.pdf"),
+			Test:     "missing-files: upload invoice",
+			TestFile: file,
+		},
+		{Field: "fixtures", Path: "customers", Test: "missing-files: upload invoice", TestFile: file},
+		{
+			Field:    "mocks",
+			Path:     filepath.Join(dir, "bodies", "backend-order.json"),
+			Test:     "missing-files: get order",
+			TestFile: file,
+		},
+	}, missingErr.Files)
+}
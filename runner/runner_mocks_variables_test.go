@@ -0,0 +1,67 @@
+package runner
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/lamoda/gonkey/mocks"
+)
+
+func TestMocksWithVariables(t *testing.T) {
+	m := mocks.NewNop("backend")
+	if err := m.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer m.Shutdown()
+
+	srv := testServerProxy(m.Service("backend").ServerAddr())
+	defer srv.Close()
+
+	RunWithTesting(t, &RunWithTestingParams{
+		Server:   srv,
+		TestsDir: filepath.Join("testdata", "mocks-variables"),
+		Mocks:    m,
+	})
+}
+
+func TestMocksWithVariablesOfSteps(t *testing.T) {
+	m := mocks.NewNop("backend")
+	if err := m.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer m.Shutdown()
+
+	srv := testServerProxy(m.Service("backend").ServerAddr())
+	defer srv.Close()
+
+	RunWithTesting(t, &RunWithTestingParams{
+		Server:   srv,
+		TestsDir: filepath.Join("testdata", "mocks-variables-steps"),
+		Mocks:    m,
+	})
+}
+
+func testServerProxy(backendAddr string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.Method == http.MethodPost {
+			_, _ = io.WriteString(w, `{"id": "42"}`)
+			return
+		}
+
+		resp, err := http.Get(fmt.Sprintf("http://%s%s", backendAddr, r.URL.Path))
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		defer func() { _ = resp.Body.Close() }()
+
+		w.WriteHeader(resp.StatusCode)
+		_, _ = io.Copy(w, resp.Body)
+	}))
+}
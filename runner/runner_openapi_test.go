@@ -0,0 +1,113 @@
+package runner
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lamoda/gonkey/openapi"
+	"github.com/lamoda/gonkey/output/json_report"
+	"github.com/lamoda/gonkey/testloader/yaml_file"
+	"github.com/lamoda/gonkey/variables"
+)
+
+func TestOpenAPIValidation(t *testing.T) {
+	srv, _ := testUsersServer()
+	defer srv.Close()
+
+	RunWithTesting(t, &RunWithTestingParams{
+		Server:                   srv,
+		TestsDir:                 filepath.Join("testdata", "openapi", "passing"),
+		OpenAPISpec:              filepath.Join("testdata", "openapi", "spec.yaml"),
+		ValidateOpenAPIResponses: true,
+	})
+}
+
+func TestOpenAPIViolations(t *testing.T) {
+	srv, requests := testUsersServer()
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "gonkey-openapi")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	reportPath := filepath.Join(dir, "report.json")
+
+	validator, err := openapi.NewValidator(filepath.Join("testdata", "openapi", "spec.yaml"))
+	require.NoError(t, err)
+	validator.SetValidateResponses(true)
+
+	handler := NewConsoleHandler()
+	r := New(
+		&Config{
+			Host:      srv.URL,
+			Variables: variables.New(),
+			OpenAPI:   validator,
+		},
+		yaml_file.NewLoader(filepath.Join("testdata", "openapi", "failing")),
+		handler.HandleTest,
+	)
+	addCheckers(r, &RunWithTestingParams{})
+	jsonOutput := json_report.NewOutput(reportPath)
+	r.AddOutput(jsonOutput)
+
+	require.NoError(t, r.Run())
+	require.NoError(t, jsonOutput.Finalize())
+	assert.Equal(t, 3, handler.Summary().Failed)
+	// the requests not conforming to the spec aren't sent
+	assert.Equal(t, []string{"/v1/users/2"}, *requests)
+
+	data, err := ioutil.ReadFile(reportPath)
+	require.NoError(t, err)
+	var report json_report.Report
+	require.NoError(t, json.Unmarshal(data, &report))
+	require.Len(t, report.Tests, 3)
+
+	assert.Equal(t, []json_report.CheckReport{{
+		Checker: "openapi_request",
+		Errors:  []string{`path parameter "id": value abc: an invalid integer: strconv.ParseFloat: parsing "abc": invalid syntax`},
+	}}, report.Tests[0].Checks)
+
+	require.Len(t, report.Tests[1].Checks, 1)
+	assert.Equal(t, "openapi_request", report.Tests[1].Checks[0].Checker)
+	assert.ElementsMatch(t, []string{
+		`request body: field name: property "name" is missing`,
+		"request body: field age: number must be at least 0",
+	}, report.Tests[1].Checks[0].Errors)
+
+	checks := report.Tests[2].Checks
+	require.NotEmpty(t, checks)
+	last := checks[len(checks)-1]
+	assert.Equal(t, "openapi_response", last.Checker)
+	assert.ElementsMatch(t, []string{
+		`response: field name: property "name" is missing`,
+		"response: field id: Field must be set to integer or not be present",
+	}, last.Errors)
+}
+
+// testUsersServer serves the users described by testdata/openapi/spec.yaml,
+// the response for the user 2 doesn't conform to the spec
+func testUsersServer() (*httptest.Server, *[]string) {
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v1/users":
+			w.WriteHeader(http.StatusCreated)
+			_, _ = io.WriteString(w, `{"id": 2, "name": "bob"}`)
+		case "/v1/users/2":
+			_, _ = io.WriteString(w, `{"id": "2"}`)
+		default:
+			_, _ = io.WriteString(w, `{"id": 1, "name": "alice"}`)
+		}
+	}))
+	return srv, &requests
+}
@@ -0,0 +1,96 @@
+package runner
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lamoda/gonkey/models"
+	"github.com/lamoda/gonkey/testloader/yaml_file"
+	"github.com/lamoda/gonkey/variables"
+)
+
+type panickingChecker struct{}
+
+func (panickingChecker) Name() string {
+	return "panicking"
+}
+
+func (panickingChecker) Check(t models.TestInterface, _ *models.Result) ([]error, error) {
+	if t.Path() == "/first" {
+		panic("unexpected response")
+	}
+	return nil, nil
+}
+
+func runPanicsTests(t *testing.T, config *Config) (*ConsoleHandler, []*models.Result) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"ok": true}`))
+	}))
+	defer srv.Close()
+
+	config.Host = srv.URL
+	config.Variables = variables.New()
+	out := &resultsOutput{}
+	handler := NewConsoleHandler()
+	r := New(config, yaml_file.NewLoader(filepath.Join("testdata", "panics")), handler.HandleTest)
+	addCheckers(r, &RunWithTestingParams{})
+	r.AddCheckers(panickingChecker{})
+	r.AddOutput(out)
+
+	require.NoError(t, r.Run())
+	return handler, out.results
+}
+
+func TestCheckerPanic(t *testing.T) {
+	handler, results := runPanicsTests(t, &Config{})
+
+	assert.Equal(t, 2, handler.Summary().Total)
+	assert.Equal(t, 1, handler.Summary().Failed)
+	require.Len(t, results, 2)
+
+	// the other checkers of the test are run
+	checks := results[0].Checks
+	require.Len(t, checks, 6)
+	assert.Equal(t, "response_body", checks[0].Checker)
+	assert.Empty(t, checks[0].Errors)
+	assert.Equal(t, "panicking", checks[5].Checker)
+	require.Len(t, checks[5].Errors, 1)
+
+	p, ok := checks[5].Errors[0].(*PanicError)
+	require.True(t, ok)
+	assert.Equal(t, "panicking", p.Name)
+	assert.Equal(t, "unexpected response", p.Value)
+	assert.Contains(t, p.Stack, "panickingChecker.Check")
+	assert.Contains(t, p.Error(), "panicking panicked: unexpected response\n")
+	assert.Equal(t, []error{p}, results[0].Errors)
+
+	assert.True(t, results[1].Passed())
+}
+
+func TestHookPanic(t *testing.T) {
+	handler, results := runPanicsTests(t, &Config{
+		RequestInterceptor: func(req *http.Request) {
+			if req.URL.Path == "/first" {
+				var headers map[string]string
+				headers["X-Request-Id"] = "1"
+			}
+		},
+	})
+
+	assert.Equal(t, 1, handler.Summary().Failed)
+	require.Len(t, results, 2)
+
+	require.Len(t, results[0].Checks, 1)
+	assert.Equal(t, "RequestInterceptor", results[0].Checks[0].Checker)
+	require.Len(t, results[0].Errors, 1)
+	assert.Contains(t, results[0].Errors[0].Error(), "RequestInterceptor panicked: assignment to entry in nil map\n")
+	assert.Contains(t, results[0].Errors[0].Error(), "TestHookPanic")
+
+	assert.True(t, results[1].Passed())
+}
@@ -0,0 +1,71 @@
+package runner
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lamoda/gonkey/testloader/yaml_file"
+	"github.com/lamoda/gonkey/variables"
+)
+
+func TestProxyOverride(t *testing.T) {
+	srv := testProxyServer("service")
+	defer srv.Close()
+	runnerProxy := testProxyServer("proxy of the runner")
+	defer runnerProxy.Close()
+	testProxy := testProxyServer("proxy of the test")
+	defer testProxy.Close()
+
+	proxyURL, err := url.Parse(runnerProxy.URL)
+	require.NoError(t, err)
+
+	vars := variables.New()
+	vars.Add(variables.NewVariable("otherProxy", testProxy.URL))
+
+	handler := NewConsoleHandler()
+	r := New(
+		&Config{
+			Host:         srv.URL,
+			Variables:    vars,
+			HttpProxyURL: proxyURL,
+		},
+		yaml_file.NewLoader(filepath.Join("testdata", "proxy", "passing")),
+		handler.HandleTest,
+	)
+	addCheckers(r, &RunWithTestingParams{})
+
+	require.NoError(t, r.Run())
+	assert.Equal(t, 0, handler.Summary().Failed)
+	assert.Equal(t, 3, handler.Summary().Total)
+}
+
+func TestProxyMalformed(t *testing.T) {
+	srv := testProxyServer("service")
+	defer srv.Close()
+
+	r := New(
+		&Config{
+			Host:      srv.URL,
+			Variables: variables.New(),
+		},
+		yaml_file.NewLoader(filepath.Join("testdata", "proxy", "failing")),
+		NewConsoleHandler().HandleTest,
+	)
+
+	err := r.Run()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `invalid proxy "ftp://proxy.example.com": the scheme must be http, https or socks5`)
+}
+
+// testProxyServer answers with its name, a proxy gets the requests with the absolute URLs
+func testProxyServer(name string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(name))
+	}))
+}
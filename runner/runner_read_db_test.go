@@ -0,0 +1,52 @@
+package runner
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/lamoda/gonkey/testloader/yaml_file"
+	"github.com/lamoda/gonkey/variables"
+)
+
+func TestDbChecksReadFromReadDB(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id": 1}`))
+	}))
+	defer srv.Close()
+
+	db, dbMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+	replica, replicaMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = replica.Close() }()
+	replicaMock.ExpectQuery(regexp.QuoteMeta("SELECT id, status FROM orders WHERE id = 1")).
+		WillReturnRows(sqlmock.NewRows([]string{"row_to_json"}).AddRow(`{"id": 1, "status": "new"}`))
+
+	params := &RunWithTestingParams{DB: db, ReadDB: replica}
+	handler := NewConsoleHandler()
+	r := New(
+		&Config{
+			Host:      srv.URL,
+			Variables: variables.New(),
+			DB:        readDB(params),
+		},
+		yaml_file.NewLoader(filepath.Join("testdata", "read_db")),
+		handler.HandleTest,
+	)
+	addCheckers(r, params)
+
+	require.NoError(t, r.Run())
+	assert.Equal(t, 1, handler.Summary().Total)
+	assert.Equal(t, 0, handler.Summary().Failed)
+	assert.NoError(t, replicaMock.ExpectationsWereMet())
+	assert.NoError(t, dbMock.ExpectationsWereMet())
+}
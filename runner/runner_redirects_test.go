@@ -0,0 +1,138 @@
+package runner
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lamoda/gonkey/output/json_report"
+	"github.com/lamoda/gonkey/signing"
+	"github.com/lamoda/gonkey/testloader/yaml_file"
+	"github.com/lamoda/gonkey/variables"
+)
+
+func TestRedirects(t *testing.T) {
+	srv := testRedirectsServer()
+	defer srv.Close()
+
+	RunWithTesting(t, &RunWithTestingParams{
+		Server:   srv,
+		TestsDir: filepath.Join("testdata", "redirects", "passing"),
+	})
+}
+
+func TestRedirectsMismatch(t *testing.T) {
+	srv := testRedirectsServer()
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "gonkey-redirects")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	reportPath := filepath.Join(dir, "report.json")
+
+	handler := NewConsoleHandler()
+	r := New(
+		&Config{
+			Host:      srv.URL,
+			Variables: variables.New(),
+		},
+		yaml_file.NewLoader(filepath.Join("testdata", "redirects", "failing")),
+		handler.HandleTest,
+	)
+	addCheckers(r, &RunWithTestingParams{})
+	jsonOutput := json_report.NewOutput(reportPath)
+	r.AddOutput(jsonOutput)
+
+	require.NoError(t, r.Run())
+	require.NoError(t, jsonOutput.Finalize())
+	assert.Equal(t, 2, handler.Summary().Failed)
+
+	data, err := ioutil.ReadFile(reportPath)
+	require.NoError(t, err)
+	var report json_report.Report
+	require.NoError(t, json.Unmarshal(data, &report))
+	require.Len(t, report.Tests, 2)
+
+	assert.Equal(t, []string{
+		"redirect 1 does not match: expected status 302, location /new, actual status 301, location /new",
+		"unexpected redirect 2: status 302, location /final?from=new",
+	}, report.Tests[0].Errors)
+
+	loopURL := srv.URL + "/loop-a"
+	assert.Equal(t, []string{
+		"redirect loop: " + loopURL + " -> " + srv.URL + "/loop-b -> " + loopURL,
+		"unexpected redirect 2: status 302, location /loop-a",
+	}, report.Tests[1].Errors)
+}
+
+func TestRedirectsToAnotherHost(t *testing.T) {
+	headers := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"authorization": r.Header.Get("Authorization"),
+			"cookie":        r.Header.Get("Cookie"),
+			"requestId":     r.Header.Get("X-Request-Id"),
+		})
+	})
+	other := httptest.NewServer(headers)
+	defer other.Close()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/elsewhere":
+			http.Redirect(w, r, other.URL+"/headers", http.StatusFound)
+		case "/here":
+			http.Redirect(w, r, "/headers", http.StatusFound)
+		default:
+			headers(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	RunWithTesting(t, &RunWithTestingParams{
+		Server:   srv,
+		TestsDir: filepath.Join("testdata", "redirects", "cross-host"),
+		RequestSigner: signing.SignerFunc(func(req *http.Request) error {
+			req.Header.Set("Authorization", "Signed")
+			return nil
+		}),
+	})
+}
+
+func testRedirectsServer() *httptest.Server {
+	redirect := func(w http.ResponseWriter, location string, status int) {
+		w.Header().Set("Location", location)
+		w.WriteHeader(status)
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/old":
+			redirect(w, "/new", http.StatusMovedPermanently)
+		case "/new":
+			redirect(w, "/final?from=new", http.StatusFound)
+		case "/final":
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(map[string]string{"page": "final", "method": r.Method})
+		case "/submit":
+			redirect(w, "/echo", http.StatusTemporaryRedirect)
+		case "/see-other":
+			redirect(w, "/echo", http.StatusSeeOther)
+		case "/loop-a":
+			redirect(w, "/loop-b", http.StatusFound)
+		case "/loop-b":
+			redirect(w, "/loop-a", http.StatusFound)
+		case "/echo":
+			body, _ := ioutil.ReadAll(r.Body)
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(map[string]string{"method": r.Method, "body": string(body)})
+		}
+	}))
+}
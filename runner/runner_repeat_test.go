@@ -0,0 +1,68 @@
+package runner
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lamoda/gonkey/models"
+	"github.com/lamoda/gonkey/testloader/yaml_file"
+	"github.com/lamoda/gonkey/variables"
+)
+
+func TestRepeat(t *testing.T) {
+	flakyCalls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/flaky" {
+			flakyCalls++
+			// the second call fails
+			if flakyCalls == 2 {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		}
+		_, _ = w.Write([]byte(`{"status": "ok"}`))
+	}))
+	defer srv.Close()
+
+	var names []string
+	var passed []bool
+	r := New(
+		&Config{
+			Host:      srv.URL,
+			Variables: variables.New(),
+		},
+		yaml_file.NewLoader(filepath.Join("testdata", "repeat")),
+		func(test models.TestInterface, executeTest testExecutor) error {
+			result, err := executeTest(test)
+			if err != nil {
+				return err
+			}
+			names = append(names, test.GetName())
+			passed = append(passed, result.Passed())
+			return nil
+		},
+	)
+	addCheckers(r, &RunWithTestingParams{})
+
+	require.NoError(t, r.Run())
+	assert.Equal(t, []string{
+		"stable [1 of 3]",
+		"stable [2 of 3]",
+		"stable [3 of 3]",
+		"flaky [1 of 5]",
+		"flaky [2 of 5]",
+		"once",
+	}, names)
+	assert.Equal(t, []bool{true, true, true, true, false, true}, passed)
+	assert.Equal(t, 2, flakyCalls)
+
+	assert.Equal(t, []models.RepeatedTest{
+		{Name: "stable", FileName: filepath.Join("testdata", "repeat", "repeat.yaml"), Count: 3, Runs: 3, Passed: 3},
+		{Name: "flaky", FileName: filepath.Join("testdata", "repeat", "repeat.yaml"), Count: 5, Runs: 2, Passed: 1},
+	}, r.RepeatedTests())
+}
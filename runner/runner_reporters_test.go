@@ -0,0 +1,44 @@
+package runner
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lamoda/gonkey/models"
+	"github.com/lamoda/gonkey/output"
+)
+
+func TestReporters(t *testing.T) {
+	srv := testServiceServer("orders")
+	defer srv.Close()
+
+	var reported []string
+	reporter := output.ReporterFunc(func(result *models.Result) {
+		reported = append(reported, result.Test.GetName())
+	})
+	// the result is reported before it's passed to the outputs
+	var reportedBeforeOutput []bool
+	outputFunc := reporterCheckOutput(func(test models.TestInterface, _ *models.Result) {
+		reportedBeforeOutput = append(reportedBeforeOutput,
+			len(reported) != 0 && reported[len(reported)-1] == test.GetName())
+	})
+
+	RunWithTesting(t, &RunWithTestingParams{
+		Server:     srv,
+		TestsDir:   filepath.Join("testdata", "status-reason"),
+		Reporters:  []output.Reporter{reporter},
+		OutputFunc: outputFunc,
+	})
+
+	assert.Equal(t, []string{"pending feature", "known broken", "running test"}, reported)
+	assert.Equal(t, []bool{true, true, true}, reportedBeforeOutput)
+}
+
+type reporterCheckOutput func(models.TestInterface, *models.Result)
+
+func (o reporterCheckOutput) Process(test models.TestInterface, result *models.Result) error {
+	o(test, result)
+	return nil
+}
@@ -0,0 +1,80 @@
+package runner
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lamoda/gonkey/testloader/yaml_file"
+	"github.com/lamoda/gonkey/variables"
+)
+
+func signPayload(ctx RequestBuilderContext) ([]byte, error) {
+	orderID, ok := ctx.Variables.Value("orderId")
+	if !ok {
+		return nil, errors.New("orderId is not set")
+	}
+	return json.Marshal(map[string]interface{}{
+		"payload":   json.RawMessage(ctx.Test.GetRequest()),
+		"signature": "sig-" + orderID,
+	})
+}
+
+func TestRequestBuilder(t *testing.T) {
+	srv := testEchoServer()
+	defer srv.Close()
+
+	RunWithTesting(t, &RunWithTestingParams{
+		Server:          srv,
+		TestsDir:        filepath.Join("testdata", "request-builder"),
+		RequestBuilders: map[string]RequestBuilder{"signPayload": signPayload},
+	})
+}
+
+func TestUnknownRequestBuilder(t *testing.T) {
+	srv := testEchoServer()
+	defer srv.Close()
+
+	r := New(
+		&Config{
+			Host:      srv.URL,
+			Variables: variables.New(),
+		},
+		yaml_file.NewLoader(filepath.Join("testdata", "request-builder")),
+		NewConsoleHandler().HandleTest,
+	)
+
+	err := r.Run()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown requestBuilder signPayload")
+}
+
+func TestUnknownRequestBuilderDryRun(t *testing.T) {
+	handler := NewConsoleHandler()
+	r := New(
+		&Config{
+			Variables: variables.New(),
+			DryRun:    true,
+		},
+		yaml_file.NewLoader(filepath.Join("testdata", "request-builder")),
+		handler.HandleTest,
+	)
+
+	require.NoError(t, r.Run())
+	assert.Equal(t, 1, handler.Summary().Failed)
+}
+
+func testEchoServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+	}))
+}
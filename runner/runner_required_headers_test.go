@@ -0,0 +1,76 @@
+package runner
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lamoda/gonkey/models"
+	"github.com/lamoda/gonkey/testloader/yaml_file"
+	"github.com/lamoda/gonkey/variables"
+)
+
+func TestRequiredHeaders(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/secure" {
+			w.Header().Set("Strict-Transport-Security", "max-age=31536000; includeSubDomains")
+			w.Header().Set("X-Content-Type-Options", "nosniff")
+		} else {
+			w.Header().Set("X-Content-Type-Options", "sniff")
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	results := map[string]*models.Result{}
+	r := New(
+		&Config{
+			Host:      srv.URL,
+			Variables: variables.New(),
+			HeaderPolicies: []HeaderPolicy{{
+				Name: "security",
+				Headers: map[string]string{
+					"Strict-Transport-Security": "$matchRegexp(^max-age=\\d+)",
+					"X-Content-Type-Options":    "nosniff",
+				},
+			}},
+		},
+		yaml_file.NewLoader(filepath.Join("testdata", "required-headers")),
+		func(test models.TestInterface, executeTest testExecutor) error {
+			result, err := executeTest(test)
+			if err != nil {
+				return err
+			}
+			results[test.GetName()] = result
+			return nil
+		},
+	)
+	addCheckers(r, &RunWithTestingParams{})
+	require.NoError(t, r.Run())
+	require.Len(t, results, 3)
+
+	assert.True(t, results["required headers: present"].Passed())
+	assert.True(t, results["required headers: opted out"].Passed())
+	for _, check := range results["required headers: opted out"].Checks {
+		assert.NotEqual(t, requiredHeadersCheck, check.Checker)
+	}
+
+	missing := results["required headers: missing"]
+	assert.False(t, missing.Passed())
+	var errs []string
+	for _, check := range missing.Checks {
+		if check.Checker == requiredHeadersCheck {
+			for _, err := range check.Errors {
+				errs = append(errs, err.Error())
+			}
+		}
+	}
+	assert.Equal(t, []string{
+		"response has no header Strict-Transport-Security required by policy security",
+		`response header X-Content-Type-Options "sniff" does not match "nosniff" required by policy security`,
+	}, errs)
+}
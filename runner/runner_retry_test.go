@@ -0,0 +1,163 @@
+package runner
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lamoda/gonkey/checker/response_body"
+	"github.com/lamoda/gonkey/mocks"
+	"github.com/lamoda/gonkey/models"
+	"github.com/lamoda/gonkey/testloader/yaml_file"
+	"github.com/lamoda/gonkey/variables"
+)
+
+func TestRetryPolicy(t *testing.T) {
+	srv := testJobsServer()
+	defer srv.Close()
+
+	RunWithTesting(t, &RunWithTestingParams{
+		Server:   srv,
+		TestsDir: filepath.Join("testdata", "retry", "passing"),
+	})
+}
+
+func TestRetryPolicyFailures(t *testing.T) {
+	srv := testJobsServer()
+	defer srv.Close()
+
+	handler := NewConsoleHandler()
+	r := New(
+		&Config{
+			Host:      srv.URL,
+			Variables: variables.New(),
+		},
+		yaml_file.NewLoader(filepath.Join("testdata", "retry", "failing")),
+		handler.HandleTest,
+	)
+	addCheckers(r, &RunWithTestingParams{})
+
+	require.NoError(t, r.Run())
+
+	summary := handler.Summary()
+	assert.Equal(t, 3, summary.Total)
+	assert.Equal(t, 3, summary.Failed)
+}
+
+func TestRetryPolicyWithMocksAndCustomFuncs(t *testing.T) {
+	m := mocks.NewNop("backend")
+	require.NoError(t, m.Start())
+	defer m.Shutdown()
+
+	srv := testServerProxy(m.Service("backend").ServerAddr())
+	defer srv.Close()
+
+	RunWithTesting(t, &RunWithTestingParams{
+		Server:   srv,
+		TestsDir: filepath.Join("testdata", "retry", "mocks"),
+		Mocks:    m,
+		CustomCompareFuncs: map[string]response_body.CustomCompareFunc{
+			"pending": func(ctx response_body.CustomCompareContext, actual interface{}) error {
+				if actual != "pending" {
+					return fmt.Errorf("%s is not pending", ctx.Path)
+				}
+				return nil
+			},
+		},
+	})
+}
+
+// testJobsServer responds that the job is pending on the first request and done on the next ones,
+// the job "done" is done at once and the job "never" is never done
+func testJobsServer() *httptest.Server {
+	var mu sync.Mutex
+	calls := map[string]int{}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls[r.URL.Path]++
+		call := calls[r.URL.Path]
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/jobs/done" || (r.URL.Path != "/jobs/never" && call > 1):
+			_, _ = io.WriteString(w, `{"state": "done"}`)
+		default:
+			w.WriteHeader(http.StatusAccepted)
+			_, _ = io.WriteString(w, `{"state": "pending"}`)
+		}
+	}))
+}
+
+func TestRetryAfterReplacesDelay(t *testing.T) {
+	var mu sync.Mutex
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls++
+		call := calls
+		mu.Unlock()
+
+		if call == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"state": "done"}`)
+	}))
+	defer srv.Close()
+
+	// the delay of the policy is 10 seconds, the test would time out without Retry-After
+	start := time.Now()
+	RunWithTesting(t, &RunWithTestingParams{
+		Server:   srv,
+		TestsDir: filepath.Join("testdata", "retry", "throttled"),
+	})
+	assert.Equal(t, 2, calls)
+	assert.Less(t, int64(time.Since(start)), int64(5*time.Second))
+}
+
+func TestRetryDelay(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	throttled := func(retryAfter string) *models.Result {
+		return &models.Result{
+			ResponseStatusCode: http.StatusTooManyRequests,
+			ResponseHeaders:    map[string][]string{"Retry-After": {retryAfter}},
+		}
+	}
+	policy := models.RetryPolicy{Attempts: 3, Delay: 2}
+
+	tests := []struct {
+		name   string
+		policy models.RetryPolicy
+		result *models.Result
+		delay  time.Duration
+	}{
+		{"delay of the policy", policy, &models.Result{ResponseStatusCode: http.StatusAccepted}, 2 * time.Second},
+		{"seconds", policy, throttled("5"), 5 * time.Second},
+		{"HTTP date", policy, throttled("Fri, 01 Mar 2024 12:00:07 GMT"), 7 * time.Second},
+		{"date in the past", policy, throttled("Fri, 01 Mar 2024 11:00:00 GMT"), 0},
+		{"invalid value", policy, throttled("soon"), 2 * time.Second},
+		{"default cap", policy, throttled("3600"), time.Minute},
+		{"cap of the policy", models.RetryPolicy{Attempts: 3, MaxRetryAfter: 10}, throttled("30"), 10 * time.Second},
+		{"not throttled", policy, &models.Result{
+			ResponseStatusCode: http.StatusServiceUnavailable,
+			ResponseHeaders:    map[string][]string{"Retry-After": {"5"}},
+		}, 2 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.delay, retryDelay(tt.policy, tt.result, now))
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package runner
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lamoda/gonkey/models"
+	"github.com/lamoda/gonkey/testloader/yaml_file"
+	"github.com/lamoda/gonkey/variables"
+)
+
+type vaultProvider map[string]string
+
+func (p vaultProvider) Resolve(path string) (string, error) {
+	value, ok := p[path]
+	if !ok {
+		return "", errors.New("secret not found")
+	}
+	return value, nil
+}
+
+type resultsOutput struct {
+	results []*models.Result
+}
+
+func (o *resultsOutput) Process(_ models.TestInterface, result *models.Result) error {
+	o.results = append(o.results, result)
+	return nil
+}
+
+func TestSecretsFromVariableProvider(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"token": strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "),
+		})
+	}))
+	defer srv.Close()
+
+	out := &resultsOutput{}
+	handler := NewConsoleHandler()
+	r := New(
+		&Config{
+			Host:             srv.URL,
+			Variables:        variables.New(),
+			VariableProvider: vaultProvider{"api/token": "s3cr3t"},
+		},
+		yaml_file.NewLoader(filepath.Join("testdata", "secrets")),
+		handler.HandleTest,
+	)
+	addCheckers(r, &RunWithTestingParams{})
+	r.AddOutput(out)
+
+	require.NoError(t, r.Run())
+	assert.Equal(t, 0, handler.Summary().Failed)
+
+	require.Len(t, out.results, 1)
+	result := out.results[0]
+	assert.Equal(t, `{"token":"******"}`+"\n", result.ResponseBody)
+	assert.Equal(t, "Bearer ******", result.Test.Headers()["Authorization"])
+	assert.Contains(t, result.RequestCurl, `-H 'Authorization: Bearer ******'`)
+	assert.NotContains(t, result.RequestCurl, "s3cr3t")
+}
+
+func TestSecretsNotResolved(t *testing.T) {
+	handler := NewConsoleHandler()
+	r := New(
+		&Config{
+			Host:             "http://localhost",
+			Variables:        variables.New(),
+			VariableProvider: vaultProvider{},
+		},
+		yaml_file.NewLoader(filepath.Join("testdata", "secrets")),
+		handler.HandleTest,
+	)
+
+	err := r.Run()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unable to resolve secret api/token: secret not found")
+}
@@ -0,0 +1,55 @@
+package runner
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lamoda/gonkey/testloader/yaml_file"
+	"github.com/lamoda/gonkey/variables"
+)
+
+func TestNamedServers(t *testing.T) {
+	orders := testServiceServer("orders")
+	defer orders.Close()
+	inventory := testServiceServer("inventory")
+	defer inventory.Close()
+
+	RunWithTesting(t, &RunWithTestingParams{
+		Server:   orders,
+		Servers:  map[string]*httptest.Server{"inventory": inventory},
+		TestsDir: filepath.Join("testdata", "servers", "passing"),
+	})
+}
+
+func TestUnknownServer(t *testing.T) {
+	orders := testServiceServer("orders")
+	defer orders.Close()
+
+	handler := NewConsoleHandler()
+	r := New(
+		&Config{
+			Host:      orders.URL,
+			Variables: variables.New(),
+		},
+		yaml_file.NewLoader(filepath.Join("testdata", "servers", "failing")),
+		handler.HandleTest,
+	)
+	addCheckers(r, &RunWithTestingParams{})
+
+	err := r.Run()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown server "billing"`)
+}
+
+func testServiceServer(name string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"service": "`+name+`"}`)
+	}))
+}
@@ -0,0 +1,70 @@
+package runner
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lamoda/gonkey/mocks"
+	"github.com/lamoda/gonkey/output/json_report"
+	"github.com/lamoda/gonkey/testloader/yaml_file"
+	"github.com/lamoda/gonkey/variables"
+)
+
+func TestSpyMocks(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"id": "42", "status": "paid"}`)
+	}))
+	defer backend.Close()
+
+	m := mocks.NewNop("backend")
+	require.NoError(t, m.Start())
+	defer m.Shutdown()
+
+	srv := testServerProxy(m.Service("backend").ServerAddr())
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "gonkey-spy")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	reportPath := filepath.Join(dir, "report.json")
+
+	vars := variables.New()
+	vars.Set("backendURL", backend.URL)
+	handler := NewConsoleHandler()
+	r := New(
+		&Config{
+			Host:        srv.URL,
+			Mocks:       m,
+			MocksLoader: mocks.NewLoader(m),
+			Variables:   vars,
+		},
+		yaml_file.NewLoader(filepath.Join("testdata", "spy")),
+		handler.HandleTest,
+	)
+	addCheckers(r, &RunWithTestingParams{})
+	jsonOutput := json_report.NewOutput(reportPath)
+	r.AddOutput(jsonOutput)
+
+	require.NoError(t, r.Run())
+	require.NoError(t, jsonOutput.Finalize())
+	assert.True(t, handler.Summary().Success)
+
+	data, err := ioutil.ReadFile(reportPath)
+	require.NoError(t, err)
+	var report json_report.Report
+	require.NoError(t, json.Unmarshal(data, &report))
+	require.Len(t, report.Tests, 1)
+	assert.Equal(t, []json_report.SpiedRequestReport{
+		{Service: "backend", Method: http.MethodGet, URL: "/orders/42", Status: http.StatusOK},
+	}, report.Tests[0].SpiedRequests)
+}
@@ -0,0 +1,69 @@
+package runner
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lamoda/gonkey/output/json_report"
+	"github.com/lamoda/gonkey/testloader/yaml_file"
+	"github.com/lamoda/gonkey/variables"
+)
+
+func TestStatusReason(t *testing.T) {
+	srv := testServiceServer("orders")
+	defer srv.Close()
+
+	RunWithTesting(t, &RunWithTestingParams{
+		Server:   srv,
+		TestsDir: filepath.Join("testdata", "status-reason"),
+	})
+}
+
+func TestStatusReasonInOutputs(t *testing.T) {
+	srv := testServiceServer("orders")
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "gonkey-status-reason")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	reportPath := filepath.Join(dir, "report.json")
+
+	handler := NewConsoleHandler()
+	r := New(
+		&Config{
+			Host:      srv.URL,
+			Variables: variables.New(),
+		},
+		yaml_file.NewLoader(filepath.Join("testdata", "status-reason")),
+		handler.HandleTest,
+	)
+	addCheckers(r, &RunWithTestingParams{})
+	jsonOutput := json_report.NewOutput(reportPath)
+	r.AddOutput(jsonOutput)
+
+	require.NoError(t, r.Run())
+	require.NoError(t, jsonOutput.Finalize())
+
+	summary := handler.Summary()
+	assert.True(t, summary.Success)
+	assert.Equal(t, 1, summary.Skipped)
+	assert.Equal(t, 1, summary.Broken)
+
+	data, err := ioutil.ReadFile(reportPath)
+	require.NoError(t, err)
+	var report json_report.Report
+	require.NoError(t, json.Unmarshal(data, &report))
+
+	require.Len(t, report.Tests, 3)
+	assert.Equal(t, "skipped", report.Tests[0].Status)
+	assert.Equal(t, "waiting for ORDERS-123", report.Tests[0].Reason)
+	assert.Equal(t, "broken", report.Tests[1].Status)
+	assert.Equal(t, "flaky, see ORDERS-456", report.Tests[1].Reason)
+	assert.Equal(t, "passed", report.Tests[2].Status)
+}
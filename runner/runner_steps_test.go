@@ -0,0 +1,128 @@
+package runner
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lamoda/gonkey/testloader/yaml_file"
+	"github.com/lamoda/gonkey/variables"
+)
+
+func TestSteps(t *testing.T) {
+	loader := &memoryLoader{location: filepath.Join("testdata", "steps", "fixtures")}
+	srv := testOrdersServer(loader)
+	defer srv.Close()
+
+	vars := variables.New()
+	handler := NewConsoleHandler()
+	r := New(
+		&Config{
+			Host:           srv.URL,
+			FixturesLoader: loader,
+			Variables:      vars,
+		},
+		yaml_file.NewLoader(filepath.Join("testdata", "steps", "passing")),
+		handler.HandleTest,
+	)
+	addCheckers(r, &RunWithTestingParams{})
+
+	results := &resultsOutput{}
+	r.AddOutput(results)
+
+	require.NoError(t, r.Run())
+	assert.Equal(t, 4, handler.Summary().Total)
+	assert.Equal(t, 0, handler.Summary().Failed)
+	assert.Equal(t, []string{
+		"order lifecycle [step 1 of 4: create]",
+		"order lifecycle [step 2 of 4: read]",
+		"order lifecycle [step 3 of 4: delete]",
+		"order lifecycle [step 4 of 4: step 4]",
+	}, testNames(results))
+
+	// the fixtures are loaded and cleaned once, the variables don't outlive the scenario
+	assert.Equal(t, []string{"customers"}, loader.cleaned)
+	_, ok := vars.Value("orderId")
+	assert.False(t, ok)
+}
+
+func TestStepsSkippedAfterFailure(t *testing.T) {
+	loader := &memoryLoader{location: filepath.Join("testdata", "steps", "fixtures")}
+	srv := testOrdersServer(loader)
+	defer srv.Close()
+
+	handler := NewConsoleHandler()
+	r := New(
+		&Config{
+			Host:      srv.URL,
+			Variables: variables.New(),
+		},
+		yaml_file.NewLoader(filepath.Join("testdata", "steps", "failing")),
+		handler.HandleTest,
+	)
+	addCheckers(r, &RunWithTestingParams{})
+
+	results := &resultsOutput{}
+	r.AddOutput(results)
+
+	require.NoError(t, r.Run())
+	assert.Equal(t, 2, handler.Summary().Total)
+	assert.Equal(t, 1, handler.Summary().Failed)
+	assert.Equal(t, 1, handler.Summary().Skipped)
+	require.Len(t, results.results, 2)
+	assert.Empty(t, results.results[0].Test.GetStatus())
+	assert.Equal(t, "skipped", results.results[1].Test.GetStatus())
+	assert.Equal(t, "step 1 of the scenario failed", results.results[1].Test.GetReason())
+}
+
+func testNames(results *resultsOutput) []string {
+	var names []string
+	for _, result := range results.results {
+		names = append(names, result.Test.GetName())
+	}
+	return names
+}
+
+// testOrdersServer creates, reads and deletes the orders of the customers loaded as fixtures
+func testOrdersServer(customers *memoryLoader) *httptest.Server {
+	var mu sync.Mutex
+	orders := map[string]string{}
+	lastID := 0
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		id := strings.TrimPrefix(r.URL.Path, "/orders/")
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/orders":
+			var order struct{ Customer string }
+			_ = json.NewDecoder(r.Body).Decode(&order)
+			if _, ok := customers.get(order.Customer); !ok {
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				return
+			}
+			lastID++
+			id = strconv.Itoa(lastID)
+			orders[id] = order.Customer
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(map[string]string{"id": id, "customer": order.Customer})
+		case r.Method == http.MethodGet && orders[id] != "":
+			_ = json.NewEncoder(w).Encode(map[string]string{"id": id, "customer": orders[id]})
+		case r.Method == http.MethodDelete && orders[id] != "":
+			delete(orders, id)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
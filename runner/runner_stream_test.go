@@ -0,0 +1,109 @@
+package runner
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lamoda/gonkey/testloader/yaml_file"
+	"github.com/lamoda/gonkey/variables"
+)
+
+func TestStreamResponse(t *testing.T) {
+	srv := testStreamServer()
+	defer srv.Close()
+
+	RunWithTesting(t, &RunWithTestingParams{
+		Server:   srv,
+		TestsDir: filepath.Join("testdata", "stream", "passing"),
+	})
+}
+
+func TestStreamResponseMismatch(t *testing.T) {
+	srv := testStreamServer()
+	defer srv.Close()
+
+	handler := NewConsoleHandler()
+	r := New(
+		&Config{
+			Host:      srv.URL,
+			Variables: variables.New(),
+		},
+		yaml_file.NewLoader(filepath.Join("testdata", "stream", "failing")),
+		handler.HandleTest,
+	)
+	addCheckers(r, &RunWithTestingParams{})
+
+	require.NoError(t, r.Run())
+
+	summary := handler.Summary()
+	assert.Equal(t, 2, summary.Total)
+	assert.Equal(t, 2, summary.Failed)
+}
+
+func TestSSEResponse(t *testing.T) {
+	srv := testStreamServer()
+	defer srv.Close()
+
+	RunWithTesting(t, &RunWithTestingParams{
+		Server:   srv,
+		TestsDir: filepath.Join("testdata", "sse", "passing"),
+	})
+}
+
+func TestSSEResponseMismatch(t *testing.T) {
+	srv := testStreamServer()
+	defer srv.Close()
+
+	handler := NewConsoleHandler()
+	r := New(
+		&Config{
+			Host:      srv.URL,
+			Variables: variables.New(),
+		},
+		yaml_file.NewLoader(filepath.Join("testdata", "sse", "failing")),
+		handler.HandleTest,
+	)
+	addCheckers(r, &RunWithTestingParams{})
+
+	require.NoError(t, r.Run())
+
+	summary := handler.Summary()
+	assert.Equal(t, 1, summary.Total)
+	assert.Equal(t, 1, summary.Failed)
+}
+
+func testStreamServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		flusher := w.(http.Flusher)
+
+		switch r.URL.Path {
+		case "/events":
+			_, _ = io.WriteString(w, "{\"event\": \"start\", \"id\": 1}\n")
+			flusher.Flush()
+			_, _ = io.WriteString(w, "{\"event\": \"progress\", \"id\": 2}\n")
+			flusher.Flush()
+			_, _ = io.WriteString(w, "{\"event\": \"done\", \"id\": 3}\n")
+		case "/endless":
+			_, _ = io.WriteString(w, "{\"event\": \"start\"}\n")
+			flusher.Flush()
+			_, _ = io.WriteString(w, "{\"event\": \"heartbeat\"}\n")
+			flusher.Flush()
+			// keep the stream open until the client goes away
+			<-r.Context().Done()
+		case "/sse":
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = io.WriteString(w, ": connected\n\nevent: progress\nid: 1\ndata: {\"percent\": 50}\n\n")
+			flusher.Flush()
+			_, _ = io.WriteString(w, "data: line 1\ndata: line 2\n\n")
+			flusher.Flush()
+			<-r.Context().Done()
+		}
+	}))
+}
@@ -2,26 +2,44 @@ package runner
 
 import (
 	"database/sql"
-	"errors"
+	"io"
+	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"os"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/aerospike/aerospike-client-go/v5"
+	"github.com/gocql/gocql"
 	"github.com/joho/godotenv"
+	"google.golang.org/protobuf/reflect/protoreflect"
 
 	"github.com/lamoda/gonkey/checker"
 	"github.com/lamoda/gonkey/checker/response_body"
+	"github.com/lamoda/gonkey/checker/response_cache"
+	"github.com/lamoda/gonkey/checker/response_cookie"
 	"github.com/lamoda/gonkey/checker/response_db"
 	"github.com/lamoda/gonkey/checker/response_header"
+	"github.com/lamoda/gonkey/checker/response_metrics"
+	"github.com/lamoda/gonkey/checker/response_snapshot"
+	"github.com/lamoda/gonkey/checker/response_status_text"
+	"github.com/lamoda/gonkey/files"
 	"github.com/lamoda/gonkey/fixtures"
+	"github.com/lamoda/gonkey/fixtures/postgres"
+	"github.com/lamoda/gonkey/logging"
 	"github.com/lamoda/gonkey/mocks"
 	"github.com/lamoda/gonkey/models"
+	"github.com/lamoda/gonkey/openapi"
 	"github.com/lamoda/gonkey/output"
 	"github.com/lamoda/gonkey/output/allure_report"
+	"github.com/lamoda/gonkey/output/json_report"
 	testingOutput "github.com/lamoda/gonkey/output/testing"
+	"github.com/lamoda/gonkey/querycount"
+	"github.com/lamoda/gonkey/signing"
 	aerospikeAdapter "github.com/lamoda/gonkey/storage/aerospike"
+	cassandraAdapter "github.com/lamoda/gonkey/storage/cassandra"
 	"github.com/lamoda/gonkey/testloader/yaml_file"
 	"github.com/lamoda/gonkey/variables"
 )
@@ -31,6 +49,14 @@ type Aerospike struct {
 	Namespace string
 }
 
+type Cassandra struct {
+	*gocql.Session
+	// ReadConsistency is used by the dbQuery checks, gocql.Quorum by default
+	ReadConsistency gocql.Consistency
+}
+
+const defaultEnvOverridesDir = "environments"
+
 type RunWithTestingParams struct {
 	Server      *httptest.Server
 	TestsDir    string
@@ -44,6 +70,141 @@ type RunWithTestingParams struct {
 	OutputFunc    output.OutputInterface
 	Checkers      []checker.CheckerInterface
 	FixtureLoader fixtures.Loader
+	// Reporters receive the result of every test right after it finishes, e.g. to stream the results
+	// to a dashboard, the reporters shared by the parallel tests must be wrapped with output.SyncReporter
+	Reporters []output.Reporter
+	// CustomCompareFuncs can be referenced in the expected response body as "$custom:name"
+	CustomCompareFuncs map[string]response_body.CustomCompareFunc
+	// ExpectedBodies are the expected response bodies as Go values by test name, e.g.
+	// response_body.ExpectedBody(200, Order{ID: 1}), for the statuses without the bodies in the test files
+	ExpectedBodies map[string]response_body.ExpectedBodyFunc
+	// ExpectedBodyTransforms modify the expected response bodies of the test files by test name before
+	// they are compared, e.g. to add the values derived from the request
+	ExpectedBodyTransforms map[string]response_body.ExpectedBodyTransform
+	// RequestBuilders build the request bodies of the tests referring to them as "requestBuilder: name"
+	RequestBuilders map[string]RequestBuilder
+	// ServerLogs is the source of the tested service logs (e.g. a pipe connected to its stderr),
+	// the logs written during a failed test are shown in its report
+	ServerLogs io.Reader
+	// ServerLogsMaxSize limits the size of the logs kept for a test (64KB by default)
+	ServerLogsMaxSize int
+	// SerializeFixtures makes the tests loading fixtures into the same tables wait for each other,
+	// it allows to call RunWithTesting from parallel tests using the same database
+	SerializeFixtures bool
+	// EnvOverridesDir is the directory with environment overrides files, the file <GONKEY_ENV>.yaml
+	// is used if GONKEY_ENV is set. By default "environments" is used.
+	EnvOverridesDir string
+	// ProfilesFile is the path to the file with the variable profiles the tests refer to with useProfile
+	ProfilesFile string
+	// Servers are the named servers which can be targeted by the tests with "server: <name>",
+	// the tests without server are sent to Server
+	Servers map[string]*httptest.Server
+	// SummaryGate fails the test if the results of the tests as a whole don't satisfy it,
+	// e.g. if too many tests failed or a test tagged as critical failed
+	SummaryGate *SummaryGate
+	// Headers are added to the requests of all tests, the headers of a test win over them
+	Headers map[string]string
+	// HeaderPolicies are the headers required in the responses of all tests unless a test
+	// disables the required_headers check
+	HeaderPolicies []HeaderPolicy
+	// OpenAPISpec is the path to the OpenAPI 3 spec of the service, the tests with the requests
+	// not conforming to it fail without being sent
+	OpenAPISpec string
+	// ValidateOpenAPIResponses makes the responses be validated against the spec as well
+	ValidateOpenAPIResponses bool
+	// DbDsn is used to open the database of DbType if DB is not set, the database is closed
+	// when the tests are finished
+	DbDsn string
+	// DbOptions are the connection pool settings of the database opened with DbDsn
+	DbOptions fixtures.DBOptions
+	// ReadDB is the connection of the DB checks (dbQuery, idempotency) if they read from another
+	// connection than the fixtures are loaded with, e.g. from a replica. DB is used if it's not set,
+	// the fixtures are always loaded into DB.
+	ReadDB *sql.DB
+	// BaseDir is the directory for the relative paths of the files referenced by the tests
+	// (request files, golden files, mock files, etc.), by default they are relative to the test file
+	BaseDir string
+	// CacheDir is the directory of the cache of the parsed test files, the unchanged files
+	// are not parsed again, GONKEY_CACHE_DIR by default
+	CacheDir string
+	// WaitTimeout makes the tests wait for DB and WaitForAddrs to respond before running,
+	// the test fails if they are not ready in time
+	WaitTimeout time.Duration
+	// WaitForAddrs are TCP addresses (host:port) of the dependencies to wait for, e.g. a message broker,
+	// the timeout is 30 seconds unless WaitTimeout is set
+	WaitForAddrs []string
+	// SchemaPerTest makes the fixtures of each test be loaded into its own Postgres schema,
+	// the service under test has to use it in its search_path
+	SchemaPerTest *postgres.SchemaOptions
+	// FixturesCopyThreshold makes the Postgres fixtures of the tables having at least the number of rows
+	// be loaded with COPY instead of INSERT, the DB must be opened with the lib/pq driver
+	FixturesCopyThreshold int
+	// SharedFixturesDirs are the directories of the fixtures shared by the test suites, e.g. the common seeds,
+	// the fixtures are looked up in them in order after FixturesDir
+	SharedFixturesDirs []string
+	// ProtobufMessages describe the protobuf messages stored in the bytea columns: the Postgres fixtures
+	// serialize the values marked with $protobuf and the DB checker decodes the columns of dbProtobufColumns
+	ProtobufMessages []protoreflect.MessageDescriptor
+	// DbExplainSlowerThan attaches the EXPLAIN ANALYZE plans of the DB checks to the results of the tests
+	// whose request or check query took longer, the plans are captured for PostgreSQL only
+	DbExplainSlowerThan time.Duration
+	// RequestInterceptor is called with every request right before it's sent, e.g. to sign it
+	RequestInterceptor func(*http.Request)
+	// ResponseInterceptor is called with every response before the checkers
+	ResponseInterceptor func(*http.Response)
+	// RequestSigner signs every request after the request interceptor, e.g. with AWS Signature Version 4
+	RequestSigner signing.Signer
+	// VariableProvider resolves the references {{ $secret:path }}, the environment variables are used by default
+	VariableProvider variables.Provider
+	// UniqueValues generate {{ $uniqueInt }}, e.g. variables.NewUniqueValues(seed) to reproduce the values
+	// of a run, the process-wide generator shared by the parallel tests is used by default
+	UniqueValues *variables.UniqueValues
+	// Cassandra is the session used to load the fixtures and to run dbQuery checks with DbType fixtures.Cassandra
+	Cassandra Cassandra
+	// SlowestTests is the number of the slowest tests logged when the tests are finished,
+	// the time of the fixtures is logged separately
+	SlowestTests int
+	// FS is the filesystem the tests, the fixtures and the files of the mocks are read from,
+	// the OS filesystem by default, e.g. files.FromFS of the files embedded with go:embed
+	FS files.FS
+	// FailUnusedMocks fails the tests if a service mock defined in the test is never called,
+	// the mocks with `optional: true` may be left uncalled
+	FailUnusedMocks bool
+	// RateLimit paces the requests of the tests, e.g. NewRateLimiter(20, 1, 10*time.Millisecond),
+	// pass the same limiter to the parallel tests to limit their requests in total
+	RateLimit *RateLimiter
+	// HTTP2 makes the client negotiate HTTP/2 with the TLS servers supporting it, e.g. the server
+	// started with httptest.NewUnstartedServer, EnableHTTP2 and StartTLS
+	HTTP2 bool
+	// DisableKeepAlives, MaxIdleConns and MaxIdleConnsPerHost control the reuse of the connections
+	// to the service, see runner.Config
+	DisableKeepAlives   bool
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	// Redact are the rules redacting the personal data in the outputs, the JSON paths of the values
+	// of the bodies (e.g. $.customer.email) or the regular expressions, see output.NewRedaction
+	Redact []string
+	// MetricsURL is the URL of the Prometheus metrics of the service scraped before and after the requests
+	// of the tests with metricsDelta, e.g. the URL of the server with /metrics
+	MetricsURL string
+	// SnapshotsDir is the directory of the snapshots of the responses, the responses are compared with the snapshots
+	// stored by the previous run, GONKEY_UPDATE_GOLDEN rewrites the ones which differ
+	SnapshotsDir string
+	// SnapshotIgnorePaths are the paths of the volatile fields of the responses not compared with the snapshots
+	// in all the tests, e.g. $.items[*].createdAt
+	SnapshotIgnorePaths []string
+	// QueryCounter counts the DB queries of the service for maxDbQueries of the tests,
+	// the service must open its DB with querycount.NewConnector sharing the counter
+	QueryCounter *querycount.Counter
+	// GoroutineLeaks fails the tests after which the process runs more goroutines than before them
+	// and the allowance, e.g. the goroutines of the handlers of Server not finished after the response
+	GoroutineLeaks *GoroutineLeaks
+	// BodyFormatters pretty-print the bodies in the testing and allure outputs by content type,
+	// output.DefaultBodyFormatters by default, e.g. with a formatter for application/x-protobuf added
+	BodyFormatters output.BodyFormatters
+	// Logger receives the structured events of the runner and the mocks, e.g. to route them to slog,
+	// the events are written to stdout with GONKEY_DEBUG by default
+	Logger logging.Logger
 }
 
 // RunWithTesting is a helper function the wraps the common Run and provides simple way
@@ -51,7 +212,19 @@ type RunWithTestingParams struct {
 func RunWithTesting(t *testing.T, params *RunWithTestingParams) {
 	var mocksLoader *mocks.Loader
 	if params.Mocks != nil {
+		if value := os.Getenv("GONKEY_MOCKS_SEED"); value != "" {
+			seed, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				t.Fatalf("GONKEY_MOCKS_SEED should be an integer, got %q", value)
+			}
+			params.Mocks.SetRandomSeed(seed)
+		}
+		params.Mocks.SetFailOnUnused(params.FailUnusedMocks)
 		mocksLoader = mocks.NewLoader(params.Mocks)
+		mocksLoader.SetEnvironment(os.Getenv("GONKEY_ENV"))
+		if params.FS != nil {
+			mocksLoader.SetFS(params.FS)
+		}
 	}
 
 	if params.EnvFilePath != "" {
@@ -60,17 +233,45 @@ func RunWithTesting(t *testing.T, params *RunWithTestingParams) {
 		}
 	}
 
+	if params.DB == nil && params.DbDsn != "" {
+		db, err := fixtures.OpenDBWithOptions(params.DbType, params.DbDsn, params.DbOptions)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer db.Close()
+
+		// the params of the caller are kept intact
+		withDB := *params
+		withDB.DB = db
+		params = &withDB
+	}
+
+	if params.WaitTimeout > 0 || len(params.WaitForAddrs) != 0 {
+		if err := WaitForDependencies(params.DB, params.WaitForAddrs, params.WaitTimeout); err != nil {
+			t.Fatal(err)
+		}
+	}
+
 	debug := os.Getenv("GONKEY_DEBUG") != ""
 
 	var fixturesLoader fixtures.Loader
-	if params.DB != nil || params.Aerospike.Client != nil || params.FixtureLoader != nil {
+	if params.DB != nil || params.Aerospike.Client != nil || params.Cassandra.Session != nil || params.FixtureLoader != nil {
 		fixturesLoader = fixtures.NewLoader(&fixtures.Config{
 			Location:      params.FixturesDir,
 			DB:            params.DB,
 			Aerospike:     aerospikeAdapter.New(params.Aerospike.Client, params.Aerospike.Namespace),
+			Cassandra:     cassandraAdapter.New(params.Cassandra.Session, params.Cassandra.ReadConsistency),
 			Debug:         debug,
 			DbType:        params.DbType,
 			FixtureLoader: params.FixtureLoader,
+			SchemaPerTest: params.SchemaPerTest,
+			CopyThreshold: params.FixturesCopyThreshold,
+			FS:            params.FS,
+			Environment:   os.Getenv("GONKEY_ENV"),
+
+			SharedLocations: params.SharedFixturesDirs,
+
+			ProtobufMessages: params.ProtobufMessages,
 		})
 	}
 
@@ -88,36 +289,181 @@ func RunWithTesting(t *testing.T, params *RunWithTestingParams) {
 	if params.OutputFunc != nil {
 		runner.AddOutput(params.OutputFunc)
 	} else {
-		runner.AddOutput(testingOutput.NewOutput())
+		testOutput := testingOutput.NewOutput()
+		testOutput.SetMaxBodySize(maxBodySizeFromEnv(t, "GONKEY_OUTPUT_MAX_BODY_SIZE"))
+		if params.BodyFormatters != nil {
+			testOutput.SetBodyFormatters(params.BodyFormatters)
+		}
+		runner.AddOutput(testOutput)
 	}
 
 	if os.Getenv("GONKEY_ALLURE_DIR") != "" {
 		allureOutput := allure_report.NewOutput("Gonkey", os.Getenv("GONKEY_ALLURE_DIR"))
+		allureOutput.SetMaxBodySize(maxBodySizeFromEnv(t, "GONKEY_ALLURE_MAX_BODY_SIZE"))
+		if params.BodyFormatters != nil {
+			allureOutput.SetBodyFormatters(params.BodyFormatters)
+		}
 		defer allureOutput.Finalize()
 		runner.AddOutput(allureOutput)
 	}
 
+	if os.Getenv("GONKEY_JSON_REPORT") != "" {
+		jsonOutput := json_report.NewOutput(os.Getenv("GONKEY_JSON_REPORT"))
+		defer func() {
+			if err := jsonOutput.Finalize(); err != nil {
+				t.Error(err)
+			}
+		}()
+		runner.AddOutput(jsonOutput)
+	}
+
+	runner.AddReporters(params.Reporters...)
 	addCheckers(runner, params)
 
 	err := runner.Run()
+	if report := output.FormatRepeatedTests(runner.RepeatedTests()); report != "" {
+		t.Log(report)
+	}
+	if params.SlowestTests > 0 {
+		if report := output.FormatSlowestTests(runner.SlowestTests(params.SlowestTests)); report != "" {
+			t.Log(report)
+		}
+	}
 	if err != nil {
 		t.Fatal(err)
 	}
 }
 
+// readDB returns the connection the DB checks read from
+func readDB(params *RunWithTestingParams) *sql.DB {
+	if params.ReadDB != nil {
+		return params.ReadDB
+	}
+	return params.DB
+}
+
+func fixturesLocks(params *RunWithTestingParams) *fixtures.TableLocks {
+	if !params.SerializeFixtures {
+		return nil
+	}
+	return fixtures.TableLocksFor(params.DB)
+}
+
+func maxBodySizeFromEnv(t *testing.T, name string) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return 0
+	}
+
+	size, err := strconv.Atoi(value)
+	if err != nil || size < 0 {
+		t.Fatalf("%s should be a non-negative number of bytes, got %q", name, value)
+	}
+	return size
+}
+
 func initRunner(t *testing.T, params *RunWithTestingParams, mocksLoader *mocks.Loader, fixturesLoader fixtures.Loader, proxyURL *url.URL) *Runner {
 	yamlLoader := yaml_file.NewLoader(params.TestsDir)
 	yamlLoader.SetFileFilter(os.Getenv("GONKEY_FILE_FILTER"))
+	yamlLoader.SetBaseDir(params.BaseDir)
+	if params.CacheDir != "" {
+		yamlLoader.SetCacheDir(params.CacheDir)
+	} else {
+		yamlLoader.SetCacheDir(os.Getenv(yaml_file.CacheDirEnv))
+	}
+	if params.FS != nil {
+		yamlLoader.SetFS(params.FS)
+	}
+
+	if value := os.Getenv("GONKEY_SAMPLE"); value != "" {
+		rate, err := yaml_file.ParseSampleRate(value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var seed int64
+		if value := os.Getenv("GONKEY_SAMPLE_SEED"); value != "" {
+			seed, err = strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				t.Fatalf("GONKEY_SAMPLE_SEED should be an integer, got %q", value)
+			}
+		}
+		yamlLoader.SetSample(rate, seed)
+	}
+
+	if env := os.Getenv("GONKEY_ENV"); env != "" {
+		dir := params.EnvOverridesDir
+		if dir == "" {
+			dir = defaultEnvOverridesDir
+		}
+		overrides, err := yaml_file.LoadOverrides(yaml_file.OverridesFile(dir, env))
+		if err != nil {
+			t.Fatal(err)
+		}
+		yamlLoader.SetOverrides(overrides)
+	}
+	if params.ProfilesFile != "" {
+		profiles, err := yaml_file.LoadProfiles(params.ProfilesFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		yamlLoader.SetProfiles(profiles)
+	}
+
+	hosts := make(map[string]string, len(params.Servers))
+	for name, srv := range params.Servers {
+		hosts[name] = srv.URL
+	}
+
+	var validator *openapi.Validator
+	if params.OpenAPISpec != "" {
+		var err error
+		validator, err = openapi.NewValidator(params.OpenAPISpec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		validator.SetValidateResponses(params.ValidateOpenAPIResponses)
+	}
+
+	redaction, err := output.NewRedaction(params.Redact)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	handler := testingHandler{t}
 	runner := New(
 		&Config{
-			Host:           params.Server.URL,
-			Mocks:          params.Mocks,
-			MocksLoader:    mocksLoader,
-			FixturesLoader: fixturesLoader,
-			Variables:      variables.New(),
-			HttpProxyURL:   proxyURL,
+			Host:                params.Server.URL,
+			Hosts:               hosts,
+			SummaryGate:         params.SummaryGate,
+			Headers:             params.Headers,
+			HeaderPolicies:      params.HeaderPolicies,
+			OpenAPI:             validator,
+			Mocks:               params.Mocks,
+			MocksLoader:         mocksLoader,
+			FixturesLoader:      fixturesLoader,
+			Variables:           variables.New(),
+			HttpProxyURL:        proxyURL,
+			HTTP2:               params.HTTP2,
+			DisableKeepAlives:   params.DisableKeepAlives,
+			MaxIdleConns:        params.MaxIdleConns,
+			MaxIdleConnsPerHost: params.MaxIdleConnsPerHost,
+			Redaction:           redaction,
+			FixturesLocks:       fixturesLocks(params),
+			ServerLogs:          params.ServerLogs,
+			ServerLogsMaxSize:   params.ServerLogsMaxSize,
+			RateLimit:           params.RateLimit,
+			QueryCounter:        params.QueryCounter,
+			DB:                  readDB(params),
+			GoroutineLeaks:      params.GoroutineLeaks,
+			RequestBuilders:     params.RequestBuilders,
+			Logger:              params.Logger,
+
+			RequestInterceptor:  params.RequestInterceptor,
+			ResponseInterceptor: params.ResponseInterceptor,
+			RequestSigner:       params.RequestSigner,
+			VariableProvider:    params.VariableProvider,
+			UniqueValues:        params.UniqueValues,
+			UpdateGolden:        os.Getenv("GONKEY_UPDATE_GOLDEN") != "",
 		},
 		yamlLoader,
 		handler.HandleTest,
@@ -126,11 +472,42 @@ func initRunner(t *testing.T, params *RunWithTestingParams, mocksLoader *mocks.L
 }
 
 func addCheckers(runner *Runner, params *RunWithTestingParams) {
-	runner.AddCheckers(response_body.NewChecker())
+	runner.AddCheckers(response_body.NewCheckerWithOptions(response_body.Options{
+		CustomFuncs:            params.CustomCompareFuncs,
+		ExpectedBodies:         params.ExpectedBodies,
+		ExpectedBodyTransforms: params.ExpectedBodyTransforms,
+		Variables:              runner.config.Variables,
+		UpdateGolden:           os.Getenv("GONKEY_UPDATE_GOLDEN") != "",
+		CaptureResponses:       os.Getenv(response_body.CaptureResponsesEnv) != "",
+	}))
 	runner.AddCheckers(response_header.NewChecker())
+	runner.AddCheckers(response_cache.NewChecker())
+	runner.AddCheckers(response_cookie.NewChecker())
+	runner.AddCheckers(response_status_text.NewChecker())
+	if params.MetricsURL != "" {
+		runner.AddCheckers(response_metrics.NewChecker(params.MetricsURL))
+	}
+	if params.SnapshotsDir != "" {
+		runner.AddCheckers(response_snapshot.NewChecker(response_snapshot.Options{
+			Dir:         params.SnapshotsDir,
+			Update:      os.Getenv("GONKEY_UPDATE_GOLDEN") != "",
+			IgnorePaths: params.SnapshotIgnorePaths,
+		}))
+	}
 
-	if params.DB != nil {
-		runner.AddCheckers(response_db.NewChecker(params.DB))
+	if params.DbType == fixtures.Cassandra && params.Cassandra.Session != nil {
+		runner.AddCheckers(response_db.NewSelectorChecker(
+			cassandraAdapter.New(params.Cassandra.Session, params.Cassandra.ReadConsistency),
+			response_db.Options{UpdateGolden: os.Getenv("GONKEY_UPDATE_GOLDEN") != "", Verbose: testing.Verbose()},
+		))
+	} else if db := readDB(params); db != nil {
+		runner.AddCheckers(response_db.NewCheckerWithOptions(db, response_db.Options{
+			UpdateGolden:      os.Getenv("GONKEY_UPDATE_GOLDEN") != "",
+			Dialect:           response_db.DialectOf(params.DbType),
+			Verbose:           testing.Verbose(),
+			ProtobufMessages:  params.ProtobufMessages,
+			ExplainSlowerThan: params.DbExplainSlowerThan,
+		}))
 	}
 
 	runner.AddCheckers(params.Checkers...)
@@ -144,15 +521,15 @@ func (h testingHandler) HandleTest(test models.TestInterface, executeTest testEx
 	var returnErr error
 	h.t.Run(test.GetName(), func(t *testing.T) {
 		result, err := executeTest(test)
+		if isNotRun(err) {
+			t.Skip(err)
+		}
+
 		if err != nil {
 			returnErr = err
 			t.Fatal(err)
 		}
 
-		if errors.Is(err, errTestSkipped) || errors.Is(err, errTestBroken) {
-			t.Skip()
-		}
-
 		if !result.Passed() {
 			t.Fail()
 		}
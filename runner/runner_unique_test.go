@@ -0,0 +1,27 @@
+package runner
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/lamoda/gonkey/variables"
+)
+
+func TestUniqueValuesOfSeed(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body interface{}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"path": r.URL.Path, "body": body})
+	}))
+	defer srv.Close()
+
+	RunWithTesting(t, &RunWithTestingParams{
+		Server:       srv,
+		TestsDir:     filepath.Join("testdata", "unique"),
+		UniqueValues: variables.NewUniqueValues(100),
+	})
+}
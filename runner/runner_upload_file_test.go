@@ -2,6 +2,7 @@ package runner
 
 import (
 	"encoding/json"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
@@ -63,3 +64,44 @@ func formFile(t *testing.T, r *http.Request, field string) (string, string) {
 
 	return header.Filename, string(contents)
 }
+
+type uploadedPart struct {
+	Name        string `json:"name"`
+	FileName    string `json:"fileName"`
+	ContentType string `json:"contentType"`
+	Content     string `json:"content"`
+}
+
+func TestUploadParts(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reader, err := r.MultipartReader()
+		require.NoError(t, err)
+
+		parts := []uploadedPart{}
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+
+			content, err := ioutil.ReadAll(part)
+			require.NoError(t, err)
+			parts = append(parts, uploadedPart{
+				Name:        part.FormName(),
+				FileName:    part.FileName(),
+				ContentType: part.Header.Get("Content-Type"),
+				Content:     string(content),
+			})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		require.NoError(t, json.NewEncoder(w).Encode(parts))
+	}))
+	defer srv.Close()
+
+	RunWithTesting(t, &RunWithTestingParams{
+		Server:   srv,
+		TestsDir: filepath.Join("testdata", "upload-parts"),
+	})
+}
@@ -0,0 +1,71 @@
+package runner
+
+import (
+	"fmt"
+	"io"
+	"sync"
+)
+
+const defaultServerLogsMaxSize = 64 * 1024
+
+// serverLogs continuously reads the logs of the tested service and keeps the part
+// written since the last reset, only the last maxSize bytes are kept
+type serverLogs struct {
+	sync.Mutex
+	buf     []byte
+	skipped int
+	maxSize int
+}
+
+func newServerLogs(src io.Reader, maxSize int) *serverLogs {
+	if maxSize <= 0 {
+		maxSize = defaultServerLogsMaxSize
+	}
+	l := &serverLogs{maxSize: maxSize}
+	go l.read(src)
+	return l
+}
+
+func (l *serverLogs) read(src io.Reader) {
+	chunk := make([]byte, 4096)
+	for {
+		n, err := src.Read(chunk)
+		if n > 0 {
+			l.write(chunk[:n])
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (l *serverLogs) write(p []byte) {
+	l.Lock()
+	defer l.Unlock()
+
+	l.buf = append(l.buf, p...)
+	if extra := len(l.buf) - l.maxSize; extra > 0 {
+		l.skipped += extra
+		l.buf = append(l.buf[:0], l.buf[extra:]...)
+	}
+}
+
+// reset drops the logs collected so far
+func (l *serverLogs) reset() {
+	l.Lock()
+	defer l.Unlock()
+
+	l.buf = l.buf[:0]
+	l.skipped = 0
+}
+
+// collect returns the logs written since the last reset
+func (l *serverLogs) collect() string {
+	l.Lock()
+	defer l.Unlock()
+
+	if l.skipped > 0 {
+		return fmt.Sprintf("...%d bytes skipped\n%s", l.skipped, l.buf)
+	}
+	return string(l.buf)
+}
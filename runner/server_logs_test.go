@@ -0,0 +1,36 @@
+package runner
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServerLogsCollectsSinceReset(t *testing.T) {
+	r, w := io.Pipe()
+	defer w.Close()
+
+	logs := newServerLogs(r, 0)
+
+	_, _ = io.WriteString(w, "before the test\n")
+	assert.Eventually(t, func() bool { return logs.collect() != "" }, time.Second, time.Millisecond)
+
+	logs.reset()
+	_, _ = io.WriteString(w, "during the test\n")
+
+	assert.Eventually(t, func() bool { return logs.collect() == "during the test\n" }, time.Second, time.Millisecond)
+}
+
+func TestServerLogsKeepsLatest(t *testing.T) {
+	logs := &serverLogs{maxSize: 5}
+
+	logs.write([]byte("abc"))
+	logs.write([]byte("defgh"))
+
+	assert.Equal(t, "...3 bytes skipped\ndefgh", logs.collect())
+
+	logs.reset()
+	assert.Equal(t, "", logs.collect())
+}
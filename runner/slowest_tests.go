@@ -0,0 +1,24 @@
+package runner
+
+import (
+	"sort"
+
+	"github.com/lamoda/gonkey/models"
+)
+
+// SlowestTests returns up to n executed tests which took the longest time, the slowest first,
+// the time of the fixtures isn't counted. Skipped and broken tests are not included.
+func (r *Runner) SlowestTests(n int) []models.TestDuration {
+	tests := make([]models.TestDuration, len(r.durations))
+	copy(tests, r.durations)
+	sort.SliceStable(tests, func(i, j int) bool {
+		return tests[i].Duration > tests[j].Duration
+	})
+	if n < 0 {
+		n = 0
+	}
+	if n < len(tests) {
+		tests = tests[:n]
+	}
+	return tests
+}
@@ -0,0 +1,46 @@
+package runner
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lamoda/gonkey/testloader/yaml_file"
+	"github.com/lamoda/gonkey/variables"
+)
+
+func TestSlowestTests(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ms, _ := strconv.Atoi(r.URL.Query().Get("ms"))
+		time.Sleep(time.Duration(ms) * time.Millisecond)
+	}))
+	defer srv.Close()
+
+	r := New(
+		&Config{
+			Host:      srv.URL,
+			Variables: variables.New(),
+		},
+		yaml_file.NewLoader(filepath.Join("testdata", "slowest-tests")),
+		NewConsoleHandler().HandleTest,
+	)
+	addCheckers(r, &RunWithTestingParams{})
+	require.NoError(t, r.Run())
+
+	slowest := r.SlowestTests(2)
+	require.Len(t, slowest, 2)
+	assert.Equal(t, "slowest", slowest[0].Name)
+	assert.GreaterOrEqual(t, int64(slowest[0].Duration), int64(60*time.Millisecond))
+	assert.Equal(t, "slow", slowest[1].Name)
+	assert.Zero(t, slowest[1].FixturesDuration)
+
+	// the skipped test isn't reported
+	assert.Len(t, r.SlowestTests(10), 3)
+	assert.Empty(t, r.SlowestTests(0))
+}
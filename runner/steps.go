@@ -0,0 +1,94 @@
+package runner
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lamoda/gonkey/fixtures"
+	"github.com/lamoda/gonkey/models"
+)
+
+// runSteps runs the steps of the scenario in order, each step is a test of its own for the handler
+// and the outputs. The fixtures of the scenario are loaded once before the first step, the variables
+// set by the steps are kept until the end of the scenario and the steps after a failed one are skipped.
+// The variables are applied to each step, including its mocks, when the step starts.
+func (r *Runner) runSteps(scenario models.TestInterface, stats *summaryStats) error {
+	// the skipped and broken scenarios are reported once
+	if scenario.GetStatus() != "" {
+		return r.runRepeated(scenario, stats)
+	}
+
+	restore := r.config.Variables.Scope()
+	defer restore()
+	r.config.Variables.Load(scenario.GetCombinedVariables())
+
+	cleanFixtures, err := r.loadScenarioFixtures(scenario)
+	if err != nil {
+		return fmt.Errorf("test %s error: %s", scenario.GetName(), err)
+	}
+
+	failed := 0
+	for i, step := range scenario.GetSteps() {
+		if failed != 0 {
+			step.SetStatus("skipped")
+			step.SetReason(fmt.Sprintf("step %d of the scenario failed", failed))
+		}
+
+		passed := false
+		testExecutor := func(models.TestInterface) (*models.Result, error) {
+			result, err := r.executeAndOutput(step, stats)
+			passed = err == nil && result.Passed()
+			return result, err
+		}
+		if err := r.testExecutionHandler(step, testExecutor); err != nil {
+			_ = cleanFixtures()
+			return fmt.Errorf("test %s error: %s", step.GetName(), err)
+		}
+		if !passed && failed == 0 {
+			failed = i + 1
+		}
+	}
+
+	if err := cleanFixtures(); err != nil {
+		return fmt.Errorf("test %s error: %s", scenario.GetName(), err)
+	}
+	return nil
+}
+
+// loadScenarioFixtures loads the fixtures of the scenario for all of its steps, the returned function
+// cleans them after the last step, the fixtures are only validated by the dry runs
+func (r *Runner) loadScenarioFixtures(scenario models.TestInterface) (clean func() error, err error) {
+	names := scenario.Fixtures()
+	if r.config.FixturesLoader == nil || names == nil {
+		return func() error { return nil }, nil
+	}
+
+	if r.config.DryRun {
+		if validator, ok := r.config.FixturesLoader.(fixtures.Validator); ok {
+			if err := validator.Validate(names); err != nil {
+				return nil, fmt.Errorf("unable to load fixtures [%s], error:\n%s", strings.Join(names, ", "), err)
+			}
+		}
+		return func() error { return nil }, nil
+	}
+
+	unlock, err := r.lockFixtures(scenario)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.config.FixturesLoader.Load(names); err != nil {
+		unlock()
+		return nil, fmt.Errorf("unable to load fixtures [%s], error:\n%s", strings.Join(names, ", "), err)
+	}
+	r.logger.Log("fixtures loaded", "test", scenario.GetName(), "fixtures", strings.Join(names, ","))
+
+	return func() error {
+		defer unlock()
+		if cleaner, ok := r.config.FixturesLoader.(fixtures.Cleaner); ok {
+			if err := cleaner.Clean(names); err != nil {
+				return fmt.Errorf("unable to clean fixtures [%s], error:\n%s", strings.Join(names, ", "), err)
+			}
+		}
+		return nil
+	}, nil
+}
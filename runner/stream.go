@@ -0,0 +1,36 @@
+package runner
+
+import (
+	"bytes"
+	"io"
+	"time"
+)
+
+// readStream reads the streaming response body as it arrives until the server closes the stream
+// or the timeout expires. The data received before the timeout is returned without an error.
+func readStream(body io.ReadCloser, timeout time.Duration) ([]byte, error) {
+	var buf bytes.Buffer
+	done := make(chan error, 1)
+	go func() {
+		_, err := io.Copy(&buf, body)
+		done <- err
+	}()
+
+	if timeout <= 0 {
+		err := <-done
+		return buf.Bytes(), err
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case err := <-done:
+		return buf.Bytes(), err
+	case <-timer.C:
+		// closing the body interrupts the pending read
+		_ = body.Close()
+		<-done
+		return buf.Bytes(), nil
+	}
+}
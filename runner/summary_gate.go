@@ -0,0 +1,89 @@
+package runner
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lamoda/gonkey/models"
+)
+
+// SummaryGate fails the run when the results of the tests as a whole are not good enough,
+// it is evaluated after all tests are executed
+type SummaryGate struct {
+	// MaxFailures is the max number of failed tests, 0 means no limit
+	MaxFailures int
+	// MaxFailureRate is the max share of failed tests among the executed ones (e.g. 0.05 for 5%),
+	// 0 means no limit
+	MaxFailureRate float64
+	// RequireTags are the tags of the tests which must pass, e.g. "critical"
+	RequireTags []string
+}
+
+// SummaryGateError is returned by Runner.Run when the results don't satisfy the summary gate
+type SummaryGateError struct {
+	Violations []string
+}
+
+func (e *SummaryGateError) Error() string {
+	return "summary gate failed: " + strings.Join(e.Violations, "; ")
+}
+
+// summaryStats holds the results of the executed tests, skipped and broken tests are not counted
+type summaryStats struct {
+	total  int
+	failed int
+	// failedRequired are the names of the failed tests with required tags
+	failedRequired []string
+}
+
+func (s *summaryStats) add(gate *SummaryGate, test models.TestInterface, result *models.Result) {
+	s.total++
+	if result.Passed() {
+		return
+	}
+	s.failed++
+	if hasAnyTag(test.GetTags(), gate.RequireTags) {
+		s.failedRequired = append(s.failedRequired, test.GetName())
+	}
+}
+
+func (g *SummaryGate) check(stats *summaryStats) error {
+	var violations []string
+
+	if g.MaxFailures > 0 && stats.failed > g.MaxFailures {
+		violations = append(violations, fmt.Sprintf(
+			"%d tests failed, at most %d allowed", stats.failed, g.MaxFailures))
+	}
+
+	if g.MaxFailureRate > 0 && stats.total > 0 {
+		rate := float64(stats.failed) / float64(stats.total)
+		if rate > g.MaxFailureRate {
+			violations = append(violations, fmt.Sprintf(
+				"%.2f%% of tests failed, at most %.2f%% allowed", rate*100, g.MaxFailureRate*100))
+		}
+	}
+
+	if len(stats.failedRequired) != 0 {
+		violations = append(violations, fmt.Sprintf(
+			"tests tagged with %s failed: %s",
+			strings.Join(g.RequireTags, ", "),
+			strings.Join(stats.failedRequired, ", "),
+		))
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+	return &SummaryGateError{Violations: violations}
+}
+
+func hasAnyTag(tags, required []string) bool {
+	for _, tag := range tags {
+		for _, r := range required {
+			if tag == r {
+				return true
+			}
+		}
+	}
+	return false
+}
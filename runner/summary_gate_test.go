@@ -0,0 +1,69 @@
+package runner
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lamoda/gonkey/testloader/yaml_file"
+	"github.com/lamoda/gonkey/variables"
+)
+
+func TestSummaryGate(t *testing.T) {
+	tests := []struct {
+		name       string
+		gate       *SummaryGate
+		violations []string
+	}{
+		{
+			name: "without gate",
+		},
+		{
+			name: "failures within limit",
+			gate: &SummaryGate{MaxFailures: 1, MaxFailureRate: 0.3, RequireTags: []string{"smoke"}},
+		},
+		{
+			name:       "too many failures",
+			gate:       &SummaryGate{MaxFailures: 1, MaxFailureRate: 0.2},
+			violations: []string{"25.00% of tests failed, at most 20.00% allowed"},
+		},
+		{
+			name:       "required test failed",
+			gate:       &SummaryGate{RequireTags: []string{"critical"}},
+			violations: []string{"tests tagged with critical failed: orders service pretends to be inventory"},
+		},
+	}
+
+	srv := testServiceServer("orders")
+	defer srv.Close()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewConsoleHandler()
+			r := New(
+				&Config{
+					Host:        srv.URL,
+					Variables:   variables.New(),
+					SummaryGate: tt.gate,
+				},
+				yaml_file.NewLoader(filepath.Join("testdata", "summary-gate")),
+				handler.HandleTest,
+			)
+			addCheckers(r, &RunWithTestingParams{})
+
+			err := r.Run()
+			assert.Equal(t, 1, handler.Summary().Failed)
+			if tt.violations == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			var gateErr *SummaryGateError
+			require.True(t, errors.As(err, &gateErr))
+			assert.Equal(t, tt.violations, gateErr.Violations)
+		})
+	}
+}
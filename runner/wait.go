@@ -0,0 +1,67 @@
+package runner
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net"
+	"time"
+)
+
+const (
+	defaultWaitTimeout = 30 * time.Second
+
+	waitMinDelay = 100 * time.Millisecond
+	waitMaxDelay = 2 * time.Second
+)
+
+// WaitForDependencies pings the database and dials the TCP addresses (host:port) until all of them respond,
+// the attempts are repeated with a growing delay until the timeout expires (30 seconds if it's zero).
+// The database may be nil.
+func WaitForDependencies(db *sql.DB, addrs []string, timeout time.Duration) error {
+	if timeout == 0 {
+		timeout = defaultWaitTimeout
+	}
+	deadline := time.Now().Add(timeout)
+	delay := waitMinDelay
+	for {
+		err := pingDependencies(db, addrs, deadline)
+		if err == nil {
+			return nil
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return fmt.Errorf("dependencies are not ready after %s: %s", timeout, err)
+		}
+		if delay > remaining {
+			delay = remaining
+		}
+		time.Sleep(delay)
+
+		delay *= 2
+		if delay > waitMaxDelay {
+			delay = waitMaxDelay
+		}
+	}
+}
+
+func pingDependencies(db *sql.DB, addrs []string, deadline time.Time) error {
+	if db != nil {
+		ctx, cancel := context.WithDeadline(context.Background(), deadline)
+		err := db.PingContext(ctx)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("database: %s", err)
+		}
+	}
+
+	for _, addr := range addrs {
+		conn, err := net.DialTimeout("tcp", addr, time.Until(deadline))
+		if err != nil {
+			return fmt.Errorf("address %s: %s", addr, err)
+		}
+		_ = conn.Close()
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package runner
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestWaitForDependencies(t *testing.T) {
+	// the address is free until the dependency comes up
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	require.NoError(t, ln.Close())
+
+	started := make(chan net.Listener, 1)
+	go func() {
+		time.Sleep(300 * time.Millisecond)
+		ln, err := net.Listen("tcp", addr)
+		if err != nil {
+			close(started)
+			return
+		}
+		started <- ln
+	}()
+
+	err = WaitForDependencies(nil, []string{addr}, 5*time.Second)
+	if ln, ok := <-started; ok {
+		defer ln.Close()
+	}
+	assert.NoError(t, err)
+}
+
+func TestWaitForDependenciesTimeout(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	require.NoError(t, ln.Close())
+
+	start := time.Now()
+	err = WaitForDependencies(nil, []string{addr}, 300*time.Millisecond)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "dependencies are not ready after 300ms: address "+addr)
+	assert.WithinDuration(t, start.Add(300*time.Millisecond), time.Now(), 200*time.Millisecond)
+}
+
+func TestWaitForDependenciesDatabase(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	assert.NoError(t, WaitForDependencies(db, nil, time.Second))
+
+	mock.ExpectClose()
+	require.NoError(t, db.Close())
+	err = WaitForDependencies(db, nil, 200*time.Millisecond)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "database: sql: database is closed")
+}
@@ -0,0 +1,129 @@
+package signing
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// oauth2ExpiryDelta is how long before the expiration the token is requested again,
+// so that the token doesn't expire while the request is on its way
+const oauth2ExpiryDelta = 10 * time.Second
+
+// OAuth2Config is the client of the OAuth2 client credentials grant
+type OAuth2Config struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+}
+
+// OAuth2ClientCredentials authorizes the requests with the bearer token obtained by the OAuth2
+// client credentials grant. The token is shared by all the requests, including the ones
+// of the tests running in parallel, and is requested again when it expires.
+type OAuth2ClientCredentials struct {
+	config OAuth2Config
+	client *http.Client
+	now    func() time.Time
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+type oauth2Token struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// NewOAuth2ClientCredentials returns the signer requesting the tokens with the client,
+// http.DefaultClient is used if the client is nil
+func NewOAuth2ClientCredentials(config OAuth2Config, client *http.Client) *OAuth2ClientCredentials {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &OAuth2ClientCredentials{
+		config: config,
+		client: client,
+		now:    time.Now,
+	}
+}
+
+// Sign sets the Authorization header with the bearer token
+func (o *OAuth2ClientCredentials) Sign(req *http.Request) error {
+	token, err := o.Token()
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+// Token returns the cached token or requests a new one if there is no valid token,
+// the concurrent callers wait for the single token request
+func (o *OAuth2ClientCredentials) Token() (string, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.token != "" && (o.expiry.IsZero() || o.now().Before(o.expiry)) {
+		return o.token, nil
+	}
+
+	token, err := o.requestToken()
+	if err != nil {
+		return "", fmt.Errorf("failed to get OAuth2 token from %s: %s", o.config.TokenURL, err)
+	}
+	o.token = token.AccessToken
+	o.expiry = time.Time{}
+	if token.ExpiresIn > 0 {
+		o.expiry = o.now().Add(time.Duration(token.ExpiresIn)*time.Second - oauth2ExpiryDelta)
+	}
+	return o.token, nil
+}
+
+func (o *OAuth2ClientCredentials) requestToken() (*oauth2Token, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(o.config.Scopes) > 0 {
+		form.Set("scope", strings.Join(o.config.Scopes, " "))
+	}
+	req, err := http.NewRequest(http.MethodPost, o.config.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	// the credentials are encoded before they are put into the header as required by RFC 6749
+	req.SetBasicAuth(url.QueryEscape(o.config.ClientID), url.QueryEscape(o.config.ClientSecret))
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("server responded with status %d: %s", resp.StatusCode, body)
+	}
+
+	var token oauth2Token
+	if err := json.Unmarshal(body, &token); err != nil {
+		return nil, fmt.Errorf("invalid token response: %s", err)
+	}
+	if token.AccessToken == "" {
+		return nil, fmt.Errorf("no access_token in the token response: %s", body)
+	}
+	if token.TokenType != "" && !strings.EqualFold(token.TokenType, "bearer") {
+		return nil, fmt.Errorf("unsupported token type %s", token.TokenType)
+	}
+	return &token, nil
+}
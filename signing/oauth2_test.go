@@ -0,0 +1,103 @@
+package signing
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testTokenServer(t *testing.T, calls *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(calls, 1)
+
+		id, secret, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "client", id)
+		assert.Equal(t, "s%3Acret", secret)
+		assert.NoError(t, r.ParseForm())
+		assert.Equal(t, "client_credentials", r.PostForm.Get("grant_type"))
+		assert.Equal(t, "orders:read orders:write", r.PostForm.Get("scope"))
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token": "token-%d", "token_type": "Bearer", "expires_in": 60}`, n)
+	}))
+}
+
+func testOAuth2(url string) *OAuth2ClientCredentials {
+	return NewOAuth2ClientCredentials(OAuth2Config{
+		TokenURL:     url,
+		ClientID:     "client",
+		ClientSecret: "s:cret",
+		Scopes:       []string{"orders:read", "orders:write"},
+	}, nil)
+}
+
+func TestOAuth2ClientCredentials(t *testing.T) {
+	var calls int32
+	srv := testTokenServer(t, &calls)
+	defer srv.Close()
+
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	o := testOAuth2(srv.URL)
+	o.now = func() time.Time { return now }
+
+	req, err := http.NewRequest(http.MethodGet, "http://service.local/orders", nil)
+	require.NoError(t, err)
+
+	require.NoError(t, o.Sign(req))
+	assert.Equal(t, "Bearer token-1", req.Header.Get("Authorization"))
+
+	// the token is cached until it's about to expire
+	now = now.Add(49 * time.Second)
+	require.NoError(t, o.Sign(req))
+	assert.Equal(t, "Bearer token-1", req.Header.Get("Authorization"))
+
+	now = now.Add(time.Second)
+	require.NoError(t, o.Sign(req))
+	assert.Equal(t, "Bearer token-2", req.Header.Get("Authorization"))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestOAuth2ClientCredentialsConcurrent(t *testing.T) {
+	var calls int32
+	srv := testTokenServer(t, &calls)
+	defer srv.Close()
+
+	o := testOAuth2(srv.URL)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			token, err := o.Token()
+			assert.NoError(t, err)
+			assert.Equal(t, "token-1", token)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestOAuth2ClientCredentialsError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"error": "invalid_client"}`))
+	}))
+	defer srv.Close()
+
+	req, err := http.NewRequest(http.MethodGet, "http://service.local/orders", nil)
+	require.NoError(t, err)
+
+	err = testOAuth2(srv.URL).Sign(req)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `server responded with status 401: {"error": "invalid_client"}`)
+	assert.Empty(t, req.Header.Get("Authorization"))
+}
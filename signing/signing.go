@@ -0,0 +1,62 @@
+// Package signing contains the signers of the requests sent by the runner, e.g. for the services
+// requiring requests signed with AWS Signature Version 4 or authorized with OAuth2
+package signing
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// Signer signs the request right before it's sent, when its body and headers are final
+type Signer interface {
+	Sign(req *http.Request) error
+}
+
+// SignerFunc is the function used as a Signer
+type SignerFunc func(req *http.Request) error
+
+func (f SignerFunc) Sign(req *http.Request) error {
+	return f(req)
+}
+
+// Chain returns the signer calling the signers in the given order,
+// the first error stops the signing
+func Chain(signers ...Signer) Signer {
+	return SignerFunc(func(req *http.Request) error {
+		for _, s := range signers {
+			if err := s.Sign(req); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// requestBody returns the body of the request without consuming it
+func requestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if req.GetBody == nil {
+		// the body can't be read again, so it's replaced with the copy
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		_ = req.Body.Close()
+		req.GetBody = func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(bytes.NewReader(body)), nil
+		}
+		req.Body, _ = req.GetBody()
+		return body, nil
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return ioutil.ReadAll(body)
+}
@@ -0,0 +1,30 @@
+package signing
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChain(t *testing.T) {
+	var calls []string
+	signer := func(name string, err error) Signer {
+		return SignerFunc(func(req *http.Request) error {
+			calls = append(calls, name)
+			return err
+		})
+	}
+
+	req, err := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	require.NoError(t, err)
+
+	assert.NoError(t, Chain(signer("first", nil), signer("second", nil)).Sign(req))
+	assert.Equal(t, []string{"first", "second"}, calls)
+
+	calls = nil
+	assert.EqualError(t, Chain(signer("first", errors.New("failed")), signer("second", nil)).Sign(req), "failed")
+	assert.Equal(t, []string{"first"}, calls)
+}
@@ -0,0 +1,132 @@
+package signing
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// the requests and the signatures are from the test suite of AWS Signature Version 4
+func testSigV4() *SigV4 {
+	s := NewSigV4(AWSCredentials{
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+	}, "us-east-1", "service")
+	s.now = func() time.Time {
+		return time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+	}
+	return s
+}
+
+func TestSigV4(t *testing.T) {
+	tests := []struct {
+		name      string
+		method    string
+		url       string
+		body      string
+		headers   map[string]string
+		signed    string
+		signature string
+	}{
+		{
+			name:      "get-vanilla",
+			method:    http.MethodGet,
+			url:       "https://example.amazonaws.com/",
+			signed:    "host;x-amz-date",
+			signature: "5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		},
+		{
+			name:      "get-vanilla-query-order-key-case",
+			method:    http.MethodGet,
+			url:       "https://example.amazonaws.com/?Param2=value2&Param1=value1",
+			signed:    "host;x-amz-date",
+			signature: "b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500",
+		},
+		{
+			name:      "get-vanilla-query-order-value",
+			method:    http.MethodGet,
+			url:       "https://example.amazonaws.com/?Param1=value2&Param1=value1",
+			signed:    "host;x-amz-date",
+			signature: "5772eed61e12b33fae39ee5e7012498b51d56abc0abb7c60486157bd471c4694",
+		},
+		{
+			name:      "get-vanilla-query-order-key",
+			method:    http.MethodGet,
+			url:       "https://example.amazonaws.com/?Param1=value2&Param1=Value1",
+			signed:    "host;x-amz-date",
+			signature: "eedbc4e291e521cf13422ffca22be7d2eb8146eecf653089df300a15b2382bd1",
+		},
+		{
+			name:      "post-vanilla",
+			method:    http.MethodPost,
+			url:       "https://example.amazonaws.com/",
+			signed:    "host;x-amz-date",
+			signature: "5da7c1a2acd57cee7505fc6676e4e544621c30862966e37dddb68e92efbe5d6b",
+		},
+		{
+			name:      "post-x-www-form-urlencoded",
+			method:    http.MethodPost,
+			url:       "https://example.amazonaws.com/",
+			body:      "Param1=value1",
+			headers:   map[string]string{"Content-Type": "application/x-www-form-urlencoded"},
+			signed:    "content-type;host;x-amz-date",
+			signature: "ff11897932ad3f4e8b18135d722051e5ac45fc38421b1da7b9d196a0fe09473a",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+			require.NoError(t, err)
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+
+			require.NoError(t, testSigV4().Sign(req))
+			assert.Equal(t, "20150830T123600Z", req.Header.Get("X-Amz-Date"))
+			assert.Equal(t,
+				"AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, "+
+					"SignedHeaders="+tt.signed+", Signature="+tt.signature,
+				req.Header.Get("Authorization"),
+			)
+		})
+	}
+}
+
+func TestSigV4CanonicalQuery(t *testing.T) {
+	// the parameters are sorted by the names and then by the values as in get-vanilla-query-order-key,
+	// so the names being the prefixes of the others go first even though "-" sorts before "="
+	req, err := http.NewRequest(http.MethodGet,
+		"https://example.amazonaws.com/?page-size=10&page=2&a-b=2&a=1&Param1=value2&Param1=Value1", nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, "Param1=Value1&Param1=value2&a=1&a-b=2&page=2&page-size=10", canonicalQuery(req))
+}
+
+func TestSigV4WithSessionToken(t *testing.T) {
+	s := testSigV4()
+	s.credentials.SessionToken = "token"
+
+	req, err := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	require.NoError(t, err)
+	require.NoError(t, s.Sign(req))
+
+	assert.Equal(t, "token", req.Header.Get("X-Amz-Security-Token"))
+	assert.Contains(t, req.Header.Get("Authorization"), "SignedHeaders=host;x-amz-date;x-amz-security-token,")
+}
+
+func TestSigV4KeepsBody(t *testing.T) {
+	req, err := http.NewRequest(http.MethodPost, "https://example.amazonaws.com/", strings.NewReader("Param1=value1"))
+	require.NoError(t, err)
+	// the body can't be read again without GetBody
+	req.GetBody = nil
+
+	require.NoError(t, testSigV4().Sign(req))
+	body, err := ioutil.ReadAll(req.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "Param1=value1", string(body))
+}
@@ -0,0 +1,32 @@
+package cassandra
+
+import (
+	"github.com/gocql/gocql"
+)
+
+type Client struct {
+	*gocql.Session
+	readConsistency gocql.Consistency
+}
+
+// New wraps the session, the rows checked by the tests are read with readConsistency
+// to see the writes of the service in spite of the eventual consistency, gocql.Quorum by default
+func New(session *gocql.Session, readConsistency gocql.Consistency) *Client {
+	if readConsistency == gocql.Any {
+		// ANY is valid for the writes only
+		readConsistency = gocql.Quorum
+	}
+
+	return &Client{
+		Session:         session,
+		readConsistency: readConsistency,
+	}
+}
+
+func (c *Client) Exec(stmt string, values ...interface{}) error {
+	return c.Query(stmt, values...).Exec()
+}
+
+func (c *Client) Select(stmt string) ([]map[string]interface{}, error) {
+	return c.Query(stmt).Consistency(c.readConsistency).Iter().SliceMap()
+}
@@ -0,0 +1,294 @@
+package har
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Options defines how HAR entries are converted
+type Options struct {
+	// Hosts of the service under test, requests to these hosts become tests.
+	// If empty, requests to all hosts which are not listed in MockHosts become tests.
+	Hosts []string
+	// MockHosts maps hosts of third-party services to the names of gonkey mocks,
+	// requests to these hosts become mocks of the test which precedes them
+	MockHosts map[string]string
+	// RedactHeaders lists headers (case-insensitive) which values are replaced with variables,
+	// e.g. Authorization becomes {{ $Authorization }}. Cookie replaces the values of all the cookies
+	// of the requests, e.g. the cookie sid becomes {{ $Cookie_sid }}, the name of a cookie replaces only its value
+	RedactHeaders []string
+}
+
+// skippedHeaders are set by the HTTP client and shouldn't be part of the test
+var skippedHeaders = map[string]bool{
+	"host":              true,
+	"content-length":    true,
+	"cookie":            true,
+	"connection":        true,
+	"accept-encoding":   true,
+	"transfer-encoding": true,
+}
+
+var notWordRx = regexp.MustCompile(`\W`)
+
+type converter struct {
+	opts Options
+}
+
+type mockCall struct {
+	path  string
+	entry entry
+}
+
+// ConvertFile reads the HAR file and returns gonkey tests in YAML format
+func ConvertFile(path string, opts Options) ([]byte, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %s:\n%s", path, err)
+	}
+	return Convert(data, opts)
+}
+
+// Convert converts HAR data to gonkey tests in YAML format
+func Convert(data []byte, opts Options) ([]byte, error) {
+	var f harFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse HAR: %s", err)
+	}
+
+	c := &converter{opts: opts}
+	tests, err := c.convert(sortEntries(f.Log.Entries))
+	if err != nil {
+		return nil, err
+	}
+	if len(tests) == 0 {
+		return nil, fmt.Errorf("no requests to convert found in HAR")
+	}
+
+	return yaml.Marshal(tests)
+}
+
+func (c *converter) convert(entries []entry) ([]yaml.MapSlice, error) {
+	var tests []yaml.MapSlice
+	var current *entry
+	var calls map[string][]mockCall
+
+	flush := func() {
+		if current != nil {
+			tests = append(tests, c.makeTest(*current, calls))
+		}
+	}
+
+	for i := range entries {
+		e := entries[i]
+		u, err := url.Parse(e.Request.URL)
+		if err != nil {
+			return nil, fmt.Errorf("entry %d: invalid url %s: %s", i, e.Request.URL, err)
+		}
+
+		if mockName, ok := c.mockName(u); ok {
+			// calls made before the first test have no test to be attached to
+			if current != nil {
+				calls[mockName] = append(calls[mockName], mockCall{path: u.Path, entry: e})
+			}
+			continue
+		}
+		if !c.isTested(u) {
+			continue
+		}
+
+		flush()
+		current = &e
+		calls = make(map[string][]mockCall)
+	}
+	flush()
+
+	return tests, nil
+}
+
+func (c *converter) mockName(u *url.URL) (string, bool) {
+	if name, ok := c.opts.MockHosts[u.Host]; ok {
+		return name, true
+	}
+	name, ok := c.opts.MockHosts[u.Hostname()]
+	return name, ok
+}
+
+func (c *converter) isTested(u *url.URL) bool {
+	if len(c.opts.Hosts) == 0 {
+		return true
+	}
+	for _, host := range c.opts.Hosts {
+		if host == u.Host || host == u.Hostname() {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *converter) makeTest(e entry, calls map[string][]mockCall) yaml.MapSlice {
+	u, _ := url.Parse(e.Request.URL)
+
+	test := yaml.MapSlice{
+		{Key: "name", Value: fmt.Sprintf("%s %s", e.Request.Method, u.Path)},
+		{Key: "method", Value: e.Request.Method},
+		{Key: "path", Value: u.Path},
+	}
+	if u.RawQuery != "" {
+		test = append(test, yaml.MapItem{Key: "query", Value: "?" + u.RawQuery})
+	}
+	if headers := c.makeHeaders(e.Request.Headers); len(headers) != 0 {
+		test = append(test, yaml.MapItem{Key: "headers", Value: headers})
+	}
+	if len(e.Request.Cookies) != 0 {
+		test = append(test, yaml.MapItem{Key: "cookies", Value: c.makeCookies(e.Request.Cookies)})
+	}
+	if e.Request.PostData != nil && e.Request.PostData.Text != "" {
+		test = append(test, yaml.MapItem{Key: "request", Value: e.Request.PostData.Text})
+	}
+	if len(calls) != 0 {
+		test = append(test, yaml.MapItem{Key: "mocks", Value: makeMocks(calls)})
+	}
+
+	test = append(test, yaml.MapItem{
+		Key:   "response",
+		Value: yaml.MapSlice{{Key: e.Response.Status, Value: responseBody(e.Response)}},
+	})
+
+	return test
+}
+
+func (c *converter) makeHeaders(headers []header) yaml.MapSlice {
+	res := yaml.MapSlice{}
+	for _, h := range headers {
+		// HTTP/2 pseudo-headers like :authority
+		if strings.HasPrefix(h.Name, ":") || skippedHeaders[strings.ToLower(h.Name)] {
+			continue
+		}
+		value := h.Value
+		if c.isRedacted(h.Name) {
+			value = fmt.Sprintf("{{ $%s }}", notWordRx.ReplaceAllString(h.Name, "_"))
+		}
+		res = append(res, yaml.MapItem{Key: h.Name, Value: value})
+	}
+	return res
+}
+
+func (c *converter) makeCookies(cookies []cookie) yaml.MapSlice {
+	res := yaml.MapSlice{}
+	redactAll := c.isRedacted("Cookie")
+	for _, ck := range cookies {
+		value := ck.Value
+		if redactAll || c.isRedacted(ck.Name) {
+			value = fmt.Sprintf("{{ $Cookie_%s }}", notWordRx.ReplaceAllString(ck.Name, "_"))
+		}
+		res = append(res, yaml.MapItem{Key: ck.Name, Value: value})
+	}
+	return res
+}
+
+func (c *converter) isRedacted(name string) bool {
+	for _, redacted := range c.opts.RedactHeaders {
+		if strings.EqualFold(redacted, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func makeMocks(calls map[string][]mockCall) yaml.MapSlice {
+	mocks := yaml.MapSlice{}
+	for _, mockName := range sortedKeys(calls) {
+		byPath := make(map[string][]entry)
+		var paths []string
+		for _, call := range calls[mockName] {
+			if _, ok := byPath[call.path]; !ok {
+				paths = append(paths, call.path)
+			}
+			byPath[call.path] = append(byPath[call.path], call.entry)
+		}
+
+		uris := yaml.MapSlice{}
+		for _, path := range paths {
+			uris = append(uris, yaml.MapItem{Key: path, Value: makeMockDefinition(byPath[path])})
+		}
+
+		mocks = append(mocks, yaml.MapItem{Key: mockName, Value: yaml.MapSlice{
+			{Key: "strategy", Value: "uriVary"},
+			{Key: "uris", Value: uris},
+		}})
+	}
+	return mocks
+}
+
+// makeMockDefinition returns constant reply for a single call and sequence of replies for many calls
+func makeMockDefinition(entries []entry) yaml.MapSlice {
+	if len(entries) == 1 {
+		return makeConstantReply(entries[0].Response)
+	}
+
+	sequence := make([]yaml.MapSlice, 0, len(entries))
+	for _, e := range entries {
+		sequence = append(sequence, makeConstantReply(e.Response))
+	}
+	return yaml.MapSlice{
+		{Key: "strategy", Value: "sequence"},
+		{Key: "sequence", Value: sequence},
+	}
+}
+
+func makeConstantReply(resp response) yaml.MapSlice {
+	reply := yaml.MapSlice{
+		{Key: "strategy", Value: "constant"},
+		{Key: "body", Value: responseBody(resp)},
+		{Key: "statusCode", Value: resp.Status},
+	}
+	if resp.Content.MimeType != "" {
+		reply = append(reply, yaml.MapItem{
+			Key:   "headers",
+			Value: yaml.MapSlice{{Key: "Content-Type", Value: resp.Content.MimeType}},
+		})
+	}
+	return reply
+}
+
+func responseBody(resp response) string {
+	if resp.Content.Encoding == "base64" {
+		if decoded, err := base64.StdEncoding.DecodeString(resp.Content.Text); err == nil {
+			return string(decoded)
+		}
+	}
+	return resp.Content.Text
+}
+
+func sortEntries(entries []entry) []entry {
+	sorted := make([]entry, len(entries))
+	copy(sorted, entries)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		ti, erri := time.Parse(time.RFC3339Nano, sorted[i].StartedDateTime)
+		tj, errj := time.Parse(time.RFC3339Nano, sorted[j].StartedDateTime)
+		if erri != nil || errj != nil {
+			return false
+		}
+		return ti.Before(tj)
+	})
+	return sorted
+}
+
+func sortedKeys(m map[string][]mockCall) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
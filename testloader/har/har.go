@@ -0,0 +1,53 @@
+package har
+
+// HAR format structures, only the fields needed for conversion are described
+// (see http://www.softwareishard.com/blog/har-12-spec/)
+
+type harFile struct {
+	Log harLog `json:"log"`
+}
+
+type harLog struct {
+	Entries []entry `json:"entries"`
+}
+
+type entry struct {
+	StartedDateTime string   `json:"startedDateTime"`
+	Request         request  `json:"request"`
+	Response        response `json:"response"`
+}
+
+type request struct {
+	Method   string    `json:"method"`
+	URL      string    `json:"url"`
+	Headers  []header  `json:"headers"`
+	Cookies  []cookie  `json:"cookies"`
+	PostData *postData `json:"postData"`
+}
+
+type response struct {
+	Status  int      `json:"status"`
+	Headers []header `json:"headers"`
+	Content content  `json:"content"`
+}
+
+type header struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type cookie struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type postData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type content struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Encoding string `json:"encoding"`
+}
@@ -0,0 +1,93 @@
+package har
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+
+	"github.com/lamoda/gonkey/testloader/yaml_file"
+)
+
+func TestConvertFile(t *testing.T) {
+	data, err := ConvertFile("testdata/example.har", Options{
+		Hosts:         []string{"service.local"},
+		MockHosts:     map[string]string{"catalog.local": "catalog"},
+		RedactHeaders: []string{"authorization"},
+	})
+	require.NoError(t, err)
+
+	var tests []yaml_file.TestDefinition
+	require.NoError(t, yaml.Unmarshal(data, &tests))
+	require.Len(t, tests, 2)
+
+	assert.Equal(t, "POST", tests[0].Method)
+	assert.Equal(t, "/orders", tests[0].RequestURL)
+	assert.Equal(t, "?source=web", tests[0].QueryParams)
+	assert.Equal(t, map[string]string{
+		"Content-Type":  "application/json",
+		"Authorization": "{{ $Authorization }}",
+	}, tests[0].HeadersVal)
+	assert.Equal(t, map[string]string{"sid": "abc"}, tests[0].CookiesVal)
+	assert.Equal(t, `{"item": 1}`, tests[0].RequestTmpl)
+	assert.Equal(t, map[int]string{200: `{"id": 42}`}, tests[0].ResponseTmpls)
+
+	catalog, ok := tests[0].MocksDefinition["catalog"].(map[interface{}]interface{})
+	require.True(t, ok)
+	assert.Equal(t, "uriVary", catalog["strategy"])
+	uris := catalog["uris"].(map[interface{}]interface{})
+	assert.Equal(t, map[interface{}]interface{}{
+		"strategy":   "constant",
+		"body":       `{"id": 1}`,
+		"statusCode": 200,
+		"headers":    map[interface{}]interface{}{"Content-Type": "application/json"},
+	}, uris["/items/1"])
+
+	assert.Equal(t, "GET", tests[1].Method)
+	assert.Equal(t, "/orders/42", tests[1].RequestURL)
+	assert.Nil(t, tests[1].MocksDefinition)
+	assert.Equal(t, map[int]string{200: "ok"}, tests[1].ResponseTmpls)
+}
+
+func TestConvertRedactsCookies(t *testing.T) {
+	tests := []struct {
+		name    string
+		redact  []string
+		cookies map[string]string
+	}{
+		{
+			name:    "not redacted",
+			cookies: map[string]string{"session_id": "s3cr3t-session", "theme": "dark"},
+		},
+		{
+			name:    "all cookies",
+			redact:  []string{"cookie"},
+			cookies: map[string]string{"session_id": "{{ $Cookie_session_id }}", "theme": "{{ $Cookie_theme }}"},
+		},
+		{
+			name:    "cookie by name",
+			redact:  []string{"Session_ID"},
+			cookies: map[string]string{"session_id": "{{ $Cookie_session_id }}", "theme": "dark"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := ConvertFile("testdata/session.har", Options{RedactHeaders: tt.redact})
+			require.NoError(t, err)
+
+			var definitions []yaml_file.TestDefinition
+			require.NoError(t, yaml.Unmarshal(data, &definitions))
+			require.Len(t, definitions, 1)
+			assert.Equal(t, tt.cookies, definitions[0].CookiesVal)
+			if tt.redact != nil {
+				assert.NotContains(t, string(data), "s3cr3t-session")
+			}
+		})
+	}
+}
+
+func TestConvertNothingToConvert(t *testing.T) {
+	_, err := Convert([]byte(`{"log": {"entries": []}}`), Options{})
+	assert.Error(t, err)
+}
@@ -7,3 +7,10 @@ import (
 type LoaderInterface interface {
 	Load() ([]models.TestInterface, error)
 }
+
+// FilteringLoaderInterface is implemented by the loaders which leave some of the tests out, e.g. by a filter
+// or a sample. IsFilteredOut tells if the test named so (or the test whose case is named so) exists but isn't
+// loaded, the runner skips the tests depending on such tests instead of failing on the unknown ones.
+type FilteringLoaderInterface interface {
+	IsFilteredOut(name string) bool
+}
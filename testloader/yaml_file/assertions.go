@@ -0,0 +1,27 @@
+package yaml_file
+
+import (
+	"fmt"
+
+	"github.com/lamoda/gonkey/models"
+)
+
+// assertionsStatus validates the assertions blocks and returns the expected status of the response:
+// responseStatus if it's set, otherwise the status has to match one of the blocks
+func assertionsStatus(testDefinition TestDefinition) (models.ResponseStatus, error) {
+	if len(testDefinition.Assertions) == 0 {
+		return testDefinition.ResponseStatus, nil
+	}
+
+	var status models.ResponseStatus
+	for i, block := range testDefinition.Assertions {
+		if len(block.When) == 0 {
+			return nil, fmt.Errorf("assertions block #%d has no when", i+1)
+		}
+		status = append(status, block.When...)
+	}
+	if testDefinition.ResponseStatus != nil {
+		return testDefinition.ResponseStatus, nil
+	}
+	return status, nil
+}
@@ -0,0 +1,238 @@
+package yaml_file
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/lamoda/gonkey/files"
+)
+
+// CacheDirEnv is the environment variable with the directory of the cache of the parsed test files
+const CacheDirEnv = "GONKEY_CACHE_DIR"
+
+// definitionFingerprint changes with the fields of the definitions, so that the entries written
+// by another version of gonkey are not used
+var definitionFingerprint = typeFingerprint(reflect.TypeOf(TestDefinition{}))
+
+// cache keeps the parsed definitions of the test files in the directory. The entry of the file is used
+// while neither the file nor the request files and the includes read while parsing it change.
+type cache struct {
+	dir string
+}
+
+type cacheEntry struct {
+	// Exact is false if the definitions don't survive the encoding unchanged,
+	// e.g. they hold the values of unknown types, such files are parsed every time
+	Exact       bool
+	Deps        []cacheDep
+	Probes      []cacheProbe
+	Definitions interface{}
+}
+
+// cacheDep is a file read while parsing the test file with the hash of its content
+type cacheDep struct {
+	Path string
+	Hash string
+}
+
+// cacheProbe is a file looked up while parsing the test file, e.g. to resolve the relative paths
+type cacheProbe struct {
+	Path   string
+	Exists bool
+}
+
+func (c *cache) parse(fsys files.FS, absPath, baseDir string, defaults *dirDefaults) ([]Test, error) {
+	data, err := fsys.ReadFile(absPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %s:\n%s", absPath, err)
+	}
+
+	path := filepath.Join(c.dir, cacheKey(absPath, baseDir, data, defaults.key())+".json")
+	entry, ok := readCacheEntry(fsys, path)
+	if ok && entry.Exact {
+		var definitions []TestDefinition
+		if err := decodeCacheValue(entry.Definitions, reflect.ValueOf(&definitions).Elem()); err == nil {
+			return makeTests(absPath, baseDir, definitions)
+		}
+		ok = false
+	}
+
+	recording := &recordingFS{FS: fsys}
+	definitions, err := parseDefinitions(recording, absPath, baseDir, data, defaults)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		// the cache only speeds the loading up, the test file is parsed if it can't be written
+		_ = writeCacheEntry(path, recording.deps, recording.probes, definitions)
+	}
+	return makeTests(absPath, baseDir, definitions)
+}
+
+func cacheKey(absPath, baseDir string, data, defaults []byte) string {
+	h := sha256.New()
+	for _, part := range []string{definitionFingerprint, absPath, baseDir} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	h.Write(defaults)
+	h.Write([]byte{0})
+	h.Write(data)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// readCacheEntry returns the entry if the files it depends on are unchanged
+func readCacheEntry(fsys files.FS, path string) (cacheEntry, bool) {
+	var entry cacheEntry
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return entry, false
+	}
+	if err := unmarshalCacheEntry(data, &entry); err != nil {
+		return entry, false
+	}
+
+	for _, dep := range entry.Deps {
+		data, err := fsys.ReadFile(dep.Path)
+		if err != nil || hashOf(data) != dep.Hash {
+			return entry, false
+		}
+	}
+	for _, probe := range entry.Probes {
+		if _, err := fsys.Stat(probe.Path); (err == nil) != probe.Exists {
+			return entry, false
+		}
+	}
+	return entry, true
+}
+
+func writeCacheEntry(path string, deps []cacheDep, probes []cacheProbe, definitions []TestDefinition) error {
+	data, err := marshalExactCacheEntry(deps, probes, definitions)
+	if err != nil {
+		if data, err = json.Marshal(cacheEntry{Deps: deps, Probes: probes}); err != nil {
+			return err
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	// the entry is renamed into place, so the parallel runs never read a partially written entry
+	tmp, err := ioutil.TempFile(filepath.Dir(path), ".gonkey-cache-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// marshalExactCacheEntry fails unless the definitions are decoded from the entry unchanged
+func marshalExactCacheEntry(deps []cacheDep, probes []cacheProbe, definitions []TestDefinition) ([]byte, error) {
+	encoded, err := encodeCacheValue(reflect.ValueOf(definitions))
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(cacheEntry{Exact: true, Deps: deps, Probes: probes, Definitions: encoded})
+	if err != nil {
+		return nil, err
+	}
+
+	var entry cacheEntry
+	if err := unmarshalCacheEntry(data, &entry); err != nil {
+		return nil, err
+	}
+	var decoded []TestDefinition
+	if err := decodeCacheValue(entry.Definitions, reflect.ValueOf(&decoded).Elem()); err != nil {
+		return nil, err
+	}
+	if !reflect.DeepEqual(decoded, definitions) {
+		return nil, errors.New("the definitions change in the cache")
+	}
+	return data, nil
+}
+
+func unmarshalCacheEntry(data []byte, entry *cacheEntry) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(entry)
+}
+
+// recordingFS records the files read while parsing the test file, i.e. the request files and their includes,
+// and the files looked up
+type recordingFS struct {
+	files.FS
+	deps   []cacheDep
+	probes []cacheProbe
+}
+
+func (fs *recordingFS) Stat(name string) (os.FileInfo, error) {
+	info, err := fs.FS.Stat(name)
+	fs.probes = append(fs.probes, cacheProbe{Path: name, Exists: err == nil})
+	return info, err
+}
+
+func (fs *recordingFS) ReadFile(name string) ([]byte, error) {
+	data, err := fs.FS.ReadFile(name)
+	if err == nil {
+		fs.deps = append(fs.deps, cacheDep{Path: name, Hash: hashOf(data)})
+	}
+	return data, err
+}
+
+func hashOf(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// typeFingerprint describes the fields of the type with the fields of their types
+func typeFingerprint(t reflect.Type) string {
+	var b strings.Builder
+	writeType(&b, t, map[reflect.Type]bool{})
+	return b.String()
+}
+
+func writeType(b *strings.Builder, t reflect.Type, seen map[reflect.Type]bool) {
+	b.WriteString(t.String())
+	if seen[t] {
+		return
+	}
+	seen[t] = true
+
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array:
+		b.WriteString("<")
+		writeType(b, t.Elem(), seen)
+		b.WriteString(">")
+	case reflect.Map:
+		b.WriteString("<")
+		writeType(b, t.Key(), seen)
+		b.WriteString(",")
+		writeType(b, t.Elem(), seen)
+		b.WriteString(">")
+	case reflect.Struct:
+		b.WriteString("{")
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			fmt.Fprintf(b, "%s `%s` ", field.Name, field.Tag)
+			writeType(b, field.Type, seen)
+			b.WriteString(";")
+		}
+		b.WriteString("}")
+	}
+}
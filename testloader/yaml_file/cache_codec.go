@@ -0,0 +1,266 @@
+package yaml_file
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// The definitions are cached as JSON trees built by reflection: unlike encoding/json and encoding/gob
+// the encoding keeps nil apart from the empty lists and maps and the concrete types of the interface{}
+// values, and it skips the func fields, e.g. the custom comparators of the comparison params.
+
+// cacheValueTypes are the types the YAML values of the interface{} fields are decoded to
+var cacheValueTypes = map[string]reflect.Type{}
+
+func init() {
+	for _, v := range []interface{}{
+		map[interface{}]interface{}{},
+		map[string]interface{}{},
+		[]interface{}{},
+		"",
+		false,
+		0,
+		int64(0),
+		uint64(0),
+		float64(0),
+		time.Time{},
+	} {
+		t := reflect.TypeOf(v)
+		cacheValueTypes[t.String()] = t
+	}
+}
+
+func encodeCacheValue(v reflect.Value) (interface{}, error) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map:
+		if v.IsNil() {
+			return nil, nil
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return v.Bool(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return json.Number(strconv.FormatInt(v.Int(), 10)), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return json.Number(strconv.FormatUint(v.Uint(), 10)), nil
+	case reflect.Float32, reflect.Float64:
+		// a string, as JSON has no infinities
+		return strconv.FormatFloat(v.Float(), 'g', -1, 64), nil
+	case reflect.Ptr:
+		return encodeCacheValue(v.Elem())
+	case reflect.Interface:
+		elem := v.Elem()
+		if cacheValueTypes[elem.Type().String()] != elem.Type() {
+			return nil, fmt.Errorf("values of type %s can't be cached", elem.Type())
+		}
+		encoded, err := encodeCacheValue(elem)
+		if err != nil {
+			return nil, err
+		}
+		return []interface{}{elem.Type().String(), encoded}, nil
+	case reflect.Slice, reflect.Array:
+		items := make([]interface{}, v.Len())
+		for i := range items {
+			item, err := encodeCacheValue(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			items[i] = item
+		}
+		return items, nil
+	case reflect.Map:
+		// the keys and the values in turn, the keys are not always strings
+		items := make([]interface{}, 0, 2*v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			key, err := encodeCacheValue(iter.Key())
+			if err != nil {
+				return nil, err
+			}
+			value, err := encodeCacheValue(iter.Value())
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, key, value)
+		}
+		return items, nil
+	case reflect.Struct:
+		if t, ok := v.Interface().(time.Time); ok {
+			return t.Format(time.RFC3339Nano), nil
+		}
+		fields := map[string]interface{}{}
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if field.PkgPath != "" || v.Field(i).IsZero() {
+				continue
+			}
+			if uncacheable(field.Type) {
+				return nil, fmt.Errorf("field %s can't be cached", field.Name)
+			}
+			value, err := encodeCacheValue(v.Field(i))
+			if err != nil {
+				return nil, err
+			}
+			fields[field.Name] = value
+		}
+		return fields, nil
+	}
+	return nil, fmt.Errorf("values of type %s can't be cached", v.Type())
+}
+
+// uncacheable reports the func fields and the collections of funcs
+func uncacheable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Func, reflect.Chan:
+		return true
+	case reflect.Map, reflect.Slice, reflect.Array, reflect.Ptr:
+		return uncacheable(t.Elem())
+	}
+	return false
+}
+
+func decodeCacheValue(data interface{}, v reflect.Value) error {
+	if data == nil {
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		s, ok := data.(string)
+		if !ok {
+			return cacheTypeError(data, v)
+		}
+		v.SetString(s)
+	case reflect.Bool:
+		b, ok := data.(bool)
+		if !ok {
+			return cacheTypeError(data, v)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, ok := data.(json.Number)
+		if !ok {
+			return cacheTypeError(data, v)
+		}
+		i, err := strconv.ParseInt(string(n), 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, ok := data.(json.Number)
+		if !ok {
+			return cacheTypeError(data, v)
+		}
+		u, err := strconv.ParseUint(string(n), 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		s, ok := data.(string)
+		if !ok {
+			return cacheTypeError(data, v)
+		}
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Ptr:
+		elem := reflect.New(v.Type().Elem())
+		if err := decodeCacheValue(data, elem.Elem()); err != nil {
+			return err
+		}
+		v.Set(elem)
+	case reflect.Interface:
+		pair, ok := data.([]interface{})
+		if !ok || len(pair) != 2 {
+			return cacheTypeError(data, v)
+		}
+		name, _ := pair[0].(string)
+		t, ok := cacheValueTypes[name]
+		if !ok {
+			return fmt.Errorf("unknown cached type %q", name)
+		}
+		elem := reflect.New(t).Elem()
+		if err := decodeCacheValue(pair[1], elem); err != nil {
+			return err
+		}
+		v.Set(elem)
+	case reflect.Slice, reflect.Array:
+		items, ok := data.([]interface{})
+		if !ok {
+			return cacheTypeError(data, v)
+		}
+		if v.Kind() == reflect.Slice {
+			v.Set(reflect.MakeSlice(v.Type(), len(items), len(items)))
+		} else if len(items) != v.Len() {
+			return cacheTypeError(data, v)
+		}
+		for i, item := range items {
+			if err := decodeCacheValue(item, v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		items, ok := data.([]interface{})
+		if !ok || len(items)%2 != 0 {
+			return cacheTypeError(data, v)
+		}
+		m := reflect.MakeMapWithSize(v.Type(), len(items)/2)
+		for i := 0; i < len(items); i += 2 {
+			key := reflect.New(v.Type().Key()).Elem()
+			if err := decodeCacheValue(items[i], key); err != nil {
+				return err
+			}
+			value := reflect.New(v.Type().Elem()).Elem()
+			if err := decodeCacheValue(items[i+1], value); err != nil {
+				return err
+			}
+			m.SetMapIndex(key, value)
+		}
+		v.Set(m)
+	case reflect.Struct:
+		if v.Type() == reflect.TypeOf(time.Time{}) {
+			s, ok := data.(string)
+			if !ok {
+				return cacheTypeError(data, v)
+			}
+			t, err := time.Parse(time.RFC3339Nano, s)
+			if err != nil {
+				return err
+			}
+			v.Set(reflect.ValueOf(t))
+			return nil
+		}
+		fields, ok := data.(map[string]interface{})
+		if !ok {
+			return cacheTypeError(data, v)
+		}
+		for name, value := range fields {
+			field, ok := v.Type().FieldByName(name)
+			if !ok || len(field.Index) != 1 || field.PkgPath != "" {
+				return fmt.Errorf("unknown cached field %s of %s", name, v.Type())
+			}
+			if err := decodeCacheValue(value, v.Field(field.Index[0])); err != nil {
+				return err
+			}
+		}
+	default:
+		return cacheTypeError(data, v)
+	}
+	return nil
+}
+
+func cacheTypeError(data interface{}, v reflect.Value) error {
+	return fmt.Errorf("can't decode cached %T into %s", data, v.Type())
+}
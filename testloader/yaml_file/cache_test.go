@@ -0,0 +1,133 @@
+package yaml_file
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lamoda/gonkey/files"
+)
+
+func TestCacheKeepsDefinitions(t *testing.T) {
+	cacheDir, err := ioutil.TempDir("", "gonkey-cache")
+	require.NoError(t, err)
+	defer os.RemoveAll(cacheDir)
+
+	c := &cache{dir: cacheDir}
+	for _, path := range []string{
+		"testdata/anchors.yaml",
+		"testdata/assertions.yaml",
+		"testdata/db-query-params.yaml",
+		"testdata/expected-state.yaml",
+		"testdata/relative-paths/relative-paths.yaml",
+		"testdata/request-file/request-file.yaml",
+		"testdata/response-status.yaml",
+		"testdata/variables.yaml",
+	} {
+		t.Run(path, func(t *testing.T) {
+			expected, err := parseTestDefinitionFile(files.OS, path, "")
+			require.NoError(t, err)
+
+			_, err = c.parse(files.OS, path, "", nil)
+			require.NoError(t, err)
+			data, err := ioutil.ReadFile(path)
+			require.NoError(t, err)
+			entry, ok := readCacheEntry(files.OS, filepath.Join(cacheDir, cacheKey(path, "", data, nil)+".json"))
+			require.True(t, ok)
+			assert.True(t, entry.Exact)
+
+			cached, err := c.parse(files.OS, path, "", nil)
+			require.NoError(t, err)
+			assert.Equal(t, expected, cached)
+		})
+	}
+}
+
+func TestCacheInvalidation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gonkey-tests")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "bodies"), 0755))
+
+	write := func(name, content string) {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+	write("orders.yaml", "- name: create order\n  method: POST\n  path: /orders\n  requestFile: bodies/order.json\n")
+	write("bodies/order.json", `{"items": [{{ include "item.json" }}]}`)
+	write("bodies/item.json", `{"sku": "sku-1"}`)
+
+	c := &cache{dir: filepath.Join(dir, "cache")}
+	load := func() *Test {
+		tests, err := c.parse(files.OS, filepath.Join(dir, "orders.yaml"), "", nil)
+		require.NoError(t, err)
+		require.Len(t, tests, 1)
+		return &tests[0]
+	}
+
+	assert.Equal(t, `{"items": [{"sku": "sku-1"}]}`, load().GetRequest())
+	assert.Equal(t, `{"items": [{"sku": "sku-1"}]}`, load().GetRequest())
+
+	write("bodies/item.json", `{"sku": "sku-2"}`)
+	assert.Equal(t, `{"items": [{"sku": "sku-2"}]}`, load().GetRequest(), "include changed")
+
+	write("bodies/order.json", `{"order": {{ include "item.json" }}}`)
+	assert.Equal(t, `{"order": {"sku": "sku-2"}}`, load().GetRequest(), "request file changed")
+
+	write("orders.yaml", "- name: create order\n  method: PUT\n  path: /orders\n  requestFile: bodies/order.json\n")
+	assert.Equal(t, "PUT", load().GetMethod(), "test file changed")
+}
+
+func TestCacheInvalidationByResolvedPaths(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gonkey-tests")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	const invoice = "testdata/relative-paths/bodies/order.json"
+	testFile := filepath.Join(dir, "orders.yaml")
+	require.NoError(t, ioutil.WriteFile(testFile, []byte(
+		"- name: create order\n  method: POST\n  path: /orders\n  form:\n    files:\n      invoice: "+invoice+"\n",
+	), 0644))
+
+	c := &cache{dir: filepath.Join(dir, "cache")}
+	load := func() string {
+		tests, err := c.parse(files.OS, testFile, "", nil)
+		require.NoError(t, err)
+		require.Len(t, tests, 1)
+		return tests[0].GetForm().Files["invoice"]
+	}
+
+	// the file relative to the working directory is used until the file appears next to the test file
+	assert.Equal(t, invoice, load())
+	assert.Equal(t, invoice, load())
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, filepath.Dir(invoice)), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, invoice), nil, 0644))
+	assert.Equal(t, filepath.Join(dir, invoice), load())
+}
+
+func TestCacheCorruptedEntry(t *testing.T) {
+	cacheDir, err := ioutil.TempDir("", "gonkey-cache")
+	require.NoError(t, err)
+	defer os.RemoveAll(cacheDir)
+
+	path := "testdata/anchors.yaml"
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	entryPath := filepath.Join(cacheDir, cacheKey(path, "", data, nil)+".json")
+	require.NoError(t, ioutil.WriteFile(entryPath, []byte("{not json"), 0644))
+
+	expected, err := parseTestDefinitionFile(files.OS, path, "")
+	require.NoError(t, err)
+	tests, err := (&cache{dir: cacheDir}).parse(files.OS, path, "", nil)
+	require.NoError(t, err)
+	assert.Equal(t, expected, tests)
+
+	// the broken entry is replaced
+	entry, ok := readCacheEntry(files.OS, entryPath)
+	require.True(t, ok)
+	assert.True(t, entry.Exact)
+}
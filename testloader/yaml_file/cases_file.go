@@ -0,0 +1,76 @@
+package yaml_file
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+
+	"github.com/lamoda/gonkey/files"
+)
+
+// loadCasesFile appends the rows of casesFile of the definition to its cases, the header row of the CSV
+// names the variables the values of the rows are bound to
+func loadCasesFile(fsys files.FS, definition *TestDefinition) error {
+	if definition.CasesFile == nil {
+		return nil
+	}
+	cases, err := readCasesFile(fsys, *definition.CasesFile)
+	if err != nil {
+		return fmt.Errorf("test %s: %s", definition.Name, err)
+	}
+
+	// the cases are copied as they may be shared with other tests by YAML aliases
+	definition.Cases = append(append([]CaseData{}, definition.Cases...), cases...)
+	return nil
+}
+
+func readCasesFile(fsys files.FS, casesFile CasesFile) ([]CaseData, error) {
+	if casesFile.Path == "" {
+		return nil, fmt.Errorf("casesFile has no path")
+	}
+	data, err := fsys.ReadFile(casesFile.Path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read cases file %s: %s", casesFile.Path, err)
+	}
+	// the rows of RFC 4180 may have the quoted fields with commas, quotes and line breaks
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid cases file %s: %s", casesFile.Path, err)
+	}
+	if len(records) < 2 {
+		return nil, fmt.Errorf("cases file %s has no rows after the header", casesFile.Path)
+	}
+
+	header := records[0]
+	nameIdx := -1
+	for i, column := range header {
+		if column == "" {
+			return nil, fmt.Errorf("cases file %s has a column without a name", casesFile.Path)
+		}
+		if column == casesFile.NameColumn {
+			nameIdx = i
+		}
+	}
+	if casesFile.NameColumn != "" && nameIdx < 0 {
+		return nil, fmt.Errorf("cases file %s has no column %s", casesFile.Path, casesFile.NameColumn)
+	}
+
+	cases := make([]CaseData, 0, len(records)-1)
+	names := make(map[string]bool, len(records)-1)
+	for _, record := range records[1:] {
+		variables := make(map[string]interface{}, len(header))
+		for i, column := range header {
+			variables[column] = record[i]
+		}
+		c := CaseData{Variables: variables}
+		if nameIdx >= 0 {
+			c.Name = record[nameIdx]
+			if c.Name == "" || names[c.Name] {
+				return nil, fmt.Errorf("cases file %s has a row with an empty or duplicate name %q", casesFile.Path, c.Name)
+			}
+			names[c.Name] = true
+		}
+		cases = append(cases, c)
+	}
+	return cases, nil
+}
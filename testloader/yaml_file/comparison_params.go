@@ -0,0 +1,86 @@
+package yaml_file
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/lamoda/gonkey/compare"
+)
+
+// templatedComparisonParams are the comparison params which can be templates
+var templatedComparisonParams = map[string]bool{
+	"ignoreValues":         true,
+	"ignoreArraysOrdering": true,
+	"disallowExtraFields":  true,
+	"ignoreDbOrdering":     true,
+	"normalizeKeys":        true,
+	"numberTypeStrict":     true,
+}
+
+// ComparisonParams are the comparisonParams of the test, the params can be the templates
+// evaluated before the checks, e.g. disallowExtraFields: '{{ ne $apiVersion "v1" }}'
+type ComparisonParams struct {
+	compare.CompareParams `yaml:",inline"`
+	// Templates are the templates of the params by their names in the test file
+	Templates map[string]string `json:"-" yaml:"-"`
+}
+
+func (p *ComparisonParams) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var raw map[string]interface{}
+	if err := unmarshal(&raw); err != nil {
+		return err
+	}
+	for name, value := range raw {
+		tmpl, ok := value.(string)
+		if !ok || !templatedComparisonParams[name] || !strings.Contains(tmpl, "{{") {
+			continue
+		}
+		if p.Templates == nil {
+			p.Templates = map[string]string{}
+		}
+		p.Templates[name] = tmpl
+		delete(raw, name)
+	}
+
+	// the rest of the params are decoded as usual
+	data, err := yaml.Marshal(raw)
+	if err != nil {
+		return err
+	}
+	return yaml.Unmarshal(data, &p.CompareParams)
+}
+
+// set sets the param evaluated from its template
+func (p *ComparisonParams) set(name, value string) error {
+	var target *bool
+	switch name {
+	case "normalizeKeys":
+		if _, err := compare.KeyNormalizer(value); err != nil {
+			return err
+		}
+		p.NormalizeKeys = value
+		return nil
+	case "ignoreValues":
+		target = &p.IgnoreValues
+	case "ignoreArraysOrdering":
+		target = &p.IgnoreArraysOrdering
+	case "disallowExtraFields":
+		target = &p.DisallowExtraFields
+	case "ignoreDbOrdering":
+		target = &p.IgnoreDbOrdering
+	case "numberTypeStrict":
+		target = &p.NumberTypeStrict
+	default:
+		return fmt.Errorf("unknown comparison param %s", name)
+	}
+
+	b, err := strconv.ParseBool(strings.TrimSpace(value))
+	if err != nil {
+		return fmt.Errorf("%s must be true or false, got %q", name, value)
+	}
+	*target = b
+	return nil
+}
@@ -0,0 +1,35 @@
+package yaml_file
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+func TestComparisonParamsTemplates(t *testing.T) {
+	var params ComparisonParams
+	err := yaml.Unmarshal([]byte(`
+ignoreArraysOrdering: true
+disallowExtraFields: '{{ ne $apiVersion "v1" }}'
+normalizeKeys: '{{ if eq $apiVersion "v1" }}snake{{ end }}'
+`), &params)
+	require.NoError(t, err)
+
+	assert.True(t, params.IgnoreArraysOrdering)
+	assert.False(t, params.DisallowExtraFields)
+	assert.Empty(t, params.NormalizeKeys)
+	assert.Equal(t, map[string]string{
+		"disallowExtraFields": `{{ ne $apiVersion "v1" }}`,
+		"normalizeKeys":       `{{ if eq $apiVersion "v1" }}snake{{ end }}`,
+	}, params.Templates)
+
+	require.NoError(t, params.set("disallowExtraFields", "true"))
+	require.NoError(t, params.set("normalizeKeys", "snake"))
+	assert.True(t, params.DisallowExtraFields)
+	assert.Equal(t, "snake", params.NormalizeKeys)
+
+	assert.EqualError(t, params.set("numberTypeStrict", "v2"), `numberTypeStrict must be true or false, got "v2"`)
+	assert.Error(t, params.set("normalizeKeys", "kebab"))
+}
@@ -0,0 +1,108 @@
+package yaml_file
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/lamoda/gonkey/files"
+)
+
+// DefaultsFile is the file of a directory with the defaults of the tests of the directory and of its subdirectories
+const DefaultsFile = "gonkey.yaml"
+
+// dirDefaults are the fields of the tests merged from the defaults files of the directories,
+// the files of the nested directories take priority over the files of their parents
+type dirDefaults struct {
+	values map[interface{}]interface{}
+	// data is the encoded values, the cache entries of the tests depend on it
+	data []byte
+}
+
+// loadDirDefaults merges the defaults file of the directory, if it exists, into the defaults of the parent directory
+func loadDirDefaults(fsys files.FS, dir string, parent *dirDefaults) (*dirDefaults, error) {
+	path := filepath.Join(dir, DefaultsFile)
+	data, err := fsys.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return parent, nil
+		}
+		return nil, fmt.Errorf("unable to read directory defaults %s: %s", path, err)
+	}
+
+	var values map[interface{}]interface{}
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("unable to parse directory defaults %s: %s", path, err)
+	}
+	// the types of the fields are checked here to report the errors with the path of the defaults file
+	var definition TestDefinition
+	if err := yaml.Unmarshal(data, &definition); err != nil {
+		return nil, fmt.Errorf("unable to parse directory defaults %s: %s", path, err)
+	}
+
+	if parent != nil {
+		values = mergeDefaults(parent.values, values)
+	}
+	if data, err = yaml.Marshal(values); err != nil {
+		return nil, err
+	}
+	return &dirDefaults{values: values, data: data}, nil
+}
+
+// key returns the part of the key of the cache entries of the tests
+func (d *dirDefaults) key() []byte {
+	if d == nil {
+		return nil
+	}
+	return d.data
+}
+
+// apply parses the definitions of the file with the defaults merged into them. The definitions
+// holding only the anchored blocks and the steps of the scenarios are left as they are.
+func (d *dirDefaults) apply(data []byte) ([]TestDefinition, error) {
+	var items []interface{}
+	if err := yaml.Unmarshal(data, &items); err != nil {
+		return nil, err
+	}
+	for i, item := range items {
+		values, ok := item.(map[interface{}]interface{})
+		if !ok {
+			continue
+		}
+		if _, ok := values["definitions"]; ok {
+			continue
+		}
+		items[i] = mergeDefaults(d.values, values)
+	}
+
+	merged, err := yaml.Marshal(items)
+	if err != nil {
+		return nil, err
+	}
+	var definitions []TestDefinition
+	if err := yaml.Unmarshal(merged, &definitions); err != nil {
+		return nil, err
+	}
+	return definitions, nil
+}
+
+// mergeDefaults returns a new map with the values of patch added to or replacing the values of base,
+// the maps are merged key by key, the other values of patch (the lists as well) replace the values of base
+func mergeDefaults(base, patch map[interface{}]interface{}) map[interface{}]interface{} {
+	res := make(map[interface{}]interface{}, len(base)+len(patch))
+	for k, v := range base {
+		res[k] = v
+	}
+	for k, v := range patch {
+		baseMap, baseIsMap := res[k].(map[interface{}]interface{})
+		patchMap, patchIsMap := v.(map[interface{}]interface{})
+		if baseIsMap && patchIsMap {
+			res[k] = mergeDefaults(baseMap, patchMap)
+			continue
+		}
+		res[k] = v
+	}
+	return res
+}
@@ -0,0 +1,55 @@
+package yaml_file
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoaderMergesDirectoryDefaults(t *testing.T) {
+	tests, err := NewLoader("testdata/defaults").Load()
+	require.NoError(t, err)
+	require.Len(t, tests, 3)
+
+	listItems, createItem, listOrders := tests[0], tests[1], tests[2]
+
+	assert.Equal(t, "GET", listItems.GetMethod())
+	assert.Equal(t, map[string]string{"Accept": "application/json", "X-Service": "items"}, listItems.Headers())
+	assert.True(t, listItems.IgnoreArraysOrdering())
+	assert.Equal(t, map[int]string{200: "{}"}, listItems.GetResponses())
+
+	assert.Equal(t, "POST", createItem.GetMethod())
+	assert.False(t, createItem.IgnoreArraysOrdering())
+	assert.Equal(t, map[int]string{200: "{}", 201: "{}"}, createItem.GetResponses())
+
+	assert.Equal(t, "/orders", listOrders.Path())
+	assert.Equal(t, map[string]string{
+		"Accept":        "application/json",
+		"X-Service":     "shop",
+		"Authorization": "Bearer token",
+	}, listOrders.Headers())
+	assert.True(t, listOrders.IgnoreArraysOrdering())
+	assert.True(t, listOrders.DisallowExtraFields())
+}
+
+func TestLoaderMergesDefaultsIntoSingleFile(t *testing.T) {
+	tests, err := NewLoader("testdata/defaults/orders/orders.yaml").Load()
+	require.NoError(t, err)
+	require.Len(t, tests, 1)
+
+	// only the defaults file of the directory of the test file is merged
+	assert.Equal(t, map[string]string{"Authorization": "Bearer token"}, tests[0].Headers())
+	assert.Equal(t, "", tests[0].GetMethod())
+}
+
+func TestLoaderInvalidDirectoryDefaults(t *testing.T) {
+	_, err := NewLoader("testdata/defaults-invalid").Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unable to parse directory defaults testdata/defaults-invalid/gonkey.yaml")
+}
+
+func TestCacheDependsOnDirectoryDefaults(t *testing.T) {
+	key := cacheKey("orders.yaml", "", []byte("- name: orders"), nil)
+	assert.NotEqual(t, key, cacheKey("orders.yaml", "", []byte("- name: orders"), []byte("method: GET\n")))
+}
@@ -0,0 +1,67 @@
+package yaml_file
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/lamoda/gonkey/models"
+)
+
+// ExpectedState holds the rows the tables must contain after the request by table name,
+// the columns missing in the rows are not compared
+type ExpectedState map[string][]map[string]interface{}
+
+// stateChecks turns the expected state into the DB checks selecting all the rows of each table,
+// the rows are ordered by the first column of the table unless ignoreDbOrdering is set
+func stateChecks(state ExpectedState) ([]models.DatabaseCheck, error) {
+	tables := make([]string, 0, len(state))
+	for table := range state {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+
+	checks := make([]models.DatabaseCheck, 0, len(tables))
+	for _, table := range tables {
+		// an empty list of rows expects the table to be empty
+		response := make([]string, 0, len(state[table]))
+		for i, row := range state[table] {
+			encoded, err := json.Marshal(jsonValue(row))
+			if err != nil {
+				return nil, fmt.Errorf("expectedState: row %d of table %s: %s", i, table, err)
+			}
+			response = append(response, string(encoded))
+		}
+		checks = append(checks, &dbCheck{
+			query:    fmt.Sprintf("SELECT * FROM %s ORDER BY 1", table),
+			response: response,
+		})
+	}
+	return checks, nil
+}
+
+// jsonValue converts the maps decoded from YAML to the maps with string keys JSON can encode
+func jsonValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		res := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			res[key] = jsonValue(item)
+		}
+		return res
+	case map[interface{}]interface{}:
+		res := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			res[fmt.Sprint(key)] = jsonValue(item)
+		}
+		return res
+	case []interface{}:
+		res := make([]interface{}, len(v))
+		for i, item := range v {
+			res[i] = jsonValue(item)
+		}
+		return res
+	default:
+		return v
+	}
+}
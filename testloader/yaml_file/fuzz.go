@@ -0,0 +1,47 @@
+package yaml_file
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/lamoda/gonkey/models"
+)
+
+// fuzzResponseStatus is the expected status of the fuzz tests without responseStatus:
+// any status but a server error
+var fuzzResponseStatus = models.ResponseStatus{"1xx", "2xx", "3xx", "4xx"}
+
+// validateType validates the type of the test and the block of the type,
+// the fuzz tests expect any status below 500 by default
+func validateType(definition *TestDefinition) error {
+	switch definition.Type {
+	case "":
+		if definition.Fuzz != nil {
+			return errors.New("the fuzz block is set, but the type of the test is not fuzz")
+		}
+		return nil
+	case models.TestTypeFuzz:
+	default:
+		return fmt.Errorf("unknown test type %s", definition.Type)
+	}
+
+	fuzz := definition.Fuzz
+	if fuzz == nil || fuzz.Schema == "" {
+		return errors.New("fuzz tests need the schema of the request body in the fuzz block")
+	}
+	if fuzz.Iterations < 1 {
+		return fmt.Errorf("fuzz iterations must be positive, got %d", fuzz.Iterations)
+	}
+	if definition.RequestTmpl != "" || definition.RequestBuilder != "" || definition.Form != nil {
+		return errors.New("the request of fuzz tests is generated from the schema, " +
+			"`request`, `requestFile`, `requestBuilder` and `form` can't be used")
+	}
+	if len(definition.Cases) != 0 {
+		return errors.New("fuzz tests can't have cases")
+	}
+
+	if definition.ResponseStatus == nil && len(definition.Assertions) == 0 {
+		definition.ResponseStatus = fuzzResponseStatus
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package yaml_file
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lamoda/gonkey/models"
+)
+
+func TestValidateTypeFuzz(t *testing.T) {
+	definition := TestDefinition{
+		Type: models.TestTypeFuzz,
+		Fuzz: &models.Fuzz{Schema: "order.json", Iterations: 10},
+	}
+	require.NoError(t, validateType(&definition))
+	assert.Equal(t, models.ResponseStatus{"1xx", "2xx", "3xx", "4xx"}, definition.ResponseStatus)
+
+	definition = TestDefinition{
+		Type:           models.TestTypeFuzz,
+		Fuzz:           &models.Fuzz{Schema: "order.json", Iterations: 10},
+		ResponseStatus: models.ResponseStatus{"2xx"},
+	}
+	require.NoError(t, validateType(&definition))
+	assert.Equal(t, models.ResponseStatus{"2xx"}, definition.ResponseStatus)
+}
+
+func TestValidateTypeErrors(t *testing.T) {
+	fuzz := &models.Fuzz{Schema: "order.json", Iterations: 10}
+	tests := []struct {
+		name       string
+		definition TestDefinition
+		err        string
+	}{
+		{
+			name:       "unknown type",
+			definition: TestDefinition{Type: "load"},
+			err:        "unknown test type load",
+		},
+		{
+			name:       "fuzz block without type",
+			definition: TestDefinition{Fuzz: fuzz},
+			err:        "the fuzz block is set, but the type of the test is not fuzz",
+		},
+		{
+			name:       "no schema",
+			definition: TestDefinition{Type: models.TestTypeFuzz, Fuzz: &models.Fuzz{Iterations: 10}},
+			err:        "fuzz tests need the schema of the request body in the fuzz block",
+		},
+		{
+			name:       "no iterations",
+			definition: TestDefinition{Type: models.TestTypeFuzz, Fuzz: &models.Fuzz{Schema: "order.json"}},
+			err:        "fuzz iterations must be positive, got 0",
+		},
+		{
+			name:       "request",
+			definition: TestDefinition{Type: models.TestTypeFuzz, Fuzz: fuzz, RequestTmpl: "{}"},
+			err: "the request of fuzz tests is generated from the schema, " +
+				"`request`, `requestFile`, `requestBuilder` and `form` can't be used",
+		},
+		{
+			name:       "cases",
+			definition: TestDefinition{Type: models.TestTypeFuzz, Fuzz: fuzz, Cases: []CaseData{{}}},
+			err:        "fuzz tests can't have cases",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.EqualError(t, validateType(&tt.definition), tt.err)
+		})
+	}
+}
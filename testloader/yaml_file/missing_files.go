@@ -0,0 +1,56 @@
+package yaml_file
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lamoda/gonkey/files"
+	"github.com/lamoda/gonkey/models"
+)
+
+// missingFiles returns the files read by the loader which don't exist: the request file, its includes
+// and the cases file of the definition, the paths must be resolved. The other files are checked by the runner.
+func missingFiles(fsys files.FS, definition *TestDefinition) []models.MissingFile {
+	var missing []models.MissingFile
+	check := func(field, path string) {
+		if path == "" || strings.Contains(path, "{{") || exists(fsys, path) {
+			return
+		}
+		missing = append(missing, models.MissingFile{Field: field, Path: path, Test: definition.Name})
+	}
+
+	check("requestFile", definition.RequestFile)
+	if definition.RequestFile != "" {
+		for _, include := range includedFiles(fsys, definition.RequestFile, 0) {
+			check("include", include)
+		}
+	}
+	if definition.CasesFile != nil {
+		check("casesFile", definition.CasesFile.Path)
+	}
+	return missing
+}
+
+// includedFiles returns the files included by the request file and by its existing includes
+func includedFiles(fsys files.FS, path string, depth int) []string {
+	data, err := fsys.ReadFile(path)
+	if err != nil || depth > maxIncludeDepth {
+		return nil
+	}
+	var included []string
+	for _, match := range includeRx.FindAllStringSubmatch(string(data), -1) {
+		includePath := match[1]
+		if !filepath.IsAbs(includePath) {
+			includePath = filepath.Join(filepath.Dir(path), includePath)
+		}
+		included = append(included, includePath)
+		included = append(included, includedFiles(fsys, includePath, depth+1)...)
+	}
+	return included
+}
+
+func exists(fsys files.FS, path string) bool {
+	_, err := fsys.Stat(path)
+	return err == nil || !os.IsNotExist(err)
+}
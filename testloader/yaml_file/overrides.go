@@ -0,0 +1,57 @@
+package yaml_file
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Overrides are environment-specific values patched into every loaded test,
+// they take priority over the values defined in the tests
+type Overrides struct {
+	Variables map[string]string `json:"variables" yaml:"variables"`
+	Headers   map[string]string `json:"headers" yaml:"headers"`
+}
+
+// OverridesFile returns path of the overrides file of the environment
+func OverridesFile(dir, env string) string {
+	return filepath.Join(dir, env+".yaml")
+}
+
+// LoadOverrides reads overrides from the YAML file
+func LoadOverrides(path string) (*Overrides, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read environment overrides: %s", err)
+	}
+
+	var overrides Overrides
+	if err := yaml.UnmarshalStrict(data, &overrides); err != nil {
+		return nil, fmt.Errorf("unable to parse environment overrides %s: %s", path, err)
+	}
+	return &overrides, nil
+}
+
+func (o *Overrides) apply(test *Test) {
+	test.Variables = mergeStrings(test.Variables, o.Variables)
+	test.CombinedVariables = mergeStrings(test.CombinedVariables, o.Variables)
+	test.HeadersVal = mergeStrings(test.HeadersVal, o.Headers)
+}
+
+// mergeStrings returns a new map with the values of patch added to or replacing the values of base
+func mergeStrings(base, patch map[string]string) map[string]string {
+	if len(patch) == 0 {
+		return base
+	}
+
+	res := make(map[string]string, len(base)+len(patch))
+	for k, v := range base {
+		res[k] = v
+	}
+	for k, v := range patch {
+		res[k] = v
+	}
+	return res
+}
@@ -0,0 +1,34 @@
+package yaml_file
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoaderAppliesOverrides(t *testing.T) {
+	overrides, err := LoadOverrides(OverridesFile("testdata/environments", "staging"))
+	require.NoError(t, err)
+
+	loader := NewLoader("testdata/overrides.yaml")
+	loader.SetOverrides(overrides)
+
+	tests, err := loader.Load()
+	require.NoError(t, err)
+	require.Len(t, tests, 2)
+
+	assert.Equal(t, map[string]string{"orderId": "100", "status": "new"}, tests[0].GetCombinedVariables())
+	assert.Equal(t, map[string]string{"X-Env": "staging", "Accept": "application/json"}, tests[0].Headers())
+	assert.Equal(t, "100", tests[1].GetCombinedVariables()["orderId"])
+}
+
+func TestLoadOverridesUnknownField(t *testing.T) {
+	_, err := LoadOverrides("testdata/overrides.yaml")
+	assert.Error(t, err)
+}
+
+func TestLoadOverridesMissingFile(t *testing.T) {
+	_, err := LoadOverrides(OverridesFile("testdata/environments", "production"))
+	assert.Error(t, err)
+}
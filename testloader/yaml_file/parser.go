@@ -2,12 +2,15 @@ package yaml_file
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
-	"io/ioutil"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"text/template"
 
+	"github.com/lamoda/gonkey/compare"
+	"github.com/lamoda/gonkey/files"
 	"github.com/lamoda/gonkey/models"
 
 	"gopkg.in/yaml.v2"
@@ -18,27 +21,147 @@ const (
 	gonkeyProtectSubstitute = "!protect!"
 )
 
-var gonkeyProtectTemplate = regexp.MustCompile("{{\\s*\\$")
+var (
+	gonkeyProtectTemplate = regexp.MustCompile("{{\\s*\\$")
+	unknownAnchorRx       = regexp.MustCompile(`unknown anchor '(.+)' referenced`)
+)
+
+// parseTestDefinitionFile parses the tests of the file, the relative paths of the files referenced by them
+// are resolved against baseDir or against the directory of the test file if baseDir is empty
+func parseTestDefinitionFile(fsys files.FS, absPath, baseDir string) ([]Test, error) {
+	return parseTestFileWithDefaults(fsys, absPath, baseDir, nil)
+}
 
-func parseTestDefinitionFile(absPath string) ([]Test, error) {
-	data, err := ioutil.ReadFile(absPath)
+// parseTestFileWithDefaults parses the tests of the file with the defaults of its directory merged into them
+func parseTestFileWithDefaults(fsys files.FS, absPath, baseDir string, defaults *dirDefaults) ([]Test, error) {
+	data, err := fsys.ReadFile(absPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read file %s:\n%s", absPath, err)
 	}
 
+	definitions, err := parseDefinitions(fsys, absPath, baseDir, data, defaults)
+	if err != nil {
+		return nil, err
+	}
+	return makeTests(absPath, baseDir, definitions)
+}
+
+// parseDefinitions unmarshals the definitions of the tests from the content of the file,
+// with the defaults of the directory, the resolved paths and the loaded request files
+func parseDefinitions(fsys files.FS, absPath, baseDir string, data []byte, defaults *dirDefaults) ([]TestDefinition, error) {
 	var testDefinitions []TestDefinition
 
 	// reading the test source file
 	if err := yaml.Unmarshal(data, &testDefinitions); err != nil {
+		if matches := unknownAnchorRx.FindStringSubmatch(err.Error()); matches != nil {
+			return nil, fmt.Errorf(
+				"failed to unmarshall %s:\nalias *%s refers to an anchor which is not defined above in this file "+
+					"(anchors can't be shared between files)",
+				absPath,
+				matches[1],
+			)
+		}
 		return nil, fmt.Errorf("failed to unmarshall %s:\n%s", absPath, err)
 	}
+	// the file is parsed as it is first, for the errors to refer to its lines
+	if defaults != nil {
+		var err error
+		if testDefinitions, err = defaults.apply(data); err != nil {
+			return nil, fmt.Errorf("failed to apply directory defaults to %s:\n%s", absPath, err)
+		}
+	}
 
-	var tests []Test
+	baseDir = definitionsDir(absPath, baseDir)
 
+	var definitions []TestDefinition
+	var missing []models.MissingFile
 	for _, definition := range testDefinitions {
+		// the item only holds anchored blocks for other tests
+		if definition.Definitions != nil {
+			continue
+		}
+
+		if err := prepareDefinition(fsys, &definition, baseDir); err != nil {
+			if models.CollectMissingFiles(err, &missing) {
+				continue
+			}
+			return nil, err
+		}
+		definitions = append(definitions, definition)
+	}
+	if len(missing) != 0 {
+		for i := range missing {
+			missing[i].TestFile = absPath
+		}
+		return nil, &models.MissingFilesError{Files: missing}
+	}
+	return definitions, nil
+}
+
+// prepareDefinition resolves the paths and loads the request files of the definition and of its steps
+func prepareDefinition(fsys files.FS, definition *TestDefinition, baseDir string) error {
+	resolvePaths(fsys, definition, baseDir)
+	// the missing files of all the tests are reported at once
+	missing := missingFiles(fsys, definition)
+
+	if definition.Steps != nil {
+		// the steps are copied as they may be shared with other tests by YAML aliases
+		steps := make([]TestDefinition, len(definition.Steps))
+		copy(steps, definition.Steps)
+		for i := range steps {
+			if err := prepareDefinition(fsys, &steps[i], baseDir); err != nil {
+				if models.CollectMissingFiles(err, &missing) {
+					continue
+				}
+				return err
+			}
+		}
+		definition.Steps = steps
+	}
+	if len(missing) != 0 {
+		return &models.MissingFilesError{Files: missing}
+	}
+
+	if err := loadRequestFile(fsys, definition); err != nil {
+		return err
+	}
+	return loadCasesFile(fsys, definition)
+}
+
+// makeTests validates the definitions of the file and makes the tests of them and of their cases
+func makeTests(absPath, baseDir string, definitions []TestDefinition) ([]Test, error) {
+	baseDir = definitionsDir(absPath, baseDir)
+
+	var tests []Test
+	for _, definition := range definitions {
+		if err := validateType(&definition); err != nil {
+			return nil, fmt.Errorf("test %s: %s", definition.Name, err)
+		}
+		if err := validateSteps(definition); err != nil {
+			return nil, fmt.Errorf("test %s: %s", definition.Name, err)
+		}
+		if err := validateComparison(definition); err != nil {
+			return nil, fmt.Errorf("test %s: %s", definition.Name, err)
+		}
+		if err := validateConditional(definition.ConditionalRequest); err != nil {
+			return nil, fmt.Errorf("test %s: %s", definition.Name, err)
+		}
+		if err := validateRollback(definition); err != nil {
+			return nil, fmt.Errorf("test %s: %s", definition.Name, err)
+		}
+
 		if testCases, err := makeTestFromDefinition(absPath, definition); err != nil {
 			return nil, err
 		} else {
+			for i := range testCases {
+				testCases[i].BaseDir = baseDir
+			}
+			if len(definition.Steps) != 0 {
+				// the scenario has no cases, its only test runs the steps
+				if testCases[0].StepTests, err = makeSteps(absPath, baseDir, definition); err != nil {
+					return nil, err
+				}
+			}
 			tests = append(tests, testCases...)
 		}
 	}
@@ -46,6 +169,71 @@ func parseTestDefinitionFile(absPath string) ([]Test, error) {
 	return tests, nil
 }
 
+// validateExpressions validates the operands of the comparison expressions in the expected body
+func validateExpressions(body string) error {
+	if err := compare.ValidateRanges(body); err != nil {
+		return err
+	}
+	return compare.ValidateSimilarities(body)
+}
+
+// validateComparison validates the key normalization, the ranges and the similarity thresholds in the expected bodies
+func validateComparison(definition TestDefinition) error {
+	if _, err := compare.KeyNormalizer(definition.ComparisonParams.NormalizeKeys); err != nil {
+		return err
+	}
+	for _, body := range definition.ResponseTmpls {
+		if err := validateExpressions(body); err != nil {
+			return err
+		}
+	}
+	for _, bodies := range definition.ResponsesOneOf {
+		for _, body := range bodies {
+			if err := validateExpressions(body); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validateConditional validates the validators of the conditional request
+func validateConditional(conditional *models.Conditional) error {
+	if conditional == nil {
+		return nil
+	}
+	for _, validator := range conditional.Validators {
+		if validator != models.ValidatorETag && validator != models.ValidatorLastModified {
+			return fmt.Errorf("unknown validator %s of the conditional request, expected %s or %s",
+				validator, models.ValidatorETag, models.ValidatorLastModified)
+		}
+	}
+	return nil
+}
+
+// validateRollback rejects rollbackOnFailure of the test and of its steps: the fixtures are committed before the test
+// and the service writes through its own connections, so there is no transaction to roll back to a savepoint
+func validateRollback(definition TestDefinition) error {
+	if definition.RollbackOnFailure != nil {
+		return errors.New("rollbackOnFailure is not supported: rolling back to a savepoint requires " +
+			"the transactional fixture mode and a connection shared with the service, which gonkey doesn't have")
+	}
+	for i, step := range definition.Steps {
+		if err := validateRollback(step); err != nil {
+			return fmt.Errorf("step %d: %s", i+1, err)
+		}
+	}
+	return nil
+}
+
+// definitionsDir returns the directory the relative paths of the file are resolved against
+func definitionsDir(absPath, baseDir string) string {
+	if baseDir == "" {
+		return filepath.Dir(absPath)
+	}
+	return baseDir
+}
+
 func substituteArgs(tmpl string, args map[string]interface{}) (string, error) {
 	tmpl = gonkeyProtectTemplate.ReplaceAllString(tmpl, gonkeyProtectSubstitute)
 
@@ -77,6 +265,24 @@ func substituteArgsToMap(tmpl map[string]string, args map[string]interface{}) (m
 	return res, nil
 }
 
+// substituteArgsToParams substitutes the args into the string params of the DB query
+func substituteArgsToParams(params []interface{}, args map[string]interface{}) ([]interface{}, error) {
+	if params == nil {
+		return nil, nil
+	}
+	res := make([]interface{}, len(params))
+	for i, param := range params {
+		if s, ok := param.(string); ok {
+			var err error
+			if param, err = substituteArgs(s, args); err != nil {
+				return nil, err
+			}
+		}
+		res[i] = param
+	}
+	return res, nil
+}
+
 // Make tests from the given test definition.
 func makeTestFromDefinition(filePath string, testDefinition TestDefinition) ([]Test, error) {
 	var tests []Test
@@ -94,11 +300,26 @@ func makeTestFromDefinition(filePath string, testDefinition TestDefinition) ([]T
 		test.DbResponse = testDefinition.DbResponseTmpl
 		test.CombinedVariables = testDefinition.Variables
 
+		status, err := assertionsStatus(testDefinition)
+		if err != nil {
+			return nil, fmt.Errorf("test %s: %s", testDefinition.Name, err)
+		}
+		test.ResponseStatus = status
+
 		dbChecks := []models.DatabaseCheck{}
 		for _, check := range testDefinition.DatabaseChecks {
-			dbChecks = append(dbChecks, &dbCheck{query: check.DbQueryTmpl, response: check.DbResponseTmpl})
+			dbChecks = append(dbChecks, &dbCheck{
+				query:        check.DbQueryTmpl,
+				params:       check.DbQueryParams,
+				response:     check.DbResponseTmpl,
+				responseFile: check.ExpectedDbFile,
+			})
 		}
-		test.DbChecks = dbChecks
+		stateChecks, err := stateChecks(testDefinition.ExpectedState)
+		if err != nil {
+			return nil, fmt.Errorf("test %s: %s", testDefinition.Name, err)
+		}
+		test.DbChecks = append(dbChecks, stateChecks...)
 
 		return append(tests, test), nil
 	}
@@ -119,10 +340,19 @@ func makeTestFromDefinition(filePath string, testDefinition TestDefinition) ([]T
 		combinedVariables = testDefinition.Variables
 	}
 
+	status, err := assertionsStatus(testDefinition)
+	if err != nil {
+		return nil, fmt.Errorf("test %s: %s", testDefinition.Name, err)
+	}
+
 	// produce as many tests as cases defined
 	for caseIdx, testCase := range testDefinition.Cases {
-		test := Test{TestDefinition: testDefinition, Filename: filePath}
+		test := Test{TestDefinition: testDefinition, Filename: filePath, DefinitionName: testDefinition.Name}
 		test.Name = fmt.Sprintf("%s #%d", test.Name, caseIdx+1)
+		if testCase.Name != "" {
+			test.Name = fmt.Sprintf("%s #%s", testDefinition.Name, testCase.Name)
+		}
+		test.ResponseStatus = status
 
 		if testCase.Description != "" {
 			test.Description = testCase.Description
@@ -199,10 +429,18 @@ func makeTestFromDefinition(filePath string, testDefinition TestDefinition) ([]T
 		if err != nil {
 			return nil, err
 		}
+		test.DbQueryParamsVal, err = substituteArgsToParams(testDefinition.DbQueryParamsVal, testCase.DbQueryArgs)
+		if err != nil {
+			return nil, err
+		}
 
+		// the variables of the previous cases are kept unless redefined, the merged map is a new one
+		// so that the tests of the previous cases keep their values
+		caseVariables := make(map[string]string, len(testCase.Variables))
 		for key, value := range testCase.Variables {
-			combinedVariables[key] = value.(string)
+			caseVariables[key] = value.(string)
 		}
+		combinedVariables = mergeStrings(combinedVariables, caseVariables)
 		test.CombinedVariables = combinedVariables
 
 		// compile DbResponse
@@ -231,7 +469,16 @@ func makeTestFromDefinition(filePath string, testDefinition TestDefinition) ([]T
 				return nil, err
 			}
 
-			c := &dbCheck{query: query}
+			params, err := substituteArgsToParams(check.DbQueryParams, testCase.DbQueryArgs)
+			if err != nil {
+				return nil, err
+			}
+
+			c := &dbCheck{query: query, params: params, responseFile: check.ExpectedDbFile}
+			// the empty response asserts that the query returns no rows, it must not become the missing one
+			if check.DbResponseTmpl != nil {
+				c.response = make([]string, 0, len(check.DbResponseTmpl))
+			}
 			for _, tpl := range check.DbResponseTmpl {
 				responseString, err := substituteArgs(tpl, testCase.DbResponseArgs)
 				if err != nil {
@@ -244,7 +491,11 @@ func makeTestFromDefinition(filePath string, testDefinition TestDefinition) ([]T
 			dbChecks = append(dbChecks, c)
 		}
 
-		test.DbChecks = dbChecks
+		stateChecks, err := stateChecks(testDefinition.ExpectedState)
+		if err != nil {
+			return nil, fmt.Errorf("test %s: %s", testDefinition.Name, err)
+		}
+		test.DbChecks = append(dbChecks, stateChecks...)
 
 		tests = append(tests, test)
 	}
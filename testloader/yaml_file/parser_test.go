@@ -4,7 +4,14 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lamoda/gonkey/files"
+	"github.com/lamoda/gonkey/models"
 )
 
 var testsYAMLData = `
@@ -65,7 +72,7 @@ func TestParseTestsWithCases(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	tests, err := parseTestDefinitionFile(tmpfile.Name())
+	tests, err := parseTestDefinitionFile(files.OS, tmpfile.Name(), "")
 	if err != nil {
 		t.Error(err)
 	}
@@ -73,3 +80,264 @@ func TestParseTestsWithCases(t *testing.T) {
 		t.Errorf("wait len(tests) == 2, got len(tests) == %d", len(tests))
 	}
 }
+
+func TestParseTestsWithAnchors(t *testing.T) {
+	tests, err := parseTestDefinitionFile(files.OS, "testdata/anchors.yaml", "")
+	require.NoError(t, err)
+	require.Len(t, tests, 2)
+
+	order := `{"id": 1, "status": "created"}`
+	assert.Equal(t, "create order", tests[0].GetName())
+	assert.Equal(t, order, tests[0].GetRequest())
+	assert.Equal(t, map[int]string{200: order}, tests[0].GetResponses())
+	assert.Equal(t, map[string]string{
+		"Content-Type":  "application/json",
+		"Authorization": "Bearer token",
+	}, tests[0].Headers())
+
+	assert.Equal(t, map[string]string{
+		"Content-Type":  "application/json",
+		"Authorization": "Bearer token",
+		"X-Request-Id":  "1",
+	}, tests[1].Headers())
+}
+
+func TestParseTestsWithUnknownAnchor(t *testing.T) {
+	_, err := parseTestDefinitionFile(files.OS, "testdata/anchors-unknown.yaml", "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "alias *order refers to an anchor which is not defined")
+}
+
+func TestParseTestsWithInvalidRange(t *testing.T) {
+	_, err := parseTestDefinitionFile(files.OS, "testdata/invalid-range.yaml", "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `test invalid range: range $between:1,x: operand "x" is not a number`)
+}
+
+func TestParseTestsWithInvalidSimilarity(t *testing.T) {
+	_, err := parseTestDefinitionFile(files.OS, "testdata/invalid-similarity.yaml", "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `test invalid similarity: similarity threshold "90" must be a number from 0 to 1`)
+}
+
+func TestParseTestsWithInvalidConditionalRequest(t *testing.T) {
+	_, err := parseTestDefinitionFile(files.OS, "testdata/invalid-conditional.yaml", "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(),
+		"test invalid validator: unknown validator expires of the conditional request, expected etag or lastModified")
+}
+
+func TestParseTestsWithRollbackOnFailure(t *testing.T) {
+	_, err := parseTestDefinitionFile(files.OS, "testdata/rollback-on-failure.yaml", "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "test create and read: step 2: rollbackOnFailure is not supported: "+
+		"rolling back to a savepoint requires the transactional fixture mode and a connection shared with the service")
+}
+
+func TestParseTestsWithResponseStatus(t *testing.T) {
+	tests, err := parseTestDefinitionFile(files.OS, "testdata/response-status.yaml", "")
+	require.NoError(t, err)
+	require.Len(t, tests, 3)
+
+	assert.Equal(t, models.ResponseStatus{"200"}, tests[0].GetResponseStatus())
+	assert.Equal(t, models.ResponseStatus{"2xx"}, tests[1].GetResponseStatus())
+	assert.Equal(t, models.ResponseStatus{"200", "201"}, tests[2].GetResponseStatus())
+	assert.True(t, tests[1].GetResponseStatus().Match(204))
+	assert.False(t, tests[1].GetResponseStatus().Match(302))
+}
+
+func TestParseTestsWithDbQueryParams(t *testing.T) {
+	tests, err := parseTestDefinitionFile(files.OS, "testdata/db-query-params.yaml", "")
+	require.NoError(t, err)
+	require.Len(t, tests, 2)
+
+	assert.Equal(t, []interface{}{"paid", 100}, tests[0].DbQueryParams())
+	checks := tests[0].GetDatabaseChecks()
+	require.Len(t, checks, 1)
+	assert.Equal(t, []interface{}{"{{ $orderId }}"}, checks[0].DbQueryParams())
+
+	checks = tests[1].GetDatabaseChecks()
+	require.Len(t, checks, 1)
+	assert.Equal(t, []interface{}{"paid"}, checks[0].DbQueryParams())
+}
+
+func TestParseTestsWithNoDbRows(t *testing.T) {
+	tests, err := parseTestDefinitionFile(files.OS, "testdata/db-no-rows.yaml", "")
+	require.NoError(t, err)
+	require.Len(t, tests, 2)
+
+	// the empty responses are kept to assert that the queries return no rows
+	for _, test := range tests {
+		assert.NotNil(t, test.DbResponseJson(), test.GetName())
+		assert.Empty(t, test.DbResponseJson(), test.GetName())
+	}
+	checks := tests[1].GetDatabaseChecks()
+	require.Len(t, checks, 1)
+	assert.NotNil(t, checks[0].DbResponseJson())
+	assert.Empty(t, checks[0].DbResponseJson())
+}
+
+func TestParseTestsWithExpectedState(t *testing.T) {
+	tests, err := parseTestDefinitionFile(files.OS, "testdata/expected-state.yaml", "")
+	require.NoError(t, err)
+	require.Len(t, tests, 1)
+
+	checks := tests[0].GetDatabaseChecks()
+	require.Len(t, checks, 3)
+	assert.Equal(t, "SELECT count(*) AS count FROM events", checks[0].DbQueryString())
+	assert.Equal(t, "SELECT * FROM orders ORDER BY 1", checks[1].DbQueryString())
+	assert.Equal(t, []string{
+		`{"id":1,"meta":{"source":"api"},"status":"paid"}`,
+		`{"id":2,"status":"new"}`,
+	}, checks[1].DbResponseJson())
+	assert.Equal(t, "SELECT * FROM payments ORDER BY 1", checks[2].DbQueryString())
+	assert.Equal(t, []string{}, checks[2].DbResponseJson())
+}
+
+func TestParseTestsWithAssertions(t *testing.T) {
+	tests, err := parseTestDefinitionFile(files.OS, "testdata/assertions.yaml", "")
+	require.NoError(t, err)
+	require.Len(t, tests, 2)
+
+	assert.Equal(t, models.ResponseStatus{"2xx", "400", "404"}, tests[0].GetResponseStatus())
+	require.Len(t, tests[0].GetAssertions(), 2)
+	assert.Equal(t, `{"error": "not found"}`, tests[0].GetAssertions()[1].Response)
+	assert.Equal(t, models.ResponseStatus{"2xx", "4xx"}, tests[1].GetResponseStatus())
+}
+
+func TestParseTestsWithInvalidAssertions(t *testing.T) {
+	_, err := parseTestDefinitionFile(files.OS, "testdata/assertions-invalid.yaml", "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "test no when: assertions block #1 has no when")
+}
+
+func TestParseTestsWithInvalidResponseBodySize(t *testing.T) {
+	_, err := parseTestDefinitionFile(files.OS, "testdata/response-body-size-invalid.yaml", "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid body size bounds [100, 10], min is greater than max")
+}
+
+func TestParseTestsWithInvalidResponseStatus(t *testing.T) {
+	_, err := parseTestDefinitionFile(files.OS, "testdata/response-status-invalid.yaml", "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `invalid response status "2x"`)
+}
+
+func TestParseTestsWithRequestFile(t *testing.T) {
+	tests, err := parseTestDefinitionFile(files.OS, "testdata/request-file/request-file.yaml", "")
+	require.NoError(t, err)
+	require.Len(t, tests, 2)
+
+	expectedBody := `{
+  "customer": "{{ $customer }}",
+  "items": [{"sku": "sku-1", "quantity": 1}]
+}
+`
+	assert.Equal(t, expectedBody, tests[0].GetRequest())
+	assert.Equal(t, "application/json", tests[0].ContentType())
+	assert.Equal(t, "application/vnd.api+json", tests[1].ContentType())
+}
+
+func TestParseTestsWithCasesFile(t *testing.T) {
+	tests, err := parseTestDefinitionFile(files.OS, "testdata/cases-file/orders.yaml", "")
+	require.NoError(t, err)
+	require.Len(t, tests, 5)
+
+	assert.Equal(t, "create order #alice", tests[0].GetName())
+	assert.Equal(t, map[string]string{
+		"customer": "alice",
+		"comment":  "fragile, handle with care",
+		"total":    "10",
+	}, tests[0].GetCombinedVariables())
+	assert.Equal(t, "create order #bob", tests[1].GetName())
+	assert.Equal(t, "say \"hi\"\nat the door", tests[1].GetCombinedVariables()["comment"])
+	assert.Equal(t, "20", tests[1].GetCombinedVariables()["total"])
+
+	// the cases of the file follow the ones of the test
+	assert.Equal(t, []string{"get order #1", "get order #2", "get order #3"},
+		[]string{tests[2].GetName(), tests[3].GetName(), tests[4].GetName()})
+	assert.Equal(t, map[string]string{"id": "100"}, tests[2].GetCombinedVariables())
+	assert.Equal(t, "bob", tests[4].GetCombinedVariables()["customer"])
+}
+
+func TestParseTestsWithInvalidCasesFile(t *testing.T) {
+	_, err := parseTestDefinitionFile(files.OS, "testdata/cases-file/duplicates.yaml", "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "test create order: cases file ")
+	assert.Contains(t, err.Error(), `duplicates.csv has a row with an empty or duplicate name "alice"`)
+}
+
+func TestParseTestsWithRecursiveRequestFile(t *testing.T) {
+	_, err := parseTestDefinitionFile(files.OS, "testdata/request-file/recursive.yaml", "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "too deep includes")
+}
+
+func TestLoadTestsWithMissingFiles(t *testing.T) {
+	dir := filepath.Join("testdata", "missing-files")
+	_, err := NewLoader(dir).Load()
+	require.Error(t, err)
+
+	missingErr, ok := err.(*models.MissingFilesError)
+	require.True(t, ok, err.Error())
+	// the files of all the tests of all the files are reported
+	assert.Equal(t, []models.MissingFile{
+		{
+			Field:    "include",
+			Path:     filepath.Join(dir, "requests", "items.json"),
+			Test:     "create order",
+			TestFile: dir + "/orders.yaml",
+		},
+		{
+			Field:    "casesFile",
+			Path:     filepath.Join(dir, "orders.csv"),
+			Test:     "get orders",
+			TestFile: dir + "/orders.yaml",
+		},
+		{
+			Field:    "requestFile",
+			Path:     filepath.Join(dir, "requests", "user.json"),
+			Test:     "post user",
+			TestFile: dir + "/users.yaml",
+		},
+	}, missingErr.Files)
+	assert.Contains(t, err.Error(), "the tests reference 3 missing files:\n  include "+
+		filepath.Join(dir, "requests", "items.json")+" of test create order ("+dir+"/orders.yaml)\n")
+}
+
+func TestParseTestsWithRelativePaths(t *testing.T) {
+	dir := filepath.Join("testdata", "relative-paths")
+	tests, err := parseTestDefinitionFile(files.OS, filepath.Join(dir, "relative-paths.yaml"), "")
+	require.NoError(t, err)
+	require.Len(t, tests, 1)
+
+	test := tests[0]
+	assert.Equal(t, dir, test.GetBaseDir())
+	assert.Equal(t, "{\"id\": 1}\n", test.GetRequest())
+	assert.Equal(t, map[string]string{
+		"invoice":  filepath.Join(dir, "bodies", "invoice.pdf"),
+		"absolute": "/tmp/absolute.pdf",
+	}, test.GetForm().Files)
+	goldenFile, _ := test.GetResponseBodyFile(200)
+	assert.Equal(t, filepath.Join(dir, "golden", "order.json"), goldenFile)
+	assert.Equal(t, filepath.Join(dir, "scripts", "before.sh"), test.BeforeScriptPath())
+	// a bare command is looked up in PATH and a path with a variable is left for the substitution
+	assert.Equal(t, "true", test.AfterRequestScriptPath())
+	assert.Equal(t, "{{ $dbFiles }}/orders.json", test.GetDatabaseChecks()[0].DbResponseFile())
+}
+
+func TestParseTestsWithLegacyRelativePaths(t *testing.T) {
+	// the paths relative to the working directory are kept if there are no such files next to the test file
+	tests, err := parseTestDefinitionFile(files.OS, filepath.Join("testdata", "relative-paths", "legacy-paths.yaml"), "")
+	require.NoError(t, err)
+	require.Len(t, tests, 1)
+
+	assert.Equal(t, "{\"id\": 1}\n", tests[0].GetRequest())
+	assert.Equal(t, map[string]string{"invoice": "testdata/relative-paths/bodies/order.json"}, tests[0].GetForm().Files)
+}
+
+func TestParseTestsWithBaseDir(t *testing.T) {
+	_, err := parseTestDefinitionFile(files.OS, filepath.Join("testdata", "relative-paths", "relative-paths.yaml"), "testdata")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), filepath.Join("testdata", "bodies", "order.json"))
+}
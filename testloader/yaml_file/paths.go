@@ -0,0 +1,84 @@
+package yaml_file
+
+import (
+	"strings"
+
+	"github.com/lamoda/gonkey/files"
+	"github.com/lamoda/gonkey/models"
+)
+
+// resolvePaths makes the relative paths of the files referenced by the definition relative to baseDir,
+// which is the directory of the test file unless it's overridden by the loader, see files.ResolvePath
+func resolvePaths(fsys files.FS, definition *TestDefinition, baseDir string) {
+	definition.RequestFile = resolvePath(fsys, baseDir, definition.RequestFile)
+	definition.RequestSnapshotFile = resolvePath(fsys, baseDir, definition.RequestSnapshotFile)
+	definition.ExpectedDbFile = resolvePath(fsys, baseDir, definition.ExpectedDbFile)
+	definition.BeforeScriptParams.PathTmpl = resolveScriptPath(fsys, baseDir, definition.BeforeScriptParams.PathTmpl)
+	definition.AfterRequestScriptParams.PathTmpl = resolveScriptPath(fsys, baseDir, definition.AfterRequestScriptParams.PathTmpl)
+
+	// the maps and slices are copied as they may be shared with other tests by YAML aliases
+	if definition.Fuzz != nil {
+		fuzz := *definition.Fuzz
+		fuzz.Schema = resolvePath(fsys, baseDir, fuzz.Schema)
+		definition.Fuzz = &fuzz
+	}
+
+	if definition.CasesFile != nil {
+		casesFile := *definition.CasesFile
+		casesFile.Path = resolvePath(fsys, baseDir, casesFile.Path)
+		definition.CasesFile = &casesFile
+	}
+
+	if definition.ResponseBodyFiles != nil {
+		files := make(map[int]string, len(definition.ResponseBodyFiles))
+		for code, file := range definition.ResponseBodyFiles {
+			files[code] = resolvePath(fsys, baseDir, file)
+		}
+		definition.ResponseBodyFiles = files
+	}
+
+	if definition.DatabaseChecks != nil {
+		checks := make([]DatabaseCheck, len(definition.DatabaseChecks))
+		for i, check := range definition.DatabaseChecks {
+			check.ExpectedDbFile = resolvePath(fsys, baseDir, check.ExpectedDbFile)
+			checks[i] = check
+		}
+		definition.DatabaseChecks = checks
+	}
+
+	if definition.Form != nil && (definition.Form.Files != nil || definition.Form.Parts != nil) {
+		form := *definition.Form
+		if definition.Form.Files != nil {
+			form.Files = make(map[string]string, len(definition.Form.Files))
+			for name, file := range definition.Form.Files {
+				form.Files[name] = resolvePath(fsys, baseDir, file)
+			}
+		}
+		if definition.Form.Parts != nil {
+			form.Parts = make([]models.FormPart, len(definition.Form.Parts))
+			for i, part := range definition.Form.Parts {
+				part.File = resolvePath(fsys, baseDir, part.File)
+				form.Parts[i] = part
+			}
+		}
+		definition.Form = &form
+	}
+}
+
+// resolvePath joins the relative path with baseDir, the paths starting with a variable
+// are left as is since the variable may hold an absolute path
+func resolvePath(fsys files.FS, baseDir, path string) string {
+	if strings.HasPrefix(path, "{{") {
+		return path
+	}
+	return files.ResolvePath(fsys, baseDir, path)
+}
+
+// resolveScriptPath resolves the script path only if it has a directory,
+// a bare name is a command looked up in PATH
+func resolveScriptPath(fsys files.FS, baseDir, path string) string {
+	if !strings.ContainsAny(path, `/\`) {
+		return path
+	}
+	return resolvePath(fsys, baseDir, path)
+}
@@ -0,0 +1,41 @@
+package yaml_file
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Profiles are the named sets of variables shared by the tests, a test loads a set with useProfile,
+// the variables of the test take priority over the values of the profile
+type Profiles map[string]map[string]string
+
+// LoadProfiles reads profiles from the YAML file, the keys of the file are the names of the profiles
+func LoadProfiles(path string) (Profiles, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read variable profiles: %s", err)
+	}
+
+	var profiles Profiles
+	if err := yaml.UnmarshalStrict(data, &profiles); err != nil {
+		return nil, fmt.Errorf("unable to parse variable profiles %s: %s", path, err)
+	}
+	return profiles, nil
+}
+
+func (p Profiles) apply(test *Test) error {
+	if test.UseProfile == "" {
+		return nil
+	}
+	profile, ok := p[test.UseProfile]
+	if !ok {
+		return fmt.Errorf("test %s uses unknown profile %s", test.Name, test.UseProfile)
+	}
+
+	// the profile is copied, the tests must not share the maps of the variables
+	test.Variables = mergeStrings(mergeStrings(nil, profile), test.Variables)
+	test.CombinedVariables = mergeStrings(mergeStrings(nil, profile), test.CombinedVariables)
+	return nil
+}
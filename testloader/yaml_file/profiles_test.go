@@ -0,0 +1,40 @@
+package yaml_file
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoaderAppliesProfiles(t *testing.T) {
+	profiles, err := LoadProfiles("testdata/profiles/profiles.yaml")
+	require.NoError(t, err)
+
+	loader := NewLoader("testdata/profiles/tests.yaml")
+	loader.SetProfiles(profiles)
+
+	tests, err := loader.Load()
+	require.NoError(t, err)
+	require.Len(t, tests, 2)
+
+	assert.Equal(t, map[string]string{"userId": "42", "plan": "premium", "discount": "20"}, tests[0].GetCombinedVariables())
+	assert.Equal(t, map[string]string{"userId": "42", "plan": "trial", "discount": "10"}, tests[1].GetCombinedVariables())
+	assert.Equal(t, "10", profiles["premiumUser"]["discount"])
+}
+
+func TestLoaderUnknownProfile(t *testing.T) {
+	profiles, err := LoadProfiles("testdata/profiles/profiles.yaml")
+	require.NoError(t, err)
+
+	loader := NewLoader("testdata/profiles/unknown-profile.yaml")
+	loader.SetProfiles(profiles)
+
+	_, err = loader.Load()
+	assert.EqualError(t, err, "test test with unknown profile uses unknown profile basicUser")
+}
+
+func TestLoadProfilesMissingFile(t *testing.T) {
+	_, err := LoadProfiles("testdata/profiles/missing.yaml")
+	assert.Error(t, err)
+}
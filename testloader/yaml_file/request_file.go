@@ -0,0 +1,84 @@
+package yaml_file
+
+import (
+	"fmt"
+	"mime"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/lamoda/gonkey/files"
+)
+
+// maxIncludeDepth protects from the files including each other
+const maxIncludeDepth = 10
+
+var includeRx = regexp.MustCompile(`{{\s*include\s+"([^"]+)"\s*}}`)
+
+// loadRequestFile reads the request body from requestFile of the definition
+// and sets the default Content-Type according to the file extension
+func loadRequestFile(fsys files.FS, definition *TestDefinition) error {
+	if definition.RequestFile == "" {
+		return nil
+	}
+	if definition.RequestTmpl != "" {
+		return fmt.Errorf("test %s: `request` and `requestFile` can't be used together", definition.Name)
+	}
+
+	body, err := readRequestFile(fsys, definition.RequestFile, 0)
+	if err != nil {
+		return fmt.Errorf("test %s: %s", definition.Name, err)
+	}
+	definition.RequestTmpl = body
+
+	if _, ok := definition.HeadersVal["Content-Type"]; ok {
+		return nil
+	}
+	contentType := mime.TypeByExtension(filepath.Ext(definition.RequestFile))
+	if contentType == "" {
+		return nil
+	}
+	headers := make(map[string]string, len(definition.HeadersVal)+1)
+	for k, v := range definition.HeadersVal {
+		headers[k] = v
+	}
+	headers["Content-Type"] = contentType
+	definition.HeadersVal = headers
+
+	return nil
+}
+
+// readRequestFile reads the file replacing {{ include "path" }} with the content of the included files,
+// the paths of the included files are relative to the including file
+func readRequestFile(fsys files.FS, path string, depth int) (string, error) {
+	if depth > maxIncludeDepth {
+		return "", fmt.Errorf("too deep includes in %s, probably the files include each other", path)
+	}
+
+	data, err := fsys.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("unable to read request file: %s", err)
+	}
+
+	var includeErr error
+	body := includeRx.ReplaceAllStringFunc(string(data), func(include string) string {
+		if includeErr != nil {
+			return include
+		}
+		includePath := includeRx.FindStringSubmatch(include)[1]
+		if !filepath.IsAbs(includePath) {
+			includePath = filepath.Join(filepath.Dir(path), includePath)
+		}
+		content, err := readRequestFile(fsys, includePath, depth+1)
+		if err != nil {
+			includeErr = err
+			return include
+		}
+		return strings.TrimRight(content, "\n")
+	})
+	if includeErr != nil {
+		return "", includeErr
+	}
+
+	return body, nil
+}
@@ -0,0 +1,137 @@
+package yaml_file
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/lamoda/gonkey/models"
+)
+
+// AlwaysRunTag marks the tests which are run regardless of sampling
+const AlwaysRunTag = "always-run"
+
+// ParseSampleRate parses the share of the tests to run given as a percentage ("10%")
+// or a fraction ("0.1")
+func ParseSampleRate(value string) (float64, error) {
+	value = strings.TrimSpace(value)
+	percent := strings.HasSuffix(value, "%")
+
+	rate, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid sample %q, expected a percentage (10%%) or a fraction (0.1)", value)
+	}
+	if percent {
+		rate /= 100
+	}
+	if rate <= 0 || rate > 1 {
+		return 0, fmt.Errorf("invalid sample %q, it should be greater than 0 and not greater than 100%%", value)
+	}
+	return rate, nil
+}
+
+// SetSample makes the loader select the given share of the tests, the selection is determined
+// by the seed, so the same seed selects the same tests. The tests tagged with AlwaysRunTag
+// are always selected and not counted.
+func (l *YamlFileLoader) SetSample(rate float64, seed int64) {
+	l.sampleRate = rate
+	l.sampleSeed = seed
+}
+
+// sample selects ceil(rate * N) of the tests with the lowest hashes of the seed, file and name
+// and the tests they depend on, the order of the tests is kept
+func sample(tests []Test, rate float64, seed int64) []Test {
+	type candidate struct {
+		index int
+		hash  uint64
+	}
+
+	var candidates []candidate
+	selected := make([]bool, len(tests))
+	for i := range tests {
+		if hasTag(&tests[i], AlwaysRunTag) {
+			selected[i] = true
+			continue
+		}
+		candidates = append(candidates, candidate{index: i, hash: sampleHash(&tests[i], seed)})
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].hash != candidates[j].hash {
+			return candidates[i].hash < candidates[j].hash
+		}
+		return candidates[i].index < candidates[j].index
+	})
+	count := int(math.Ceil(rate * float64(len(candidates))))
+	for _, c := range candidates[:count] {
+		selected[c.index] = true
+	}
+	selectDependencies(tests, selected)
+
+	res := make([]Test, 0, count)
+	for i, test := range tests {
+		if selected[i] {
+			res = append(res, test)
+		}
+	}
+	return res
+}
+
+// selectDependencies selects the tests the selected ones depend on in turn, so the sampled tests
+// aren't skipped for their dependencies
+func selectDependencies(tests []Test, selected []bool) {
+	queue := make([]int, 0, len(tests))
+	for i := range tests {
+		if selected[i] {
+			queue = append(queue, i)
+		}
+	}
+	for len(queue) != 0 {
+		test := &tests[queue[0]]
+		queue = queue[1:]
+		for _, dependency := range test.GetDependsOn() {
+			for _, j := range dependencyIndexes(tests, dependency) {
+				if !selected[j] {
+					selected[j] = true
+					queue = append(queue, j)
+				}
+			}
+		}
+	}
+}
+
+// dependencyIndexes returns the indexes of the tests named the dependency or, if there are none,
+// of the cases of the test named so
+func dependencyIndexes(tests []Test, dependency string) []int {
+	var exact, cases []int
+	for i := range tests {
+		switch name := tests[i].GetName(); {
+		case name == dependency:
+			exact = append(exact, i)
+		case models.IsCaseOf(name, dependency):
+			cases = append(cases, i)
+		}
+	}
+	if len(exact) != 0 {
+		return exact
+	}
+	return cases
+}
+
+func sampleHash(test *Test, seed int64) uint64 {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%d\x00%s\x00%s\x00%s", seed, test.GetFileName(), test.GetName(), test.GetDescription())
+	return h.Sum64()
+}
+
+func hasTag(test *Test, tag string) bool {
+	for _, t := range test.GetTags() {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
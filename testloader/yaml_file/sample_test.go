@@ -0,0 +1,92 @@
+package yaml_file
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lamoda/gonkey/models"
+)
+
+func TestParseSampleRate(t *testing.T) {
+	for value, expected := range map[string]float64{"10%": 0.1, "0.25": 0.25, " 100% ": 1} {
+		rate, err := ParseSampleRate(value)
+		require.NoError(t, err, value)
+		assert.InDelta(t, expected, rate, 1e-9, value)
+	}
+
+	for _, value := range []string{"", "ten", "0", "-5%", "150%", "1.5"} {
+		_, err := ParseSampleRate(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func loadSample(t *testing.T, rate float64, seed int64) []string {
+	loader := NewLoader("testdata/sample")
+	loader.SetSample(rate, seed)
+	tests, err := loader.Load()
+	require.NoError(t, err)
+	return testNames(tests)
+}
+
+func testNames(tests []models.TestInterface) []string {
+	names := make([]string, len(tests))
+	for i, test := range tests {
+		names[i] = test.GetName()
+	}
+	return names
+}
+
+func TestSample(t *testing.T) {
+	names := loadSample(t, 0.1, 42)
+
+	// 10% of 20 sampled tests and the test which is always run
+	require.Len(t, names, 3)
+	assert.Equal(t, "health check", names[0])
+
+	assert.Equal(t, names, loadSample(t, 0.1, 42), "the same seed should select the same tests")
+	assert.NotEqual(t, names, loadSample(t, 0.1, 7), "another seed should select other tests")
+	assert.Len(t, loadSample(t, 0.5, 42), 11)
+	assert.Len(t, loadSample(t, 1, 42), 21)
+}
+
+func TestSampleSelectsDependencies(t *testing.T) {
+	pulled := false
+	for seed := int64(0); seed < 20; seed++ {
+		loader := NewLoader("testdata/sample-depends")
+		loader.SetSample(0.1, seed)
+		tests, err := loader.Load()
+		require.NoError(t, err)
+
+		// the cases of the test the sampled ones depend on are selected too
+		names := testNames(tests)
+		if len(names) > 2 {
+			pulled = true
+			assert.Equal(t, []string{"create order #1", "create order #2"}, names[:2], "seed %d", seed)
+		}
+	}
+	assert.True(t, pulled, "the dependencies should be selected for some of the seeds")
+}
+
+func TestIsFilteredOut(t *testing.T) {
+	loader := NewLoader("testdata/filtered")
+	loader.SetFileFilter("read.yaml")
+	tests, err := loader.Load()
+	require.NoError(t, err)
+	require.Equal(t, []string{"read order"}, testNames(tests))
+
+	assert.True(t, loader.IsFilteredOut("create order"))
+	assert.True(t, loader.IsFilteredOut("create order #express"))
+	assert.True(t, loader.IsFilteredOut("read order"))
+	assert.False(t, loader.IsFilteredOut("craete order"))
+
+	loader = NewLoader("testdata/sample-depends")
+	loader.SetSample(0.1, 42)
+	tests, err = loader.Load()
+	require.NoError(t, err)
+	for _, name := range []string{"read order 1", "read order 2", "read order 3"} {
+		assert.True(t, loader.IsFilteredOut(name), name)
+	}
+	assert.False(t, loader.IsFilteredOut("read order 11"))
+}
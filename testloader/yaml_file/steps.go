@@ -0,0 +1,79 @@
+package yaml_file
+
+import (
+	"errors"
+	"fmt"
+)
+
+// validateSteps validates the scenario: the requests are sent by its steps, the fixtures
+// and the status belong to the scenario as a whole
+func validateSteps(definition TestDefinition) error {
+	if len(definition.Steps) == 0 {
+		return nil
+	}
+
+	switch {
+	case definition.Type != "":
+		return fmt.Errorf("scenarios can't be %s tests", definition.Type)
+	case definition.Method != "" || definition.RequestURL != "" || definition.RequestTmpl != "" ||
+		definition.RequestBuilder != "" || definition.Form != nil || definition.ResponseTmpls != nil:
+		return errors.New("the requests of a scenario are sent by its steps, " +
+			"`method`, `path`, `request`, `requestFile`, `requestBuilder`, `form` and `response` can't be used with `steps`")
+	case definition.MocksDefinition != nil:
+		return errors.New("the mocks of a scenario are set by its steps")
+	case len(definition.Cases) != 0:
+		return errors.New("scenarios can't have cases")
+	case definition.Repeat != nil:
+		return errors.New("scenarios can't be repeated")
+	}
+
+	for i, step := range definition.Steps {
+		var err error
+		switch {
+		case len(step.Steps) != 0:
+			err = errors.New("steps can't have steps")
+		case step.FixtureFiles != nil:
+			err = errors.New("the fixtures are loaded once for the whole scenario, set them on the scenario")
+		case step.Status != "":
+			err = errors.New("the status is set on the scenario")
+		case step.Type != "" || step.Fuzz != nil:
+			err = errors.New("steps can't be fuzz tests")
+		case len(step.Cases) != 0:
+			err = errors.New("steps can't have cases")
+		case step.Repeat != nil:
+			err = errors.New("steps can't be repeated")
+		default:
+			if err = validateComparison(step); err == nil {
+				err = validateConditional(step.ConditionalRequest)
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("step %d: %s", i+1, err)
+		}
+	}
+	return nil
+}
+
+// makeSteps makes the tests of the steps of the scenario named after it, the steps without tags
+// have the tags of the scenario
+func makeSteps(absPath, baseDir string, scenario TestDefinition) ([]Test, error) {
+	var steps []Test
+	for i, definition := range scenario.Steps {
+		name := definition.Name
+		if name == "" {
+			name = fmt.Sprintf("step %d", i+1)
+		}
+		definition.Name = fmt.Sprintf("%s [step %d of %d: %s]", scenario.Name, i+1, len(scenario.Steps), name)
+		if definition.Tags == nil {
+			definition.Tags = scenario.Tags
+		}
+
+		tests, err := makeTestFromDefinition(absPath, definition)
+		if err != nil {
+			return nil, err
+		}
+		tests[0].BaseDir = baseDir
+		steps = append(steps, tests[0])
+	}
+	return steps, nil
+}
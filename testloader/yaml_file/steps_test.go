@@ -0,0 +1,97 @@
+package yaml_file
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lamoda/gonkey/files"
+	"github.com/lamoda/gonkey/models"
+)
+
+func TestParseTestsWithSteps(t *testing.T) {
+	tests, err := parseTestDefinitionFile(files.OS, "testdata/steps.yaml", "")
+	require.NoError(t, err)
+	require.Len(t, tests, 1)
+
+	scenario := tests[0]
+	assert.Equal(t, []string{"customers"}, scenario.Fixtures())
+	assert.Equal(t, map[string]string{"customer": "alice"}, scenario.GetCombinedVariables())
+
+	steps := scenario.GetSteps()
+	require.Len(t, steps, 2)
+	assert.Equal(t, "order lifecycle [step 1 of 2: create]", steps[0].GetName())
+	assert.Equal(t, "{\"sku\": \"sku-1\", \"quantity\": 1}\n", steps[0].GetRequest())
+	assert.Equal(t, []string{"orders"}, steps[0].GetTags())
+	assert.Equal(t, "testdata", steps[0].GetBaseDir())
+	assert.Empty(t, steps[0].Fixtures())
+
+	assert.Equal(t, "order lifecycle [step 2 of 2: step 2]", steps[1].GetName())
+	assert.Equal(t, "/orders/{{ $orderId }}", steps[1].Path())
+	assert.Equal(t, []string{"read"}, steps[1].GetTags())
+
+	// the steps are copies, the scenario isn't changed through them
+	steps[0].SetStatus("skipped")
+	assert.Empty(t, scenario.GetSteps()[0].GetStatus())
+}
+
+func TestValidateStepsErrors(t *testing.T) {
+	step := TestDefinition{Method: "GET", RequestURL: "/orders"}
+	tests := []struct {
+		name       string
+		definition TestDefinition
+		err        string
+	}{
+		{
+			name:       "fuzz scenario",
+			definition: TestDefinition{Type: models.TestTypeFuzz, Steps: []TestDefinition{step}},
+			err:        "scenarios can't be fuzz tests",
+		},
+		{
+			name:       "request",
+			definition: TestDefinition{Method: "GET", Steps: []TestDefinition{step}},
+			err: "the requests of a scenario are sent by its steps, " +
+				"`method`, `path`, `request`, `requestFile`, `requestBuilder`, `form` and `response` can't be used with `steps`",
+		},
+		{
+			name:       "mocks",
+			definition: TestDefinition{MocksDefinition: map[string]interface{}{}, Steps: []TestDefinition{step}},
+			err:        "the mocks of a scenario are set by its steps",
+		},
+		{
+			name:       "cases",
+			definition: TestDefinition{Cases: []CaseData{{}}, Steps: []TestDefinition{step}},
+			err:        "scenarios can't have cases",
+		},
+		{
+			name:       "step fixtures",
+			definition: TestDefinition{Steps: []TestDefinition{step, {FixtureFiles: []string{"orders"}}}},
+			err:        "step 2: the fixtures are loaded once for the whole scenario, set them on the scenario",
+		},
+		{
+			name:       "step status",
+			definition: TestDefinition{Steps: []TestDefinition{{Status: "skipped"}}},
+			err:        "step 1: the status is set on the scenario",
+		},
+		{
+			name:       "nested steps",
+			definition: TestDefinition{Steps: []TestDefinition{{Steps: []TestDefinition{step}}}},
+			err:        "step 1: steps can't have steps",
+		},
+		{
+			name: "step ranges",
+			definition: TestDefinition{Steps: []TestDefinition{
+				{ResponseTmpls: map[int]string{200: `{"score": "$between:1,x"}`}},
+			}},
+			err: `step 1: range $between:1,x: operand "x" is not a number`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateSteps(tt.definition)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
@@ -7,19 +7,28 @@ import (
 )
 
 type dbCheck struct {
-	query    string
-	response []string
+	query        string
+	params       []interface{}
+	response     []string
+	responseFile string
 }
 
-func (c *dbCheck) DbQueryString() string        { return c.query }
-func (c *dbCheck) DbResponseJson() []string     { return c.response }
-func (c *dbCheck) SetDbQueryString(q string)    { c.query = q }
-func (c *dbCheck) SetDbResponseJson(r []string) { c.response = r }
+func (c *dbCheck) DbQueryString() string            { return c.query }
+func (c *dbCheck) DbQueryParams() []interface{}     { return c.params }
+func (c *dbCheck) DbResponseJson() []string         { return c.response }
+func (c *dbCheck) DbResponseFile() string           { return c.responseFile }
+func (c *dbCheck) SetDbQueryString(q string)        { c.query = q }
+func (c *dbCheck) SetDbQueryParams(p []interface{}) { c.params = p }
+func (c *dbCheck) SetDbResponseJson(r []string)     { c.response = r }
 
 type Test struct {
 	TestDefinition
 
 	Filename string
+	// DefinitionName is the name of the test with cases in its file, empty for the tests without cases
+	DefinitionName string
+	// BaseDir is the directory the relative paths of the referenced files are resolved against
+	BaseDir string
 
 	Request            string
 	Responses          map[int]string
@@ -32,6 +41,9 @@ type Test struct {
 	CombinedVariables map[string]string
 
 	DbChecks []models.DatabaseCheck
+
+	// StepTests are the tests of the steps of the scenario
+	StepTests []Test
 }
 
 func (t *Test) ToQuery() string {
@@ -58,16 +70,169 @@ func (t *Test) GetResponses() map[int]string {
 	return t.Responses
 }
 
+func (t *Test) GetResponseStatus() models.ResponseStatus {
+	return t.ResponseStatus
+}
+
 func (t *Test) GetResponse(code int) (string, bool) {
 	val, ok := t.Responses[code]
 	return val, ok
 }
 
+func (t *Test) GetResponsesOneOf() map[int][]string {
+	return t.ResponsesOneOf
+}
+
 func (t *Test) GetResponseHeaders(code int) (map[string]string, bool) {
 	val, ok := t.ResponseHeaders[code]
 	return val, ok
 }
 
+func (t *Test) GetResponseHeadersOrdered(code int) (map[string][]string, bool) {
+	val, ok := t.ResponseHeadersOrdered[code]
+	return val, ok
+}
+
+func (t *Test) GetResponseTrailers(code int) (map[string]string, bool) {
+	val, ok := t.ResponseTrailers[code]
+	return val, ok
+}
+
+func (t *Test) GetResponseCacheControl(code int) (map[string]string, bool) {
+	val, ok := t.ResponseCacheControl[code]
+	return val, ok
+}
+
+func (t *Test) GetResponseCookies(code int) (map[string]string, bool) {
+	val, ok := t.ResponseCookies[code]
+	return val, ok
+}
+
+func (t *Test) GetResponseStatusText(code int) (string, bool) {
+	val, ok := t.ResponseStatusTexts[code]
+	return val, ok
+}
+
+func (t *Test) GetRequestSnapshotFile() string {
+	return t.RequestSnapshotFile
+}
+
+func (t *Test) GetResponseBodyFile(code int) (string, bool) {
+	val, ok := t.ResponseBodyFiles[code]
+	return val, ok
+}
+
+func (t *Test) GetProtobufResponse() *models.ProtobufResponse {
+	return t.ProtobufResponse
+}
+
+func (t *Test) GetResponseBodyValidJSON() bool {
+	return t.ResponseBodyValidJSON
+}
+
+func (t *Test) GetResponseBodyMatchRegexp() string {
+	return t.ResponseBodyMatchRegexp
+}
+
+func (t *Test) GetResponseProto() string {
+	return t.ResponseProto
+}
+
+func (t *Test) GetResponseBodySize() *models.BodySize {
+	return t.ResponseBodySize
+}
+
+func (t *Test) GetResponseBodyEncoding() *models.BodyEncoding {
+	return t.ResponseBodyEncoding
+}
+
+func (t *Test) GetStreamResponse() *models.StreamResponse {
+	return t.StreamResponse
+}
+
+func (t *Test) GetResponseXPaths() map[int][]string {
+	return t.ResponseXPaths
+}
+
+func (t *Test) GetResponseJSONPaths() map[int][]string {
+	return t.ResponseJSONPaths
+}
+
+func (t *Test) GetResponseRoot() string {
+	return t.ResponseRoot
+}
+
+func (t *Test) GetRetryPolicy() *models.RetryPolicy {
+	return t.RetryPolicy
+}
+
+func (t *Test) GetAssertRetry() *models.AssertRetry {
+	return t.AssertRetry
+}
+
+func (t *Test) GetRepeat() *models.Repeat {
+	return t.Repeat
+}
+
+func (t *Test) GetAssertions() []models.Assertions {
+	return t.Assertions
+}
+
+func (t *Test) GetRedirects() []models.RedirectHop {
+	return t.Redirects
+}
+
+func (t *Test) GetResponseLocations() models.ResponseLocations {
+	return t.ResponseLocations
+}
+
+func (t *Test) GetMaxDbQueries() *int {
+	return t.MaxDbQueries
+}
+
+func (t *Test) GetIdempotency() *models.Idempotency {
+	return t.Idempotency
+}
+
+func (t *Test) GetConditionalRequest() *models.Conditional {
+	return t.ConditionalRequest
+}
+
+func (t *Test) GetMetricsDelta() map[string]string {
+	return t.MetricsDelta
+}
+
+func (t *Test) GetSnapshotIgnorePaths() []string {
+	return t.SnapshotIgnorePaths
+}
+
+func (t *Test) GetGrpcStatus() *models.GrpcStatus {
+	return t.GrpcStatus
+}
+
+func (t *Test) GetFuzz() *models.Fuzz {
+	if t.Type != models.TestTypeFuzz {
+		return nil
+	}
+	return t.Fuzz
+}
+
+func (t *Test) GetEnv() map[string]string {
+	return t.Env
+}
+
+func (t *Test) GetRequestBuilder() string {
+	return t.RequestBuilder
+}
+
+func (t *Test) GetProxy() string {
+	return t.Proxy
+}
+
+func (t *Test) GetServer() string {
+	return t.Server
+}
+
 func (t *Test) NeedsCheckingValues() bool {
 	return !t.ComparisonParams.IgnoreValues
 }
@@ -76,6 +241,25 @@ func (t *Test) GetName() string {
 	return t.Name
 }
 
+func (t *Test) GetDefinitionName() string {
+	if t.DefinitionName != "" {
+		return t.DefinitionName
+	}
+	return t.Name
+}
+
+func (t *Test) GetSteps() []models.TestInterface {
+	if len(t.StepTests) == 0 {
+		return nil
+	}
+	steps := make([]models.TestInterface, len(t.StepTests))
+	for i := range t.StepTests {
+		step := t.StepTests[i]
+		steps[i] = &step
+	}
+	return steps
+}
+
 func (t *Test) GetDescription() string {
 	return t.Description
 }
@@ -84,6 +268,22 @@ func (t *Test) GetStatus() string {
 	return t.Status
 }
 
+func (t *Test) GetReason() string {
+	return t.Reason
+}
+
+func (t *Test) GetTags() []string {
+	return t.Tags
+}
+
+func (t *Test) GetDisabledCheckers() []string {
+	return t.DisableCheckers
+}
+
+func (t *Test) GetDependsOn() []string {
+	return t.DependsOn
+}
+
 func (t *Test) IgnoreArraysOrdering() bool {
 	return t.ComparisonParams.IgnoreArraysOrdering
 }
@@ -96,6 +296,22 @@ func (t *Test) IgnoreDbOrdering() bool {
 	return t.ComparisonParams.IgnoreDbOrdering
 }
 
+func (t *Test) NormalizeKeys() string {
+	return t.ComparisonParams.NormalizeKeys
+}
+
+func (t *Test) NumberTypeStrict() bool {
+	return t.ComparisonParams.NumberTypeStrict
+}
+
+func (t *Test) GetComparisonParamsTemplates() map[string]string {
+	return t.ComparisonParams.Templates
+}
+
+func (t *Test) SetComparisonParam(name, value string) error {
+	return t.ComparisonParams.set(name, value)
+}
+
 func (t *Test) Fixtures() []string {
 	return t.FixtureFiles
 }
@@ -142,13 +358,25 @@ func (t *Test) DbQueryString() string {
 	return t.DbQuery
 }
 
+func (t *Test) DbQueryParams() []interface{} {
+	return t.DbQueryParamsVal
+}
+
 func (t *Test) DbResponseJson() []string {
 	return t.DbResponse
 }
 
+func (t *Test) DbResponseFile() string {
+	return t.ExpectedDbFile
+}
+
 func (t *Test) GetDatabaseChecks() []models.DatabaseCheck       { return t.DbChecks }
 func (t *Test) SetDatabaseChecks(checks []models.DatabaseCheck) { t.DbChecks = checks }
 
+func (t *Test) GetDbProtobufColumns() map[string]string {
+	return t.DbProtobufColumns
+}
+
 func (t *Test) GetVariables() map[string]string {
 	return t.Variables
 }
@@ -169,6 +397,10 @@ func (t *Test) GetFileName() string {
 	return t.Filename
 }
 
+func (t *Test) GetBaseDir() string {
+	return t.BaseDir
+}
+
 func (t *Test) Clone() models.TestInterface {
 	res := *t
 
@@ -212,10 +444,66 @@ func (t *Test) SetDbQueryString(query string) {
 	t.DbQuery = query
 }
 
+func (t *Test) SetDbQueryParams(params []interface{}) {
+	t.DbQueryParamsVal = params
+}
+
 func (t *Test) SetDbResponseJson(responses []string) {
 	t.DbResponse = responses
 }
 
+func (t *Test) SetServiceMocks(mocks map[string]interface{}) {
+	t.MocksDefinition = mocks
+}
+
+func (t *Test) SetRedirects(redirects []models.RedirectHop) {
+	t.Redirects = redirects
+}
+
+func (t *Test) SetProxy(proxy string) {
+	t.Proxy = proxy
+}
+
+func (t *Test) SetResponseLocations(locations models.ResponseLocations) {
+	t.ResponseLocations = locations
+}
+
+func (t *Test) SetStreamResponse(stream *models.StreamResponse) {
+	t.StreamResponse = stream
+}
+
+func (t *Test) SetProtobufResponse(response *models.ProtobufResponse) {
+	t.ProtobufResponse = response
+}
+
+func (t *Test) SetResponseHeaders(headers map[int]map[string]string) {
+	t.ResponseHeaders = headers
+}
+
+func (t *Test) SetResponsesOneOf(responses map[int][]string) {
+	t.ResponsesOneOf = responses
+}
+
+func (t *Test) SetResponseXPaths(xpaths map[int][]string) {
+	t.ResponseXPaths = xpaths
+}
+
+func (t *Test) SetResponseJSONPaths(jsonPaths map[int][]string) {
+	t.ResponseJSONPaths = jsonPaths
+}
+
+func (t *Test) SetEnv(env map[string]string) {
+	t.Env = env
+}
+
+func (t *Test) SetName(name string) {
+	t.Name = name
+}
+
 func (t *Test) SetStatus(status string) {
 	t.Status = status
 }
+
+func (t *Test) SetReason(reason string) {
+	t.Reason = reason
+}
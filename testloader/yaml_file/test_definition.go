@@ -1,38 +1,93 @@
 package yaml_file
 
 import (
-	"github.com/lamoda/gonkey/compare"
 	"github.com/lamoda/gonkey/models"
 )
 
 type TestDefinition struct {
 	Name                     string                    `json:"name" yaml:"name"`
 	Description              string                    `json:"description" yaml:"description"`
+	Type                     string                    `json:"type" yaml:"type"`
 	Status                   string                    `json:"status" yaml:"status"`
+	Reason                   string                    `json:"reason" yaml:"reason"`
+	Tags                     []string                  `json:"tags" yaml:"tags"`
+	DisableCheckers          []string                  `json:"disableCheckers" yaml:"disableCheckers"`
+	DependsOn                []string                  `json:"dependsOn" yaml:"dependsOn"`
+	UseProfile               string                    `json:"useProfile" yaml:"useProfile"`
 	Variables                map[string]string         `json:"variables" yaml:"variables"`
 	VariablesToSet           VariablesToSet            `json:"variables_to_set" yaml:"variables_to_set"`
 	Form                     *models.Form              `json:"form" yaml:"form"`
 	Method                   string                    `json:"method" yaml:"method"`
+	Server                   string                    `json:"server" yaml:"server"`
+	Proxy                    string                    `json:"proxy" yaml:"proxy"`
 	RequestURL               string                    `json:"path" yaml:"path"`
 	QueryParams              string                    `json:"query" yaml:"query"`
 	RequestTmpl              string                    `json:"request" yaml:"request"`
+	RequestFile              string                    `json:"requestFile" yaml:"requestFile"`
+	RequestBuilder           string                    `json:"requestBuilder" yaml:"requestBuilder"`
+	RequestSnapshotFile      string                    `json:"requestSnapshotFile" yaml:"requestSnapshotFile"`
+	ResponseStatus           models.ResponseStatus     `json:"responseStatus" yaml:"responseStatus"`
 	ResponseTmpls            map[int]string            `json:"response" yaml:"response"`
+	ResponsesOneOf           map[int][]string          `json:"responseOneOf" yaml:"responseOneOf"`
 	ResponseHeaders          map[int]map[string]string `json:"responseHeaders" yaml:"responseHeaders"`
+	ResponseHeadersOrdered   OrderedResponseHeaders    `json:"responseHeadersOrdered" yaml:"responseHeadersOrdered"`
+	ResponseTrailers         map[int]map[string]string `json:"responseTrailers" yaml:"responseTrailers"`
+	ResponseProto            string                    `json:"responseProto" yaml:"responseProto"`
+	ResponseCacheControl     map[int]map[string]string `json:"responseCacheControl" yaml:"responseCacheControl"`
+	ResponseCookies          map[int]map[string]string `json:"responseCookies" yaml:"responseCookies"`
+	ResponseStatusTexts      map[int]string            `json:"responseStatusText" yaml:"responseStatusText"`
+	ResponseLocations        models.ResponseLocations  `json:"responseLocation" yaml:"responseLocation"`
+	ResponseBodyFiles        map[int]string            `json:"responseBodyFile" yaml:"responseBodyFile"`
+	SnapshotIgnorePaths      []string                  `json:"snapshotIgnorePaths" yaml:"snapshotIgnorePaths"`
+	ResponseBodyValidJSON    bool                      `json:"responseBodyValidJSON" yaml:"responseBodyValidJSON"`
+	ResponseBodyMatchRegexp  string                    `json:"responseBodyMatchRegexp" yaml:"responseBodyMatchRegexp"`
+	ResponseBodySize         *models.BodySize          `json:"responseBodySize" yaml:"responseBodySize"`
+	ResponseBodyEncoding     *models.BodyEncoding      `json:"responseBodyEncoding" yaml:"responseBodyEncoding"`
+	StreamResponse           *models.StreamResponse    `json:"responseStream" yaml:"responseStream"`
+	ProtobufResponse         *models.ProtobufResponse  `json:"responseProtobuf" yaml:"responseProtobuf"`
+	GrpcStatus               *models.GrpcStatus        `json:"responseGrpcStatus" yaml:"responseGrpcStatus"`
+	ResponseXPaths           map[int][]string          `json:"responseXPath" yaml:"responseXPath"`
+	ResponseJSONPaths        map[int][]string          `json:"responseJSONPath" yaml:"responseJSONPath"`
+	ResponseRoot             string                    `json:"responseRoot" yaml:"responseRoot"`
+	Assertions               []models.Assertions       `json:"assertions" yaml:"assertions"`
+	RetryPolicy              *models.RetryPolicy       `json:"retryPolicy" yaml:"retryPolicy"`
+	AssertRetry              *models.AssertRetry       `json:"assertRetry" yaml:"assertRetry"`
+	Repeat                   *models.Repeat            `json:"repeat" yaml:"repeat"`
+	Redirects                []models.RedirectHop      `json:"redirects" yaml:"redirects"`
+	MaxDbQueries             *int                      `json:"maxDbQueries" yaml:"maxDbQueries"`
+	Idempotency              *models.Idempotency       `json:"idempotency" yaml:"idempotency"`
+	ConditionalRequest       *models.Conditional       `json:"conditionalRequest" yaml:"conditionalRequest"`
+	MetricsDelta             map[string]string         `json:"metricsDelta" yaml:"metricsDelta"`
+	Fuzz                     *models.Fuzz              `json:"fuzz" yaml:"fuzz"`
 	BeforeScriptParams       scriptParams              `json:"beforeScript" yaml:"beforeScript"`
 	AfterRequestScriptParams scriptParams              `json:"afterRequestScript" yaml:"afterRequestScript"`
 	HeadersVal               map[string]string         `json:"headers" yaml:"headers"`
 	CookiesVal               map[string]string         `json:"cookies" yaml:"cookies"`
+	Env                      map[string]string         `json:"env" yaml:"env"`
 	Cases                    []CaseData                `json:"cases" yaml:"cases"`
-	ComparisonParams         compare.CompareParams     `json:"comparisonParams" yaml:"comparisonParams"`
+	CasesFile                *CasesFile                `json:"casesFile" yaml:"casesFile"`
+	Steps                    []TestDefinition          `json:"steps" yaml:"steps"`
+	ComparisonParams         ComparisonParams          `json:"comparisonParams" yaml:"comparisonParams"`
 	FixtureFiles             []string                  `json:"fixtures" yaml:"fixtures"`
 	MocksDefinition          map[string]interface{}    `json:"mocks" yaml:"mocks"`
 	PauseValue               int                       `json:"pause" yaml:"pause"`
 	DbQueryTmpl              string                    `json:"dbQuery" yaml:"dbQuery"`
+	DbQueryParamsVal         []interface{}             `json:"dbQueryParams" yaml:"dbQueryParams"`
 	DbResponseTmpl           []string                  `json:"dbResponse" yaml:"dbResponse"`
+	ExpectedDbFile           string                    `json:"expectedDbFile" yaml:"expectedDbFile"`
 	DatabaseChecks           []DatabaseCheck           `json:"dbChecks" yaml:"dbChecks"`
+	DbProtobufColumns        map[string]string         `json:"dbProtobufColumns" yaml:"dbProtobufColumns"`
+	ExpectedState            ExpectedState             `json:"expectedState" yaml:"expectedState"`
+	// RollbackOnFailure is only read to reject it, see validateRollback
+	RollbackOnFailure *bool `json:"rollbackOnFailure" yaml:"rollbackOnFailure"`
+	// Definitions holds blocks that are referenced by YAML aliases from other tests of the file,
+	// an item with definitions is not a test itself
+	Definitions interface{} `json:"definitions" yaml:"definitions"`
 }
 
 type CaseData struct {
+	// Name is appended to the name of the test instead of the number of the case
+	Name                   string                         `json:"name" yaml:"name"`
 	RequestArgs            map[string]interface{}         `json:"requestArgs" yaml:"requestArgs"`
 	ResponseArgs           map[int]map[string]interface{} `json:"responseArgs" yaml:"responseArgs"`
 	BeforeScriptArgs       map[string]interface{}         `json:"beforeScriptArgs" yaml:"beforeScriptArgs"`
@@ -44,9 +99,19 @@ type CaseData struct {
 	Variables              map[string]interface{}         `json:"variables" yaml:"variables"`
 }
 
+// CasesFile is the CSV file whose rows are the cases of the test, the header row names the variables
+// the values of the rows are bound to
+type CasesFile struct {
+	Path string `json:"path" yaml:"path"`
+	// NameColumn is the column naming the cases, they are numbered by default
+	NameColumn string `json:"nameColumn" yaml:"nameColumn"`
+}
+
 type DatabaseCheck struct {
-	DbQueryTmpl    string   `json:"dbQuery" yaml:"dbQuery"`
-	DbResponseTmpl []string `json:"dbResponse" yaml:"dbResponse"`
+	DbQueryTmpl    string        `json:"dbQuery" yaml:"dbQuery"`
+	DbQueryParams  []interface{} `json:"dbQueryParams" yaml:"dbQueryParams"`
+	DbResponseTmpl []string      `json:"dbResponse" yaml:"dbResponse"`
+	ExpectedDbFile string        `json:"expectedDbFile" yaml:"expectedDbFile"`
 }
 
 type scriptParams struct {
@@ -56,6 +121,10 @@ type scriptParams struct {
 
 type VariablesToSet map[int]map[string]string
 
+// OrderedResponseHeaders are the expected values of the response headers by status code,
+// all values of a header must match them in the same order
+type OrderedResponseHeaders map[int]map[string][]string
+
 /*
 There can be two types of data in yaml-file:
  1. JSON-paths:
@@ -7,6 +7,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/lamoda/gonkey/files"
 	"github.com/lamoda/gonkey/models"
 	"github.com/lamoda/gonkey/variables"
 )
@@ -20,7 +21,7 @@ func TestParse_EniromentVariables(t *testing.T) {
 	err := godotenv.Load(envFile)
 	require.NoError(t, err)
 
-	tests, err := parseTestDefinitionFile("testdata/variables-enviroment.yaml")
+	tests, err := parseTestDefinitionFile(files.OS, "testdata/variables-enviroment.yaml", "")
 	require.NoError(t, err)
 
 	testOriginal := &tests[0]
@@ -38,7 +39,7 @@ func TestParse_EniromentVariables(t *testing.T) {
 
 func TestParseTestsWithVariables(t *testing.T) {
 
-	tests, err := parseTestDefinitionFile("testdata/variables.yaml")
+	tests, err := parseTestDefinitionFile(files.OS, "testdata/variables.yaml", "")
 	require.NoError(t, err)
 
 	testOriginal := &tests[0]
@@ -57,7 +58,7 @@ func TestParseTestsWithVariables(t *testing.T) {
 
 func TestParseTestsWithCombinedVariables(t *testing.T) {
 
-	tests, err := parseTestDefinitionFile("testdata/combined-variables.yaml")
+	tests, err := parseTestDefinitionFile(files.OS, "testdata/combined-variables.yaml", "")
 	require.NoError(t, err)
 
 	testOriginal := &tests[0]
@@ -95,6 +96,12 @@ func checkOriginal(t *testing.T, test models.TestInterface, combined bool) {
 	assert.True(t, ok)
 	assert.Equal(t, "{{ $respRx }}", resp)
 
+	raw, ok := test.ServiceMocks()["server"]
+	assert.True(t, ok)
+	mockMap, ok := raw.(map[interface{}]interface{})
+	assert.True(t, ok)
+	assert.Equal(t, "{\"reqParam\": \"{{ $reqParam }}\"}", mockMap["body"])
+
 	if combined {
 		resp, ok = test.GetResponse(501)
 		assert.True(t, ok)
@@ -1,73 +1,187 @@
 package yaml_file
 
 import (
-	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strings"
 
+	"github.com/lamoda/gonkey/files"
 	"github.com/lamoda/gonkey/models"
+
+	"gopkg.in/yaml.v2"
 )
 
 type YamlFileLoader struct {
 	testsLocation string
 	fileFilter    string
+	overrides     *Overrides
+	profiles      Profiles
+	sampleRate    float64
+	sampleSeed    int64
+	baseDir       string
+	fs            files.FS
+	cache         *cache
+	// parsed are the names of the tests parsed by the last Load before the sampling,
+	// filteredFiles are the test files left out by the file filter
+	parsed        []string
+	filteredFiles []string
 }
 
 func NewLoader(testsLocation string) *YamlFileLoader {
 	return &YamlFileLoader{
 		testsLocation: testsLocation,
+		fs:            files.OS,
 	}
 }
 
 func (l *YamlFileLoader) Load() ([]models.TestInterface, error) {
+	l.filteredFiles = nil
 	fileTests, err := l.parseTestsWithCases(l.testsLocation)
 	if err != nil {
 		return nil, err
 	}
+	l.parsed = make([]string, len(fileTests))
+	for i := range fileTests {
+		l.parsed[i] = fileTests[i].GetName()
+	}
+
+	if l.sampleRate > 0 && l.sampleRate < 1 {
+		fileTests = sample(fileTests, l.sampleRate, l.sampleSeed)
+	}
 
 	ret := make([]models.TestInterface, len(fileTests))
 	for i, test := range fileTests {
 		test := test
+		if err := l.profiles.apply(&test); err != nil {
+			return nil, err
+		}
+		if l.overrides != nil {
+			l.overrides.apply(&test)
+		}
 		ret[i] = &test
 	}
 	return ret, nil
 }
 
+// IsFilteredOut tells if the test named so was sampled out by the last Load or is defined in a test file
+// left out by the file filter, the test files are only parsed for the names of the tests here
+func (l *YamlFileLoader) IsFilteredOut(name string) bool {
+	for _, parsed := range l.parsed {
+		if parsed == name || models.IsCaseOf(parsed, name) {
+			return true
+		}
+	}
+	for _, path := range l.filteredFiles {
+		data, err := l.fs.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		// the file isn't loaded, so it isn't validated either
+		var definitions []struct {
+			Name string `yaml:"name"`
+		}
+		if yaml.Unmarshal(data, &definitions) != nil {
+			continue
+		}
+		for _, definition := range definitions {
+			if definition.Name == name || models.IsCaseOf(name, definition.Name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func (l *YamlFileLoader) SetFileFilter(f string) {
 	l.fileFilter = f
 }
 
+// SetOverrides sets environment-specific values which are patched into every loaded test
+func (l *YamlFileLoader) SetOverrides(o *Overrides) {
+	l.overrides = o
+}
+
+// SetProfiles sets the variable profiles the tests refer to with useProfile
+func (l *YamlFileLoader) SetProfiles(p Profiles) {
+	l.profiles = p
+}
+
+// SetBaseDir sets the directory for the relative paths of the files referenced by the tests,
+// by default they are relative to the directory of the test file
+func (l *YamlFileLoader) SetBaseDir(dir string) {
+	l.baseDir = dir
+}
+
+// SetFS sets the filesystem the tests and the request files are read from, the OS filesystem by default,
+// the other files referenced by the tests are read by the runner and the checkers from the OS filesystem
+func (l *YamlFileLoader) SetFS(fsys files.FS) {
+	l.fs = fsys
+}
+
+// SetCacheDir enables the cache of the parsed test files in the directory, the unchanged files
+// are not parsed again, see CacheDirEnv
+func (l *YamlFileLoader) SetCacheDir(dir string) {
+	l.cache = nil
+	if dir != "" {
+		l.cache = &cache{dir: dir}
+	}
+}
+
 func (l *YamlFileLoader) parseTestsWithCases(path string) ([]Test, error) {
-	stat, err := os.Stat(path)
+	stat, err := l.fs.Stat(path)
 	if err != nil {
 		return nil, err
 	}
-	return l.lookupPath(path, stat)
+
+	// the test file given alone gets the defaults of its directory
+	var defaults *dirDefaults
+	if !stat.IsDir() {
+		if defaults, err = loadDirDefaults(l.fs, filepath.Dir(path), nil); err != nil {
+			return nil, err
+		}
+	}
+	return l.lookupPath(path, stat, defaults)
 }
 
-// lookupPath recursively walks over the directory and parses YML files it finds
-func (l *YamlFileLoader) lookupPath(path string, fi os.FileInfo) ([]Test, error) {
+// lookupPath recursively walks over the directory and parses YML files it finds,
+// the defaults of the directories are merged into the tests
+func (l *YamlFileLoader) lookupPath(path string, fi os.FileInfo, defaults *dirDefaults) ([]Test, error) {
 	if !fi.IsDir() {
 		if !l.fitsFilter(path) {
+			l.filteredFiles = append(l.filteredFiles, path)
 			return []Test{}, nil
 		}
-		return parseTestDefinitionFile(path)
+		if l.cache != nil {
+			return l.cache.parse(l.fs, path, l.baseDir, defaults)
+		}
+		return parseTestFileWithDefaults(l.fs, path, l.baseDir, defaults)
 	}
-	files, err := ioutil.ReadDir(path)
+	entries, err := l.fs.ReadDir(path)
 	if err != nil {
 		return nil, err
 	}
+	if defaults, err = loadDirDefaults(l.fs, path, defaults); err != nil {
+		return nil, err
+	}
 	var tests []Test
-	for _, fi := range files {
-		if !fi.IsDir() && !isYmlFile(fi.Name()) {
+	var missing []models.MissingFile
+	for _, fi := range entries {
+		if !fi.IsDir() && (!isYmlFile(fi.Name()) || fi.Name() == DefaultsFile) {
 			continue
 		}
-		moreTests, err := l.lookupPath(path+"/"+fi.Name(), fi)
+		moreTests, err := l.lookupPath(path+"/"+fi.Name(), fi, defaults)
 		if err != nil {
+			// the other files are parsed to report the missing files of all of them
+			if models.CollectMissingFiles(err, &missing) {
+				continue
+			}
 			return nil, err
 		}
 		tests = append(tests, moreTests...)
 	}
+	if len(missing) != 0 {
+		return nil, &models.MissingFilesError{Files: missing}
+	}
 	return tests, nil
 }
 
@@ -0,0 +1,33 @@
+//go:build go1.16
+// +build go1.16
+
+package yaml_file
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lamoda/gonkey/files"
+)
+
+func TestLoadFromFS(t *testing.T) {
+	loader := NewLoader("cases")
+	loader.SetFS(files.FromFS(fstest.MapFS{
+		"cases/orders.yaml": {Data: []byte(
+			"- name: create order\n  method: POST\n  path: /orders\n  requestFile: bodies/order.json\n",
+		)},
+		"cases/bodies/order.json": {Data: []byte(`{"id": 1}`)},
+		"cases/readme.md":         {Data: []byte("not a test")},
+	}))
+
+	tests, err := loader.Load()
+	require.NoError(t, err)
+	require.Len(t, tests, 1)
+	assert.Equal(t, "create order", tests[0].GetName())
+	assert.Equal(t, "cases/orders.yaml", tests[0].GetFileName())
+	assert.Equal(t, `{"id": 1}`, tests[0].GetRequest())
+	assert.Equal(t, "application/json", tests[0].Headers()["Content-Type"])
+}
@@ -39,7 +39,7 @@ func fromJson(names, paths []string, body string) (*Variables, error) {
 				fmt.Errorf("path '%s' doesn't exist in given json", paths[n])
 		}
 
-		vars.Add(NewVariable(names[n], res.String()))
+		vars.Add(NewJSONVariable(names[n], res.String(), res.Raw))
 	}
 
 	return vars, nil
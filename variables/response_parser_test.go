@@ -0,0 +1,57 @@
+package variables
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lamoda/gonkey/testloader/yaml_file"
+)
+
+func TestExpectedBodyWithJSONVariables(t *testing.T) {
+	vars, err := FromResponse(map[string]string{
+		"id":     "id",
+		"name":   "name",
+		"paid":   "paid",
+		"tags":   "tags",
+		"status": "status",
+	}, `{"id": 42, "name": "John \"Jr\"", "paid": true, "tags": ["a", "b"], "status": "new"}`, true)
+	require.NoError(t, err)
+
+	vs := New()
+	vs.Merge(vars)
+
+	test := &yaml_file.Test{
+		TestDefinition: yaml_file.TestDefinition{
+			ResponsesOneOf: map[int][]string{200: {`{"id": "{{ $id }}"}`}},
+		},
+		Request: `{"order": "{{ $id }}"}`,
+		Responses: map[int]string{
+			200: `{"id": "{{ $id }}", "name": "{{ $name }}", "paid": "{{ $paid }}", "tags": "{{ $tags }}", ` +
+				`"title": "order {{ $id }} is {{ $status }}", "status": "{{ $status }}"}`,
+		},
+	}
+	applied := vs.Apply(test)
+
+	assert.Equal(t,
+		`{"id": 42, "name": "John \"Jr\"", "paid": true, "tags": ["a", "b"], `+
+			`"title": "order 42 is new", "status": "new"}`,
+		applied.GetResponses()[200],
+	)
+	assert.Equal(t, []string{`{"id": 42}`}, applied.GetResponsesOneOf()[200])
+	// the request is not an expected body, the values are substituted as text
+	assert.Equal(t, `{"order": "42"}`, applied.GetRequest())
+}
+
+func TestExpectedBodyWithPlainVariables(t *testing.T) {
+	vs := New()
+	vs.Set("id", "42")
+
+	test := &yaml_file.Test{
+		Responses: map[int]string{200: `{"id": "{{ $id }}", "count": {{ $id }}}`},
+	}
+	applied := vs.Apply(test)
+
+	assert.Equal(t, `{"id": "42", "count": 42}`, applied.GetResponses()[200])
+}
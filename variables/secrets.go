@@ -0,0 +1,68 @@
+package variables
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+)
+
+// secretRx matches the references to the secrets: {{ $secret:path }}
+var secretRx = regexp.MustCompile(`{{\s*\$secret:([^\s{}]+)\s*}}`)
+
+// Provider resolves the references {{ $secret:path }} from an external source, e.g. Vault or AWS SSM,
+// the format of the path is up to the provider
+type Provider interface {
+	Resolve(path string) (string, error)
+}
+
+// EnvProvider is the default provider, the path is the name of the environment variable
+type EnvProvider struct{}
+
+func (EnvProvider) Resolve(path string) (string, error) {
+	value, ok := os.LookupEnv(path)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", path)
+	}
+	return value, nil
+}
+
+// SetProvider replaces the provider of the secrets, the secrets resolved before are kept
+func (vs *Variables) SetProvider(p Provider) {
+	vs.provider = p
+}
+
+// Secrets returns the values of the secrets resolved so far, e.g. to mask them in the outputs
+func (vs *Variables) Secrets() []string {
+	res := make([]string, 0, len(vs.secrets))
+	for _, value := range vs.secrets {
+		res = append(res, value)
+	}
+	return res
+}
+
+// SecretsErr returns the first error of resolving the secrets during the last Apply,
+// the references which failed to resolve are left as is
+func (vs *Variables) SecretsErr() error {
+	return vs.secretsErr
+}
+
+// performSecrets replaces the references to the secrets with their values,
+// each secret is requested from the provider once
+func (vs *Variables) performSecrets(str string) string {
+	return secretRx.ReplaceAllStringFunc(str, func(ref string) string {
+		path := secretRx.FindStringSubmatch(ref)[1]
+		if value, ok := vs.secrets[path]; ok {
+			return value
+		}
+
+		value, err := vs.provider.Resolve(path)
+		if err != nil {
+			if vs.secretsErr == nil {
+				vs.secretsErr = fmt.Errorf("unable to resolve secret %s: %s", path, err)
+			}
+			return ref
+		}
+		vs.secrets[path] = value
+		return value
+	})
+}
@@ -0,0 +1,75 @@
+package variables
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lamoda/gonkey/testloader/yaml_file"
+)
+
+type mapProvider struct {
+	secrets map[string]string
+	calls   int
+}
+
+func (p *mapProvider) Resolve(path string) (string, error) {
+	p.calls++
+	value, ok := p.secrets[path]
+	if !ok {
+		return "", errors.New("not found")
+	}
+	return value, nil
+}
+
+func TestSecrets(t *testing.T) {
+	provider := &mapProvider{secrets: map[string]string{"api/token": "s3cr3t"}}
+	vs := New()
+	vs.SetProvider(provider)
+	vs.Set("auth", "Bearer {{ $secret:api/token }}")
+
+	test := &yaml_file.Test{
+		TestDefinition: yaml_file.TestDefinition{
+			HeadersVal: map[string]string{"Authorization": "{{ $auth }}"},
+		},
+		Request: `{"token": "{{ $secret:api/token }}"}`,
+	}
+	applied := vs.Apply(test)
+
+	require.NoError(t, vs.SecretsErr())
+	assert.Equal(t, `{"token": "s3cr3t"}`, applied.GetRequest())
+	assert.Equal(t, "Bearer s3cr3t", applied.Headers()["Authorization"])
+	assert.Equal(t, []string{"s3cr3t"}, vs.Secrets())
+	// the resolved secrets are cached
+	assert.Equal(t, 1, provider.calls)
+}
+
+func TestSecretsNotResolved(t *testing.T) {
+	vs := New()
+	vs.SetProvider(&mapProvider{})
+
+	applied := vs.Apply(&yaml_file.Test{Request: `{"token": "{{ $secret:api/token }}"}`})
+	assert.EqualError(t, vs.SecretsErr(), "unable to resolve secret api/token: not found")
+	assert.Equal(t, `{"token": "{{ $secret:api/token }}"}`, applied.GetRequest())
+
+	// the error is of the last Apply only
+	vs.Apply(&yaml_file.Test{Request: "{}"})
+	assert.NoError(t, vs.SecretsErr())
+}
+
+func TestEnvProvider(t *testing.T) {
+	require.NoError(t, os.Setenv("GONKEY_TEST_SECRET", "s3cr3t"))
+	defer os.Unsetenv("GONKEY_TEST_SECRET")
+
+	vs := New()
+	applied := vs.Apply(&yaml_file.Test{Request: "{{ $secret:GONKEY_TEST_SECRET }}"})
+	require.NoError(t, vs.SecretsErr())
+	assert.Equal(t, "s3cr3t", applied.GetRequest())
+
+	vs.Apply(&yaml_file.Test{Request: "{{ $secret:GONKEY_TEST_MISSING_SECRET }}"})
+	assert.EqualError(t, vs.SecretsErr(),
+		"unable to resolve secret GONKEY_TEST_MISSING_SECRET: environment variable GONKEY_TEST_MISSING_SECRET is not set")
+}
@@ -0,0 +1,70 @@
+package variables
+
+import (
+	"regexp"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// uniqueRx matches the references to the unique values: {{ $uniqueInt }} and {{ $uniqueInt:name }}
+var uniqueRx = regexp.MustCompile(`{{\s*\$uniqueInt(?::(\w+))?\s*}}`)
+
+// UniqueValues generates the integers unique within a run, the runners sharing it (e.g. the parallel Go tests)
+// never get the same value. The values are the seed plus the number of the values generated so far,
+// so they are reproducible when the tests are run one by one with the same seed.
+type UniqueValues struct {
+	seed  int64
+	count int64
+}
+
+// defaultUniqueValues are shared by all the variables of the process unless SetUniqueValues is called,
+// the seed fits the 32-bit columns
+var defaultUniqueValues = NewUniqueValues(time.Now().UnixNano() % 1000000000)
+
+func NewUniqueValues(seed int64) *UniqueValues {
+	return &UniqueValues{seed: seed}
+}
+
+// Seed returns the seed of the values, e.g. to log it and reproduce the values of a failed run
+func (u *UniqueValues) Seed() int64 {
+	return u.seed
+}
+
+// Next returns the next unique value, it's safe for concurrent use
+func (u *UniqueValues) Next() int64 {
+	return u.seed + atomic.AddInt64(&u.count, 1)
+}
+
+// SetUniqueValues replaces the generator of {{ $uniqueInt }}, the values of the process-wide one
+// are unique across all the runners of the process
+func (vs *Variables) SetUniqueValues(u *UniqueValues) {
+	vs.unique = u
+}
+
+// UniqueValues returns the generator of {{ $uniqueInt }}
+func (vs *Variables) UniqueValues() *UniqueValues {
+	return vs.unique
+}
+
+// ResetUniqueNames forgets the values of {{ $uniqueInt:name }}, the runner calls it before each test,
+// so the references with the same name get the same value within a test only
+func (vs *Variables) ResetUniqueNames() {
+	vs.uniqueNames = make(map[string]string)
+}
+
+// performUnique replaces each {{ $uniqueInt }} with a new unique value
+// and all {{ $uniqueInt:name }} with the same name with the same one
+func (vs *Variables) performUnique(str string) string {
+	return uniqueRx.ReplaceAllStringFunc(str, func(ref string) string {
+		name := uniqueRx.FindStringSubmatch(ref)[1]
+		if value, ok := vs.uniqueNames[name]; ok && name != "" {
+			return value
+		}
+		value := strconv.FormatInt(vs.unique.Next(), 10)
+		if name != "" {
+			vs.uniqueNames[name] = value
+		}
+		return value
+	})
+}
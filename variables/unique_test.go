@@ -0,0 +1,57 @@
+package variables
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lamoda/gonkey/testloader/yaml_file"
+)
+
+func TestUniqueValues(t *testing.T) {
+	vs := New()
+	vs.SetUniqueValues(NewUniqueValues(1000))
+	vs.Set("id", "{{ $uniqueInt:order }}")
+
+	test := &yaml_file.Test{
+		TestDefinition: yaml_file.TestDefinition{
+			RequestURL: "/orders/{{ $id }}",
+		},
+		Request: `{"id": {{ $uniqueInt:order }}, "item": {{ $uniqueInt }}, "other": {{ $uniqueInt }}}`,
+	}
+	applied := vs.Apply(test)
+	assert.Equal(t, "/orders/1001", applied.Path())
+	assert.Equal(t, `{"id": 1001, "item": 1002, "other": 1003}`, applied.GetRequest())
+
+	// the names get new values in the next test
+	vs.ResetUniqueNames()
+	applied = vs.Apply(test)
+	assert.Equal(t, "/orders/1004", applied.Path())
+	assert.Equal(t, int64(1000), vs.UniqueValues().Seed())
+}
+
+func TestUniqueValuesAreSharedByVariables(t *testing.T) {
+	unique := NewUniqueValues(0)
+	var mu sync.Mutex
+	seen := make(map[string]bool)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			vs := New()
+			vs.SetUniqueValues(unique)
+			for j := 0; j < 100; j++ {
+				value := vs.perform("{{ $uniqueInt }}")
+				mu.Lock()
+				assert.False(t, seen[value], value)
+				seen[value] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Len(t, seen, 400)
+}
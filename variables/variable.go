@@ -11,6 +11,9 @@ type Variable struct {
 	value        string
 	defaultValue string
 	rx           *regexp.Regexp
+	// raw is the JSON of the value captured from a JSON response, empty for the other variables
+	raw      string
+	quotedRx *regexp.Regexp
 }
 
 // NewVariable creates new variable with given name and value
@@ -27,6 +30,15 @@ func NewVariable(name, value string) *Variable {
 	}
 }
 
+// NewJSONVariable creates the variable captured from a JSON response, raw is the JSON of the value,
+// e.g. 42 or "abc", it replaces the quoted references "{{ $name }}" in the expected bodies
+func NewJSONVariable(name, value, raw string) *Variable {
+	v := NewVariable(name, value)
+	v.raw = raw
+	v.quotedRx = regexp.MustCompile(fmt.Sprintf(`"{{\s*\$%s\s*}}"`, v.name))
+	return v
+}
+
 func NewFromEnvironment(name string) *Variable {
 	val := os.Getenv(name)
 	if val == "" {
@@ -47,3 +59,12 @@ func (v *Variable) Perform(str string) string {
 
 	return string(res)
 }
+
+// performJSON replaces the quoted references to the variable captured from a JSON response
+// with the JSON of its value keeping its type, e.g. "{{ $id }}" becomes 42 for a number
+func (v *Variable) performJSON(str string) string {
+	if v.raw == "" {
+		return str
+	}
+	return v.quotedRx.ReplaceAllLiteralString(str, v.raw)
+}
@@ -8,6 +8,13 @@ import (
 
 type Variables struct {
 	variables variables
+	provider  Provider
+	// secrets are the resolved values of the references {{ $secret:path }} by their paths
+	secrets    map[string]string
+	secretsErr error
+	// unique generates {{ $uniqueInt }}, uniqueNames are the values of {{ $uniqueInt:name }} of the current test
+	unique      *UniqueValues
+	uniqueNames map[string]string
 }
 
 type variables map[string]*Variable
@@ -16,7 +23,11 @@ var variableRx = regexp.MustCompile(`{{\s*\$(\w+)\s*}}`)
 
 func New() *Variables {
 	return &Variables{
-		variables: make(variables),
+		variables:   make(variables),
+		provider:    EnvProvider{},
+		secrets:     make(map[string]string),
+		unique:      defaultUniqueValues,
+		uniqueNames: make(map[string]string),
 	}
 }
 
@@ -43,17 +54,20 @@ func (vs *Variables) Apply(t models.TestInterface) models.TestInterface {
 	if vs == nil {
 		return newTest
 	}
+	vs.secretsErr = nil
 
 	newTest.SetQuery(vs.perform(newTest.ToQuery()))
 	newTest.SetMethod(vs.perform(newTest.GetMethod()))
 	newTest.SetPath(vs.perform(newTest.Path()))
 	newTest.SetRequest(vs.perform(newTest.GetRequest()))
 	newTest.SetDbQueryString(vs.perform(newTest.DbQueryString()))
+	newTest.SetDbQueryParams(vs.performParams(newTest.DbQueryParams()))
 	newTest.SetDbResponseJson(vs.performDbResponses(newTest.DbResponseJson()))
 
 	dbChecks := []models.DatabaseCheck{}
 	for _, def := range newTest.GetDatabaseChecks() {
 		def.SetDbQueryString(vs.perform(def.DbQueryString()))
+		def.SetDbQueryParams(vs.performParams(def.DbQueryParams()))
 		def.SetDbResponseJson(vs.performDbResponses(def.DbResponseJson()))
 		dbChecks = append(dbChecks, def)
 	}
@@ -61,13 +75,53 @@ func (vs *Variables) Apply(t models.TestInterface) models.TestInterface {
 
 	newTest.SetResponses(vs.performResponses(newTest.GetResponses()))
 	newTest.SetHeaders(vs.performHeaders(newTest.Headers()))
+	if env := newTest.GetEnv(); env != nil {
+		newTest.SetEnv(vs.performHeaders(env))
+	}
 
 	if form := newTest.GetForm(); form != nil {
 		newTest.SetForm(vs.performForm(form))
 	}
 
-	for _, definition := range newTest.ServiceMocks() {
-		vs.performInterface(definition)
+	if mocksDefinition := newTest.ServiceMocks(); mocksDefinition != nil {
+		newTest.SetServiceMocks(vs.performMocks(mocksDefinition))
+	}
+
+	if stream := newTest.GetStreamResponse(); stream != nil {
+		newTest.SetStreamResponse(vs.performStream(stream))
+	}
+	if redirects := newTest.GetRedirects(); redirects != nil {
+		performed := make([]models.RedirectHop, len(redirects))
+		for i, hop := range redirects {
+			performed[i] = models.RedirectHop{Status: hop.Status, Location: vs.perform(hop.Location)}
+		}
+		newTest.SetRedirects(performed)
+	}
+	newTest.SetProxy(vs.perform(newTest.GetProxy()))
+	if locations := newTest.GetResponseLocations(); locations != nil {
+		performed := make(models.ResponseLocations, len(locations))
+		for code, location := range locations {
+			performed[code] = models.ResponseLocation{
+				Path:     vs.perform(location.Path),
+				Query:    vs.performHeaders(location.Query),
+				Variable: location.Variable,
+			}
+		}
+		newTest.SetResponseLocations(performed)
+	}
+	if xpaths := newTest.GetResponseXPaths(); xpaths != nil {
+		newTest.SetResponseXPaths(vs.performAssertions(xpaths))
+	}
+	if oneOf := newTest.GetResponsesOneOf(); oneOf != nil {
+		newTest.SetResponsesOneOf(vs.performBodies(oneOf))
+	}
+	if jsonPaths := newTest.GetResponseJSONPaths(); jsonPaths != nil {
+		newTest.SetResponseJSONPaths(vs.performAssertions(jsonPaths))
+	}
+	if protobuf := newTest.GetProtobufResponse(); protobuf != nil {
+		performed := *protobuf
+		performed.Body = vs.performResponses(protobuf.Body)
+		newTest.SetProtobufResponse(&performed)
 	}
 
 	return newTest
@@ -80,6 +134,27 @@ func (vs *Variables) Merge(vars *Variables) {
 	}
 }
 
+// Scope saves the variables, the returned function restores them, e.g. to keep the variables
+// set by the steps of a scenario within it
+func (vs *Variables) Scope() (restore func()) {
+	saved := make(variables, len(vs.variables))
+	for k, v := range vs.variables {
+		saved[k] = v
+	}
+	return func() {
+		vs.variables = saved
+	}
+}
+
+// Value returns value of the variable (checking environment variables as well)
+func (vs *Variables) Value(name string) (string, bool) {
+	v := vs.get(name)
+	if v == nil {
+		return "", false
+	}
+	return v.value, true
+}
+
 func (vs *Variables) Len() int {
 	return len(vs.variables)
 }
@@ -105,27 +180,89 @@ func (vs *Variables) perform(str string) string {
 		}
 	}
 
-	return str
+	// the secrets are resolved after the variables, so the values of the variables can refer to them
+	return vs.performSecrets(vs.performUnique(str))
 }
 
-func (vs *Variables) performInterface(value interface{}) {
-	if mapValue, ok := value.(map[interface{}]interface{}); ok {
-		for key := range mapValue {
-			if strValue, ok := mapValue[key].(string); ok {
-				mapValue[key] = vs.perform(strValue)
-			} else {
-				vs.performInterface(mapValue[key])
+// performAssertions returns a copy of the XPath or JSONPath assertions with all variables replaced
+func (vs *Variables) performAssertions(assertionsByStatus map[int][]string) map[int][]string {
+	res := make(map[int][]string, len(assertionsByStatus))
+	for status, assertions := range assertionsByStatus {
+		performed := make([]string, len(assertions))
+		for i, assertion := range assertions {
+			performed[i] = vs.perform(assertion)
+		}
+		res[status] = performed
+	}
+	return res
+}
+
+// performStream returns a copy of the stream expectations with all variables replaced
+func (vs *Variables) performStream(stream *models.StreamResponse) *models.StreamResponse {
+	res := &models.StreamResponse{
+		Type:    stream.Type,
+		Timeout: stream.Timeout,
+		Lines:   make(map[int][]string, len(stream.Lines)),
+	}
+
+	for status, lines := range stream.Lines {
+		performed := make([]string, len(lines))
+		for i, line := range lines {
+			performed[i] = vs.perform(line)
+		}
+		res.Lines[status] = performed
+	}
+	if stream.Events != nil {
+		res.Events = make(map[int][]models.SSEEvent, len(stream.Events))
+		for status, events := range stream.Events {
+			performed := make([]models.SSEEvent, len(events))
+			for i, event := range events {
+				performed[i] = models.SSEEvent{
+					Event: vs.perform(event.Event),
+					Data:  vs.perform(event.Data),
+					ID:    vs.perform(event.ID),
+				}
 			}
+			res.Events[status] = performed
 		}
 	}
-	if arrValue, ok := value.([]interface{}); ok {
-		for idx := range arrValue {
-			if strValue, ok := arrValue[idx].(string); ok {
-				arrValue[idx] = vs.perform(strValue)
-			} else {
-				vs.performInterface(arrValue[idx])
+	return res
+}
+
+// performMocks returns a copy of mocks definitions with all variables replaced,
+// the original definitions are left untouched so they can be reused by the next cases
+func (vs *Variables) performMocks(mocksDefinition map[string]interface{}) map[string]interface{} {
+	res := make(map[string]interface{}, len(mocksDefinition))
+
+	for serviceName, definition := range mocksDefinition {
+		res[serviceName] = vs.performInterface(definition)
+	}
+	return res
+}
+
+// performInterface replaces variables in all string keys and values of the given value
+// and returns the result as a new value
+func (vs *Variables) performInterface(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		return vs.perform(v)
+	case map[interface{}]interface{}:
+		res := make(map[interface{}]interface{}, len(v))
+		for key, item := range v {
+			if strKey, ok := key.(string); ok {
+				key = vs.perform(strKey)
 			}
+			res[key] = vs.performInterface(item)
+		}
+		return res
+	case []interface{}:
+		res := make([]interface{}, len(v))
+		for idx, item := range v {
+			res[idx] = vs.performInterface(item)
 		}
+		return res
+	default:
+		return value
 	}
 }
 
@@ -146,7 +283,18 @@ func (vs *Variables) performForm(form *models.Form) *models.Form {
 	for k, v := range form.Files {
 		files[k] = vs.perform(v)
 	}
-	return &models.Form{Files: files}
+
+	var parts []models.FormPart
+	if form.Parts != nil {
+		parts = make([]models.FormPart, len(form.Parts))
+		for i, part := range form.Parts {
+			// the content of the files is sent as is
+			part.Body = vs.perform(part.Body)
+			part.File = vs.perform(part.File)
+			parts[i] = part
+		}
+	}
+	return &models.Form{Files: files, Parts: parts}
 }
 
 func (vs *Variables) performHeaders(headers map[string]string) map[string]string {
@@ -164,7 +312,46 @@ func (vs *Variables) performResponses(responses map[int]string) map[int]string {
 	res := make(map[int]string)
 
 	for k, v := range responses {
-		res[k] = vs.perform(v)
+		res[k] = vs.performBody(v)
+	}
+	return res
+}
+
+// performBodies returns a copy of the acceptable bodies by status with all variables replaced
+func (vs *Variables) performBodies(bodiesByStatus map[int][]string) map[int][]string {
+	res := make(map[int][]string, len(bodiesByStatus))
+	for status, bodies := range bodiesByStatus {
+		performed := make([]string, len(bodies))
+		for i, body := range bodies {
+			performed[i] = vs.performBody(body)
+		}
+		res[status] = performed
+	}
+	return res
+}
+
+// performBody replaces all variables in the expected body, the quoted references to the variables
+// captured from JSON responses are replaced with their JSON so numbers, booleans and objects keep their types
+func (vs *Variables) performBody(str string) string {
+	for _, k := range usedVariables(str) {
+		if v := vs.get(k); v != nil {
+			str = v.performJSON(str)
+		}
+	}
+	return vs.perform(str)
+}
+
+// performParams returns a copy of the params of the DB query with the variables replaced in the strings
+func (vs *Variables) performParams(params []interface{}) []interface{} {
+	if params == nil {
+		return nil
+	}
+	res := make([]interface{}, len(params))
+	for i, param := range params {
+		if s, ok := param.(string); ok {
+			param = vs.perform(s)
+		}
+		res[i] = param
 	}
 	return res
 }